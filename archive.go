@@ -0,0 +1,220 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrArchiveOverflow is reported when an ArchiveBestEffort archive is given up
+// because the sink could not keep up with the client.
+var ErrArchiveOverflow = errors.New("archive: sink too slow, archive given up")
+
+const defaultArchiveQueue = 64
+
+// ArchivePolicy tells how the Archive middleware reacts to a failing or slow sink.
+type ArchivePolicy uint8
+
+const (
+	// ArchiveBestEffort always serves the client: the archive is given up, and
+	// reported to OnError, if the sink fails or falls behind by more than the queue.
+	ArchiveBestEffort ArchivePolicy = iota
+	// ArchiveRequired serves only what can be archived: requests are answered
+	// with status 503 if the sink cannot be opened, client writes wait while the
+	// queue is full, and fail once the sink failed, cutting the response short.
+	ArchiveRequired
+)
+
+// ArchiveConfig defines the config of the Archive middleware.
+type ArchiveConfig struct {
+	// Sink returns the writer archiving the response body of a request, such as
+	// a file or the pipe of an object storage upload. It is closed after the
+	// request. Required.
+	Sink func(c *Context) (io.WriteCloser, error)
+
+	// Policy tells how to react to a failing or slow sink.
+	// Optional. Default value is ArchiveBestEffort.
+	Policy ArchivePolicy
+
+	// QueueSize is the number of writes queued for the sink while it lags
+	// behind the client. Optional. Default value is 64.
+	QueueSize int
+
+	// OnError is called after a request whose archive failed. Optional. Default
+	// value logs the error in debug mode.
+	OnError func(c *Context, err error)
+
+	// Skip tells which requests are not archived. Optional.
+	Skip Skipper
+}
+
+// Archive returns a middleware streaming the response body to the client while
+// writing it to an archive sink, for endpoints which must retain the exact
+// content they served. The sink is written asynchronously so a slow sink only
+// delays the client once the queue is full, depending on the policy.
+func Archive(conf ArchiveConfig) HandlerFunc {
+	assert1(conf.Sink != nil, "ArchiveConfig.Sink is required")
+	if conf.QueueSize <= 0 {
+		conf.QueueSize = defaultArchiveQueue
+	}
+	if conf.OnError == nil {
+		conf.OnError = func(c *Context, err error) {
+			debugPrint("cannot archive response of %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		}
+	}
+
+	return func(c *Context) {
+		if conf.Skip != nil && conf.Skip(c) {
+			c.Next()
+			return
+		}
+		sink, err := conf.Sink(c)
+		if err != nil {
+			conf.OnError(c, err)
+			if conf.Policy == ArchiveRequired {
+				c.AbortWithStatus(http.StatusServiceUnavailable)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		w := &archiveWriter{
+			ResponseWriter: c.Writer,
+			policy:         conf.Policy,
+			queue:          make(chan []byte, conf.QueueSize),
+			failed:         make(chan struct{}),
+			done:           make(chan struct{}),
+		}
+		go w.drain(sink)
+		c.Writer = w
+		defer func() { c.Writer = w.ResponseWriter }()
+
+		c.Next()
+
+		close(w.queue)
+		<-w.done
+		if err := sink.Close(); err != nil {
+			w.fail(err)
+		}
+		if err := w.Err(); err != nil {
+			conf.OnError(c, err)
+		}
+	}
+}
+
+type archiveWriter struct {
+	ResponseWriter
+	policy  ArchivePolicy
+	queue   chan []byte
+	done    chan struct{}
+	stopped bool
+
+	failOnce sync.Once
+	failed   chan struct{}
+	err      error
+}
+
+func (w *archiveWriter) Write(data []byte) (int, error) {
+	if err := w.archive(data); err != nil {
+		return 0, err
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *archiveWriter) WriteString(s string) (int, error) {
+	if err := w.archive([]byte(s)); err != nil {
+		return 0, err
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// archive queues a copy of data for the sink, as the caller may reuse it.
+func (w *archiveWriter) archive(data []byte) error {
+	if w.stopped || len(data) == 0 {
+		return nil
+	}
+	chunk := append([]byte(nil), data...)
+	if w.policy == ArchiveRequired {
+		select {
+		case <-w.failed:
+			return w.err
+		default:
+		}
+		select {
+		case w.queue <- chunk:
+			return nil
+		case <-w.failed:
+			return w.err
+		}
+	}
+
+	select {
+	case <-w.failed:
+		w.stopped = true
+		return nil
+	default:
+	}
+	select {
+	case w.queue <- chunk:
+	default:
+		w.stopped = true
+		w.fail(ErrArchiveOverflow)
+	}
+	return nil
+}
+
+// drain writes the queued chunks to the sink until the queue is closed.
+func (w *archiveWriter) drain(sink io.Writer) {
+	defer close(w.done)
+	for chunk := range w.queue {
+		select {
+		case <-w.failed:
+			continue
+		default:
+		}
+		if _, err := sink.Write(chunk); err != nil {
+			w.fail(err)
+		}
+	}
+}
+
+func (w *archiveWriter) fail(err error) {
+	w.failOnce.Do(func() {
+		w.err = err
+		close(w.failed)
+	})
+}
+
+// Err returns the error which made archiving fail, if any.
+func (w *archiveWriter) Err() error {
+	select {
+	case <-w.failed:
+		return w.err
+	default:
+		return nil
+	}
+}
+
+// ArchiveFileSink returns an ArchiveConfig.Sink writing each response body into
+// its own file in dir, which is created if needed.
+func ArchiveFileSink(dir string) func(c *Context) (io.WriteCloser, error) {
+	var seq uint64
+	return func(c *Context) (io.WriteCloser, error) {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return nil, err
+		}
+		name := fmt.Sprintf("%s-%06d.body", time.Now().Format("20060102T150405"), atomic.AddUint64(&seq, 1))
+		return os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testArchiveSink is an archive sink failing after limit bytes, or waiting for
+// release before each write.
+type testArchiveSink struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	limit   int
+	release chan struct{}
+	closed  bool
+}
+
+func (s *testArchiveSink) Write(p []byte) (int, error) {
+	if s.release != nil {
+		<-s.release
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.limit > 0 && s.buf.Len()+len(p) > s.limit {
+		return 0, errors.New("sink full")
+	}
+	return s.buf.Write(p)
+}
+
+func (s *testArchiveSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func archiveRouter(conf ArchiveConfig, writes int) (*Engine, *[]error) {
+	var errs []error
+	conf.OnError = func(_ *Context, err error) { errs = append(errs, err) }
+	router := New()
+	router.Use(Archive(conf))
+	router.GET("/", func(c *Context) {
+		c.Status(http.StatusOK)
+		for i := 0; i < writes; i++ {
+			if _, err := c.Writer.WriteString("chunk;"); err != nil {
+				return
+			}
+		}
+	})
+	return router, &errs
+}
+
+func TestArchive(t *testing.T) {
+	sink := &testArchiveSink{}
+	router, errs := archiveRouter(ArchiveConfig{Sink: func(*Context) (io.WriteCloser, error) { return sink, nil }}, 3)
+
+	w := PerformRequest(router, http.MethodGet, "/")
+	assert.Equal(t, "chunk;chunk;chunk;", w.Body.String())
+	assert.Equal(t, w.Body.String(), sink.buf.String())
+	assert.True(t, sink.closed)
+	assert.Empty(t, *errs)
+}
+
+func TestArchiveBestEffort(t *testing.T) {
+	sink := &testArchiveSink{limit: 8}
+	router, errs := archiveRouter(ArchiveConfig{Sink: func(*Context) (io.WriteCloser, error) { return sink, nil }}, 3)
+	w := PerformRequest(router, http.MethodGet, "/")
+	assert.Equal(t, "chunk;chunk;chunk;", w.Body.String())
+	require.Len(t, *errs, 1)
+	assert.EqualError(t, (*errs)[0], "sink full")
+
+	// a sink lagging behind by more than the queue is given up
+	slow := &testArchiveSink{release: make(chan struct{})}
+	var overflow []error
+	router = New()
+	router.Use(Archive(ArchiveConfig{
+		Sink:      func(*Context) (io.WriteCloser, error) { return slow, nil },
+		QueueSize: 1,
+		OnError:   func(_ *Context, err error) { overflow = append(overflow, err) },
+	}))
+	router.GET("/", func(c *Context) {
+		for i := 0; i < 5; i++ {
+			c.Writer.WriteString("chunk;") //nolint: errcheck
+		}
+		close(slow.release)
+	})
+	w = PerformRequest(router, http.MethodGet, "/")
+	assert.Equal(t, "chunk;chunk;chunk;chunk;chunk;", w.Body.String())
+	require.Len(t, overflow, 1)
+	assert.ErrorIs(t, overflow[0], ErrArchiveOverflow)
+
+	router, errs = archiveRouter(ArchiveConfig{Sink: func(*Context) (io.WriteCloser, error) { return nil, errors.New("no sink") }}, 1)
+	w = PerformRequest(router, http.MethodGet, "/")
+	assert.Equal(t, "chunk;", w.Body.String())
+	assert.Len(t, *errs, 1)
+}
+
+func TestArchiveRequired(t *testing.T) {
+	sink := &testArchiveSink{limit: 8}
+	router, errs := archiveRouter(ArchiveConfig{
+		Sink:   func(*Context) (io.WriteCloser, error) { return sink, nil },
+		Policy: ArchiveRequired,
+	}, 100)
+	w := PerformRequest(router, http.MethodGet, "/")
+	assert.Less(t, w.Body.Len(), 600)
+	assert.Equal(t, "chunk;", sink.buf.String())
+	require.Len(t, *errs, 1)
+	assert.EqualError(t, (*errs)[0], "sink full")
+
+	router, _ = archiveRouter(ArchiveConfig{
+		Sink:   func(*Context) (io.WriteCloser, error) { return nil, errors.New("no sink") },
+		Policy: ArchiveRequired,
+	}, 1)
+	w = PerformRequest(router, http.MethodGet, "/")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Empty(t, w.Body.String())
+}
+
+func TestArchiveFileSink(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "archive")
+	router, errs := archiveRouter(ArchiveConfig{
+		Sink: ArchiveFileSink(dir),
+		Skip: func(c *Context) bool { return c.Query("skip") != "" },
+	}, 2)
+
+	PerformRequest(router, http.MethodGet, "/")
+	PerformRequest(router, http.MethodGet, "/?skip=1")
+	assert.Empty(t, *errs)
+	files, err := filepath.Glob(filepath.Join(dir, "*.body"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Equal(t, "chunk;chunk;", string(data))
+
+	assert.Panics(t, func() { Archive(ArchiveConfig{}) })
+}
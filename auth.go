@@ -45,6 +45,7 @@ func (a authPairs) searchCredential(authValue string) (string, bool) {
 // the key is the user name and the value is the password, as well as the name of the Realm.
 // If the realm is empty, "Authorization Required" will be used by default.
 // (see http://tools.ietf.org/html/rfc2617#section-1.2)
+// Routes whose RouteConfig.RequireAuth is ConfigOff are let through.
 func BasicAuthForRealm(accounts Accounts, realm string) HandlerFunc {
 	if realm == "" {
 		realm = "Authorization Required"
@@ -52,6 +53,9 @@ func BasicAuthForRealm(accounts Accounts, realm string) HandlerFunc {
 	realm = "Basic realm=" + strconv.Quote(realm)
 	pairs := processAccounts(accounts)
 	return func(c *Context) {
+		if c.engine != nil && c.engine.routeConfigured.Load() && c.RouteConfig().RequireAuth == ConfigOff {
+			return
+		}
 		// Search user in the slice of allowed credentials
 		user, found := pairs.searchCredential(c.requestHeader("Authorization"))
 		if !found {
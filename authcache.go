@@ -0,0 +1,293 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultAuthCacheEntries = 10000
+
+// ErrNoCredentials is the error of the requests without credentials, returned
+// by AuthCache.Authenticate without calling the AuthFunc.
+var ErrNoCredentials = errors.New("auth: no credentials")
+
+// AuthFunc authenticates the credentials of a request, such as a bearer token
+// verified against a JWKS or an identity provider, and returns the principal
+// of the request, or an error when it is not authenticated.
+type AuthFunc func(c *Context, token string) (any, error)
+
+// AuthCacheConfig defines the caching of the decisions of an AuthFunc.
+type AuthCacheConfig struct {
+	// Token returns the credentials of the request, empty when there are none.
+	// Optional. Default value is the bearer token of the Authorization header.
+	Token func(c *Context) string
+
+	// TTL is how long a principal is cached across requests. Optional. Default
+	// value is 0: the decisions are only memoized within a request.
+	TTL time.Duration
+
+	// ErrorTTL is how long an error is cached across requests, to spare the
+	// identity provider the retries of invalid tokens. Optional. Default value
+	// is 0: the errors are not cached.
+	ErrorTTL time.Duration
+
+	// Expiry returns how long a decision may be cached, such as until the
+	// expiry of the token, 0 not to cache it. It overrides TTL and ErrorTTL.
+	// Optional.
+	Expiry func(principal any, err error) time.Duration
+
+	// MaxEntries is the maximum number of cached decisions. Optional. Default
+	// value is 10000.
+	MaxEntries int
+
+	// Key is the context key the principal is set to by Handler. Optional.
+	// Default value is AuthUserKey.
+	Key string
+}
+
+// AuthCache caches the decisions of an expensive AuthFunc, keyed by the
+// SHA-256 hash of the token so that the tokens are not kept in memory. The
+// decision is memoized for the request, so that the middlewares and handlers
+// authenticating it share it, and cached across the requests for the
+// configured TTL. The concurrent requests with the same token wait for a
+// single call to the AuthFunc, whose request context is not canceled with the
+// request making it:
+//
+//	auth := gin.NewAuthCache(verifyJWT, gin.AuthCacheConfig{TTL: time.Minute})
+//	router.Use(auth.Handler())
+//	admin.POST("/users/:id/revoke", func(c *gin.Context) {
+//	    id := c.Param("id")
+//	    auth.InvalidateIf(func(p any) bool { return p.(*User).ID == id })
+//	})
+type AuthCache struct {
+	fn   AuthFunc
+	conf AuthCacheConfig
+
+	mu       sync.Mutex
+	entries  map[[sha256.Size]byte]authEntry
+	inflight map[[sha256.Size]byte]*authCall
+	// gen is incremented by the invalidations, so that the decisions of the
+	// calls started before them are not cached.
+	gen uint64
+}
+
+// authEntry is a decision cached across requests.
+type authEntry struct {
+	principal any
+	err       error
+	expires   time.Time
+}
+
+// authCall is a running call to the AuthFunc, waited for by the concurrent
+// requests with the same token.
+type authCall struct {
+	done      chan struct{}
+	gen       uint64
+	principal any
+	err       error
+}
+
+// authDecision is a decision memoized for a request.
+type authDecision struct {
+	cache     *AuthCache
+	principal any
+	err       error
+}
+
+// NewAuthCache returns a cache of the decisions of fn.
+func NewAuthCache(fn AuthFunc, conf AuthCacheConfig) *AuthCache {
+	if conf.Token == nil {
+		conf.Token = bearerToken
+	}
+	if conf.MaxEntries <= 0 {
+		conf.MaxEntries = defaultAuthCacheEntries
+	}
+	if conf.Key == "" {
+		conf.Key = AuthUserKey
+	}
+	return &AuthCache{
+		fn:       fn,
+		conf:     conf,
+		entries:  make(map[[sha256.Size]byte]authEntry),
+		inflight: make(map[[sha256.Size]byte]*authCall),
+	}
+}
+
+// bearerToken returns the bearer token of the Authorization header.
+func bearerToken(c *Context) string {
+	scheme, token, ok := strings.Cut(c.requestHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// Authenticate returns the principal of the request, or the error of the
+// AuthFunc, ErrNoCredentials when the request has no token. The decision is
+// taken once per request.
+func (a *AuthCache) Authenticate(c *Context) (any, error) {
+	for _, d := range c.authDecisions {
+		if d.cache == a {
+			return d.principal, d.err
+		}
+	}
+	var principal any
+	err := ErrNoCredentials
+	if token := a.conf.Token(c); token != "" {
+		principal, err = a.lookup(c, token)
+	}
+	c.authDecisions = append(c.authDecisions, authDecision{cache: a, principal: principal, err: err})
+	return principal, err
+}
+
+// lookup returns the cached decision of token, calling the AuthFunc on a miss.
+func (a *AuthCache) lookup(c *Context, token string) (any, error) {
+	key := sha256.Sum256([]byte(token))
+	a.mu.Lock()
+	if e, ok := a.entries[key]; ok {
+		if c.now().Before(e.expires) {
+			a.mu.Unlock()
+			return e.principal, e.err
+		}
+		delete(a.entries, key)
+	}
+	if call, ok := a.inflight[key]; ok {
+		a.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.principal, call.err
+		case <-c.Request.Context().Done():
+			return nil, c.Request.Context().Err()
+		}
+	}
+	call := &authCall{done: make(chan struct{}), gen: a.gen}
+	a.inflight[key] = call
+	a.mu.Unlock()
+
+	// the waiters must not get the cancellation of this request
+	req := c.Request
+	c.Request = req.WithContext(context.WithoutCancel(req.Context()))
+	defer func() {
+		c.Request = req
+		a.mu.Lock()
+		delete(a.inflight, key)
+		if ttl := a.ttl(call.principal, call.err); ttl > 0 && call.gen == a.gen {
+			now := c.now()
+			a.store(key, authEntry{principal: call.principal, err: call.err, expires: now.Add(ttl)}, now)
+		}
+		a.mu.Unlock()
+		close(call.done)
+	}()
+	call.err = errAuthPanic
+	call.principal, call.err = a.fn(c, token)
+	return call.principal, call.err
+}
+
+// errAuthPanic is the decision given to the requests waiting for a call to
+// the AuthFunc that panicked. It is never cached.
+var errAuthPanic = errors.New("auth: authentication panicked")
+
+// ttl returns how long a decision is cached. The panics and the context errors
+// do not tell whether the token is valid, and are never cached.
+func (a *AuthCache) ttl(principal any, err error) time.Duration {
+	if err == errAuthPanic || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return 0
+	}
+	if a.conf.Expiry != nil {
+		return a.conf.Expiry(principal, err)
+	}
+	if err != nil {
+		return a.conf.ErrorTTL
+	}
+	return a.conf.TTL
+}
+
+// store caches the decision, evicting the expired ones at now, then any, when
+// the cache is full. a.mu must be held.
+func (a *AuthCache) store(key [sha256.Size]byte, e authEntry, now time.Time) {
+	if len(a.entries) >= a.conf.MaxEntries {
+		for k, old := range a.entries {
+			if !now.Before(old.expires) {
+				delete(a.entries, k)
+			}
+		}
+		for k := range a.entries {
+			if len(a.entries) < a.conf.MaxEntries {
+				break
+			}
+			delete(a.entries, k)
+		}
+	}
+	a.entries[key] = e
+}
+
+// Invalidate removes the cached decision of token, such as a revoked token.
+func (a *AuthCache) Invalidate(token string) {
+	key := sha256.Sum256([]byte(token))
+	a.mu.Lock()
+	delete(a.entries, key)
+	a.gen++
+	a.mu.Unlock()
+}
+
+// InvalidateIf removes the cached principals for which match returns true,
+// such as the ones of a user whose permissions changed, and returns their
+// number.
+func (a *AuthCache) InvalidateIf(match func(principal any) bool) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.gen++
+	n := 0
+	for k, e := range a.entries {
+		if e.err == nil && match(e.principal) {
+			delete(a.entries, k)
+			n++
+		}
+	}
+	return n
+}
+
+// Purge removes all the cached decisions, such as after a rotation of the
+// signing keys.
+func (a *AuthCache) Purge() {
+	a.mu.Lock()
+	clear(a.entries)
+	a.gen++
+	a.mu.Unlock()
+}
+
+// Len returns the number of cached decisions, expired ones included.
+func (a *AuthCache) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.entries)
+}
+
+// Handler returns a middleware authenticating the requests with the cache. It
+// sets the principal to the configured context key, and aborts the requests
+// that are not authenticated with 401 Unauthorized. Routes whose
+// RouteConfig.RequireAuth is ConfigOff are let through.
+func (a *AuthCache) Handler() HandlerFunc {
+	return func(c *Context) {
+		if c.engine != nil && c.engine.routeConfigured.Load() && c.RouteConfig().RequireAuth == ConfigOff {
+			return
+		}
+		principal, err := a.Authenticate(c)
+		if err != nil {
+			c.Header("WWW-Authenticate", "Bearer")
+			_ = c.Error(err)
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Set(a.conf.Key, principal)
+	}
+}
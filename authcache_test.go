@@ -0,0 +1,231 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errInvalidToken = errors.New("invalid token")
+
+// countingAuth returns an AuthFunc accepting the token "good" as "alice", and
+// counting its calls.
+func countingAuth(calls *atomic.Int32) AuthFunc {
+	return func(_ *Context, token string) (any, error) {
+		calls.Add(1)
+		if token != "good" {
+			return nil, errInvalidToken
+		}
+		return "alice", nil
+	}
+}
+
+func TestAuthCacheMemoizesPerRequest(t *testing.T) {
+	var calls atomic.Int32
+	auth := NewAuthCache(countingAuth(&calls), AuthCacheConfig{})
+	router := New()
+	router.Use(auth.Handler(), auth.Handler())
+	router.GET("/", func(c *Context) {
+		principal, err := auth.Authenticate(c)
+		assert.NoError(t, err)
+		c.String(http.StatusOK, "%v %v", principal, c.MustGet(AuthUserKey))
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/", header{"Authorization", "Bearer good"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "alice alice", w.Body.String())
+	assert.Equal(t, int32(1), calls.Load())
+
+	// without TTL, nothing is cached across requests
+	PerformRequest(router, http.MethodGet, "/", header{"Authorization", "Bearer good"})
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Zero(t, auth.Len())
+
+	w = PerformRequest(router, http.MethodGet, "/")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestAuthCacheTTL(t *testing.T) {
+	var calls atomic.Int32
+	auth := NewAuthCache(countingAuth(&calls), AuthCacheConfig{TTL: time.Hour, ErrorTTL: 20 * time.Millisecond})
+	router := New()
+	router.Use(auth.Handler())
+	router.GET("/", func(c *Context) { c.String(http.StatusOK, "%v", c.MustGet(AuthUserKey)) })
+
+	for i := 0; i < 3; i++ {
+		w := PerformRequest(router, http.MethodGet, "/", header{"Authorization", "Bearer good"})
+		assert.Equal(t, "alice", w.Body.String())
+		w = PerformRequest(router, http.MethodGet, "/", header{"Authorization", "Bearer bad"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, 2, auth.Len())
+
+	// the errors expire first
+	time.Sleep(30 * time.Millisecond)
+	PerformRequest(router, http.MethodGet, "/", header{"Authorization", "Bearer good"})
+	PerformRequest(router, http.MethodGet, "/", header{"Authorization", "Bearer bad"})
+	assert.Equal(t, int32(3), calls.Load())
+
+	auth.Invalidate("good")
+	PerformRequest(router, http.MethodGet, "/", header{"Authorization", "Bearer good"})
+	assert.Equal(t, int32(4), calls.Load())
+
+	assert.Equal(t, 1, auth.InvalidateIf(func(p any) bool { return p == "alice" }))
+	auth.Purge()
+	assert.Zero(t, auth.Len())
+}
+
+func TestAuthCacheExpiryAndMaxEntries(t *testing.T) {
+	var calls atomic.Int32
+	auth := NewAuthCache(func(_ *Context, token string) (any, error) {
+		calls.Add(1)
+		return token, nil
+	}, AuthCacheConfig{
+		MaxEntries: 2,
+		Expiry: func(principal any, _ error) time.Duration {
+			if principal == "session" {
+				return 0
+			}
+			return time.Hour
+		},
+		Token: func(c *Context) string { return c.Query("token") },
+		Key:   "principal",
+	})
+	router := New()
+	router.Use(auth.Handler())
+	router.GET("/", func(c *Context) { c.String(http.StatusOK, "%v", c.MustGet("principal")) })
+
+	PerformRequest(router, http.MethodGet, "/?token=session")
+	PerformRequest(router, http.MethodGet, "/?token=session")
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Zero(t, auth.Len())
+
+	for _, token := range []string{"a", "b", "c"} {
+		w := PerformRequest(router, http.MethodGet, "/?token="+token)
+		assert.Equal(t, token, w.Body.String())
+	}
+	assert.Equal(t, 2, auth.Len())
+}
+
+func TestAuthCacheConcurrentLookups(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	auth := NewAuthCache(func(_ *Context, _ string) (any, error) {
+		calls.Add(1)
+		<-release
+		return "alice", nil
+	}, AuthCacheConfig{TTL: time.Hour})
+	router := New()
+	router.Use(auth.Handler())
+	router.GET("/", func(c *Context) { c.String(http.StatusOK, "%v", c.MustGet(AuthUserKey)) })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := PerformRequest(router, http.MethodGet, "/", header{"Authorization", "Bearer good"})
+			assert.Equal(t, "alice", w.Body.String())
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestAuthCachePanic(t *testing.T) {
+	auth := NewAuthCache(func(_ *Context, _ string) (any, error) {
+		panic("identity provider down")
+	}, AuthCacheConfig{TTL: time.Hour})
+	router := New()
+	router.Use(RecoveryWithWriter(io.Discard), auth.Handler())
+	router.GET("/", handlerTest1)
+
+	w := PerformRequest(router, http.MethodGet, "/", header{"Authorization", "Bearer good"})
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Zero(t, auth.Len())
+}
+
+func TestAuthCacheSharedCall(t *testing.T) {
+	started := make(chan struct{}, 1)
+	var release chan struct{}
+	var fail atomic.Bool
+	auth := NewAuthCache(func(c *Context, _ string) (any, error) {
+		started <- struct{}{}
+		<-release
+		if fail.Load() {
+			return nil, context.DeadlineExceeded
+		}
+		return "alice", c.Request.Context().Err()
+	}, AuthCacheConfig{TTL: time.Hour, ErrorTTL: time.Hour})
+	router := New()
+	router.Use(auth.Handler())
+	router.GET("/", func(c *Context) { c.String(http.StatusOK, "%v", c.MustGet(AuthUserKey)) })
+
+	serve := func(ctx context.Context) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+		req.Header.Set("Authorization", "Bearer good")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	// serves a request, and another one sharing its call to the AuthFunc,
+	// running during while the call waits
+	shared := func(ctx context.Context, during func()) (first, other *httptest.ResponseRecorder) {
+		release = make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			first = serve(ctx)
+		}()
+		<-started
+		go func() {
+			defer wg.Done()
+			other = serve(context.Background())
+		}()
+		time.Sleep(20 * time.Millisecond)
+		during()
+		close(release)
+		wg.Wait()
+		return first, other
+	}
+
+	// the cancellation of the first request is not shared
+	ctx, cancel := context.WithCancel(context.Background())
+	first, other := shared(ctx, cancel)
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "alice", other.Body.String())
+	assert.Equal(t, 1, auth.Len())
+
+	// an invalidation during the call wins
+	auth.Purge()
+	first, other = shared(context.Background(), func() { auth.Invalidate("good") })
+	assert.Equal(t, "alice", first.Body.String())
+	assert.Equal(t, "alice", other.Body.String())
+	assert.Zero(t, auth.Len())
+
+	// the context errors are not cached
+	fail.Store(true)
+	release = make(chan struct{})
+	close(release)
+	w := serve(context.Background())
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Zero(t, auth.Len())
+}
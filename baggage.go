@@ -0,0 +1,273 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// BaggageKey is the key under which the baggage of the request is stored in the context.
+const BaggageKey = "_gin-gonic/gin/baggagekey"
+
+// BaggageHeader is the header carrying W3C baggage, see https://www.w3.org/TR/baggage.
+const BaggageHeader = "baggage"
+
+// Limits of a baggage header set by the W3C specification.
+const (
+	maxBaggageMembers = 180
+	maxBaggageBytes   = 8192
+)
+
+// BaggageMember is a key/value pair of baggage, with its optional properties
+// kept as is, e.g. "ttl=30".
+type BaggageMember struct {
+	Key        string
+	Value      string
+	Properties string
+}
+
+// Baggage is the W3C baggage of a request: application-defined key/value pairs,
+// such as tenant or user identifiers, propagated along the requests it causes.
+type Baggage struct {
+	members []BaggageMember
+}
+
+// ParseBaggage parses the value of a baggage header. Invalid members are
+// skipped, and members past the W3C limits are dropped.
+func ParseBaggage(header string) *Baggage {
+	b := &Baggage{}
+	if len(header) > maxBaggageBytes {
+		header = header[:maxBaggageBytes]
+	}
+	for _, item := range strings.Split(header, ",") {
+		if len(b.members) == maxBaggageMembers {
+			break
+		}
+		pair, props, _ := strings.Cut(item, ";")
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !isBaggageKey(key) {
+			continue
+		}
+		value, err := url.PathUnescape(strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+		b.Set(key, value)
+		b.members[b.index(key)].Properties = strings.TrimSpace(props)
+	}
+	return b
+}
+
+func isBaggageKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c <= ' ' || c >= 0x7f || strings.IndexByte(`"(),/:;<=>?@[\]{}`, c) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *Baggage) index(key string) int {
+	for i := range b.members {
+		if b.members[i].Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// Get returns the value of key, and whether it is present.
+func (b *Baggage) Get(key string) (string, bool) {
+	if i := b.index(key); i >= 0 {
+		return b.members[i].Value, true
+	}
+	return "", false
+}
+
+// Set sets the value of key, dropping its properties. It panics if key is not
+// a valid baggage key.
+func (b *Baggage) Set(key, value string) {
+	if !isBaggageKey(key) {
+		panic("gin: invalid baggage key " + key)
+	}
+	if i := b.index(key); i >= 0 {
+		b.members[i] = BaggageMember{Key: key, Value: value}
+		return
+	}
+	b.members = append(b.members, BaggageMember{Key: key, Value: value})
+}
+
+// Delete removes key.
+func (b *Baggage) Delete(key string) {
+	if i := b.index(key); i >= 0 {
+		b.members = append(b.members[:i], b.members[i+1:]...)
+	}
+}
+
+// Members returns a copy of the members, in order.
+func (b *Baggage) Members() []BaggageMember {
+	return append([]BaggageMember(nil), b.members...)
+}
+
+// Len returns the number of members.
+func (b *Baggage) Len() int {
+	return len(b.members)
+}
+
+// String returns the baggage as the value of a baggage header. Members which
+// would exceed the W3C size limit are left out.
+func (b *Baggage) String() string {
+	var sb strings.Builder
+	for _, m := range b.members {
+		item := m.Key + "=" + escapeBaggageValue(m.Value)
+		if m.Properties != "" {
+			item += ";" + m.Properties
+		}
+		if sb.Len()+len(item)+1 > maxBaggageBytes {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(item)
+	}
+	return sb.String()
+}
+
+// escapeBaggageValue percent-encodes the bytes of value which are not
+// baggage-octets, as well as '%'.
+func escapeBaggageValue(value string) string {
+	const hex = "0123456789ABCDEF"
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c > ' ' && c < 0x7f && c != '"' && c != ',' && c != ';' && c != '\\' && c != '%' {
+			sb.WriteByte(c)
+			continue
+		}
+		sb.WriteByte('%')
+		sb.WriteByte(hex[c>>4])
+		sb.WriteByte(hex[c&0xf])
+	}
+	return sb.String()
+}
+
+// Inject sets the baggage header of h, or removes it if the baggage is empty.
+func (b *Baggage) Inject(h http.Header) {
+	if value := b.String(); value != "" {
+		h.Set(BaggageHeader, value)
+	} else {
+		h.Del(BaggageHeader)
+	}
+}
+
+// Baggage returns the baggage of the request, parsed from its baggage headers on
+// first use. Changes to it are propagated to the requests made with
+// BaggageTransport, and by Inject.
+func (c *Context) Baggage() *Baggage {
+	if b, ok := c.Value(BaggageKey).(*Baggage); ok {
+		return b
+	}
+	var header string
+	if c.Request != nil {
+		header = strings.Join(c.Request.Header.Values(BaggageHeader), ",")
+	}
+	b := ParseBaggage(header)
+	c.Set(BaggageKey, b)
+	return b
+}
+
+type baggageContextKey struct{}
+
+// ContextWithBaggage returns a copy of ctx carrying b, for BaggageTransport.
+func ContextWithBaggage(ctx context.Context, b *Baggage) context.Context {
+	return context.WithValue(ctx, baggageContextKey{}, b)
+}
+
+// BaggageFromContext returns the baggage carried by ctx, set either by
+// ContextWithBaggage or by Context.Baggage if ctx is a *Context. As a *Context
+// is reused once its request is served, outbound requests should rather carry
+// ContextWithBaggage(c.Request.Context(), c.Baggage()).
+func BaggageFromContext(ctx context.Context) *Baggage {
+	if b, ok := ctx.Value(baggageContextKey{}).(*Baggage); ok {
+		return b
+	}
+	if b, ok := ctx.Value(BaggageKey).(*Baggage); ok {
+		return b
+	}
+	return nil
+}
+
+type baggageTransport struct {
+	next http.RoundTripper
+}
+
+// BaggageTransport returns a RoundTripper propagating the baggage of the request
+// context to the requests it sends, such as proxied or outbound requests. Baggage
+// already set on a request header is replaced. If next is nil,
+// http.DefaultTransport is used.
+//
+//	client := &http.Client{Transport: gin.BaggageTransport(nil)}
+//	ctx := gin.ContextWithBaggage(c.Request.Context(), c.Baggage())
+//	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, upstream, nil)
+//	resp, err := client.Do(req)
+func BaggageTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return baggageTransport{next: next}
+}
+
+func (t baggageTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if b := BaggageFromContext(req.Context()); b != nil {
+		req = req.Clone(req.Context())
+		b.Inject(req.Header)
+	}
+	return t.next.RoundTrip(req)
+}
+
+// BaggageConfig defines the config of the PropagateBaggage middleware.
+type BaggageConfig struct {
+	// FromKeys maps context keys to baggage keys: the values set under these
+	// context keys by previous handlers, e.g. AuthUserKey, are added to the baggage.
+	FromKeys map[string]string
+
+	// ToKeys maps baggage keys to context keys: the values of these baggage keys
+	// received from the client are set in the context, for handlers using c.GetString.
+	ToKeys map[string]string
+}
+
+// PropagateBaggage returns a middleware copying identifiers between the context
+// and the baggage of the request, so that they follow the requests made with
+// BaggageTransport:
+//
+//	router.Use(gin.BasicAuth(accounts), gin.PropagateBaggage(gin.BaggageConfig{
+//	    FromKeys: map[string]string{gin.AuthUserKey: "user.id"},
+//	    ToKeys:   map[string]string{"tenant.id": "tenant"},
+//	}))
+func PropagateBaggage(conf BaggageConfig) HandlerFunc {
+	return func(c *Context) {
+		b := c.Baggage()
+		for baggageKey, key := range conf.ToKeys {
+			if value, ok := b.Get(baggageKey); ok {
+				c.Set(key, value)
+			}
+		}
+		for key, baggageKey := range conf.FromKeys {
+			if value := c.GetString(key); value != "" {
+				b.Set(baggageKey, value)
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBaggage(t *testing.T) {
+	b := ParseBaggage("tenant.id=acme, user = alice%20b ;ttl=30,bad key=1,novalue,enc=%ZZ")
+	assert.Equal(t, []BaggageMember{
+		{Key: "tenant.id", Value: "acme"},
+		{Key: "user", Value: "alice b", Properties: "ttl=30"},
+	}, b.Members())
+	assert.Equal(t, "tenant.id=acme,user=alice%20b;ttl=30", b.String())
+
+	v, ok := b.Get("user")
+	assert.True(t, ok)
+	assert.Equal(t, "alice b", v)
+	_, ok = b.Get("missing")
+	assert.False(t, ok)
+
+	b.Set("user", "bob,\"x\"%")
+	b.Delete("tenant.id")
+	b.Delete("missing")
+	assert.Equal(t, 1, b.Len())
+	assert.Equal(t, "user=bob%2C%22x%22%25", b.String())
+	assert.Equal(t, "bob,\"x\"%", ParseBaggage(b.String()).Members()[0].Value)
+
+	assert.Panics(t, func() { b.Set("a b", "c") })
+
+	many := strings.Repeat("k=v,", 200)
+	assert.Equal(t, 1, ParseBaggage(many).Len())
+	var sb strings.Builder
+	for i := 0; i < 200; i++ {
+		sb.WriteString("k" + strconv.Itoa(i) + "=v,")
+	}
+	assert.Equal(t, maxBaggageMembers, ParseBaggage(sb.String()).Len())
+
+	big := &Baggage{}
+	big.Set("a", strings.Repeat("x", maxBaggageBytes))
+	big.Set("b", "c")
+	assert.Equal(t, "b=c", big.String())
+}
+
+func TestContextBaggage(t *testing.T) {
+	c, _ := CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest(http.MethodGet, "/", nil)
+	c.Request.Header.Add("Baggage", "a=1")
+	c.Request.Header.Add("Baggage", "b=2")
+
+	b := c.Baggage()
+	assert.Equal(t, "a=1,b=2", b.String())
+	assert.Same(t, b, c.Baggage())
+	assert.Same(t, b, BaggageFromContext(c))
+
+	h := http.Header{}
+	b.Inject(h)
+	assert.Equal(t, "a=1,b=2", h.Get(BaggageHeader))
+	(&Baggage{}).Inject(h)
+	assert.Empty(t, h.Values(BaggageHeader))
+
+	assert.Nil(t, BaggageFromContext(context.Background()))
+	assert.Same(t, b, BaggageFromContext(ContextWithBaggage(context.Background(), b)))
+}
+
+func TestBaggageTransport(t *testing.T) {
+	var received string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(BaggageHeader)
+	}))
+	defer upstream.Close()
+	client := &http.Client{Transport: BaggageTransport(nil)}
+
+	router := New()
+	router.Use(func(c *Context) {
+		c.Set(AuthUserKey, "alice")
+	}, PropagateBaggage(BaggageConfig{
+		FromKeys: map[string]string{AuthUserKey: "user.id"},
+		ToKeys:   map[string]string{"tenant.id": "tenant"},
+	}))
+	router.GET("/", func(c *Context) {
+		ctx := ContextWithBaggage(c.Request.Context(), c.Baggage())
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL, nil)
+		req.Header.Set(BaggageHeader, "stale=1")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		c.String(http.StatusOK, c.GetString("tenant"))
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/", header{Key: "baggage", Value: "tenant.id=acme"})
+	assert.Equal(t, "acme", w.Body.String())
+	assert.Equal(t, "tenant.id=acme,user.id=alice", received)
+
+	// requests without baggage in their context are sent as is
+	req, _ := http.NewRequest(http.MethodGet, upstream.URL, nil)
+	req.Header.Set(BaggageHeader, "own=1")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "own=1", received)
+}
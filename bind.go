@@ -0,0 +1,93 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"reflect"
+
+	"github.com/jialequ/mpgw/binding"
+)
+
+// BindJSON binds the JSON body of the request into a new T, usually a struct,
+// and returns it. As Context.BindJSON, it aborts the request with HTTP 400 if
+// any error occurs:
+//
+//	req, err := gin.BindJSON[CreateUserRequest](c)
+//	if err != nil {
+//	    return
+//	}
+func BindJSON[T any](c *Context) (T, error) {
+	return bindTo[T](c.BindJSON)
+}
+
+// BindXML binds the XML body of the request into a new T, see BindJSON.
+func BindXML[T any](c *Context) (T, error) {
+	return bindTo[T](c.BindXML)
+}
+
+// BindYAML binds the YAML body of the request into a new T, see BindJSON.
+func BindYAML[T any](c *Context) (T, error) {
+	return bindTo[T](c.BindYAML)
+}
+
+// BindTOML binds the TOML body of the request into a new T, see BindJSON.
+func BindTOML[T any](c *Context) (T, error) {
+	return bindTo[T](c.BindTOML)
+}
+
+// BindQuery binds the query string of the request into a new T, see BindJSON.
+func BindQuery[T any](c *Context) (T, error) {
+	return bindTo[T](c.BindQuery)
+}
+
+// BindHeader binds the headers of the request into a new T, see BindJSON.
+func BindHeader[T any](c *Context) (T, error) {
+	return bindTo[T](c.BindHeader)
+}
+
+// BindUri binds the uri params of the request into a new T, see BindJSON.
+func BindUri[T any](c *Context) (T, error) {
+	return bindTo[T](c.BindUri)
+}
+
+// BindAll binds the uri params, the headers, the query string and the body of
+// the request into a new T, see Context.BindAll and BindJSON.
+func BindAll[T any](c *Context) (T, error) {
+	return bindTo[T](c.BindAll)
+}
+
+// BindWith binds the request into a new T using the binding engine, see
+// Context.MustBindWith and BindJSON.
+func BindWith[T any](c *Context, b binding.Binding) (T, error) {
+	return bindTo[T](func(obj any) error { return c.MustBindWith(obj, b) })
+}
+
+func bindTo[T any](bind func(obj any) error) (T, error) {
+	var obj T
+	err := bind(&obj)
+	return obj, err
+}
+
+// Typed adapts a handler taking the request as a typed value, bound with
+// Context.BindAll, into a HandlerFunc. The request is aborted with HTTP 400 if
+// the binding fails, without calling fn:
+//
+//	router.POST("/users/:org", gin.Typed(func(c *gin.Context, req CreateUserRequest) {
+//	    c.JSON(http.StatusCreated, users.Create(req))
+//	}))
+//
+// T must not be a pointer.
+func Typed[T any](fn func(c *Context, req T)) HandlerFunc {
+	if reflect.TypeOf((*T)(nil)).Elem().Kind() == reflect.Ptr {
+		panic("gin.Typed: the request type can not be a pointer, use gin.Typed[Struct] instead of gin.Typed[*Struct]")
+	}
+	return func(c *Context) {
+		req, err := BindAll[T](c)
+		if err != nil {
+			return
+		}
+		fn(c, req)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jialequ/mpgw/binding"
+	"github.com/stretchr/testify/assert"
+)
+
+type typedBindRequest struct {
+	Org  string `uri:"org" json:"-"`
+	Name string `form:"name" json:"name" binding:"required"`
+	Page int    `form:"page" json:"page"`
+}
+
+func TestBindTyped(t *testing.T) {
+	router := New()
+	var bound typedBindRequest
+	var err error
+	router.POST("/json", func(c *Context) { bound, err = BindJSON[typedBindRequest](c) })
+	router.GET("/query", func(c *Context) { bound, err = BindQuery[typedBindRequest](c) })
+	router.GET("/with", func(c *Context) { bound, err = BindWith[typedBindRequest](c, binding.Form) })
+	router.GET("/orgs/:org", func(c *Context) { bound, err = BindUri[typedBindRequest](c) })
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/json", strings.NewReader(`{"name":"ada","page":2}`))
+	router.ServeHTTP(w, req)
+	assert.NoError(t, err)
+	assert.Equal(t, typedBindRequest{Name: "ada", Page: 2}, bound)
+
+	w = PerformRequest(router, http.MethodGet, "/query?name=bob&page=3")
+	assert.NoError(t, err)
+	assert.Equal(t, typedBindRequest{Name: "bob", Page: 3}, bound)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	PerformRequest(router, http.MethodGet, "/with?name=eve")
+	assert.NoError(t, err)
+	assert.Equal(t, "eve", bound.Name)
+
+	// the request is aborted if the binding fails
+	w = PerformRequest(router, http.MethodGet, "/query?page=3")
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = PerformRequest(router, http.MethodGet, "/orgs/acme")
+	assert.Error(t, err)
+	assert.Equal(t, "acme", bound.Org)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestTypedHandler(t *testing.T) {
+	router := New()
+	router.POST("/orgs/:org/users", Typed(func(c *Context, req typedBindRequest) {
+		c.String(http.StatusCreated, req.Org+"/"+req.Name)
+	}))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/orgs/acme/users?page=1", strings.NewReader(`{"name":"ada"}`))
+	req.Header.Set("Content-Type", MIMEJSON)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "acme/ada", w.Body.String())
+
+	w = PerformRequest(router, http.MethodPost, "/orgs/acme/users")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	assert.Panics(t, func() { Typed(func(*Context, *typedBindRequest) {}) })
+}
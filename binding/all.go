@@ -0,0 +1,72 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package binding
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// decoder is implemented by the bindings which can decode a request without
+// validating the result, so that BindAll validates once all the sources are
+// decoded.
+type decoder interface {
+	decode(req *http.Request, obj any) error
+}
+
+// SourceError is the error of a source decoded by BindAll.
+type SourceError struct {
+	// Source is "body", "query", "header" or "uri".
+	Source string
+	Err    error
+}
+
+func (err *SourceError) Error() string {
+	return err.Source + ": " + err.Err.Error()
+}
+
+func (err *SourceError) Unwrap() error {
+	return err.Err
+}
+
+// BindAll fills obj from the body of req, selected by its Content-Type as with
+// Default, its query string, its headers and the uri params, in that order, so
+// that a field present in several sources takes the value of the last one: the
+// uri params take precedence over the headers, the headers over the query
+// string and the query string over the body. Requests without body only use
+// the other sources. The struct is validated once all the sources are decoded.
+//
+// The errors of all the sources, wrapped in a *SourceError, and the validation
+// error are reported together, joined by errors.Join.
+func BindAll(req *http.Request, uri map[string][]string, obj any) error {
+	var errs []error
+	if req.Body != nil && req.Body != http.NoBody && req.ContentLength != 0 {
+		contentType, _, _ := strings.Cut(req.Header.Get("Content-Type"), ";")
+		b := Default(http.MethodPost, strings.TrimSpace(contentType))
+		var err error
+		if d, ok := b.(decoder); ok {
+			err = d.decode(req, obj)
+		} else {
+			err = b.Bind(req, obj)
+		}
+		if err != nil {
+			errs = append(errs, &SourceError{Source: "body", Err: err})
+		}
+	}
+	if err := mapForm(obj, req.URL.Query()); err != nil {
+		errs = append(errs, &SourceError{Source: "query", Err: err})
+	}
+	if err := mapHeader(obj, req.Header); err != nil {
+		errs = append(errs, &SourceError{Source: "header", Err: err})
+	}
+	if err := mapURI(obj, uri); err != nil {
+		errs = append(errs, &SourceError{Source: "uri", Err: err})
+	}
+	if err := validate(obj); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
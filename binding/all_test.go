@@ -0,0 +1,61 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package binding
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bindAllRequest struct {
+	ID     int    `uri:"id" binding:"required"`
+	Token  string `header:"X-Token" binding:"required"`
+	Page   int    `form:"page"`
+	Name   string `form:"name" json:"name" binding:"required"`
+	Amount int    `form:"amount" json:"amount" binding:"gt=0"`
+}
+
+func TestBindAll(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "/orders/7?page=2&name=query", bytes.NewBufferString(`{"name":"body","amount":3}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("X-Token", "secret")
+
+	var obj bindAllRequest
+	require.NoError(t, BindAll(req, map[string][]string{"id": {"7"}}, &obj))
+	assert.Equal(t, bindAllRequest{ID: 7, Token: "secret", Page: 2, Name: "query", Amount: 3}, obj)
+
+	// the required fields of the other sources do not fail the body
+	req, _ = http.NewRequest(http.MethodPost, "/", bytes.NewBufferString("name=form&amount=4"))
+	req.Header.Set("Content-Type", MIMEPOSTForm)
+	req.Header.Set("X-Token", "secret")
+	obj = bindAllRequest{}
+	require.NoError(t, BindAll(req, map[string][]string{"id": {"8"}}, &obj))
+	assert.Equal(t, "form", obj.Name)
+	assert.Equal(t, 8, obj.ID)
+
+	req, _ = http.NewRequest(http.MethodGet, "/?page=x", nil)
+	obj = bindAllRequest{}
+	err := BindAll(req, map[string][]string{"id": {"y"}}, &obj)
+	var sourceErr *SourceError
+	require.ErrorAs(t, err, &sourceErr)
+	assert.Equal(t, "query", sourceErr.Source)
+	assert.Contains(t, err.Error(), "uri: ")
+	var validationErrs validator.ValidationErrors
+	require.ErrorAs(t, err, &validationErrs)
+	assert.Len(t, validationErrs, 4)
+
+	req, _ = http.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"name":`))
+	req.Header.Set("Content-Type", MIMEJSON)
+	err = BindAll(req, nil, &obj)
+	require.ErrorAs(t, err, &sourceErr)
+	assert.Equal(t, "body", sourceErr.Source)
+	assert.True(t, errors.As(err, &validationErrs))
+}
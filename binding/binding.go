@@ -23,6 +23,7 @@ const (
 	MIMEYAML              = "application/x-yaml"
 	MIMEYAML2             = "application/yaml"
 	MIMETOML              = "application/toml"
+	MIMEJSONAPI           = "application/vnd.api+json"
 )
 
 // Binding describes the interface which needs to be implemented for binding the
@@ -98,6 +99,8 @@ func Default(method, contentType string) Binding {
 	switch contentType {
 	case MIMEJSON:
 		return JSON
+	case MIMEJSONAPI:
+		return JSONAPI
 	case MIMEXML, MIMEXML2:
 		return XML
 	case MIMEPROTOBUF:
@@ -19,44 +19,55 @@ func (formBinding) Name() string {
 	return "form"
 }
 
-func (formBinding) Bind(req *http.Request, obj any) error {
-	if err := req.ParseForm(); err != nil {
+func (b formBinding) Bind(req *http.Request, obj any) error {
+	if err := b.decode(req, obj); err != nil {
 		return err
 	}
-	if err := req.ParseMultipartForm(defaultMemory); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+	return validate(obj)
+}
+
+func (formBinding) decode(req *http.Request, obj any) error {
+	if err := req.ParseForm(); err != nil {
 		return err
 	}
-	if err := mapForm(obj, req.Form); err != nil {
+	if err := req.ParseMultipartForm(defaultMemory); err != nil && !errors.Is(err, http.ErrNotMultipart) {
 		return err
 	}
-	return validate(obj)
+	return mapForm(obj, req.Form)
 }
 
 func (formPostBinding) Name() string {
 	return "form-urlencoded"
 }
 
-func (formPostBinding) Bind(req *http.Request, obj any) error {
-	if err := req.ParseForm(); err != nil {
+func (b formPostBinding) Bind(req *http.Request, obj any) error {
+	if err := b.decode(req, obj); err != nil {
 		return err
 	}
-	if err := mapForm(obj, req.PostForm); err != nil {
+	return validate(obj)
+}
+
+func (formPostBinding) decode(req *http.Request, obj any) error {
+	if err := req.ParseForm(); err != nil {
 		return err
 	}
-	return validate(obj)
+	return mapForm(obj, req.PostForm)
 }
 
 func (formMultipartBinding) Name() string {
 	return "multipart/form-data"
 }
 
-func (formMultipartBinding) Bind(req *http.Request, obj any) error {
-	if err := req.ParseMultipartForm(defaultMemory); err != nil {
+func (b formMultipartBinding) Bind(req *http.Request, obj any) error {
+	if err := b.decode(req, obj); err != nil {
 		return err
 	}
-	if err := mappingByPtr(obj, (*multipartRequest)(req), "form"); err != nil {
+	return validate(obj)
+}
+
+func (formMultipartBinding) decode(req *http.Request, obj any) error {
+	if err := req.ParseMultipartForm(defaultMemory); err != nil {
 		return err
 	}
-
-	return validate(obj)
+	return mappingByPtr(obj, (*multipartRequest)(req), "form")
 }
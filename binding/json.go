@@ -30,15 +30,25 @@ func (jsonBinding) Name() string {
 	return "json"
 }
 
-func (jsonBinding) Bind(req *http.Request, obj any) error {
-	if req == nil || req.Body == nil {
-		return errors.New("invalid request")
+func (b jsonBinding) Bind(req *http.Request, obj any) error {
+	if err := b.decode(req, obj); err != nil {
+		return err
 	}
-	return decodeJSON(req.Body, obj)
+	return validate(obj)
 }
 
 func (jsonBinding) BindBody(body []byte, obj any) error {
-	return decodeJSON(bytes.NewReader(body), obj)
+	if err := decodeJSON(bytes.NewReader(body), obj); err != nil {
+		return err
+	}
+	return validate(obj)
+}
+
+func (jsonBinding) decode(req *http.Request, obj any) error {
+	if req == nil || req.Body == nil {
+		return errors.New("invalid request")
+	}
+	return decodeJSON(req.Body, obj)
 }
 
 func decodeJSON(r io.Reader, obj any) error {
@@ -49,8 +59,5 @@ func decodeJSON(r io.Reader, obj any) error {
 	if EnableDecoderDisallowUnknownFields {
 		decoder.DisallowUnknownFields()
 	}
-	if err := decoder.Decode(obj); err != nil {
-		return err
-	}
-	return validate(obj)
+	return decoder.Decode(obj)
 }
@@ -0,0 +1,182 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package binding
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/jialequ/mpgw/internal/json"
+)
+
+// JSONAPI implements the Binding interface for JSON:API documents, see
+// https://jsonapi.org. The primary data is flattened before being decoded into
+// the object: the attributes become fields, the resource id the "id" field, and
+// each relationship a field named after it holding the related id, or a slice of
+// ids for to-many relationships. A slice object receives a collection.
+var JSONAPI BindingBody = jsonAPIBinding{}
+
+type jsonAPIBinding struct{}
+
+// rawJSON is a raw encoded JSON value, like encoding/json.RawMessage, usable
+// with any of the JSON packages selected by build tags.
+type rawJSON []byte
+
+func (m rawJSON) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return []byte("null"), nil
+	}
+	return m, nil
+}
+
+func (m *rawJSON) UnmarshalJSON(data []byte) error {
+	*m = append((*m)[0:0], data...)
+	return nil
+}
+
+type jsonAPIDocument struct {
+	Data rawJSON `json:"data"`
+}
+
+type jsonAPIResource struct {
+	Type          string                         `json:"type"`
+	ID            string                         `json:"id"`
+	Attributes    map[string]rawJSON             `json:"attributes"`
+	Relationships map[string]jsonAPIRelationship `json:"relationships"`
+}
+
+type jsonAPIRelationship struct {
+	Data rawJSON `json:"data"`
+}
+
+type jsonAPIIdentifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+func (jsonAPIBinding) Name() string {
+	return "jsonapi"
+}
+
+func (b jsonAPIBinding) Bind(req *http.Request, obj any) error {
+	if err := b.decode(req, obj); err != nil {
+		return err
+	}
+	return validate(obj)
+}
+
+func (jsonAPIBinding) BindBody(body []byte, obj any) error {
+	if err := decodeJSONAPI(body, obj); err != nil {
+		return err
+	}
+	return validate(obj)
+}
+
+func (jsonAPIBinding) decode(req *http.Request, obj any) error {
+	if req == nil || req.Body == nil {
+		return errors.New("invalid request")
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	return decodeJSONAPI(body, obj)
+}
+
+// decodeJSONAPI decodes the primary data of a JSON:API document into obj.
+func decodeJSONAPI(body []byte, obj any) error {
+	var doc jsonAPIDocument
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return err
+	}
+	data := bytes.TrimSpace(doc.Data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return errors.New("jsonapi: missing primary data")
+	}
+
+	var flat []byte
+	var err error
+	if data[0] == '[' {
+		var resources []jsonAPIResource
+		if err = json.Unmarshal(data, &resources); err != nil {
+			return err
+		}
+		items := make([]map[string]rawJSON, 0, len(resources))
+		for i := range resources {
+			item, ferr := flattenJSONAPIResource(&resources[i])
+			if ferr != nil {
+				return ferr
+			}
+			items = append(items, item)
+		}
+		flat, err = json.Marshal(items)
+	} else {
+		var resource jsonAPIResource
+		if err = json.Unmarshal(data, &resource); err != nil {
+			return err
+		}
+		item, ferr := flattenJSONAPIResource(&resource)
+		if ferr != nil {
+			return ferr
+		}
+		flat, err = json.Marshal(item)
+	}
+	if err != nil {
+		return err
+	}
+	return decodeJSON(bytes.NewReader(flat), obj)
+}
+
+func flattenJSONAPIResource(resource *jsonAPIResource) (map[string]rawJSON, error) {
+	if resource.Type == "" {
+		return nil, errors.New("jsonapi: resource object without type")
+	}
+	item := make(map[string]rawJSON, len(resource.Attributes)+len(resource.Relationships)+1)
+	for name, value := range resource.Attributes {
+		item[name] = value
+	}
+	if resource.ID != "" {
+		id, err := json.Marshal(resource.ID)
+		if err != nil {
+			return nil, err
+		}
+		item["id"] = id
+	}
+	for name, rel := range resource.Relationships {
+		ids, err := jsonAPIRelationshipIDs(rel.Data)
+		if err != nil {
+			return nil, err
+		}
+		item[name] = ids
+	}
+	return item, nil
+}
+
+// jsonAPIRelationshipIDs returns the related id, or the ids of a to-many
+// relationship, as JSON.
+func jsonAPIRelationshipIDs(data rawJSON) (rawJSON, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return rawJSON("null"), nil
+	}
+	if data[0] == '[' {
+		var identifiers []jsonAPIIdentifier
+		if err := json.Unmarshal(data, &identifiers); err != nil {
+			return nil, err
+		}
+		ids := make([]string, 0, len(identifiers))
+		for _, identifier := range identifiers {
+			ids = append(ids, identifier.ID)
+		}
+		return json.Marshal(ids)
+	}
+	var identifier jsonAPIIdentifier
+	if err := json.Unmarshal(data, &identifier); err != nil {
+		return nil, err
+	}
+	return json.Marshal(identifier.ID)
+}
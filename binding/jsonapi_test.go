@@ -0,0 +1,62 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package binding
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type jsonAPIArticle struct {
+	ID     string   `json:"id"`
+	Title  string   `json:"title" binding:"required"`
+	Pages  int      `json:"pages"`
+	Author string   `json:"author"`
+	Tags   []string `json:"tags"`
+}
+
+func TestBindingJSONAPI(t *testing.T) {
+	body := `{"data": {
+		"type": "articles", "id": "1",
+		"attributes": {"title": "JSON:API", "pages": 3},
+		"relationships": {
+			"author": {"data": {"type": "people", "id": "9"}},
+			"tags": {"data": [{"type": "tags", "id": "a"}, {"type": "tags", "id": "b"}]}
+		}
+	}}`
+	req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", MIMEJSONAPI)
+
+	assert.Equal(t, JSONAPI, Default(http.MethodPost, MIMEJSONAPI))
+	assert.Equal(t, "jsonapi", JSONAPI.Name())
+
+	var article jsonAPIArticle
+	require.NoError(t, JSONAPI.Bind(req, &article))
+	assert.Equal(t, jsonAPIArticle{ID: "1", Title: "JSON:API", Pages: 3, Author: "9", Tags: []string{"a", "b"}}, article)
+}
+
+func TestBindingJSONAPICollection(t *testing.T) {
+	var articles []jsonAPIArticle
+	err := JSONAPI.BindBody([]byte(`{"data": [
+		{"type": "articles", "attributes": {"title": "a"}},
+		{"type": "articles", "attributes": {"title": "b"}, "relationships": {"author": {"data": null}}}
+	]}`), &articles)
+	require.NoError(t, err)
+	assert.Equal(t, []jsonAPIArticle{{Title: "a"}, {Title: "b"}}, articles)
+}
+
+func TestBindingJSONAPIErrors(t *testing.T) {
+	var article jsonAPIArticle
+	assert.Error(t, JSONAPI.BindBody([]byte(`{"data": {"type": "articles", "attributes": {}}}`), &article))
+	assert.EqualError(t, JSONAPI.BindBody([]byte(`{"data": {"attributes": {"title": "a"}}}`), &article),
+		"jsonapi: resource object without type")
+	assert.EqualError(t, JSONAPI.BindBody([]byte(`{"meta": {}}`), &article), "jsonapi: missing primary data")
+	assert.Error(t, JSONAPI.BindBody([]byte(`{`), &article))
+	assert.Error(t, JSONAPI.Bind(nil, &article))
+}
@@ -20,18 +20,25 @@ func (msgpackBinding) Name() string {
 	return "msgpack"
 }
 
-func (msgpackBinding) Bind(req *http.Request, obj any) error {
-	return decodeMsgPack(req.Body, obj)
+func (b msgpackBinding) Bind(req *http.Request, obj any) error {
+	if err := b.decode(req, obj); err != nil {
+		return err
+	}
+	return validate(obj)
 }
 
 func (msgpackBinding) BindBody(body []byte, obj any) error {
-	return decodeMsgPack(bytes.NewReader(body), obj)
+	if err := decodeMsgPack(bytes.NewReader(body), obj); err != nil {
+		return err
+	}
+	return validate(obj)
+}
+
+func (msgpackBinding) decode(req *http.Request, obj any) error {
+	return decodeMsgPack(req.Body, obj)
 }
 
 func decodeMsgPack(r io.Reader, obj any) error {
 	cdc := new(codec.MsgpackHandle)
-	if err := codec.NewDecoder(r, cdc).Decode(&obj); err != nil {
-		return err
-	}
-	return validate(obj)
+	return codec.NewDecoder(r, cdc).Decode(&obj)
 }
@@ -6,9 +6,13 @@ package binding
 
 import (
 	"errors"
+	"fmt"
+	"mime"
 	"mime/multipart"
 	"net/http"
 	"reflect"
+	"strconv"
+	"strings"
 )
 
 type multipartRequest http.Request
@@ -23,9 +27,26 @@ var (
 	ErrMultiFileHeaderLenInvalid = errors.New("unsupported len of array for []*multipart.FileHeader")
 )
 
+var (
+	// ErrFileTooLarge is returned by the multipart binding for a file larger
+	// than the maxsize of the file tag of its field.
+	ErrFileTooLarge = errors.New("file too large")
+
+	// ErrFileType is returned by the multipart binding for a file whose
+	// Content-Type is not one of the mime types of the file tag of its field.
+	ErrFileType = errors.New("file type not allowed")
+
+	// ErrTooManyFiles is returned by the multipart binding for more files than
+	// the maxcount of the file tag of their field.
+	ErrTooManyFiles = errors.New("too many files")
+)
+
 // TrySet tries to set a value by the multipart request with the binding a form file
 func (r *multipartRequest) TrySet(value reflect.Value, field reflect.StructField, key string, opt setOptions) (bool, error) {
 	if files := r.MultipartForm.File[key]; len(files) != 0 {
+		if err := checkFiles(field, key, files); err != nil {
+			return false, err
+		}
 		return setByMultipartFormFile(value, field, files)
 	}
 
@@ -72,3 +93,80 @@ func setArrayOfMultipartFormFiles(value reflect.Value, field reflect.StructField
 	}
 	return true, nil
 }
+
+// checkFiles checks the files of a field against the constraints of its file
+// tag, such as `file:"maxsize=5MB,mime=image/png|image/*,maxcount=3"`. The
+// sizes accept the KB, MB and GB suffixes, the mime types are matched against
+// the Content-Type declared by the client and may end with a wildcard subtype.
+func checkFiles(field reflect.StructField, key string, files []*multipart.FileHeader) error {
+	tag := field.Tag.Get("file")
+	if tag == "" {
+		return nil
+	}
+	var maxSize int64
+	var maxCount int
+	var mimeTypes []string
+	for _, opt := range strings.Split(tag, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(opt), "=")
+		var err error
+		switch k {
+		case "maxsize":
+			maxSize, err = parseFileSize(v)
+		case "maxcount":
+			maxCount, err = strconv.Atoi(v)
+		case "mime":
+			mimeTypes = strings.Split(v, "|")
+		default:
+			err = errors.New("unknown option")
+		}
+		if err != nil {
+			return fmt.Errorf("invalid file tag %q of field %s: %w", tag, field.Name, err)
+		}
+	}
+
+	if maxCount > 0 && len(files) > maxCount {
+		return fmt.Errorf("%w: %s has %d files, at most %d allowed", ErrTooManyFiles, key, len(files), maxCount)
+	}
+	for _, file := range files {
+		if maxSize > 0 && file.Size > maxSize {
+			return fmt.Errorf("%w: %s %q has %d bytes, at most %d allowed", ErrFileTooLarge, key, file.Filename, file.Size, maxSize)
+		}
+		if len(mimeTypes) > 0 && !matchMIMEType(file.Header.Get("Content-Type"), mimeTypes) {
+			return fmt.Errorf("%w: %s %q is %q", ErrFileType, key, file.Filename, file.Header.Get("Content-Type"))
+		}
+	}
+	return nil
+}
+
+// parseFileSize parses a size in bytes, with an optional KB, MB or GB suffix.
+func parseFileSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	unit := int64(1)
+	for suffix, n := range map[string]int64{"KB": 1 << 10, "MB": 1 << 20, "GB": 1 << 30} {
+		if strings.HasSuffix(s, suffix) {
+			s, unit = strings.TrimSuffix(s, suffix), n
+			break
+		}
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return n * unit, nil
+}
+
+// matchMIMEType reports whether contentType is one of the mime types, such as
+// "image/png" or "image/*".
+func matchMIMEType(contentType string, mimeTypes []string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range mimeTypes {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == mediaType || strings.HasSuffix(t, "/*") && strings.HasPrefix(mediaType, t[:len(t)-1]) {
+			return true
+		}
+	}
+	return false
+}
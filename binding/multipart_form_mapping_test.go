@@ -9,6 +9,7 @@ import (
 	"io"
 	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -136,3 +137,53 @@ func assertMultipartFileHeader(t *testing.T, fh *multipart.FileHeader, file test
 	err = fl.Close()
 	assert.NoError(t, err)
 }
+
+func TestFormMultipartBindingFileConstraints(t *testing.T) {
+	newRequest := func(name string, count int, size int, contentType string) *http.Request {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		assert.NoError(t, mw.WriteField("title", "holidays"))
+		for i := 0; i < count; i++ {
+			h := make(textproto.MIMEHeader)
+			h.Set("Content-Disposition", `form-data; name="`+name+`"; filename="photo.png"`)
+			h.Set("Content-Type", contentType)
+			fw, err := mw.CreatePart(h)
+			assert.NoError(t, err)
+			_, err = fw.Write(bytes.Repeat([]byte("x"), size))
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, mw.Close())
+		req, _ := http.NewRequest(http.MethodPost, "/", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		return req
+	}
+
+	type album struct {
+		Title  string                  `form:"title"`
+		Cover  *multipart.FileHeader   `form:"cover" file:"maxsize=1KB,mime=image/png|image/jpeg"`
+		Photos []*multipart.FileHeader `form:"photos" file:"maxcount=2,mime=image/*"`
+	}
+
+	var s album
+	assert.NoError(t, FormMultipart.Bind(newRequest("cover", 1, 1024, "image/png"), &s))
+	assert.Equal(t, "holidays", s.Title)
+	assert.Equal(t, int64(1024), s.Cover.Size)
+	s = album{}
+	assert.NoError(t, FormMultipart.Bind(newRequest("photos", 2, 10, "image/webp"), &s))
+	assert.Len(t, s.Photos, 2)
+
+	err := FormMultipart.Bind(newRequest("cover", 1, 1025, "image/png"), &album{})
+	assert.ErrorIs(t, err, ErrFileTooLarge)
+	assert.EqualError(t, err, `file too large: cover "photo.png" has 1025 bytes, at most 1024 allowed`)
+	err = FormMultipart.Bind(newRequest("cover", 1, 10, "image/gif"), &album{})
+	assert.ErrorIs(t, err, ErrFileType)
+	err = FormMultipart.Bind(newRequest("photos", 1, 10, "text/plain"), &album{})
+	assert.ErrorIs(t, err, ErrFileType)
+	err = FormMultipart.Bind(newRequest("photos", 3, 10, "image/png"), &album{})
+	assert.ErrorIs(t, err, ErrTooManyFiles)
+
+	var invalid struct {
+		File *multipart.FileHeader `form:"file" file:"maxsize=big"`
+	}
+	assert.ErrorContains(t, FormMultipart.Bind(newRequest("file", 1, 10, "image/png"), &invalid), "invalid file tag")
+}
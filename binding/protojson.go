@@ -0,0 +1,162 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package binding
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// These implement the Binding interface for proto messages, including messages
+// built at runtime with dynamicpb from a descriptor.
+var (
+	ProtoJSON BindingBody = protoJSONBinding{}
+	ProtoForm Binding     = protoFormBinding{}
+)
+
+type protoJSONBinding struct{}
+
+func (protoJSONBinding) Name() string {
+	return "protojson"
+}
+
+func (b protoJSONBinding) Bind(req *http.Request, obj any) error {
+	if req == nil || req.Body == nil {
+		return errors.New("invalid request")
+	}
+	buf, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	return b.BindBody(buf, obj)
+}
+
+func (protoJSONBinding) BindBody(body []byte, obj any) error {
+	msg, ok := obj.(proto.Message)
+	if !ok {
+		return errors.New("obj is not ProtoMessage")
+	}
+	return protojson.Unmarshal(body, msg)
+}
+
+type protoFormBinding struct{}
+
+func (protoFormBinding) Name() string {
+	return "protoform"
+}
+
+func (protoFormBinding) Bind(req *http.Request, obj any) error {
+	msg, ok := obj.(proto.Message)
+	if !ok {
+		return errors.New("obj is not ProtoMessage")
+	}
+	if err := req.ParseForm(); err != nil {
+		return err
+	}
+	if err := req.ParseMultipartForm(defaultMemory); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return err
+	}
+	return mapProtoForm(msg.ProtoReflect(), req.Form)
+}
+
+// mapProtoForm sets the scalar and repeated scalar fields of m from form values
+// keyed by the field JSON name or proto name.
+func mapProtoForm(m protoreflect.Message, form map[string][]string) error {
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		values, ok := form[fd.JSONName()]
+		if !ok {
+			values, ok = form[string(fd.Name())]
+		}
+		if !ok || len(values) == 0 {
+			continue
+		}
+		if fd.IsMap() || fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind {
+			return fmt.Errorf("field %q: form binding only supports scalar fields", fd.Name())
+		}
+		if fd.IsList() {
+			list := m.Mutable(fd).List()
+			for _, s := range values {
+				v, err := protoScalar(fd, s)
+				if err != nil {
+					return err
+				}
+				list.Append(v)
+			}
+			continue
+		}
+		v, err := protoScalar(fd, values[0])
+		if err != nil {
+			return err
+		}
+		m.Set(fd, v)
+	}
+	return nil
+}
+
+func protoScalar(fd protoreflect.FieldDescriptor, s string) (protoreflect.Value, error) { // NOSONAR
+	var (
+		v   protoreflect.Value
+		err error
+	)
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		var b bool
+		b, err = strconv.ParseBool(s)
+		v = protoreflect.ValueOfBool(b)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		var n int64
+		n, err = strconv.ParseInt(s, 10, 32)
+		v = protoreflect.ValueOfInt32(int32(n))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		var n int64
+		n, err = strconv.ParseInt(s, 10, 64)
+		v = protoreflect.ValueOfInt64(n)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		var n uint64
+		n, err = strconv.ParseUint(s, 10, 32)
+		v = protoreflect.ValueOfUint32(uint32(n))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		var n uint64
+		n, err = strconv.ParseUint(s, 10, 64)
+		v = protoreflect.ValueOfUint64(n)
+	case protoreflect.FloatKind:
+		var f float64
+		f, err = strconv.ParseFloat(s, 32)
+		v = protoreflect.ValueOfFloat32(float32(f))
+	case protoreflect.DoubleKind:
+		var f float64
+		f, err = strconv.ParseFloat(s, 64)
+		v = protoreflect.ValueOfFloat64(f)
+	case protoreflect.StringKind:
+		v = protoreflect.ValueOfString(s)
+	case protoreflect.BytesKind:
+		var b []byte
+		b, err = base64.StdEncoding.DecodeString(s)
+		v = protoreflect.ValueOfBytes(b)
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		var n int64
+		n, err = strconv.ParseInt(s, 10, 32)
+		v = protoreflect.ValueOfEnum(protoreflect.EnumNumber(n))
+	default:
+		return v, fmt.Errorf("field %q: unsupported kind %s", fd.Name(), fd.Kind())
+	}
+	if err != nil {
+		return v, fmt.Errorf("field %q: %w", fd.Name(), err)
+	}
+	return v, nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package binding
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func userDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	field := func(name string, num int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(num),
+			Type:   typ.Enum(),
+			Label:  label.Enum(),
+		}
+	}
+	opt := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	rep := descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+	statusField := field("status", 5, descriptorpb.FieldDescriptorProto_TYPE_ENUM, opt)
+	statusField.TypeName = proto.String(".test.Status")
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("user.proto"),
+		Package: proto.String("test"),
+		Syntax:  proto.String("proto3"),
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Status"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("UNKNOWN"), Number: proto.Int32(0)},
+				{Name: proto.String("ACTIVE"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("User"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("user_name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, opt),
+				field("age", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32, opt),
+				field("scores", 3, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, rep),
+				field("admin", 4, descriptorpb.FieldDescriptorProto_TYPE_BOOL, opt),
+				statusField,
+			},
+		}},
+	}, nil)
+	require.NoError(t, err)
+	return fd.Messages().ByName("User")
+}
+
+func TestBindingProtoJSONDynamic(t *testing.T) {
+	desc := userDescriptor(t)
+	msg := dynamicpb.NewMessage(desc)
+	req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(`{"userName":"gin","age":3,"status":"ACTIVE"}`))
+	require.NoError(t, ProtoJSON.Bind(req, msg))
+	assert.Equal(t, "protojson", ProtoJSON.Name())
+	assert.Equal(t, "gin", msg.Get(desc.Fields().ByName("user_name")).String())
+	assert.Equal(t, int64(3), msg.Get(desc.Fields().ByName("age")).Int())
+	assert.Equal(t, protoreflect.EnumNumber(1), msg.Get(desc.Fields().ByName("status")).Enum())
+
+	assert.Error(t, ProtoJSON.BindBody([]byte(`{"unknown":1}`), msg))
+	assert.Error(t, ProtoJSON.BindBody([]byte(`{}`), &struct{}{}))
+}
+
+func TestBindingProtoFormDynamic(t *testing.T) {
+	desc := userDescriptor(t)
+	msg := dynamicpb.NewMessage(desc)
+	req, _ := http.NewRequest(http.MethodGet, "/?user_name=gin&age=3&scores=1.5&scores=2&admin=true&status=1", nil)
+	require.NoError(t, ProtoForm.Bind(req, msg))
+	assert.Equal(t, "protoform", ProtoForm.Name())
+	assert.Equal(t, "gin", msg.Get(desc.Fields().ByName("user_name")).String())
+	assert.True(t, msg.Get(desc.Fields().ByName("admin")).Bool())
+	scores := msg.Get(desc.Fields().ByName("scores")).List()
+	assert.Equal(t, 2, scores.Len())
+	assert.Equal(t, 1.5, scores.Get(0).Float())
+	assert.Equal(t, protoreflect.EnumNumber(1), msg.Get(desc.Fields().ByName("status")).Enum())
+
+	req, _ = http.NewRequest(http.MethodGet, "/?userName=x&status=ACTIVE", nil)
+	msg = dynamicpb.NewMessage(desc)
+	require.NoError(t, ProtoForm.Bind(req, msg))
+	assert.Equal(t, "x", msg.Get(desc.Fields().ByName("user_name")).String())
+
+	req, _ = http.NewRequest(http.MethodGet, "/?age=old", nil)
+	assert.EqualError(t, ProtoForm.Bind(req, dynamicpb.NewMessage(desc)), `field "age": strconv.ParseInt: parsing "old": invalid syntax`)
+	assert.Error(t, ProtoForm.Bind(req, &struct{}{}))
+}
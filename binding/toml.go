@@ -26,6 +26,10 @@ func (tomlBinding) BindBody(body []byte, obj any) error {
 	return decodeToml(bytes.NewReader(body), obj)
 }
 
+func (tomlBinding) decode(req *http.Request, obj any) error {
+	return decodeToml(req.Body, obj)
+}
+
 func decodeToml(r io.Reader, obj any) error {
 	decoder := toml.NewDecoder(r)
 	if err := decoder.Decode(obj); err != nil {
@@ -17,17 +17,24 @@ func (xmlBinding) Name() string {
 	return "xml"
 }
 
-func (xmlBinding) Bind(req *http.Request, obj any) error {
-	return decodeXML(req.Body, obj)
+func (b xmlBinding) Bind(req *http.Request, obj any) error {
+	if err := b.decode(req, obj); err != nil {
+		return err
+	}
+	return validate(obj)
 }
 
 func (xmlBinding) BindBody(body []byte, obj any) error {
-	return decodeXML(bytes.NewReader(body), obj)
-}
-func decodeXML(r io.Reader, obj any) error {
-	decoder := xml.NewDecoder(r)
-	if err := decoder.Decode(obj); err != nil {
+	if err := decodeXML(bytes.NewReader(body), obj); err != nil {
 		return err
 	}
 	return validate(obj)
 }
+
+func (xmlBinding) decode(req *http.Request, obj any) error {
+	return decodeXML(req.Body, obj)
+}
+func decodeXML(r io.Reader, obj any) error {
+	decoder := xml.NewDecoder(r)
+	return decoder.Decode(obj)
+}
@@ -18,18 +18,25 @@ func (yamlBinding) Name() string {
 	return "yaml"
 }
 
-func (yamlBinding) Bind(req *http.Request, obj any) error {
-	return decodeYAML(req.Body, obj)
+func (b yamlBinding) Bind(req *http.Request, obj any) error {
+	if err := b.decode(req, obj); err != nil {
+		return err
+	}
+	return validate(obj)
 }
 
 func (yamlBinding) BindBody(body []byte, obj any) error {
-	return decodeYAML(bytes.NewReader(body), obj)
+	if err := decodeYAML(bytes.NewReader(body), obj); err != nil {
+		return err
+	}
+	return validate(obj)
+}
+
+func (yamlBinding) decode(req *http.Request, obj any) error {
+	return decodeYAML(req.Body, obj)
 }
 
 func decodeYAML(r io.Reader, obj any) error {
 	decoder := yaml.NewDecoder(r)
-	if err := decoder.Decode(obj); err != nil {
-		return err
-	}
-	return validate(obj)
+	return decoder.Decode(obj)
 }
@@ -0,0 +1,88 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+const defaultBufferMemory = 1 << 20
+
+// RequestBuffering is how the request bodies forwarded to upstreams are
+// buffered, see RouteConfig.RequestBuffering.
+type RequestBuffering uint8
+
+const (
+	// BufferingInherit inherits the setting, streaming by default.
+	BufferingInherit RequestBuffering = iota
+	// BufferingStream streams the bodies through: they cannot be replayed, so
+	// the requests with a body are neither retried nor hedged.
+	BufferingStream
+	// BufferingMemory buffers the bodies up to RouteConfig.BufferMemory in
+	// memory, so that they can be replayed. The larger ones stream through.
+	BufferingMemory
+	// BufferingDisk buffers the bodies up to RouteConfig.BufferMemory in
+	// memory, and the larger ones in a temporary file removed once the request
+	// is served. Use RouteConfig.MaxBodyBytes to bound the files.
+	BufferingDisk
+)
+
+// bufferRequestBody buffers the request body to forward it to an upstream,
+// according to the buffering of the route. It returns the body to send, the
+// function replaying it and its size, or a nil function if it streams through.
+func (c *Context) bufferRequestBody() (io.Reader, func() (io.ReadCloser, error), int64, error) {
+	conf := c.RouteConfig()
+	body := c.Request.Body
+	if conf.RequestBuffering < BufferingMemory {
+		return body, nil, 0, nil
+	}
+	limit := conf.BufferMemory
+	if limit <= 0 {
+		limit = defaultBufferMemory
+	}
+	if conf.RequestBuffering == BufferingMemory && c.Request.ContentLength > limit {
+		return body, nil, 0, nil
+	}
+
+	buf, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if int64(len(buf)) <= limit {
+		return bytes.NewReader(buf), func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(buf)), nil
+		}, int64(len(buf)), nil
+	}
+	if conf.RequestBuffering == BufferingMemory {
+		return io.MultiReader(bytes.NewReader(buf), body), nil, 0, nil
+	}
+
+	f, err := os.CreateTemp("", "gin-body-*")
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	c.spools = append(c.spools, f)
+	size, err := io.Copy(f, io.MultiReader(bytes.NewReader(buf), body))
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return io.NewSectionReader(f, 0, size), func() (io.ReadCloser, error) {
+		return io.NopCloser(io.NewSectionReader(f, 0, size)), nil
+	}, size, nil
+}
+
+// removeSpools removes the temporary files of the request bodies buffered on
+// disk.
+func (c *Context) removeSpools() {
+	for _, f := range c.spools {
+		f.Close()
+		if err := os.Remove(f.Name()); err != nil {
+			debugPrint("cannot remove buffered request body: %v", err)
+		}
+	}
+	c.spools = nil
+}
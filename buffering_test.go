@@ -0,0 +1,87 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestBuffering(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if calls.Add(1)%2 != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, "%d %s", r.ContentLength, body)
+	}))
+	defer server.Close()
+
+	router := New()
+	u, err := router.AddUpstream("users", UpstreamConfig{
+		URL:   server.URL,
+		Retry: &RetryPolicy{NonIdempotent: true, Backoff: time.Millisecond},
+	})
+	require.NoError(t, err)
+	var spooled []string
+	proxy := func(c *Context) {
+		req, err := u.NewRequest(c, c.Request.Method, "/users", c.Request.Body)
+		require.NoError(t, err)
+		resp, err := u.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		spooled, _ = filepath.Glob(filepath.Join(tmp, "gin-body-*"))
+		c.DataFromReader(resp.StatusCode, resp.ContentLength, "text/plain", resp.Body, nil)
+	}
+	router.Configure(RouteConfig{BufferMemory: 4})
+	router.POST("/stream", proxy)
+	router.POST("/memory", proxy)
+	router.Route("/memory", http.MethodPost).Override(RouteConfig{RequestBuffering: BufferingMemory})
+	router.POST("/disk", proxy)
+	router.Route("/disk", http.MethodPost).Override(RouteConfig{RequestBuffering: BufferingDisk})
+
+	for _, tt := range []struct {
+		path, body string
+		code       int
+		response   string
+		spooled    int
+	}{
+		// the bodies streamed through are not retried
+		{"/stream", "abc", http.StatusServiceUnavailable, "", 0},
+		{"/memory", "abc", http.StatusOK, "3 abc", 0},
+		{"/memory", "abcdef", http.StatusServiceUnavailable, "", 0},
+		{"/disk", "abc", http.StatusOK, "3 abc", 0},
+		{"/disk", "abcdef", http.StatusOK, "6 abcdef", 1},
+	} {
+		calls.Store(0)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+		assert.Equal(t, tt.code, w.Code, tt.path+" "+tt.body)
+		assert.Equal(t, tt.response, w.Body.String(), tt.path+" "+tt.body)
+		assert.Len(t, spooled, tt.spooled, tt.path+" "+tt.body)
+	}
+
+	// the temporary files are removed once the requests are served
+	entries, err := os.ReadDir(tmp)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	assert.Equal(t, RetryStats{Requests: 5, Attempts: 8, Retries: 3}, u.RetryStats())
+}
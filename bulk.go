@@ -0,0 +1,75 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import "slices"
+
+// RouteSpec is a route registered by AddRoutes.
+type RouteSpec struct {
+	Method   string
+	Path     string
+	Handlers HandlersChain
+}
+
+// AddRoutes registers routes in bulk, as Handle does for each of them. It is
+// faster than registering the routes one by one for the large route sets,
+// such as the generated ones: the trees are ordered by priority once all the
+// routes are inserted, the memory of the routes is allocated at once, and the
+// route table is updated once while the engine serves requests:
+//
+//	specs := make([]gin.RouteSpec, 0, len(endpoints))
+//	for _, e := range endpoints {
+//	    specs = append(specs, gin.RouteSpec{Method: e.Method, Path: e.Path, Handlers: gin.HandlersChain{e.Handler}})
+//	}
+//	router.AddRoutes(specs)
+func (group *RouterGroup) AddRoutes(specs []RouteSpec) IRoutes {
+	engine := group.engine
+	size := 0
+	for _, spec := range specs {
+		if !regEnLetter.MatchString(spec.Method) {
+			panic("http method " + spec.Method + " is not valid")
+		}
+		n := len(group.Handlers) + len(spec.Handlers)
+		assert1(n < int(abortIndex), "too many handlers")
+		size += n
+	}
+	paths := make([]string, len(specs))
+	chains := make([]HandlersChain, len(specs))
+	handlers := make(HandlersChain, size)
+	for i, spec := range specs {
+		n := len(group.Handlers) + len(spec.Handlers)
+		chains[i], handlers = handlers[:n:n], handlers[n:]
+		copy(chains[i], group.Handlers)
+		copy(chains[i][len(group.Handlers):], spec.Handlers)
+		paths[i] = group.calculateAbsolutePath(spec.Path)
+		engine.checkRoute(group.host, spec.Method, paths[i], chains[i])
+	}
+
+	engine.update(func() {
+		roots := make(map[string]*node)
+		for i, spec := range specs {
+			roots[spec.Method] = engine.insertRoute(group.host, spec.Method, paths[i], chains[i], false)
+		}
+		for method, root := range roots {
+			root.sortChildren()
+			if engine.prioritized > 0 {
+				engine.prioritize(group.host, method)
+			}
+		}
+
+		slab := make([]Route, len(specs))
+		engine.routes = slices.Grow(engine.routes, len(specs))
+		if engine.routeIndex == nil {
+			engine.routeIndex = make(map[string]*Route, len(specs))
+		}
+		for i, spec := range specs {
+			route := &slab[i]
+			*route = Route{Method: spec.Method, Path: paths[i], Host: group.host, group: group}
+			engine.routes = append(engine.routes, route)
+			engine.routeIndex[routeKey(group.host, spec.Method, paths[i])] = route
+		}
+	})
+	return group.returnObj()
+}
@@ -0,0 +1,166 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// generatedRoutes returns n routes of a generated API.
+func generatedRoutes(n int) []RouteSpec {
+	specs := make([]RouteSpec, n)
+	for i := range specs {
+		svc := i % 97
+		var path string
+		switch i % 4 {
+		case 0:
+			path = fmt.Sprintf("/api/v%d/svc%d/res%d", i%3, svc, i)
+		case 1:
+			path = fmt.Sprintf("/api/v%d/svc%d/res%d/:id", i%3, svc, i)
+		case 2:
+			path = fmt.Sprintf("/api/v%d/svc%d/res%d/:id/items", i%3, svc, i)
+		default:
+			path = fmt.Sprintf("/static/svc%d/file%d", svc, i)
+		}
+		method := http.MethodGet
+		if i%5 == 0 {
+			method = http.MethodPost
+		}
+		specs[i] = RouteSpec{Method: method, Path: path, Handlers: HandlersChain{func(c *Context) {
+			c.String(http.StatusOK, "%s %s", c.FullPath(), c.Param("id"))
+		}}}
+	}
+	return specs
+}
+
+// treeShape describes the nodes of a tree with their priority, their children
+// in the order of their index.
+func treeShape(n *node) string {
+	children := make([]string, len(n.children))
+	for i, child := range n.children {
+		children[i] = treeShape(child)
+	}
+	slices.Sort(children[:len(n.indices)])
+	return fmt.Sprintf("%s:%d{%s}", n.path, n.priority, strings.Join(children, ","))
+}
+
+// assertChildrenSorted asserts that the indexed children of the nodes of the
+// tree are ordered by priority.
+func assertChildrenSorted(t *testing.T, n *node) {
+	for i := 1; i < len(n.indices); i++ {
+		assert.GreaterOrEqual(t, n.children[i-1].priority, n.children[i].priority, n.children[i].fullPath)
+	}
+	for _, child := range n.children {
+		assertChildrenSorted(t, child)
+	}
+}
+
+func TestAddRoutes(t *testing.T) {
+	specs := generatedRoutes(1000)
+	bulk := New()
+	v1 := bulk.Group("/v1")
+	v1.AddRoutes(specs)
+	for _, spec := range specs {
+		v1.Route(spec.Path, spec.Method).Tags("generated")
+	}
+	sequential := New()
+	for _, spec := range specs {
+		sequential.Group("/v1").Handle(spec.Method, spec.Path, spec.Handlers...)
+	}
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		root, want := bulk.trees.get(method), sequential.trees.get(method)
+		require.NotNil(t, root)
+		assert.Equal(t, treeShape(want), treeShape(root))
+		assertChildrenSorted(t, root)
+	}
+	for _, spec := range specs {
+		path := "/v1" + strings.Replace(spec.Path, ":id", "42", 1)
+		w := PerformRequest(bulk, spec.Method, path)
+		assert.Equal(t, PerformRequest(sequential, spec.Method, path).Body.String(), w.Body.String(), path)
+	}
+	assert.Equal(t, "/v1/api/v1/svc1/res1/:id 42", PerformRequest(bulk, http.MethodGet, "/v1/api/v1/svc1/res1/42").Body.String())
+
+	// the routes are kept in the order of the specs, with the annotations
+	require.Len(t, bulk.routes, len(specs))
+	for i, route := range bulk.routes {
+		assert.Equal(t, specs[i].Method, route.Method)
+		assert.Equal(t, "/v1"+specs[i].Path, route.Path)
+	}
+	for _, route := range bulk.Routes() {
+		assert.Equal(t, []string{"generated"}, route.Tags)
+	}
+}
+
+func TestAddRoutesPriority(t *testing.T) {
+	router := New()
+	router.GET("/:v", func(c *Context) { c.String(http.StatusOK, "page") })
+	router.Route("/:v", http.MethodGet).Priority(1)
+	router.AddRoutes([]RouteSpec{
+		{Method: http.MethodGet, Path: "/special", Handlers: HandlersChain{func(c *Context) { c.String(http.StatusOK, "special") }}},
+	})
+	assert.Equal(t, "page", PerformRequest(router, http.MethodGet, "/special").Body.String())
+}
+
+func TestAddRoutesServing(t *testing.T) {
+	router := New()
+	router.GET("/ping", func(c *Context) { c.String(http.StatusOK, "pong") })
+	assert.Equal(t, http.StatusOK, PerformRequest(router, http.MethodGet, "/ping").Code)
+
+	router.AddRoutes(generatedRoutes(100))
+	assert.Equal(t, http.StatusOK, PerformRequest(router, http.MethodGet, "/static/svc3/file3").Code)
+	assert.Len(t, router.Routes(), 101)
+
+	// a conflict registers none of the routes
+	assert.Panics(t, func() {
+		router.AddRoutes([]RouteSpec{
+			{Method: http.MethodGet, Path: "/new", Handlers: HandlersChain{handlerTest1}},
+			{Method: http.MethodGet, Path: "/ping", Handlers: HandlersChain{handlerTest1}},
+		})
+	})
+	assert.Equal(t, http.StatusNotFound, PerformRequest(router, http.MethodGet, "/new").Code)
+	assert.Len(t, router.Routes(), 101)
+}
+
+func TestAddRoutesInvalid(t *testing.T) {
+	router := New()
+	assert.PanicsWithValue(t, "http method get is not valid", func() {
+		router.AddRoutes([]RouteSpec{{Method: "get", Path: "/", Handlers: HandlersChain{handlerTest1}}})
+	})
+	assert.Panics(t, func() {
+		router.AddRoutes([]RouteSpec{{Method: http.MethodGet, Path: "/"}})
+	})
+	assert.Empty(t, router.Routes())
+}
+
+func BenchmarkAddRoutes(b *testing.B) {
+	SetMode(ReleaseMode)
+	defer SetMode(TestMode)
+	specs := generatedRoutes(50000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		New().AddRoutes(specs)
+	}
+}
+
+func BenchmarkAddRoutesOneByOne(b *testing.B) {
+	SetMode(ReleaseMode)
+	defer SetMode(TestMode)
+	specs := generatedRoutes(50000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		router := New()
+		for _, spec := range specs {
+			router.Handle(spec.Method, spec.Path, spec.Handlers...)
+		}
+	}
+}
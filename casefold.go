@@ -0,0 +1,208 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jialequ/mpgw/internal/bytesconv"
+)
+
+// CaseInsensitive sets whether the routes of the group and of its subgroups
+// match the request paths case-insensitively, such as "/API/Users" for the
+// route "/api/users". Unlike RedirectFixedPath, which applies to all the
+// routes, the request is served directly instead of being redirected. The
+// values of the parameters keep the case of the request path. Subgroups can
+// disable it again:
+//
+//	api := router.Group("/api").CaseInsensitive(true)
+//	api.GET("/users/:id", getUser)
+func (group *RouterGroup) CaseInsensitive(enabled bool) *RouterGroup {
+	group.caseInsensitive = &enabled
+	if enabled {
+		group.engine.caseInsensitive.Store(true)
+	}
+	return group
+}
+
+// matchesCaseInsensitive reports whether the group or its closest parent
+// setting it matches case-insensitively.
+func (group *RouterGroup) matchesCaseInsensitive() bool {
+	for ; group != nil; group = group.parent {
+		if group.caseInsensitive != nil {
+			return *group.caseInsensitive
+		}
+	}
+	return false
+}
+
+// serveCaseInsensitive serves the request with the route of the tree matching
+// its path case-insensitively, when the route is registered by a
+// case-insensitive group. It reports whether the request was served.
+func (engine *Engine) serveCaseInsensitive(c *Context, root *node, host, httpMethod, rPath string, unescape bool) bool {
+	fixedPath, ok := engine.fixPathCase(root, rPath, false)
+	if !ok {
+		return false
+	}
+	*c.params = (*c.params)[:0]
+	*c.skippedNodes = (*c.skippedNodes)[:0]
+	value := root.getValue(bytesconv.BytesToString(fixedPath), c.params, c.skippedNodes, unescape)
+	if value.handlers == nil {
+		return false
+	}
+	route := engine.table.Load().routeIndex[routeKey(host, httpMethod, value.fullPath)]
+	if route == nil || !route.group.matchesCaseInsensitive() {
+		return false
+	}
+	route.caseFixes.Add(1)
+	if value.params != nil {
+		c.Params = *value.params
+	}
+	c.routeHost = host
+	engine.serveRoute(c, httpMethod, value)
+	return true
+}
+
+// fixPathCase returns the path of the route of the tree matching path
+// case-insensitively, with Unicode case folding if enabled.
+func (engine *Engine) fixPathCase(root *node, path string, fixTrailingSlash bool) ([]byte, bool) {
+	if engine.UnicodeCaseFolding {
+		return root.findFoldedPath(path, fixTrailingSlash)
+	}
+	return root.findCaseInsensitivePath(path, fixTrailingSlash)
+}
+
+// foldEqual reports whether the runes are equal under Unicode case folding,
+// or have the same lower or upper case, such as the Turkish "ı" and "i".
+func foldEqual(a, b rune) bool {
+	if a == b || unicode.ToLower(a) == unicode.ToLower(b) || unicode.ToUpper(a) == unicode.ToUpper(b) {
+		return true
+	}
+	for f := unicode.SimpleFold(a); f != a; f = unicode.SimpleFold(f) {
+		if f == b {
+			return true
+		}
+	}
+	return false
+}
+
+// findFoldedPath is findCaseInsensitivePath with Unicode case folding: the
+// runes of path and of the routes are compared whole, so that the runes
+// whose encodings differ in length match.
+func (n *node) findFoldedPath(path string, fixTrailingSlash bool) ([]byte, bool) {
+	ciPath := n.findFoldedPathRec(0, "", path, make([]byte, 0, len(path)+1), fixTrailingSlash)
+	return ciPath, ciPath != nil
+}
+
+// findFoldedPathRec matches path against the static node from off on.
+// pending holds the first bytes of a rune of the route split across nodes.
+func (n *node) findFoldedPathRec(off int, pending, path string, ciPath []byte, fixTrailingSlash bool) []byte { // NOSONAR
+	for off < len(n.path) {
+		seg := pending + n.path[off:]
+		if !utf8.FullRuneInString(seg) {
+			// the rune continues in a child
+			for _, child := range n.children {
+				if child.nType == static {
+					if out := child.findFoldedPathRec(0, seg, path, ciPath, fixTrailingSlash); out != nil {
+						return out
+					}
+				}
+			}
+			return nil
+		}
+		want, size := utf8.DecodeRuneInString(seg)
+		got, gotSize := utf8.DecodeRuneInString(path)
+		if path == "" || !foldEqual(want, got) {
+			// Try to fix the path by adding a trailing slash
+			if fixTrailingSlash && path == "" && seg == "/" && n.handlers != nil {
+				return append(ciPath, '/')
+			}
+			return nil
+		}
+		ciPath = append(ciPath, seg[:size]...)
+		off += size - len(pending)
+		pending = ""
+		path = path[gotSize:]
+	}
+
+	if path == "" {
+		if n.handlers != nil {
+			return ciPath
+		}
+		if fixTrailingSlash {
+			for _, child := range n.children {
+				if (child.path == "/" && child.handlers != nil) ||
+					(child.nType == catchAll && child.children[0].handlers != nil && child.children[0].accepts("/")) {
+					return append(ciPath, '/')
+				}
+			}
+		}
+		return nil
+	}
+
+	children := n.children
+	if n.wildChild {
+		children = children[:len(children)-1]
+	}
+	for _, child := range children {
+		if out := child.findFoldedPathRec(0, "", path, ciPath, fixTrailingSlash); out != nil {
+			return out
+		}
+	}
+	if n.wildChild {
+		if out := n.children[len(n.children)-1].findFoldedWildcard(path, ciPath, fixTrailingSlash); out != nil {
+			return out
+		}
+	}
+
+	// Try to fix the path by removing its trailing slash
+	if fixTrailingSlash && path == "/" && n.handlers != nil {
+		return ciPath
+	}
+	return nil
+}
+
+// findFoldedWildcard matches path against the wildcard node, whose values
+// keep the case of the path.
+func (n *node) findFoldedWildcard(path string, ciPath []byte, fixTrailingSlash bool) []byte {
+	if n.nType == catchAll {
+		if !n.accepts(path) {
+			return nil
+		}
+		return append(ciPath, path...)
+	}
+
+	end := strings.IndexByte(path, '/')
+	if end < 0 {
+		end = len(path)
+	}
+	value, rest := path[:end], path[end:]
+	for ; n != nil; n = n.alt {
+		if !n.accepts(value) {
+			continue
+		}
+		if rest == "" {
+			if n.handlers != nil {
+				return append(ciPath, value...)
+			}
+			if fixTrailingSlash && len(n.children) == 1 && n.children[0].path == "/" && n.children[0].handlers != nil {
+				return append(append(ciPath, value...), '/')
+			}
+			continue
+		}
+		if len(n.children) > 0 {
+			if out := n.children[0].findFoldedPathRec(0, "", rest, append(ciPath, value...), fixTrailingSlash); out != nil {
+				return out
+			}
+		}
+		// Try to fix the path by removing its trailing slash
+		if fixTrailingSlash && rest == "/" && n.handlers != nil {
+			return append(ciPath, value...)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterGroupCaseInsensitive(t *testing.T) {
+	router := New()
+	router.GET("/Public/Info", handlerTest1)
+	api := router.Group("/api").CaseInsensitive(true)
+	api.GET("/users/:id", func(c *Context) { c.String(http.StatusOK, c.FullPath()+" "+c.Param("id")) })
+	api.Group("/strict").CaseInsensitive(false).GET("/items", handlerTest1)
+	router.Host("api.example.com").CaseInsensitive(true).GET("/Items", handlerTest1)
+
+	w := PerformRequest(router, http.MethodGet, "/API/Users/AbC")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "/api/users/:id AbC", w.Body.String())
+	assert.Equal(t, http.StatusOK, PerformRequest(router, http.MethodGet, "/api/users/1").Code)
+
+	assert.Equal(t, http.StatusNotFound, PerformRequest(router, http.MethodGet, "/public/info").Code)
+	assert.Equal(t, http.StatusNotFound, PerformRequest(router, http.MethodGet, "/api/STRICT/items").Code)
+	assert.Equal(t, http.StatusOK, PerformRequest(router, http.MethodGet, "/api/strict/items").Code)
+	assert.Equal(t, http.StatusNotFound, PerformRequest(router, http.MethodPost, "/API/users/1").Code)
+
+	assert.Equal(t, http.StatusOK, performHostRequest(router, http.MethodGet, "api.example.com", "/ITEMS").Code)
+	assert.Equal(t, http.StatusNotFound, performHostRequest(router, http.MethodGet, "other.org", "/ITEMS").Code)
+
+	// the case-insensitive routes are served directly, the other ones redirected
+	router.RedirectFixedPath = true
+	assert.Equal(t, http.StatusOK, PerformRequest(router, http.MethodGet, "/API/USERS/1").Code)
+	w = PerformRequest(router, http.MethodGet, "/public/info")
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/Public/Info", w.Header().Get("Location"))
+}
+
+func TestTreeFindFoldedPath(t *testing.T) {
+	tree := &node{}
+	routes := []string{
+		"/hi", "/b/", "/ABC/", "/search/:query", "/cmd/:tool/", "/src/*filepath",
+		"/x", "/x/y", "/y/", "/y/z", "/0/:id", "/0/:id/1", "/1/:id/", "/1/:id/2",
+		"/aa", "/a/", "/doc", "/doc/go_faq.html", "/doc/go/away", "/no/a", "/no/b",
+		"/Π", "/u/apfêl/", "/u/äpfêl/", "/u/öpfêl", "/v/Äpfêl/", "/v/Öpfêl",
+		"/w/♬", "/w/♭/", "/w/𠜎", "/w/𠜏/",
+		"/größe", "/unit/k", "/ıstanbul/:district", "/straẞe/", "/ä", "/ö",
+	}
+	for _, route := range routes {
+		tree.addRoute(route, fakeHandler(route))
+	}
+
+	// the folded lookup finds the paths found by the byte-wise one
+	for _, route := range routes {
+		for _, in := range []string{route, strings.ToUpper(route), strings.ToLower(route), route + "/", strings.TrimSuffix(route, "/")} {
+			for _, fix := range []bool{true, false} {
+				want, wantFound := tree.findCaseInsensitivePath(in, fix)
+				if !wantFound {
+					continue
+				}
+				out, found := tree.findFoldedPath(in, fix)
+				assert.True(t, found, in)
+				assert.Equal(t, string(want), string(out), in)
+			}
+		}
+	}
+
+	for _, tt := range []struct {
+		in, out string
+		fix     bool
+	}{
+		// multi-byte folds changing the length of the path
+		{"/GRÖẞE", "/größe", false},
+		{"/GRÖSSE", "", false},
+		{"/unit/K", "/unit/k", false},
+		{"/STRASSE/", "", false},
+		{"/STRAßE", "/straẞe/", true},
+		{"/ISTANBUL/Kadıköy", "/ıstanbul/Kadıköy", false},
+		{"/istanbul/Kadıköy/", "/ıstanbul/Kadıköy", true},
+		// runes of the routes split across nodes
+		{"/Ä", "/ä", false},
+		{"/Ö/", "/ö", true},
+		{"/SRC/Some/File", "/src/Some/File", false},
+		{"/NO", "", true},
+	} {
+		out, found := tree.findFoldedPath(tt.in, tt.fix)
+		assert.Equal(t, tt.out != "", found, tt.in)
+		assert.Equal(t, tt.out, string(out), tt.in)
+	}
+}
+
+func TestEngineUnicodeCaseFolding(t *testing.T) {
+	router := New()
+	router.RedirectFixedPath = true
+	router.GET("/größe", handlerTest1)
+	router.Group("/api").CaseInsensitive(true).GET("/straße/:id", func(c *Context) {
+		c.String(http.StatusOK, c.FullPath()+" "+c.Param("id"))
+	})
+
+	assert.Equal(t, http.StatusNotFound, PerformRequest(router, http.MethodGet, "/GRÖẞE").Code)
+	assert.Equal(t, http.StatusNotFound, PerformRequest(router, http.MethodGet, "/API/STRAẞE/Ab").Code)
+
+	router.UnicodeCaseFolding = true
+	w := PerformRequest(router, http.MethodGet, "/GRÖẞE")
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/gr%C3%B6%C3%9Fe", w.Header().Get("Location"))
+	w = PerformRequest(router, http.MethodGet, "/API/STRAẞE/Ab")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "/api/straße/:id Ab", w.Body.String())
+	assert.True(t, router.Clone().UnicodeCaseFolding)
+}
+
+func TestFoldEqual(t *testing.T) {
+	for _, pair := range [][2]rune{{'a', 'A'}, {'ß', 'ẞ'}, {'k', 'K'}, {'s', 'ſ'}, {'ı', 'I'}, {'İ', 'i'}, {'θ', 'ϑ'}} {
+		assert.True(t, foldEqual(pair[0], pair[1]), string(pair[:]))
+		assert.True(t, foldEqual(pair[1], pair[0]), string(pair[:]))
+	}
+	assert.False(t, foldEqual('a', 'b'))
+	assert.False(t, foldEqual('ß', 's'))
+}
@@ -0,0 +1,137 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Route metadata keys read by ChangeEvents, see RouteHandle.Meta.
+const (
+	// MetaEntity is the name of the entity changed by a route, such as "user".
+	MetaEntity = "entity"
+	// MetaEntityID is the name of the path param identifying the changed
+	// entity. Default value is "id".
+	MetaEntityID = "entity.id"
+)
+
+// ChangeEvent describes a change made by a successful mutating request, as
+// published by ChangeEvents.
+type ChangeEvent struct {
+	Method string
+	// Route is the route matched by the request, and Host its host pattern
+	// registered with Engine.Host, if any.
+	Route string
+	Host  string
+	Path  string
+	// Params are the path params of the request.
+	Params Params
+	Status int
+	// RequestID is the request id of the request, empty if it has none.
+	RequestID string
+	// Entity and EntityID identify the changed entity, from the MetaEntity
+	// and MetaEntityID metadata of the route. Empty if the route has none.
+	Entity   string
+	EntityID string
+	// Meta is the metadata of the route. It must not be modified.
+	Meta map[string]any
+	Time time.Time
+}
+
+// ChangePublisher publishes the change events to a message broker, an outbox
+// table or a change-data-capture pipeline.
+type ChangePublisher interface {
+	PublishChange(ctx context.Context, e ChangeEvent) error
+}
+
+// ChangePublisherFunc is a function publishing change events.
+type ChangePublisherFunc func(ctx context.Context, e ChangeEvent) error
+
+// PublishChange calls f(ctx, e).
+func (f ChangePublisherFunc) PublishChange(ctx context.Context, e ChangeEvent) error {
+	return f(ctx, e)
+}
+
+// ChangeEventsConfig defines the config of the ChangeEvents middleware.
+type ChangeEventsConfig struct {
+	// Publisher publishes the change events. Required.
+	Publisher ChangePublisher
+
+	// RequestIDHeader is the header carrying the request id, read from the
+	// response, then from the request. Optional. Default value is
+	// "X-Request-Id".
+	RequestIDHeader string
+
+	// OnError is called when the publisher fails. The response has already
+	// been written. Optional. Default value logs the error in debug mode.
+	OnError func(c *Context, e ChangeEvent, err error)
+}
+
+// ChangeEvents returns a middleware publishing a ChangeEvent after each
+// successful mutating request, that is a POST, PUT, PATCH or DELETE request
+// answered with a 2xx status, for change-data-capture integrations at the
+// edge. The routes name the entities they change with metadata:
+//
+//	router.Use(gin.ChangeEvents(gin.ChangeEventsConfig{Publisher: outbox}))
+//	router.PUT("/users/:uid", updateUser)
+//	router.Route("/users/:uid").Meta(gin.MetaEntity, "user").Meta(gin.MetaEntityID, "uid")
+//
+// The event is published in the request goroutine, once the handlers have
+// returned, with the context of the request: slow publishers should queue the
+// events. The hijacked requests are not published.
+func ChangeEvents(conf ChangeEventsConfig) HandlerFunc {
+	assert1(conf.Publisher != nil, "change events publisher can not be nil")
+	if conf.RequestIDHeader == "" {
+		conf.RequestIDHeader = "X-Request-Id"
+	}
+	if conf.OnError == nil {
+		conf.OnError = func(c *Context, e ChangeEvent, err error) {
+			debugPrint("[WARNING] Publishing the change of %s %s failed: %v\n", e.Method, e.Path, err)
+		}
+	}
+	return func(c *Context) {
+		c.Next()
+		status := c.Writer.Status()
+		if !isMutatingMethod(c.Request.Method) || c.hijacked || status < 200 || status > 299 {
+			return
+		}
+		e := ChangeEvent{
+			Method:    c.Request.Method,
+			Route:     c.FullPath(),
+			Host:      c.routeHost,
+			Path:      c.Request.URL.Path,
+			Params:    append(Params(nil), c.Params...),
+			Status:    status,
+			RequestID: c.Writer.Header().Get(conf.RequestIDHeader),
+			Meta:      c.RouteMeta(),
+			Time:      time.Now(),
+		}
+		if e.RequestID == "" {
+			e.RequestID = c.requestHeader(conf.RequestIDHeader)
+		}
+		if entity, ok := e.Meta[MetaEntity].(string); ok {
+			e.Entity = entity
+			idParam, ok := e.Meta[MetaEntityID].(string)
+			if !ok {
+				idParam = "id"
+			}
+			e.EntityID = c.Param(idParam)
+		}
+		if err := conf.Publisher.PublishChange(c.Request.Context(), e); err != nil {
+			conf.OnError(c, e, err)
+		}
+	}
+}
+
+// isMutatingMethod reports whether method changes resources.
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
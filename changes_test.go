@@ -0,0 +1,79 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeEvents(t *testing.T) {
+	var events []ChangeEvent
+	router := New()
+	router.Use(ChangeEvents(ChangeEventsConfig{
+		Publisher: ChangePublisherFunc(func(_ context.Context, e ChangeEvent) error {
+			events = append(events, e)
+			return nil
+		}),
+	}))
+	router.PUT("/users/:uid", func(c *Context) {
+		c.Header("X-Request-Id", "req-1")
+		c.Status(http.StatusNoContent)
+	})
+	router.Route("/users/:uid", http.MethodPut).Meta(MetaEntity, "user").Meta(MetaEntityID, "uid")
+	router.DELETE("/orders/:id", func(c *Context) { c.Status(http.StatusOK) })
+	router.Route("/orders/:id", http.MethodDelete).Meta(MetaEntity, "order")
+	router.POST("/login", func(c *Context) { c.Status(http.StatusOK) })
+	router.POST("/fail", func(c *Context) { c.Status(http.StatusConflict) })
+	router.GET("/users/:uid", func(c *Context) { c.Status(http.StatusOK) })
+
+	PerformRequest(router, http.MethodPut, "/users/42")
+	PerformRequest(router, http.MethodDelete, "/orders/7", header{"X-Request-Id", "req-2"})
+	PerformRequest(router, http.MethodPost, "/login")
+	PerformRequest(router, http.MethodPost, "/fail")
+	PerformRequest(router, http.MethodGet, "/users/42")
+
+	if assert.Len(t, events, 3) {
+		e := events[0]
+		assert.Equal(t, http.MethodPut, e.Method)
+		assert.Equal(t, "/users/:uid", e.Route)
+		assert.Equal(t, "/users/42", e.Path)
+		assert.Equal(t, Params{{Key: "uid", Value: "42"}}, e.Params)
+		assert.Equal(t, http.StatusNoContent, e.Status)
+		assert.Equal(t, "req-1", e.RequestID)
+		assert.Equal(t, "user", e.Entity)
+		assert.Equal(t, "42", e.EntityID)
+		assert.False(t, e.Time.IsZero())
+
+		assert.Equal(t, "order", events[1].Entity)
+		assert.Equal(t, "7", events[1].EntityID)
+		assert.Equal(t, "req-2", events[1].RequestID)
+
+		assert.Equal(t, "/login", events[2].Route)
+		assert.Empty(t, events[2].Entity)
+		assert.Nil(t, events[2].Meta)
+	}
+}
+
+func TestChangeEventsPublishError(t *testing.T) {
+	errBroker := errors.New("broker down")
+	var failed error
+	router := New()
+	router.Use(ChangeEvents(ChangeEventsConfig{
+		Publisher: ChangePublisherFunc(func(context.Context, ChangeEvent) error { return errBroker }),
+		OnError:   func(_ *Context, _ ChangeEvent, err error) { failed = err },
+	}))
+	router.POST("/users", func(c *Context) { c.Status(http.StatusCreated) })
+
+	w := PerformRequest(router, http.MethodPost, "/users")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, errBroker, failed)
+
+	assert.Panics(t, func() { ChangeEvents(ChangeEventsConfig{}) })
+}
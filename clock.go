@@ -0,0 +1,191 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Clock is a time source, see Engine.Clock.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// AfterFunc calls f once d elapsed, like time.AfterFunc.
+	AfterFunc(d time.Duration, f func()) ClockTimer
+}
+
+// ClockTimer is a timer started by Clock.AfterFunc.
+type ClockTimer interface {
+	// Stop prevents the timer from firing. It returns false if the timer
+	// already fired or was stopped.
+	Stop() bool
+}
+
+// systemClock is the clock of the time package.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) ClockTimer {
+	return time.AfterFunc(d, f)
+}
+
+// clock returns the time source of the engine.
+func (engine *Engine) clock() Clock {
+	if engine.Clock == nil {
+		return systemClock{}
+	}
+	return engine.Clock
+}
+
+// now returns the current time of the engine clock.
+func (engine *Engine) now() time.Time {
+	return engine.clock().Now()
+}
+
+// now returns the current time of the engine clock, or of the system clock if
+// the context has no engine.
+func (c *Context) now() time.Time {
+	if c.engine == nil {
+		return time.Now()
+	}
+	return c.engine.now()
+}
+
+// withTimeout returns a copy of parent canceled with cause, or
+// context.DeadlineExceeded if nil, once d elapsed on the engine clock.
+func (engine *Engine) withTimeout(parent context.Context, d time.Duration, cause error) (context.Context, context.CancelFunc) {
+	if engine.Clock == nil {
+		return context.WithTimeoutCause(parent, d, cause)
+	}
+	if cause == nil {
+		cause = context.DeadlineExceeded
+	}
+	deadline := engine.Clock.Now().Add(d)
+	if parentDeadline, ok := parent.Deadline(); ok && parentDeadline.Before(deadline) {
+		deadline = parentDeadline
+	}
+	inner, cancel := context.WithCancelCause(parent)
+	ctx := &clockContext{Context: inner, deadline: deadline}
+	timer := engine.Clock.AfterFunc(d, func() {
+		ctx.expired.Store(true)
+		cancel(cause)
+	})
+	return ctx, func() {
+		timer.Stop()
+		cancel(context.Canceled)
+	}
+}
+
+// clockContext is a context with a deadline on an engine clock.
+type clockContext struct {
+	context.Context
+	deadline time.Time
+	expired  atomic.Bool
+}
+
+func (ctx *clockContext) Deadline() (time.Time, bool) {
+	return ctx.deadline, true
+}
+
+func (ctx *clockContext) Err() error {
+	err := ctx.Context.Err()
+	if err != nil && ctx.expired.Load() {
+		return context.DeadlineExceeded
+	}
+	return err
+}
+
+// FakeClock is a Clock for tests, whose time only moves with Advance:
+//
+//	clock := gin.NewFakeClock(time.Now())
+//	router.Clock = clock
+//	...
+//	clock.Advance(time.Minute)
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFakeClock returns a fake clock set to now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the time of the clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// AfterFunc calls f once the clock advanced by d, on the goroutine calling
+// Advance.
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) ClockTimer {
+	c.mu.Lock()
+	t := &fakeTimer{clock: c, at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+	c.mu.Unlock()
+	if d <= 0 {
+		c.Advance(0)
+	}
+	return t
+}
+
+// Advance moves the clock forward by d, calling the functions of the timers
+// due in order before returning.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	end := c.now.Add(d)
+	for {
+		next := -1
+		for i, t := range c.timers {
+			if !t.at.After(end) && (next < 0 || t.at.Before(c.timers[next].at)) {
+				next = i
+			}
+		}
+		if next < 0 {
+			break
+		}
+		t := c.timers[next]
+		c.timers = append(c.timers[:next], c.timers[next+1:]...)
+		if t.at.After(c.now) {
+			c.now = t.at
+		}
+		c.mu.Unlock()
+		t.f()
+		c.mu.Lock()
+	}
+	if end.After(c.now) {
+		c.now = end
+	}
+	c.mu.Unlock()
+}
+
+// fakeTimer is a timer of a FakeClock.
+type fakeTimer struct {
+	clock *FakeClock
+	at    time.Time
+	f     func()
+}
+
+func (t *fakeTimer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, other := range c.timers {
+		if other == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	var fired []time.Time
+	record := func() { fired = append(fired, clock.Now()) }
+	clock.AfterFunc(2*time.Second, record)
+	clock.AfterFunc(time.Second, record)
+	stopped := clock.AfterFunc(time.Second, record)
+	assert.True(t, stopped.Stop())
+	assert.False(t, stopped.Stop())
+
+	clock.Advance(500 * time.Millisecond)
+	assert.Empty(t, fired)
+	clock.Advance(2 * time.Second)
+	assert.Equal(t, []time.Time{start.Add(time.Second), start.Add(2 * time.Second)}, fired)
+	assert.Equal(t, start.Add(2500*time.Millisecond), clock.Now())
+}
+
+func TestEngineClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	router := New()
+	router.Clock = clock
+
+	var logged LogFormatterParams
+	router.Use(LoggerWithConfig(LoggerConfig{
+		Output:    io.Discard,
+		Formatter: func(p LogFormatterParams) string { logged = p; return "" },
+	}))
+	var deadline time.Time
+	var ctxErr error
+	router.GET("/slow", func(c *Context) {
+		deadline, _ = c.Request.Context().Deadline()
+		clock.Advance(3 * time.Second)
+		<-c.Request.Context().Done()
+		ctxErr = c.Request.Context().Err()
+	})
+	router.Route("/slow", http.MethodGet).Timeout(time.Second)
+	router.GET("/configured", func(c *Context) {
+		clock.Advance(time.Minute)
+		ctxErr = c.Request.Context().Err()
+		c.Status(http.StatusNoContent)
+	})
+	router.Route("/configured", http.MethodGet).Override(RouteConfig{Timeout: time.Minute})
+
+	w := PerformRequest(router, http.MethodGet, "/slow")
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.Equal(t, start.Add(time.Second), deadline)
+	assert.ErrorIs(t, ctxErr, context.DeadlineExceeded)
+	assert.Equal(t, 3*time.Second, logged.Latency)
+	assert.Equal(t, start.Add(3*time.Second), logged.TimeStamp)
+
+	w = PerformRequest(router, http.MethodGet, "/configured")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.ErrorIs(t, ctxErr, context.DeadlineExceeded)
+	assert.Equal(t, time.Minute, logged.Latency)
+
+	// the cached decisions expire on the engine clock
+	calls := 0
+	auth := NewAuthCache(func(*Context, string) (any, error) {
+		calls++
+		return "user", nil
+	}, AuthCacheConfig{TTL: time.Minute})
+	router.GET("/me", auth.Handler(), func(c *Context) { c.String(http.StatusOK, c.GetString(AuthUserKey)) })
+	for _, advance := range []time.Duration{0, 30 * time.Second, 30 * time.Second} {
+		clock.Advance(advance)
+		w = PerformRequest(router, http.MethodGet, "/me", header{"Authorization", "Bearer token"})
+		assert.Equal(t, "user", w.Body.String())
+	}
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, clock, router.Clone().Clock)
+}
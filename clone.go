@@ -0,0 +1,251 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"html/template"
+	"net"
+
+	"github.com/jialequ/mpgw/render"
+)
+
+// Clone returns a deep copy of the engine: its settings, route trees, routes
+// and the groups they were registered with can be changed without affecting
+// the engine, to isolate tests or to build the next generation of a config,
+// validate it and serve it with Swap:
+//
+//	next := router.Clone()
+//	next.GET("/v2/users", listUsersV2)
+//	if err := next.Validate(); err != nil {
+//	    return err
+//	}
+//	router.Swap(next)
+//
+// The handlers are shared, as are the connection registry, the connection
+// metrics, the event bus, the wide events, the cookie keys and the upstreams,
+// which follow the connections and requests across the generations. The route
+// statistics and the usage of the deprecated routes start afresh. The groups
+// held by the caller still register their routes on the engine: the routes of
+// the clone are registered with its own groups, such as the one embedded in
+// it.
+func (engine *Engine) Clone() *Engine {
+	clone := &Engine{
+		RedirectTrailingSlash:  engine.RedirectTrailingSlash,
+		RedirectFixedPath:      engine.RedirectFixedPath,
+		UnicodeCaseFolding:     engine.UnicodeCaseFolding,
+		HandleMethodNotAllowed: engine.HandleMethodNotAllowed,
+		HandleHeadWithGet:      engine.HandleHeadWithGet,
+		HandleOPTIONS:          engine.HandleOPTIONS,
+		ForwardedByClientIP:    engine.ForwardedByClientIP,
+		AppEngine:              engine.AppEngine,
+		UseRawPath:             engine.UseRawPath,
+		UnescapePathValues:     engine.UnescapePathValues,
+		RemoveExtraSlash:       engine.RemoveExtraSlash,
+		RemoteIPHeaders:        append([]string(nil), engine.RemoteIPHeaders...),
+		TrustedPlatform:        engine.TrustedPlatform,
+		MaxMultipartMemory:     engine.MaxMultipartMemory,
+		UseH2C:                 engine.UseH2C,
+		ContextWithFallback:    engine.ContextWithFallback,
+		HTTPHardening:          engine.HTTPHardening,
+		MaxPathLength:          engine.MaxPathLength,
+		MaxRouteParams:         engine.MaxRouteParams,
+		MaxTreeDepth:           engine.MaxTreeDepth,
+		HTMLBufferSize:         engine.HTMLBufferSize,
+		TimeRequests:           engine.TimeRequests,
+		Sandbox:                engine.Sandbox,
+		Clock:                  engine.Clock,
+
+		delims:           engine.delims,
+		secureJSONPrefix: engine.secureJSONPrefix,
+		HTMLRender:       engine.HTMLRender,
+		FuncMap:          make(template.FuncMap, len(engine.FuncMap)),
+		allNoRoute:       engine.allNoRoute,
+		allNoMethod:      engine.allNoMethod,
+		noRoute:          engine.noRoute,
+		noMethod:         engine.noMethod,
+		trustedProxies:   append([]string(nil), engine.trustedProxies...),
+		trustedCIDRs:     append([]*net.IPNet(nil), engine.trustedCIDRs...),
+		platform:         engine.platform,
+		errorMapping:     engine.errorMapping,
+
+		checks: append([]engineCheck(nil), engine.checks...),
+	}
+	for name, fn := range engine.FuncMap {
+		clone.FuncMap[name] = fn
+	}
+	if engine.constraints != nil {
+		clone.constraints = make(map[string]func(string) bool, len(engine.constraints))
+		for name, fn := range engine.constraints {
+			clone.constraints[name] = fn
+		}
+	}
+	if engine.formats != nil {
+		clone.formats = make(map[string]func(data any) render.Render, len(engine.formats))
+		for mimeType, fn := range engine.formats {
+			clone.formats[mimeType] = fn
+		}
+	}
+	clone.routeConfigured.Store(engine.routeConfigured.Load())
+	clone.caseInsensitive.Store(engine.caseInsensitive.Load())
+	clone.pool.New = func() any {
+		return clone.allocateContext(clone.table.Load().maxParams)
+	}
+
+	clone.connectionsOnce.Do(func() { clone.connections = engine.Connections() })
+	clone.connMetrics.Store(engine.connMetrics.Load())
+	clone.events.Store(engine.events.Load())
+	clone.wideEvents.Store(engine.wideEvents.Load())
+	clone.cookieKeys.Store(engine.cookieKeys.Load())
+	engine.upstreamsMu.RLock()
+	if engine.upstreams != nil {
+		clone.upstreams = make(map[string]*Upstream, len(engine.upstreams))
+		for name, u := range engine.upstreams {
+			clone.upstreams[name] = u
+		}
+	}
+	engine.upstreamsMu.RUnlock()
+
+	groups := map[*RouterGroup]*RouterGroup{&engine.RouterGroup: &clone.RouterGroup}
+	clone.RouterGroup = engine.RouterGroup.copy(clone, groups)
+
+	t := engine.table.Load()
+	clone.routeTable = t.copy()
+	routes := make(map[*Route]*Route, len(t.routes))
+	for i, route := range t.routes {
+		cp := route.clone(clone, groups)
+		routes[route] = cp
+		clone.routes[i] = cp
+	}
+	clone.disabledRoutes.Store(engine.disabledRoutes.Load())
+	clone.routeIndex = cloneRouteMap(t.routeIndex, routes)
+	clone.namedRoutes = cloneRouteMap(t.namedRoutes, routes)
+	clone.deprecated = cloneRouteMap(t.deprecated, routes)
+	clone.table.Store(clone.routeTable)
+	return clone
+}
+
+// copy returns a copy of the group for the clone, its parents being copied
+// once through groups.
+func (group *RouterGroup) copy(clone *Engine, groups map[*RouterGroup]*RouterGroup) RouterGroup {
+	cp := RouterGroup{
+		Handlers:      group.Handlers,
+		basePath:      group.basePath,
+		engine:        clone,
+		root:          group.root,
+		host:          group.host,
+		parent:        group.parent.cloneFor(clone, groups),
+		trailingSlash: group.trailingSlash,
+	}
+	if group.config != nil {
+		conf := group.config.clone()
+		cp.config = &conf
+	}
+	if group.caseInsensitive != nil {
+		enabled := *group.caseInsensitive
+		cp.caseInsensitive = &enabled
+	}
+	return cp
+}
+
+// cloneFor returns the copy of the group for the clone, nil for nil.
+func (group *RouterGroup) cloneFor(clone *Engine, groups map[*RouterGroup]*RouterGroup) *RouterGroup {
+	if group == nil {
+		return nil
+	}
+	if cp, ok := groups[group]; ok {
+		return cp
+	}
+	cp := new(RouterGroup)
+	groups[group] = cp
+	*cp = group.copy(clone, groups)
+	return cp
+}
+
+// clone returns a copy of the config, with its own values.
+func (conf RouteConfig) clone() RouteConfig {
+	if conf.Values != nil {
+		values := make(map[string]any, len(conf.Values))
+		for k, v := range conf.Values {
+			values[k] = v
+		}
+		conf.Values = values
+	}
+	return conf
+}
+
+// clone returns a copy of the route for the clone, registered with the copy
+// of its group.
+func (route *Route) clone(clone *Engine, groups map[*RouterGroup]*RouterGroup) *Route {
+	cp := &Route{
+		Method:      route.Method,
+		Path:        route.Path,
+		Host:        route.Host,
+		Name:        route.Name,
+		Description: route.Description,
+		Tags:        append([]string(nil), route.Tags...),
+		bodyType:    route.bodyType,
+		bodyBinding: route.bodyBinding,
+		responses:   append([]RouteResponse(nil), route.responses...),
+		group:       route.group.cloneFor(clone, groups),
+		timeout:     route.timeout,
+		priority:    route.priority,
+		mock:        route.mock,
+	}
+	if route.Deprecation != nil {
+		d := route.Deprecation
+		cp.Deprecation = &RouteDeprecation{Sunset: d.Sunset, Message: d.Message, link: d.link}
+	}
+	if route.meta != nil {
+		cp.meta = make(map[string]any, len(route.meta))
+		for k, v := range route.meta {
+			cp.meta[k] = v
+		}
+	}
+	if route.override != nil {
+		conf := route.override.clone()
+		cp.override = &conf
+	}
+	cp.disabled.Store(route.disabled.Load())
+	cp.disabledUntil.Store(route.disabledUntil.Load())
+	return cp
+}
+
+// cloneRouteMap returns a copy of an index of routes, with their copies.
+func cloneRouteMap(index map[string]*Route, routes map[*Route]*Route) map[string]*Route {
+	if index == nil {
+		return nil
+	}
+	cp := make(map[string]*Route, len(index))
+	for key, route := range index {
+		cp[key] = routes[route]
+	}
+	return cp
+}
+
+// clone returns a deep copy of the trees. The handlers chains, never changed
+// once registered, are shared.
+func (trees methodTrees) clone() methodTrees {
+	cp := make(methodTrees, len(trees), cap(trees))
+	for i, tree := range trees {
+		cp[i] = methodTree{method: tree.method, root: tree.root.clone()}
+	}
+	return cp
+}
+
+// clone returns a deep copy of the node, its children and alternatives.
+func (n *node) clone() *node {
+	if n == nil {
+		return nil
+	}
+	cp := *n
+	if n.children != nil {
+		cp.children = make([]*node, len(n.children))
+		for i, child := range n.children {
+			cp.children[i] = child.clone()
+		}
+	}
+	cp.alt = n.alt.clone()
+	return &cp
+}
@@ -0,0 +1,56 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngineClone(t *testing.T) {
+	router := New()
+	router.Configure(RouteConfig{Timeout: time.Second})
+	api := router.Group("/api")
+	api.Configure(RouteConfig{MaxBodyBytes: 10})
+	api.GET("/users", handlerTest1)
+	api.Route("/users", http.MethodGet).Name("users")
+	router.Host("*.example.com").GET("/tenant", handlerTest1)
+
+	clone := router.Clone()
+	clone.GET("/api/usage", handlerTest1)
+	clone.Configure(RouteConfig{Timeout: 2 * time.Second})
+	assert.NoError(t, clone.DisableRoute(http.MethodGet, "/api/users", http.StatusServiceUnavailable))
+
+	// the routes of the clone do not leak into the engine
+	assert.Len(t, router.Routes(), 2)
+	assert.Len(t, clone.Routes(), 3)
+	w := PerformRequest(router, http.MethodGet, "/api/usage")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = PerformRequest(router, http.MethodGet, "/api/users")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = PerformRequest(clone, http.MethodGet, "/api/usage")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = PerformRequest(clone, http.MethodGet, "/api/users")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	w = performHostRequest(clone, http.MethodGet, "acme.example.com", "/tenant")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	conf, _ := router.ResolveRouteConfig(http.MethodGet, "/api/users")
+	assert.Equal(t, RouteConfig{Timeout: time.Second, MaxBodyBytes: 10}, conf)
+	conf, _ = clone.ResolveRouteConfig(http.MethodGet, "/api/users")
+	assert.Equal(t, RouteConfig{Timeout: 2 * time.Second, MaxBodyBytes: 10}, conf)
+	path, err := clone.PathBuilder("users").Build()
+	assert.NoError(t, err)
+	assert.Equal(t, "/api/users", path)
+
+	// the groups held by the caller keep registering on the engine
+	api.GET("/admins", handlerTest1)
+	w = PerformRequest(clone, http.MethodGet, "/api/admins")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// LastModifiedFunc returns when the resource of a request was last modified,
+// zero if unknown.
+type LastModifiedFunc func(c *Context) (time.Time, error)
+
+// Conditional returns a middleware answering the conditional GET and HEAD
+// requests whose resource was not modified since their If-Modified-Since
+// header with 304 Not Modified, before the handlers after it run, so that the
+// expensive collection endpoints are only queried when they changed:
+//
+//	router.GET("/users", gin.Conditional(usersLastModified), listUsers)
+//
+// The responses get the Last-Modified header. The requests with an
+// If-None-Match header are left to the ETag validation of the handlers, and
+// the ones whose resource has no modification time run as usual. The errors
+// of lm abort the request with 500 Internal Server Error. See LastChanges to
+// derive the modification times from the change events of the routes.
+func Conditional(lm LastModifiedFunc) HandlerFunc {
+	assert1(lm != nil, "last modified func can not be nil")
+	return func(c *Context) {
+		if method := c.Request.Method; method != http.MethodGet && method != http.MethodHead {
+			c.Next()
+			return
+		}
+		modified, err := lm(c)
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err) //nolint: errcheck
+			return
+		}
+		if modified.IsZero() {
+			c.Next()
+			return
+		}
+		c.Header("Last-Modified", modified.UTC().Format(http.TimeFormat))
+		if c.requestHeader("If-None-Match") != "" {
+			c.Next()
+			return
+		}
+		since, err := http.ParseTime(c.requestHeader("If-Modified-Since"))
+		if err == nil && !modified.Truncate(time.Second).After(since) {
+			c.AbortWithStatus(http.StatusNotModified)
+			return
+		}
+		c.Next()
+	}
+}
+
+// LastChanges records the time of the last change of each entity published
+// by ChangeEvents, so that the routes reading an entity are conditional on
+// its changes. The routes name the entities with the MetaEntity metadata:
+//
+//	changes := gin.NewLastChanges()
+//	router.Use(gin.ChangeEvents(gin.ChangeEventsConfig{Publisher: changes}))
+//	router.POST("/users", createUser)
+//	router.GET("/users", gin.Conditional(changes.LastModified), listUsers)
+//	router.Route("/users").Meta(gin.MetaEntity, "user")
+//
+// The entities not changed since the engine started have no modification
+// time. It is safe for concurrent use.
+type LastChanges struct {
+	mu    sync.RWMutex
+	times map[string]time.Time
+}
+
+// NewLastChanges returns an empty LastChanges.
+func NewLastChanges() *LastChanges {
+	return &LastChanges{times: make(map[string]time.Time)}
+}
+
+// PublishChange records the change of the entity of e, if any.
+func (l *LastChanges) PublishChange(_ context.Context, e ChangeEvent) error {
+	if e.Entity == "" {
+		return nil
+	}
+	l.mu.Lock()
+	if e.Time.After(l.times[e.Entity]) {
+		l.times[e.Entity] = e.Time
+	}
+	l.mu.Unlock()
+	return nil
+}
+
+// Changed returns the time of the last change of entity, zero if none.
+func (l *LastChanges) Changed(entity string) time.Time {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.times[entity]
+}
+
+// LastModified is the LastModifiedFunc of the routes reading the entity named
+// by their MetaEntity metadata, see RouteHandle.Meta.
+func (l *LastChanges) LastModified(c *Context) (time.Time, error) {
+	entity, _ := c.RouteMeta()[MetaEntity].(string)
+	if entity == "" {
+		return time.Time{}, nil
+	}
+	return l.Changed(entity), nil
+}
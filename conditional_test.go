@@ -0,0 +1,101 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConditional(t *testing.T) {
+	modified := time.Date(2024, 3, 1, 12, 0, 0, 500, time.UTC)
+	calls := 0
+	router := New()
+	router.GET("/users", Conditional(func(c *Context) (time.Time, error) {
+		switch c.Query("case") {
+		case "unknown":
+			return time.Time{}, nil
+		case "failing":
+			return time.Time{}, errors.New("down")
+		}
+		return modified, nil
+	}), func(c *Context) {
+		calls++
+		c.JSON(http.StatusOK, []string{"ada"})
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/users")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Fri, 01 Mar 2024 12:00:00 GMT", w.Header().Get("Last-Modified"))
+	assert.Equal(t, 1, calls)
+
+	for _, since := range []string{"Fri, 01 Mar 2024 12:00:00 GMT", "Sat, 02 Mar 2024 00:00:00 GMT"} {
+		w = PerformRequest(router, http.MethodGet, "/users", header{"If-Modified-Since", since})
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+		assert.Equal(t, "Fri, 01 Mar 2024 12:00:00 GMT", w.Header().Get("Last-Modified"))
+	}
+	assert.Equal(t, 1, calls)
+
+	for _, h := range []header{
+		{"If-Modified-Since", "Fri, 01 Mar 2024 11:59:59 GMT"},
+		{"If-Modified-Since", "garbage"},
+		{"If-None-Match", `"v1"`},
+	} {
+		w = PerformRequest(router, http.MethodGet, "/users", h, header{"If-Modified-Since", "Sat, 02 Mar 2024 00:00:00 GMT"})
+		assert.Equal(t, http.StatusOK, w.Code, h)
+	}
+
+	w = PerformRequest(router, http.MethodGet, "/users?case=unknown", header{"If-Modified-Since", "Sat, 02 Mar 2024 00:00:00 GMT"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Last-Modified"))
+
+	w = PerformRequest(router, http.MethodGet, "/users?case=failing")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, 5, calls)
+}
+
+func TestLastChanges(t *testing.T) {
+	changes := NewLastChanges()
+	router := New()
+	router.Use(ChangeEvents(ChangeEventsConfig{Publisher: changes}))
+	router.POST("/users", func(c *Context) {
+		c.Status(http.StatusCreated)
+	})
+	router.Route("/users", http.MethodPost).Meta(MetaEntity, "user")
+	router.GET("/users", Conditional(changes.LastModified), func(c *Context) {
+		c.String(http.StatusOK, "users")
+	})
+	router.Route("/users", http.MethodGet).Meta(MetaEntity, "user")
+	router.GET("/orders", Conditional(changes.LastModified), func(c *Context) {
+		c.String(http.StatusOK, "orders")
+	})
+
+	// not changed since the start
+	w := PerformRequest(router, http.MethodGet, "/users")
+	assert.Empty(t, w.Header().Get("Last-Modified"))
+
+	PerformRequest(router, http.MethodPost, "/users")
+	changed := changes.Changed("user")
+	assert.False(t, changed.IsZero())
+	w = PerformRequest(router, http.MethodGet, "/users")
+	assert.Equal(t, changed.UTC().Format(http.TimeFormat), w.Header().Get("Last-Modified"))
+	w = PerformRequest(router, http.MethodGet, "/users", header{"If-Modified-Since", w.Header().Get("Last-Modified")})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	// the later changes win
+	assert.NoError(t, changes.PublishChange(context.Background(), ChangeEvent{Entity: "user", Time: changed.Add(time.Hour)}))
+	assert.NoError(t, changes.PublishChange(context.Background(), ChangeEvent{Entity: "user", Time: changed}))
+	assert.Equal(t, changed.Add(time.Hour), changes.Changed("user"))
+
+	w = PerformRequest(router, http.MethodGet, "/orders")
+	assert.Equal(t, "orders", w.Body.String())
+	assert.Empty(t, w.Header().Get("Last-Modified"))
+}
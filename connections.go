@@ -0,0 +1,258 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TrackedConn is a long-lived connection (websocket, SSE stream, ...) registered
+// in the engine's ConnRegistry. It is created by Context.TrackConnection.
+type TrackedConn struct {
+	// ID uniquely identifies the connection within its registry.
+	ID uint64
+	// RemoteAddr is the remote address of the request which opened the connection.
+	RemoteAddr string
+	// FullPath is the matched route of the request which opened the connection.
+	FullPath string
+	// Since is the time the connection was registered.
+	Since time.Time
+
+	mu       sync.RWMutex
+	tags     map[string]string
+	closer   io.Closer
+	ctx      context.Context
+	cancel   context.CancelFunc
+	registry *ConnRegistry
+	closed   int32
+	released int32
+}
+
+// Tag attaches a key/value pair to the connection, e.g. Tag("tenant", "acme").
+// Tags are used to select connections with ConnRegistry.CloseTagged.
+func (tc *TrackedConn) Tag(key, value string) *TrackedConn {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if tc.tags == nil {
+		tc.tags = make(map[string]string)
+	}
+	tc.tags[key] = value
+	return tc
+}
+
+// GetTag returns the value of the tag with the given key.
+func (tc *TrackedConn) GetTag(key string) (value string, ok bool) {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	value, ok = tc.tags[key]
+	return
+}
+
+// Tags returns a copy of all tags attached to the connection.
+func (tc *TrackedConn) Tags() map[string]string {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	tags := make(map[string]string, len(tc.tags))
+	for k, v := range tc.tags {
+		tags[k] = v
+	}
+	return tags
+}
+
+// SetCloser sets the io.Closer invoked when the connection is closed through the
+// registry. Handlers which hijack the connection should pass the net.Conn here.
+func (tc *TrackedConn) SetCloser(closer io.Closer) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.closer = closer
+}
+
+// Context returns a context which is canceled when the connection is closed through
+// the registry or when the request context is done. Streaming loops should watch it.
+func (tc *TrackedConn) Context() context.Context {
+	return tc.ctx
+}
+
+// Done is a shortcut for tc.Context().Done().
+func (tc *TrackedConn) Done() <-chan struct{} {
+	return tc.ctx.Done()
+}
+
+// Close cancels the connection context and invokes the closer, if any.
+// It is safe to call Close multiple times.
+func (tc *TrackedConn) Close() error {
+	if !atomic.CompareAndSwapInt32(&tc.closed, 0, 1) {
+		return nil
+	}
+	tc.cancel()
+	tc.mu.RLock()
+	closer := tc.closer
+	tc.mu.RUnlock()
+	if closer != nil {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Release removes the connection from its registry. Handlers must call it (usually
+// deferred) once the connection is finished.
+func (tc *TrackedConn) Release() {
+	if !atomic.CompareAndSwapInt32(&tc.released, 0, 1) {
+		return
+	}
+	tc.cancel()
+	tc.registry.remove(tc)
+}
+
+// ConnRegistry keeps track of the long-lived connections served by an Engine,
+// allowing to enumerate, tag and close them, e.g. to close all the connections of
+// a tenant on logout or to drain them during a graceful shutdown.
+type ConnRegistry struct {
+	mu     sync.RWMutex
+	conns  map[uint64]*TrackedConn
+	nextID uint64
+	empty  chan struct{}
+}
+
+// NewConnRegistry returns an empty ConnRegistry.
+func NewConnRegistry() *ConnRegistry {
+	return &ConnRegistry{conns: make(map[uint64]*TrackedConn)}
+}
+
+func (r *ConnRegistry) add(tc *TrackedConn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	tc.ID = r.nextID
+	tc.registry = r
+	r.conns[tc.ID] = tc
+}
+
+func (r *ConnRegistry) remove(tc *TrackedConn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.conns, tc.ID)
+	if len(r.conns) == 0 && r.empty != nil {
+		close(r.empty)
+		r.empty = nil
+	}
+}
+
+// Len returns the number of registered connections.
+func (r *ConnRegistry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.conns)
+}
+
+// List returns a snapshot of the registered connections.
+func (r *ConnRegistry) List() []*TrackedConn {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	conns := make([]*TrackedConn, 0, len(r.conns))
+	for _, tc := range r.conns {
+		conns = append(conns, tc)
+	}
+	return conns
+}
+
+// Range calls fn for each registered connection until fn returns false.
+func (r *ConnRegistry) Range(fn func(tc *TrackedConn) bool) {
+	for _, tc := range r.List() {
+		if !fn(tc) {
+			return
+		}
+	}
+}
+
+// CloseWhere closes every connection for which match returns true and returns
+// the number of closed connections.
+func (r *ConnRegistry) CloseWhere(match func(tc *TrackedConn) bool) int {
+	n := 0
+	for _, tc := range r.List() {
+		if match(tc) {
+			tc.Close() //nolint: errcheck
+			n++
+		}
+	}
+	return n
+}
+
+// CloseTagged closes every connection tagged with the given key/value pair.
+func (r *ConnRegistry) CloseTagged(key, value string) int {
+	return r.CloseWhere(func(tc *TrackedConn) bool {
+		v, ok := tc.GetTag(key)
+		return ok && v == value
+	})
+}
+
+// CloseAll closes every registered connection.
+func (r *ConnRegistry) CloseAll() int {
+	return r.CloseWhere(func(*TrackedConn) bool { return true })
+}
+
+// Drain closes every registered connection and waits until all of them have been
+// released by their handlers or ctx is done. It is meant to be used together with
+// http.Server.Shutdown, which does not wait for hijacked or streaming connections:
+//
+//	srv.RegisterOnShutdown(func() { router.Connections().Drain(ctx) })
+func (r *ConnRegistry) Drain(ctx context.Context) error {
+	r.mu.Lock()
+	if len(r.conns) == 0 {
+		r.mu.Unlock()
+		return nil
+	}
+	if r.empty == nil {
+		r.empty = make(chan struct{})
+	}
+	empty := r.empty
+	r.mu.Unlock()
+
+	r.CloseAll()
+
+	select {
+	case <-empty:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Connections returns the registry of long-lived connections of the engine.
+// The registry is created on first use.
+func (engine *Engine) Connections() *ConnRegistry {
+	engine.connectionsOnce.Do(func() {
+		engine.connections = NewConnRegistry()
+	})
+	return engine.connections
+}
+
+// TrackConnection registers the current request as a long-lived connection in the
+// engine's ConnRegistry. The request context is replaced by one which is canceled
+// when the connection is closed through the registry, so Context.Stream and any
+// loop watching c.Request.Context() stop. The returned TrackedConn must be released:
+//
+//	router.GET("/events", func(c *gin.Context) {
+//	    conn := c.TrackConnection().Tag("tenant", c.Param("tenant"))
+//	    defer conn.Release()
+//	    c.Stream(...)
+//	})
+func (c *Context) TrackConnection() *TrackedConn {
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	tc := &TrackedConn{
+		RemoteAddr: c.Request.RemoteAddr,
+		FullPath:   c.fullPath,
+		Since:      time.Now(),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+	c.Request = c.Request.WithContext(ctx)
+	c.engine.Connections().add(tc)
+	return tc
+}
@@ -0,0 +1,102 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+func TestConnRegistryTagAndClose(t *testing.T) {
+	router := New()
+	registered := make(chan *TrackedConn, 2)
+	router.GET("/events/:tenant", func(c *Context) {
+		conn := c.TrackConnection().Tag("tenant", c.Param("tenant"))
+		defer conn.Release()
+		registered <- conn
+		c.Stream(func(w io.Writer) bool {
+			time.Sleep(time.Millisecond)
+			return true
+		})
+	})
+
+	done := make(chan struct{}, 2)
+	for _, tenant := range []string{"acme", "globex"} {
+		go func(tenant string) {
+			req := httptest.NewRequest(http.MethodGet, "/events/"+tenant, nil)
+			router.ServeHTTP(CreateTestResponseRecorder(), req)
+			done <- struct{}{}
+		}(tenant)
+	}
+	conns := []*TrackedConn{<-registered, <-registered}
+	assert.Equal(t, 2, router.Connections().Len())
+	assert.Equal(t, "/events/:tenant", conns[0].FullPath)
+
+	assert.Equal(t, 1, router.Connections().CloseTagged("tenant", "acme"))
+	<-done
+	assert.Equal(t, 1, router.Connections().Len())
+	remaining := router.Connections().List()
+	v, ok := remaining[0].GetTag("tenant")
+	assert.True(t, ok)
+	assert.Equal(t, "globex", v)
+	assert.Equal(t, map[string]string{"tenant": "globex"}, remaining[0].Tags())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, router.Connections().Drain(ctx))
+	<-done
+	assert.Equal(t, 0, router.Connections().Len())
+}
+
+func TestConnRegistryCloser(t *testing.T) {
+	c, _ := CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest(http.MethodGet, "/ws", nil)
+
+	closed := 0
+	conn := c.TrackConnection()
+	conn.SetCloser(closerFunc(func() error {
+		closed++
+		return errors.New("closed")
+	}))
+	assert.Equal(t, conn.Context(), c.Request.Context())
+	assert.EqualError(t, conn.Close(), "closed")
+	assert.NoError(t, conn.Close())
+	assert.Equal(t, 1, closed)
+	<-conn.Done()
+
+	seen := 0
+	c.engine.Connections().Range(func(*TrackedConn) bool {
+		seen++
+		return false
+	})
+	assert.Equal(t, 1, seen)
+	conn.Release()
+	conn.Release()
+	assert.Equal(t, 0, c.engine.Connections().Len())
+}
+
+func TestConnRegistryDrainTimeout(t *testing.T) {
+	c, _ := CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest(http.MethodGet, "/ws", nil)
+	conn := c.TrackConnection()
+	defer conn.Release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, c.engine.Connections().Drain(ctx), context.DeadlineExceeded)
+	assert.Error(t, conn.Context().Err())
+	assert.NoError(t, NewConnRegistry().Drain(ctx))
+}
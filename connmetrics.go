@@ -0,0 +1,305 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/quic-go/quic-go/logging"
+)
+
+// connRateWindow is the window over which ConnStats.AcceptRate is computed.
+const connRateWindow = 10 * time.Second
+
+// Protocols reported in ConnStats.Protocols.
+const (
+	ProtocolHTTP1 = "HTTP/1.1"
+	ProtocolHTTP2 = "HTTP/2"
+	ProtocolHTTP3 = "HTTP/3"
+)
+
+// HandshakeBuckets are the upper bounds of the buckets of the TLS handshake
+// durations histogram.
+var HandshakeBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// ConnStats is a snapshot of the counters of a ConnMetrics.
+type ConnStats struct {
+	// Accepted is the number of accepted connections, and AcceptRate the
+	// number per second over the last complete 10s window.
+	Accepted   uint64
+	AcceptRate float64
+	// Active is the number of open connections.
+	Active int64
+	// Protocols counts the connections by HTTP protocol: ProtocolHTTP1,
+	// ProtocolHTTP2 or ProtocolHTTP3.
+	Protocols map[string]uint64
+	// TLSVersions counts the TLS connections by version, such as "TLS 1.3".
+	TLSVersions map[string]uint64
+	// Handshakes are the durations of the completed TLS handshakes.
+	Handshakes HandshakeStats
+	// HandshakeFailures is the number of TLS connections closed before the end
+	// of their handshake.
+	HandshakeFailures uint64
+}
+
+// HandshakeStats is a histogram of TLS handshake durations.
+type HandshakeStats struct {
+	Count uint64
+	Sum   time.Duration
+	// Buckets are the cumulative counts of the handshakes lasting at most the
+	// matching HandshakeBuckets bound.
+	Buckets []uint64
+}
+
+// ConnMetrics collects listener-level metrics: the accepted and active
+// connections, their protocols and the durations of their TLS handshakes, for
+// capacity planning. Its hooks are set by the Run methods of the engine once
+// Engine.ConnMetrics was called; servers built by hand set them explicitly:
+//
+//	m := router.ConnMetrics()
+//	srv := &http.Server{Handler: router, ConnState: m.ConnState, TLSConfig: m.TLSConfig(conf)}
+//	h3 := &http3.Server{Handler: router, QUICConfig: &quic.Config{Tracer: m.QUICTracer}}
+type ConnMetrics struct {
+	accepted          uint64
+	active            int64
+	handshakeFailures uint64
+
+	mu          sync.Mutex
+	conns       map[net.Conn]bool
+	windowStart time.Time
+	windowCount uint64
+	acceptRate  float64
+	protocols   map[string]uint64
+	tlsVersions map[string]uint64
+	handshakes  HandshakeStats
+}
+
+// NewConnMetrics returns an empty ConnMetrics.
+func NewConnMetrics() *ConnMetrics {
+	return &ConnMetrics{
+		conns:       make(map[net.Conn]bool),
+		windowStart: time.Now(),
+		protocols:   make(map[string]uint64),
+		tlsVersions: make(map[string]uint64),
+		handshakes:  HandshakeStats{Buckets: make([]uint64, len(HandshakeBuckets))},
+	}
+}
+
+// ConnState tracks the connections of an http.Server, see http.Server.ConnState.
+func (m *ConnMetrics) ConnState(conn net.Conn, state http.ConnState) {
+	switch state {
+	case http.StateNew:
+		m.mu.Lock()
+		m.conns[conn] = false
+		m.mu.Unlock()
+		m.accept()
+	case http.StateActive:
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if seen, ok := m.conns[conn]; !ok || seen {
+			return
+		}
+		m.conns[conn] = true
+		protocol := ProtocolHTTP1
+		if tlsConn, ok := conn.(*tls.Conn); ok {
+			cs := tlsConn.ConnectionState()
+			if cs.NegotiatedProtocol == "h2" {
+				protocol = ProtocolHTTP2
+			}
+			m.tlsVersions[tls.VersionName(cs.Version)]++
+		}
+		m.protocols[protocol]++
+	case http.StateHijacked, http.StateClosed:
+		m.mu.Lock()
+		_, ok := m.conns[conn]
+		delete(m.conns, conn)
+		m.mu.Unlock()
+		if !ok {
+			return
+		}
+		atomic.AddInt64(&m.active, -1)
+		if tlsConn, isTLS := conn.(*tls.Conn); isTLS && state == http.StateClosed && !tlsConn.ConnectionState().HandshakeComplete {
+			atomic.AddUint64(&m.handshakeFailures, 1)
+		}
+	}
+}
+
+// TLSConfig returns a copy of conf measuring the durations of the handshakes.
+// As the config returned by GetConfigForClient is used as is, conf must be
+// complete: unlike with http.Server.ListenAndServeTLS, the certificates are
+// not loaded and the ALPN protocols are not added.
+func (m *ConnMetrics) TLSConfig(conf *tls.Config) *tls.Config {
+	base := conf.Clone()
+	wrapped := conf.Clone()
+	wrapped.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		start := time.Now()
+		c := base
+		if base.GetConfigForClient != nil {
+			override, err := base.GetConfigForClient(hello)
+			if err != nil {
+				return nil, err
+			}
+			if override != nil {
+				c = override
+			}
+		}
+		c = c.Clone()
+		verify := c.VerifyConnection
+		c.VerifyConnection = func(cs tls.ConnectionState) error {
+			if verify != nil {
+				if err := verify(cs); err != nil {
+					return err
+				}
+			}
+			m.handshake(time.Since(start))
+			return nil
+		}
+		return c, nil
+	}
+	return wrapped
+}
+
+// QUICTracer tracks the connections of an HTTP/3 server, see quic.Config.Tracer.
+func (m *ConnMetrics) QUICTracer(_ context.Context, p logging.Perspective, _ logging.ConnectionID) *logging.ConnectionTracer {
+	if p != logging.PerspectiveServer {
+		return nil
+	}
+	var (
+		start     time.Time
+		started   bool
+		completed bool
+		mu        sync.Mutex
+	)
+	return &logging.ConnectionTracer{
+		StartedConnection: func(net.Addr, net.Addr, logging.ConnectionID, logging.ConnectionID) {
+			mu.Lock()
+			start, started = time.Now(), true
+			mu.Unlock()
+			m.accept()
+			m.mu.Lock()
+			m.protocols[ProtocolHTTP3]++
+			m.tlsVersions[tls.VersionName(tls.VersionTLS13)]++
+			m.mu.Unlock()
+		},
+		DroppedEncryptionLevel: func(level logging.EncryptionLevel) {
+			mu.Lock()
+			defer mu.Unlock()
+			if level == logging.EncryptionHandshake && started && !completed {
+				completed = true
+				m.handshake(time.Since(start))
+			}
+		},
+		Close: func() {
+			mu.Lock()
+			defer mu.Unlock()
+			if !started {
+				return
+			}
+			started = false
+			atomic.AddInt64(&m.active, -1)
+			if !completed {
+				atomic.AddUint64(&m.handshakeFailures, 1)
+			}
+		},
+	}
+}
+
+// Stats returns a snapshot of the counters.
+func (m *ConnMetrics) Stats() ConnStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rotate(time.Now())
+	stats := ConnStats{
+		Accepted:          atomic.LoadUint64(&m.accepted),
+		AcceptRate:        m.acceptRate,
+		Active:            atomic.LoadInt64(&m.active),
+		Protocols:         make(map[string]uint64, len(m.protocols)),
+		TLSVersions:       make(map[string]uint64, len(m.tlsVersions)),
+		Handshakes:        m.handshakes,
+		HandshakeFailures: atomic.LoadUint64(&m.handshakeFailures),
+	}
+	stats.Handshakes.Buckets = append([]uint64(nil), m.handshakes.Buckets...)
+	for protocol, n := range m.protocols {
+		stats.Protocols[protocol] = n
+	}
+	for version, n := range m.tlsVersions {
+		stats.TLSVersions[version] = n
+	}
+	return stats
+}
+
+func (m *ConnMetrics) accept() {
+	atomic.AddUint64(&m.accepted, 1)
+	atomic.AddInt64(&m.active, 1)
+	m.mu.Lock()
+	m.rotate(time.Now())
+	m.windowCount++
+	m.mu.Unlock()
+}
+
+// rotate starts a new rate window when the current one is over.
+func (m *ConnMetrics) rotate(now time.Time) {
+	elapsed := now.Sub(m.windowStart)
+	if elapsed < connRateWindow {
+		return
+	}
+	if elapsed < 2*connRateWindow {
+		m.acceptRate = float64(m.windowCount) / connRateWindow.Seconds()
+		m.windowStart = m.windowStart.Add(connRateWindow)
+	} else {
+		// no accept during the whole last window
+		m.acceptRate = 0
+		m.windowStart = now
+	}
+	m.windowCount = 0
+}
+
+func (m *ConnMetrics) handshake(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handshakes.Count++
+	m.handshakes.Sum += d
+	for i, bound := range HandshakeBuckets {
+		if d <= bound {
+			m.handshakes.Buckets[i]++
+		}
+	}
+}
+
+// ConnMetrics returns the connection metrics of the engine, created on first
+// use. Once created, the Run methods collect them.
+func (engine *Engine) ConnMetrics() *ConnMetrics {
+	if m := engine.connMetrics.Load(); m != nil {
+		return m
+	}
+	engine.connMetrics.CompareAndSwap(nil, NewConnMetrics())
+	return engine.connMetrics.Load()
+}
+
+// server returns the http.Server used by the Run methods, collecting the
+// connection metrics if enabled.
+func (engine *Engine) server(addr string) *http.Server {
+	srv := &http.Server{Addr: addr, Handler: engine.Handler()} //nolint: gosec
+	if m := engine.connMetrics.Load(); m != nil {
+		srv.ConnState = m.ConnState
+	}
+	return srv
+}
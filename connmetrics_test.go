@@ -0,0 +1,140 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMetricsServer(t *testing.T) (*httptest.Server, *ConnMetrics) {
+	router := New()
+	router.GET("/", func(c *Context) { c.String(http.StatusOK, c.Request.Proto) })
+	m := router.ConnMetrics()
+	assert.Same(t, m, router.ConnMetrics())
+	srv := httptest.NewUnstartedServer(router)
+	srv.Config = router.server("")
+	t.Cleanup(srv.Close)
+	return srv, m
+}
+
+func TestConnMetrics(t *testing.T) {
+	srv, m := newMetricsServer(t)
+	srv.Start()
+
+	client := &http.Client{Transport: &http.Transport{}}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(srv.URL)
+		require.NoError(t, err)
+		// drained, so that the connection is reused
+		_, err = io.Copy(io.Discard, resp.Body)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	stats := m.Stats()
+	assert.Equal(t, uint64(1), stats.Accepted)
+	assert.Equal(t, int64(1), stats.Active)
+	assert.Equal(t, map[string]uint64{ProtocolHTTP1: 1}, stats.Protocols)
+	assert.Empty(t, stats.TLSVersions)
+
+	client.CloseIdleConnections()
+	assert.Eventually(t, func() bool { return m.Stats().Active == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestConnMetricsTLS(t *testing.T) {
+	srv, m := newMetricsServer(t)
+	cert, err := tls.LoadX509KeyPair("./testdata/certificate/cert.pem", "./testdata/certificate/key.pem")
+	require.NoError(t, err)
+	srv.EnableHTTP2 = true
+	srv.TLS = m.TLSConfig(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+		MinVersion:   tls.VersionTLS12,
+	})
+	srv.StartTLS()
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true}, //nolint: gosec
+		ForceAttemptHTTP2: true,
+	}}
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 2, resp.ProtoMajor)
+
+	// a connection closed before its handshake
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	require.NoError(t, err)
+	conn.Close()
+
+	client.CloseIdleConnections()
+	assert.Eventually(t, func() bool { return m.Stats().Active == 0 }, time.Second, 10*time.Millisecond)
+	stats := m.Stats()
+	assert.Equal(t, uint64(2), stats.Accepted)
+	assert.Equal(t, map[string]uint64{ProtocolHTTP2: 1}, stats.Protocols)
+	assert.Equal(t, map[string]uint64{"TLS 1.3": 1}, stats.TLSVersions)
+	assert.Equal(t, uint64(1), stats.Handshakes.Count)
+	assert.Positive(t, stats.Handshakes.Sum)
+	assert.Equal(t, uint64(1), stats.Handshakes.Buckets[len(HandshakeBuckets)-1])
+	assert.Equal(t, uint64(1), stats.HandshakeFailures)
+}
+
+func TestConnMetricsQUIC(t *testing.T) {
+	m := NewConnMetrics()
+	assert.Nil(t, m.QUICTracer(context.Background(), logging.PerspectiveClient, logging.ConnectionID{}))
+
+	handshaken := m.QUICTracer(context.Background(), logging.PerspectiveServer, logging.ConnectionID{})
+	handshaken.StartedConnection(nil, nil, logging.ConnectionID{}, logging.ConnectionID{})
+	handshaken.DroppedEncryptionLevel(logging.EncryptionInitial)
+	handshaken.DroppedEncryptionLevel(logging.EncryptionHandshake)
+	failed := m.QUICTracer(context.Background(), logging.PerspectiveServer, logging.ConnectionID{})
+	failed.StartedConnection(nil, nil, logging.ConnectionID{}, logging.ConnectionID{})
+
+	stats := m.Stats()
+	assert.Equal(t, uint64(2), stats.Accepted)
+	assert.Equal(t, int64(2), stats.Active)
+	assert.Equal(t, map[string]uint64{ProtocolHTTP3: 2}, stats.Protocols)
+	assert.Equal(t, map[string]uint64{"TLS 1.3": 2}, stats.TLSVersions)
+	assert.Equal(t, uint64(1), stats.Handshakes.Count)
+
+	handshaken.Close()
+	failed.Close()
+	failed.Close()
+	stats = m.Stats()
+	assert.Equal(t, int64(0), stats.Active)
+	assert.Equal(t, uint64(1), stats.HandshakeFailures)
+}
+
+func TestConnMetricsAcceptRate(t *testing.T) {
+	m := NewConnMetrics()
+	for i := 0; i < 50; i++ {
+		m.accept()
+	}
+	assert.Zero(t, m.Stats().AcceptRate)
+
+	m.windowStart = m.windowStart.Add(-connRateWindow - time.Second)
+	assert.InDelta(t, 5.0, m.Stats().AcceptRate, 0.001)
+	assert.Zero(t, m.windowCount)
+
+	m.windowStart = m.windowStart.Add(-3 * connRateWindow)
+	assert.Zero(t, m.Stats().AcceptRate)
+}
+
+func TestEngineServerWithoutMetrics(t *testing.T) {
+	router := New()
+	srv := router.server(":8080")
+	assert.Equal(t, ":8080", srv.Addr)
+	assert.Nil(t, srv.ConnState)
+}
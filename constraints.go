@@ -0,0 +1,112 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import "strings"
+
+// builtinConstraints are the param constraints available to every engine.
+var builtinConstraints = map[string]func(string) bool{
+	"int":   isIntParam,
+	"alpha": isAlphaParam,
+	"alnum": isAlnumParam,
+	"uuid":  isUUIDParam,
+}
+
+// RegisterConstraint registers a param constraint, used by the routes
+// registered afterwards by suffixing a param with "|" and its name. The value
+// of the param must satisfy fn for the route to match, so routes whose params
+// have different constraints can share the same position:
+//
+//	router.RegisterConstraint("sku", isSKU)
+//	router.GET("/users/:id|int", getUserByID)
+//	router.GET("/users/:name|alpha", getUserByName)
+//	router.GET("/products/:sku|sku", getProduct)
+//
+// The constraints are tried in registration order, before the unconstrained
+// param at the same position if any. The built-in constraints are "int",
+// "alpha", "alnum" and "uuid".
+//
+// Catch-all wildcards are constrained by the number of segments they match
+// instead, as in "/files/*path{1,3}", "/files/*path{1,}" or "/files/*path{2}",
+// so that "/files/*path{1,}" does not match "/files/".
+func (engine *Engine) RegisterConstraint(name string, fn func(string) bool) {
+	assert1(name != "" && !strings.ContainsAny(name, "/:*|"), "invalid constraint name "+name)
+	assert1(fn != nil, "constraint "+name+" can not be nil")
+	if engine.constraints == nil {
+		engine.constraints = make(map[string]func(string) bool, len(builtinConstraints)+1)
+		for k, v := range builtinConstraints {
+			engine.constraints[k] = v
+		}
+	}
+	engine.constraints[name] = fn
+}
+
+// routeConstraints returns the param constraints of the engine.
+func (engine *Engine) routeConstraints() map[string]func(string) bool {
+	if engine.constraints == nil {
+		return builtinConstraints
+	}
+	return engine.constraints
+}
+
+// isIntParam reports whether s is a decimal integer, optionally negative.
+func isIntParam(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	if s == "" {
+		return false
+	}
+	for _, c := range []byte(s) {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isAlphaParam reports whether s is made of ASCII letters.
+func isAlphaParam(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range []byte(s) {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// isAlnumParam reports whether s is made of ASCII letters and digits.
+func isAlnumParam(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range []byte(s) {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// isUUIDParam reports whether s is a UUID in its canonical form.
+func isUUIDParam(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i, c := range []byte(s) {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngineRegisterConstraint(t *testing.T) {
+	router := New()
+	router.RegisterConstraint("sku", func(s string) bool { return strings.HasPrefix(s, "SKU-") })
+	router.GET("/users/:id|int", func(c *Context) { c.String(http.StatusOK, "id "+c.Param("id")) })
+	router.GET("/users/:name|alpha", func(c *Context) { c.String(http.StatusOK, "name "+c.Param("name")) })
+	router.GET("/products/:sku|sku", func(c *Context) { c.String(http.StatusOK, c.FullPath()) })
+
+	assert.Equal(t, "id 42", PerformRequest(router, http.MethodGet, "/users/42").Body.String())
+	assert.Equal(t, "name bob", PerformRequest(router, http.MethodGet, "/users/bob").Body.String())
+	assert.Equal(t, http.StatusNotFound, PerformRequest(router, http.MethodGet, "/users/bob42").Code)
+	assert.Equal(t, "/products/:sku|sku", PerformRequest(router, http.MethodGet, "/products/SKU-1").Body.String())
+	assert.Equal(t, http.StatusNotFound, PerformRequest(router, http.MethodGet, "/products/1").Code)
+
+	assert.Len(t, router.Routes(), 3)
+	assert.Equal(t, []RouteParam{{Name: "id", Constraint: "int"}}, router.RouteDocs()[0].Params)
+	assert.NoError(t, router.Validate())
+
+	assert.PanicsWithValue(t, "unknown constraint 'nope' in path '/orders/:id|nope'", func() {
+		router.GET("/orders/:id|nope", handlerTest1)
+	})
+	assert.Panics(t, func() { router.RegisterConstraint("a|b", func(string) bool { return true }) })
+	assert.Panics(t, func() { router.RegisterConstraint("nil", nil) })
+	// the registered constraints are per engine
+	assert.Panics(t, func() { New().GET("/products/:sku|sku", handlerTest1) })
+}
+
+func TestBuiltinConstraints(t *testing.T) {
+	for name, values := range map[string]map[string]bool{
+		"int":   {"42": true, "-7": true, "": false, "-": false, "4a": false},
+		"alpha": {"abc": true, "ABC": true, "": false, "ab1": false},
+		"alnum": {"ab1": true, "": false, "a-b": false},
+		"uuid": {
+			"0f8fad5b-d9cb-469f-a165-70867728950e": true,
+			"0F8FAD5B-D9CB-469F-A165-70867728950E": true,
+			"0f8fad5bd9cb469fa16570867728950e":     false,
+			"0f8fad5b-d9cb-469f-a165-70867728950g": false,
+		},
+	} {
+		for value, ok := range values {
+			assert.Equal(t, ok, builtinConstraints[name](value), name+" "+value)
+		}
+	}
+}
+
+func TestCatchAllSegmentBounds(t *testing.T) {
+	router := New()
+	router.GET("/files/*path{1,3}", func(c *Context) { c.String(http.StatusOK, c.Param("path")) })
+	router.Route("/files/*path{1,3}", http.MethodGet).Name("file")
+
+	assert.Equal(t, "/a/b", PerformRequest(router, http.MethodGet, "/files/a/b").Body.String())
+	assert.Equal(t, http.StatusNotFound, PerformRequest(router, http.MethodGet, "/files/").Code)
+	assert.Equal(t, http.StatusNotFound, PerformRequest(router, http.MethodGet, "/files/a/b/c/d").Code)
+	// no redirect to "/files/", which the catch-all refuses
+	assert.Equal(t, http.StatusNotFound, PerformRequest(router, http.MethodGet, "/files").Code)
+
+	assert.Equal(t, []RouteParam{{Name: "path", CatchAll: true, Constraint: "{1,3}"}}, router.RouteDocs()[0].Params)
+	path, err := router.PathBuilder("file").Param("path", "a/b").Build()
+	assert.NoError(t, err)
+	assert.Equal(t, "/files/a/b", path)
+	assert.NoError(t, router.Validate())
+
+	assert.PanicsWithValue(t, "invalid segment bounds in catch-all '*path{3,1}' in path '/docs/*path{3,1}'", func() {
+		router.GET("/docs/*path{3,1}", handlerTest1)
+	})
+}
@@ -7,14 +7,16 @@ package gin
 import (
 	"errors"
 	"io"
+	"io/fs"
 	"log"
+	"log/slog"
 	"math"
 	"mime/multipart"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
-	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -22,6 +24,9 @@ import (
 	"github.com/gin-contrib/sse"
 	"github.com/jialequ/mpgw/binding"
 	"github.com/jialequ/mpgw/render"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
 )
 
 // Content-Type MIME of the most common data formats.
@@ -36,6 +41,8 @@ const (
 	MIMEYAML              = binding.MIMEYAML
 	MIMEYAML2             = binding.MIMEYAML2
 	MIMETOML              = binding.MIMETOML
+	MIMEJSONAPI           = binding.MIMEJSONAPI
+	MIMEPROTOBUF          = binding.MIMEPROTOBUF
 )
 
 // BodyBytesKey indicates a default body bytes key.
@@ -62,22 +69,41 @@ type Context struct {
 	handlers HandlersChain
 	index    int8
 	fullPath string
+	// routeHost is the host pattern of the matched route, see Engine.Host.
+	routeHost string
 
 	engine       *Engine
 	params       *Params
 	skippedNodes *[]skippedNode
 
-	// This mutex protects Keys map.
+	// This mutex protects Keys map, messages and logFields.
 	mu sync.RWMutex
 
 	// Keys is a key/value pair exclusively for the context of each request.
 	Keys map[string]any
 
+	// messages are the typed messages of the request, see Emit.
+	messages messages
+
+	// logFields are the fields of the access record, see LogFields.
+	logFields []slog.Attr
+
 	// Errors is a list of errors attached to all the handlers/middlewares who used this context.
 	Errors errorMsgs
 
 	// Accepted defines a list of manually accepted formats for content negotiation.
 	Accepted []string
+	// rejected are the formats refused by the Accept header with q=0.
+	rejected []string
+
+	// cspNonce is the Content Security Policy nonce of the request, see
+	// CSPNonce.
+	cspNonce string
+
+	// deadlined reports whether the request context was given a deadline by
+	// a timeout or SetDeadline, and deadlines are the guards of the latter.
+	deadlined bool
+	deadlines []*timeoutWriter
 
 	// queryCache caches the query result from c.Request.URL.Query().
 	queryCache url.Values
@@ -89,6 +115,34 @@ type Context struct {
 	// SameSite allows a server to define a cookie attribute making it impossible for
 	// the browser to send this cookie along with cross-site requests.
 	sameSite http.SameSite
+
+	// watchdog times the handlers run by Next, see Watchdog.
+	watchdog *watchdog
+	// headerLint attributes the response headers to the handlers run by Next,
+	// see HeaderLint.
+	headerLint *headerLint
+
+	// timing reports whether the phases of the request are timed, see
+	// Engine.TimeRequests and Engine.WideEvents.
+	timing bool
+	phases requestPhases
+
+	// hijacked reports whether the connection was taken over, see Hijack.
+	hijacked bool
+
+	// authDecisions are the decisions of the auth caches memoized for the
+	// request, see AuthCache.
+	authDecisions []authDecision
+
+	// spools are the temporary files of the request bodies buffered on disk,
+	// removed once the request is served, see BufferingDisk.
+	spools []*os.File
+
+	// tx is the transaction of the request, see Transactional.
+	tx *requestTx
+	// headerHooks are called once before the response header is written,
+	// and may change its status.
+	headerHooks []func()
 }
 
 /************************************/
@@ -102,12 +156,25 @@ func (c *Context) reset() {
 	c.index = -1
 
 	c.fullPath = ""
+	c.routeHost = ""
 	c.Keys = nil
+	c.messages = messages{}
+	c.logFields = nil
 	c.Errors = c.Errors[:0]
 	c.Accepted = nil
+	c.rejected = nil
+	c.cspNonce = ""
+	c.deadlined = false
+	c.deadlines = c.deadlines[:0]
 	c.queryCache = nil
 	c.formCache = nil
 	c.sameSite = 0
+	c.watchdog = nil
+	c.headerLint = nil
+	c.hijacked = false
+	c.authDecisions = nil
+	c.tx = nil
+	c.headerHooks = nil
 	*c.params = (*c.params)[:0]
 	*c.skippedNodes = (*c.skippedNodes)[:0]
 }
@@ -122,10 +189,14 @@ func (c *Context) Copy() *Context {
 	}
 
 	cp.writermem.ResponseWriter = nil
+	cp.writermem.owner = &cp
 	cp.Writer = &cp.writermem
 	cp.index = abortIndex
 	cp.handlers = nil
 	cp.fullPath = c.fullPath
+	cp.routeHost = c.routeHost
+	cp.cspNonce = c.cspNonce
+	cp.deadlined = c.deadlined
 
 	cKeys := c.Keys
 	cp.Keys = make(map[string]any, len(cKeys))
@@ -133,6 +204,8 @@ func (c *Context) Copy() *Context {
 	for k, v := range cKeys {
 		cp.Keys[k] = v
 	}
+	cp.messages = c.messages.copy()
+	cp.logFields = slices.Clone(c.logFields)
 	c.mu.RUnlock()
 
 	cParams := c.Params
@@ -189,11 +262,28 @@ func (c *Context) Next() {
 		if c.handlers[c.index] == nil {
 			continue
 		}
-		c.handlers[c.index](c)
+		if c.timing {
+			c.runTimed()
+		} else {
+			c.runHandler()
+		}
 		c.index++
 	}
 }
 
+// runHandler runs the current handler of the chain, through the header lint
+// and the watchdog of the request if any.
+func (c *Context) runHandler() {
+	switch {
+	case c.headerLint != nil:
+		c.headerLint.run(c)
+	case c.watchdog != nil:
+		c.watchdog.run(c)
+	default:
+		c.handlers[c.index](c)
+	}
+}
+
 // IsAborted returns true if the current context was aborted.
 func (c *Context) IsAborted() bool {
 	return c.index >= abortIndex
@@ -613,26 +703,16 @@ func (c *Context) MultipartForm() (*multipart.Form, error) {
 	return c.Request.MultipartForm, err
 }
 
-// SaveUploadedFile uploads the form file to specific dst.
-func (c *Context) SaveUploadedFile(file *multipart.FileHeader, dst string) error {
-	src, err := file.Open()
-	if err != nil {
-		return err
+// SaveUploadedFile uploads the form file to specific dst, validated by the
+// options if given, see SaveOptions. It panics if more than one SaveOptions is
+// given.
+func (c *Context) SaveUploadedFile(file *multipart.FileHeader, dst string, opts ...SaveOptions) error {
+	assert1(len(opts) <= 1, "SaveUploadedFile takes at most one SaveOptions")
+	var opt SaveOptions
+	if len(opts) > 0 {
+		opt = opts[0]
 	}
-	defer src.Close()
-
-	if err = os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
-		return err
-	}
-
-	out, err := os.Create(dst)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-
-	_, err = io.Copy(out, src)
-	return err
+	return saveUploadedFile(file, dst, opt)
 }
 
 // Bind checks the Method and Content-Type to select a binding engine automatically,
@@ -694,6 +774,16 @@ func (c *Context) BindUri(obj any) error {
 	return nil
 }
 
+// BindAll binds the passed struct pointer using c.ShouldBindAll.
+// It will abort the request with HTTP 400 if any error occurs.
+func (c *Context) BindAll(obj any) error {
+	if err := c.ShouldBindAll(obj); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err).SetType(ErrorTypeBind) //nolint: errcheck
+		return err
+	}
+	return nil
+}
+
 // MustBindWith binds the passed struct pointer using the specified binding engine.
 // It will abort the request with HTTP 400 if any error occurs.
 // See the binding package.
@@ -756,11 +846,39 @@ func (c *Context) ShouldBindHeader(obj any) error {
 
 // ShouldBindUri binds the passed struct pointer using the specified binding engine.
 func (c *Context) ShouldBindUri(obj any) error {
+	return binding.Uri.BindUri(c.paramsMap(), obj)
+}
+
+// ShouldBindAll binds the passed struct pointer from the uri params, the
+// headers, the query string and the body at once, in that order of precedence,
+// and validates it once filled. The errors of all the sources are reported
+// together. See binding.BindAll.
+func (c *Context) ShouldBindAll(obj any) error {
+	return binding.BindAll(c.Request, c.paramsMap(), obj)
+}
+
+// paramsMap returns the uri params as a map, for the uri binding.
+func (c *Context) paramsMap() map[string][]string {
 	m := make(map[string][]string, len(c.Params))
 	for _, v := range c.Params {
 		m[v.Key] = []string{v.Value}
 	}
-	return binding.Uri.BindUri(m, obj)
+	return m
+}
+
+// ShouldBindProto binds the request into a new dynamic message described by desc,
+// enabling schema-driven endpoints without generated Go types. JSON bodies are
+// decoded with binding.ProtoJSON, query strings and forms with binding.ProtoForm.
+func (c *Context) ShouldBindProto(desc protoreflect.MessageDescriptor) (*dynamicpb.Message, error) {
+	msg := dynamicpb.NewMessage(desc)
+	b := binding.ProtoForm
+	if c.Request.Method != http.MethodGet && c.ContentType() == binding.MIMEJSON {
+		b = binding.ProtoJSON
+	}
+	if err := c.ShouldBindWith(msg, b); err != nil {
+		return nil, err
+	}
+	return msg, nil
 }
 
 // ShouldBindWith binds the passed struct pointer using the specified binding engine.
@@ -823,6 +941,16 @@ func (c *Context) ShouldBindBodyWithPlain(obj any) error {
 // the remote IP (coming from Request.RemoteAddr) is returned.
 func (c *Context) ClientIP() string {
 	// Check if we're running on a trusted platform, continue running backwards if error
+	if p := c.engine.platform; p != nil {
+		// the platform is the only source of the client IP
+		if addr := p.clientIP(c); addr != "" {
+			return addr
+		}
+		if remoteIP := net.ParseIP(c.RemoteIP()); remoteIP != nil {
+			return remoteIP.String()
+		}
+		return ""
+	}
 	if c.engine.TrustedPlatform != "" {
 		// Developers can define their own header of Trusted Platform or use predefined constants
 		if addr := c.requestHeader(c.engine.TrustedPlatform); addr != "" {
@@ -971,6 +1099,9 @@ func (c *Context) Cookie(name string) (string, error) {
 
 // Render writes the response headers and calls render.Render to render data.
 func (c *Context) Render(code int, r render.Render) {
+	if c.timing {
+		defer c.timeRender(c.now())
+	}
 	c.Status(code)
 
 	if !bodyAllowedForStatus(code) {
@@ -979,6 +1110,11 @@ func (c *Context) Render(code int, r render.Render) {
 		return
 	}
 
+	if html, ok := r.(render.HTML); ok {
+		c.renderHTML(html)
+		return
+	}
+
 	if err := r.Render(c.Writer); err != nil {
 		// Pushing error to c.Errors
 		_ = c.Error(err)
@@ -999,6 +1135,7 @@ func (c *Context) HTML(code int, name string, obj any) {
 // WARNING: we recommend using this only for development purposes since printing pretty JSON is
 // more CPU and bandwidth consuming. Use Context.JSON() instead.
 func (c *Context) IndentedJSON(code int, obj any) {
+	obj = c.headerFields(obj)
 	c.Render(code, render.IndentedJSON{Data: obj})
 }
 
@@ -1006,6 +1143,7 @@ func (c *Context) IndentedJSON(code int, obj any) {
 // Default prepends "while(1)," to response body if the given struct is array values.
 // It also sets the Content-Type as "application/json".
 func (c *Context) SecureJSON(code int, obj any) {
+	obj = c.headerFields(obj)
 	c.Render(code, render.SecureJSON{Prefix: c.engine.secureJSONPrefix, Data: obj})
 }
 
@@ -1013,6 +1151,7 @@ func (c *Context) SecureJSON(code int, obj any) {
 // It adds padding to response body to request data from a server residing in a different domain than the client.
 // It also sets the Content-Type as "application/javascript".
 func (c *Context) JSONP(code int, obj any) {
+	obj = c.headerFields(obj)
 	callback := c.DefaultQuery("callback", "")
 	if callback == "" {
 		c.Render(code, render.JSON{Data: obj})
@@ -1023,35 +1162,49 @@ func (c *Context) JSONP(code int, obj any) {
 
 // JSON serializes the given struct as JSON into the response body.
 // It also sets the Content-Type as "application/json".
+// The encoding stops when the request is canceled, see render.CancelableJSON.
+// The fields of a struct tagged with `respheader:"Name"` are sent as response
+// headers instead of in the body, such as `respheader:"X-Total-Count"`; the
+// same goes for the other struct renders.
 func (c *Context) JSON(code int, obj any) {
+	obj = c.headerFields(obj)
+	if c.Request != nil && c.Request.Context().Done() != nil {
+		c.Render(code, render.CancelableJSON{Context: c.Request.Context(), Data: obj})
+		return
+	}
 	c.Render(code, render.JSON{Data: obj})
 }
 
 // AsciiJSON serializes the given struct as JSON into the response body with unicode to ASCII string.
 // It also sets the Content-Type as "application/json".
 func (c *Context) AsciiJSON(code int, obj any) {
+	obj = c.headerFields(obj)
 	c.Render(code, render.AsciiJSON{Data: obj})
 }
 
 // PureJSON serializes the given struct as JSON into the response body.
 // PureJSON, unlike JSON, does not replace special html characters with their unicode entities.
 func (c *Context) PureJSON(code int, obj any) {
+	obj = c.headerFields(obj)
 	c.Render(code, render.PureJSON{Data: obj})
 }
 
 // XML serializes the given struct as XML into the response body.
 // It also sets the Content-Type as "application/xml".
 func (c *Context) XML(code int, obj any) {
+	obj = c.headerFields(obj)
 	c.Render(code, render.XML{Data: obj})
 }
 
 // YAML serializes the given struct as YAML into the response body.
 func (c *Context) YAML(code int, obj any) {
+	obj = c.headerFields(obj)
 	c.Render(code, render.YAML{Data: obj})
 }
 
 // TOML serializes the given struct as TOML into the response body.
 func (c *Context) TOML(code int, obj any) {
+	obj = c.headerFields(obj)
 	c.Render(code, render.TOML{Data: obj})
 }
 
@@ -1060,6 +1213,31 @@ func (c *Context) ProtoBuf(code int, obj any) {
 	c.Render(code, render.ProtoBuf{Data: obj})
 }
 
+// JSONAPI serializes the given JSON:API document, or primary data wrapped into
+// a document, into the response body with the application/vnd.api+json Content-Type.
+func (c *Context) JSONAPI(code int, obj any) {
+	c.Render(code, render.JSONAPI{Data: obj})
+}
+
+// JSONAPIErrors aborts the request and writes a JSON:API document holding the
+// errors attached to the context, or errs if given.
+func (c *Context) JSONAPIErrors(code int, errs ...render.JSONAPIError) {
+	if len(errs) == 0 {
+		for _, err := range c.Errors {
+			errs = append(errs, render.NewJSONAPIError(code, err.Err))
+		}
+	}
+	c.Abort()
+	c.JSONAPI(code, render.JSONAPIDocument{Errors: errs})
+}
+
+// ProtoJSON serializes the given proto message, generated or dynamic, with the
+// canonical proto JSON mapping into the response body.
+// It also sets the Content-Type as "application/json".
+func (c *Context) ProtoJSON(code int, msg proto.Message) {
+	c.Render(code, render.ProtoJSON{Data: msg})
+}
+
 // String writes the given string into the response body.
 func (c *Context) String(code int, format string, values ...any) {
 	c.Render(code, render.String{Format: format, Data: values})
@@ -1115,17 +1293,55 @@ func escapeQuotes(s string) string {
 }
 
 // FileAttachment writes the specified file into the body stream in an efficient way
-// On the client side, the file will typically be downloaded with the given filename
+// On the client side, the file will typically be downloaded with the given filename.
+// The Range and If-Range requests are answered with the requested ranges of
+// the file, so that the downloads can be resumed.
 func (c *Context) FileAttachment(filepath, filename string) {
+	f, err := os.Open(filepath)
+	var stat os.FileInfo
+	if err == nil {
+		defer f.Close()
+		stat, err = f.Stat()
+	}
+	switch {
+	case errors.Is(err, fs.ErrNotExist) || err == nil && stat.IsDir():
+		http.Error(c.Writer, "404 page not found", http.StatusNotFound)
+		return
+	case errors.Is(err, fs.ErrPermission):
+		http.Error(c.Writer, "403 Forbidden", http.StatusForbidden)
+		return
+	case err != nil:
+		http.Error(c.Writer, "500 Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
 	if isASCII(filename) {
 		c.Writer.Header().Set("Content-Disposition", `attachment; filename="`+escapeQuotes(filename)+`"`)
 	} else {
 		c.Writer.Header().Set("Content-Disposition", `attachment; filename*=UTF-8''`+url.QueryEscape(filename))
 	}
-	http.ServeFile(c.Writer, c.Request, filepath)
+	http.ServeContent(bufferedCopyWriter{c.Writer}, c.Request, filepath, stat.ModTime(), f)
+}
+
+var copyBufferPool = sync.Pool{New: func() any {
+	buf := make([]byte, 32<<10)
+	return &buf
+}}
+
+// bufferedCopyWriter copies the bodies read from with a pooled buffer.
+type bufferedCopyWriter struct {
+	ResponseWriter
+}
+
+func (w bufferedCopyWriter) ReadFrom(r io.Reader) (int64, error) {
+	buf := copyBufferPool.Get().(*[]byte)
+	defer copyBufferPool.Put(buf)
+	// hide ReadFrom from io.CopyBuffer
+	return io.CopyBuffer(struct{ io.Writer }{w.ResponseWriter}, r, *buf)
 }
 
-// SSEvent writes a Server-Sent Event into the body stream.
+// SSEvent writes a Server-Sent Event into the body stream. See Context.SSE for
+// the streams with event IDs, heartbeats and client disconnect detection.
 func (c *Context) SSEvent(name string, message any) {
 	c.Render(-1, sse.Event{
 		Event: name,
@@ -1138,10 +1354,16 @@ func (c *Context) SSEvent(name string, message any) {
 func (c *Context) Stream(step func(w io.Writer) bool) bool {
 	w := c.Writer
 	clientGone := w.CloseNotify()
+	var done <-chan struct{}
+	if c.Request != nil {
+		done = c.Request.Context().Done()
+	}
 	for {
 		select {
 		case <-clientGone:
 			return true
+		case <-done:
+			return true
 		default:
 			keepOpen := step(w)
 			w.Flush()
@@ -1158,19 +1380,27 @@ func (c *Context) Stream(step func(w io.Writer) bool) bool {
 
 // Negotiate contains all negotiations data.
 type Negotiate struct {
-	Offered  []string
-	HTMLName string
-	HTMLData any
-	JSONData any
-	XMLData  any
-	YAMLData any
-	Data     any
-	TOMLData any
+	Offered     []string
+	HTMLName    string
+	HTMLData    any
+	JSONData    any
+	XMLData     any
+	YAMLData    any
+	Data        any
+	TOMLData    any
+	JSONAPIData any
+	// FormatData is the data of the formats registered with
+	// Engine.RegisterFormat, keyed by MIME type, defaulting to Data.
+	FormatData map[string]any
 }
 
 // Negotiate calls different Render according to acceptable Accept format.
+// Besides JSON, HTML, XML, YAML, TOML and JSON:API, the offered formats may
+// be any format registered with Engine.RegisterFormat, such as the built-in
+// protobuf and msgpack ones.
 func (c *Context) Negotiate(code int, config Negotiate) {
-	switch c.NegotiateFormat(config.Offered...) {
+	format := c.NegotiateFormat(config.Offered...)
+	switch format {
 	case binding.MIMEJSON:
 		data := chooseData(config.JSONData, config.Data)
 		c.JSON(code, data)
@@ -1191,23 +1421,37 @@ func (c *Context) Negotiate(code int, config Negotiate) {
 		data := chooseData(config.TOMLData, config.Data)
 		c.TOML(code, data)
 
+	case binding.MIMEJSONAPI:
+		data := chooseData(config.JSONAPIData, config.Data)
+		c.JSONAPI(code, data)
+
 	default:
+		if fn, ok := c.engine.renderFormats()[format]; ok {
+			data := chooseData(config.FormatData[format], config.Data)
+			c.Render(code, fn(data))
+			return
+		}
 		c.AbortWithError(http.StatusNotAcceptable, errors.New("the accepted formats are not offered by the server")) //nolint: errcheck
 	}
 }
 
-// NegotiateFormat returns an acceptable Accept format.
+// NegotiateFormat returns an acceptable Accept format. The media ranges of
+// the Accept header are tried by decreasing quality value, and the formats it
+// refuses with q=0 are never returned.
 func (c *Context) NegotiateFormat(offered ...string) string { // NOSONAR
 	assert1(len(offered) > 0, "you must provide at least one offer")
 
 	if c.Accepted == nil {
-		c.Accepted = parseAccept(c.requestHeader("Accept"))
+		c.Accepted, c.rejected = parseAccept(c.requestHeader("Accept"))
 	}
-	if len(c.Accepted) == 0 {
+	if len(c.Accepted) == 0 && len(c.rejected) == 0 {
 		return offered[0]
 	}
 	for _, accepted := range c.Accepted {
 		for _, offer := range offered {
+			if slices.Contains(c.rejected, offer) {
+				continue
+			}
 			// According to RFC 2616 and RFC 2396, non-ASCII characters are not allowed in headers,
 			// therefore we can just iterate over the string without casting it into []rune
 			i := 0
@@ -1243,17 +1487,24 @@ func (c *Context) hasRequestContext() bool {
 	return hasFallback && hasRequestContext
 }
 
+// hasRequestDeadline returns whether c.Request has Context and fallback, or a
+// deadline set by a timeout or SetDeadline.
+func (c *Context) hasRequestDeadline() bool {
+	return c.deadlined || c.hasRequestContext()
+}
+
 // Deadline returns that there is no deadline (ok==false) when c.Request has no Context.
 func (c *Context) Deadline() (deadline time.Time, ok bool) {
-	if !c.hasRequestContext() {
+	if !c.hasRequestDeadline() {
 		return
 	}
 	return c.Request.Context().Deadline()
 }
 
 // Done returns nil (chan which will wait forever) when c.Request has no Context.
+// It is closed once the deadline of the request passes, see SetDeadline.
 func (c *Context) Done() <-chan struct{} {
-	if !c.hasRequestContext() {
+	if !c.hasRequestDeadline() {
 		return nil
 	}
 	return c.Request.Context().Done()
@@ -1261,7 +1512,7 @@ func (c *Context) Done() <-chan struct{} {
 
 // Err returns nil when c.Request has no Context.
 func (c *Context) Err() error {
-	if !c.hasRequestContext() {
+	if !c.hasRequestDeadline() {
 		return nil
 	}
 	return c.Request.Context().Err()
@@ -17,6 +17,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"sync"
@@ -25,9 +26,13 @@ import (
 
 	"github.com/gin-contrib/sse"
 	"github.com/jialequ/mpgw/binding"
+	"github.com/jialequ/mpgw/render"
 	testdata "github.com/jialequ/mpgw/testdata/protoexample"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 var _ context.Context = (*Context)(nil)
@@ -86,7 +91,7 @@ func TestContextFormFile(t *testing.T) {
 		assert.Equal(t, "test", f.Filename)
 	}
 
-	assert.NoError(t, c.SaveUploadedFile(f, "test"))
+	assert.NoError(t, c.SaveUploadedFile(f, filepath.Join(t.TempDir(), "test")))
 }
 
 func TestContextFormFileFailed(t *testing.T) {
@@ -120,7 +125,7 @@ func TestContextMultipartForm(t *testing.T) {
 		assert.NotNil(t, f)
 	}
 
-	assert.NoError(t, c.SaveUploadedFile(f.File["file"][0], "test"))
+	assert.NoError(t, c.SaveUploadedFile(f.File["file"][0], filepath.Join(t.TempDir(), "test")))
 }
 
 func TestSaveUploadedOpenFailed(t *testing.T) {
@@ -135,7 +140,7 @@ func TestSaveUploadedOpenFailed(t *testing.T) {
 	f := &multipart.FileHeader{
 		Filename: "file",
 	}
-	assert.Error(t, c.SaveUploadedFile(f, "test"))
+	assert.Error(t, c.SaveUploadedFile(f, filepath.Join(t.TempDir(), "test")))
 }
 
 func TestSaveUploadedCreateFailed(t *testing.T) {
@@ -742,6 +747,23 @@ func TestContextRenderJSON(t *testing.T) {
 	assert.Equal(t, literal_2634, w.Header().Get(literal_9251))
 }
 
+func TestContextRenderJSONCanceled(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := CreateTestContext(w)
+	ctx, cancel := context.WithCancel(context.Background())
+	c.Request, _ = http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+
+	c.JSON(http.StatusOK, []int{1, 2})
+	assert.Equal(t, "[1,2]", w.Body.String())
+
+	cancel()
+	w.Body.Reset()
+	c.JSON(http.StatusOK, []int{1, 2})
+	assert.Empty(t, w.Body.String())
+	assert.True(t, c.IsAborted())
+	assert.ErrorIs(t, c.Errors.Last(), context.Canceled)
+}
+
 // Tests that the response is serialized as JSONP
 // and Content-Type is set to application/javascript
 func TestContextRenderJSONP(t *testing.T) {
@@ -1125,6 +1147,62 @@ func TestContextRenderUTF8Attachment(t *testing.T) {
 	assert.Equal(t, `attachment; filename*=UTF-8''`+url.QueryEscape(newFilename), w.Header().Get(literal_8794))
 }
 
+func TestContextRenderRangeAttachment(t *testing.T) {
+	content := strings.Repeat("0123456789", 10000)
+	path := filepath.Join(t.TempDir(), "data.bin")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	stat, err := os.Stat(path)
+	require.NoError(t, err)
+	modified := stat.ModTime().UTC().Format(http.TimeFormat)
+
+	router := New()
+	router.GET("/file", func(c *Context) {
+		c.FileAttachment(path, "data.bin")
+	})
+	router.HEAD("/file", func(c *Context) {
+		c.FileAttachment(path, "data.bin")
+	})
+	router.GET("/missing", func(c *Context) {
+		c.FileAttachment(path+".missing", "data.bin")
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/file")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, content, w.Body.String())
+	assert.Equal(t, "bytes", w.Header().Get("Accept-Ranges"))
+	assert.Equal(t, `attachment; filename="data.bin"`, w.Header().Get(literal_8794))
+
+	w = PerformRequest(router, http.MethodGet, "/file", header{"Range", "bytes=50000-50009"})
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "0123456789", w.Body.String())
+	assert.Equal(t, "bytes 50000-50009/100000", w.Header().Get("Content-Range"))
+	assert.Equal(t, "10", w.Header().Get("Content-Length"))
+
+	// resumed from an offset
+	w = PerformRequest(router, http.MethodGet, "/file", header{"Range", "bytes=99995-"}, header{"If-Range", modified})
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "56789", w.Body.String())
+
+	// the file changed since
+	w = PerformRequest(router, http.MethodGet, "/file", header{"Range", "bytes=99995-"},
+		header{"If-Range", stat.ModTime().Add(-time.Hour).UTC().Format(http.TimeFormat)})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, w.Body.String(), len(content))
+
+	w = PerformRequest(router, http.MethodGet, "/file", header{"Range", "bytes=200000-"})
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, w.Code)
+	assert.Equal(t, "bytes */100000", w.Header().Get("Content-Range"))
+
+	w = PerformRequest(router, http.MethodHead, "/file")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "100000", w.Header().Get("Content-Length"))
+	assert.Empty(t, w.Body.String())
+
+	w = PerformRequest(router, http.MethodGet, "/missing")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, w.Header().Get(literal_8794))
+}
+
 // TestContextRenderYAML tests that the response is serialized as YAML
 // and Content-Type is set to application/yaml
 func TestContextRenderYAML(t *testing.T) {
@@ -1793,6 +1871,35 @@ func TestContextShouldBindHeader(t *testing.T) {
 	assert.Equal(t, 0, w.Body.Len())
 }
 
+func TestContextShouldBindAll(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := CreateTestContext(w)
+
+	c.Request, _ = http.NewRequest("POST", "/users/42?verbose=true", bytes.NewBufferString(`{"name":"gin"}`))
+	c.Request.Header.Add("Content-Type", MIMEJSON)
+	c.Request.Header.Add("X-Request-Id", "abc")
+	c.Params = Params{{Key: "id", Value: "42"}}
+
+	var obj struct {
+		ID        int    `uri:"id" binding:"required"`
+		RequestID string `header:"X-Request-Id"`
+		Verbose   bool   `form:"verbose"`
+		Name      string `json:"name" binding:"required"`
+	}
+	assert.NoError(t, c.ShouldBindAll(&obj))
+	assert.Equal(t, 42, obj.ID)
+	assert.Equal(t, "abc", obj.RequestID)
+	assert.True(t, obj.Verbose)
+	assert.Equal(t, "gin", obj.Name)
+	assert.Equal(t, 0, w.Body.Len())
+
+	c.Request, _ = http.NewRequest("POST", "/users/x", nil)
+	c.Params = Params{{Key: "id", Value: "x"}}
+	assert.Error(t, c.BindAll(&obj))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.True(t, c.IsAborted())
+}
+
 func TestContextShouldBindWithQuery(t *testing.T) {
 	w := httptest.NewRecorder()
 	c, _ := CreateTestContext(w)
@@ -2755,6 +2862,67 @@ func TestInterceptedHeader(t *testing.T) {
 	assert.Equal(t, "present", w.Result().Header.Get("X-Test-2"))
 }
 
+func TestContextShouldBindProto(t *testing.T) {
+	desc := (&descriptorpb.FileDescriptorProto{}).ProtoReflect().Descriptor()
+
+	c, _ := CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest(http.MethodPost, "/", strings.NewReader(`{"unknown":"gin"}`))
+	c.Request.Header.Add("Content-Type", MIMEJSON)
+	_, err := c.ShouldBindProto(desc)
+	assert.Error(t, err)
+
+	c.Request, _ = http.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"gin.proto"}`))
+	c.Request.Header.Add("Content-Type", MIMEJSON)
+	msg, err := c.ShouldBindProto(desc)
+	require.NoError(t, err)
+	assert.Equal(t, "gin.proto", msg.Get(desc.Fields().ByName("name")).String())
+
+	c.Request, _ = http.NewRequest(http.MethodGet, "/?package=gin&dependency=a&dependency=b", nil)
+	msg, err = c.ShouldBindProto(desc)
+	require.NoError(t, err)
+	assert.Equal(t, "gin", msg.Get(desc.Fields().ByName("package")).String())
+	assert.Equal(t, 2, msg.Get(desc.Fields().ByName("dependency")).List().Len())
+}
+
+func TestContextRenderProtoJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := CreateTestContext(w)
+
+	c.ProtoJSON(http.StatusCreated, wrapperspb.String("gin"))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.JSONEq(t, `"gin"`, w.Body.String())
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+}
+
+func TestContextRenderJSONAPI(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodGet, "/", nil)
+	c.Request.Header.Add("Accept", MIMEJSONAPI)
+
+	c.Negotiate(http.StatusOK, Negotiate{
+		Offered: []string{MIMEJSON, MIMEJSONAPI},
+		Data:    render.JSONAPIResource{Type: "articles", ID: "1"},
+	})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":{"type":"articles","id":"1"}}`, w.Body.String())
+	assert.Equal(t, MIMEJSONAPI, w.Header().Get("Content-Type"))
+}
+
+func TestContextJSONAPIErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := CreateTestContext(w)
+	c.Error(errors.New("invalid title")) //nolint: errcheck
+
+	c.JSONAPIErrors(http.StatusUnprocessableEntity)
+
+	assert.True(t, c.IsAborted())
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.JSONEq(t, `{"errors":[{"status":"422","title":"Unprocessable Entity","detail":"invalid title"}]}`, w.Body.String())
+}
+
 const literal_6170 = "31/12/2016 14:55"
 
 const literal_9251 = "Content-Type"
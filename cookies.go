@@ -0,0 +1,199 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCookie is returned for the signed and encrypted cookies which were
+// tampered with, signed or encrypted with an unknown key, or which expired.
+var ErrInvalidCookie = errors.New("cookie: invalid or expired")
+
+// minCookieKeyLen is the minimum length of the cookie keys.
+const minCookieKeyLen = 32
+
+// cookieKeys are the keys derived from the cookie keys of an engine, the
+// first ones signing and encrypting the cookies.
+type cookieKeys struct {
+	sign [][]byte
+	aead []cipher.AEAD
+}
+
+// SetCookieKeys sets the secret keys of the signed and encrypted cookies, see
+// Context.SetSignedCookie and Context.SetEncryptedCookie. The first key signs
+// and encrypts the cookies, all of them verify and decrypt them: to rotate
+// the keys, a new key is prepended, and the old one removed once the cookies
+// it signed expired. The keys are random, of at least 32 bytes, and can be
+// set while the engine serves requests:
+//
+//	router.SetCookieKeys(newKey, oldKey)
+//
+// It panics if a key is too short. Without keys, the signed and encrypted
+// cookies panic.
+func (engine *Engine) SetCookieKeys(keys ...[]byte) {
+	if len(keys) == 0 {
+		engine.cookieKeys.Store(nil)
+		return
+	}
+	derived := &cookieKeys{}
+	for _, key := range keys {
+		assert1(len(key) >= minCookieKeyLen, "cookie keys must be at least "+strconv.Itoa(minCookieKeyLen)+" bytes")
+		derived.sign = append(derived.sign, deriveCookieKey(key, "signed"))
+		block, err := aes.NewCipher(deriveCookieKey(key, "encrypted"))
+		if err != nil {
+			panic(err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			panic(err)
+		}
+		derived.aead = append(derived.aead, aead)
+	}
+	engine.cookieKeys.Store(derived)
+}
+
+// deriveCookieKey derives the key of a purpose from a cookie key, not to use
+// the same key to sign and to encrypt.
+func deriveCookieKey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("gin cookie " + purpose)) //nolint: errcheck
+	return mac.Sum(nil)
+}
+
+// cookieKeys returns the cookie keys of the engine.
+func (c *Context) cookieKeys() *cookieKeys {
+	var keys *cookieKeys
+	if c.engine != nil {
+		keys = c.engine.cookieKeys.Load()
+	}
+	if keys == nil {
+		panic("the cookie keys are not set, see Engine.SetCookieKeys")
+	}
+	return keys
+}
+
+// cookieExpiry returns the expiry of a cookie of maxAge, as unix seconds, 0 for
+// the session cookies.
+func (c *Context) cookieExpiry(maxAge int) int64 {
+	if maxAge <= 0 {
+		return 0
+	}
+	return c.now().Add(time.Duration(maxAge) * time.Second).Unix()
+}
+
+// cookieExpired reports whether a cookie of expiry expired.
+func (c *Context) cookieExpired(expiry int64) bool {
+	return expiry != 0 && c.now().Unix() >= expiry
+}
+
+// SetSignedCookie adds a cookie as SetCookie does, signed with the cookie keys
+// of the engine, see Engine.SetCookieKeys. Its value is readable by the client
+// but cannot be modified, see SignedCookie. The signature covers the name and
+// the expiry of the cookie, which cannot be used once expired.
+func (c *Context) SetSignedCookie(name, value string, maxAge int, path, domain string, secure, httpOnly bool) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(value)) + "." + strconv.FormatInt(c.cookieExpiry(maxAge), 10)
+	mac := cookieMAC(c.cookieKeys().sign[0], name, payload)
+	c.SetCookie(name, payload+"."+base64.RawURLEncoding.EncodeToString(mac), maxAge, path, domain, secure, httpOnly)
+}
+
+// SignedCookie returns the value of the named cookie set by SetSignedCookie,
+// http.ErrNoCookie if not found, or ErrInvalidCookie if its signature is not
+// valid or it expired.
+func (c *Context) SignedCookie(name string) (string, error) {
+	keys := c.cookieKeys()
+	cookie, err := c.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	i := strings.LastIndexByte(cookie, '.')
+	if i < 0 {
+		return "", ErrInvalidCookie
+	}
+	payload := cookie[:i]
+	mac, err := base64.RawURLEncoding.DecodeString(cookie[i+1:])
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	valid := false
+	for _, key := range keys.sign {
+		if hmac.Equal(mac, cookieMAC(key, name, payload)) {
+			valid = true
+			break
+		}
+	}
+	encoded, expiry, _ := strings.Cut(payload, ".")
+	exp, err := strconv.ParseInt(expiry, 10, 64)
+	if !valid || err != nil || c.cookieExpired(exp) {
+		return "", ErrInvalidCookie
+	}
+	value, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	return string(value), nil
+}
+
+// cookieMAC returns the signature of the payload of the named cookie.
+func cookieMAC(key []byte, name, payload string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(name + "=" + payload)) //nolint: errcheck
+	return mac.Sum(nil)
+}
+
+// SetEncryptedCookie adds a cookie as SetCookie does, encrypted with AES-GCM
+// with the cookie keys of the engine, see Engine.SetCookieKeys. Its value can
+// neither be read nor modified by the client, see EncryptedCookie. As for the
+// signed cookies, its name and expiry are authenticated.
+func (c *Context) SetEncryptedCookie(name, value string, maxAge int, path, domain string, secure, httpOnly bool) {
+	aead := c.cookieKeys().aead[0]
+	plaintext := binary.BigEndian.AppendUint64(make([]byte, 0, 8+len(value)), uint64(c.cookieExpiry(maxAge)))
+	plaintext = append(plaintext, value...)
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		panic(err)
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(name))
+	c.SetCookie(name, base64.RawURLEncoding.EncodeToString(sealed), maxAge, path, domain, secure, httpOnly)
+}
+
+// EncryptedCookie returns the value of the named cookie set by
+// SetEncryptedCookie, http.ErrNoCookie if not found, or ErrInvalidCookie if it
+// cannot be decrypted or it expired.
+func (c *Context) EncryptedCookie(name string) (string, error) {
+	keys := c.cookieKeys()
+	cookie, err := c.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(cookie)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	for _, aead := range keys.aead {
+		if len(sealed) < aead.NonceSize() {
+			break
+		}
+		plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(name))
+		if err != nil {
+			continue
+		}
+		if len(plaintext) < 8 || c.cookieExpired(int64(binary.BigEndian.Uint64(plaintext))) {
+			break
+		}
+		return string(plaintext[8:]), nil
+	}
+	return "", ErrInvalidCookie
+}
@@ -0,0 +1,168 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testCookieKey  = bytes.Repeat([]byte("k"), 32)
+	testCookieKey2 = bytes.Repeat([]byte("n"), 32)
+)
+
+// cookieRouter returns a router setting the cookie of a name, signed or
+// encrypted, with the value of the v query param, and echoing it.
+func cookieRouter(clock Clock) *Engine {
+	router := New()
+	router.Clock = clock
+	router.SetCookieKeys(testCookieKey)
+	router.GET("/:kind/set", func(c *Context) {
+		if c.Param("kind") == "signed" {
+			c.SetSignedCookie("session", c.Query("v"), 60, "/", "", true, true)
+		} else {
+			c.SetEncryptedCookie("session", c.Query("v"), 60, "/", "", true, true)
+		}
+	})
+	router.GET("/:kind/get", func(c *Context) {
+		read := c.EncryptedCookie
+		if c.Param("kind") == "signed" {
+			read = c.SignedCookie
+		}
+		value, err := read(c.DefaultQuery("name", "session"))
+		if err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		c.String(http.StatusOK, value)
+	})
+	return router
+}
+
+// getCookie performs a request with a cookie.
+func getCookie(router *Engine, path string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestSignedAndEncryptedCookies(t *testing.T) {
+	for _, kind := range []string{"signed", "encrypted"} {
+		t.Run(kind, func(t *testing.T) {
+			clock := NewFakeClock(time.Unix(1700000000, 0))
+			router := cookieRouter(clock)
+
+			w := PerformRequest(router, http.MethodGet, "/"+kind+"/set?v=user%3D42%3B+admin")
+			cookies := w.Result().Cookies()
+			require.Len(t, cookies, 1)
+			cookie := cookies[0]
+			assert.Equal(t, 60, cookie.MaxAge)
+			assert.True(t, cookie.Secure)
+			assert.True(t, cookie.HttpOnly)
+			assert.Equal(t, kind == "signed", strings.HasPrefix(cookie.Value, "dXNlcj00MjsgYWRtaW4."), cookie.Value)
+
+			w = getCookie(router, "/"+kind+"/get", cookie)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "user=42; admin", w.Body.String())
+			assert.Equal(t, http.ErrNoCookie.Error(), getCookie(router, "/"+kind+"/get", nil).Body.String())
+
+			// tampered with or renamed
+			for i := range cookie.Value {
+				flipped := []byte(cookie.Value)
+				if flipped[i] == 'A' {
+					flipped[i] = 'B'
+				} else {
+					flipped[i] = 'A'
+				}
+				if i == len(flipped)-1 {
+					// the last character has unused bits
+					flipped = append(flipped, 'A')
+				}
+				w := getCookie(router, "/"+kind+"/get", &http.Cookie{Name: "session", Value: string(flipped)})
+				assert.Equal(t, ErrInvalidCookie.Error(), w.Body.String(), string(flipped))
+			}
+			for _, value := range []string{"garbage", "a.b.c", "-1.0.AAAA", ""} {
+				w := getCookie(router, "/"+kind+"/get", &http.Cookie{Name: "session", Value: value})
+				assert.Equal(t, ErrInvalidCookie.Error(), w.Body.String(), value)
+			}
+			w = getCookie(router, "/"+kind+"/get?name=other", &http.Cookie{Name: "other", Value: cookie.Value})
+			assert.Equal(t, ErrInvalidCookie.Error(), w.Body.String())
+
+			// the keys rotate
+			router.SetCookieKeys(testCookieKey2, testCookieKey)
+			assert.Equal(t, "user=42; admin", getCookie(router, "/"+kind+"/get", cookie).Body.String())
+			rotated := PerformRequest(router, http.MethodGet, "/"+kind+"/set?v=new").Result().Cookies()[0]
+			router.SetCookieKeys(testCookieKey2)
+			assert.Equal(t, ErrInvalidCookie.Error(), getCookie(router, "/"+kind+"/get", cookie).Body.String())
+			assert.Equal(t, "new", getCookie(router, "/"+kind+"/get", rotated).Body.String())
+
+			// the cookies expire
+			clock.Advance(59 * time.Second)
+			assert.Equal(t, "new", getCookie(router, "/"+kind+"/get", rotated).Body.String())
+			clock.Advance(time.Second)
+			assert.Equal(t, ErrInvalidCookie.Error(), getCookie(router, "/"+kind+"/get", rotated).Body.String())
+		})
+	}
+}
+
+func TestEncryptedCookieNonce(t *testing.T) {
+	router := cookieRouter(nil)
+	first := PerformRequest(router, http.MethodGet, "/encrypted/set?v=same").Result().Cookies()[0]
+	second := PerformRequest(router, http.MethodGet, "/encrypted/set?v=same").Result().Cookies()[0]
+	assert.NotEqual(t, first.Value, second.Value)
+	assert.NotContains(t, first.Value, "c2FtZQ")
+}
+
+func TestSessionCookies(t *testing.T) {
+	c, _ := CreateTestContext(httptest.NewRecorder())
+	c.engine.SetCookieKeys(testCookieKey)
+	c.SetSignedCookie("signed", "a", 0, "/", "", false, false)
+	c.SetEncryptedCookie("encrypted", "b", 0, "/", "", false, false)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, cookie := range (&http.Response{Header: c.Writer.Header()}).Cookies() {
+		assert.Zero(t, cookie.MaxAge)
+		req.AddCookie(cookie)
+	}
+	c.Request = req
+	value, err := c.SignedCookie("signed")
+	require.NoError(t, err)
+	assert.Equal(t, "a", value)
+	value, err = c.EncryptedCookie("encrypted")
+	require.NoError(t, err)
+	assert.Equal(t, "b", value)
+}
+
+func TestCookieKeysRequired(t *testing.T) {
+	c, _ := CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.PanicsWithValue(t, "the cookie keys are not set, see Engine.SetCookieKeys", func() {
+		c.SetSignedCookie("session", "v", 0, "/", "", false, false)
+	})
+	assert.PanicsWithValue(t, "the cookie keys are not set, see Engine.SetCookieKeys", func() {
+		c.EncryptedCookie("session") //nolint: errcheck
+	})
+	assert.PanicsWithValue(t, "cookie keys must be at least 32 bytes", func() {
+		c.engine.SetCookieKeys(testCookieKey, []byte("short"))
+	})
+
+	c.engine.SetCookieKeys(testCookieKey)
+	clone := c.engine.Clone()
+	c.engine.SetCookieKeys()
+	assert.NotNil(t, clone.cookieKeys.Load())
+	assert.Nil(t, c.engine.cookieKeys.Load())
+}
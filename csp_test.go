@@ -0,0 +1,74 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSPNonce(t *testing.T) {
+	c, _ := CreateTestContext(httptest.NewRecorder())
+	nonce := c.CSPNonce()
+	assert.Len(t, nonce, 24)
+	assert.Equal(t, nonce, c.CSPNonce())
+	assert.Equal(t, nonce, c.Copy().CSPNonce())
+
+	c.reset()
+	assert.NotEqual(t, nonce, c.CSPNonce())
+}
+
+func TestContentSecurityPolicy(t *testing.T) {
+	for _, tt := range []struct{ policy, want string }{
+		{"default-src 'self'; script-src 'self'", "default-src 'self'; script-src 'self' 'nonce-N'"},
+		{"script-src 'self';style-src 'self'; img-src *;", "script-src 'self' 'nonce-N'; style-src 'self' 'nonce-N'; img-src *"},
+		{"default-src 'self'; img-src *", "default-src 'self' 'nonce-N'; img-src *"},
+		{"img-src *", "img-src *; script-src 'nonce-N'"},
+		{"", "script-src 'nonce-N'"},
+	} {
+		router := New()
+		var nonce string
+		router.Use(ContentSecurityPolicy(tt.policy))
+		router.GET("/", func(c *Context) {
+			nonce = c.CSPNonce()
+		})
+		w := PerformRequest(router, http.MethodGet, "/")
+		assert.Equal(t, strings.ReplaceAll(tt.want, "'nonce-N'", "'nonce-"+nonce+"'"), w.Header().Get("Content-Security-Policy"), tt.policy)
+	}
+}
+
+func TestCSPNonceTemplate(t *testing.T) {
+	router := New()
+	router.SetHTMLTemplate(template.Must(template.New("page").Funcs(template.FuncMap{"cspNonce": TemplateCSPNonce}).
+		Parse(`<script nonce="{{cspNonce}}">go()</script><style nonce={{cspNonce}}></style>`)))
+	router.Use(ContentSecurityPolicy("script-src 'self'"))
+	router.GET("/", func(c *Context) {
+		c.HTML(http.StatusOK, "page", nil)
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/")
+	policy := w.Header().Get("Content-Security-Policy")
+	require.True(t, strings.HasPrefix(policy, "script-src 'self' 'nonce-"))
+	nonce := strings.TrimSuffix(strings.TrimPrefix(policy, "script-src 'self' 'nonce-"), "'")
+	assert.Equal(t, `<script nonce="`+nonce+`">go()</script><style nonce=`+nonce+`></style>`, w.Body.String())
+	assert.NotEqual(t, w.Body.String(), PerformRequest(router, http.MethodGet, "/").Body.String())
+
+	// without the middleware
+	router = New()
+	router.SetHTMLTemplate(template.Must(template.New("page").Funcs(template.FuncMap{"cspNonce": TemplateCSPNonce}).
+		Parse(`<script nonce="{{cspNonce}}"></script>`)))
+	router.GET("/", func(c *Context) {
+		c.Header("Content-Security-Policy", "script-src 'nonce-"+c.CSPNonce()+"'")
+		c.HTML(http.StatusOK, "page", nil)
+	})
+	w = PerformRequest(router, http.MethodGet, "/")
+	assert.Contains(t, w.Header().Get("Content-Security-Policy"), strings.TrimSuffix(strings.TrimPrefix(w.Body.String(), `<script nonce="`), `"></script>`))
+}
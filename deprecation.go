@@ -0,0 +1,163 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxDeprecatedCallers is the number of callers of a deprecated route whose
+// calls are counted and logged one by one, so that the clients rotating their
+// IPs do not grow the usage without bound.
+const maxDeprecatedCallers = 1000
+
+// RouteDeprecation describes a deprecated route, see RouteHandle.Deprecated.
+type RouteDeprecation struct {
+	// Sunset is when the route stops being served, zero if unknown.
+	Sunset time.Time `json:"sunset"`
+	// Message tells the clients what to use instead.
+	Message string `json:"message,omitempty"`
+
+	link string
+
+	mu         sync.Mutex
+	calls      uint64
+	lastCall   time.Time
+	callers    map[string]uint64
+	otherCalls uint64
+}
+
+// DeprecationUsage is the usage of a deprecated route since the engine started.
+type DeprecationUsage struct {
+	Method   string
+	Path     string
+	Sunset   time.Time
+	Calls    uint64
+	LastCall time.Time
+	// Callers counts the calls per caller identity: the authenticated user set
+	// under AuthUserKey if any, else the client IP. Only the first 1000
+	// callers are counted, the calls of the next ones go to OtherCalls.
+	Callers    map[string]uint64
+	OtherCalls uint64
+}
+
+// Deprecated marks the routes as deprecated. Their responses get the
+// Deprecation, Sunset (if sunset is not zero) and Link headers, and their first
+// use by each caller is logged to gin.DefaultWriter. If message contains a path
+// or URL, e.g. "use /v2/users", it is advertised as the successor-version Link.
+// See Engine.DeprecatedUsage.
+func (h *RouteHandle) Deprecated(sunset time.Time, message string) *RouteHandle {
+	engine := h.engine
+	return h.annotate(func(route *Route) {
+		route.Deprecation = &RouteDeprecation{
+			Sunset:  sunset,
+			Message: message,
+			link:    successorLink(message),
+		}
+		if engine.deprecated == nil {
+			engine.deprecated = make(map[string]*Route)
+		}
+		engine.deprecated[routeKey(route.Host, route.Method, route.Path)] = route
+	})
+}
+
+// successorLink returns the first word of message which is a path or a URL.
+func successorLink(message string) string {
+	for _, word := range strings.Fields(message) {
+		word = strings.TrimRight(word, ".,;)")
+		if strings.HasPrefix(word, "/") || strings.Contains(word, "://") {
+			return word
+		}
+	}
+	return ""
+}
+
+// deprecatedRoute returns the route if it is deprecated.
+func (engine *Engine) deprecatedRoute(host, method, fullPath string) *Route {
+	return engine.table.Load().deprecated[routeKey(host, method, fullPath)]
+}
+
+// serveDeprecated serves a request of a deprecated route.
+func serveDeprecated(c *Context, route *Route) {
+	dep := route.Deprecation
+	header := c.Writer.Header()
+	header.Set("Deprecation", "true")
+	if !dep.Sunset.IsZero() {
+		header.Set("Sunset", dep.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if dep.link != "" {
+		header.Add("Link", "<"+dep.link+`>; rel="successor-version"`)
+	}
+
+	c.Next()
+
+	caller := c.GetString(AuthUserKey)
+	if caller == "" {
+		caller = c.ClientIP()
+	}
+	dep.mu.Lock()
+	dep.calls++
+	dep.lastCall = c.now()
+	if dep.callers == nil {
+		dep.callers = make(map[string]uint64)
+	}
+	first, full := false, false
+	if n, ok := dep.callers[caller]; ok || len(dep.callers) < maxDeprecatedCallers {
+		dep.callers[caller] = n + 1
+		first = n == 0
+	} else {
+		dep.otherCalls++
+		full = dep.otherCalls == 1
+	}
+	dep.mu.Unlock()
+
+	if first {
+		fmt.Fprintf(DefaultWriter, "[GIN-deprecated] %s %s called by %q (%s), sunset %s\n",
+			route.Method, route.Path, caller, c.Request.UserAgent(), sunsetString(dep.Sunset))
+	}
+	if full {
+		fmt.Fprintf(DefaultWriter, "[GIN-deprecated] %s %s called by more than %d callers, the next ones are not logged\n",
+			route.Method, route.Path, maxDeprecatedCallers)
+	}
+}
+
+func sunsetString(sunset time.Time) string {
+	if sunset.IsZero() {
+		return "unknown"
+	}
+	return sunset.UTC().Format(time.DateOnly)
+}
+
+// DeprecatedUsage returns the usage of the deprecated routes, in registration order,
+// to drive their removal.
+func (engine *Engine) DeprecatedUsage() []DeprecationUsage {
+	var usage []DeprecationUsage
+	for _, route := range engine.table.Load().routes {
+		dep := route.Deprecation
+		if dep == nil {
+			continue
+		}
+		dep.mu.Lock()
+		callers := make(map[string]uint64, len(dep.callers))
+		for caller, n := range dep.callers {
+			callers[caller] = n
+		}
+		usage = append(usage, DeprecationUsage{
+			Method:     route.Method,
+			Path:       route.Path,
+			Sunset:     dep.Sunset,
+			Calls:      dep.calls,
+			LastCall:   dep.lastCall,
+			Callers:    callers,
+			OtherCalls: dep.otherCalls,
+		})
+		dep.mu.Unlock()
+	}
+	return usage
+}
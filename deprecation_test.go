@@ -0,0 +1,100 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteDeprecated(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	defaultWriter := DefaultWriter
+	defer func() { DefaultWriter = defaultWriter }()
+	DefaultWriter = buffer
+
+	sunset := time.Date(2030, time.January, 2, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(time.Unix(1700000000, 0))
+	router := New()
+	router.Clock = clock
+	v1 := router.Group("/v1", func(c *Context) {
+		if user := c.GetHeader("X-User"); user != "" {
+			c.Set(AuthUserKey, user)
+		}
+	})
+	v1.GET("/users/:id", handlerTest1)
+	v1.Route("/users/:id", http.MethodGet).Deprecated(sunset, "use /v2/users/:id instead.")
+	router.GET("/v2/users/:id", handlerTest1)
+
+	w := PerformRequest(router, http.MethodGet, "/v1/users/1", header{Key: "X-User", Value: "alice"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "true", w.Header().Get("Deprecation"))
+	assert.Equal(t, "Wed, 02 Jan 2030 00:00:00 GMT", w.Header().Get("Sunset"))
+	assert.Equal(t, `</v2/users/:id>; rel="successor-version"`, w.Header().Get("Link"))
+
+	PerformRequest(router, http.MethodGet, "/v1/users/2", header{Key: "X-User", Value: "alice"})
+	PerformRequest(router, http.MethodGet, "/v1/users/3")
+	w = PerformRequest(router, http.MethodGet, "/v2/users/1")
+	assert.Empty(t, w.Header().Get("Deprecation"))
+
+	assert.Equal(t, 2, bytes.Count(buffer.Bytes(), []byte("[GIN-deprecated] GET /v1/users/:id")))
+	assert.Contains(t, buffer.String(), `called by "alice"`)
+	assert.Contains(t, buffer.String(), "sunset 2030-01-02")
+
+	usage := router.DeprecatedUsage()
+	require.Len(t, usage, 1)
+	assert.Equal(t, "/v1/users/:id", usage[0].Path)
+	assert.Equal(t, sunset, usage[0].Sunset)
+	assert.Equal(t, uint64(3), usage[0].Calls)
+	assert.Equal(t, uint64(2), usage[0].Callers["alice"])
+	assert.Len(t, usage[0].Callers, 2)
+	assert.Equal(t, clock.Now(), usage[0].LastCall)
+
+	docs := router.RouteDocs()
+	require.NotNil(t, docs[0].Deprecation)
+	assert.Equal(t, "use /v2/users/:id instead.", docs[0].Deprecation.Message)
+	assert.Nil(t, docs[1].Deprecation)
+}
+
+func TestRouteDeprecatedWithoutSunset(t *testing.T) {
+	router := New()
+	router.GET("/old", handlerTest1)
+	router.Route("/old", http.MethodGet).Deprecated(time.Time{}, "no replacement")
+
+	w := PerformRequest(router, http.MethodGet, "/old")
+	assert.Equal(t, "true", w.Header().Get("Deprecation"))
+	assert.Empty(t, w.Header().Get("Sunset"))
+	assert.Empty(t, w.Header().Get("Link"))
+}
+
+func TestRouteDeprecatedCallersLimit(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	defaultWriter := DefaultWriter
+	defer func() { DefaultWriter = defaultWriter }()
+	DefaultWriter = buffer
+
+	router := New()
+	router.Use(func(c *Context) { c.Set(AuthUserKey, c.GetHeader("X-User")) })
+	router.GET("/old", handlerTest1)
+	router.Route("/old").Deprecated(time.Time{}, "")
+	for i := 0; i < maxDeprecatedCallers+5; i++ {
+		PerformRequest(router, http.MethodGet, "/old", header{Key: "X-User", Value: strconv.Itoa(i)})
+	}
+	PerformRequest(router, http.MethodGet, "/old", header{Key: "X-User", Value: "0"})
+
+	usage := router.DeprecatedUsage()
+	require.Len(t, usage, 1)
+	assert.Equal(t, uint64(maxDeprecatedCallers+6), usage[0].Calls)
+	assert.Len(t, usage[0].Callers, maxDeprecatedCallers)
+	assert.Equal(t, uint64(2), usage[0].Callers["0"])
+	assert.Equal(t, uint64(5), usage[0].OtherCalls)
+	assert.Equal(t, maxDeprecatedCallers+1, bytes.Count(buffer.Bytes(), []byte("[GIN-deprecated]")))
+}
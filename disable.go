@@ -0,0 +1,111 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrRouteNotRegistered is returned by Engine.DisableRoute and
+// Engine.EnableRoute when no route is registered for the method and path.
+var ErrRouteNotRegistered = errors.New("route not registered")
+
+// DisableRoute makes the route registered for method and path answer status,
+// such as 404, 410 or 503, without running its handlers, until EnableRoute is
+// called. The route stays in the routing tree, so it can be disabled and enabled
+// at runtime, during an incident or a staged rollout. The engine middlewares
+// still run, as for the unknown routes. status must be a 4xx or 5xx status.
+func (engine *Engine) DisableRoute(method, path string, status int) error {
+	return engine.disableRoute("", method, path, status, time.Time{})
+}
+
+// DisableRouteUntil is DisableRoute for a maintenance ending at until: the
+// Retry-After header of the responses advertises the end, and the route is
+// served again once it is reached.
+func (engine *Engine) DisableRouteUntil(method, path string, status int, until time.Time) error {
+	return engine.disableRoute("", method, path, status, until)
+}
+
+// EnableRoute enables again a route disabled by DisableRoute.
+func (engine *Engine) EnableRoute(method, path string) error {
+	return engine.disableRoute("", method, path, 0, time.Time{})
+}
+
+// DisableHostRoute is DisableRoute for a route registered with Engine.Host.
+func (engine *Engine) DisableHostRoute(host, method, path string, status int) error {
+	return engine.disableRoute(host, method, path, status, time.Time{})
+}
+
+// EnableHostRoute is EnableRoute for a route registered with Engine.Host.
+func (engine *Engine) EnableHostRoute(host, method, path string) error {
+	return engine.disableRoute(host, method, path, 0, time.Time{})
+}
+
+// disableRoute disables the route with status until the given time if not
+// zero, or enables it if status is 0.
+func (engine *Engine) disableRoute(host, method, path string, status int, until time.Time) error {
+	if status != 0 && (status < http.StatusBadRequest || status > 599) {
+		return fmt.Errorf("invalid status %d for a disabled route", status)
+	}
+	route := engine.table.Load().routeIndex[routeKey(host, method, path)]
+	if route == nil {
+		return fmt.Errorf("%w: %s %s%s", ErrRouteNotRegistered, method, host, path)
+	}
+	var deadline int64
+	if !until.IsZero() {
+		deadline = until.UnixNano()
+	}
+	route.disabledUntil.Store(deadline)
+	previous := route.disabled.Swap(int32(status))
+	switch {
+	case previous == 0 && status != 0:
+		engine.disabledRoutes.Add(1)
+	case previous != 0 && status == 0:
+		engine.disabledRoutes.Add(-1)
+	}
+	return nil
+}
+
+// Disabled returns the status answered by the route while it is disabled by
+// Engine.DisableRoute, or 0.
+func (route *Route) Disabled() int {
+	if until := route.disabledUntil.Load(); until != 0 && time.Now().UnixNano() >= until {
+		return 0
+	}
+	return int(route.disabled.Load())
+}
+
+// serveDisabled answers the request of a disabled route, and reports whether
+// the route is disabled.
+func (engine *Engine) serveDisabled(c *Context, httpMethod string) bool {
+	route := engine.table.Load().routeIndex[routeKey(c.routeHost, httpMethod, c.fullPath)]
+	if route == nil {
+		return false
+	}
+	status := route.Disabled()
+	if status == 0 {
+		return false
+	}
+	var shed Shed
+	if until := route.disabledUntil.Load(); until != 0 {
+		shed.RetryAfter = time.Until(time.Unix(0, until))
+	}
+	switch status {
+	case http.StatusNotFound:
+		c.handlers = engine.allNoRoute
+		serveError(c, status, default404Body)
+	case http.StatusServiceUnavailable, http.StatusTooManyRequests:
+		shed.writeHeaders(c.Writer.Header())
+		fallthrough
+	default:
+		c.handlers = engine.Handlers
+		serveError(c, status, []byte(strconv.Itoa(status)+" "+http.StatusText(status)))
+	}
+	return true
+}
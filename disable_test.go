@@ -0,0 +1,60 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngineDisableRoute(t *testing.T) {
+	var middleware int
+	router := New()
+	router.Use(func(c *Context) { middleware++ })
+	router.GET("/users/:id", func(c *Context) { c.String(http.StatusOK, "user") })
+	router.GET("/orders", func(c *Context) { c.String(http.StatusOK, "orders") })
+	router.Host("api.example.com").GET("/orders", func(c *Context) { c.String(http.StatusOK, "api orders") })
+	router.NoRoute(func(c *Context) { c.String(http.StatusNotFound, "custom 404") })
+
+	assert.NoError(t, router.DisableRoute(http.MethodGet, "/users/:id", http.StatusServiceUnavailable))
+	w := PerformRequest(router, http.MethodGet, "/users/1")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "503 Service Unavailable", w.Body.String())
+	assert.Equal(t, 1, middleware)
+	assert.Equal(t, "orders", PerformRequest(router, http.MethodGet, "/orders").Body.String())
+
+	assert.NoError(t, router.DisableRoute(http.MethodGet, "/users/:id", http.StatusGone))
+	assert.Equal(t, http.StatusGone, PerformRequest(router, http.MethodGet, "/users/1").Code)
+	assert.NoError(t, router.DisableRoute(http.MethodGet, "/orders", http.StatusNotFound))
+	w = PerformRequest(router, http.MethodGet, "/orders")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "custom 404", w.Body.String())
+	assert.Equal(t, int32(2), router.disabledRoutes.Load())
+
+	assert.NoError(t, router.DisableHostRoute("api.example.com", http.MethodGet, "/orders", http.StatusServiceUnavailable))
+	assert.Equal(t, http.StatusServiceUnavailable, performHostRequest(router, http.MethodGet, "api.example.com", "/orders").Code)
+	assert.NoError(t, router.EnableHostRoute("api.example.com", http.MethodGet, "/orders"))
+	assert.Equal(t, "api orders", performHostRequest(router, http.MethodGet, "api.example.com", "/orders").Body.String())
+
+	assert.NoError(t, router.EnableRoute(http.MethodGet, "/users/:id"))
+	assert.NoError(t, router.EnableRoute(http.MethodGet, "/users/:id"))
+	assert.Equal(t, "user", PerformRequest(router, http.MethodGet, "/users/1").Body.String())
+	assert.Equal(t, int32(1), router.disabledRoutes.Load())
+
+	assert.NoError(t, router.DisableRouteUntil(http.MethodGet, "/users/:id", http.StatusServiceUnavailable, time.Now().Add(90*time.Second)))
+	w = PerformRequest(router, http.MethodGet, "/users/1")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "90", w.Header().Get("Retry-After"))
+	assert.NoError(t, router.DisableRouteUntil(http.MethodGet, "/users/:id", http.StatusServiceUnavailable, time.Now().Add(-time.Second)))
+	assert.Equal(t, "user", PerformRequest(router, http.MethodGet, "/users/1").Body.String())
+	assert.NoError(t, router.EnableRoute(http.MethodGet, "/users/:id"))
+
+	assert.ErrorIs(t, router.DisableRoute(http.MethodPost, "/orders", http.StatusGone), ErrRouteNotRegistered)
+	assert.ErrorIs(t, router.EnableRoute(http.MethodGet, "/missing"), ErrRouteNotRegistered)
+	assert.EqualError(t, router.DisableRoute(http.MethodGet, "/orders", http.StatusOK), "invalid status 200 for a disabled route")
+}
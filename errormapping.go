@@ -0,0 +1,217 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"reflect"
+
+	"github.com/jialequ/mpgw/internal/json"
+	"github.com/jialequ/mpgw/render"
+)
+
+// ErrorMapper maps an error to the problem details of the response, and
+// reports whether it applies to the error. The status of the problem is
+// required, its title defaults to the status text.
+type ErrorMapper func(err error) (Problem, bool)
+
+// MapErrorIs maps the errors matching target, as with errors.Is, to status.
+func MapErrorIs(target error, status int) ErrorMapper {
+	return func(err error) (Problem, bool) {
+		return Problem{Status: status}, errors.Is(err, target)
+	}
+}
+
+// MapErrorAs maps the errors of type T, as with errors.As, to status. The
+// message of the error is the detail of the problem.
+func MapErrorAs[T error](status int) ErrorMapper {
+	return func(err error) (Problem, bool) {
+		var target T
+		if !errors.As(err, &target) {
+			return Problem{}, false
+		}
+		return Problem{Status: status, Detail: target.Error()}, true
+	}
+}
+
+// MapErrorFunc maps the errors matching the predicate to status.
+func MapErrorFunc(match func(err error) bool, status int) ErrorMapper {
+	return func(err error) (Problem, bool) {
+		return Problem{Status: status}, match(err)
+	}
+}
+
+// grpcStatuses are the HTTP statuses of the gRPC codes, as mapped by the gRPC
+// gateways.
+var grpcStatuses = [...]int{
+	http.StatusOK,                  // OK
+	499,                            // Canceled, client closed request
+	http.StatusInternalServerError, // Unknown
+	http.StatusBadRequest,          // InvalidArgument
+	http.StatusGatewayTimeout,      // DeadlineExceeded
+	http.StatusNotFound,            // NotFound
+	http.StatusConflict,            // AlreadyExists
+	http.StatusForbidden,           // PermissionDenied
+	http.StatusTooManyRequests,     // ResourceExhausted
+	http.StatusBadRequest,          // FailedPrecondition
+	http.StatusConflict,            // Aborted
+	http.StatusBadRequest,          // OutOfRange
+	http.StatusNotImplemented,      // Unimplemented
+	http.StatusInternalServerError, // Internal
+	http.StatusServiceUnavailable,  // Unavailable
+	http.StatusInternalServerError, // DataLoss
+	http.StatusUnauthorized,        // Unauthenticated
+}
+
+// mapGRPCStatus maps the errors implementing GRPCStatus, such as the errors
+// of the gRPC clients, to the HTTP status of their code, with their message as
+// detail. The status is read by reflection, not to depend on gRPC.
+func mapGRPCStatus(err error) (Problem, bool) {
+	for _, err := range errorTree(err) {
+		method := reflect.ValueOf(err).MethodByName("GRPCStatus")
+		if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+			continue
+		}
+		status := method.Call(nil)[0]
+		if status.Kind() == reflect.Pointer && status.IsNil() {
+			continue
+		}
+		code, ok := callMethod(status, "Code")
+		if !ok || !code.CanUint() || code.Uint() >= uint64(len(grpcStatuses)) || code.Uint() == 0 {
+			continue
+		}
+		problem := Problem{Status: grpcStatuses[code.Uint()]}
+		if message, ok := callMethod(status, "Message"); ok && message.Kind() == reflect.String {
+			problem.Detail = message.String()
+		}
+		return problem, true
+	}
+	return Problem{}, false
+}
+
+// callMethod calls the method of v without arguments returning one value.
+func callMethod(v reflect.Value, name string) (reflect.Value, bool) {
+	method := v.MethodByName(name)
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return reflect.Value{}, false
+	}
+	return method.Call(nil)[0], true
+}
+
+// errorTree returns err and the errors it wraps, depth first.
+func errorTree(err error) []error {
+	var tree []error
+	for pending := []error{err}; len(pending) > 0; {
+		err, pending = pending[len(pending)-1], pending[:len(pending)-1]
+		if err == nil {
+			continue
+		}
+		tree = append(tree, err)
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			pending = append(pending, u.Unwrap())
+		case interface{ Unwrap() []error }:
+			for i := len(u.Unwrap()) - 1; i >= 0; i-- {
+				pending = append(pending, u.Unwrap()[i])
+			}
+		}
+	}
+	return tree
+}
+
+// builtinErrorMappers are the mappers applied after the ones of MapErrors.
+var builtinErrorMappers = []ErrorMapper{
+	mapGRPCStatus,
+	MapErrorIs(sql.ErrNoRows, http.StatusNotFound),
+	MapErrorAs[*ParamError](http.StatusBadRequest),
+}
+
+// errorMapping is the central error handler of an engine, see MapErrors.
+type errorMapping struct {
+	mappers []ErrorMapper
+}
+
+// MapErrors enables the central error handler, and registers mappers of the
+// errors to the statuses and problem details of the responses. When a route
+// returns without writing a response and with errors attached to the context,
+// the last one is answered with application/problem+json by the first mapper
+// applying to it, in the order of registration, then by the builtin ones:
+//
+//   - the errors implementing GRPCStatus(), such as the ones of the gRPC
+//     clients, are mapped to the HTTP status of their code;
+//   - sql.ErrNoRows is mapped to 404 Not Found;
+//   - the *ParamError of the typed param accessors, such as
+//     Context.ParamInt, are mapped to 400 Bad Request.
+//
+// The other errors keep the error status set by the handler with
+// Context.Status, or are answered with 500 Internal Server Error. Unless the
+// mapper sets the detail of the problem, the message of the error is only
+// disclosed if it is a public or binding error, see ErrorType. The responses
+// already written, such as the ones of Context.AbortWithError, are left as
+// is. For example:
+//
+//	router.MapErrors(
+//	    gin.MapErrorIs(ErrQuotaExceeded, http.StatusTooManyRequests),
+//	    gin.MapErrorAs[*ValidationError](http.StatusUnprocessableEntity),
+//	)
+//	router.GET("/users/:id", func(c *gin.Context) {
+//	    user, err := db.User(c.Param("id"))
+//	    if err != nil {
+//	        _ = c.Error(err) // sql.ErrNoRows answers 404
+//	        return
+//	    }
+//	    c.JSON(http.StatusOK, user)
+//	})
+func (engine *Engine) MapErrors(mappers ...ErrorMapper) {
+	m := &errorMapping{}
+	if engine.errorMapping != nil {
+		m.mappers = append(m.mappers, engine.errorMapping.mappers...)
+	}
+	m.mappers = append(m.mappers, mappers...)
+	engine.errorMapping = m
+}
+
+// handle answers the last error of the request if no response was written.
+func (m *errorMapping) handle(c *Context) {
+	if c.Writer.Written() || len(c.Errors) == 0 || c.hijacked {
+		return
+	}
+	last := c.Errors.Last()
+	problem, ok := m.problem(last.Err)
+	if !ok {
+		problem.Status = c.Writer.Status()
+		if problem.Status < http.StatusBadRequest {
+			problem.Status = http.StatusInternalServerError
+		}
+	}
+	if problem.Detail == "" && last.IsType(ErrorTypePublic|ErrorTypeBind) {
+		problem.Detail = last.Error()
+	}
+	if problem.Type == "" {
+		problem.Type = "about:blank"
+	}
+	if problem.Title == "" {
+		problem.Title = http.StatusText(problem.Status)
+	}
+	body, err := json.Marshal(problem)
+	if err != nil {
+		panic(err)
+	}
+	c.Render(problem.Status, render.Data{ContentType: MIMEProblemJSON, Data: body})
+}
+
+// problem returns the problem details of the first mapper applying to err.
+func (m *errorMapping) problem(err error) (Problem, bool) {
+	for _, mappers := range [][]ErrorMapper{m.mappers, builtinErrorMappers} {
+		for _, mapper := range mappers {
+			if problem, ok := mapper(err); ok && problem.Status > 0 {
+				return problem, true
+			}
+		}
+	}
+	return Problem{}, false
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// grpcTestStatus mimics the status of the gRPC errors.
+type grpcTestStatus struct {
+	code uint32
+	msg  string
+}
+
+func (s *grpcTestStatus) Code() uint32    { return s.code }
+func (s *grpcTestStatus) Message() string { return s.msg }
+
+type grpcTestError struct{ status *grpcTestStatus }
+
+func (e grpcTestError) Error() string               { return "rpc error: " + e.status.msg }
+func (e grpcTestError) GRPCStatus() *grpcTestStatus { return e.status }
+
+type quotaError struct{ limit int }
+
+func (e *quotaError) Error() string { return fmt.Sprintf("quota of %d exceeded", e.limit) }
+
+var errTestMaintenance = errors.New("maintenance")
+
+func TestEngineMapErrors(t *testing.T) {
+	router := New()
+	fail := func(err error) HandlerFunc {
+		return func(c *Context) { _ = c.Error(err) }
+	}
+	router.GET("/rows", fail(fmt.Errorf("user 42: %w", sql.ErrNoRows)))
+	router.GET("/grpc", fail(fmt.Errorf("call: %w", grpcTestError{&grpcTestStatus{code: 7, msg: "not your account"}})))
+	router.GET("/quota", fail(&quotaError{limit: 10}))
+	router.GET("/maintenance", fail(errTestMaintenance))
+	router.GET("/private", fail(errors.New("password=hunter2")))
+	router.GET("/public", func(c *Context) {
+		c.Error(errors.New("name is too long")).SetType(ErrorTypePublic) //nolint: errcheck
+		c.Status(http.StatusUnprocessableEntity)
+	})
+	router.GET("/aborted", func(c *Context) {
+		_ = c.AbortWithError(http.StatusConflict, sql.ErrNoRows)
+	})
+	router.GET("/written", func(c *Context) {
+		_ = c.Error(sql.ErrNoRows)
+		c.String(http.StatusOK, "ok")
+	})
+
+	// errors are not mapped before MapErrors
+	w := PerformRequest(router, http.MethodGet, "/rows")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	router.MapErrors(MapErrorAs[*quotaError](http.StatusTooManyRequests))
+	router.MapErrors(
+		MapErrorFunc(func(err error) bool { return errors.Is(err, errTestMaintenance) }, http.StatusServiceUnavailable),
+		MapErrorIs(sql.ErrNoRows, http.StatusGone),
+	)
+	for _, tt := range []struct {
+		path, body string
+		status     int
+	}{
+		{"/rows", `{"type":"about:blank","title":"Gone","status":410}`, http.StatusGone},
+		{"/grpc", `{"type":"about:blank","title":"Forbidden","status":403,"detail":"not your account"}`, http.StatusForbidden},
+		{"/quota", `{"type":"about:blank","title":"Too Many Requests","status":429,"detail":"quota of 10 exceeded"}`, http.StatusTooManyRequests},
+		{"/maintenance", `{"type":"about:blank","title":"Service Unavailable","status":503}`, http.StatusServiceUnavailable},
+		{"/private", `{"type":"about:blank","title":"Internal Server Error","status":500}`, http.StatusInternalServerError},
+		{"/public", `{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"name is too long"}`, http.StatusUnprocessableEntity},
+		{"/written", "ok", http.StatusOK},
+		{"/aborted", "", http.StatusConflict},
+	} {
+		w = PerformRequest(router, http.MethodGet, tt.path)
+		assert.Equal(t, tt.status, w.Code, tt.path)
+		assert.Equal(t, tt.body, w.Body.String(), tt.path)
+	}
+	w = PerformRequest(router, http.MethodGet, "/rows")
+	assert.Equal(t, MIMEProblemJSON, w.Header().Get("Content-Type"))
+
+	// the builtin mappers apply after the registered ones
+	clone := New()
+	clone.MapErrors()
+	clone.GET("/rows", fail(sql.ErrNoRows))
+	w = PerformRequest(clone, http.MethodGet, "/rows")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, router.errorMapping, router.Clone().errorMapping)
+}
@@ -0,0 +1,66 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"strings"
+)
+
+// RequireIfMatch enforces the If-Match precondition of a request changing a
+// resource whose current entity tag is currentETag, for optimistic concurrency:
+// the change is only applied if the client saw the latest version of the
+// resource. It reports whether the request may proceed, and otherwise aborts
+// it with 412 Precondition Failed, advertising the current ETag, or with 428
+// Precondition Required when the unsafe request has no If-Match header while
+// the RequireIfMatch setting of the route config is on:
+//
+//	router.Configure(gin.RouteConfig{RequireIfMatch: gin.ConfigOn})
+//	router.PUT("/users/:id", func(c *gin.Context) {
+//	    user := load(c.Param("id"))
+//	    if !c.RequireIfMatch(user.ETag()) {
+//	        return
+//	    }
+//	    ...
+//	})
+//
+// currentETag may be quoted or not, and is empty when the resource does not
+// exist. The tags are compared with the strong comparison, so weak tags never
+// match.
+func (c *Context) RequireIfMatch(currentETag string) bool {
+	if currentETag != "" && !strings.HasSuffix(currentETag, `"`) {
+		currentETag = `"` + currentETag + `"`
+	}
+	values := c.Request.Header.Values("If-Match")
+	if len(values) == 0 {
+		if !isSafeMethod(c.Request.Method) && c.RouteConfig().RequireIfMatch.Enabled() {
+			c.AbortWithStatus(http.StatusPreconditionRequired)
+			return false
+		}
+		return true
+	}
+	if currentETag != "" {
+		weak := strings.HasPrefix(currentETag, "W/")
+		for _, value := range values {
+			for _, tag := range strings.Split(value, ",") {
+				if tag = strings.TrimSpace(tag); tag == "*" || !weak && tag == currentETag {
+					return true
+				}
+			}
+		}
+		c.Header("ETag", currentETag)
+	}
+	c.AbortWithStatus(http.StatusPreconditionFailed)
+	return false
+}
+
+// isSafeMethod reports whether method is safe, read-only.
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
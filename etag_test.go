@@ -0,0 +1,63 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextRequireIfMatch(t *testing.T) {
+	router := New()
+	etag := "v2"
+	update := func(c *Context) {
+		if !c.RequireIfMatch(etag) {
+			return
+		}
+		c.String(http.StatusOK, "updated")
+	}
+	router.PUT("/documents/:id", update)
+	router.GET("/documents/:id", update)
+	strict := router.Group("/strict")
+	strict.Configure(RouteConfig{RequireIfMatch: ConfigOn})
+	strict.PUT("/documents/:id", update)
+	strict.GET("/documents/:id", update)
+
+	w := PerformRequest(router, http.MethodPut, "/documents/1", header{"If-Match", `"v2"`})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = PerformRequest(router, http.MethodPut, "/documents/1", header{"If-Match", `"v1", "v2"`})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = PerformRequest(router, http.MethodPut, "/documents/1", header{"If-Match", "*"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = PerformRequest(router, http.MethodPut, "/documents/1", header{"If-Match", `"v1"`})
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Equal(t, `"v2"`, w.Header().Get("ETag"))
+	assert.Empty(t, w.Body.String())
+	w = PerformRequest(router, http.MethodPut, "/documents/1", header{"If-Match", `W/"v2"`})
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	w = PerformRequest(router, http.MethodPut, "/documents/1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = PerformRequest(router, http.MethodPut, "/strict/documents/1")
+	assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+	w = PerformRequest(router, http.MethodGet, "/strict/documents/1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = PerformRequest(router, http.MethodPut, "/strict/documents/1", header{"If-Match", `"v2"`})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// weak tags only match *, missing resources never match
+	etag = `W/"v3"`
+	w = PerformRequest(router, http.MethodPut, "/documents/1", header{"If-Match", `W/"v3"`})
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	w = PerformRequest(router, http.MethodPut, "/documents/1", header{"If-Match", "*"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	etag = ""
+	w = PerformRequest(router, http.MethodPut, "/documents/1", header{"If-Match", "*"})
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Empty(t, w.Header().Get("ETag"))
+}
@@ -0,0 +1,265 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventKind is the kind of a lifecycle event.
+type EventKind uint8
+
+// Lifecycle events published by the engine.
+const (
+	// EventEngineStarted is published by the Run methods before serving, see EngineStarted.
+	EventEngineStarted EventKind = iota + 1
+	// EventRouteMatched is published when a request matches a route, see RouteMatched.
+	EventRouteMatched
+	// EventRequestCompleted is published when a request has been served, see RequestCompleted.
+	EventRequestCompleted
+
+	numEventKinds
+)
+
+const defaultEventBuffer = 256
+
+func (k EventKind) String() string {
+	switch k {
+	case EventEngineStarted:
+		return "EngineStarted"
+	case EventRouteMatched:
+		return "RouteMatched"
+	case EventRequestCompleted:
+		return "RequestCompleted"
+	}
+	return "Unknown"
+}
+
+// Event is a lifecycle event, one of EngineStarted, RouteMatched and RequestCompleted.
+type Event interface {
+	Kind() EventKind
+}
+
+// EngineStarted is published when the engine starts serving.
+type EngineStarted struct {
+	Network string
+	Addr    string
+	Time    time.Time
+}
+
+// Kind returns EventEngineStarted.
+func (EngineStarted) Kind() EventKind { return EventEngineStarted }
+
+// RouteMatched is published when a request matches a route, before its handlers
+// run. Context is the live context for synchronous subscribers, which may
+// annotate or abort the request, and a read-only copy for asynchronous ones.
+type RouteMatched struct {
+	Context  *Context
+	Method   string
+	Path     string
+	FullPath string
+	// Host is the host pattern of the route registered with Engine.Host, if any.
+	Host string
+	Time time.Time
+}
+
+// Kind returns EventRouteMatched.
+func (RouteMatched) Kind() EventKind { return EventRouteMatched }
+
+// RequestCompleted is published when a request has been served, whether it
+// matched a route or not.
+type RequestCompleted struct {
+	Method   string
+	Path     string
+	FullPath string
+	ClientIP string
+	Status   int
+	Size     int
+	Latency  time.Duration
+	Errors   []string
+	Time     time.Time
+}
+
+// Kind returns EventRequestCompleted.
+func (RequestCompleted) Kind() EventKind { return EventRequestCompleted }
+
+type subscriber struct {
+	fn      func(Event)
+	ch      chan Event
+	done    chan struct{}
+	dropped atomic.Uint64
+}
+
+// EventBus publishes the lifecycle events of an engine to its subscribers.
+// Publishing an event nobody subscribed to costs a single atomic load.
+type EventBus struct {
+	mu   sync.Mutex
+	subs [numEventKinds]atomic.Pointer[[]*subscriber]
+}
+
+// Events returns the event bus of the engine.
+func (engine *Engine) Events() *EventBus {
+	if bus := engine.events.Load(); bus != nil {
+		return bus
+	}
+	engine.events.CompareAndSwap(nil, &EventBus{})
+	return engine.events.Load()
+}
+
+// Subscribe registers fn to be called synchronously, in the publishing
+// goroutine, for each event of the given kind. Slow subscribers slow down the
+// requests. It returns a function removing the subscription.
+func (bus *EventBus) Subscribe(kind EventKind, fn func(Event)) (unsubscribe func()) {
+	return bus.add(kind, &subscriber{fn: fn})
+}
+
+// SubscribeAsync registers fn to be called in a dedicated goroutine for each
+// event of the given kind. Events are queued in a buffer of the given size,
+// default 256, and dropped when it is full. It returns a function removing the
+// subscription and stopping the goroutine.
+func (bus *EventBus) SubscribeAsync(kind EventKind, buffer int, fn func(Event)) (unsubscribe func()) {
+	if buffer <= 0 {
+		buffer = defaultEventBuffer
+	}
+	sub := &subscriber{fn: fn, ch: make(chan Event, buffer), done: make(chan struct{})}
+	go func() {
+		for {
+			select {
+			case e := <-sub.ch:
+				sub.fn(e)
+			case <-sub.done:
+				return
+			}
+		}
+	}()
+	return bus.add(kind, sub)
+}
+
+func (bus *EventBus) add(kind EventKind, sub *subscriber) func() {
+	assert1(kind > 0 && kind < numEventKinds, "unknown event kind")
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	var subs []*subscriber
+	if old := bus.subs[kind].Load(); old != nil {
+		subs = append(subs, *old...)
+	}
+	subs = append(subs, sub)
+	bus.subs[kind].Store(&subs)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { bus.remove(kind, sub) })
+	}
+}
+
+func (bus *EventBus) remove(kind EventKind, sub *subscriber) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	old := bus.subs[kind].Load()
+	subs := make([]*subscriber, 0, len(*old))
+	for _, s := range *old {
+		if s != sub {
+			subs = append(subs, s)
+		}
+	}
+	bus.subs[kind].Store(&subs)
+	if sub.done != nil {
+		close(sub.done)
+	}
+}
+
+// Has reports whether events of the given kind have subscribers.
+func (bus *EventBus) Has(kind EventKind) bool {
+	if bus == nil {
+		return false
+	}
+	subs := bus.subs[kind].Load()
+	return subs != nil && len(*subs) > 0
+}
+
+// Publish publishes an event to the subscribers of its kind.
+func (bus *EventBus) Publish(e Event) {
+	subs := bus.subs[e.Kind()].Load()
+	if subs == nil {
+		return
+	}
+	var async Event
+	for _, sub := range *subs {
+		if sub.ch == nil {
+			sub.fn(e)
+			continue
+		}
+		if async == nil {
+			async = e
+			if matched, ok := e.(RouteMatched); ok && matched.Context != nil {
+				matched.Context = matched.Context.Copy()
+				async = matched
+			}
+		}
+		select {
+		case sub.ch <- async:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// Dropped returns the number of events dropped because the buffer of an
+// asynchronous subscriber was full.
+func (bus *EventBus) Dropped() uint64 {
+	var dropped uint64
+	for kind := range bus.subs {
+		if subs := bus.subs[kind].Load(); subs != nil {
+			for _, sub := range *subs {
+				dropped += sub.dropped.Load()
+			}
+		}
+	}
+	return dropped
+}
+
+// publishStarted publishes EngineStarted, if it has subscribers.
+func (engine *Engine) publishStarted(network, addr string) {
+	if bus := engine.events.Load(); bus.Has(EventEngineStarted) {
+		bus.Publish(EngineStarted{Network: network, Addr: addr, Time: time.Now()})
+	}
+}
+
+// serveWithEvents serves a request, publishing RouteMatched and RequestCompleted
+// to their subscribers.
+func (engine *Engine) serveWithEvents(c *Context, bus *EventBus) {
+	start := time.Now()
+	engine.handleHTTPRequest(c)
+	if !bus.Has(EventRequestCompleted) {
+		return
+	}
+	bus.Publish(RequestCompleted{
+		Method:   c.Request.Method,
+		Path:     c.Request.URL.Path,
+		FullPath: c.FullPath(),
+		ClientIP: c.ClientIP(),
+		Status:   c.Writer.Status(),
+		Size:     c.Writer.Size(),
+		Latency:  time.Since(start),
+		Errors:   c.Errors.Errors(),
+		Time:     start,
+	})
+}
+
+// publishMatched publishes RouteMatched, if it has subscribers.
+func (engine *Engine) publishMatched(c *Context) {
+	if bus := engine.events.Load(); bus.Has(EventRouteMatched) {
+		bus.Publish(RouteMatched{
+			Context:  c,
+			Method:   c.Request.Method,
+			Path:     c.Request.URL.Path,
+			FullPath: c.fullPath,
+			Host:     c.routeHost,
+			Time:     time.Now(),
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventsRequestLifecycle(t *testing.T) {
+	router := New()
+	router.GET("/users/:id", func(c *Context) {
+		c.Error(errors.New("oops")) //nolint: errcheck
+		c.String(http.StatusOK, c.GetString("matched"))
+	})
+
+	var events []Event
+	bus := router.Events()
+	assert.Same(t, bus, router.Events())
+	bus.Subscribe(EventRouteMatched, func(e Event) {
+		events = append(events, e)
+		e.(RouteMatched).Context.Set("matched", "yes")
+	})
+	unsubscribe := bus.Subscribe(EventRequestCompleted, func(e Event) {
+		events = append(events, e)
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/users/42")
+	assert.Equal(t, "yes", w.Body.String())
+	require.Len(t, events, 2)
+	matched := events[0].(RouteMatched)
+	assert.Equal(t, "/users/42", matched.Path)
+	assert.Equal(t, "/users/:id", matched.FullPath)
+	completed := events[1].(RequestCompleted)
+	assert.Equal(t, EventRequestCompleted, completed.Kind())
+	assert.Equal(t, http.MethodGet, completed.Method)
+	assert.Equal(t, "/users/:id", completed.FullPath)
+	assert.Equal(t, http.StatusOK, completed.Status)
+	assert.Equal(t, 3, completed.Size)
+	assert.Equal(t, []string{"oops"}, completed.Errors)
+
+	// unmatched requests complete without matching
+	PerformRequest(router, http.MethodGet, "/missing")
+	require.Len(t, events, 3)
+	assert.Equal(t, http.StatusNotFound, events[2].(RequestCompleted).Status)
+
+	unsubscribe()
+	unsubscribe()
+	PerformRequest(router, http.MethodGet, "/missing")
+	assert.Len(t, events, 3)
+	assert.False(t, bus.Has(EventRequestCompleted))
+}
+
+func TestEventsRouteMatchedAbort(t *testing.T) {
+	router := New()
+	router.GET("/", handlerTest1)
+	router.Events().Subscribe(EventRouteMatched, func(e Event) {
+		e.(RouteMatched).Context.AbortWithStatus(http.StatusForbidden)
+	})
+	w := PerformRequest(router, http.MethodGet, "/")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestEventsAsync(t *testing.T) {
+	router := New()
+	router.GET("/", func(c *Context) {
+		c.Set("k", "v")
+	})
+
+	var mu sync.Mutex
+	var got []Event
+	received := make(chan struct{}, 10)
+	unsubscribe := router.Events().SubscribeAsync(EventRouteMatched, 0, func(e Event) {
+		mu.Lock()
+		got = append(got, e)
+		mu.Unlock()
+		received <- struct{}{}
+	})
+	PerformRequest(router, http.MethodGet, "/")
+	<-received
+	mu.Lock()
+	matched := got[0].(RouteMatched)
+	mu.Unlock()
+	assert.Equal(t, "/", matched.FullPath)
+	assert.NotNil(t, matched.Context)
+	unsubscribe()
+
+	// a full buffer drops events
+	block := make(chan struct{})
+	bus := &EventBus{}
+	bus.SubscribeAsync(EventEngineStarted, 1, func(Event) { <-block })
+	for i := 0; i < 5; i++ {
+		bus.Publish(EngineStarted{})
+	}
+	assert.GreaterOrEqual(t, bus.Dropped(), uint64(3))
+	close(block)
+}
+
+func TestEventsEngineStarted(t *testing.T) {
+	router := New()
+	started := make(chan EngineStarted, 1)
+	router.Events().Subscribe(EventEngineStarted, func(e Event) {
+		started <- e.(EngineStarted)
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		router.RunListener(listener) //nolint: errcheck
+	}()
+	select {
+	case e := <-started:
+		assert.Equal(t, "tcp", e.Network)
+		assert.Equal(t, listener.Addr().String(), e.Addr)
+	case <-time.After(time.Second):
+		t.Fatal("EngineStarted not published")
+	}
+	listener.Close()
+}
+
+func TestEventKind(t *testing.T) {
+	assert.Equal(t, "EngineStarted", EventEngineStarted.String())
+	assert.Equal(t, "RouteMatched", EventRouteMatched.String())
+	assert.Equal(t, "RequestCompleted", EventRequestCompleted.String())
+	assert.Equal(t, "Unknown", EventKind(0).String())
+	assert.Panics(t, func() {
+		(&EventBus{}).Subscribe(numEventKinds, func(Event) {})
+	})
+	assert.False(t, (*EventBus)(nil).Has(EventRouteMatched))
+}
@@ -0,0 +1,272 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jialequ/mpgw/binding"
+	"github.com/jialequ/mpgw/internal/json"
+)
+
+// DefaultRouteExamplesPath is the path where Engine.RouteExamples serves the examples.
+const DefaultRouteExamplesPath = "/__examples"
+
+// maxExampleDepth bounds the nesting of generated examples, for recursive types.
+const maxExampleDepth = 5
+
+var (
+	timeType        = reflect.TypeOf(time.Time{})
+	exampleTime     = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	exampleBindings = map[string]string{
+		binding.XML.Name():           MIMEXML,
+		binding.Form.Name():          MIMEPOSTForm,
+		binding.FormPost.Name():      MIMEPOSTForm,
+		binding.FormMultipart.Name(): MIMEMultipartPOSTForm,
+	}
+)
+
+// RouteExample is an example request of a route, as served by Engine.RouteExamples.
+type RouteExample struct {
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Type        string `json:"type"`
+	ContentType string `json:"contentType"`
+	Body        string `json:"body,omitempty"`
+	Curl        string `json:"curl"`
+}
+
+// Binds annotates the routes with the type their handlers bind requests to,
+// given as a value or a pointer, and its binding, binding.JSON by default. The
+// example values of the fields are read from their example tags:
+//
+//	type CreateUser struct {
+//	    Name  string `json:"name" example:"Ada"`
+//	    Admin bool   `json:"admin"`
+//	}
+//
+//	router.POST("/users", createUser)
+//	router.Route("/users", http.MethodPost).Binds(CreateUser{})
+func (h *RouteHandle) Binds(obj any, b ...binding.Binding) *RouteHandle {
+	t := reflect.TypeOf(obj)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	assert1(t != nil, "Binds needs a typed value")
+	bb := binding.Binding(binding.JSON)
+	if len(b) > 0 && b[0] != nil {
+		bb = b[0]
+	}
+	return h.annotate(func(route *Route) {
+		route.bodyType, route.bodyBinding = t, bb
+	})
+}
+
+// RouteExamples registers a GET handler at DefaultRouteExamplesPath serving, in
+// debug mode only, example requests and curl commands for the routes annotated
+// with Binds. It answers 404 in the other modes. The given handlers run before
+// the examples.
+func (engine *Engine) RouteExamples(handlers ...HandlerFunc) IRoutes {
+	handlers = append(handlers, func(c *Context) {
+		if !IsDebugging() {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		scheme := "http"
+		if c.Request.TLS != nil {
+			scheme = "https"
+		}
+		c.JSON(http.StatusOK, engine.routeExamples(scheme+"://"+c.Request.Host))
+	})
+	routes := engine.GET(DefaultRouteExamplesPath, handlers...)
+	engine.Route(DefaultRouteExamplesPath, http.MethodGet).Describe("Example requests of the routes")
+	return routes
+}
+
+// routeExamples returns the examples of the routes annotated with Binds, with
+// curl commands sending them to baseURL.
+func (engine *Engine) routeExamples(baseURL string) []RouteExample {
+	examples := []RouteExample{}
+	for _, route := range engine.table.Load().routes {
+		if route.bodyType == nil {
+			continue
+		}
+		example := RouteExample{
+			Method:      route.Method,
+			Path:        route.Path,
+			Type:        route.bodyTypeName(),
+			ContentType: MIMEJSON,
+		}
+		if mime, ok := exampleBindings[route.bodyBinding.Name()]; ok {
+			example.ContentType = mime
+		}
+
+		value := reflect.New(route.bodyType).Elem()
+		fillExample(value, "", 0)
+		target := baseURL + examplePath(route.Path)
+		curl := []string{"curl", "-X", route.Method}
+		switch example.ContentType {
+		case MIMEXML:
+			body, _ := xml.MarshalIndent(value.Interface(), "", "  ")
+			example.Body = string(body)
+			curl = append(curl, "-H", shellQuote("Content-Type: "+MIMEXML), "-d", shellQuote(example.Body))
+		case MIMEPOSTForm:
+			form := url.Values{}
+			exampleForm(value, form)
+			if bodyAllowedForMethod(route.Method) {
+				example.Body = form.Encode()
+				curl = append(curl, "-H", shellQuote("Content-Type: "+MIMEPOSTForm), "-d", shellQuote(example.Body))
+			} else {
+				example.ContentType = ""
+				target += "?" + form.Encode()
+			}
+		case MIMEMultipartPOSTForm:
+			form := url.Values{}
+			exampleForm(value, form)
+			example.Body = form.Encode()
+			for _, key := range sortedKeys(form) {
+				for _, v := range form[key] {
+					curl = append(curl, "-F", shellQuote(key+"="+v))
+				}
+			}
+		default:
+			body, _ := json.MarshalIndent(value.Interface(), "", "  ")
+			example.Body = string(body)
+			compact, _ := json.Marshal(value.Interface())
+			curl = append(curl, "-H", shellQuote("Content-Type: "+MIMEJSON), "-d", shellQuote(string(compact)))
+		}
+		example.Curl = strings.Join(append(curl[:3:3], append([]string{shellQuote(target)}, curl[3:]...)...), " ")
+		examples = append(examples, example)
+	}
+	return examples
+}
+
+// examplePath replaces the parameters of a route path with placeholders.
+func examplePath(path string) string {
+	var sb strings.Builder
+	for _, part := range splitRoutePath(path) {
+		if part.param {
+			sb.WriteString("<" + part.text + ">")
+		} else {
+			sb.WriteString(part.text)
+		}
+	}
+	return sb.String()
+}
+
+func bodyAllowedForMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// shellQuote quotes s for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fillExample sets v to an example value, parsed from the example tag of its
+// field if any.
+func fillExample(v reflect.Value, example string, depth int) {
+	if example != "" {
+		if v.Kind() == reflect.String {
+			v.SetString(example)
+			return
+		}
+		if json.Unmarshal([]byte(example), v.Addr().Interface()) == nil {
+			return
+		}
+	}
+	if v.Type() == timeType {
+		v.Set(reflect.ValueOf(exampleTime))
+		return
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString("string")
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Pointer:
+		if depth < maxExampleDepth {
+			v.Set(reflect.New(v.Type().Elem()))
+			fillExample(v.Elem(), example, depth+1)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if field := v.Type().Field(i); field.IsExported() {
+				fillExample(v.Field(i), field.Tag.Get("example"), depth+1)
+			}
+		}
+	case reflect.Slice:
+		if depth < maxExampleDepth && v.Type().Elem().Kind() != reflect.Uint8 {
+			v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+			fillExample(v.Index(0), "", depth+1)
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			fillExample(v.Index(i), "", depth+1)
+		}
+	case reflect.Map:
+		if depth < maxExampleDepth && v.Type().Key().Kind() == reflect.String {
+			v.Set(reflect.MakeMap(v.Type()))
+			elem := reflect.New(v.Type().Elem()).Elem()
+			fillExample(elem, "", depth+1)
+			v.SetMapIndex(reflect.ValueOf("key").Convert(v.Type().Key()), elem)
+		}
+	}
+}
+
+// exampleForm adds the fields of a struct example to form, named after their
+// form tags as binding.Form does.
+func exampleForm(v reflect.Value, form url.Values) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("form"), ",")
+		if name == "-" {
+			continue
+		}
+		fv := v.Field(i)
+		for fv.Kind() == reflect.Pointer && !fv.IsNil() {
+			fv = fv.Elem()
+		}
+		if name == "" {
+			if fv.Kind() == reflect.Struct && fv.Type() != timeType {
+				exampleForm(fv, form)
+				continue
+			}
+			name = field.Name
+		}
+		switch {
+		case fv.Type() == timeType:
+			form.Add(name, fv.Interface().(time.Time).Format(time.RFC3339))
+		case fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array:
+			for j := 0; j < fv.Len(); j++ {
+				form.Add(name, fmt.Sprint(fv.Index(j).Interface()))
+			}
+		case fv.Kind() != reflect.Struct && fv.Kind() != reflect.Map && fv.Kind() != reflect.Pointer:
+			form.Add(name, fmt.Sprint(fv.Interface()))
+		}
+	}
+}
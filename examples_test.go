@@ -0,0 +1,106 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jialequ/mpgw/binding"
+	"github.com/jialequ/mpgw/internal/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type exampleAddress struct {
+	City string `json:"city" xml:"city" example:"Paris"`
+}
+
+type exampleUser struct {
+	Name     string            `json:"name" xml:"name" form:"name" example:"O'Brien"`
+	Age      int               `json:"age" xml:"age" form:"age" example:"42"`
+	Admin    bool              `json:"admin" xml:"admin" form:"admin"`
+	Roles    []string          `json:"roles" xml:"role" form:"role" example:"[\"ops\",\"dev\"]"`
+	Born     time.Time         `json:"born" xml:"born" form:"born"`
+	Address  *exampleAddress   `json:"address" xml:"address" form:"-"`
+	Labels   map[string]string `json:"labels" xml:"-" form:"-"`
+	Self     *exampleUser      `json:"-" xml:"-" form:"-"`
+	internal string
+}
+
+func TestBinds(t *testing.T) {
+	router := New()
+	router.POST("/users", handlerTest1)
+	router.Route("/users", http.MethodPost).Binds(&exampleUser{})
+	router.PUT("/users/:id", handlerTest1)
+	router.Route("/users/:id", http.MethodPut).Binds(exampleUser{}, binding.XML)
+	router.GET("/search", handlerTest1)
+	router.Route("/search", http.MethodGet).Binds(exampleUser{}, binding.Form)
+	router.POST("/upload", handlerTest1)
+	router.Route("/upload", http.MethodPost).Binds(exampleUser{}, binding.FormMultipart)
+	router.GET("/plain", handlerTest1)
+
+	docs := router.RouteDocs()
+	assert.Equal(t, "gin.exampleUser", docs[0].Body)
+	assert.Equal(t, reflect.TypeOf(exampleUser{}), docs[0].BodyType)
+	assert.Equal(t, binding.XML, docs[1].BodyBinding)
+	assert.Empty(t, docs[4].Body)
+	router.GET("/nil", handlerTest1)
+	assert.Panics(t, func() { router.Route("/nil", http.MethodGet).Binds(nil) })
+
+	examples := router.routeExamples("http://localhost:8080")
+	require.Len(t, examples, 4)
+
+	jsonExample := examples[0]
+	assert.Equal(t, MIMEJSON, jsonExample.ContentType)
+	assert.Equal(t, "gin.exampleUser", jsonExample.Type)
+	var user exampleUser
+	require.NoError(t, json.Unmarshal([]byte(jsonExample.Body), &user))
+	assert.Equal(t, "O'Brien", user.Name)
+	assert.Equal(t, 42, user.Age)
+	assert.True(t, user.Admin)
+	assert.Equal(t, []string{"ops", "dev"}, user.Roles)
+	assert.Equal(t, time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC), user.Born)
+	assert.Equal(t, &exampleAddress{City: "Paris"}, user.Address)
+	assert.Equal(t, map[string]string{"key": "string"}, user.Labels)
+	assert.Contains(t, jsonExample.Curl, `curl -X POST 'http://localhost:8080/users' -H 'Content-Type: application/json' -d '{"name":"O'\''Brien",`)
+
+	xmlExample := examples[1]
+	assert.Equal(t, MIMEXML, xmlExample.ContentType)
+	assert.Contains(t, xmlExample.Body, "<name>O&#39;Brien</name>")
+	assert.Contains(t, xmlExample.Body, "<role>ops</role>")
+	assert.Contains(t, xmlExample.Curl, "curl -X PUT 'http://localhost:8080/users/<id>' -H 'Content-Type: application/xml'")
+
+	formExample := examples[2]
+	assert.Empty(t, formExample.ContentType)
+	assert.Empty(t, formExample.Body)
+	assert.Equal(t, "curl -X GET 'http://localhost:8080/search?admin=true&age=42&born=2024-01-01T00%3A00%3A00Z&name=O%27Brien&role=ops&role=dev'", formExample.Curl)
+
+	multipartExample := examples[3]
+	assert.Equal(t, MIMEMultipartPOSTForm, multipartExample.ContentType)
+	assert.Equal(t, "curl -X POST 'http://localhost:8080/upload' -F 'admin=true' -F 'age=42' -F 'born=2024-01-01T00:00:00Z' -F 'name=O'\\''Brien' -F 'role=ops' -F 'role=dev'", multipartExample.Curl)
+}
+
+func TestRouteExamples(t *testing.T) {
+	router := New()
+	router.POST("/users", handlerTest1)
+	router.Route("/users", http.MethodPost).Binds(exampleUser{})
+	router.RouteExamples()
+
+	w := PerformRequest(router, http.MethodGet, DefaultRouteExamplesPath)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	SetMode(DebugMode)
+	defer SetMode(TestMode)
+	w = PerformRequest(router, http.MethodGet, DefaultRouteExamplesPath)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var examples []RouteExample
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &examples))
+	require.Len(t, examples, 1)
+	assert.Equal(t, "/users", examples[0].Path)
+	assert.Contains(t, examples[0].Curl, "'http://example.com/users'")
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// groupFallback holds the handlers of the requests matching the prefix of a
+// group but none of the routes, see RouterGroup.Fallback.
+type groupFallback struct {
+	host     string
+	prefix   string
+	handlers HandlersChain
+}
+
+// matches reports whether the fallback handles the request path for the
+// matched host pattern.
+func (fb *groupFallback) matches(host, path string) bool {
+	if fb.host != "" && fb.host != host {
+		return false
+	}
+	return path == fb.prefix || strings.HasPrefix(path, fb.prefix+"/")
+}
+
+// Fallback sets the handlers of the requests matching the prefix of the group,
+// and its host, but none of the routes, instead of the ones set by
+// Engine.NoRoute. They run after the middlewares of the group and return a 404
+// code by default. The fallback of the longest prefix applies, such as the one
+// proxying the unmatched legacy traffic to an upstream:
+//
+//	legacy := router.Group("/legacy")
+//	legacy.GET("/status", status)
+//	legacy.Fallback(proxyToLegacy)
+//
+// Like NoRoute, the fallbacks do not take precedence over the 405 responses
+// of Engine.HandleMethodNotAllowed. Calling Fallback again replaces the
+// handlers of the group.
+func (group *RouterGroup) Fallback(handlers ...HandlerFunc) {
+	assert1(len(handlers) > 0, "there must be at least one handler")
+	engine := group.engine
+	fb := groupFallback{
+		host:     group.host,
+		prefix:   strings.TrimSuffix(group.basePath, "/"),
+		handlers: group.combineHandlers(handlers),
+	}
+	engine.update(func() {
+		if fb.host != "" {
+			// the requests for the host of the group match its host pattern
+			engine.treesFor(fb.host)
+		}
+		fallbacks := slices.DeleteFunc(slices.Clone(engine.fallbacks), func(other groupFallback) bool {
+			return other.host == fb.host && other.prefix == fb.prefix
+		})
+		fallbacks = append(fallbacks, fb)
+		// the longest prefixes first, then the ones of a host
+		slices.SortStableFunc(fallbacks, func(a, b groupFallback) int {
+			if len(a.prefix) != len(b.prefix) {
+				return len(b.prefix) - len(a.prefix)
+			}
+			return len(b.host) - len(a.host)
+		})
+		engine.fallbacks = fallbacks
+	})
+}
+
+// serveFallback serves a request matching no route with the fallback of the
+// longest group prefix, and reports whether there is one.
+func serveFallback(c *Context, fallbacks []groupFallback, host, path string) bool {
+	for i := range fallbacks {
+		if fb := &fallbacks[i]; fb.matches(host, path) {
+			c.handlers = fb.handlers
+			serveError(c, http.StatusNotFound, default404Body)
+			return true
+		}
+	}
+	return false
+}
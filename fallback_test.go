@@ -0,0 +1,59 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterGroupFallback(t *testing.T) {
+	router := New()
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(func(c *Context) { c.String(http.StatusNotFound, "no route") })
+	router.GET("/status", func(c *Context) { c.String(http.StatusOK, "status") })
+
+	legacy := router.Group("/legacy", func(c *Context) { c.Header("X-Group", "legacy") })
+	legacy.GET("/users", func(c *Context) { c.String(http.StatusOK, "users") })
+	legacy.Fallback(func(c *Context) { c.String(http.StatusOK, "proxied "+c.Request.URL.Path) })
+	legacy.Group("/admin").Fallback(func(c *Context) { c.String(http.StatusForbidden, "admin") })
+	router.Host("api.example.com").Group("/legacy").Fallback(func(*Context) {})
+
+	for _, tt := range []struct {
+		method, host, path string
+		code               int
+		body               string
+	}{
+		{http.MethodGet, "example.com", "/legacy/users", http.StatusOK, "users"},
+		{http.MethodPost, "example.com", "/legacy/orders/1", http.StatusOK, "proxied /legacy/orders/1"},
+		{http.MethodGet, "example.com", "/legacy", http.StatusOK, "proxied /legacy"},
+		{http.MethodGet, "example.com", "/legacy/admin/users", http.StatusForbidden, "admin"},
+		{http.MethodGet, "example.com", "/legacyx", http.StatusNotFound, "no route"},
+		{http.MethodGet, "example.com", "/other", http.StatusNotFound, "no route"},
+		// the fallbacks do not take precedence over the 405 responses
+		{http.MethodPost, "example.com", "/legacy/users", http.StatusMethodNotAllowed, "405 method not allowed"},
+		// the fallback of a host group returns 404 by default
+		{http.MethodGet, "api.example.com", "/legacy/orders", http.StatusNotFound, "404 page not found"},
+		{http.MethodGet, "api.example.com", "/status", http.StatusOK, "status"},
+	} {
+		w := performHostRequest(router, tt.method, tt.host, tt.path)
+		assert.Equal(t, tt.code, w.Code, tt.host+tt.path)
+		assert.Equal(t, tt.body, w.Body.String(), tt.host+tt.path)
+	}
+	w := performHostRequest(router, http.MethodGet, "example.com", "/legacy/orders")
+	assert.Equal(t, "legacy", w.Header().Get("X-Group"))
+
+	// a fallback replaces the previous one of the group
+	legacy.Fallback(func(c *Context) { c.String(http.StatusBadGateway, "down") })
+	w = performHostRequest(router, http.MethodGet, "example.com", "/legacy/orders")
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Equal(t, "down", w.Body.String())
+	assert.Len(t, router.table.Load().fallbacks, 3)
+
+	clone := router.Clone()
+	assert.Equal(t, http.StatusBadGateway, PerformRequest(clone, http.MethodGet, "/legacy/orders").Code)
+}
@@ -0,0 +1,54 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"mime"
+
+	"github.com/jialequ/mpgw/render"
+)
+
+// builtinFormats are the formats negotiable by every engine besides the ones
+// Context.Negotiate knows.
+var builtinFormats = map[string]func(data any) render.Render{
+	MIMEPROTOBUF: func(data any) render.Render { return render.ProtoBuf{Data: data} },
+}
+
+// RegisterFormat registers the render of a MIME type, so that Context.Negotiate
+// renders the data of the type with it when it is offered and accepted:
+//
+//	router.RegisterFormat("application/x-ndjson", func(data any) render.Render {
+//	    return NDJSON{Items: data}
+//	})
+//	router.GET("/users", func(c *gin.Context) {
+//	    c.Negotiate(http.StatusOK, gin.Negotiate{
+//	        Offered: []string{gin.MIMEJSON, "application/x-ndjson", gin.MIMEPROTOBUF},
+//	        Data:    users,
+//	    })
+//	})
+//
+// The built-in formats are protobuf and, unless built with the nomsgpack tag,
+// msgpack. The formats of JSON, HTML, XML, YAML, TOML and JSON:API are
+// rendered from their field of Negotiate and can not be registered.
+func (engine *Engine) RegisterFormat(mimeType string, fn func(data any) render.Render) {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	assert1(err == nil && mediaType == mimeType, "invalid format MIME type "+mimeType)
+	assert1(fn != nil, "format "+mimeType+" can not be nil")
+	if engine.formats == nil {
+		engine.formats = make(map[string]func(data any) render.Render, len(builtinFormats)+1)
+		for k, v := range builtinFormats {
+			engine.formats[k] = v
+		}
+	}
+	engine.formats[mimeType] = fn
+}
+
+// renderFormats returns the registered formats of the engine.
+func (engine *Engine) renderFormats() map[string]func(data any) render.Render {
+	if engine == nil || engine.formats == nil {
+		return builtinFormats
+	}
+	return engine.formats
+}
@@ -0,0 +1,24 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build !nomsgpack
+
+package gin
+
+import (
+	"github.com/jialequ/mpgw/binding"
+	"github.com/jialequ/mpgw/render"
+)
+
+// Content-Type MIME of msgpack.
+const (
+	MIMEMSGPACK  = binding.MIMEMSGPACK
+	MIMEMSGPACK2 = binding.MIMEMSGPACK2
+)
+
+func init() {
+	msgpack := func(data any) render.Render { return render.MsgPack{Data: data} }
+	builtinFormats[MIMEMSGPACK] = msgpack
+	builtinFormats[MIMEMSGPACK2] = msgpack
+}
@@ -0,0 +1,29 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build !nomsgpack
+
+package gin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+)
+
+func TestNegotiateMsgPack(t *testing.T) {
+	router := New()
+	router.GET("/", func(c *Context) {
+		c.Negotiate(http.StatusOK, Negotiate{Offered: []string{MIMEJSON, MIMEMSGPACK2}, Data: H{"name": "ada"}})
+	})
+	w := PerformRequest(router, http.MethodGet, "/", header{"Accept", "application/msgpack, application/json;q=0.9"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/msgpack; charset=utf-8", w.Header().Get("Content-Type"))
+	var got map[string]string
+	require.NoError(t, codec.NewDecoderBytes(w.Body.Bytes(), new(codec.MsgpackHandle)).Decode(&got))
+	assert.Equal(t, map[string]string{"name": "ada"}, got)
+}
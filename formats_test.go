@@ -0,0 +1,104 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jialequ/mpgw/render"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const mimeNDJSON = "application/x-ndjson"
+
+// ndjson renders the items of a slice as JSON lines.
+type ndjson struct {
+	items []string
+}
+
+func (r ndjson) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	enc := json.NewEncoder(w)
+	for _, item := range r.items {
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r ndjson) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", mimeNDJSON)
+}
+
+func formatsRouter() *Engine {
+	router := New()
+	router.RegisterFormat(mimeNDJSON, func(data any) render.Render {
+		return ndjson{items: data.([]string)}
+	})
+	router.GET("/", func(c *Context) {
+		c.Negotiate(http.StatusOK, Negotiate{
+			Offered:    []string{MIMEJSON, mimeNDJSON, MIMEPROTOBUF},
+			Data:       []string{"a", "b"},
+			FormatData: map[string]any{MIMEPROTOBUF: wrapperspb.String("a,b")},
+		})
+	})
+	return router
+}
+
+func TestNegotiateRegisteredFormats(t *testing.T) {
+	router := formatsRouter()
+
+	w := PerformRequest(router, http.MethodGet, "/", header{"Accept", mimeNDJSON})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, mimeNDJSON, w.Header().Get("Content-Type"))
+	assert.Equal(t, "\"a\"\n\"b\"\n", w.Body.String())
+
+	w = PerformRequest(router, http.MethodGet, "/", header{"Accept", MIMEPROTOBUF})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, MIMEPROTOBUF, w.Header().Get("Content-Type"))
+	var msg wrapperspb.StringValue
+	require.NoError(t, proto.Unmarshal(w.Body.Bytes(), &msg))
+	assert.Equal(t, "a,b", msg.GetValue())
+
+	// by quality
+	w = PerformRequest(router, http.MethodGet, "/", header{"Accept", "application/json;q=0.5, application/x-ndjson;q=0.8, */*;q=0.1"})
+	assert.Equal(t, mimeNDJSON, w.Header().Get("Content-Type"))
+	w = PerformRequest(router, http.MethodGet, "/", header{"Accept", "application/x-ndjson;q=0, application/*"})
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	w = PerformRequest(router, http.MethodGet, "/", header{"Accept", "application/json;q=0, application/x-ndjson;q=0"})
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+
+	// the unregistered formats are not acceptable
+	router = New()
+	router.GET("/", func(c *Context) {
+		c.Negotiate(http.StatusOK, Negotiate{Offered: []string{mimeNDJSON}, Data: []string{"a"}})
+	})
+	w = PerformRequest(router, http.MethodGet, "/", header{"Accept", mimeNDJSON})
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+}
+
+func TestRegisterFormat(t *testing.T) {
+	router := formatsRouter()
+	clone := router.Clone()
+	router.RegisterFormat(mimeNDJSON, func(data any) render.Render {
+		return render.String{Format: "replaced"}
+	})
+	assert.Equal(t, "replaced", PerformRequest(router, http.MethodGet, "/", header{"Accept", mimeNDJSON}).Body.String())
+	assert.Equal(t, "\"a\"\n\"b\"\n", PerformRequest(clone, http.MethodGet, "/", header{"Accept", mimeNDJSON}).Body.String())
+	assert.NotContains(t, builtinFormats, mimeNDJSON)
+
+	assert.PanicsWithValue(t, "invalid format MIME type application/json; charset=utf-8", func() {
+		router.RegisterFormat("application/json; charset=utf-8", func(data any) render.Render { return nil })
+	})
+	assert.PanicsWithValue(t, "format text/csv can not be nil", func() {
+		router.RegisterFormat("text/csv", nil)
+	})
+}
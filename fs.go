@@ -5,8 +5,15 @@
 package gin
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
 	"net/http"
 	"os"
+	"path"
+	"runtime/debug"
+	"sync"
+	"time"
 )
 
 // OnlyFilesFS implements an http.FileSystem without `Readdir` functionality.
@@ -49,3 +56,126 @@ func Dir(root string, listDirectory bool) http.FileSystem {
 
 	return &OnlyFilesFS{FileSystem: fs}
 }
+
+// buildTime returns the time the binary was built: the time of the commit
+// stamped in its build info, or else the modification time of the executable.
+var buildTime = sync.OnceValue(func() time.Time {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.time" {
+				if t, err := time.Parse(time.RFC3339, setting.Value); err == nil {
+					return t
+				}
+			}
+		}
+	}
+	if exe, err := os.Executable(); err == nil {
+		if info, err := os.Stat(exe); err == nil {
+			return info.ModTime()
+		}
+	}
+	return time.Now()
+})
+
+// embeddedFS serves the files of a file system without modification times,
+// such as an embed.FS, as modified at the build time of the binary, with the
+// ETags hashing their content computed once.
+type embeddedFS struct {
+	http.FileSystem
+	modTime time.Time
+	etags   map[string]string
+}
+
+// newEmbeddedFS wraps fs if its root has no modification time, or returns nil.
+func newEmbeddedFS(fs http.FileSystem) *embeddedFS {
+	root, err := fs.Open("/")
+	if err != nil {
+		return nil
+	}
+	info, err := root.Stat()
+	root.Close()
+	if err != nil || !info.ModTime().IsZero() {
+		return nil
+	}
+
+	efs := &embeddedFS{FileSystem: fs, modTime: buildTime(), etags: make(map[string]string)}
+	walkable := fs
+	if o, ok := fs.(*OnlyFilesFS); ok {
+		walkable = o.FileSystem
+	}
+	efs.hashDir(walkable, "/")
+	return efs
+}
+
+// hashDir hashes the files of the directory name, skipping the unreadable ones.
+func (efs *embeddedFS) hashDir(fs http.FileSystem, name string) {
+	dir, err := fs.Open(name)
+	if err != nil {
+		return
+	}
+	entries, err := dir.Readdir(-1)
+	dir.Close()
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		file := path.Join(name, entry.Name())
+		if entry.IsDir() {
+			efs.hashDir(fs, file)
+			continue
+		}
+		if etag, ok := hashFile(fs, file); ok {
+			efs.etags[file] = etag
+		}
+	}
+}
+
+// hashFile returns the strong ETag of the content of a file.
+func hashFile(fs http.FileSystem, name string) (string, bool) {
+	f, err := fs.Open(name)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", false
+	}
+	return `"` + base64.RawURLEncoding.EncodeToString(h.Sum(nil)[:16]) + `"`, true
+}
+
+// etag returns the ETag of the file served for the request path name.
+func (efs *embeddedFS) etag(name string) string {
+	return efs.etags[path.Clean("/"+name)]
+}
+
+// Open opens a file, giving it the build time if it has no modification time.
+func (efs *embeddedFS) Open(name string) (http.File, error) {
+	f, err := efs.FileSystem.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return embeddedFile{File: f, modTime: efs.modTime}, nil
+}
+
+type embeddedFile struct {
+	http.File
+	modTime time.Time
+}
+
+func (f embeddedFile) Stat() (os.FileInfo, error) {
+	info, err := f.File.Stat()
+	if err != nil || !info.ModTime().IsZero() {
+		return info, err
+	}
+	return embeddedFileInfo{FileInfo: info, modTime: f.modTime}, nil
+}
+
+type embeddedFileInfo struct {
+	os.FileInfo
+	modTime time.Time
+}
+
+func (i embeddedFileInfo) ModTime() time.Time {
+	return i.modTime
+}
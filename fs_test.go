@@ -5,8 +5,10 @@ import (
 	"net/http"
 	"os"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type mockFileSystem struct {
@@ -69,3 +71,44 @@ func TestDir(t *testing.T) {
 
 	assert.Equal(t, &OnlyFilesFS{FileSystem: http.Dir(testRoot)}, fs)
 }
+
+func TestStaticFSEmbedded(t *testing.T) {
+	// the files of a fstest.MapFS have no modification time, as in an embed.FS
+	assets := fstest.MapFS{
+		"app.js":       {Data: []byte("console.log(1)")},
+		"css/site.css": {Data: []byte("body{}")},
+	}
+	router := New()
+	router.StaticFS("/static", http.FS(assets))
+	modified := buildTime().UTC().Format(http.TimeFormat)
+
+	w := PerformRequest(router, http.MethodGet, "/static/app.js")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "console.log(1)", w.Body.String())
+	assert.Equal(t, modified, w.Header().Get("Last-Modified"))
+	etag := w.Header().Get("ETag")
+	require.Len(t, etag, 24)
+
+	w = PerformRequest(router, http.MethodGet, "/static/app.js", header{"If-None-Match", etag})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	w = PerformRequest(router, http.MethodGet, "/static/app.js", header{"If-Modified-Since", modified})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	w = PerformRequest(router, http.MethodGet, "/static/css/site.css")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+
+	w = PerformRequest(router, http.MethodGet, "/static/missing.js")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, w.Header().Get("ETag"))
+
+	// the files with modification times are served as is
+	router = New()
+	router.Static("/static", ".")
+	w = PerformRequest(router, http.MethodGet, "/static/fs.go")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("ETag"))
+	assert.NotEqual(t, modified, w.Header().Get("Last-Modified"))
+}
@@ -5,6 +5,7 @@
 package gin
 
 import (
+	"crypto/tls"
 	"fmt"
 	"html/template"
 	"net"
@@ -12,12 +13,16 @@ import (
 	"os"
 	"path"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/jialequ/mpgw/internal/bytesconv"
 	"github.com/jialequ/mpgw/render"
 
+	"github.com/quic-go/quic-go"
 	"github.com/quic-go/quic-go/http3"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
@@ -70,8 +75,12 @@ func (c HandlersChain) Last() HandlerFunc {
 type RouteInfo struct {
 	Method      string
 	Path        string
+	Host        string
 	Handler     string
 	HandlerFunc HandlerFunc
+	Name        string
+	Description string
+	Tags        []string
 }
 
 // RoutesInfo defines a RouteInfo slice.
@@ -112,6 +121,13 @@ type Engine struct {
 	// RedirectTrailingSlash is independent of this option.
 	RedirectFixedPath bool
 
+	// UnicodeCaseFolding if enabled, the case-insensitive lookups of
+	// RedirectFixedPath and RouterGroup.CaseInsensitive match the runes
+	// equal under Unicode case folding, even when their encodings differ in
+	// length, such as "ß" and "ẞ", "k" and the Kelvin sign, or the Turkish
+	// dotless "ı" and "i". Otherwise, the runes of different lengths never match.
+	UnicodeCaseFolding bool
+
 	// HandleMethodNotAllowed if enabled, the router checks if another method is allowed for the
 	// current route, if the current request can not be routed.
 	// If this is the case, the request is answered with 'Method Not Allowed'
@@ -120,6 +136,20 @@ type Engine struct {
 	// handler.
 	HandleMethodNotAllowed bool
 
+	// HandleHeadWithGet if enabled, the HEAD requests matching no HEAD route
+	// are served by the GET route of their path, as net/http does: the body is
+	// dropped and, unless the handlers set it or flush the response, the
+	// Content-Length is its size. HEAD is then listed in the Allow header of
+	// the 405 responses along with GET.
+	HandleHeadWithGet bool
+
+	// HandleOPTIONS if enabled, the OPTIONS requests matching no OPTIONS route
+	// are answered with 204 No Content and an Allow header listing the methods
+	// of the routes matching their path, after the global middlewares. The
+	// requests for "*" list all the methods. OPTIONS is then listed in the
+	// Allow header of the 405 responses.
+	HandleOPTIONS bool
+
 	// ForwardedByClientIP if enabled, client IP will be parsed from the request's headers that
 	// match those stored at `(*gin.Engine).RemoteIPHeaders`. If no IP was
 	// fetched, it falls back to the IP obtained from
@@ -151,7 +181,8 @@ type Engine struct {
 	RemoteIPHeaders []string
 
 	// TrustedPlatform if set to a constant of value gin.Platform*, trusts the headers set by
-	// that platform, for example to determine the client IP
+	// that platform, for example to determine the client IP. See Engine.SetPlatform
+	// to trust them only from the proxies of the platform.
 	TrustedPlatform string
 
 	// MaxMultipartMemory value of 'maxMemory' param that is given to http.Request's ParseMultipartForm
@@ -164,6 +195,47 @@ type Engine struct {
 	// ContextWithFallback enable fallback Context.Deadline(), Context.Done(), Context.Err() and Context.Value() when Context.Request.Context() is not nil.
 	ContextWithFallback bool
 
+	// HTTPHardening enables a strict parsing of HTTP/1.x requests by the Run* methods,
+	// against request smuggling. See HTTPHardening.
+	HTTPHardening HTTPHardening
+
+	// MaxPathLength is the maximum length of the path of a request, checked
+	// before the route lookup: longer paths are answered with 414 URI Too Long.
+	// Optional. Default value is 0, unlimited.
+	MaxPathLength int
+
+	// MaxRouteParams is the maximum number of params of a route, checked when
+	// it is registered, and of params matched by a request, checked after the
+	// route lookup: the requests matching a route registered before the limit
+	// was set with more params are answered with 400 Bad Request.
+	// Optional. Default value is 0, unlimited.
+	MaxRouteParams int
+
+	// MaxTreeDepth is the maximum number of segments of a route, checked when
+	// it is registered. Optional. Default value is 0, unlimited.
+	MaxTreeDepth int
+
+	// HTMLBufferSize is the size up to which the output of the HTML templates
+	// is buffered, so that a template failing within it is answered with 500
+	// Internal Server Error instead of a partial page, see Context.HTML. A
+	// negative size disables the buffering. Optional. Default value is 32 KiB.
+	HTMLBufferSize int
+
+	// TimeRequests times the phases of the requests, see Context.Timings.
+	// The wide events time them too. Optional. Default value is false.
+	TimeRequests bool
+
+	// Sandbox defines when the mock responses of the routes are served, see
+	// RouteHandle.Mock. Optional. Default value serves none.
+	Sandbox SandboxConfig
+
+	// Clock is the time source of the logger latencies, the route timeouts
+	// and RouteConfig.Timeout, the pressure guards, the retry budgets of the
+	// upstreams, and the expiries of the cached authentication decisions and
+	// upstream tokens. Tests can set a FakeClock to advance the time instead
+	// of sleeping. Optional. Default value is the system clock.
+	Clock Clock
+
 	delims           render.Delims
 	secureJSONPrefix string
 	HTMLRender       render.HTMLRender
@@ -173,11 +245,44 @@ type Engine struct {
 	noRoute          HandlersChain
 	noMethod         HandlersChain
 	pool             sync.Pool
-	trees            methodTrees
-	maxParams        uint16
-	maxSections      uint16
 	trustedProxies   []string
 	trustedCIDRs     []*net.IPNet
+	platform         *trustedPlatform
+	errorMapping     *errorMapping
+
+	connections     *ConnRegistry
+	connectionsOnce sync.Once
+	connMetrics     atomic.Pointer[ConnMetrics]
+	routeStats      atomic.Pointer[RouteStats]
+	disabledRoutes  atomic.Int32
+
+	// routeTable is the routing state the routes are registered on, and
+	// table the one read by the requests, see Update.
+	*routeTable
+	table   atomic.Pointer[routeTable]
+	serving atomic.Bool
+	updates updates
+
+	routeConfigured atomic.Bool
+	caseInsensitive atomic.Bool
+	constraints     map[string]func(string) bool
+	formats         map[string]func(data any) render.Render
+
+	events atomic.Pointer[EventBus]
+	// wideEvents emits the wide events of the requests, see WideEvents.
+	wideEvents atomic.Pointer[wideEvents]
+	// cookieKeys sign and encrypt the cookies, see SetCookieKeys.
+	cookieKeys atomic.Pointer[cookieKeys]
+
+	// swapped is the engine serving the requests instead, see Swap, and
+	// generations the history of the swaps.
+	swapped     atomic.Pointer[Engine]
+	generations generations
+
+	upstreamsMu sync.RWMutex
+	upstreams   map[string]*Upstream
+
+	checks []engineCheck
 }
 
 var _ IRouter = (*Engine)(nil)
@@ -209,15 +314,16 @@ func New(opts ...OptionFunc) *Engine {
 		RemoveExtraSlash:       false,
 		UnescapePathValues:     true,
 		MaxMultipartMemory:     defaultMultipartMemory,
-		trees:                  make(methodTrees, 0, 9),
+		routeTable:             &routeTable{trees: make(methodTrees, 0, 9)},
 		delims:                 render.Delims{Left: "{{", Right: "}}"},
 		secureJSONPrefix:       "while(1);",
 		trustedProxies:         []string{"0.0.0.0/0", "::/0"},
 		trustedCIDRs:           defaultTrustedCIDRs,
 	}
 	engine.RouterGroup.engine = engine
+	engine.table.Store(engine.routeTable)
 	engine.pool.New = func() any {
-		return engine.allocateContext(engine.maxParams)
+		return engine.allocateContext(engine.table.Load().maxParams)
 	}
 	return engine.With(opts...)
 }
@@ -239,10 +345,17 @@ func (engine *Engine) Handler() http.Handler {
 	return h2c.NewHandler(engine, h2s)
 }
 
+// allocateContext returns a context for the pool. Its params slice is not
+// allocated while no route has params, getValue growing it on demand.
 func (engine *Engine) allocateContext(maxParams uint16) *Context {
-	v := make(Params, 0, maxParams)
-	skippedNodes := make([]skippedNode, 0, engine.maxSections)
-	return &Context{engine: engine, params: &v, skippedNodes: &skippedNodes}
+	var v Params
+	if maxParams > 0 {
+		v = make(Params, 0, maxParams)
+	}
+	skippedNodes := make([]skippedNode, 0, engine.table.Load().maxSections)
+	c := &Context{engine: engine, params: &v, skippedNodes: &skippedNodes}
+	c.writermem.owner = c
+	return c
 }
 
 // Delims sets template left and right delims and returns an Engine instance.
@@ -262,11 +375,11 @@ func (engine *Engine) SecureJsonPrefix(prefix string) *Engine {
 func (engine *Engine) LoadHTMLGlob(pattern string) {
 	left := engine.delims.Left
 	right := engine.delims.Right
-	templ := template.Must(template.New("").Delims(left, right).Funcs(engine.FuncMap).ParseGlob(pattern))
+	templ := template.Must(template.New("").Delims(left, right).Funcs(engine.templateFuncs()).ParseGlob(pattern))
 
 	if IsDebugging() {
 		debugPrintLoadTemplate(templ)
-		engine.HTMLRender = render.HTMLDebug{Glob: pattern, FuncMap: engine.FuncMap, Delims: engine.delims}
+		engine.HTMLRender = render.HTMLDebug{Glob: pattern, FuncMap: engine.templateFuncs(), Delims: engine.delims}
 		return
 	}
 
@@ -277,11 +390,11 @@ func (engine *Engine) LoadHTMLGlob(pattern string) {
 // and associates the result with HTML renderer.
 func (engine *Engine) LoadHTMLFiles(files ...string) {
 	if IsDebugging() {
-		engine.HTMLRender = render.HTMLDebug{Files: files, FuncMap: engine.FuncMap, Delims: engine.delims}
+		engine.HTMLRender = render.HTMLDebug{Files: files, FuncMap: engine.templateFuncs(), Delims: engine.delims}
 		return
 	}
 
-	templ := template.Must(template.New("").Delims(engine.delims.Left, engine.delims.Right).Funcs(engine.FuncMap).ParseFiles(files...))
+	templ := template.Must(template.New("").Delims(engine.delims.Left, engine.delims.Right).Funcs(engine.templateFuncs()).ParseFiles(files...))
 	engine.SetHTMLTemplate(templ)
 }
 
@@ -291,7 +404,7 @@ func (engine *Engine) SetHTMLTemplate(templ *template.Template) {
 		debugPrintWARNINGSetHTMLTemplate()
 	}
 
-	engine.HTMLRender = render.HTMLProduction{Template: templ.Funcs(engine.FuncMap)}
+	engine.HTMLRender = render.HTMLProduction{Template: templ.Funcs(engine.templateFuncs())}
 }
 
 // SetFuncMap sets the FuncMap used for template.FuncMap.
@@ -338,36 +451,102 @@ func (engine *Engine) rebuild405Handlers() {
 	engine.allNoMethod = engine.combineHandlers(engine.noMethod)
 }
 
-func (engine *Engine) addRoute(method, path string, handlers HandlersChain) {
+func (engine *Engine) addRoute(method, path string, handlers HandlersChain) *Route {
+	return engine.addHostRoute("", method, path, handlers)
+}
+
+// addHostRoute adds a route matching the requests for a host pattern, or any
+// host if empty.
+func (engine *Engine) addHostRoute(host, method, path string, handlers HandlersChain) (route *Route) {
+	engine.checkRoute(host, method, path, handlers)
+
+	engine.update(func() {
+		engine.insertRoute(host, method, path, handlers, true)
+		if engine.prioritized > 0 {
+			engine.prioritize(host, method)
+		}
+
+		route = &Route{Method: method, Path: path, Host: host}
+		engine.routes = append(engine.routes, route)
+		if engine.routeIndex == nil {
+			engine.routeIndex = make(map[string]*Route)
+		}
+		engine.routeIndex[routeKey(host, method, path)] = route
+	})
+	return route
+}
+
+// checkRoute panics if a route cannot be registered, and prints it in debug
+// mode.
+func (engine *Engine) checkRoute(host, method, path string, handlers HandlersChain) {
 	assert1(path[0] == '/', "path must begin with '/'")
 	assert1(method != "", "HTTP method can not be empty")
 	assert1(len(handlers) > 0, "there must be at least one handler")
 
-	debugPrintRoute(method, path, handlers)
+	if engine.MaxRouteParams > 0 {
+		params := len(routeParams(path))
+		assert1(params <= engine.MaxRouteParams,
+			fmt.Sprintf("route '%s' has %d params, more than MaxRouteParams (%d)", path, params, engine.MaxRouteParams))
+	}
+	if engine.MaxTreeDepth > 0 {
+		depth := int(countSections(path))
+		assert1(depth <= engine.MaxTreeDepth,
+			fmt.Sprintf("route '%s' has %d segments, more than MaxTreeDepth (%d)", path, depth, engine.MaxTreeDepth))
+	}
+
+	debugPrintRoute(method, host+path, handlers)
+}
 
-	root := engine.trees.get(method)
+// insertRoute adds the handlers of a route to the tree of its method, and
+// returns the root of the tree. Its children are reordered by priority unless
+// reorder is false.
+func (engine *Engine) insertRoute(host, method, path string, handlers HandlersChain, reorder bool) *node {
+	trees := engine.treesFor(host)
+	root := trees.get(method)
 	if root == nil {
 		root = new(node)
 		root.fullPath = "/"
-		engine.trees = append(engine.trees, methodTree{method: method, root: root})
+		*trees = append(*trees, methodTree{method: method, root: root})
 	}
-	root.addRoute(path, handlers)
+	root.insertRoute(path, handlers, engine.routeConstraints(), reorder)
 
-	if paramsCount := countParams(path); paramsCount > engine.maxParams {
+	paramsCount := countParams(path)
+	if isCatchAllHost(host) {
+		paramsCount++
+	}
+	if paramsCount > engine.maxParams {
 		engine.maxParams = paramsCount
 	}
 
 	if sectionsCount := countSections(path); sectionsCount > engine.maxSections {
 		engine.maxSections = sectionsCount
 	}
+	return root
 }
 
 // Routes returns a slice of registered routes, including some useful information, such as:
 // the http method, path and the handler name.
 func (engine *Engine) Routes() (routes RoutesInfo) {
-	for _, tree := range engine.trees {
+	t := engine.table.Load()
+	for _, tree := range t.trees {
 		routes = iterate("", tree.method, routes, tree.root)
 	}
+	for _, pattern := range sortedKeys(t.hosts) {
+		n := len(routes)
+		for _, tree := range t.hosts[pattern].trees {
+			routes = iterate("", tree.method, routes, tree.root)
+		}
+		for i := n; i < len(routes); i++ {
+			routes[i].Host = pattern
+		}
+	}
+	for i := range routes {
+		if route := t.routeIndex[routeKey(routes[i].Host, routes[i].Method, routes[i].Path)]; route != nil {
+			routes[i].Name = route.Name
+			routes[i].Description = route.Description
+			routes[i].Tags = route.Tags
+		}
+	}
 	return routes
 }
 
@@ -383,7 +562,9 @@ func iterate(path, method string, routes RoutesInfo, root *node) RoutesInfo {
 		})
 	}
 	for _, child := range root.children {
-		routes = iterate(path, method, routes, child)
+		for ; child != nil; child = child.alt {
+			routes = iterate(path, method, routes, child)
+		}
 	}
 	return routes
 }
@@ -392,9 +573,13 @@ func (engine *Engine) prepareTrustedCIDRs() ([]*net.IPNet, error) {
 	if engine.trustedProxies == nil {
 		return nil, nil
 	}
+	return parseCIDRs(engine.trustedProxies)
+}
 
-	cidr := make([]*net.IPNet, 0, len(engine.trustedProxies))
-	for _, trustedProxy := range engine.trustedProxies {
+// parseCIDRs parses a list of IP addresses and CIDRs.
+func parseCIDRs(trustedProxies []string) ([]*net.IPNet, error) {
+	cidr := make([]*net.IPNet, 0, len(trustedProxies))
+	for _, trustedProxy := range trustedProxies {
 		if !strings.Contains(trustedProxy, "/") {
 			ip := parseIP(trustedProxy)
 			if ip == nil {
@@ -486,15 +671,24 @@ func updateRouteTree(n *node) {
 		return
 	}
 	for _, child := range n.children {
-		updateRouteTree(child)
+		for ; child != nil; child = child.alt {
+			updateRouteTree(child)
+		}
 	}
 }
 
 // updateRouteTrees do update to the route trees
 func (engine *Engine) updateRouteTrees() {
-	for _, tree := range engine.trees {
-		updateRouteTree(tree.root)
-	}
+	engine.update(func() {
+		for _, tree := range engine.trees {
+			updateRouteTree(tree.root)
+		}
+		for _, ht := range engine.hosts {
+			for _, tree := range ht.trees {
+				updateRouteTree(tree.root)
+			}
+		}
+	})
 }
 
 // parseIP parse a string representation of an IP and returns a net.IP with the
@@ -524,7 +718,17 @@ func (engine *Engine) Run(addr ...string) (err error) {
 	engine.updateRouteTrees()
 	address := resolveAddress(addr)
 	debugPrint("Listening and serving HTTP on %s\n", address)
-	err = http.ListenAndServe(address, engine.Handler())
+	if !engine.HTTPHardening.enabled() {
+		engine.publishStarted("tcp", address)
+		err = engine.server(address).ListenAndServe()
+		return
+	}
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return
+	}
+	engine.publishStarted("tcp", listener.Addr().String())
+	err = engine.server("").Serve(engine.HardenListener(listener))
 	return
 }
 
@@ -540,7 +744,21 @@ func (engine *Engine) RunTLS(addr, certFile, keyFile string) (err error) {
 			solve112)
 	}
 
-	err = http.ListenAndServeTLS(addr, certFile, keyFile, engine.Handler())
+	srv := engine.server(addr)
+	if m := engine.connMetrics.Load(); m != nil {
+		var cert tls.Certificate
+		if cert, err = tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+			return
+		}
+		srv.TLSConfig = m.TLSConfig(&tls.Config{
+			Certificates: []tls.Certificate{cert},
+			NextProtos:   []string{"h2", "http/1.1"},
+			MinVersion:   tls.VersionTLS12,
+		})
+		certFile, keyFile = "", ""
+	}
+	engine.publishStarted("tcp", addr)
+	err = srv.ListenAndServeTLS(certFile, keyFile)
 	return
 }
 
@@ -563,7 +781,8 @@ func (engine *Engine) RunUnix(file string) (err error) {
 	defer listener.Close()
 	defer os.Remove(file)
 
-	err = http.Serve(listener, engine.Handler())
+	engine.publishStarted("unix", file)
+	err = engine.server("").Serve(engine.HardenListener(listener))
 	return
 }
 
@@ -601,6 +820,12 @@ func (engine *Engine) RunQUIC(addr, certFile, keyFile string) (err error) {
 			"Please check https://pkg.go.dev/github.com/jialequ/mpgw#readme-don-t-trust-all-proxies for details.")
 	}
 
+	engine.publishStarted("udp", addr)
+	if m := engine.connMetrics.Load(); m != nil {
+		srv := &http3.Server{Addr: addr, Handler: engine.Handler(), QUICConfig: &quic.Config{Tracer: m.QUICTracer}}
+		err = srv.ListenAndServeTLS(certFile, keyFile)
+		return
+	}
 	err = http3.ListenAndServeQUIC(addr, certFile, keyFile, engine.Handler())
 	return
 }
@@ -616,18 +841,55 @@ func (engine *Engine) RunListener(listener net.Listener) (err error) {
 			solve112)
 	}
 
-	err = http.Serve(listener, engine.Handler())
+	engine.publishStarted(listener.Addr().Network(), listener.Addr().String())
+	err = engine.server("").Serve(engine.HardenListener(listener))
 	return
 }
 
 // ServeHTTP conforms to the http.Handler interface.
 func (engine *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if next := engine.swapped.Load(); next != nil {
+		next.serveHTTP(w, req)
+		return
+	}
+	engine.serveHTTP(w, req)
+}
+
+// serveHTTP serves the request with the routes of the engine.
+func (engine *Engine) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	if !engine.serving.Load() {
+		engine.serving.Store(true)
+	}
 	c := engine.pool.Get().(*Context)
 	c.writermem.reset(w)
 	c.Request = req
 	c.reset()
+	wide := engine.wideEvents.Load()
+	if c.timing = wide != nil || engine.TimeRequests; c.timing {
+		c.phases = requestPhases{start: c.now(), handlers: c.phases.handlers[:0]}
+	}
 
-	engine.handleHTTPRequest(c)
+	if bus := engine.events.Load(); bus != nil {
+		engine.serveWithEvents(c, bus)
+	} else {
+		engine.handleHTTPRequest(c)
+	}
+	if c.writermem.headPending {
+		// the header of a HEAD response whose body was dropped
+		c.writermem.WriteHeaderNow()
+	}
+	if len(c.deadlines) > 0 {
+		c.finishDeadlines()
+	}
+	if c.timing {
+		c.phases.end = c.now()
+	}
+	if wide != nil {
+		wide.emit(c)
+	}
+	if c.spools != nil {
+		c.removeSpools()
+	}
 
 	engine.pool.Put(c)
 }
@@ -652,12 +914,43 @@ func (engine *Engine) handleHTTPRequest(c *Context) { // NOSONAR
 		unescape = engine.UnescapePathValues
 	}
 
+	if engine.MaxPathLength > 0 && len(rPath) > engine.MaxPathLength {
+		rejectPath(c, http.StatusRequestURITooLong)
+		return
+	}
+
 	if engine.RemoveExtraSlash {
 		rPath = cleanPath(rPath)
 	}
 
+	// Find the routes of the host, falling back to the ones of any host
+	table := engine.table.Load()
+	ht := table.matchHost(c.Request.Host)
+	var hostTSR *node
+	if ht != nil {
+		if root := ht.trees.get(httpMethod); root != nil {
+			value := root.getValue(rPath, c.params, c.skippedNodes, unescape)
+			if value.handlers != nil {
+				if value.params != nil {
+					c.Params = *value.params
+				}
+				c.routeHost = ht.pattern
+				engine.serveRoute(c, httpMethod, value)
+				return
+			}
+			if engine.caseInsensitive.Load() && engine.serveCaseInsensitive(c, root, ht.pattern, httpMethod, rPath, unescape) {
+				return
+			}
+			if value.tsr {
+				hostTSR = root
+			}
+			*c.params = (*c.params)[:0]
+			*c.skippedNodes = (*c.skippedNodes)[:0]
+		}
+	}
+
 	// Find root of the tree for the given HTTP method
-	t := engine.trees
+	t := table.trees
 	for i, tl := 0, len(t); i < tl; i++ {
 		if t[i].method != httpMethod {
 			continue
@@ -669,37 +962,42 @@ func (engine *Engine) handleHTTPRequest(c *Context) { // NOSONAR
 			c.Params = *value.params
 		}
 		if value.handlers != nil {
-			c.handlers = value.handlers
-			c.fullPath = value.fullPath
-			c.Next()
-			c.writermem.WriteHeaderNow()
+			engine.serveRoute(c, httpMethod, value)
+			return
+		}
+		if engine.caseInsensitive.Load() && engine.serveCaseInsensitive(c, root, "", httpMethod, rPath, unescape) {
 			return
 		}
 		if httpMethod != http.MethodConnect && rPath != "/" {
-			if value.tsr && engine.RedirectTrailingSlash {
-				redirectTrailingSlash(c)
+			if hostTSR != nil && engine.serveTrailingSlash(c, hostTSR, ht.pattern, httpMethod, rPath, unescape) {
+				return
+			}
+			if value.tsr && engine.serveTrailingSlash(c, root, "", httpMethod, rPath, unescape) {
 				return
 			}
 			if engine.RedirectFixedPath && redirectFixedPath(c, root, engine.RedirectFixedPath) {
+				engine.countFixedPath(c, root, httpMethod)
 				return
 			}
 		}
 		break
 	}
 
+	if httpMethod == http.MethodHead && engine.HandleHeadWithGet && engine.serveHeadWithGet(c, table, ht, rPath, unescape) {
+		return
+	}
+
+	if httpMethod == http.MethodOptions && engine.HandleOPTIONS {
+		if allowed := engine.allowedMethods(c, table, ht, httpMethod, rPath, unescape); len(allowed) > 0 {
+			serveOptions(c, engine.Handlers, allowed)
+			return
+		}
+	}
+
 	if engine.HandleMethodNotAllowed {
 		// According to RFC 7231 section 6.5.5, MUST generate an Allow header field in response
 		// containing a list of the target resource's currently supported methods.
-		allowed := make([]string, 0, len(t)-1)
-		for _, tree := range engine.trees {
-			if tree.method == httpMethod {
-				continue
-			}
-			if value := tree.root.getValue(rPath, nil, c.skippedNodes, unescape); value.handlers != nil {
-				allowed = append(allowed, tree.method)
-			}
-		}
-		if len(allowed) > 0 {
+		if allowed := engine.allowedMethods(c, table, ht, httpMethod, rPath, unescape); len(allowed) > 0 {
 			c.handlers = engine.allNoMethod
 			c.writermem.Header().Set("Allow", strings.Join(allowed, ", "))
 			serveError(c, http.StatusMethodNotAllowed, default405Body)
@@ -707,10 +1005,136 @@ func (engine *Engine) handleHTTPRequest(c *Context) { // NOSONAR
 		}
 	}
 
+	if len(table.fallbacks) > 0 {
+		host := ""
+		if ht != nil {
+			host = ht.pattern
+		}
+		if serveFallback(c, table.fallbacks, host, rPath) {
+			return
+		}
+	}
+	if table.catchAllHost != nil && engine.serveCatchAllHost(c, table.catchAllHost, httpMethod, rPath, unescape) {
+		return
+	}
+
 	c.handlers = engine.allNoRoute
 	serveError(c, http.StatusNotFound, default404Body)
 }
 
+// allowedMethods returns the methods of the routes matching the path, but
+// httpMethod, along with the ones answered automatically: HEAD with
+// HandleHeadWithGet, and OPTIONS with HandleOPTIONS. The asterisk-form path of
+// the OPTIONS requests matches all the routes.
+func (engine *Engine) allowedMethods(c *Context, table *routeTable, ht *hostTrees, httpMethod, rPath string, unescape bool) []string {
+	trees := table.trees
+	if ht != nil {
+		trees = append(ht.trees[:len(ht.trees):len(ht.trees)], trees...)
+	}
+	allowed := make([]string, 0, len(trees))
+	for _, tree := range trees {
+		if tree.method == httpMethod || slices.Contains(allowed, tree.method) {
+			continue
+		}
+		if rPath == "*" && httpMethod == http.MethodOptions {
+			allowed = append(allowed, tree.method)
+		} else if value := tree.root.getValue(rPath, nil, c.skippedNodes, unescape); value.handlers != nil {
+			allowed = append(allowed, tree.method)
+		}
+	}
+	if len(allowed) == 0 {
+		return nil
+	}
+	if engine.HandleHeadWithGet && slices.Contains(allowed, http.MethodGet) && !slices.Contains(allowed, http.MethodHead) {
+		allowed = append(allowed, http.MethodHead)
+	}
+	if engine.HandleOPTIONS && !slices.Contains(allowed, http.MethodOptions) {
+		allowed = append(allowed, http.MethodOptions)
+	}
+	return allowed
+}
+
+// serveOptions answers an OPTIONS request matching no OPTIONS route with the
+// allowed methods, after the global middlewares, such as a CORS one.
+func serveOptions(c *Context, handlers HandlersChain, allowed []string) {
+	c.handlers = handlers
+	c.writermem.Header().Set("Allow", strings.Join(allowed, ", "))
+	c.writermem.status = http.StatusNoContent
+	c.Next()
+	c.writermem.WriteHeaderNow()
+}
+
+// serveHeadWithGet serves a HEAD request with the GET route of its path, of
+// the host first, and reports whether there is one.
+func (engine *Engine) serveHeadWithGet(c *Context, table *routeTable, ht *hostTrees, rPath string, unescape bool) bool {
+	for _, trees := range [2]*hostTrees{ht, {trees: table.trees}} {
+		if trees == nil {
+			continue
+		}
+		root := trees.trees.get(http.MethodGet)
+		if root == nil {
+			continue
+		}
+		*c.params = (*c.params)[:0]
+		*c.skippedNodes = (*c.skippedNodes)[:0]
+		if value := root.getValue(rPath, c.params, c.skippedNodes, unescape); value.handlers != nil {
+			c.Params = c.Params[:0]
+			if value.params != nil {
+				c.Params = *value.params
+			}
+			c.routeHost = trees.pattern
+			engine.serveRoute(c, http.MethodGet, value)
+			return true
+		}
+	}
+	return false
+}
+
+// serveRoute serves a request matching a route.
+func (engine *Engine) serveRoute(c *Context, httpMethod string, value nodeValue) {
+	// the param of a host pattern is not counted
+	if engine.MaxRouteParams > 0 && value.params != nil && len(*value.params) > engine.MaxRouteParams {
+		rejectPath(c, http.StatusBadRequest)
+		return
+	}
+	c.handlers = value.handlers
+	c.fullPath = value.fullPath
+	if engine.disabledRoutes.Load() > 0 && engine.serveDisabled(c, httpMethod) {
+		return
+	}
+	if stats := engine.routeStats.Load(); stats != nil {
+		stats.hit(c, httpMethod)
+	}
+	if engine.routeConfigured.Load() {
+		if cancel := engine.applyRouteConfig(c); cancel != nil {
+			defer cancel()
+		}
+	}
+	var timeout *timeoutWriter
+	if engine.table.Load().timedRoutes > 0 {
+		timeout = engine.startTimeout(c, httpMethod)
+	}
+	if engine.table.Load().mockedRoutes > 0 {
+		engine.mockHandlers(c, httpMethod)
+	}
+	engine.publishMatched(c)
+	if route := engine.deprecatedRoute(c.routeHost, httpMethod, value.fullPath); route != nil {
+		serveDeprecated(c, route)
+	} else {
+		c.Next()
+	}
+	if len(c.deadlines) > 0 {
+		c.finishDeadlines()
+	}
+	if timeout != nil {
+		timeout.finish(c)
+	}
+	if engine.errorMapping != nil {
+		engine.errorMapping.handle(c)
+	}
+	c.writermem.WriteHeaderNow()
+}
+
 var mimePlain = []string{MIMEPlain}
 
 func serveError(c *Context, code int, defaultMessage []byte) {
@@ -730,7 +1154,16 @@ func serveError(c *Context, code int, defaultMessage []byte) {
 	c.writermem.WriteHeaderNow()
 }
 
-func redirectTrailingSlash(c *Context) {
+// rejectPath answers a request whose path exceeds the limits of the engine
+// with code, running the global middlewares.
+func rejectPath(c *Context, code int) {
+	c.handlers = c.engine.Handlers
+	serveError(c, code, []byte(strconv.Itoa(code)+" "+http.StatusText(code)))
+}
+
+// redirectTrailingSlash redirects to the path with or without its trailing
+// slash, with the code or the default one of redirectRequest if 0.
+func redirectTrailingSlash(c *Context, code int) {
 	req := c.Request
 	p := req.URL.Path
 	if prefix := path.Clean(c.Request.Header.Get("X-Forwarded-Prefix")); prefix != "." {
@@ -743,29 +1176,31 @@ func redirectTrailingSlash(c *Context) {
 	if length := len(p); length > 1 && p[length-1] == '/' {
 		req.URL.Path = p[:length-1]
 	}
-	redirectRequest(c)
+	redirectRequest(c, code)
 }
 
 func redirectFixedPath(c *Context, root *node, trailingSlash bool) bool {
 	req := c.Request
 	rPath := req.URL.Path
 
-	if fixedPath, ok := root.findCaseInsensitivePath(cleanPath(rPath), trailingSlash); ok {
+	if fixedPath, ok := c.engine.fixPathCase(root, cleanPath(rPath), trailingSlash); ok {
 		req.URL.Path = bytesconv.BytesToString(fixedPath)
-		redirectRequest(c)
+		redirectRequest(c, 0)
 		return true
 	}
 	return false
 }
 
-func redirectRequest(c *Context) {
+func redirectRequest(c *Context, code int) {
 	req := c.Request
 	rPath := req.URL.Path
 	rURL := req.URL.String()
 
-	code := http.StatusMovedPermanently // Permanent redirect, request with GET method
-	if req.Method != http.MethodGet {
-		code = http.StatusTemporaryRedirect
+	if code == 0 {
+		code = http.StatusMovedPermanently // Permanent redirect, request with GET method
+		if req.Method != http.MethodGet {
+			code = http.StatusTemporaryRedirect
+		}
 	}
 	debugPrint("redirecting request %d: %s --> %s", code, rPath, rURL)
 	http.Redirect(c.Writer, req, rURL, code)
@@ -354,6 +354,83 @@ func TestAddRouteFails(t *testing.T) {
 	})
 }
 
+func TestStaticRouteAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+	router := New()
+	router.GET("/status", func(_ *Context) {})
+	router.GET("/users/new", func(c *Context) { assert.Empty(t, c.Params) })
+	router.GET("/users/:id", func(_ *Context) {})
+
+	for _, path := range []string{"/status", "/users/new", "/users/1"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		allocs := testing.AllocsPerRun(100, func() { router.ServeHTTP(w, req) })
+		assert.Zero(t, allocs, path)
+	}
+
+	// no params slice while no route has params
+	static := New()
+	static.GET("/status", func(_ *Context) {})
+	c := static.pool.Get().(*Context)
+	assert.Nil(t, *c.params)
+}
+
+func TestEnginePathGuards(t *testing.T) {
+	router := New()
+	router.MaxPathLength = 32
+	router.MaxRouteParams = 4
+	router.Use(func(c *Context) { c.Header("X-Global", "1") })
+	router.GET("/*path", func(c *Context) { c.String(http.StatusOK, c.Param("path")) })
+
+	w := PerformRequest(router, http.MethodGet, "/a/b/c/d")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = PerformRequest(router, http.MethodGet, "/"+strings.Repeat("a", 32))
+	assert.Equal(t, http.StatusRequestURITooLong, w.Code)
+	assert.Equal(t, "414 Request URI Too Long", w.Body.String())
+	assert.Equal(t, "1", w.Header().Get("X-Global"))
+
+	// a catch-all param matches any number of segments
+	w = PerformRequest(router, http.MethodGet, "/a/b/c/d/e")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// the limit is set after the route is registered
+	router = New()
+	router.Use(func(c *Context) { c.Header("X-Global", "1") })
+	router.GET("/:a/:b/:c", func(c *Context) {})
+	router.MaxRouteParams = 2
+	w = PerformRequest(router, http.MethodGet, "/a/b/c")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-Global"))
+
+	clone := router.Clone()
+	assert.Equal(t, http.StatusBadRequest, PerformRequest(clone, http.MethodGet, "/a/b/c").Code)
+}
+
+func TestEngineRouteLimits(t *testing.T) {
+	router := New()
+	router.MaxRouteParams = 2
+	router.MaxTreeDepth = 4
+	router.GET("/users/:id/posts/:post", handlerTest1)
+	router.GET("/a/b/c/d", handlerTest1)
+
+	assert.PanicsWithValue(t, "route '/:a/:b/:c' has 3 params, more than MaxRouteParams (2)", func() {
+		router.GET("/:a/:b/:c", handlerTest1)
+	})
+	assert.PanicsWithValue(t, "route '/a/b/c/d/e' has 5 segments, more than MaxTreeDepth (4)", func() {
+		router.GET("/a/b/c/d/e", handlerTest1)
+	})
+	assert.Len(t, router.Routes(), 2)
+
+	// the static routes deeper than the limit are served
+	w := PerformRequest(router, http.MethodGet, "/a/b/c/d")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = PerformRequest(router, http.MethodGet, "/users/1/posts/2")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
 func TestCreateDefaultRouter(t *testing.T) {
 	router := Default()
 	assert.Len(t, router.Handlers, 2)
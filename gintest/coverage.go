@@ -0,0 +1,166 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package gintest provides helpers to test gin applications.
+package gintest
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	gin "github.com/jialequ/mpgw"
+)
+
+// TestingT is the subset of testing.TB used by Coverage.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// RouteCoverage is the number of requests served by a route during a test run.
+type RouteCoverage struct {
+	Method string
+	Host   string
+	Path   string
+	Hits   int
+}
+
+// String returns the route as "METHOD host/path".
+func (r RouteCoverage) String() string {
+	return r.Method + " " + r.Host + r.Path
+}
+
+// Coverage records the registered routes of an engine exercised by the
+// requests served during a test run:
+//
+//	func TestAPI(t *testing.T) {
+//	    router := setupRouter()
+//	    cov := gintest.CoverageTracker(router)
+//	    defer cov.Require(t)
+//	    ...
+//	}
+type Coverage struct {
+	engine      *gin.Engine
+	unsubscribe func()
+
+	mu      sync.Mutex
+	hits    map[string]int
+	ignored map[string]bool
+}
+
+// CoverageTracker starts recording the routes of engine matched by the
+// requests it serves, until Stop is called.
+func CoverageTracker(engine *gin.Engine) *Coverage {
+	cov := &Coverage{
+		engine:  engine,
+		hits:    make(map[string]int),
+		ignored: make(map[string]bool),
+	}
+	cov.unsubscribe = engine.Events().Subscribe(gin.EventRouteMatched, func(e gin.Event) {
+		m := e.(gin.RouteMatched)
+		cov.mu.Lock()
+		cov.hits[coverageKey(m.Method, m.Host, m.FullPath)]++
+		cov.mu.Unlock()
+	})
+	return cov
+}
+
+// coverageKey returns the key of the route of method, host and path.
+func coverageKey(method, host, path string) string {
+	return method + " " + host + path
+}
+
+// Ignore excludes the routes registered for method and path from the report,
+// such as health checks which are not worth a test. An empty method ignores all
+// the methods of path.
+func (cov *Coverage) Ignore(method, path string) *Coverage {
+	cov.mu.Lock()
+	defer cov.mu.Unlock()
+	cov.ignored[method+" "+path] = true
+	return cov
+}
+
+// ignores reports whether the route of method and path is ignored.
+func (cov *Coverage) ignores(method, path string) bool {
+	return cov.ignored[method+" "+path] || cov.ignored[" "+path]
+}
+
+// Stop stops recording the requests.
+func (cov *Coverage) Stop() {
+	cov.unsubscribe()
+}
+
+// Routes returns the registered routes which are not ignored, in registration
+// order, with the number of requests they served.
+func (cov *Coverage) Routes() []RouteCoverage {
+	cov.mu.Lock()
+	defer cov.mu.Unlock()
+	docs := cov.engine.RouteDocs()
+	routes := make([]RouteCoverage, 0, len(docs))
+	for _, doc := range docs {
+		if cov.ignores(doc.Method, doc.Path) {
+			continue
+		}
+		routes = append(routes, RouteCoverage{
+			Method: doc.Method,
+			Host:   doc.Host,
+			Path:   doc.Path,
+			Hits:   cov.hits[coverageKey(doc.Method, doc.Host, doc.Path)],
+		})
+	}
+	return routes
+}
+
+// Unexercised returns the registered routes which are not ignored and served no
+// request, in registration order.
+func (cov *Coverage) Unexercised() []RouteCoverage {
+	var missed []RouteCoverage
+	for _, route := range cov.Routes() {
+		if route.Hits == 0 {
+			missed = append(missed, route)
+		}
+	}
+	return missed
+}
+
+// Report writes the ratio of the exercised routes and lists the unexercised
+// ones.
+func (cov *Coverage) Report(w io.Writer) error {
+	routes := cov.Routes()
+	var b strings.Builder
+	var missed []string
+	for _, route := range routes {
+		if route.Hits == 0 {
+			missed = append(missed, route.String())
+		}
+	}
+	exercised := len(routes) - len(missed)
+	percent := 100.0
+	if len(routes) > 0 {
+		percent = float64(exercised) * 100 / float64(len(routes))
+	}
+	fmt.Fprintf(&b, "route coverage: %d/%d (%.1f%%)\n", exercised, len(routes), percent)
+	for _, route := range missed {
+		fmt.Fprintf(&b, "  not exercised: %s\n", route)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Require fails t if a registered route which is not ignored served no
+// request, listing them.
+func (cov *Coverage) Require(t TestingT) {
+	t.Helper()
+	missed := cov.Unexercised()
+	if len(missed) == 0 {
+		return
+	}
+	lines := make([]string, len(missed))
+	for i, route := range missed {
+		lines[i] = "\n\t" + route.String()
+	}
+	t.Errorf("%d of %d routes not exercised:%s", len(missed), len(cov.Routes()), strings.Join(lines, ""))
+}
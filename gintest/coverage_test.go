@@ -0,0 +1,74 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gintest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gin "github.com/jialequ/mpgw"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeT struct {
+	errors []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func serve(router *gin.Engine, method, host, path string) {
+	req := httptest.NewRequest(method, path, nil)
+	req.Host = host
+	router.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestCoverageTracker(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/users/:id", ok)
+	router.POST("/users", ok)
+	router.GET("/healthz", ok)
+	router.Host("api.example.com").GET("/users/:id", ok)
+
+	cov := CoverageTracker(router).Ignore("", "/healthz")
+	serve(router, http.MethodGet, "example.com", "/users/1")
+	serve(router, http.MethodGet, "example.com", "/users/2")
+	serve(router, http.MethodGet, "example.com", "/missing")
+
+	assert.Equal(t, []RouteCoverage{
+		{Method: http.MethodGet, Path: "/users/:id", Hits: 2},
+		{Method: http.MethodPost, Path: "/users"},
+		{Method: http.MethodGet, Host: "api.example.com", Path: "/users/:id"},
+	}, cov.Routes())
+
+	var report strings.Builder
+	assert.NoError(t, cov.Report(&report))
+	assert.Equal(t, "route coverage: 1/3 (33.3%)\n"+
+		"  not exercised: POST /users\n"+
+		"  not exercised: GET api.example.com/users/:id\n", report.String())
+
+	ft := &fakeT{}
+	cov.Require(ft)
+	assert.Equal(t, []string{"2 of 3 routes not exercised:\n\tPOST /users\n\tGET api.example.com/users/:id"}, ft.errors)
+
+	serve(router, http.MethodPost, "example.com", "/users")
+	serve(router, http.MethodGet, "api.example.com", "/users/1")
+	assert.Empty(t, cov.Unexercised())
+	ft = &fakeT{}
+	cov.Require(ft)
+	assert.Empty(t, ft.errors)
+
+	cov.Stop()
+	serve(router, http.MethodPost, "example.com", "/users")
+	assert.Equal(t, 1, cov.Routes()[1].Hits)
+}
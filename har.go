@@ -0,0 +1,356 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/jialequ/mpgw/internal/json"
+)
+
+const (
+	harVersion         = "1.2"
+	harRedacted        = Redacted
+	defaultHARBodySize = 64 << 10 // 64 KB
+)
+
+var defaultHARRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// HARConfig defines the config for the HARRecorder middleware.
+type HARConfig struct {
+	// Sink receives every recorded HAR document. Required.
+	// See HARFileSink to write one .har file per recorded request.
+	Sink func(har *HAR) error
+
+	// SampleRate is the fraction of requests recorded, between 0 and 1.
+	// Optional. Default value is 1 (every request).
+	SampleRate float64
+
+	// Skip indicates which requests should not be recorded. Optional.
+	Skip Skipper
+
+	// MaxBodySize is the maximum number of request and response body bytes kept
+	// in a HAR entry. Bodies are truncated beyond it.
+	// Optional. Default value is 64 KB.
+	MaxBodySize int
+
+	// RedactHeaders are request and response headers whose values are replaced,
+	// in addition to the ones registered in Redactions.
+	// Optional. Default value is Authorization, Proxy-Authorization, Cookie and Set-Cookie.
+	RedactHeaders []string
+
+	// RedactQuery are query parameters whose values are replaced, in addition
+	// to the ones registered in Redactions. Optional.
+	RedactQuery []string
+}
+
+// HAR is an HTTP Archive document, see http://www.softwareishard.com/blog/har-12-spec/.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog is the root object of a HAR document.
+type HARLog struct {
+	Version string      `json:"version"`
+	Creator HARCreator  `json:"creator"`
+	Entries []*HAREntry `json:"entries"`
+}
+
+// HARCreator describes the application which produced the HAR document.
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry is one recorded request/response pair.
+type HAREntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+}
+
+// HARRequest is the request part of a HAR entry.
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARResponse is the response part of a HAR entry.
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARNameValue is a name/value pair such as a header or a query parameter.
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData is the request body of a HAR entry.
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARContent is the response body of a HAR entry.
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARTimings holds the timings of a HAR entry, in milliseconds.
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// harWriter tees the response body into a bounded buffer.
+type harWriter struct {
+	ResponseWriter
+	body  bytes.Buffer
+	limit int
+}
+
+func (w *harWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *harWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *harWriter) capture(data []byte) {
+	if room := w.limit - w.body.Len(); room > 0 {
+		if len(data) > room {
+			data = data[:room]
+		}
+		w.body.Write(data)
+	}
+}
+
+// HARFileSink returns a HARConfig.Sink writing each HAR document into its own
+// file in dir, which is created if needed.
+func HARFileSink(dir string) func(har *HAR) error {
+	var seq uint64
+	return func(har *HAR) error {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(har, "", "  ")
+		if err != nil {
+			return err
+		}
+		name := fmt.Sprintf("%s-%06d.har", time.Now().Format("20060102T150405"), atomic.AddUint64(&seq, 1))
+		return os.WriteFile(filepath.Join(dir, name), data, 0o600)
+	}
+}
+
+// HARRecorder returns a middleware which records sampled request/response pairs
+// as HAR documents, for sharing debugging sessions with frontend teams.
+// The request body it reads is stored under BodyBytesKey, so handlers using
+// Context.ShouldBindBodyWith do not read it twice.
+func HARRecorder(conf HARConfig) HandlerFunc {
+	assert1(conf.Sink != nil, "HARConfig.Sink is required")
+	if conf.SampleRate <= 0 {
+		conf.SampleRate = 1
+	}
+	if conf.MaxBodySize <= 0 {
+		conf.MaxBodySize = defaultHARBodySize
+	}
+	if conf.RedactHeaders == nil {
+		conf.RedactHeaders = defaultHARRedactHeaders
+	}
+	redactHeaders := make(map[string]struct{}, len(conf.RedactHeaders))
+	for _, h := range conf.RedactHeaders {
+		redactHeaders[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+	redactQuery := make(map[string]struct{}, len(conf.RedactQuery))
+	for _, q := range conf.RedactQuery {
+		redactQuery[q] = struct{}{}
+	}
+
+	return func(c *Context) {
+		if (conf.Skip != nil && conf.Skip(c)) || (conf.SampleRate < 1 && rand.Float64() >= conf.SampleRate) { //nolint: gosec
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		reqBody := harReadBody(c, conf.MaxBodySize)
+
+		w := &harWriter{ResponseWriter: c.Writer, limit: conf.MaxBodySize}
+		c.Writer = w
+		defer func() { c.Writer = w.ResponseWriter }()
+
+		c.Next()
+
+		elapsed := float64(time.Since(start)) / float64(time.Millisecond)
+		entry := &HAREntry{
+			StartedDateTime: start,
+			Time:            elapsed,
+			Request:         harRequest(c.Request, reqBody, redactHeaders, redactQuery),
+			Response:        harResponse(w, redactHeaders),
+			Timings:         HARTimings{Wait: elapsed},
+		}
+		har := &HAR{Log: HARLog{
+			Version: harVersion,
+			Creator: HARCreator{Name: "gin", Version: Version},
+			Entries: []*HAREntry{entry},
+		}}
+		if err := conf.Sink(har); err != nil {
+			debugPrint("cannot record HAR entry: %v", err)
+		}
+	}
+}
+
+// harReadBody reads up to limit bytes of the request body and restores it so
+// it can be consumed again by the handlers.
+func harReadBody(c *Context, limit int) []byte {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return nil
+	}
+	if cached, ok := c.Get(BodyBytesKey); ok {
+		if body, ok := cached.([]byte); ok {
+			return body
+		}
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, int64(limit)+1))
+	if err != nil {
+		debugPrint("cannot read request body for HAR entry: %v", err)
+	}
+	if len(body) <= limit && err == nil {
+		c.Set(BodyBytesKey, body)
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		return body
+	}
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), c.Request.Body), c.Request.Body}
+	if len(body) > limit {
+		body = body[:limit]
+	}
+	return body
+}
+
+func harRequest(req *http.Request, body []byte, redactHeaders, redactQuery map[string]struct{}) HARRequest {
+	u := *req.URL
+	query := u.Query()
+	for key := range query {
+		if _, ok := redactQuery[key]; ok || Redactions.Query(key) {
+			query[key] = []string{harRedacted}
+		}
+	}
+	u.RawQuery = query.Encode()
+	if u.Host == "" {
+		u.Host = req.Host
+	}
+	if u.Scheme == "" {
+		u.Scheme = "http"
+		if req.TLS != nil {
+			u.Scheme = "https"
+		}
+	}
+
+	r := HARRequest{
+		Method:      req.Method,
+		URL:         Redactions.Redact(u.String()),
+		HTTPVersion: req.Proto,
+		Cookies:     []HARNameValue{},
+		Headers:     harHeaders(req.Header, redactHeaders),
+		QueryString: harValues(query),
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+	if _, redact := redactHeaders["Cookie"]; !redact && !Redactions.Header("Cookie") {
+		for _, cookie := range req.Cookies() {
+			r.Cookies = append(r.Cookies, HARNameValue{Name: cookie.Name, Value: cookie.Value})
+		}
+	}
+	if body != nil {
+		r.PostData = &HARPostData{MimeType: req.Header.Get("Content-Type"), Text: Redactions.Redact(string(body))}
+	}
+	return r
+}
+
+func harResponse(w *harWriter, redactHeaders map[string]struct{}) HARResponse {
+	header := w.Header()
+	size := w.Size()
+	if size < 0 {
+		size = 0
+	}
+	return HARResponse{
+		Status:      w.Status(),
+		StatusText:  http.StatusText(w.Status()),
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []HARNameValue{},
+		Headers:     harHeaders(header, redactHeaders),
+		Content: HARContent{
+			Size:     size,
+			MimeType: header.Get("Content-Type"),
+			Text:     Redactions.Redact(w.body.String()),
+		},
+		RedirectURL: header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    size,
+	}
+}
+
+func harHeaders(header http.Header, redact map[string]struct{}) []HARNameValue {
+	out := make([]HARNameValue, 0, len(header))
+	for name, values := range header {
+		_, redacted := redact[name]
+		redacted = redacted || Redactions.Header(name)
+		for _, value := range values {
+			if redacted {
+				value = harRedacted
+			} else {
+				value = Redactions.Redact(value)
+			}
+			out = append(out, HARNameValue{Name: name, Value: value})
+		}
+	}
+	return out
+}
+
+func harValues(values map[string][]string) []HARNameValue {
+	out := make([]HARNameValue, 0, len(values))
+	for name, vs := range values {
+		for _, v := range vs {
+			out = append(out, HARNameValue{Name: name, Value: Redactions.Redact(v)})
+		}
+	}
+	return out
+}
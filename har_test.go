@@ -0,0 +1,99 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jialequ/mpgw/internal/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func harHeader(values []HARNameValue, name string) string {
+	for _, v := range values {
+		if v.Name == name {
+			return v.Value
+		}
+	}
+	return ""
+}
+
+func TestHARRecorder(t *testing.T) {
+	var recorded []*HAR
+	router := New()
+	router.Use(HARRecorder(HARConfig{
+		Sink: func(har *HAR) error {
+			recorded = append(recorded, har)
+			return nil
+		},
+		MaxBodySize: 8,
+		RedactQuery: []string{"token"},
+	}))
+	router.POST("/users", func(c *Context) {
+		var body struct {
+			Name string `json:"name"`
+		}
+		require.NoError(t, c.ShouldBindBodyWithJSON(&body))
+		c.Header("Set-Cookie", "session=secret")
+		c.String(http.StatusCreated, "hello %s", body.Name)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/users?token=abc&page=1", strings.NewReader(`{"name":"gin"}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Content-Type", MIMEJSON)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, "hello gin", w.Body.String())
+	require.Len(t, recorded, 1)
+	log := recorded[0].Log
+	assert.Equal(t, "1.2", log.Version)
+	require.Len(t, log.Entries, 1)
+	entry := log.Entries[0]
+
+	assert.Equal(t, http.MethodPost, entry.Request.Method)
+	assert.Equal(t, "http://example.com/users?page=1&token=%5BREDACTED%5D", entry.Request.URL)
+	assert.Equal(t, harRedacted, harHeader(entry.Request.Headers, "Authorization"))
+	assert.Equal(t, harRedacted, harHeader(entry.Request.QueryString, "token"))
+	assert.Equal(t, `{"name":`, entry.Request.PostData.Text)
+	assert.Equal(t, MIMEJSON, entry.Request.PostData.MimeType)
+
+	assert.Equal(t, http.StatusCreated, entry.Response.Status)
+	assert.Equal(t, "Created", entry.Response.StatusText)
+	assert.Equal(t, harRedacted, harHeader(entry.Response.Headers, "Set-Cookie"))
+	assert.Equal(t, "hello gi", entry.Response.Content.Text)
+	assert.Equal(t, 9, entry.Response.Content.Size)
+}
+
+func TestHARRecorderSkipAndFileSink(t *testing.T) {
+	dir := t.TempDir()
+	router := New()
+	router.Use(HARRecorder(HARConfig{
+		Sink: HARFileSink(dir),
+		Skip: func(c *Context) bool { return c.Request.URL.Path == "/health" },
+	}))
+	router.GET("/health", func(c *Context) { c.Status(http.StatusOK) })
+	router.GET("/ping", func(c *Context) { c.String(http.StatusOK, "pong") })
+
+	PerformRequest(router, http.MethodGet, "/health")
+	PerformRequest(router, http.MethodGet, "/ping")
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	data, err := os.ReadFile(dir + "/" + files[0].Name())
+	require.NoError(t, err)
+	var har HAR
+	require.NoError(t, json.Unmarshal(data, &har))
+	assert.Equal(t, "pong", har.Log.Entries[0].Response.Content.Text)
+	assert.Nil(t, har.Log.Entries[0].Request.PostData)
+
+	assert.Panics(t, func() { HARRecorder(HARConfig{}) })
+}
@@ -0,0 +1,486 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxHardenedHeadSize = http.DefaultMaxHeaderBytes
+	maxChunkLineSize    = 4 << 10
+	hardenedReadSize    = 4 << 10
+)
+
+// HTTPHardening configures a strict parsing of HTTP/1.x requests, done on the raw
+// connection before requests reach net/http, which silently resolves some of the
+// ambiguities used for request smuggling. It is applied by Run, RunUnix, RunFd and
+// RunListener; custom servers can use Engine.HardenListener.
+type HTTPHardening struct {
+	// RejectAmbiguousFraming rejects requests with both Transfer-Encoding and
+	// Content-Length, with repeated framing headers, with whitespace before a
+	// header colon, or with bare LF line endings.
+	RejectAmbiguousFraming bool
+
+	// NormalizeLineFolding replaces obsolete line folding (a header line starting
+	// with a space or a tab) by a single space appended to the previous header.
+	NormalizeLineFolding bool
+
+	// MaxHeaderCount is the maximum number of header fields of a request.
+	// Optional. Default value is 0 (no limit).
+	MaxHeaderCount int
+}
+
+func (h HTTPHardening) enabled() bool {
+	return h.RejectAmbiguousFraming || h.NormalizeLineFolding || h.MaxHeaderCount > 0
+}
+
+// hardeningError is a request rejected by the hardened connection.
+type hardeningError struct {
+	status int
+	reason string
+}
+
+func (e *hardeningError) Error() string {
+	return "gin: rejected request: " + e.reason
+}
+
+// HardenListener wraps l so the requests read from its connections are checked
+// according to engine.HTTPHardening. It returns l unchanged when no option is set.
+// It must wrap plain text listeners, below any TLS layer.
+func (engine *Engine) HardenListener(l net.Listener) net.Listener {
+	if !engine.HTTPHardening.enabled() {
+		return l
+	}
+	return &hardenedListener{Listener: l, conf: engine.HTTPHardening}
+}
+
+type hardenedListener struct {
+	net.Listener
+	conf HTTPHardening
+}
+
+func (l *hardenedListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &hardenedConn{Conn: conn, framer: httpFramer{conf: l.conf}}, nil
+}
+
+type hardenedState int
+
+const (
+	stateHead hardenedState = iota
+	stateFixedBody
+	stateChunkSize
+	stateChunkData
+	stateChunkEnd
+	stateTrailer
+	// stateUpgrade holds the bytes following the head of a request asking to
+	// switch protocols, until it is hijacked or served without switching.
+	stateUpgrade
+	stateTunnel
+)
+
+// hardenedConn checks the requests read from the connection with an httpFramer.
+type hardenedConn struct {
+	net.Conn
+	framer httpFramer
+	buf    []byte
+	err    error
+}
+
+func (hc *hardenedConn) Read(p []byte) (int, error) {
+	f := &hc.framer
+	if perr := f.resume(); perr != nil && hc.fail(perr) {
+		return 0, perr
+	}
+	for len(f.out) == 0 {
+		if hc.err != nil {
+			return 0, hc.err
+		}
+		if f.state == stateTunnel && len(f.raw) == 0 {
+			return hc.Conn.Read(p)
+		}
+		if hc.buf == nil {
+			hc.buf = make([]byte, hardenedReadSize)
+		}
+		n, err := hc.Conn.Read(hc.buf)
+		if perr := f.feed(hc.buf[:n]); perr != nil {
+			if hc.fail(perr) {
+				return 0, perr
+			}
+			continue
+		}
+		if err != nil {
+			if isTimeout(err) {
+				// net/http interrupts reads with deadlines and keeps using the connection
+				f.interrupt()
+				if len(f.out) == 0 {
+					return 0, err
+				}
+				break
+			}
+			// let net/http see the incomplete data before the error
+			f.flush()
+			hc.err = err
+		}
+	}
+	return f.read(p), nil
+}
+
+// fail handles a request rejected by the framer, and reports whether the
+// connection was closed.
+func (hc *hardenedConn) fail(err error) bool {
+	hc.err = err
+	if !hc.framer.served {
+		hc.reject(err)
+		return true
+	}
+	// Responses to the previous requests may still be in flight: hand net/http
+	// an invalid request line, answered with 400 once they are written.
+	hc.framer.out = append(hc.framer.out, "REJECTED\r\n\r\n"...)
+	return false
+}
+
+// hijacked implements connHijackNotifier.
+func (hc *hardenedConn) hijacked() {
+	hc.framer.tunnel()
+}
+
+// isTimeout reports whether err is caused by a read deadline.
+func isTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// reject answers the rejected request and closes the connection.
+func (hc *hardenedConn) reject(err error) {
+	status := http.StatusBadRequest
+	var herr *hardeningError
+	if errors.As(err, &herr) {
+		status = herr.status
+	}
+	fmt.Fprintf(hc.Conn, "HTTP/1.1 %d %s\r\nContent-Type: text/plain; charset=utf-8\r\nConnection: close\r\n\r\n%d %s",
+		status, http.StatusText(status), status, http.StatusText(status))
+	hc.Conn.Close() //nolint: errcheck
+}
+
+// httpFramer follows the framing of the HTTP/1.x requests read from a connection,
+// so each request head is buffered, checked according to conf and possibly
+// rewritten before it is handed to net/http. Bodies are passed through as they
+// arrive.
+type httpFramer struct {
+	conf      HTTPHardening
+	state     hardenedState
+	remaining int64
+	raw       []byte
+	out       []byte
+	served    bool
+	// interrupted reports whether a read was interrupted by a deadline in
+	// stateUpgrade, see interrupt.
+	interrupted bool
+}
+
+// feed appends data read from the connection and processes it.
+func (f *httpFramer) feed(data []byte) error {
+	f.raw = append(f.raw, data...)
+	if err := f.process(); err != nil {
+		f.raw = nil
+		return err
+	}
+	return nil
+}
+
+// flush moves the unprocessed bytes to out as is.
+func (f *httpFramer) flush() {
+	f.out = append(f.out, f.raw...)
+	f.raw = nil
+}
+
+// tunnel stops following the framing, once the connection is hijacked: the
+// unprocessed bytes and the next ones are passed through as is.
+func (f *httpFramer) tunnel() {
+	f.state = stateTunnel
+	f.flush()
+}
+
+// interrupt records a read interrupted by a deadline. net/http interrupts
+// the read it waits on while serving a request once the request is served or
+// hijacked: without hijack by then, the upgrade request was served without
+// switching protocols.
+func (f *httpFramer) interrupt() {
+	if f.state == stateUpgrade {
+		f.interrupted = true
+	}
+}
+
+// resume follows the framing again, processing the bytes held meanwhile, once
+// an upgrade request was served without switching protocols.
+func (f *httpFramer) resume() error {
+	if f.state != stateUpgrade || !f.interrupted {
+		return nil
+	}
+	f.state = stateHead
+	f.interrupted = false
+	return f.feed(nil)
+}
+
+// read copies processed bytes to p.
+func (f *httpFramer) read(p []byte) int {
+	n := copy(p, f.out)
+	f.out = f.out[n:]
+	return n
+}
+
+// process moves the bytes of raw which can be handed to net/http into out.
+func (f *httpFramer) process() error {
+	for len(f.raw) > 0 {
+		switch f.state {
+		case stateHead:
+			end := headEnd(f.raw)
+			if end < 0 {
+				if len(f.raw) > maxHardenedHeadSize {
+					return &hardeningError{http.StatusRequestHeaderFieldsTooLarge, "request head too large"}
+				}
+				return nil
+			}
+			head, err := f.inspect(f.raw[:end])
+			if err != nil {
+				return err
+			}
+			f.out = append(f.out, head...)
+			f.raw = f.raw[end:]
+			f.served = true
+		case stateFixedBody, stateChunkData:
+			n := int64(len(f.raw))
+			if n > f.remaining {
+				n = f.remaining
+			}
+			f.out = append(f.out, f.raw[:n]...)
+			f.raw = f.raw[n:]
+			f.remaining -= n
+			if f.remaining == 0 {
+				if f.state == stateChunkData {
+					f.state = stateChunkEnd
+				} else {
+					f.state = stateHead
+				}
+			}
+		case stateChunkSize, stateChunkEnd, stateTrailer:
+			i := bytes.IndexByte(f.raw, '\n')
+			if i < 0 {
+				if len(f.raw) > maxChunkLineSize {
+					return &hardeningError{http.StatusBadRequest, "chunk line too long"}
+				}
+				return nil
+			}
+			line := f.raw[:i+1]
+			f.out = append(f.out, line...)
+			f.raw = f.raw[i+1:]
+			f.nextChunkState(bytes.TrimRight(line, "\r\n"))
+		case stateUpgrade:
+			// well-behaved clients wait for the protocol switch
+			if len(f.raw) > maxHardenedHeadSize {
+				return &hardeningError{http.StatusBadRequest, "data sent before the protocol switch"}
+			}
+			return nil
+		case stateTunnel:
+			f.out = append(f.out, f.raw...)
+			f.raw = nil
+		}
+	}
+	return nil
+}
+
+func (f *httpFramer) nextChunkState(line []byte) {
+	switch f.state {
+	case stateChunkSize:
+		if i := bytes.IndexByte(line, ';'); i >= 0 {
+			line = line[:i]
+		}
+		size, err := strconv.ParseInt(string(bytes.TrimSpace(line)), 16, 64)
+		switch {
+		case err != nil || size < 0:
+			// net/http rejects the body, stop following the framing
+			f.state = stateTunnel
+		case size == 0:
+			f.state = stateTrailer
+		default:
+			f.remaining = size
+			f.state = stateChunkData
+		}
+	case stateChunkEnd:
+		f.state = stateChunkSize
+	case stateTrailer:
+		if len(line) == 0 {
+			f.state = stateHead
+		}
+	}
+}
+
+// headEnd returns the length of the request head at the start of b, including
+// the blank line which ends it, or -1 if it is incomplete.
+func headEnd(b []byte) int {
+	start := 0
+	for start < len(b) && (b[start] == '\r' || b[start] == '\n') {
+		start++
+	}
+	for i := start; i < len(b); i++ {
+		if b[i] != '\n' {
+			continue
+		}
+		if i+1 < len(b) && b[i+1] == '\n' {
+			return i + 2
+		}
+		if i+2 < len(b) && b[i+1] == '\r' && b[i+2] == '\n' {
+			return i + 3
+		}
+	}
+	return -1
+}
+
+// headLine is a line of a request head, without its line terminator.
+type headLine struct {
+	text []byte
+	term string
+}
+
+// inspect checks a complete request head, sets the framing state of the body
+// which follows it and returns the head to hand to net/http.
+func (f *httpFramer) inspect(head []byte) ([]byte, error) {
+	var lines []headLine
+	for len(head) > 0 {
+		i := bytes.IndexByte(head, '\n')
+		line := headLine{text: head[:i], term: "\n"}
+		if i > 0 && head[i-1] == '\r' {
+			line = headLine{text: head[:i-1], term: "\r\n"}
+		}
+		if f.conf.RejectAmbiguousFraming && line.term == "\n" {
+			return nil, &hardeningError{http.StatusBadRequest, "bare LF line ending"}
+		}
+		lines = append(lines, line)
+		head = head[i+1:]
+	}
+
+	// skip the blank lines tolerated before the request line
+	first := 0
+	for first < len(lines) && len(lines[first].text) == 0 {
+		first++
+	}
+	if first == len(lines) {
+		return joinHeadLines(lines), nil
+	}
+	requestLine := bytes.Fields(lines[first].text)
+	if len(requestLine) == 3 && string(requestLine[2]) == "HTTP/2.0" {
+		// HTTP/2 connection preface (h2c with prior knowledge)
+		f.state = stateTunnel
+		return joinHeadLines(lines), nil
+	}
+	method := ""
+	http10 := false
+	if len(requestLine) == 3 {
+		method = string(requestLine[0])
+		http10 = string(requestLine[2]) == "HTTP/1.0"
+	}
+
+	// fold obsolete line folding into the previous header line
+	headers := make([]headLine, 0, len(lines))
+	folded := false
+	for _, line := range lines[first+1:] {
+		if len(line.text) > 0 && (line.text[0] == ' ' || line.text[0] == '\t') && len(headers) > 0 {
+			prev := &headers[len(headers)-1]
+			prev.text = append(append(bytes.Clone(prev.text), ' '), bytes.TrimSpace(line.text)...)
+			folded = true
+			continue
+		}
+		if len(line.text) > 0 {
+			headers = append(headers, line)
+		}
+	}
+	if f.conf.MaxHeaderCount > 0 && len(headers) > f.conf.MaxHeaderCount {
+		return nil, &hardeningError{http.StatusRequestHeaderFieldsTooLarge, "too many header fields"}
+	}
+
+	var contentLengths, transferEncodings []string
+	upgrade := method == http.MethodConnect
+	for _, h := range headers {
+		colon := bytes.IndexByte(h.text, ':')
+		if colon <= 0 {
+			continue
+		}
+		name := h.text[:colon]
+		if f.conf.RejectAmbiguousFraming && bytes.ContainsAny(name, " \t") {
+			return nil, &hardeningError{http.StatusBadRequest, "whitespace in header name"}
+		}
+		value := string(bytes.TrimSpace(h.text[colon+1:]))
+		switch http.CanonicalHeaderKey(string(name)) {
+		case "Content-Length":
+			contentLengths = append(contentLengths, value)
+		case "Transfer-Encoding":
+			transferEncodings = append(transferEncodings, value)
+		case "Upgrade":
+			upgrade = true
+		}
+	}
+	if f.conf.RejectAmbiguousFraming {
+		switch {
+		case len(transferEncodings) > 0 && len(contentLengths) > 0:
+			return nil, &hardeningError{http.StatusBadRequest, "both Transfer-Encoding and Content-Length"}
+		case len(transferEncodings) > 1:
+			return nil, &hardeningError{http.StatusBadRequest, "repeated Transfer-Encoding"}
+		case len(contentLengths) > 1:
+			return nil, &hardeningError{http.StatusBadRequest, "repeated Content-Length"}
+		}
+	}
+
+	switch {
+	case len(transferEncodings) > 0 && !http10:
+		f.state = stateTunnel
+		if len(transferEncodings) == 1 && strings.EqualFold(transferEncodings[0], "chunked") {
+			f.state = stateChunkSize
+		}
+	case len(contentLengths) > 0:
+		n, err := strconv.ParseInt(contentLengths[0], 10, 64)
+		switch {
+		case err != nil || n < 0:
+			// net/http rejects the request, stop following the framing
+			f.state = stateTunnel
+		case n > 0:
+			f.remaining = n
+			f.state = stateFixedBody
+		default:
+			f.state = stateHead
+		}
+	default:
+		f.state = stateHead
+	}
+	if upgrade && f.state == stateHead {
+		// the connection may be hijacked after this request
+		f.state = stateUpgrade
+	}
+
+	if !folded || !f.conf.NormalizeLineFolding {
+		return joinHeadLines(lines), nil
+	}
+	normalized := append(lines[:first+1:first+1], headers...)
+	return joinHeadLines(append(normalized, headLine{term: "\r\n"})), nil
+}
+
+func joinHeadLines(lines []headLine) []byte {
+	var b bytes.Buffer
+	for _, line := range lines {
+		b.Write(line.text)
+		b.WriteString(line.term)
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,169 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveHardened(t *testing.T, conf HTTPHardening) string {
+	router := New()
+	router.HTTPHardening = conf
+	router.POST("/echo", func(c *Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, "%s", body)
+	})
+	router.GET("/header", func(c *Context) {
+		c.String(http.StatusOK, c.GetHeader("X-Folded"))
+	})
+	router.GET("/upgrade", func(c *Context) {
+		conn, rw, err := c.Writer.Hijack()
+		require.NoError(t, err)
+		defer conn.Close()
+		io.WriteString(conn, "HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n") //nolint: errcheck
+		line, _ := rw.ReadString('\n')
+		io.WriteString(conn, "pong "+line) //nolint: errcheck
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go http.Serve(router.HardenListener(listener), router) //nolint: errcheck
+	return listener.Addr().String()
+}
+
+type rawResponse struct {
+	status int
+	body   string
+}
+
+// sendRaw writes raw to a new connection and reads n responses.
+func sendRaw(t *testing.T, addr, raw string, n int) []rawResponse {
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = io.WriteString(conn, raw)
+	require.NoError(t, err)
+
+	reader := bufio.NewReader(conn)
+	responses := make([]rawResponse, 0, n)
+	for i := 0; i < n; i++ {
+		resp, err := http.ReadResponse(reader, nil)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		responses = append(responses, rawResponse{status: resp.StatusCode, body: string(body)})
+	}
+	return responses
+}
+
+func TestHardenedConnKeepAlive(t *testing.T) {
+	addr := serveHardened(t, HTTPHardening{RejectAmbiguousFraming: true, MaxHeaderCount: 10})
+
+	responses := sendRaw(t, addr, "POST /echo HTTP/1.1\r\nHost: x\r\nContent-Length: 5\r\n\r\nhello"+
+		"POST /echo HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: chunked\r\n\r\n3;ext=1\r\nabc\r\n2\r\nde\r\n0\r\nX-Trailer: 1\r\n\r\n"+
+		"GET /header HTTP/1.1\r\nHost: x\r\nX-Folded: plain\r\n\r\n", 3)
+	assert.Equal(t, "hello", responses[0].body)
+	assert.Equal(t, "abcde", responses[1].body)
+	assert.Equal(t, "plain", responses[2].body)
+	for _, resp := range responses {
+		assert.Equal(t, http.StatusOK, resp.status)
+	}
+}
+
+func TestHardenedConnRejects(t *testing.T) {
+	addr := serveHardened(t, HTTPHardening{RejectAmbiguousFraming: true, MaxHeaderCount: 3})
+
+	tests := map[string]struct {
+		raw    string
+		status int
+	}{
+		"te and cl": {
+			"POST /echo HTTP/1.1\r\nHost: x\r\nContent-Length: 4\r\nTransfer-Encoding: chunked\r\n\r\n0\r\n\r\n",
+			http.StatusBadRequest,
+		},
+		"repeated cl": {
+			"POST /echo HTTP/1.1\r\nHost: x\r\nContent-Length: 1\r\nContent-Length: 1\r\n\r\na",
+			http.StatusBadRequest,
+		},
+		"space before colon": {
+			"POST /echo HTTP/1.1\r\nHost: x\r\nContent-Length : 1\r\n\r\na",
+			http.StatusBadRequest,
+		},
+		"bare lf": {
+			"GET /header HTTP/1.1\nHost: x\n\n",
+			http.StatusBadRequest,
+		},
+		"too many headers": {
+			"GET /header HTTP/1.1\r\nHost: x\r\nA: 1\r\nB: 2\r\nC: 3\r\n\r\n",
+			http.StatusRequestHeaderFieldsTooLarge,
+		},
+		"smuggled after valid request": {
+			"POST /echo HTTP/1.1\r\nHost: x\r\nContent-Length: 1\r\n\r\na" +
+				"POST /echo HTTP/1.1\r\nHost: x\r\nContent-Length: 4\r\nTransfer-Encoding: chunked\r\n\r\n0\r\n\r\n",
+			http.StatusBadRequest,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			n := 1
+			if name == "smuggled after valid request" {
+				n = 2
+			}
+			responses := sendRaw(t, addr, tt.raw, n)
+			assert.Equal(t, tt.status, responses[n-1].status)
+		})
+	}
+}
+
+func TestHardenedConnUpgrade(t *testing.T) {
+	addr := serveHardened(t, HTTPHardening{RejectAmbiguousFraming: true})
+
+	// the requests following an upgrade request served without switching
+	// protocols are still checked
+	responses := sendRaw(t, addr, "GET /header HTTP/1.1\r\nHost: x\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n"+
+		"POST /echo HTTP/1.1\r\nHost: x\r\nContent-Length: 4\r\nTransfer-Encoding: chunked\r\n\r\n0\r\n\r\n", 2)
+	assert.Equal(t, http.StatusOK, responses[0].status)
+	assert.Equal(t, http.StatusBadRequest, responses[1].status)
+
+	responses = sendRaw(t, addr, "GET /header HTTP/1.1\r\nHost: x\r\nUpgrade: websocket\r\n\r\n"+
+		"GET /header HTTP/1.1\r\nHost: x\r\nX-Folded: next\r\n\r\n", 2)
+	assert.Equal(t, "next", responses[1].body)
+
+	// the bytes following a switch are passed through
+	conn := dialRaw(t, addr)
+	_, err := io.WriteString(conn, "GET /upgrade HTTP/1.1\r\nHost: x\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n"+
+		"ping\r\n\r\nContent-Length: 1\n")
+	require.NoError(t, err)
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	out, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "pong ping\r\n", string(out))
+}
+
+func TestHardenedConnLineFolding(t *testing.T) {
+	addr := serveHardened(t, HTTPHardening{NormalizeLineFolding: true})
+
+	responses := sendRaw(t, addr, "GET /header HTTP/1.1\r\nHost: x\r\nX-Folded: a\r\n \t b\r\n\r\n", 1)
+	assert.Equal(t, http.StatusOK, responses[0].status)
+	assert.Equal(t, "a b", responses[0].body)
+}
+
+func TestHardenListenerDisabled(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	assert.Same(t, listener, New().HardenListener(listener))
+}
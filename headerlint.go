@@ -0,0 +1,225 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// HeaderLintConfig defines the config for the HeaderLint middleware.
+type HeaderLintConfig struct {
+	// Rules are the headers the responses must carry. Optional: only the
+	// conflicting headers are reported without them.
+	Rules []HeaderRule
+
+	// Output is a writer where the violations are reported.
+	// Optional. Default value is gin.DefaultWriter.
+	Output io.Writer
+
+	// OnViolation is called instead of writing to Output for each violation.
+	// Optional.
+	OnViolation func(c *Context, v HeaderViolation)
+
+	// Skip indicates which requests are not linted. Optional.
+	Skip Skipper
+}
+
+// HeaderRule is a header required on the responses.
+type HeaderRule struct {
+	// Header is the name of the header.
+	Header string
+
+	// Methods are the request methods whose responses carry the header.
+	// Optional. Default value is all the methods.
+	Methods []string
+
+	// Echo is the request header the response header must repeat, such as
+	// X-Request-ID: the header is only required when the request has it.
+	// Optional.
+	Echo string
+}
+
+// HeaderViolation is a response breaking the header policy, with the handlers
+// of the chain responsible for it.
+type HeaderViolation struct {
+	Method string
+	Path   string
+	Header string
+	// Problem describes the violation.
+	Problem string
+	// Handlers are the handlers involved: the one writing the response for a
+	// missing header, the ones setting the values of a conflicting header.
+	Handlers []string
+}
+
+// String formats the violation as written to HeaderLintConfig.Output.
+func (v HeaderViolation) String() string {
+	return fmt.Sprintf("[GIN-debug] [WARNING] %s %s: %s %s (%s)\n",
+		v.Method, v.Path, v.Header, v.Problem, strings.Join(v.Handlers, ", "))
+}
+
+// headerLint attributes the changes of the response headers to the handlers
+// of a chain, by comparing them before and after each handler runs.
+type headerLint struct {
+	seen http.Header
+	// setBy is the index of the handler which last set each header, -1 for
+	// the engine.
+	setBy map[string]int
+	// running are the indices of the handlers running, nested through Next.
+	running []int
+	// wroteBy is the index of the handler which wrote the response, -1 until
+	// it is written.
+	wroteBy   int
+	conflicts []HeaderViolation
+}
+
+func (l *headerLint) run(c *Context) {
+	i := int(c.index)
+	l.observe(c, l.current())
+	l.running = append(l.running, i)
+	if c.watchdog != nil {
+		c.watchdog.run(c)
+	} else {
+		c.handlers[i](c)
+	}
+	l.observe(c, i)
+	l.running = l.running[:len(l.running)-1]
+}
+
+// current returns the index of the innermost running handler, -1 for none.
+func (l *headerLint) current() int {
+	if len(l.running) == 0 {
+		return -1
+	}
+	return l.running[len(l.running)-1]
+}
+
+// observe attributes the changes of the headers since the last observation
+// to the handler by, until the response is written. A header replaced by
+// another handler than the one which set it is a conflict, unlike the values
+// added to it.
+func (l *headerLint) observe(c *Context, by int) {
+	if l.wroteBy >= 0 {
+		return
+	}
+	header := c.Writer.Header()
+	for name, values := range header {
+		old := l.seen[name]
+		if slices.Equal(old, values) {
+			continue
+		}
+		if setBy, ok := l.setBy[name]; ok && setBy != by && len(old) > 0 &&
+			(len(values) < len(old) || !slices.Equal(old, values[:len(old)])) {
+			l.conflicts = append(l.conflicts, HeaderViolation{
+				Header:   name,
+				Problem:  fmt.Sprintf("set to %q is overwritten with %q", strings.Join(old, ", "), strings.Join(values, ", ")),
+				Handlers: []string{handlerLabel(c, setBy), handlerLabel(c, by)},
+			})
+		}
+		l.setBy[name] = by
+	}
+	l.seen = header.Clone()
+	if c.Writer.Written() {
+		l.wroteBy = by
+	}
+}
+
+// handlerLabel names the handler at index i of the chain, -1 for the engine.
+func handlerLabel(c *Context, i int) string {
+	if i < 0 {
+		return "gin"
+	}
+	return nameOfFunction(c.handlers[i])
+}
+
+// HeaderLint returns a middleware which, in debug mode, reports the responses
+// missing the headers required by the rules, such as Cache-Control on GET or
+// the echo of X-Request-ID, and the headers set by a handler and overwritten
+// by another, naming the handlers of the chain responsible. It catches the
+// drift of the header policy early, and does nothing outside of debug mode.
+// It should be registered first:
+//
+//	router.Use(gin.HeaderLint(gin.HeaderLintConfig{
+//	    Rules: []gin.HeaderRule{
+//	        {Header: "Cache-Control", Methods: []string{http.MethodGet}},
+//	        {Header: "X-Request-ID", Echo: "X-Request-ID"},
+//	    },
+//	}))
+func HeaderLint(conf HeaderLintConfig) HandlerFunc {
+	if conf.Output == nil {
+		conf.Output = DefaultWriter
+	}
+	onViolation := conf.OnViolation
+	if onViolation == nil {
+		onViolation = func(_ *Context, v HeaderViolation) {
+			fmt.Fprint(conf.Output, v.String())
+		}
+	}
+
+	return func(c *Context) {
+		if !IsDebugging() || c.headerLint != nil || (conf.Skip != nil && conf.Skip(c)) {
+			c.Next()
+			return
+		}
+
+		l := &headerLint{
+			seen:    c.Writer.Header().Clone(),
+			setBy:   make(map[string]int),
+			wroteBy: -1,
+		}
+		for name := range l.seen {
+			l.setBy[name] = -1
+		}
+		c.headerLint = l
+		c.Next()
+		l.observe(c, l.current())
+		c.headerLint = nil
+
+		path := c.FullPath()
+		if path == "" {
+			path = c.Request.URL.Path
+		}
+		report := func(v HeaderViolation) {
+			v.Method, v.Path = c.Request.Method, path
+			onViolation(c, v)
+		}
+		for _, v := range l.conflicts {
+			report(v)
+		}
+		writer := l.wroteBy
+		if writer < 0 {
+			writer = len(c.handlers) - 1
+		}
+		header := c.Writer.Header()
+		for _, rule := range conf.Rules {
+			if len(rule.Methods) > 0 && !slices.Contains(rule.Methods, c.Request.Method) {
+				continue
+			}
+			value := header.Get(rule.Header)
+			var problem string
+			switch {
+			case rule.Echo != "":
+				want := c.requestHeader(rule.Echo)
+				if want == "" || value == want {
+					continue
+				}
+				problem = fmt.Sprintf("is %q, not the %q of the request %s header", value, want, rule.Echo)
+			case value == "":
+				problem = "is missing"
+			default:
+				continue
+			}
+			report(HeaderViolation{
+				Header:   http.CanonicalHeaderKey(rule.Header),
+				Problem:  problem,
+				Handlers: []string{handlerLabel(c, writer)},
+			})
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func headerLintCacheMiddleware(c *Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Vary", "Accept-Encoding")
+	c.Next()
+}
+
+func headerLintHandler(c *Context) {
+	c.Header("Cache-Control", "max-age=60")
+	c.Writer.Header().Add("Vary", "Origin")
+	c.String(http.StatusOK, "ok")
+}
+
+func headerLintBareHandler(c *Context) {
+	c.Header("X-Request-ID", "other")
+	c.Status(http.StatusNoContent)
+}
+
+func TestHeaderLint(t *testing.T) {
+	SetMode(DebugMode)
+	defer SetMode(TestMode)
+
+	var violations []HeaderViolation
+	router := New()
+	router.Use(HeaderLint(HeaderLintConfig{
+		Rules: []HeaderRule{
+			{Header: "cache-control", Methods: []string{http.MethodGet}},
+			{Header: "X-Request-ID", Echo: "X-Request-ID"},
+		},
+		OnViolation: func(_ *Context, v HeaderViolation) { violations = append(violations, v) },
+	}))
+	router.GET("/conflict", headerLintCacheMiddleware, headerLintHandler)
+	router.GET("/bare", headerLintBareHandler)
+	router.POST("/bare", headerLintBareHandler)
+
+	w := PerformRequest(router, http.MethodGet, "/conflict")
+	assert.Equal(t, "max-age=60", w.Header().Get("Cache-Control"))
+	require.Len(t, violations, 1)
+	v := violations[0]
+	assert.Equal(t, "/conflict", v.Path)
+	assert.Equal(t, "Cache-Control", v.Header)
+	assert.Equal(t, `set to "no-store" is overwritten with "max-age=60"`, v.Problem)
+	require.Len(t, v.Handlers, 2)
+	assert.Contains(t, v.Handlers[0], "headerLintCacheMiddleware")
+	assert.Contains(t, v.Handlers[1], "headerLintHandler")
+
+	violations = nil
+	PerformRequest(router, http.MethodGet, "/bare", header{"X-Request-ID", "abc"})
+	require.Len(t, violations, 2)
+	assert.Equal(t, "Cache-Control", violations[0].Header)
+	assert.Equal(t, "is missing", violations[0].Problem)
+	assert.Contains(t, violations[0].Handlers[0], "headerLintBareHandler")
+	assert.Equal(t, "X-Request-Id", violations[1].Header)
+	assert.Equal(t, `is "other", not the "abc" of the request X-Request-ID header`, violations[1].Problem)
+
+	violations = nil
+	PerformRequest(router, http.MethodPost, "/bare")
+	assert.Empty(t, violations)
+}
+
+func TestHeaderLintOutput(t *testing.T) {
+	var buf bytes.Buffer
+	router := New()
+	router.Use(HeaderLint(HeaderLintConfig{
+		Rules:  []HeaderRule{{Header: "Cache-Control"}},
+		Output: &buf,
+	}))
+	router.GET("/bare", headerLintBareHandler)
+
+	// it does nothing outside of debug mode
+	PerformRequest(router, http.MethodGet, "/bare")
+	assert.Empty(t, buf.String())
+
+	SetMode(DebugMode)
+	defer SetMode(TestMode)
+	PerformRequest(router, http.MethodGet, "/bare")
+	assert.Contains(t, buf.String(), "[GIN-debug] [WARNING] GET /bare: Cache-Control is missing (github.com/jialequ/mpgw.headerLintBareHandler)")
+}
@@ -0,0 +1,101 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// ErrResponseWritten is returned by Context.Hijack when the response was
+// already started.
+var ErrResponseWritten = errors.New("hijack: response already written")
+
+// connHijackNotifier is implemented by the connections of the listeners which
+// follow the HTTP framing, such as the ones of SlowlorisListener, to stop once
+// the connection is hijacked.
+type connHijackNotifier interface {
+	hijacked()
+}
+
+// notifyHijacked notifies the connections wrapped by conn which follow the
+// HTTP framing that it was hijacked.
+func notifyHijacked(conn net.Conn) {
+	for inner := conn; inner != nil; {
+		if n, ok := inner.(connHijackNotifier); ok {
+			n.hijacked()
+		}
+		u, ok := inner.(interface{ NetConn() net.Conn })
+		if !ok {
+			break
+		}
+		inner = u.NetConn()
+	}
+}
+
+// Hijack takes over the connection of the request, for a raw protocol over
+// TCP, and returns it. The data already read from the connection, such as
+// pipelined bytes following the request head, are read first from the returned
+// connection, and the caller is responsible for closing it.
+//
+// Unlike the hijacking through c.Writer, it keeps the built-in middlewares
+// consistent: the context is marked as hijacked so Recovery, the response
+// transforms and the not found or not allowed responses no longer write to the
+// connection. With both, the hardened or Slowloris connections stop following
+// the framing and enforcing the deadlines of the requests. Connections
+// followed with TrackConnection should pass the returned connection to
+// TrackedConn.SetCloser.
+//
+// It returns http.ErrHijacked when the connection was already hijacked,
+// ErrResponseWritten when the response was started, and http.ErrNotSupported
+// when the connection cannot be hijacked, e.g. with HTTP/2.
+func (c *Context) Hijack() (net.Conn, error) {
+	if c.hijacked {
+		return nil, http.ErrHijacked
+	}
+	if c.Writer.Written() {
+		return nil, ErrResponseWritten
+	}
+	if _, ok := c.writermem.ResponseWriter.(http.Hijacker); !ok {
+		return nil, http.ErrNotSupported
+	}
+	conn, rw, err := c.Writer.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	c.hijacked = true
+	if err := rw.Flush(); err != nil {
+		conn.Close() //nolint: errcheck
+		return nil, err
+	}
+	if rw.Reader.Buffered() == 0 {
+		return conn, nil
+	}
+	return &hijackedConn{Conn: conn, r: rw.Reader}, nil
+}
+
+// IsHijacked reports whether the connection was taken over with Hijack.
+func (c *Context) IsHijacked() bool {
+	return c.hijacked
+}
+
+// hijackedConn is a hijacked connection whose reads first drain the data
+// buffered by net/http.
+type hijackedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (hc *hijackedConn) Read(p []byte) (int, error) {
+	if hc.r != nil {
+		if hc.r.Buffered() > 0 {
+			return hc.r.Read(p)
+		}
+		hc.r = nil
+	}
+	return hc.Conn.Read(p)
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hijackRouter(t *testing.T) *Engine {
+	router := New()
+	router.Use(RecoveryWithWriter(io.Discard), TransformResponse(ResponseTransform{ErrorProblems: true}))
+	router.GET("/raw", func(c *Context) {
+		conn, err := c.Hijack()
+		require.NoError(t, err)
+		assert.True(t, c.IsHijacked())
+		_, err = c.Hijack()
+		assert.ErrorIs(t, err, http.ErrHijacked)
+
+		io.WriteString(conn, "hello\n") //nolint: errcheck
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		assert.NoError(t, err)
+		io.WriteString(conn, "pong "+line) //nolint: errcheck
+		conn.Close()
+		panic("after hijack")
+	})
+	return router
+}
+
+func dialRaw(t *testing.T, addr string) net.Conn {
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(2 * time.Second)) //nolint: errcheck
+	return conn
+}
+
+func TestContextHijack(t *testing.T) {
+	server := httptest.NewServer(hijackRouter(t))
+	defer server.Close()
+
+	// the pipelined bytes buffered by net/http are read from the connection
+	conn := dialRaw(t, server.Listener.Addr().String())
+	_, err := io.WriteString(conn, "GET /raw HTTP/1.1\r\nHost: x\r\n\r\nping\n")
+	require.NoError(t, err)
+	out, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	assert.Equal(t, "hello\npong ping\n", string(out))
+}
+
+func TestContextHijackSlowloris(t *testing.T) {
+	events := make(chan SlowlorisEvent, 1)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	sl := NewSlowlorisListener(listener, SlowlorisConfig{
+		HeaderTimeout: 50 * time.Millisecond,
+		OnAbuse:       func(event SlowlorisEvent) { events <- event },
+	})
+	defer sl.Close()
+	go http.Serve(sl, hijackRouter(t)) //nolint: errcheck
+
+	// the raw bytes are neither held as a request head nor timed out
+	conn := dialRaw(t, sl.Addr().String())
+	_, err = io.WriteString(conn, "GET /raw HTTP/1.1\r\nHost: x\r\n\r\npi")
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	_, err = io.WriteString(conn, "ng\n")
+	require.NoError(t, err)
+	out, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	assert.Equal(t, "hello\npong ping\n", string(out))
+	assert.Empty(t, events)
+}
+
+func TestContextHijackErrors(t *testing.T) {
+	c, _ := CreateTestContext(httptest.NewRecorder())
+	_, err := c.Hijack()
+	assert.ErrorIs(t, err, http.ErrNotSupported)
+	assert.False(t, c.IsHijacked())
+
+	c.String(http.StatusOK, "written")
+	_, err = c.Hijack()
+	assert.ErrorIs(t, err, ErrResponseWritten)
+}
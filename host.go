@@ -0,0 +1,152 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net"
+	"sort"
+	"strings"
+)
+
+// hostTrees are the method trees of the routes of a host pattern.
+type hostTrees struct {
+	pattern string
+	// suffix is the suffix matched by a wildcard pattern, such as ".example.com".
+	suffix string
+	// param is the name of the param of a catch-all pattern, such as "host".
+	param string
+	trees methodTrees
+}
+
+// Host returns a router group whose routes only match the requests for the
+// given host, without port. A pattern starting with "*." matches any
+// subdomain, at any depth: "*.example.com" matches "api.example.com" and
+// "v1.api.example.com", not "example.com". Exact hosts are matched first, then
+// the longest wildcard patterns, and the requests matching no host route
+// fall back to the routes registered without host:
+//
+//	api := router.Host("api.example.com")
+//	api.GET("/users", listUsers)
+//	tenants := router.Host("*.example.com", tenant)
+//
+// The catch-all pattern "*name" matches any host, for the requests matching
+// no other route, before NoRoute: the host, without port, is the param name.
+// It receives the requests of unknown hosts and paths, such as webhooks:
+//
+//	router.Host("*host").Any("/*path", ingest)
+func (engine *Engine) Host(pattern string, handlers ...HandlerFunc) *RouterGroup {
+	pattern = strings.TrimSuffix(pattern, ".")
+	if !isCatchAllHost(pattern) {
+		pattern = strings.ToLower(pattern)
+	}
+	wildcard := strings.HasPrefix(pattern, "*.")
+	assert1(pattern != "" && pattern != "*." && pattern != "*", "host pattern can not be empty")
+	assert1(strings.LastIndexByte(pattern, '*') <= 0 && (wildcard || isCatchAllHost(pattern) || !strings.Contains(pattern, "*")),
+		"only a leading '*.' wildcard is supported in host pattern '"+pattern+"'")
+	assert1(!strings.Contains(pattern, ":") || net.ParseIP(pattern) != nil, "host pattern '"+pattern+"' can not have a port")
+
+	return &RouterGroup{
+		Handlers: engine.combineHandlers(handlers),
+		basePath: "/",
+		engine:   engine,
+		host:     pattern,
+		parent:   &engine.RouterGroup,
+	}
+}
+
+// HostPattern returns the host pattern of the group, see Engine.Host. It is
+// empty for the groups of the default host.
+func (group *RouterGroup) HostPattern() string {
+	return group.host
+}
+
+// treesFor returns the method trees of a host pattern, created on first use.
+func (engine *Engine) treesFor(pattern string) *methodTrees {
+	if pattern == "" {
+		return &engine.trees
+	}
+	if ht, ok := engine.hosts[pattern]; ok {
+		return &ht.trees
+	}
+	catchAll := isCatchAllHost(pattern)
+	if catchAll && engine.catchAllHost != nil {
+		panic("host pattern '" + pattern + "' conflicts with the catch-all host pattern '" + engine.catchAllHost.pattern + "'")
+	}
+	if engine.hosts == nil {
+		engine.hosts = make(map[string]*hostTrees)
+	}
+	ht := &hostTrees{pattern: pattern}
+	engine.hosts[pattern] = ht
+	if catchAll {
+		ht.param = pattern[1:]
+		engine.catchAllHost = ht
+	} else if strings.HasPrefix(pattern, "*.") {
+		ht.suffix = pattern[1:]
+		engine.wildcardHosts = append(engine.wildcardHosts, ht)
+		sort.SliceStable(engine.wildcardHosts, func(i, j int) bool {
+			return len(engine.wildcardHosts[i].suffix) > len(engine.wildcardHosts[j].suffix)
+		})
+	}
+	return &ht.trees
+}
+
+// matchHost returns the trees of the host pattern matching the Host header of
+// a request, nil if none does.
+func (t *routeTable) matchHost(host string) *hostTrees {
+	if len(t.hosts) == 0 {
+		return nil
+	}
+	host = requestHostname(host)
+	if ht, ok := t.hosts[host]; ok && ht.suffix == "" && ht.param == "" {
+		return ht
+	}
+	for _, ht := range t.wildcardHosts {
+		if len(host) > len(ht.suffix) && strings.HasSuffix(host, ht.suffix) {
+			return ht
+		}
+	}
+	return nil
+}
+
+// isCatchAllHost reports whether the host pattern is a catch-all one, such as
+// "*host".
+func isCatchAllHost(pattern string) bool {
+	return len(pattern) > 1 && pattern[0] == '*' && !strings.ContainsAny(pattern[1:], ".*:")
+}
+
+// serveCatchAllHost serves a request matching no route with the routes of the
+// catch-all host pattern, and reports whether one matched.
+func (engine *Engine) serveCatchAllHost(c *Context, ht *hostTrees, httpMethod, rPath string, unescape bool) bool {
+	root := ht.trees.get(httpMethod)
+	if root == nil {
+		return false
+	}
+	*c.params = (*c.params)[:0]
+	*c.skippedNodes = (*c.skippedNodes)[:0]
+	value := root.getValue(rPath, c.params, c.skippedNodes, unescape)
+	if value.handlers == nil {
+		return false
+	}
+	c.Params = append(*c.params, Param{Key: ht.param, Value: requestHostname(c.Request.Host)})
+	c.routeHost = ht.pattern
+	engine.serveRoute(c, httpMethod, value)
+	return true
+}
+
+// requestHostname returns the lower case host of a Host header, without port.
+func requestHostname(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// routeKey is the key of a route in the indexes of the engine.
+func routeKey(host, method, path string) string {
+	if host == "" {
+		return method + " " + path
+	}
+	return host + " " + method + " " + path
+}
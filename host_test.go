@@ -0,0 +1,154 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func performHostRequest(r http.Handler, method, host, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Host = host
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestEngineHost(t *testing.T) {
+	router := New()
+	router.GET("/users/:id", func(c *Context) { c.String(http.StatusOK, "default "+c.Param("id")) })
+	router.GET("/about", func(c *Context) { c.String(http.StatusOK, "about") })
+
+	api := router.Host("API.example.com", func(c *Context) { c.Header("X-Host", "api") })
+	assert.Equal(t, "api.example.com", api.HostPattern())
+	api.Group("/v1").GET("/users/:id", func(c *Context) { c.String(http.StatusOK, "api "+c.Param("id")) })
+	api.GET("/users/:id", func(c *Context) { c.String(http.StatusOK, "api root "+c.Param("id")) })
+
+	tenants := router.Host("*.example.com")
+	tenants.GET("/users/:id", func(c *Context) { c.String(http.StatusOK, "tenant "+c.Param("id")) })
+	router.Host("*.eu.example.com").GET("/users/:id", func(c *Context) { c.String(http.StatusOK, "eu "+c.Param("id")) })
+
+	for _, tt := range []struct {
+		host, path, body string
+	}{
+		{"api.example.com", "/v1/users/1", "api 1"},
+		{"API.Example.com:8443", "/users/2", "api root 2"},
+		{"acme.example.com", "/users/3", "tenant 3"},
+		{"a.b.example.com", "/users/4", "tenant 4"},
+		{"acme.eu.example.com", "/users/5", "eu 5"},
+		{"example.com", "/users/6", "default 6"},
+		{"other.org", "/users/7", "default 7"},
+		// host routes fall back to the routes of any host
+		{"api.example.com", "/about", "about"},
+	} {
+		w := performHostRequest(router, http.MethodGet, tt.host, tt.path)
+		assert.Equal(t, http.StatusOK, w.Code, tt.host+tt.path)
+		assert.Equal(t, tt.body, w.Body.String(), tt.host+tt.path)
+	}
+	assert.Equal(t, "api", performHostRequest(router, http.MethodGet, "api.example.com", "/v1/users/1").Header().Get("X-Host"))
+	assert.Equal(t, http.StatusNotFound, performHostRequest(router, http.MethodGet, "other.org", "/v1/users/1").Code)
+
+	routes := router.Routes()
+	assert.Len(t, routes, 6)
+	assert.Equal(t, "*.eu.example.com", routes[2].Host)
+	assert.Equal(t, "api.example.com", routes[4].Host)
+	assert.Equal(t, "/v1/users/:id", routes[4].Path)
+	docs := router.RouteDocs()
+	assert.Equal(t, "api.example.com", docs[2].Host)
+	assert.NoError(t, router.Validate())
+}
+
+func TestEngineHostMethodNotAllowed(t *testing.T) {
+	router := New()
+	router.HandleMethodNotAllowed = true
+	router.GET("/items", handlerTest1)
+	router.Host("api.example.com").POST("/items", handlerTest1)
+	router.Host("api.example.com").PUT("/items", handlerTest1)
+
+	w := performHostRequest(router, http.MethodDelete, "api.example.com", "/items")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "POST, PUT, GET", w.Header().Get("Allow"))
+
+	w = performHostRequest(router, http.MethodDelete, "other.org", "/items")
+	assert.Equal(t, "GET", w.Header().Get("Allow"))
+}
+
+func TestEngineHostRouteConfig(t *testing.T) {
+	router := New()
+	router.GET("/slow", func(c *Context) { c.String(http.StatusOK, "%v", c.RouteConfig().Timeout) })
+	router.Host("api.example.com").GET("/slow", func(c *Context) {
+		c.String(http.StatusOK, "%v", c.RouteConfig().Timeout)
+	})
+	router.Host("api.example.com").Route("/slow", http.MethodGet).Override(RouteConfig{Timeout: time.Second})
+	router.Host("old.example.com").GET("/slow", handlerTest1)
+	router.Host("old.example.com").Route("/slow", http.MethodGet).Deprecated(time.Time{}, "")
+
+	assert.Equal(t, "1s", performHostRequest(router, http.MethodGet, "api.example.com", "/slow").Body.String())
+	assert.Equal(t, "0s", performHostRequest(router, http.MethodGet, "other.org", "/slow").Body.String())
+	assert.Equal(t, "true", performHostRequest(router, http.MethodGet, "old.example.com", "/slow").Header().Get("Deprecation"))
+	assert.Empty(t, performHostRequest(router, http.MethodGet, "other.org", "/slow").Header().Get("Deprecation"))
+}
+
+func TestEngineHostConflicts(t *testing.T) {
+	router := New()
+	router.GET("/users/:id", handlerTest1)
+	// the same path in another host is not a conflict
+	router.Host("api.example.com").GET("/users/:name", handlerTest1)
+	assert.Panics(t, func() { router.Host("api.example.com").GET("/users/:id", handlerTest1) })
+
+	assert.Panics(t, func() { router.Host("") })
+	assert.Panics(t, func() { router.Host("api.*.com") })
+	assert.Panics(t, func() { router.Host("**.example.com") })
+	assert.Panics(t, func() { router.Host("api.example.com:8080") })
+	assert.NotPanics(t, func() { router.Host("::1") })
+}
+
+func TestEngineCatchAllHost(t *testing.T) {
+	router := New()
+	router.HandleMethodNotAllowed = true
+	router.GET("/status", func(c *Context) { c.String(http.StatusOK, "status") })
+	router.Host("api.example.com").GET("/users/:id", func(c *Context) { c.String(http.StatusOK, "api "+c.Param("id")) })
+	hooks := router.Host("*tenantHost")
+	assert.Equal(t, "*tenantHost", hooks.HostPattern())
+	hooks.Any("/*path", func(c *Context) {
+		c.String(http.StatusAccepted, c.Param("tenantHost")+" "+c.Param("path"))
+	})
+
+	for _, tt := range []struct {
+		method, host, path string
+		code               int
+		body               string
+	}{
+		{http.MethodPost, "Acme.Example.com:8443", "/hooks/github", http.StatusAccepted, "acme.example.com /hooks/github"},
+		{http.MethodGet, "api.example.com", "/users/1", http.StatusOK, "api 1"},
+		{http.MethodGet, "api.example.com", "/orders", http.StatusAccepted, "api.example.com /orders"},
+		{http.MethodGet, "other.org", "/status", http.StatusOK, "status"},
+		// the catch-all host only replaces NoRoute
+		{http.MethodPost, "other.org", "/status", http.StatusMethodNotAllowed, "405 method not allowed"},
+	} {
+		w := performHostRequest(router, tt.method, tt.host, tt.path)
+		assert.Equal(t, tt.code, w.Code, tt.host+tt.path)
+		assert.Equal(t, tt.body, w.Body.String(), tt.host+tt.path)
+	}
+
+	assert.Len(t, router.Routes(), len(anyMethods)+2)
+
+	// the table is copied by the registrations once serving, and by Clone
+	router.GET("/health", handlerTest1)
+	clone := router.Clone()
+	for _, r := range []*Engine{router, clone} {
+		w := performHostRequest(r, http.MethodPost, "acme.example.com", "/hooks/github")
+		assert.Equal(t, "acme.example.com /hooks/github", w.Body.String())
+	}
+
+	assert.Panics(t, func() { router.Host("*other").GET("/", handlerTest1) })
+	assert.Panics(t, func() { router.Host("*") })
+	assert.Panics(t, func() { router.Host("*host:8080") })
+}
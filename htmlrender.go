@@ -0,0 +1,198 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sync"
+
+	"github.com/jialequ/mpgw/render"
+)
+
+const defaultHTMLBufferSize = 32 << 10
+
+// RenderErrorTrailer is the trailer marking the HTML responses truncated by
+// the failure of their template, see RenderError.
+const RenderErrorTrailer = "X-Render-Error"
+
+// flushMarker is the output of TemplateFlush, flushing the response in place.
+const flushMarker = "<!--gin:flush-->"
+
+var htmlBufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// TemplateFlush is the flush func of the HTML templates: {{flush}} in the HTML
+// text of a template rendered by Context.HTML sends the output rendered so
+// far, flushing the buffered writers and the compression middlewares, so that
+// the browsers display the top of the page while the rest renders. The
+// templates loaded by Engine.LoadHTMLGlob and Engine.LoadHTMLFiles have it
+// unless Engine.FuncMap defines a flush func; add it to the funcs of the
+// templates parsed otherwise:
+//
+//	template.New("").Funcs(template.FuncMap{"flush": gin.TemplateFlush})
+//
+// Rendered otherwise, it outputs an HTML comment.
+func TemplateFlush() template.HTML {
+	return flushMarker
+}
+
+// templateFuncs returns the funcs of the HTML templates, the FuncMap, flush
+// and cspNonce.
+func (engine *Engine) templateFuncs() template.FuncMap {
+	funcs := make(template.FuncMap, len(engine.FuncMap)+2)
+	funcs["flush"] = TemplateFlush
+	funcs["cspNonce"] = TemplateCSPNonce
+	for name, fn := range engine.FuncMap {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// RenderError is the error of an HTML template failing or panicking while
+// rendering the response of Context.HTML, attached to the context with the
+// type ErrorTypeRender. While its output is buffered, see
+// Engine.HTMLBufferSize, it is discarded and the response answered with 500
+// Internal Server Error. Once a part of it was sent, the response is truncated
+// with the trailer X-Render-Error: truncated.
+type RenderError struct {
+	// Template is the name of the template.
+	Template string
+	// Partial reports whether a part of the response was sent.
+	Partial bool
+	// Written is the number of bytes of the output of the template sent.
+	Written int64
+	Err     error
+}
+
+func (e *RenderError) Error() string {
+	if e.Partial {
+		return fmt.Sprintf("template %q failed after %d bytes: %v", e.Template, e.Written, e.Err)
+	}
+	return fmt.Sprintf("template %q failed: %v", e.Template, e.Err)
+}
+
+func (e *RenderError) Unwrap() error {
+	return e.Err
+}
+
+// renderHTML renders an HTML template, guarding against its failures.
+func (c *Context) renderHTML(r render.HTML) {
+	size := defaultHTMLBufferSize
+	if c.engine != nil && c.engine.HTMLBufferSize != 0 {
+		size = c.engine.HTMLBufferSize
+	}
+	w := &templateWriter{ResponseWriter: c.Writer, c: c, limit: size}
+	if size > 0 && !c.Writer.Written() {
+		w.buf = htmlBufferPool.Get().(*bytes.Buffer)
+		defer w.release()
+	}
+	err := w.render(r)
+	if err == nil {
+		err = w.commit()
+	}
+	if err == nil {
+		return
+	}
+
+	rerr := &RenderError{Template: r.Name, Partial: c.Writer.Written(), Written: w.written, Err: err}
+	if rerr.Partial {
+		c.Writer.Header().Set(http.TrailerPrefix+RenderErrorTrailer, "truncated")
+	} else {
+		c.Writer.Header().Del("Content-Type")
+		c.Status(http.StatusInternalServerError)
+	}
+	_ = c.Error(rerr).SetType(ErrorTypeRender)
+	c.Abort()
+}
+
+// templateWriter buffers the output of a template up to its limit, then
+// writes it through.
+type templateWriter struct {
+	ResponseWriter
+	c     *Context
+	buf   *bytes.Buffer
+	limit int
+	// written is the number of bytes of the output written through.
+	written int64
+}
+
+// render renders the template, returning its panics as errors.
+func (w *templateWriter) render(r render.HTML) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			if p == http.ErrAbortHandler { //nolint: errorlint
+				panic(p)
+			}
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return r.Render(w)
+}
+
+func (w *templateWriter) Write(data []byte) (int, error) {
+	if len(data) == 0 {
+		// not to send the headers before the template fails
+		return 0, nil
+	}
+	// the output of a pipeline is written at once
+	if len(data) == len(flushMarker) && string(data) == flushMarker {
+		w.Flush()
+		return len(data), nil
+	}
+	if len(data) == len(cspNonceMarker) && string(data) == cspNonceMarker {
+		if _, err := w.Write([]byte(w.c.CSPNonce())); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+	if w.buf != nil {
+		if w.buf.Len()+len(data) <= w.limit {
+			return w.buf.Write(data)
+		}
+		if err := w.commit(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.ResponseWriter.Write(data)
+	w.written += int64(n)
+	return n, err
+}
+
+func (w *templateWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush writes the buffered output through and flushes it.
+func (w *templateWriter) Flush() {
+	if w.commit() == nil {
+		w.ResponseWriter.Flush()
+	}
+}
+
+// commit writes the buffered output through, and stops buffering.
+func (w *templateWriter) commit() error {
+	if w.buf == nil {
+		return nil
+	}
+	var err error
+	if w.buf.Len() > 0 {
+		var n int
+		n, err = w.ResponseWriter.Write(w.buf.Bytes())
+		w.written += int64(n)
+	}
+	w.release()
+	return err
+}
+
+// release returns the buffer to the pool.
+func (w *templateWriter) release() {
+	if w.buf != nil {
+		w.buf.Reset()
+		htmlBufferPool.Put(w.buf)
+		w.buf = nil
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTemplateFailed = errors.New("boom")
+
+// htmlRouter returns a router rendering a page of the size query param,
+// failing after it if the fail param is set, and the errors of the requests.
+func htmlRouter(bufferSize int) (*Engine, *[]*Error) {
+	router := New()
+	router.HTMLBufferSize = bufferSize
+	router.SetHTMLTemplate(template.Must(template.New("page").Funcs(template.FuncMap{
+		"fail": func() (string, error) { return "", errTemplateFailed },
+	}).Parse(`{{.Text}}{{if .Fail}}{{fail}}{{end}}`)))
+	var errs []*Error
+	router.Use(func(c *Context) {
+		c.Next()
+		errs = append(errs, c.Errors...)
+	})
+	router.GET("/", func(c *Context) {
+		size, _ := strconv.Atoi(c.Query("size"))
+		c.HTML(http.StatusOK, "page", H{"Text": strings.Repeat("x", size), "Fail": c.Query("fail") != ""})
+	})
+	return router, &errs
+}
+
+func TestHTMLRenderErrorBuffered(t *testing.T) {
+	router, errs := htmlRouter(0)
+
+	w := PerformRequest(router, http.MethodGet, "/?size=100&fail=1")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Empty(t, w.Header().Get("Content-Type"))
+	require.Len(t, *errs, 1)
+	assert.True(t, (*errs)[0].IsType(ErrorTypeRender))
+	var rerr *RenderError
+	require.ErrorAs(t, (*errs)[0], &rerr)
+	assert.Equal(t, "page", rerr.Template)
+	assert.False(t, rerr.Partial)
+	assert.Zero(t, rerr.Written)
+	require.ErrorIs(t, rerr, errTemplateFailed)
+	assert.Contains(t, rerr.Error(), `template "page" failed: `)
+
+	// the central error handler answers it
+	router.MapErrors()
+	w = PerformRequest(router, http.MethodGet, "/?size=100&fail=1")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, MIMEProblemJSON, w.Header().Get("Content-Type"))
+
+	w = PerformRequest(router, http.MethodGet, "/?size=100000")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, strings.Repeat("x", 100000), w.Body.String())
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+}
+
+func TestHTMLRenderErrorPartial(t *testing.T) {
+	router, errs := htmlRouter(64)
+
+	w := PerformRequest(router, http.MethodGet, "/?size=100&fail=1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, strings.Repeat("x", 100), w.Body.String())
+	assert.Equal(t, "truncated", w.Header().Get(http.TrailerPrefix+RenderErrorTrailer))
+	require.Len(t, *errs, 1)
+	var rerr *RenderError
+	require.ErrorAs(t, (*errs)[0], &rerr)
+	assert.True(t, rerr.Partial)
+	assert.Equal(t, int64(100), rerr.Written)
+	assert.Contains(t, rerr.Error(), `template "page" failed after 100 bytes: `)
+
+	// within the buffer
+	*errs = nil
+	w = PerformRequest(router, http.MethodGet, "/?size=10&fail=1")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	// without buffer
+	router.HTMLBufferSize = -1
+	w = PerformRequest(router, http.MethodGet, "/?size=10&fail=1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "xxxxxxxxxx", w.Body.String())
+	w = PerformRequest(router, http.MethodGet, "/?fail=1")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+// panicWriter panics on the writes.
+type panicWriter struct {
+	ResponseWriter
+}
+
+func (panicWriter) Write([]byte) (int, error) {
+	panic("write failed")
+}
+
+func TestHTMLRenderPanic(t *testing.T) {
+	c, router := CreateTestContext(httptest.NewRecorder())
+	router.HTMLBufferSize = -1
+	router.SetHTMLTemplate(template.Must(template.New("page").Parse(`text`)))
+	c.Writer = panicWriter{c.Writer}
+	c.HTML(http.StatusOK, "page", nil)
+	require.Len(t, c.Errors, 1)
+	assert.Equal(t, `template "page" failed: panic: write failed`, c.Errors[0].Error())
+	assert.True(t, c.IsAborted())
+
+	// the aborted handlers are not recovered
+	c.Writer = abortWriter{c.Writer}
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		c.HTML(http.StatusOK, "page", nil)
+	})
+}
+
+// abortWriter aborts the handler on the writes.
+type abortWriter struct {
+	ResponseWriter
+}
+
+func (abortWriter) Write([]byte) (int, error) {
+	panic(http.ErrAbortHandler)
+}
+
+// flushRecorder records the body sent at each flush.
+type flushRecorder struct {
+	ResponseWriter
+	body    *bytes.Buffer
+	flushes []string
+}
+
+func (w *flushRecorder) Flush() {
+	w.ResponseWriter.Flush()
+	w.flushes = append(w.flushes, w.body.String())
+}
+
+// gzipWriter compresses the response, as the compression middlewares.
+type gzipWriter struct {
+	ResponseWriter
+	gz *gzip.Writer
+}
+
+func (w *gzipWriter) Write(data []byte) (int, error) {
+	return w.gz.Write(data)
+}
+
+func (w *gzipWriter) WriteString(s string) (int, error) {
+	return w.gz.Write([]byte(s))
+}
+
+func (w *gzipWriter) Flush() {
+	w.gz.Flush() //nolint: errcheck
+	w.ResponseWriter.Flush()
+}
+
+func TestHTMLRenderFlush(t *testing.T) {
+	page := filepath.Join(t.TempDir(), "page.tmpl")
+	require.NoError(t, os.WriteFile(page, []byte(`<head>{{.}}</head>{{flush}}<body>{{footer}}</body>`), 0o600))
+
+	for _, bufferSize := range []int{0, -1} {
+		router := New()
+		router.HTMLBufferSize = bufferSize
+		router.SetFuncMap(template.FuncMap{"footer": func() string { return "end" }})
+		router.LoadHTMLFiles(page)
+		var recorder *flushRecorder
+		router.GET("/", func(c *Context) {
+			recorder = &flushRecorder{ResponseWriter: c.Writer, body: c.Writer.(*responseWriter).ResponseWriter.(*httptest.ResponseRecorder).Body}
+			c.Writer = recorder
+			c.HTML(http.StatusOK, "page.tmpl", "top")
+		})
+		w := PerformRequest(router, http.MethodGet, "/")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "<head>top</head><body>end</body>", w.Body.String())
+		assert.Equal(t, []string{"<head>top</head>"}, recorder.flushes)
+		assert.True(t, w.Flushed)
+	}
+
+	// through a compression middleware
+	router := New()
+	router.SetHTMLTemplate(template.Must(template.New("page").Funcs(template.FuncMap{"flush": TemplateFlush}).
+		Parse(`<head>{{.}}</head>{{flush}}<body>{{.}}</body>`)))
+	var recorder *flushRecorder
+	router.GET("/", func(c *Context) {
+		recorder = &flushRecorder{ResponseWriter: c.Writer, body: c.Writer.(*responseWriter).ResponseWriter.(*httptest.ResponseRecorder).Body}
+		gz := gzip.NewWriter(recorder)
+		defer gz.Close()
+		c.Writer = &gzipWriter{ResponseWriter: recorder, gz: gz}
+		c.HTML(http.StatusOK, "page", "top")
+	})
+	w := PerformRequest(router, http.MethodGet, "/")
+	require.Len(t, recorder.flushes, 1)
+	gz, err := gzip.NewReader(strings.NewReader(recorder.flushes[0]))
+	require.NoError(t, err)
+	top := make([]byte, 64)
+	n, _ := io.ReadAtLeast(gz, top, len("<head>top</head>"))
+	assert.Equal(t, "<head>top</head>", string(top[:n]))
+	gz, err = gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Equal(t, "<head>top</head><body>top</body>", string(body))
+
+	// a flush func of the FuncMap wins
+	router = New()
+	router.SetFuncMap(template.FuncMap{"flush": func() string { return "custom" }, "footer": func() string { return "end" }})
+	router.LoadHTMLFiles(page)
+	router.GET("/", func(c *Context) {
+		c.HTML(http.StatusOK, "page.tmpl", "top")
+	})
+	assert.Equal(t, "<head>top</head>custom<body>end</body>", PerformRequest(router, http.MethodGet, "/").Body.String())
+}
+
+func TestTemplateFlushOutside(t *testing.T) {
+	var out strings.Builder
+	templ := template.Must(template.New("").Funcs(template.FuncMap{"flush": TemplateFlush}).Parse(`a{{flush}}b`))
+	require.NoError(t, templ.Execute(&out, nil))
+	assert.Equal(t, "a<!--gin:flush-->b", out.String())
+}
@@ -0,0 +1,172 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/jialequ/mpgw/internal/json"
+)
+
+// MIMEHALJSON is the media type of HAL documents, see
+// https://datatracker.ietf.org/doc/html/draft-kelly-json-hal.
+const MIMEHALJSON = "application/hal+json"
+
+// Link is a hypermedia link.
+type Link struct {
+	Href      string `json:"href"`
+	Templated bool   `json:"templated,omitempty"`
+	Title     string `json:"title,omitempty"`
+	Type      string `json:"type,omitempty"`
+}
+
+// Links is a builder of hypermedia links keyed by relation, rendered as HAL
+// _links or as a Link header. Links to named routes are built with
+// Engine.PathBuilder:
+//
+//	links := c.Links().
+//	    Route("self", "user", "id", id).
+//	    Route("posts", "user.posts", "id", id).
+//	    Add("help", "https://docs.example.com/users")
+//	c.JSONWithLinks(http.StatusOK, user, links)
+type Links struct {
+	engine *Engine
+	rels   []string
+	links  map[string][]Link
+	err    error
+}
+
+// Links returns an empty links builder resolving routes of the engine.
+func (c *Context) Links() *Links {
+	return &Links{engine: c.engine}
+}
+
+// Add adds a link with the given relation.
+func (l *Links) Add(rel, href string) *Links {
+	return l.AddLink(rel, Link{Href: href})
+}
+
+// AddLink adds a link with the given relation. Adding several links with the
+// same relation renders them as an array.
+func (l *Links) AddLink(rel string, link Link) *Links {
+	if l.links == nil {
+		l.links = make(map[string][]Link)
+	}
+	if _, ok := l.links[rel]; !ok {
+		l.rels = append(l.rels, rel)
+	}
+	l.links[rel] = append(l.links[rel], link)
+	return l
+}
+
+// Route adds a link to the route named name, with params given as key/value
+// pairs. Errors are reported by Err.
+func (l *Links) Route(rel, name string, params ...string) *Links {
+	if len(params)%2 != 0 {
+		l.setErr(errors.New("links: odd number of route params for " + name))
+		return l
+	}
+	builder := l.engine.PathBuilder(name)
+	for i := 0; i < len(params); i += 2 {
+		builder.Param(params[i], params[i+1])
+	}
+	path, err := builder.Build()
+	if err != nil {
+		l.setErr(err)
+		return l
+	}
+	return l.Add(rel, path)
+}
+
+func (l *Links) setErr(err error) {
+	if l.err == nil {
+		l.err = err
+	}
+}
+
+// Err returns the first error met while building the links.
+func (l *Links) Err() error {
+	return l.err
+}
+
+// HAL returns the links as a HAL _links object.
+func (l *Links) HAL() map[string]any {
+	hal := make(map[string]any, len(l.rels))
+	for _, rel := range l.rels {
+		if links := l.links[rel]; len(links) == 1 {
+			hal[rel] = links[0]
+		} else {
+			hal[rel] = links
+		}
+	}
+	return hal
+}
+
+// Header returns the links as the value of a Link header, see RFC 8288.
+func (l *Links) Header() string {
+	var sb strings.Builder
+	for _, rel := range l.rels {
+		for _, link := range l.links[rel] {
+			if sb.Len() > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString("<" + link.Href + `>; rel="` + rel + `"`)
+			if link.Title != "" {
+				sb.WriteString(`; title="` + strings.ReplaceAll(link.Title, `"`, `\"`) + `"`)
+			}
+			if link.Type != "" {
+				sb.WriteString(`; type="` + link.Type + `"`)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// LinkHeader adds the links to the Link header of the response.
+func (c *Context) LinkHeader(links *Links) {
+	if value := links.Header(); value != "" {
+		c.Writer.Header().Add("Link", value)
+	}
+}
+
+// JSONWithLinks serializes obj as JSON with hypermedia links. Clients accepting
+// application/hal+json get a HAL document, obj with a _links member; others get
+// obj as is, and the links in the Link header. obj must serialize to a JSON
+// object for HAL. Errors met while building the links abort the request with
+// status 500.
+func (c *Context) JSONWithLinks(code int, obj any, links *Links) {
+	if err := links.Err(); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err) //nolint: errcheck
+		return
+	}
+	if c.NegotiateFormat(MIMEJSON, MIMEHALJSON) != MIMEHALJSON {
+		c.LinkHeader(links)
+		c.JSON(code, obj)
+		return
+	}
+
+	body, err := json.Marshal(obj)
+	if err != nil {
+		panic(err)
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) < 2 || body[0] != '{' {
+		panic("gin: JSONWithLinks needs an object serialized as a JSON object for HAL")
+	}
+	halLinks, err := json.Marshal(map[string]any{"_links": links.HAL()})
+	if err != nil {
+		panic(err)
+	}
+	doc := make([]byte, 0, len(body)+len(halLinks))
+	doc = append(doc, halLinks[:len(halLinks)-1]...)
+	if len(bytes.TrimSpace(body[1:len(body)-1])) > 0 {
+		doc = append(doc, ',')
+	}
+	doc = append(doc, body[1:]...)
+	c.Data(code, MIMEHALJSON, doc)
+}
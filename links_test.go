@@ -0,0 +1,72 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func linksRouter() *Engine {
+	router := New()
+	router.GET("/users/:id", func(c *Context) {
+		links := c.Links().
+			Route("self", "user", "id", c.Param("id")).
+			Route("posts", "user.posts", "id", c.Param("id")).
+			AddLink("help", Link{Href: "https://example.com/help", Title: `say "hi"`, Type: MIMEHTML})
+		c.JSONWithLinks(http.StatusOK, H{"name": "gin"}, links)
+	})
+	router.Route("/users/:id", http.MethodGet).Name("user")
+	router.GET("/users/:id/posts", func(c *Context) {
+		links := c.Links().Add("item", "/posts/1").Add("item", "/posts/2")
+		c.JSONWithLinks(http.StatusOK, struct{}{}, links)
+	})
+	router.Route("/users/:id/posts", http.MethodGet).Name("user.posts")
+	router.GET("/broken", func(c *Context) {
+		c.JSONWithLinks(http.StatusOK, H{}, c.Links().Route("self", "nope").Route("other", "user", "id"))
+	})
+	return router
+}
+
+func TestJSONWithLinksHAL(t *testing.T) {
+	router := linksRouter()
+
+	w := PerformRequest(router, http.MethodGet, "/users/42", header{Key: "Accept", Value: MIMEHALJSON})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, MIMEHALJSON, w.Header().Get("Content-Type"))
+	assert.Empty(t, w.Header().Get("Link"))
+	assert.JSONEq(t, `{"name":"gin","_links":{
+		"self":{"href":"/users/42"},
+		"posts":{"href":"/users/42/posts"},
+		"help":{"href":"https://example.com/help","title":"say \"hi\"","type":"text/html"}}}`, w.Body.String())
+
+	w = PerformRequest(router, http.MethodGet, "/users/42/posts", header{Key: "Accept", Value: MIMEHALJSON})
+	assert.JSONEq(t, `{"_links":{"item":[{"href":"/posts/1"},{"href":"/posts/2"}]}}`, w.Body.String())
+}
+
+func TestJSONWithLinksHeader(t *testing.T) {
+	router := linksRouter()
+
+	w := PerformRequest(router, http.MethodGet, "/users/42")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"name":"gin"}`, w.Body.String())
+	assert.Equal(t, `</users/42>; rel="self", </users/42/posts>; rel="posts", `+
+		`<https://example.com/help>; rel="help"; title="say \"hi\""; type="text/html"`, w.Header().Get("Link"))
+}
+
+func TestJSONWithLinksErrors(t *testing.T) {
+	router := linksRouter()
+
+	w := PerformRequest(router, http.MethodGet, "/broken")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	c, _ := CreateTestContext(nil)
+	links := c.Links().Route("self", "user", "id")
+	assert.EqualError(t, links.Err(), "links: odd number of route params for user")
+	assert.Empty(t, links.Header())
+	assert.Empty(t, links.HAL())
+}
@@ -0,0 +1,75 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// badLogKey is the key of the log field values given without a key, as in
+// log/slog.
+const badLogKey = "!BADKEY"
+
+// LogFields adds fields to the access record of the request written by the
+// Logger middleware, so that the handlers and middlewares enrich a single log
+// line instead of writing their own. The arguments are alternating keys and
+// values, or slog.Attr values, as for slog.Logger.Info. A field replaces the
+// previous one with the same key:
+//
+//	c.LogFields("user", user.ID, "plan", user.Plan)
+//
+// The fields added to a copy of the context are not logged.
+func (c *Context) LogFields(kv ...any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(kv) > 0 {
+		var attr slog.Attr
+		switch key := kv[0].(type) {
+		case slog.Attr:
+			attr, kv = key, kv[1:]
+		case string:
+			if len(kv) == 1 {
+				attr, kv = slog.String(badLogKey, key), nil
+			} else {
+				attr, kv = slog.Any(key, kv[1]), kv[2:]
+			}
+		default:
+			attr, kv = slog.Any(badLogKey, key), kv[1:]
+		}
+		if i := slices.IndexFunc(c.logFields, func(f slog.Attr) bool { return f.Key == attr.Key }); i >= 0 {
+			c.logFields[i] = attr
+		} else {
+			c.logFields = append(c.logFields, attr)
+		}
+	}
+}
+
+// LoggedFields returns the fields added by LogFields.
+func (c *Context) LoggedFields() []slog.Attr {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return slices.Clone(c.logFields)
+}
+
+// formatLogFields formats the fields as space-separated key=value pairs, with
+// the secrets registered in Redactions redacted, and the values quoted if
+// needed.
+func formatLogFields(fields []slog.Attr) string {
+	var b strings.Builder
+	for _, f := range fields {
+		value := Redactions.Redact(f.Value.Resolve().String())
+		if value == "" || strings.ContainsAny(value, " =\"\t\n") {
+			value = strconv.Quote(value)
+		}
+		b.WriteByte(' ')
+		b.WriteString(f.Key)
+		b.WriteByte('=')
+		b.WriteString(value)
+	}
+	return b.String()
+}
@@ -7,6 +7,7 @@ package gin
 import (
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
 	"time"
@@ -73,9 +74,11 @@ type LogFormatterParams struct {
 	ClientIP string
 	// Method is the HTTP method given to the request.
 	Method string
-	// Path is a path the client requests.
+	// Path is a path the client requests, with the values registered in
+	// Redactions redacted.
 	Path string
-	// ErrorMessage is set if error has occurred in processing the request.
+	// ErrorMessage is set if error has occurred in processing the request,
+	// with the secrets registered in Redactions redacted.
 	ErrorMessage string
 	// isTerm shows whether gin's output descriptor refers to a terminal.
 	isTerm bool
@@ -83,6 +86,8 @@ type LogFormatterParams struct {
 	BodySize int
 	// Keys are the keys set on the request's context.
 	Keys map[string]any
+	// Fields are the fields added with Context.LogFields.
+	Fields []slog.Attr
 }
 
 // StatusCodeColor is the ANSI color for appropriately logging http status code to a terminal.
@@ -149,13 +154,14 @@ var defaultLogFormatter = func(param LogFormatterParams) string {
 	if param.Latency > time.Minute {
 		param.Latency = param.Latency.Truncate(time.Second)
 	}
-	return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+	return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v%s\n%s",
 		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
 		statusColor, param.StatusCode, resetColor,
 		param.Latency,
 		param.ClientIP,
 		methodColor, param.Method, resetColor,
 		param.Path,
+		formatLogFields(param.Fields),
 		param.ErrorMessage,
 	)
 }
@@ -241,7 +247,7 @@ func LoggerWithConfig(conf LoggerConfig) HandlerFunc {
 
 	return func(c *Context) {
 		// Start timer
-		start := time.Now()
+		start := c.now()
 		path := c.Request.URL.Path
 		raw := c.Request.URL.RawQuery
 
@@ -260,21 +266,22 @@ func LoggerWithConfig(conf LoggerConfig) HandlerFunc {
 		}
 
 		// Stop timer
-		param.TimeStamp = time.Now()
+		param.TimeStamp = c.now()
 		param.Latency = param.TimeStamp.Sub(start)
 
 		param.ClientIP = c.ClientIP()
 		param.Method = c.Request.Method
 		param.StatusCode = c.Writer.Status()
-		param.ErrorMessage = c.Errors.ByType(ErrorTypePrivate).String()
+		param.ErrorMessage = Redactions.Redact(c.Errors.ByType(ErrorTypePrivate).String())
 
 		param.BodySize = c.Writer.Size()
+		param.Fields = c.LoggedFields()
 
 		if raw != "" {
-			path = path + "?" + raw
+			path = path + "?" + Redactions.RedactQuery(raw)
 		}
 
-		param.Path = path
+		param.Path = Redactions.Redact(path)
 
 		fmt.Fprint(out, formatter(param))
 	}
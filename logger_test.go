@@ -7,6 +7,8 @@ package gin
 import (
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"strings"
 	"testing"
@@ -231,6 +233,35 @@ func TestLoggerWithConfigFormatting(t *testing.T) {
 	assert.Equal(t, gotKeys, gotParam.Keys)
 }
 
+func TestLoggerLogFields(t *testing.T) {
+	buffer := new(strings.Builder)
+	var fields []slog.Attr
+	router := New()
+	router.Use(LoggerWithConfig(LoggerConfig{Output: buffer}))
+	router.Use(LoggerWithConfig(LoggerConfig{
+		Output:    io.Discard,
+		Formatter: func(p LogFormatterParams) string { fields = p.Fields; return "" },
+	}))
+	router.Use(func(c *Context) {
+		c.LogFields("tenant", "acme")
+		c.Next()
+	})
+	router.GET("/orders", func(c *Context) {
+		c.LogFields("user", 42, slog.Bool("cached", true), "note", "two words", "tenant", "other", "odd")
+		c.Copy().LogFields("ignored", 1)
+	})
+	PerformRequest(router, http.MethodGet, "/orders")
+
+	assert.Equal(t, []slog.Attr{
+		slog.String("tenant", "other"),
+		slog.Int("user", 42),
+		slog.Bool("cached", true),
+		slog.String("note", "two words"),
+		slog.String("!BADKEY", "odd"),
+	}, fields)
+	assert.Contains(t, buffer.String(), `"/orders" tenant=other user=42 cached=true note="two words" !BADKEY=odd`+"\n")
+}
+
 func TestDefaultLogFormatter(t *testing.T) {
 	timeStamp := time.Unix(1544173902, 0).UTC()
 
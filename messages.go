@@ -0,0 +1,99 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import "reflect"
+
+// messageKey is the key of the messages of type T in a Context, distinct for
+// each T without reflection.
+type messageKey[T any] struct{}
+
+// messages are the typed messages of a request, see Emit.
+type messages struct {
+	values    map[any]any
+	listeners map[any][]any
+}
+
+// Emit publishes a typed message on the request, such as the authenticated
+// user, the tenant or the parsed API version, to the following handlers. The
+// message replaces the previous one of the same type, and is passed to the
+// listeners registered with On. Messages are keyed by their type, so packages
+// define their own types instead of sharing string keys:
+//
+//	type Tenant struct{ ID string }
+//
+//	func tenancy(c *gin.Context) {
+//	    gin.Emit(c, Tenant{ID: c.GetHeader("X-Tenant")})
+//	}
+//
+//	func handler(c *gin.Context) {
+//	    tenant, ok := gin.Message[Tenant](c)
+//	}
+func Emit[T any](c *Context, msg T) {
+	key := messageKey[T]{}
+	c.mu.Lock()
+	if c.messages.values == nil {
+		c.messages.values = make(map[any]any)
+	}
+	c.messages.values[key] = msg
+	listeners := c.messages.listeners[key]
+	c.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn.(func(T))(msg)
+	}
+}
+
+// Message returns the last message of type T emitted on the request, and
+// whether there is one.
+func Message[T any](c *Context) (msg T, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	v, ok := c.messages.values[messageKey[T]{}]
+	if ok {
+		msg = v.(T)
+	}
+	return msg, ok
+}
+
+// MustMessage returns the last message of type T emitted on the request, and
+// panics if there is none.
+func MustMessage[T any](c *Context) T {
+	msg, ok := Message[T](c)
+	if !ok {
+		panic("no message of type " + reflect.TypeOf((*T)(nil)).Elem().String() + " was emitted")
+	}
+	return msg
+}
+
+// On registers a listener called with the messages of type T emitted on the
+// request, by the goroutine calling Emit. If a message of type T was already
+// emitted, fn is first called with the last one.
+func On[T any](c *Context, fn func(T)) {
+	key := messageKey[T]{}
+	c.mu.Lock()
+	if c.messages.listeners == nil {
+		c.messages.listeners = make(map[any][]any)
+	}
+	c.messages.listeners[key] = append(c.messages.listeners[key], fn)
+	v, emitted := c.messages.values[key]
+	c.mu.Unlock()
+
+	if emitted {
+		fn(v.(T))
+	}
+}
+
+// copy returns a copy of the messages, without the listeners.
+func (m *messages) copy() messages {
+	if m.values == nil {
+		return messages{}
+	}
+	values := make(map[any]any, len(m.values))
+	for k, v := range m.values {
+		values[k] = v
+	}
+	return messages{values: values}
+}
@@ -0,0 +1,65 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testTenant struct{ ID string }
+
+type testAPIVersion int
+
+func TestMessages(t *testing.T) {
+	var seen []string
+	router := New()
+	router.Use(func(c *Context) {
+		if c.Request.URL.Path == "/empty" {
+			return
+		}
+		On(c, func(tenant testTenant) { seen = append(seen, "listener "+tenant.ID) })
+		Emit(c, testTenant{ID: "acme"})
+		Emit(c, testAPIVersion(2))
+	})
+	router.GET("/", func(c *Context) {
+		tenant, ok := Message[testTenant](c)
+		assert.True(t, ok)
+		assert.Equal(t, "acme", tenant.ID)
+		assert.Equal(t, testAPIVersion(2), MustMessage[testAPIVersion](c))
+
+		// listeners registered late get the last message first
+		On(c, func(tenant testTenant) { seen = append(seen, "late "+tenant.ID) })
+		Emit(c, testTenant{ID: "globex"})
+
+		_, ok = Message[*testTenant](c)
+		assert.False(t, ok)
+		assert.PanicsWithValue(t, "no message of type error was emitted", func() { MustMessage[error](c) })
+
+		cp := c.Copy()
+		Emit(c, testTenant{ID: "initech"})
+		assert.Equal(t, "globex", MustMessage[testTenant](cp).ID)
+	})
+	router.GET("/empty", func(c *Context) {
+		_, ok := Message[testTenant](c)
+		assert.False(t, ok)
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{
+		"listener acme",
+		"late acme",
+		"listener globex", "late globex",
+		"listener initech", "late initech",
+	}, seen)
+
+	// messages do not leak to the next requests of the pooled contexts
+	seen = nil
+	PerformRequest(router, http.MethodGet, "/empty")
+	assert.Empty(t, seen)
+}
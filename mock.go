@@ -0,0 +1,153 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+
+	"github.com/jialequ/mpgw/internal/json"
+)
+
+// MockHeader is the request header asking for the mock response of a route,
+// with the value "true", and the response header marking mock responses.
+const MockHeader = "X-Mock"
+
+// SandboxConfig defines when the engine serves the mock responses of the
+// routes, see RouteHandle.Mock.
+type SandboxConfig struct {
+	// Enabled serves the mock responses to all the requests, such as in a
+	// sandbox deployment of the API.
+	Enabled bool
+
+	// Authorize reports whether a request with the X-Mock: true header comes
+	// from a test client allowed to get the mock responses. It runs in place
+	// of the handler of the route, after its middlewares, such as the
+	// authentication ones. Optional: without it, the header is ignored.
+	Authorize func(c *Context) bool
+}
+
+// RouteMock is the mock response of a route, see RouteHandle.Mock.
+type RouteMock struct {
+	// Status is the status of the response. Optional. Default value is 200.
+	Status int
+
+	// ContentType is the content type of the response.
+	// Optional. Default value is application/json.
+	ContentType string
+
+	// Header are headers added to the response. Optional.
+	Header map[string]string
+
+	// Body is the body of the response, a text/template executed with the
+	// request: .Method, .Path, .Params, the URL params by name, and .Query,
+	// the query values. The json function quotes a value as a JSON string:
+	//
+	//	{"id": {{json .Params.id}}, "page": {{or (.Query.Get "page") 1}}}
+	Body string
+}
+
+// routeMock is a parsed RouteMock.
+type routeMock struct {
+	RouteMock
+	body *template.Template
+}
+
+// routeMockData is the data the body templates of the mocks are executed with.
+type routeMockData struct {
+	Method string
+	Path   string
+	Params map[string]string
+	Query  url.Values
+}
+
+var mockFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// Mock annotates the routes with a mock response, served in place of their
+// handler when the engine runs in sandbox mode, or to the authorized test
+// clients sending the X-Mock: true header, see Engine.Sandbox. The consumers of
+// the API run their contract tests against the real routing and middlewares of
+// the engine:
+//
+//	router.GET("/users/:id", getUser)
+//	router.Route("/users/:id").Mock(gin.RouteMock{
+//	    Body: `{"id": {{json .Params.id}}, "name": "Ada"}`,
+//	})
+//
+// It panics if the body is not a valid template.
+func (h *RouteHandle) Mock(mock RouteMock) *RouteHandle {
+	if mock.Status == 0 {
+		mock.Status = http.StatusOK
+	}
+	if mock.ContentType == "" {
+		mock.ContentType = MIMEJSON
+	}
+	m := &routeMock{
+		RouteMock: mock,
+		body:      template.Must(template.New("mock").Funcs(mockFuncs).Option("missingkey=zero").Parse(mock.Body)),
+	}
+	engine := h.engine
+	return h.annotate(func(route *Route) {
+		if route.mock == nil {
+			engine.mockedRoutes++
+		}
+		route.mock = m
+	})
+}
+
+// mockHandlers replaces the handler of a request matching a route with a mock
+// response by one serving the mock when the sandbox config asks for it.
+func (engine *Engine) mockHandlers(c *Context, httpMethod string) {
+	route := engine.table.Load().routeIndex[routeKey(c.routeHost, httpMethod, c.fullPath)]
+	if route == nil || route.mock == nil || len(c.handlers) == 0 {
+		return
+	}
+	sandbox := engine.Sandbox
+	if !sandbox.Enabled && (sandbox.Authorize == nil || c.requestHeader(MockHeader) != "true") {
+		return
+	}
+	last := len(c.handlers) - 1
+	handler := c.handlers[last]
+	mock := route.mock
+	c.handlers = append(c.handlers[:last:last], func(c *Context) {
+		if !sandbox.Enabled && !sandbox.Authorize(c) {
+			handler(c)
+			return
+		}
+		mock.serve(c)
+	})
+}
+
+// serve writes the mock response.
+func (m *routeMock) serve(c *Context) {
+	data := routeMockData{
+		Method: c.Request.Method,
+		Path:   c.Request.URL.Path,
+		Params: make(map[string]string, len(c.Params)),
+		Query:  c.Request.URL.Query(),
+	}
+	for _, p := range c.Params {
+		data.Params[p.Key] = strings.TrimPrefix(p.Value, "/")
+	}
+	var body bytes.Buffer
+	if err := m.body.Execute(&body, data); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err) //nolint: errcheck
+		return
+	}
+	header := c.Writer.Header()
+	for k, v := range m.Header {
+		header.Set(k, v)
+	}
+	header.Set(MockHeader, "true")
+	c.Data(m.Status, m.ContentType, body.Bytes())
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteMock(t *testing.T) {
+	router := New()
+	authenticated := func(c *Context) {
+		if c.GetHeader("Authorization") == "Bearer tester" {
+			c.Set(AuthUserKey, "tester")
+		}
+	}
+	router.Use(authenticated)
+	router.GET("/users/:id", func(c *Context) { c.String(http.StatusOK, "real") })
+	router.Route("/users/:id", http.MethodGet).Mock(RouteMock{
+		Body:   `{"id":{{json .Params.id}},"page":{{or (.Query.Get "page") 1}}}`,
+		Header: map[string]string{"X-Total-Count": "1"},
+	})
+	router.POST("/users", func(c *Context) { c.String(http.StatusOK, "real") })
+	router.Route("/users", http.MethodPost).Mock(RouteMock{
+		Status:      http.StatusCreated,
+		ContentType: MIMEPlain,
+		Body:        "created",
+	})
+	router.GET("/health", func(c *Context) { c.String(http.StatusOK, "real") })
+
+	// the mocks are not served outside of the sandbox
+	w := PerformRequest(router, http.MethodGet, "/users/42", header{MockHeader, "true"})
+	assert.Equal(t, "real", w.Body.String())
+
+	router.Sandbox.Authorize = func(c *Context) bool { return c.GetString(AuthUserKey) == "tester" }
+	w = PerformRequest(router, http.MethodGet, "/users/42")
+	assert.Equal(t, "real", w.Body.String())
+	w = PerformRequest(router, http.MethodGet, "/users/42", header{MockHeader, "true"})
+	assert.Equal(t, "real", w.Body.String())
+	w = PerformRequest(router, http.MethodGet, "/users/4\"2?page=3", header{MockHeader, "true"}, header{"Authorization", "Bearer tester"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"id":"4\"2","page":3}`, w.Body.String())
+	assert.Equal(t, MIMEJSON, w.Header().Get("Content-Type"))
+	assert.Equal(t, "1", w.Header().Get("X-Total-Count"))
+	assert.Equal(t, "true", w.Header().Get(MockHeader))
+
+	router.Sandbox = SandboxConfig{Enabled: true}
+	w = PerformRequest(router, http.MethodPost, "/users")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "created", w.Body.String())
+	assert.Equal(t, MIMEPlain, w.Header().Get("Content-Type"))
+	w = PerformRequest(router, http.MethodGet, "/health")
+	assert.Equal(t, "real", w.Body.String())
+	w = PerformRequest(router.Clone(), http.MethodGet, "/users/1")
+	assert.Equal(t, `{"id":"1","page":1}`, w.Body.String())
+
+	router.GET("/broken", handlerTest1)
+	assert.Panics(t, func() { router.Route("/broken", http.MethodGet).Mock(RouteMock{Body: "{{"}) })
+}
@@ -0,0 +1,128 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"errors"
+	"mime/multipart"
+	"net/http"
+)
+
+var (
+	// ErrPartTooLarge is returned by the reads of a MultipartPart past
+	// MultipartStreamConfig.MaxPartSize.
+	ErrPartTooLarge = errors.New("upload: multipart part too large")
+	// ErrMultipartTooLarge is returned by a MultipartStream and its parts once
+	// the request body is read past MultipartStreamConfig.MaxSize.
+	ErrMultipartTooLarge = errors.New("upload: multipart body too large")
+)
+
+// MultipartStreamConfig defines the limits of Context.MultipartStream.
+type MultipartStreamConfig struct {
+	// MaxPartSize caps the size of each part, 0 means no limit.
+	// Optional. Default value is 0.
+	MaxPartSize int64
+
+	// MaxSize caps the size of the request body, boundaries and part headers
+	// included, 0 means no limit. Optional. Default value is 0.
+	MaxSize int64
+}
+
+// MultipartStream reads the parts of a multipart request body one at a time,
+// see Context.MultipartStream.
+type MultipartStream struct {
+	reader *multipart.Reader
+	conf   MultipartStreamConfig
+}
+
+// MultipartPart is a part of a MultipartStream, whose body is read from the
+// request as it is read, bounded by MultipartStreamConfig.MaxPartSize.
+type MultipartPart struct {
+	*multipart.Part
+	size  int64
+	limit int64
+}
+
+// MultipartStream returns a stream of the parts of the multipart/form-data or
+// multipart/mixed request body, read from the request as the handler reads
+// them, for the large uploads which should be neither buffered in memory nor
+// spooled to temporary files as by Context.MultipartForm:
+//
+//	parts, err := c.MultipartStream(gin.MultipartStreamConfig{MaxPartSize: 1 << 30})
+//	if err != nil {
+//	    ...
+//	}
+//	for {
+//	    part, err := parts.Next()
+//	    if err == io.EOF {
+//	        break
+//	    }
+//	    ...
+//	    _, err = io.Copy(bucket.Writer(part.FileName()), part)
+//	}
+//
+// The body can then not be parsed by Context.MultipartForm, Context.FormFile
+// nor the form bindings. It returns http.ErrNotMultipart if the request is
+// not multipart.
+func (c *Context) MultipartStream(conf ...MultipartStreamConfig) (*MultipartStream, error) {
+	s := &MultipartStream{}
+	if len(conf) > 0 {
+		s.conf = conf[0]
+	}
+	if s.conf.MaxSize > 0 && c.Request.Body != nil {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, s.conf.MaxSize)
+	}
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	s.reader = reader
+	return s, nil
+}
+
+// Next returns the next part of the stream, discarding the rest of the
+// previous one, or io.EOF once there is none.
+func (s *MultipartStream) Next() (*MultipartPart, error) {
+	part, err := s.reader.NextPart()
+	if err != nil {
+		return nil, multipartError(err)
+	}
+	return &MultipartPart{Part: part, limit: s.conf.MaxPartSize}, nil
+}
+
+// Read reads the body of the part. It fails with ErrPartTooLarge past
+// MultipartStreamConfig.MaxPartSize.
+func (p *MultipartPart) Read(b []byte) (int, error) {
+	if p.limit > 0 {
+		if p.size > p.limit {
+			return 0, ErrPartTooLarge
+		}
+		// read one byte past the limit to tell a part of the limit size
+		if remaining := p.limit - p.size + 1; int64(len(b)) > remaining {
+			b = b[:remaining]
+		}
+	}
+	n, err := p.Part.Read(b)
+	p.size += int64(n)
+	if p.limit > 0 && p.size > p.limit {
+		return n - 1, ErrPartTooLarge
+	}
+	return n, multipartError(err)
+}
+
+// Size returns the number of bytes of the part read so far.
+func (p *MultipartPart) Size() int64 {
+	return p.size
+}
+
+// multipartError returns ErrMultipartTooLarge for the errors of the request
+// bodies read past their limit.
+func multipartError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return ErrMultipartTooLarge
+	}
+	return err
+}
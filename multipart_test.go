@@ -0,0 +1,105 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func multipartRequest(t *testing.T, fields ...string) *http.Request {
+	buf := new(bytes.Buffer)
+	mw := multipart.NewWriter(buf)
+	for i := 0; i < len(fields); i += 2 {
+		if name, filename, ok := strings.Cut(fields[i], ":"); ok {
+			w, err := mw.CreateFormFile(name, filename)
+			require.NoError(t, err)
+			_, err = w.Write([]byte(fields[i+1]))
+			require.NoError(t, err)
+		} else {
+			require.NoError(t, mw.WriteField(fields[i], fields[i+1]))
+		}
+	}
+	require.NoError(t, mw.Close())
+	req := httptest.NewRequest(http.MethodPost, "/", buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestMultipartStream(t *testing.T) {
+	c, _ := CreateTestContext(httptest.NewRecorder())
+	c.Request = multipartRequest(t, "title", "report", "file:report.csv", "a,b\n1,2\n")
+
+	parts, err := c.MultipartStream()
+	require.NoError(t, err)
+	part, err := parts.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "title", part.FormName())
+	assert.Empty(t, part.FileName())
+	value, err := io.ReadAll(part)
+	require.NoError(t, err)
+	assert.Equal(t, "report", string(value))
+
+	part, err = parts.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "file", part.FormName())
+	assert.Equal(t, "report.csv", part.FileName())
+	content, err := io.ReadAll(part)
+	require.NoError(t, err)
+	assert.Equal(t, "a,b\n1,2\n", string(content))
+	assert.EqualValues(t, 8, part.Size())
+
+	_, err = parts.Next()
+	assert.Equal(t, io.EOF, err)
+
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("a=b"))
+	c.Request.Header.Set("Content-Type", MIMEPOSTForm)
+	_, err = c.MultipartStream()
+	assert.ErrorIs(t, err, http.ErrNotMultipart)
+}
+
+func TestMultipartStreamLimits(t *testing.T) {
+	c, _ := CreateTestContext(httptest.NewRecorder())
+	c.Request = multipartRequest(t, "exact", "1234", "file:big.bin", "123456", "last", "ok")
+	parts, err := c.MultipartStream(MultipartStreamConfig{MaxPartSize: 4})
+	require.NoError(t, err)
+
+	part, err := parts.Next()
+	require.NoError(t, err)
+	value, err := io.ReadAll(part)
+	require.NoError(t, err)
+	assert.Equal(t, "1234", string(value))
+
+	part, err = parts.Next()
+	require.NoError(t, err)
+	value, err = io.ReadAll(part)
+	assert.ErrorIs(t, err, ErrPartTooLarge)
+	assert.Equal(t, "1234", string(value))
+	_, err = part.Read(make([]byte, 8))
+	assert.ErrorIs(t, err, ErrPartTooLarge)
+
+	// the rest of a part too large is skipped
+	part, err = parts.Next()
+	require.NoError(t, err)
+	value, err = io.ReadAll(part)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(value))
+
+	c.Request = multipartRequest(t, "file:big.bin", strings.Repeat("x", 4096))
+	parts, err = c.MultipartStream(MultipartStreamConfig{MaxSize: 1024})
+	require.NoError(t, err)
+	part, err = parts.Next()
+	require.NoError(t, err)
+	_, err = io.Copy(io.Discard, part)
+	assert.ErrorIs(t, err, ErrMultipartTooLarge)
+}
@@ -0,0 +1,286 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package openapi generates the OpenAPI 3.1 document of the routes of an
+// engine, from their paths and annotations: the types they bind requests to,
+// declared with RouteHandle.Binds, and the ones of their responses, declared
+// with RouteHandle.Responds.
+package openapi
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	gin "github.com/jialequ/mpgw"
+	"github.com/jialequ/mpgw/binding"
+)
+
+// Version is the version of the OpenAPI specification of the documents.
+const Version = "3.1.0"
+
+// DefaultPath is the path where Serve serves the document.
+const DefaultPath = "/openapi.json"
+
+// Config defines the config of the generated document.
+type Config struct {
+	// Title is the title of the API. Optional. Default value is "API".
+	Title string
+
+	// Version is the version of the API. Optional. Default value is "1.0.0".
+	Version string
+
+	// Description is the description of the API. Optional.
+	Description string
+
+	// Servers are the URLs of the servers of the API. Optional.
+	Servers []string
+
+	// Host selects the routes registered with Engine.Host for this host
+	// pattern. Optional. Default value is "", the routes without host.
+	Host string
+
+	// Path is the path where Serve serves the document. Optional. Default
+	// value is DefaultPath.
+	Path string
+}
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []Server            `json:"servers,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components *Components         `json:"components,omitempty"`
+}
+
+// Info describes the API.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Server is a server of the API.
+type Server struct {
+	URL string `json:"url"`
+}
+
+// PathItem holds the operations of a path, by lower case method.
+type PathItem map[string]*Operation
+
+// Operation is a route.
+type Operation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter is a path, query or header parameter of an operation.
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody is the body of the requests of an operation.
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is a response of an operation.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the schemas of the named types, referenced by the other
+// schemas.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// bindingMediaTypes are the media types of the request bodies by binding.
+var bindingMediaTypes = map[string]string{
+	binding.JSON.Name():          binding.MIMEJSON,
+	binding.XML.Name():           binding.MIMEXML,
+	binding.YAML.Name():          binding.MIMEYAML,
+	binding.TOML.Name():          binding.MIMETOML,
+	binding.Form.Name():          binding.MIMEPOSTForm,
+	binding.FormPost.Name():      binding.MIMEPOSTForm,
+	binding.FormMultipart.Name(): binding.MIMEMultipartPOSTForm,
+}
+
+// Generate returns the OpenAPI document of the routes of engine.
+func Generate(engine *gin.Engine, conf Config) *Document {
+	if conf.Title == "" {
+		conf.Title = "API"
+	}
+	if conf.Version == "" {
+		conf.Version = "1.0.0"
+	}
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: conf.Title, Version: conf.Version, Description: conf.Description},
+		Paths:   make(map[string]PathItem),
+	}
+	for _, url := range conf.Servers {
+		doc.Servers = append(doc.Servers, Server{URL: url})
+	}
+	g := newGenerator()
+	for _, route := range engine.RouteDocs() {
+		if route.Host != conf.Host {
+			continue
+		}
+		path, params := pathTemplate(route)
+		item := doc.Paths[path]
+		if item == nil {
+			item = make(PathItem)
+			doc.Paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = g.operation(route, params)
+	}
+	if len(g.schemas) > 0 {
+		doc.Components = &Components{Schemas: g.schemas}
+	}
+	return doc
+}
+
+// Serve registers a GET handler at conf.Path serving the OpenAPI document of
+// the routes of engine, generated on the first request. The given handlers run
+// before the document.
+func Serve(engine *gin.Engine, conf Config, handlers ...gin.HandlerFunc) gin.IRoutes {
+	if conf.Path == "" {
+		conf.Path = DefaultPath
+	}
+	var once sync.Once
+	var doc *Document
+	handlers = append(handlers, func(c *gin.Context) {
+		once.Do(func() {
+			doc = Generate(engine, conf)
+			delete(doc.Paths, conf.Path)
+		})
+		c.JSON(http.StatusOK, doc)
+	})
+	routes := engine.GET(conf.Path, handlers...)
+	engine.Route(conf.Path, http.MethodGet).Describe("OpenAPI document of the routes")
+	return routes
+}
+
+// pathTemplate returns the OpenAPI template of the path of route, with its
+// parameters in braces, and the path parameters.
+func pathTemplate(route gin.RouteDoc) (string, []Parameter) {
+	path := route.Path
+	params := make([]Parameter, 0, len(route.Params))
+	wildcards := 0
+	for _, p := range route.Params {
+		name, pattern := p.Name, ":"+p.Name
+		switch {
+		case p.CatchAll:
+			pattern = "*" + p.Name
+		case p.Name == "*":
+			wildcards++
+			name, pattern = "wildcard"+strconv.Itoa(wildcards), "/*"
+		case p.Constraint != "":
+			pattern += "|" + p.Constraint
+		}
+		replacement := "{" + name + "}"
+		if pattern == "/*" {
+			replacement = "/" + replacement
+		}
+		path = strings.Replace(path, pattern, replacement, 1)
+		params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: constraintSchema(p.Constraint)})
+	}
+	return path, params
+}
+
+// constraintSchema returns the schema of a path parameter with a constraint.
+func constraintSchema(constraint string) *Schema {
+	switch constraint {
+	case "int":
+		return &Schema{Type: "integer"}
+	case "alpha":
+		return &Schema{Type: "string", Pattern: "^[A-Za-z]+$"}
+	case "alnum":
+		return &Schema{Type: "string", Pattern: "^[A-Za-z0-9]+$"}
+	case "uuid":
+		return &Schema{Type: "string", Format: "uuid"}
+	}
+	return &Schema{Type: "string"}
+}
+
+// operation returns the operation of route.
+func (g *generator) operation(route gin.RouteDoc, params []Parameter) *Operation {
+	op := &Operation{
+		OperationID: route.Name,
+		Summary:     route.Description,
+		Tags:        route.Tags,
+		Deprecated:  route.Deprecation != nil,
+		Parameters:  params,
+		Responses:   make(map[string]*Response),
+	}
+	if route.BodyType != nil {
+		name := "json"
+		if route.BodyBinding != nil {
+			name = route.BodyBinding.Name()
+		}
+		switch {
+		case name == binding.Query.Name() || name == binding.Form.Name() && !bodyAllowed(route.Method):
+			op.Parameters = append(op.Parameters, g.fieldParameters(route.BodyType, "form", "query")...)
+		case name == binding.Header.Name():
+			op.Parameters = append(op.Parameters, g.fieldParameters(route.BodyType, "header", "header")...)
+		default:
+			if mediaType, ok := bindingMediaTypes[name]; ok {
+				tag := "json"
+				if mediaType == binding.MIMEPOSTForm || mediaType == binding.MIMEMultipartPOSTForm {
+					tag = "form"
+				}
+				op.RequestBody = &RequestBody{
+					Required: true,
+					Content:  map[string]MediaType{mediaType: {Schema: g.schema(route.BodyType, tag)}},
+				}
+			}
+			op.Parameters = append(op.Parameters, g.fieldParameters(route.BodyType, "header", "header")...)
+		}
+	}
+	for _, resp := range route.Responses {
+		response := &Response{Description: http.StatusText(resp.Status)}
+		if resp.Type != nil {
+			response.Content = map[string]MediaType{binding.MIMEJSON: {Schema: g.schema(resp.Type, "json")}}
+		}
+		op.Responses[strconv.Itoa(resp.Status)] = response
+	}
+	if len(op.Responses) == 0 {
+		op.Responses["default"] = &Response{Description: "Default response"}
+	}
+	sort.SliceStable(op.Parameters, func(i, j int) bool {
+		return parameterOrder[op.Parameters[i].In] < parameterOrder[op.Parameters[j].In]
+	})
+	return op
+}
+
+// parameterOrder orders the parameters of an operation by location.
+var parameterOrder = map[string]int{"path": 0, "query": 1, "header": 2}
+
+// bodyAllowed reports whether the requests of method have a body.
+func bodyAllowed(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete, http.MethodOptions:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gin "github.com/jialequ/mpgw"
+	"github.com/jialequ/mpgw/binding"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type address struct {
+	City string `json:"city" binding:"required"`
+}
+
+type user struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name" binding:"required" example:"Ada" description:"Full name"`
+	Role      string    `json:"role" binding:"oneof=admin user"`
+	Tags      []string  `json:"tags,omitempty"`
+	Address   *address  `json:"address"`
+	CreatedAt time.Time `json:"createdAt"`
+	Secret    string    `json:"-"`
+}
+
+type createUser struct {
+	Name      string `json:"name" binding:"required"`
+	RequestID string `header:"X-Request-Id"`
+}
+
+type listUsers struct {
+	Page  int    `form:"page"`
+	Query string `form:"q" binding:"required"`
+}
+
+func TestGenerate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := func(c *gin.Context) {}
+	router.GET("/users", handler)
+	router.Route("/users", http.MethodGet).Binds(listUsers{}, binding.Form).Responds(http.StatusOK, []user{})
+	router.POST("/users", handler)
+	router.Route("/users", http.MethodPost).Binds(&createUser{}).Responds(http.StatusCreated, &user{}).Tags("users")
+	router.GET("/users/:id|int", handler)
+	router.Route("/users/:id|int", http.MethodGet).Name("getUser").Describe("Returns a user").
+		Responds(http.StatusOK, user{}).Responds(http.StatusNotFound, nil)
+	router.GET("/files/*/meta/*path", handler)
+	router.Host("admin.example.com").GET("/stats", handler)
+
+	doc := Generate(router, Config{Title: "Users", Servers: []string{"https://api.example.com"}})
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+	assert.Equal(t, Info{Title: "Users", Version: "1.0.0"}, doc.Info)
+	assert.Equal(t, []Server{{URL: "https://api.example.com"}}, doc.Servers)
+	assert.Len(t, doc.Paths, 3)
+	assert.NotContains(t, doc.Paths, "/stats")
+
+	list := doc.Paths["/users"]["get"]
+	assert.Equal(t, []Parameter{
+		{Name: "page", In: "query", Schema: &Schema{Type: "integer", Format: "int64"}},
+		{Name: "q", In: "query", Required: true, Schema: &Schema{Type: "string"}},
+	}, list.Parameters)
+	assert.Nil(t, list.RequestBody)
+	assert.Equal(t, &Schema{Type: "array", Items: &Schema{Ref: "#/components/schemas/user"}},
+		list.Responses["200"].Content["application/json"].Schema)
+
+	create := doc.Paths["/users"]["post"]
+	assert.Equal(t, []string{"users"}, create.Tags)
+	assert.Equal(t, []Parameter{{Name: "X-Request-Id", In: "header", Schema: &Schema{Type: "string"}}}, create.Parameters)
+	assert.Equal(t, &Schema{Ref: "#/components/schemas/createUser"}, create.RequestBody.Content["application/json"].Schema)
+	assert.Equal(t, &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{"name": {Type: "string"}},
+		Required:   []string{"name"},
+	}, doc.Components.Schemas["createUser"])
+	assert.Equal(t, "Created", create.Responses["201"].Description)
+
+	get := doc.Paths["/users/{id}"]["get"]
+	assert.Equal(t, "getUser", get.OperationID)
+	assert.Equal(t, "Returns a user", get.Summary)
+	assert.Equal(t, []Parameter{{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "integer"}}}, get.Parameters)
+	assert.Equal(t, &Response{Description: "Not Found"}, get.Responses["404"])
+
+	files := doc.Paths["/files/{wildcard1}/meta/{path}"]["get"]
+	assert.Len(t, files.Parameters, 2)
+	assert.Equal(t, &Response{Description: "Default response"}, files.Responses["default"])
+
+	schema := doc.Components.Schemas["user"]
+	assert.Equal(t, []string{"name"}, schema.Required)
+	assert.Equal(t, &Schema{Type: "string", Description: "Full name", Example: "Ada"}, schema.Properties["name"])
+	assert.Equal(t, []string{"admin", "user"}, schema.Properties["role"].Enum)
+	assert.Equal(t, &Schema{Type: "string", Format: "date-time"}, schema.Properties["createdAt"])
+	assert.Equal(t, &Schema{Ref: "#/components/schemas/address"}, schema.Properties["address"])
+	assert.NotContains(t, schema.Properties, "Secret")
+	assert.Equal(t, []string{"city"}, doc.Components.Schemas["address"].Required)
+
+	admin := Generate(router, Config{Host: "admin.example.com"})
+	assert.Equal(t, []string{"/stats"}, keys(admin.Paths))
+}
+
+func TestServe(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	Serve(router, Config{Path: "/docs/openapi.json"})
+	router.GET("/ping", func(c *gin.Context) {})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var doc Document
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, []string{"/ping"}, keys(doc.Paths))
+}
+
+func keys(paths map[string]PathItem) []string {
+	var keys []string
+	for path := range paths {
+		keys = append(keys, path)
+	}
+	return keys
+}
@@ -0,0 +1,207 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"mime/multipart"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is the JSON schema of a value.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Example              any                `json:"example,omitempty"`
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	fileHeaderType    = reflect.TypeOf(multipart.FileHeader{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// generator generates the schemas of the types of a document, the named
+// structs being defined once in the components.
+type generator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newGenerator() *generator {
+	return &generator{schemas: make(map[string]*Schema), names: make(map[reflect.Type]string)}
+}
+
+// schema returns the schema of t, whose fields are named by the given tag,
+// "json" or "form".
+func (g *generator) schema(t reflect.Type, tag string) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == fileHeaderType:
+		return &Schema{Type: "string", Format: "binary"}
+	case t.Kind() != reflect.Struct && (t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType)):
+		return &Schema{Type: "string"}
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := 0.0
+		return &Schema{Type: "integer", Minimum: &zero}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem(), tag)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem(), tag)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t, tag)
+		}
+		return &Schema{Ref: "#/components/schemas/" + g.define(t, tag)}
+	}
+	return &Schema{}
+}
+
+// define defines the schema of the named struct t in the components, and
+// returns its name.
+func (g *generator) define(t reflect.Type, tag string) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	for i := 2; g.schemas[name] != nil; i++ {
+		name = t.Name() + strconv.Itoa(i)
+	}
+	g.names[t] = name
+	g.schemas[name] = &Schema{} // placeholder for the recursive types
+	*g.schemas[name] = *g.structSchema(t, tag)
+	return name
+}
+
+// structSchema returns the schema of the fields of the struct t.
+func (g *generator) structSchema(t reflect.Type, tag string) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.addFields(s, t, tag)
+	return s
+}
+
+// addFields adds the fields of the struct t to s, flattening the embedded
+// structs.
+func (g *generator) addFields(s *Schema, t reflect.Type, tag string) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get(tag), ",")
+		if name == "-" || sf.Tag.Get("uri") != "" || sf.Tag.Get("header") != "" {
+			continue
+		}
+		ft := sf.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			g.addFields(s, ft, tag)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fs := g.schema(sf.Type, tag)
+		if fs.Ref == "" {
+			fs.Description = sf.Tag.Get("description")
+			if example := sf.Tag.Get("example"); example != "" {
+				var v any
+				if json.Unmarshal([]byte(example), &v) != nil || fs.Type == "string" {
+					v = example
+				}
+				fs.Example = v
+			}
+		}
+		rules := strings.Split(sf.Tag.Get("binding"), ",")
+		for _, rule := range rules {
+			if values, ok := strings.CutPrefix(rule, "oneof="); ok && fs.Ref == "" {
+				fs.Enum = strings.Fields(values)
+			}
+		}
+		if hasRule(rules, "required") {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = fs
+	}
+}
+
+// fieldParameters returns the parameters declared by the fields of the struct
+// t with the given tag, located in "query" or "header".
+func (g *generator) fieldParameters(t reflect.Type, tag, in string) []Parameter {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var params []Parameter
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get(tag), ",")
+		if name == "-" || !sf.IsExported() || name == "" && (in == "header" || sf.Tag.Get("uri") != "" || sf.Tag.Get("header") != "") {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		params = append(params, Parameter{
+			Name:     name,
+			In:       in,
+			Required: hasRule(strings.Split(sf.Tag.Get("binding"), ","), "required"),
+			Schema:   g.schema(sf.Type, tag),
+		})
+	}
+	return params
+}
+
+// hasRule reports whether the validation rules contain rule.
+func hasRule(rules []string, rule string) bool {
+	for _, r := range rules {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"strconv"
+)
+
+const (
+	defaultPaginationLimit    = 20
+	defaultPaginationMaxLimit = 100
+)
+
+// PaginationConfig defines the query parameters and limits of Context.Pagination.
+type PaginationConfig struct {
+	// PageParam is the query parameter of the 1-based page number.
+	// Optional. Default value is "page".
+	PageParam string
+
+	// LimitParam is the query parameter of the page size.
+	// Optional. Default value is "limit".
+	LimitParam string
+
+	// CursorParam is the query parameter of the opaque cursor of cursor-based pagination.
+	// Optional. Default value is "cursor".
+	CursorParam string
+
+	// DefaultLimit is the page size used when none or an invalid one is given.
+	// Optional. Default value is 20.
+	DefaultLimit int
+
+	// MaxLimit caps the page size. Optional. Default value is 100.
+	MaxLimit int
+
+	// TotalHeader is the response header holding the total number of items.
+	// Optional. Default value is "X-Total-Count".
+	TotalHeader string
+}
+
+// Pagination is the pagination requested by a client, see Context.Pagination.
+type Pagination struct {
+	// Page is the 1-based page number, 1 when the client gave none or an invalid one.
+	Page int
+	// Limit is the page size, between 1 and the configured MaxLimit.
+	Limit int
+	// Offset is the number of items before the page, (Page-1)*Limit.
+	Offset int
+	// Cursor is the opaque cursor given by the client, if any.
+	Cursor string
+
+	conf PaginationConfig
+}
+
+// Pagination parses the pagination query parameters of the request, applying the
+// defaults and caps of conf. Invalid values are replaced by their default rather
+// than rejected, so endpoints behave consistently:
+//
+//	p := c.Pagination(gin.PaginationConfig{MaxLimit: 50})
+//	items, total := store.List(p.Offset, p.Limit)
+//	p.WriteHeaders(c, total)
+//	c.JSON(http.StatusOK, items)
+func (c *Context) Pagination(conf PaginationConfig) Pagination {
+	if conf.PageParam == "" {
+		conf.PageParam = "page"
+	}
+	if conf.LimitParam == "" {
+		conf.LimitParam = "limit"
+	}
+	if conf.CursorParam == "" {
+		conf.CursorParam = "cursor"
+	}
+	if conf.MaxLimit <= 0 {
+		conf.MaxLimit = defaultPaginationMaxLimit
+	}
+	if conf.DefaultLimit <= 0 {
+		conf.DefaultLimit = defaultPaginationLimit
+	}
+	if conf.DefaultLimit > conf.MaxLimit {
+		conf.DefaultLimit = conf.MaxLimit
+	}
+	if conf.TotalHeader == "" {
+		conf.TotalHeader = "X-Total-Count"
+	}
+
+	p := Pagination{Page: 1, Limit: conf.DefaultLimit, conf: conf}
+	if page, err := strconv.Atoi(c.Query(conf.PageParam)); err == nil && page > 0 {
+		p.Page = page
+	}
+	if limit, err := strconv.Atoi(c.Query(conf.LimitParam)); err == nil && limit > 0 {
+		p.Limit = limit
+		if p.Limit > conf.MaxLimit {
+			p.Limit = conf.MaxLimit
+		}
+	}
+	p.Offset = (p.Page - 1) * p.Limit
+	p.Cursor = c.Query(conf.CursorParam)
+	return p
+}
+
+// LastPage returns the number of the last page of a collection of total items.
+func (p Pagination) LastPage(total int) int {
+	if total <= 0 {
+		return 1
+	}
+	return (total + p.Limit - 1) / p.Limit
+}
+
+// WriteHeaders writes the total number of items and the first, prev, next and
+// last page links of a page-based collection.
+func (p Pagination) WriteHeaders(c *Context, total int) {
+	c.Header(p.conf.TotalHeader, strconv.Itoa(total))
+
+	last := p.LastPage(total)
+	links := c.Links().Add("first", p.pageURL(c, 1))
+	if p.Page > 1 {
+		links.Add("prev", p.pageURL(c, min(p.Page-1, last)))
+	}
+	if p.Page < last {
+		links.Add("next", p.pageURL(c, p.Page+1))
+	}
+	links.Add("last", p.pageURL(c, last))
+	c.LinkHeader(links)
+}
+
+// WriteCursorHeaders writes the next link of a cursor-based collection, if
+// nextCursor is not empty, and the total number of items if it is not negative.
+func (p Pagination) WriteCursorHeaders(c *Context, nextCursor string, total int) {
+	if total >= 0 {
+		c.Header(p.conf.TotalHeader, strconv.Itoa(total))
+	}
+	if nextCursor == "" {
+		return
+	}
+	u := *c.Request.URL
+	query := u.Query()
+	query.Set(p.conf.CursorParam, nextCursor)
+	query.Set(p.conf.LimitParam, strconv.Itoa(p.Limit))
+	u.RawQuery = query.Encode()
+	c.LinkHeader(c.Links().Add("next", u.RequestURI()))
+}
+
+func (p Pagination) pageURL(c *Context, page int) string {
+	u := *c.Request.URL
+	query := u.Query()
+	query.Set(p.conf.PageParam, strconv.Itoa(page))
+	query.Set(p.conf.LimitParam, strconv.Itoa(p.Limit))
+	query.Del(p.conf.CursorParam)
+	u.RawQuery = query.Encode()
+	return u.RequestURI()
+}
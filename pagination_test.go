@@ -0,0 +1,94 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func paginationContext(target string) (*Context, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	c, _ := CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodGet, target, nil)
+	return c, w
+}
+
+func TestPagination(t *testing.T) {
+	c, _ := paginationContext("/items")
+	p := c.Pagination(PaginationConfig{})
+	assert.Equal(t, 1, p.Page)
+	assert.Equal(t, 20, p.Limit)
+	assert.Equal(t, 0, p.Offset)
+	assert.Empty(t, p.Cursor)
+
+	c, _ = paginationContext("/items?page=3&limit=10&cursor=abc")
+	p = c.Pagination(PaginationConfig{})
+	assert.Equal(t, 3, p.Page)
+	assert.Equal(t, 10, p.Limit)
+	assert.Equal(t, 20, p.Offset)
+	assert.Equal(t, "abc", p.Cursor)
+
+	c, _ = paginationContext("/items?page=-2&limit=500")
+	p = c.Pagination(PaginationConfig{MaxLimit: 50})
+	assert.Equal(t, 1, p.Page)
+	assert.Equal(t, 50, p.Limit)
+
+	c, _ = paginationContext("/items?page=x&limit=0")
+	p = c.Pagination(PaginationConfig{DefaultLimit: 80, MaxLimit: 50})
+	assert.Equal(t, 1, p.Page)
+	assert.Equal(t, 50, p.Limit)
+
+	c, _ = paginationContext("/items?p=2&size=5")
+	p = c.Pagination(PaginationConfig{PageParam: "p", LimitParam: "size"})
+	assert.Equal(t, 2, p.Page)
+	assert.Equal(t, 5, p.Limit)
+	assert.Equal(t, 5, p.Offset)
+}
+
+func TestPaginationLastPage(t *testing.T) {
+	p := Pagination{Limit: 10}
+	assert.Equal(t, 1, p.LastPage(0))
+	assert.Equal(t, 1, p.LastPage(10))
+	assert.Equal(t, 2, p.LastPage(11))
+}
+
+func TestPaginationWriteHeaders(t *testing.T) {
+	c, w := paginationContext("/items?page=2&limit=10&sort=name")
+	c.Pagination(PaginationConfig{}).WriteHeaders(c, 35)
+	assert.Equal(t, "35", w.Header().Get("X-Total-Count"))
+	assert.Equal(t, `</items?limit=10&page=1&sort=name>; rel="first", `+
+		`</items?limit=10&page=1&sort=name>; rel="prev", `+
+		`</items?limit=10&page=3&sort=name>; rel="next", `+
+		`</items?limit=10&page=4&sort=name>; rel="last"`, w.Header().Get("Link"))
+
+	c, w = paginationContext("/items")
+	c.Pagination(PaginationConfig{TotalHeader: "X-Total"}).WriteHeaders(c, 0)
+	assert.Equal(t, "0", w.Header().Get("X-Total"))
+	assert.Equal(t, `</items?limit=20&page=1>; rel="first", </items?limit=20&page=1>; rel="last"`, w.Header().Get("Link"))
+
+	// past the last page, prev points to the last page
+	c, w = paginationContext("/items?page=9&limit=10")
+	c.Pagination(PaginationConfig{}).WriteHeaders(c, 15)
+	assert.Contains(t, w.Header().Get("Link"), `</items?limit=10&page=2>; rel="prev"`)
+	assert.NotContains(t, w.Header().Get("Link"), `rel="next"`)
+}
+
+func TestPaginationWriteCursorHeaders(t *testing.T) {
+	c, w := paginationContext("/items?cursor=abc&limit=5")
+	p := c.Pagination(PaginationConfig{})
+	p.WriteCursorHeaders(c, "def", -1)
+	assert.Empty(t, w.Header().Get("X-Total-Count"))
+	assert.Equal(t, `</items?cursor=def&limit=5>; rel="next"`, w.Header().Get("Link"))
+
+	c, w = paginationContext("/items?cursor=def")
+	p = c.Pagination(PaginationConfig{})
+	p.WriteCursorHeaders(c, "", 12)
+	assert.Equal(t, "12", w.Header().Get("X-Total-Count"))
+	assert.Empty(t, w.Header().Get("Link"))
+}
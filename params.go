@@ -0,0 +1,127 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// errMissingParam is the cause of the ParamError of a param missing from the
+// route.
+var errMissingParam = errors.New("missing")
+
+// ParamError is the error of a URL param which cannot be converted to the
+// type requested by the typed accessors, such as Context.ParamInt. It is
+// mapped to 400 Bad Request by Engine.MapErrors, with its message as detail.
+type ParamError struct {
+	Key   string
+	Value string
+	// Type is the type the value was converted to, such as "int" or "uuid".
+	Type string
+	Err  error
+}
+
+func (e *ParamError) Error() string {
+	if errors.Is(e.Err, errMissingParam) {
+		return "missing param " + strconv.Quote(e.Key)
+	}
+	return fmt.Sprintf("param %q: %q is not a valid %s", e.Key, e.Value, e.Type)
+}
+
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// paramValue returns the value of the URL param, or a ParamError if the route
+// has no such param.
+func (c *Context) paramValue(key, typ string) (string, error) {
+	value, ok := c.Params.Get(key)
+	if !ok {
+		return "", &ParamError{Key: key, Type: typ, Err: errMissingParam}
+	}
+	return value, nil
+}
+
+// ParamInt returns the value of the URL param as an int, or a *ParamError if
+// it is missing or is not one:
+//
+//	router.GET("/users/:id", func(c *gin.Context) {
+//	    id, err := c.ParamInt("id")
+//	    if err != nil {
+//	        _ = c.AbortWithError(http.StatusBadRequest, err)
+//	        return
+//	    }
+//	})
+func (c *Context) ParamInt(key string) (int, error) {
+	value, err := c.paramValue(key, "int")
+	if err != nil {
+		return 0, err
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, &ParamError{Key: key, Value: value, Type: "int", Err: err}
+	}
+	return i, nil
+}
+
+// ParamInt64 returns the value of the URL param as an int64, see ParamInt.
+func (c *Context) ParamInt64(key string) (int64, error) {
+	value, err := c.paramValue(key, "int64")
+	if err != nil {
+		return 0, err
+	}
+	i, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, &ParamError{Key: key, Value: value, Type: "int64", Err: err}
+	}
+	return i, nil
+}
+
+// ParamUint returns the value of the URL param as a uint, see ParamInt.
+func (c *Context) ParamUint(key string) (uint, error) {
+	value, err := c.paramValue(key, "uint")
+	if err != nil {
+		return 0, err
+	}
+	u, err := strconv.ParseUint(value, 10, 0)
+	if err != nil {
+		return 0, &ParamError{Key: key, Value: value, Type: "uint", Err: err}
+	}
+	return uint(u), nil
+}
+
+// ParamBool returns the value of the URL param as a bool, as parsed by
+// strconv.ParseBool, see ParamInt.
+func (c *Context) ParamBool(key string) (bool, error) {
+	value, err := c.paramValue(key, "bool")
+	if err != nil {
+		return false, err
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, &ParamError{Key: key, Value: value, Type: "bool", Err: err}
+	}
+	return b, nil
+}
+
+// ParamUUID returns the value of the URL param as the bytes of a UUID in its
+// canonical form, such as "f47ac10b-58cc-4372-a567-0e02b2c3d479", see
+// ParamInt. The bytes convert to the UUID types of the uuid packages, as
+// uuid.UUID(id).
+func (c *Context) ParamUUID(key string) ([16]byte, error) {
+	var id [16]byte
+	value, err := c.paramValue(key, "uuid")
+	if err != nil {
+		return id, err
+	}
+	if !isUUIDParam(value) {
+		return id, &ParamError{Key: key, Value: value, Type: "uuid", Err: strconv.ErrSyntax}
+	}
+	hex.Decode(id[:], []byte(value[0:8]+value[9:13]+value[14:18]+value[19:23]+value[24:])) //nolint: errcheck
+	return id, nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextTypedParams(t *testing.T) {
+	c, _ := CreateTestContext(httptest.NewRecorder())
+	c.AddParam("id", "42")
+	c.AddParam("neg", "-7")
+	c.AddParam("big", "9223372036854775807")
+	c.AddParam("flag", "true")
+	c.AddParam("uuid", "F47AC10B-58CC-4372-A567-0E02B2C3D479")
+	c.AddParam("name", "john")
+
+	i, err := c.ParamInt("id")
+	assert.NoError(t, err)
+	assert.Equal(t, 42, i)
+	i64, err := c.ParamInt64("big")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(math.MaxInt64), i64)
+	u, err := c.ParamUint("id")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(42), u)
+	b, err := c.ParamBool("flag")
+	assert.NoError(t, err)
+	assert.True(t, b)
+	id, err := c.ParamUUID("uuid")
+	assert.NoError(t, err)
+	assert.Equal(t, [16]byte{0xf4, 0x7a, 0xc1, 0x0b, 0x58, 0xcc, 0x43, 0x72, 0xa5, 0x67, 0x0e, 0x02, 0xb2, 0xc3, 0xd4, 0x79}, id)
+
+	_, err = c.ParamUint("neg")
+	var paramErr *ParamError
+	assert.ErrorAs(t, err, &paramErr)
+	assert.Equal(t, ParamError{Key: "neg", Value: "-7", Type: "uint", Err: paramErr.Err}, *paramErr)
+	assert.EqualError(t, err, `param "neg": "-7" is not a valid uint`)
+	_, err = c.ParamInt("name")
+	assert.ErrorIs(t, err, strconv.ErrSyntax)
+	_, err = c.ParamBool("name")
+	assert.EqualError(t, err, `param "name": "john" is not a valid bool`)
+	_, err = c.ParamUUID("name")
+	assert.EqualError(t, err, `param "name": "john" is not a valid uuid`)
+	_, err = c.ParamInt64("missing")
+	assert.EqualError(t, err, `missing param "missing"`)
+}
+
+func TestParamErrorMapped(t *testing.T) {
+	router := New()
+	router.MapErrors()
+	router.GET("/users/:id", func(c *Context) {
+		id, err := c.ParamInt("id")
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		c.String(http.StatusOK, "user %d", id)
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/users/42")
+	assert.Equal(t, "user 42", w.Body.String())
+	w = PerformRequest(router, http.MethodGet, "/users/john")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, `{"type":"about:blank","title":"Bad Request","status":400,"detail":"param \"id\": \"john\" is not a valid int"}`, w.Body.String())
+}
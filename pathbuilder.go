@@ -0,0 +1,251 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ErrUnknownRoute is returned by PathBuilder.Build when no route has the
+// requested name.
+var ErrUnknownRoute = errors.New("unknown route name")
+
+// PathBuilder builds the path of a named route. Values are escaped and
+// required parameters are validated by Build:
+//
+//	path, err := router.PathBuilder("user").Param("id", id).Query("tab", "posts").Build()
+type PathBuilder struct {
+	name      string
+	route     *Route
+	params    map[string]string
+	wildcards []string
+	query     url.Values
+}
+
+// PathBuilder returns a builder for the path of the route named name.
+// See RouteHandle.Name.
+func (engine *Engine) PathBuilder(name string) *PathBuilder {
+	return &PathBuilder{name: name, route: engine.table.Load().namedRoutes[name]}
+}
+
+// Param sets the value of a path parameter. Catch-all values may contain
+// slashes, each segment is escaped on its own. The values of the anonymous
+// wildcards, as in "/files/*/meta", are set in order with the key "*".
+func (b *PathBuilder) Param(key, value string) *PathBuilder {
+	if key == segmentWildcard {
+		b.wildcards = append(b.wildcards, value)
+		return b
+	}
+	if b.params == nil {
+		b.params = make(map[string]string)
+	}
+	b.params[key] = value
+	return b
+}
+
+// Query adds a query string value.
+func (b *PathBuilder) Query(key, value string) *PathBuilder {
+	if b.query == nil {
+		b.query = make(url.Values)
+	}
+	b.query.Add(key, value)
+	return b
+}
+
+// Build returns the escaped path. It fails when the route is unknown, when a
+// named parameter is missing or empty, or when an unknown parameter was set.
+func (b *PathBuilder) Build() (string, error) {
+	if b.route == nil {
+		return "", fmt.Errorf("%w: %q", ErrUnknownRoute, b.name)
+	}
+	var sb strings.Builder
+	used, wildcards := 0, 0
+	parts := splitRoutePath(b.route.Path)
+	for i, part := range parts {
+		if !part.param {
+			sb.WriteString(part.text)
+			continue
+		}
+		if part.text == segmentWildcard {
+			if wildcards == len(b.wildcards) || b.wildcards[wildcards] == "" {
+				return "", fmt.Errorf("route %q: missing value for wildcard %d", b.name, wildcards+1)
+			}
+			sb.WriteString(url.PathEscape(b.wildcards[wildcards]))
+			wildcards++
+			continue
+		}
+		value, ok := b.params[part.text]
+		if ok {
+			used++
+		}
+		if part.catchAll {
+			sb.WriteString(escapeCatchAll(value))
+			continue
+		}
+		if value == "" {
+			return "", fmt.Errorf("route %q: missing value for parameter %q", b.name, part.text)
+		}
+		if i+2 < len(parts) && parts[i+2].param && parts[i+1].text[0] != '/' &&
+			strings.Contains(value, parts[i+1].text) {
+			// in a compound segment the value ends at the first occurrence of
+			// the literal following it
+			return "", fmt.Errorf("route %q: value of parameter %q contains %q", b.name, part.text, parts[i+1].text)
+		}
+		sb.WriteString(url.PathEscape(value))
+	}
+	if used != len(b.params) {
+		return "", fmt.Errorf("route %q: unknown parameters in %v", b.name, sortedKeys(b.params))
+	}
+	if wildcards != len(b.wildcards) {
+		return "", fmt.Errorf("route %q: %d wildcard values for %d wildcards", b.name, len(b.wildcards), wildcards)
+	}
+	if len(b.query) > 0 {
+		sb.WriteByte('?')
+		sb.WriteString(b.query.Encode())
+	}
+	return sb.String(), nil
+}
+
+// MustBuild is like Build but panics on error.
+func (b *PathBuilder) MustBuild() string {
+	path, err := b.Build()
+	if err != nil {
+		panic(err)
+	}
+	return path
+}
+
+// escapeCatchAll escapes each segment of a catch-all value, dropping the
+// leading slash which is part of the route path already.
+func escapeCatchAll(value string) string {
+	segments := strings.Split(strings.TrimPrefix(value, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// NamedRoutes returns the catalog entries of the named routes, sorted by name.
+// It is meant to feed code generators; see also WritePathBuilders.
+func (engine *Engine) NamedRoutes() []RouteDoc {
+	namedRoutes := engine.table.Load().namedRoutes
+	docs := make([]RouteDoc, 0, len(namedRoutes))
+	for _, doc := range engine.RouteDocs() {
+		if route, ok := namedRoutes[doc.Name]; ok && route.Method == doc.Method && route.Path == doc.Path {
+			docs = append(docs, doc)
+		}
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].Name < docs[j].Name })
+	return docs
+}
+
+// WritePathBuilders writes the Go source of package pkg with one function per
+// named route, e.g. UserPath(id string) string for a route named "user", so
+// clients get compile-time checked path builders. It is typically called from
+// a small program run by go generate.
+func (engine *Engine) WritePathBuilders(w io.Writer, pkg string) error {
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by gin. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	buf.WriteString("import (\n\"net/url\"\n\"strings\"\n)\n\n")
+
+	for _, doc := range engine.NamedRoutes() {
+		funcName := goIdentifier(doc.Name, true) + "Path"
+		args := make([]string, 0, len(doc.Params))
+		var body []string
+		wildcards := 0
+		for _, part := range splitRoutePath(doc.Path) {
+			switch {
+			case !part.param:
+				body = append(body, fmt.Sprintf("%q", part.text))
+			case part.text == segmentWildcard:
+				wildcards++
+				arg := fmt.Sprintf("wildcard%d", wildcards)
+				args = append(args, arg)
+				body = append(body, "url.PathEscape("+arg+")")
+			case part.catchAll:
+				arg := goIdentifier(part.text, false)
+				args = append(args, arg)
+				body = append(body, "escapeCatchAll("+arg+")")
+			default:
+				arg := goIdentifier(part.text, false)
+				args = append(args, arg)
+				body = append(body, "url.PathEscape("+arg+")")
+			}
+		}
+		if len(body) == 0 {
+			body = append(body, `""`)
+		}
+		signature := ""
+		if len(args) > 0 {
+			signature = strings.Join(args, ", ") + " string"
+		}
+		fmt.Fprintf(&buf, "// %s builds the path of the %q route: %s %s\n", funcName, doc.Name, doc.Method, doc.Path)
+		fmt.Fprintf(&buf, "func %s(%s) string {\nreturn %s\n}\n\n", funcName, signature, strings.Join(body, " + "))
+	}
+
+	buf.WriteString(`func escapeCatchAll(value string) string {
+	segments := strings.Split(strings.TrimPrefix(value, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+`)
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// goIdentifier turns a route or parameter name such as "user.show" or
+// "file-path" into a Go identifier, "UserShow" or "filePath".
+func goIdentifier(name string, exported bool) string {
+	var sb strings.Builder
+	upper := exported
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = sb.Len() > 0 || exported
+			continue
+		}
+		if sb.Len() == 0 && unicode.IsDigit(r) {
+			sb.WriteByte('_')
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		sb.WriteRune(r)
+	}
+	ident := sb.String()
+	if ident == "" {
+		ident = "_"
+	}
+	if token.IsKeyword(ident) || ident == "url" || ident == "strings" {
+		ident += "_"
+	}
+	return ident
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"go/parser"
+	"go/token"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathBuilder(t *testing.T) {
+	router := New()
+	router.GET("/users/:id", handlerTest1)
+	router.Route("/users/:id", http.MethodGet).Name("user")
+	router.Group("/v1").Any("/files/*path", handlerTest1)
+	router.Group("/v1").Route("/files/*path").Name("file")
+	router.GET("/user_:name/posts", handlerTest1)
+	router.Route("/user_:name/posts", http.MethodGet).Name("user.posts")
+	router.GET("/files/*/meta/*/:field", handlerTest1)
+	router.Route("/files/*/meta/*/:field", http.MethodGet).Name("meta")
+	router.GET("/range/:from-:to.json", handlerTest1)
+	router.Route("/range/:from-:to.json", http.MethodGet).Name("range")
+
+	path, err := router.PathBuilder("user").Param("id", "a b/c").Query("tab", "x&y").Build()
+	require.NoError(t, err)
+	assert.Equal(t, "/users/a%20b%2Fc?tab=x%26y", path)
+
+	assert.Equal(t, "/v1/files/docs/read%20me.md", router.PathBuilder("file").Param("path", "/docs/read me.md").MustBuild())
+	assert.Equal(t, "/v1/files/", router.PathBuilder("file").MustBuild())
+	assert.Equal(t, "/user_bob/posts", router.PathBuilder("user.posts").Param("name", "bob").MustBuild())
+	assert.Equal(t, "/files/a%2Fb/meta/v2/size", router.PathBuilder("meta").
+		Param("*", "a/b").Param("*", "v2").Param("field", "size").MustBuild())
+	_, err = router.PathBuilder("meta").Param("*", "a").Param("field", "size").Build()
+	assert.EqualError(t, err, `route "meta": missing value for wildcard 2`)
+	_, err = router.PathBuilder("meta").Param("*", "a").Param("*", "b").Param("*", "c").Param("field", "size").Build()
+	assert.EqualError(t, err, `route "meta": 3 wildcard values for 2 wildcards`)
+	assert.Equal(t, "/range/1-2.0.json", router.PathBuilder("range").Param("from", "1").Param("to", "2.0").MustBuild())
+	_, err = router.PathBuilder("range").Param("from", "1-1").Param("to", "2").Build()
+	assert.EqualError(t, err, `route "range": value of parameter "from" contains "-"`)
+
+	_, err = router.PathBuilder("user").Build()
+	assert.EqualError(t, err, `route "user": missing value for parameter "id"`)
+	_, err = router.PathBuilder("user").Param("id", "1").Param("uid", "1").Build()
+	assert.EqualError(t, err, `route "user": unknown parameters in [id uid]`)
+	_, err = router.PathBuilder("nope").Build()
+	assert.ErrorIs(t, err, ErrUnknownRoute)
+	assert.Panics(t, func() { router.PathBuilder("nope").MustBuild() })
+
+	router.POST("/other", handlerTest1)
+	router.POST("/users/:id", handlerTest1)
+	assert.Panics(t, func() { router.Route("/other", http.MethodPost).Name("user") })
+	assert.NotPanics(t, func() { router.Route("/users/:id", http.MethodPost).Name("user") })
+}
+
+func TestWritePathBuilders(t *testing.T) {
+	router := New()
+	router.GET("/users/:id/files/*type", handlerTest1)
+	router.Route("/users/:id/files/*type", http.MethodGet).Name("user-file")
+	router.Match([]string{http.MethodGet, http.MethodPost}, "/", handlerTest1)
+	router.Route("/").Name("home")
+	router.GET("/unnamed", handlerTest1)
+	router.GET("/files/*/meta/*/raw", handlerTest1)
+	router.Route("/files/*/meta/*/raw", http.MethodGet).Name("meta")
+
+	var sb strings.Builder
+	require.NoError(t, router.WritePathBuilders(&sb, "paths"))
+	src := sb.String()
+	assert.Contains(t, src, "func HomePath() string {")
+	assert.Contains(t, src, `return "/users/" + url.PathEscape(id) + "/files/" + escapeCatchAll(type_)`)
+	assert.Contains(t, src, `func MetaPath(wildcard1, wildcard2 string) string {`)
+	assert.NotContains(t, src, "unnamed")
+	_, err := parser.ParseFile(token.NewFileSet(), "paths.go", src, 0)
+	require.NoError(t, err)
+
+	docs := router.NamedRoutes()
+	require.Len(t, docs, 3)
+	assert.Equal(t, "home", docs[0].Name)
+	assert.Equal(t, "meta", docs[1].Name)
+	assert.Equal(t, []RouteParam{{Name: "*"}, {Name: "*"}}, docs[1].Params)
+	assert.Equal(t, "user-file", docs[2].Name)
+}
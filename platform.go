@@ -0,0 +1,216 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Platform is the profile of a platform in front of the engine, such as a CDN
+// or a load balancer: the headers it sets are trusted on the requests coming
+// from its proxies, to derive the client IP, scheme and host. See
+// Engine.SetPlatform.
+type Platform struct {
+	// Name names the platform in the errors.
+	Name string
+
+	// ClientIPHeader is the header carrying the client IP. Required.
+	ClientIPHeader string
+
+	// ClientIPIndex is the position of the client IP in ClientIPHeader when the
+	// platform appends to a list, such as X-Forwarded-For, counted from the
+	// right from 1: the entries on the left may be forged by the clients.
+	// Optional. Default value is 0, the header holding a single IP.
+	ClientIPIndex int
+
+	// SchemeHeader is the header carrying the scheme of the client request,
+	// such as X-Forwarded-Proto. Optional.
+	SchemeHeader string
+
+	// HostHeader is the header carrying the host of the client request, such
+	// as X-Forwarded-Host. Optional: the platform keeps the Host header.
+	HostHeader string
+
+	// Proxies are the IP addresses and CIDRs of the proxies of the platform:
+	// the headers of the requests coming from other addresses are ignored.
+	// Required.
+	Proxies []string
+}
+
+// CloudflarePlatform returns the profile of Cloudflare's CDN, with the IP
+// ranges it published in 2024.
+func CloudflarePlatform() Platform {
+	return Platform{
+		Name:           "cloudflare",
+		ClientIPHeader: PlatformCloudflare,
+		SchemeHeader:   "X-Forwarded-Proto",
+		Proxies: []string{
+			"173.245.48.0/20", "103.21.244.0/22", "103.22.200.0/22", "103.31.4.0/22",
+			"141.101.64.0/18", "108.162.192.0/18", "190.93.240.0/20", "188.114.96.0/20",
+			"197.234.240.0/22", "198.41.128.0/17", "162.158.0.0/15", "104.16.0.0/13",
+			"104.24.0.0/14", "172.64.0.0/13", "131.0.72.0/22",
+			"2400:cb00::/32", "2606:4700::/32", "2803:f800::/32", "2405:b500::/32",
+			"2405:8100::/32", "2a06:98c0::/29", "2c0f:f248::/32",
+		},
+	}
+}
+
+// GCPLoadBalancerPlatform returns the profile of the external Application
+// Load Balancers of Google Cloud, which append the client IP and their own to
+// X-Forwarded-For.
+func GCPLoadBalancerPlatform() Platform {
+	return Platform{
+		Name:           "gcp-lb",
+		ClientIPHeader: "X-Forwarded-For",
+		ClientIPIndex:  2,
+		SchemeHeader:   "X-Forwarded-Proto",
+		Proxies:        []string{"35.191.0.0/16", "130.211.0.0/22"},
+	}
+}
+
+// AWSALBPlatform returns the profile of the Application Load Balancers of AWS,
+// which append the client IP to X-Forwarded-For. Its Proxies must be set to
+// the subnets of the load balancer.
+func AWSALBPlatform() Platform {
+	return Platform{
+		Name:           "aws-alb",
+		ClientIPHeader: "X-Forwarded-For",
+		ClientIPIndex:  1,
+		SchemeHeader:   "X-Forwarded-Proto",
+	}
+}
+
+// FlyPlatform returns the profile of Fly.io. Its Proxies must be set to the
+// addresses of the Fly proxies, such as the private network of the app.
+func FlyPlatform() Platform {
+	return Platform{
+		Name:           "fly",
+		ClientIPHeader: PlatformFlyIO,
+		SchemeHeader:   "X-Forwarded-Proto",
+	}
+}
+
+// trustedPlatform is a validated platform profile.
+type trustedPlatform struct {
+	Platform
+	cidrs []*net.IPNet
+}
+
+// SetPlatform trusts the headers of the platform in front of the engine for
+// Context.ClientIP, Context.Scheme and Context.Host. The client IP of the
+// requests it does not vouch for is their remote address: TrustedPlatform and
+// the trusted proxies are ignored. It fails if the profile is invalid, leaving
+// the previous one in place. A nil profile removes it:
+//
+//	alb := gin.AWSALBPlatform()
+//	alb.Proxies = []string{"10.0.0.0/16"}
+//	if err := router.SetPlatform(&alb); err != nil {
+//	    log.Fatal(err)
+//	}
+func (engine *Engine) SetPlatform(p *Platform) error {
+	if p == nil {
+		engine.platform = nil
+		return nil
+	}
+	tp, err := newTrustedPlatform(*p)
+	if err != nil {
+		return err
+	}
+	engine.platform = tp
+	return nil
+}
+
+func newTrustedPlatform(p Platform) (*trustedPlatform, error) {
+	name := p.Name
+	if name == "" {
+		name = "unnamed"
+	}
+	switch {
+	case p.ClientIPHeader == "":
+		return nil, fmt.Errorf("platform %s: no client IP header", name)
+	case p.ClientIPIndex < 0:
+		return nil, fmt.Errorf("platform %s: negative client IP index", name)
+	case len(p.Proxies) == 0:
+		return nil, fmt.Errorf("platform %s: no proxies", name)
+	}
+	cidrs, err := parseCIDRs(p.Proxies)
+	if err != nil {
+		return nil, fmt.Errorf("platform %s: %w", name, err)
+	}
+	p.Proxies = append([]string(nil), p.Proxies...)
+	return &trustedPlatform{Platform: p, cidrs: cidrs}, nil
+}
+
+// checkPlatform reports the settings ignored because of the platform profile.
+func (engine *Engine) checkPlatform() error {
+	if engine.platform != nil && engine.TrustedPlatform != "" &&
+		engine.TrustedPlatform != engine.platform.ClientIPHeader {
+		return errors.New("TrustedPlatform " + engine.TrustedPlatform + " is ignored by the platform " + engine.platform.Name)
+	}
+	return nil
+}
+
+// trusts reports whether the request comes from a proxy of the platform.
+func (p *trustedPlatform) trusts(c *Context) bool {
+	ip := net.ParseIP(c.RemoteIP())
+	if ip == nil {
+		return false
+	}
+	for _, cidr := range p.cidrs {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the client IP set by the platform, or an empty string.
+func (p *trustedPlatform) clientIP(c *Context) string {
+	if !p.trusts(c) {
+		return ""
+	}
+	value := c.requestHeader(p.ClientIPHeader)
+	if p.ClientIPIndex > 0 {
+		items := strings.Split(value, ",")
+		if len(items) < p.ClientIPIndex {
+			return ""
+		}
+		value = items[len(items)-p.ClientIPIndex]
+	}
+	value = strings.TrimSpace(value)
+	if net.ParseIP(value) == nil {
+		return ""
+	}
+	return value
+}
+
+// Scheme returns the scheme of the request, "https" or "http", as set by the
+// platform of Engine.SetPlatform if the request comes from its proxies.
+func (c *Context) Scheme() string {
+	if p := c.engine.platform; p != nil && p.SchemeHeader != "" && p.trusts(c) {
+		switch scheme := strings.ToLower(strings.TrimSpace(c.requestHeader(p.SchemeHeader))); scheme {
+		case "http", "https":
+			return scheme
+		}
+	}
+	if c.Request.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// Host returns the host of the request, as set by the platform of
+// Engine.SetPlatform if the request comes from its proxies.
+func (c *Context) Host() string {
+	if p := c.engine.platform; p != nil && p.HostHeader != "" && p.trusts(c) {
+		if host := strings.TrimSpace(c.requestHeader(p.HostHeader)); host != "" {
+			return host
+		}
+	}
+	return c.Request.Host
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnginePlatform(t *testing.T) {
+	router := New()
+	alb := AWSALBPlatform()
+	assert.EqualError(t, router.SetPlatform(&alb), "platform aws-alb: no proxies")
+	alb.Proxies = []string{"10.0.0.0/16", "bad"}
+	assert.EqualError(t, router.SetPlatform(&alb), "platform aws-alb: invalid IP address: bad")
+	assert.EqualError(t, router.SetPlatform(&Platform{Proxies: []string{"10.0.0.1"}}), "platform unnamed: no client IP header")
+	alb.Proxies = []string{"10.0.0.0/16"}
+	require.NoError(t, router.SetPlatform(&alb))
+	alb.Proxies[0] = "0.0.0.0/0"
+
+	var ip, scheme, host string
+	router.GET("/", func(c *Context) { ip, scheme, host = c.ClientIP(), c.Scheme(), c.Host() })
+	serve := func(remoteAddr string, headers map[string]string) {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+		req.RemoteAddr = remoteAddr
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	forwarded := map[string]string{"X-Forwarded-For": "1.1.1.1, 2.2.2.2", "X-Forwarded-Proto": "https"}
+	serve("10.0.1.1:1234", forwarded)
+	assert.Equal(t, "2.2.2.2", ip)
+	assert.Equal(t, "https", scheme)
+	assert.Equal(t, "example.com", host)
+
+	// the headers of the other addresses are not trusted
+	serve("192.168.0.1:1234", forwarded)
+	assert.Equal(t, "192.168.0.1", ip)
+	assert.Equal(t, "http", scheme)
+
+	gcp := GCPLoadBalancerPlatform()
+	gcp.HostHeader = "X-Forwarded-Host"
+	require.NoError(t, router.SetPlatform(&gcp))
+	serve("35.191.0.1:1234", map[string]string{"X-Forwarded-For": "3.3.3.3, 1.1.1.1, 2.2.2.2", "X-Forwarded-Host": "api.example.com"})
+	assert.Equal(t, "1.1.1.1", ip)
+	assert.Equal(t, "api.example.com", host)
+	serve("35.191.0.1:1234", map[string]string{"X-Forwarded-For": "2.2.2.2"})
+	assert.Equal(t, "35.191.0.1", ip)
+
+	cloudflare := CloudflarePlatform()
+	require.NoError(t, router.SetPlatform(&cloudflare))
+	serve("[2606:4700::1]:1234", map[string]string{PlatformCloudflare: "4.4.4.4"})
+	assert.Equal(t, "4.4.4.4", ip)
+	serve("[2606:4700::1]:1234", map[string]string{PlatformCloudflare: "not an ip"})
+	assert.Equal(t, "2606:4700::1", ip)
+
+	router.TrustedPlatform = PlatformFlyIO
+	assert.EqualError(t, router.Validate(), "platform: TrustedPlatform Fly-Client-IP is ignored by the platform cloudflare")
+	assert.Equal(t, router.platform, router.Clone().platform)
+	require.NoError(t, router.SetPlatform(nil))
+	assert.NoError(t, router.Validate())
+
+	c, _ := CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "https://example.com/", nil)
+	c.Request.TLS = &tls.ConnectionState{}
+	assert.Equal(t, "https", c.Scheme())
+}
@@ -0,0 +1,274 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Encoder is a content coding used to precompress static assets. Codings not
+// in the standard library, such as brotli or zstd, are plugged in with their
+// own packages:
+//
+//	gin.Encoder{Name: "br", Ext: ".br", New: func(w io.Writer) (io.WriteCloser, error) {
+//	    return brotli.NewWriterLevel(w, brotli.BestCompression), nil
+//	}}
+type Encoder struct {
+	// Name is the content coding, as found in Accept-Encoding.
+	Name string
+	// Ext is the file extension of the compressed assets in the cache dir.
+	Ext string
+	// New returns a writer compressing to w.
+	New func(w io.Writer) (io.WriteCloser, error)
+}
+
+// GzipEncoder is the gzip Encoder, at the best compression level.
+var GzipEncoder = Encoder{Name: "gzip", Ext: ".gz", New: func(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriterLevel(w, gzip.BestCompression)
+}}
+
+var defaultPrecompressExtensions = []string{
+	".html", ".htm", ".css", ".js", ".mjs", ".json", ".map", ".svg", ".txt", ".xml", ".wasm",
+}
+
+// PrecompressConfig defines the config of StaticPrecompressed.
+type PrecompressConfig struct {
+	// Encoders lists the content codings to precompress the assets with, in
+	// order of preference when the client accepts several of them equally.
+	// Optional. Default value is []Encoder{GzipEncoder}.
+	Encoders []Encoder
+
+	// CacheDir stores the compressed assets on disk instead of in memory. Assets
+	// compressed by a previous run and newer than their source are reused.
+	// Optional. Default value is "", keeping them in memory.
+	CacheDir string
+
+	// Extensions lists the file extensions of the assets to precompress.
+	// Optional. Default value is the common text, script and wasm extensions.
+	Extensions []string
+
+	// MinSize is the size under which assets are not compressed.
+	// Optional. Default value is 1024.
+	MinSize int64
+}
+
+// precompressedAsset holds the compressed variants of an asset, keyed by coding.
+type precompressedAsset struct {
+	contentType string
+	modTime     time.Time
+	data        map[string][]byte
+	files       map[string]string
+}
+
+// StaticPrecompressed works like Static, but compresses the assets of root with
+// each configured encoder once, at startup, and serves the best variant accepted
+// by the client, so that immutable assets cost no compression CPU per request.
+// Assets added to root afterwards are served uncompressed.
+//
+//	router.StaticPrecompressed("/assets", "./dist", gin.PrecompressConfig{CacheDir: "/var/cache/assets"})
+func (group *RouterGroup) StaticPrecompressed(relativePath, root string, conf PrecompressConfig) IRoutes {
+	if strings.Contains(relativePath, ":") || strings.Contains(relativePath, "*") {
+		panic("URL parameters can not be used when serving a static folder")
+	}
+	if len(conf.Encoders) == 0 {
+		conf.Encoders = []Encoder{GzipEncoder}
+	}
+	if conf.Extensions == nil {
+		conf.Extensions = defaultPrecompressExtensions
+	}
+	if conf.MinSize <= 0 {
+		conf.MinSize = 1024
+	}
+
+	assets, err := precompressDir(root, conf)
+	if err != nil {
+		panic(err)
+	}
+	fallback := group.createStaticHandler(relativePath, Dir(root, false))
+	handler := func(c *Context) {
+		asset, ok := assets[path.Clean("/"+c.Param("filepath"))]
+		if !ok {
+			fallback(c)
+			return
+		}
+		header := c.Writer.Header()
+		header.Add("Vary", "Accept-Encoding")
+		coding := negotiateEncoding(c.requestHeader("Accept-Encoding"), conf.Encoders, asset)
+		if coding == "" {
+			fallback(c)
+			return
+		}
+		header.Set("Content-Type", asset.contentType)
+		header.Set("Content-Encoding", coding)
+		name := c.Param("filepath")
+		if data, ok := asset.data[coding]; ok {
+			http.ServeContent(c.Writer, c.Request, name, asset.modTime, bytes.NewReader(data))
+			return
+		}
+		f, err := os.Open(asset.files[coding])
+		if err != nil {
+			header.Del("Content-Encoding")
+			fallback(c)
+			return
+		}
+		defer f.Close()
+		http.ServeContent(c.Writer, c.Request, name, asset.modTime, f)
+	}
+
+	urlPattern := path.Join(relativePath, "/*filepath")
+	group.register(http.MethodGet, urlPattern, HandlersChain{handler})
+	group.register(http.MethodHead, urlPattern, HandlersChain{handler})
+	return group.returnObj()
+}
+
+// precompressDir compresses the matching assets of root, keyed by their slash
+// separated path from root.
+func precompressDir(root string, conf PrecompressConfig) (map[string]*precompressedAsset, error) {
+	assets := make(map[string]*precompressedAsset)
+	err := filepath.WalkDir(root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		ext := filepath.Ext(name)
+		if !containsString(conf.Extensions, ext) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() < conf.MinSize {
+			return nil
+		}
+		rel, err := filepath.Rel(root, name)
+		if err != nil {
+			return err
+		}
+		src, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+
+		asset := &precompressedAsset{
+			contentType: mime.TypeByExtension(ext),
+			modTime:     info.ModTime(),
+			data:        make(map[string][]byte),
+			files:       make(map[string]string),
+		}
+		if asset.contentType == "" {
+			asset.contentType = http.DetectContentType(src)
+		}
+		for _, enc := range conf.Encoders {
+			if conf.CacheDir != "" {
+				cached := filepath.Join(conf.CacheDir, rel+enc.Ext)
+				if ci, err := os.Stat(cached); err == nil && !ci.ModTime().Before(info.ModTime()) {
+					if ci.Size() < info.Size() {
+						asset.files[enc.Name] = cached
+					}
+					continue
+				}
+			}
+			compressed, err := compressAsset(enc, src)
+			if err != nil {
+				return err
+			}
+			if conf.CacheDir != "" {
+				// Assets which do not shrink are cached as is, to be skipped on restart.
+				cached := filepath.Join(conf.CacheDir, rel+enc.Ext)
+				if err := os.MkdirAll(filepath.Dir(cached), 0o755); err != nil {
+					return err
+				}
+				if len(compressed) >= len(src) {
+					compressed = src
+				}
+				if err := os.WriteFile(cached, compressed, 0o644); err != nil { //nolint: gosec
+					return err
+				}
+				if len(compressed) < len(src) {
+					asset.files[enc.Name] = cached
+				}
+				continue
+			}
+			if len(compressed) < len(src) {
+				asset.data[enc.Name] = compressed
+			}
+		}
+		if len(asset.data)+len(asset.files) > 0 {
+			assets["/"+filepath.ToSlash(rel)] = asset
+		}
+		return nil
+	})
+	return assets, err
+}
+
+func compressAsset(enc Encoder, src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := enc.New(&buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(src); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// negotiateEncoding returns the coding of the asset variant preferred by the
+// Accept-Encoding header, or "" to serve it uncompressed.
+func negotiateEncoding(acceptEncoding string, encoders []Encoder, asset *precompressedAsset) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+	qs := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		qs[strings.ToLower(strings.TrimSpace(coding))] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, enc := range encoders {
+		if _, ok := asset.data[enc.Name]; !ok && asset.files[enc.Name] == "" {
+			continue
+		}
+		q, ok := qs[enc.Name]
+		if !ok {
+			q = qs["*"]
+		}
+		if q > bestQ {
+			best, bestQ = enc.Name, q
+		}
+	}
+	return best
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
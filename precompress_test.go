@@ -0,0 +1,115 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testAsset = strings.Repeat("body { color: red; }\n", 100)
+
+// fakeEncoder is a fake coding, to test the negotiation between codings.
+var fakeEncoder = Encoder{Name: "fake", Ext: ".fake", New: func(w io.Writer) (io.WriteCloser, error) {
+	return nopWriteCloser{w}, nil
+}}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func precompressRoot(t *testing.T) string {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "css"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "css", "app.css"), []byte(testAsset), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "small.js"), []byte("alert(1)"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "logo.png"), []byte(testAsset), 0o600))
+	return root
+}
+
+func gunzip(t *testing.T, body []byte) string {
+	r, err := gzip.NewReader(bytes.NewReader(body))
+	require.NoError(t, err)
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestStaticPrecompressed(t *testing.T) {
+	router := New()
+	router.StaticPrecompressed("/assets", precompressRoot(t), PrecompressConfig{})
+
+	w := PerformRequest(router, http.MethodGet, "/assets/css/app.css", header{Key: "Accept-Encoding", Value: "br, gzip;q=0.8"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	assert.Equal(t, "text/css; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Less(t, w.Body.Len(), len(testAsset))
+	assert.Equal(t, testAsset, gunzip(t, w.Body.Bytes()))
+
+	w = PerformRequest(router, http.MethodGet, "/assets/css/app.css", header{Key: "Accept-Encoding", Value: "gzip;q=0"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, testAsset, w.Body.String())
+
+	w = PerformRequest(router, http.MethodGet, "/assets/css/app.css")
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, testAsset, w.Body.String())
+
+	// too small or not compressible by extension
+	for _, p := range []string{"/assets/small.js", "/assets/logo.png"} {
+		w = PerformRequest(router, http.MethodGet, p, header{Key: "Accept-Encoding", Value: "gzip"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"), p)
+	}
+
+	w = PerformRequest(router, http.MethodGet, "/assets/missing.css", header{Key: "Accept-Encoding", Value: "gzip"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestStaticPrecompressedCacheDir(t *testing.T) {
+	root := precompressRoot(t)
+	cacheDir := t.TempDir()
+	conf := PrecompressConfig{CacheDir: cacheDir, Encoders: []Encoder{GzipEncoder, fakeEncoder}}
+
+	router := New()
+	router.StaticPrecompressed("/assets", root, conf)
+	cached, err := os.ReadFile(filepath.Join(cacheDir, "css", "app.css.gz"))
+	require.NoError(t, err)
+	assert.Equal(t, testAsset, gunzip(t, cached))
+
+	w := PerformRequest(router, http.MethodGet, "/assets/css/app.css", header{Key: "Accept-Encoding", Value: "*"})
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, cached, w.Body.Bytes())
+
+	// the fake coding does not shrink the asset, so it is never served
+	w = PerformRequest(router, http.MethodGet, "/assets/css/app.css", header{Key: "Accept-Encoding", Value: "fake"})
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+
+	// a later start reuses the cache
+	require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "css", "app.css.gz"), []byte("cached"), 0o600))
+	router = New()
+	router.StaticPrecompressed("/assets", root, conf)
+	w = PerformRequest(router, http.MethodGet, "/assets/css/app.css", header{Key: "Accept-Encoding", Value: "gzip"})
+	assert.Equal(t, "cached", w.Body.String())
+}
+
+func TestStaticPrecompressedPanics(t *testing.T) {
+	assert.Panics(t, func() {
+		New().StaticPrecompressed("/assets/:name", t.TempDir(), PrecompressConfig{})
+	})
+	assert.Panics(t, func() {
+		New().StaticPrecompressed("/assets", filepath.Join(t.TempDir(), "missing"), PrecompressConfig{})
+	})
+}
@@ -0,0 +1,212 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"os"
+	"runtime"
+	"runtime/metrics"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultPressureRecovery   = 0.9
+	defaultPressureInterval   = time.Second
+	defaultPressureRetryAfter = time.Second
+)
+
+// PressureConfig defines the config of a PressureGuard. A zero threshold is
+// not enforced.
+type PressureConfig struct {
+	// MaxRSS is the resident set size of the process, in bytes, above which
+	// requests are shed. Optional.
+	MaxRSS uint64
+
+	// MaxHeap is the size of the live and unswept heap objects, in bytes,
+	// above which requests are shed. Optional.
+	MaxHeap uint64
+
+	// MaxGoroutines is the number of goroutines above which requests are shed.
+	// Optional.
+	MaxGoroutines int
+
+	// Recovery is the ratio of the thresholds every value must fall under for
+	// the shedding to stop, so that the guard does not flap around them.
+	// Optional. Default value is 0.9.
+	Recovery float64
+
+	// Interval is the minimum time between two samples. Samples are taken by
+	// the requests, no goroutine is started. Optional. Default value is 1s.
+	Interval time.Duration
+
+	// RetryAfter is the delay advertised in the Retry-After header of the shed
+	// requests. Optional. Default value is 1s.
+	RetryAfter time.Duration
+
+	// Allow lists the routes never shed, such as the health checks and the
+	// admin endpoints, by full path. Optional.
+	Allow []string
+
+	// OnChange is called when the shedding starts or stops. Optional.
+	OnChange func(shedding bool, sample PressureSample)
+
+	// Skip indicates which requests are never shed. Optional.
+	Skip Skipper
+}
+
+// PressureSample is a measure of the resources of the process.
+type PressureSample struct {
+	RSS        uint64
+	Heap       uint64
+	Goroutines int
+}
+
+// PressureStats are the counters of a PressureGuard.
+type PressureStats struct {
+	Shedding bool
+	Shed     uint64
+	Last     PressureSample
+}
+
+// PressureGuard sheds the requests with 503 Service Unavailable while the
+// memory or the goroutines of the process exceed their thresholds, to protect
+// a gateway from running out of memory during traffic spikes.
+type PressureGuard struct {
+	conf   PressureConfig
+	allow  map[string]struct{}
+	sample func() PressureSample
+
+	shedding atomic.Bool
+	shed     uint64
+	next     atomic.Int64
+
+	mu   sync.Mutex
+	last PressureSample
+}
+
+// NewPressureGuard returns a guard enforcing the thresholds of conf.
+func NewPressureGuard(conf PressureConfig) *PressureGuard {
+	if conf.Recovery <= 0 || conf.Recovery > 1 {
+		conf.Recovery = defaultPressureRecovery
+	}
+	if conf.Interval <= 0 {
+		conf.Interval = defaultPressureInterval
+	}
+	if conf.RetryAfter <= 0 {
+		conf.RetryAfter = defaultPressureRetryAfter
+	}
+	g := &PressureGuard{
+		conf:   conf,
+		allow:  make(map[string]struct{}, len(conf.Allow)),
+		sample: samplePressure,
+	}
+	for _, path := range conf.Allow {
+		g.allow[path] = struct{}{}
+	}
+	return g
+}
+
+// Handler returns the middleware shedding the requests.
+func (g *PressureGuard) Handler() HandlerFunc {
+	return func(c *Context) {
+		now := c.now()
+		g.update(now)
+		if !g.shedding.Load() || (g.conf.Skip != nil && g.conf.Skip(c)) {
+			return
+		}
+		if _, ok := g.allow[c.FullPath()]; ok {
+			return
+		}
+		atomic.AddUint64(&g.shed, 1)
+		// the shedding can stop at the next sample at the earliest
+		c.AbortWithShed(Shed{RetryAfter: max(g.conf.RetryAfter, time.Duration(g.next.Load()-now.UnixNano()))})
+	}
+}
+
+// Stats returns a snapshot of the guard counters.
+func (g *PressureGuard) Stats() PressureStats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return PressureStats{
+		Shedding: g.shedding.Load(),
+		Shed:     atomic.LoadUint64(&g.shed),
+		Last:     g.last,
+	}
+}
+
+// update samples the resources if the interval elapsed, by a single request.
+func (g *PressureGuard) update(now time.Time) {
+	next := g.next.Load()
+	if now.UnixNano() < next || !g.next.CompareAndSwap(next, now.Add(g.conf.Interval).UnixNano()) {
+		return
+	}
+	sample := g.sample()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.last = sample
+
+	shedding := g.shedding.Load()
+	if shedding {
+		shedding = g.exceeds(sample, g.conf.Recovery)
+	} else {
+		shedding = g.exceeds(sample, 1)
+	}
+	if g.shedding.Swap(shedding) != shedding && g.conf.OnChange != nil {
+		g.conf.OnChange(shedding, sample)
+	}
+}
+
+// exceeds reports whether a value of the sample exceeds its threshold scaled by ratio.
+func (g *PressureGuard) exceeds(s PressureSample, ratio float64) bool {
+	over := func(value, limit uint64) bool {
+		return limit > 0 && float64(value) > float64(limit)*ratio
+	}
+	return over(s.RSS, g.conf.MaxRSS) ||
+		over(s.Heap, g.conf.MaxHeap) ||
+		over(uint64(s.Goroutines), uint64(g.conf.MaxGoroutines)) //nolint: gosec
+}
+
+// samplePressure measures the process without stopping the world, unlike
+// runtime.ReadMemStats.
+func samplePressure() PressureSample {
+	samples := []metrics.Sample{
+		{Name: "/memory/classes/heap/objects:bytes"},
+		{Name: "/memory/classes/total:bytes"},
+		{Name: "/memory/classes/heap/released:bytes"},
+	}
+	metrics.Read(samples)
+	s := PressureSample{
+		Heap:       samples[0].Value.Uint64(),
+		Goroutines: runtime.NumGoroutine(),
+	}
+	if rss, ok := readRSS(); ok {
+		s.RSS = rss
+	} else {
+		// the memory mapped by the runtime, without the one returned to the OS
+		s.RSS = samples[1].Value.Uint64() - samples[2].Value.Uint64()
+	}
+	return s
+}
+
+// readRSS reads the resident set size from /proc, on Linux.
+func readRSS() (uint64, bool) {
+	data, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0, false
+	}
+	fields := bytes.Fields(data)
+	if len(fields) < 2 {
+		return 0, false
+	}
+	pages, err := strconv.ParseUint(string(fields[1]), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return pages * uint64(os.Getpagesize()), true
+}
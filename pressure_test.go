@@ -0,0 +1,82 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPressureGuard(t *testing.T) {
+	var changes []bool
+	g := NewPressureGuard(PressureConfig{
+		MaxHeap:       1000,
+		MaxGoroutines: 100,
+		Interval:      time.Nanosecond,
+		RetryAfter:    1500 * time.Millisecond,
+		Allow:         []string{"/healthz"},
+		OnChange:      func(shedding bool, _ PressureSample) { changes = append(changes, shedding) },
+		Skip:          func(c *Context) bool { return c.GetHeader("X-Admin") != "" },
+	})
+	sample := PressureSample{Heap: 500, Goroutines: 10}
+	g.sample = func() PressureSample { return sample }
+
+	router := New()
+	router.Use(g.Handler())
+	router.GET("/api", handlerTest1)
+	router.GET("/healthz", handlerTest1)
+
+	w := PerformRequest(router, http.MethodGet, "/api")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	sample.Goroutines = 101
+	w = PerformRequest(router, http.MethodGet, "/api")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusOK, PerformRequest(router, http.MethodGet, "/healthz").Code)
+	assert.Equal(t, http.StatusOK, PerformRequest(router, http.MethodGet, "/api", header{"X-Admin", "1"}).Code)
+
+	// hysteresis: still shedding until every value is under 90% of its threshold
+	sample = PressureSample{Heap: 950, Goroutines: 95}
+	assert.Equal(t, http.StatusServiceUnavailable, PerformRequest(router, http.MethodGet, "/api").Code)
+	sample.Goroutines = 50
+	assert.Equal(t, http.StatusServiceUnavailable, PerformRequest(router, http.MethodGet, "/api").Code)
+	sample.Heap = 899
+	assert.Equal(t, http.StatusOK, PerformRequest(router, http.MethodGet, "/api").Code)
+
+	assert.Equal(t, []bool{true, false}, changes)
+	stats := g.Stats()
+	assert.False(t, stats.Shedding)
+	assert.Equal(t, uint64(3), stats.Shed)
+	assert.Equal(t, sample, stats.Last)
+}
+
+func TestPressureGuardInterval(t *testing.T) {
+	g := NewPressureGuard(PressureConfig{MaxGoroutines: 1, Interval: time.Hour})
+	samples := 0
+	g.sample = func() PressureSample { samples++; return PressureSample{Goroutines: 2} }
+
+	now := time.Now()
+	g.update(now)
+	g.update(now.Add(time.Minute))
+	assert.Equal(t, 1, samples)
+	assert.True(t, g.Stats().Shedding)
+	g.update(now.Add(time.Hour))
+	assert.Equal(t, 2, samples)
+}
+
+func TestSamplePressure(t *testing.T) {
+	s := samplePressure()
+	assert.Positive(t, s.RSS)
+	assert.Positive(t, s.Heap)
+	assert.Positive(t, s.Goroutines)
+
+	g := NewPressureGuard(PressureConfig{})
+	assert.Equal(t, defaultPressureRecovery, g.conf.Recovery)
+	assert.False(t, g.exceeds(s, 1))
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"cmp"
+	"math"
+	"slices"
+)
+
+// Priority sets the priority of the routes, 0 by default. When routes overlap,
+// the lookup tries the ones of higher priority first, instead of the static
+// segments before the params:
+//
+//	router.GET("/special", special)
+//	router.GET("/:page", page)
+//	router.Route("/:page").Priority(100)
+//
+// serves /special with page, and with special only if the page route does not
+// match, e.g. if a constraint of its params rejects the value. The params with
+// other constraints at the same position are tried by descending priority too,
+// the unconstrained one last. The priority of a wildcard is the highest of the
+// routes below it, its alternatives included. The trailing slash and case
+// fixes ignore the priorities.
+func (h *RouteHandle) Priority(p int) *RouteHandle {
+	engine := h.engine
+	return h.annotate(func(route *Route) {
+		switch {
+		case route.priority == 0 && p != 0:
+			engine.prioritized++
+		case route.priority != 0 && p == 0:
+			engine.prioritized--
+		}
+		route.priority = p
+		engine.prioritize(route.Host, route.Method)
+	})
+}
+
+// prioritize orders the tree of the method for the host pattern by the
+// priorities of its routes.
+func (engine *Engine) prioritize(host, method string) {
+	root := engine.treesFor(host).get(method)
+	if root == nil {
+		return
+	}
+	priorities := make(map[string]int)
+	for _, route := range engine.routes {
+		if route.Host == host && route.Method == method && route.priority != 0 {
+			priorities[route.Path] = route.priority
+		}
+	}
+	root.prioritize(priorities)
+}
+
+// prioritize sets the yield flag of the static children of lower priority than
+// their wildcard sibling, and orders the params with constraints by priority.
+// It returns the highest priority of the routes of the subtree.
+func (n *node) prioritize(priorities map[string]int) int {
+	highest := math.MinInt
+	if n.handlers != nil {
+		highest = priorities[n.fullPath]
+	}
+	static := n.children
+	if n.wildChild {
+		static = static[:len(static)-1]
+	}
+	hints := make([]int, len(static))
+	for i, child := range static {
+		hints[i] = child.prioritize(priorities)
+		highest = max(highest, hints[i])
+	}
+	if !n.wildChild {
+		return highest
+	}
+
+	type alternative struct {
+		node *node
+		hint int
+	}
+	var alts []alternative
+	wild := math.MinInt
+	for alt := n.children[len(n.children)-1]; alt != nil; alt = alt.alt {
+		hint := alt.prioritize(priorities)
+		alts = append(alts, alternative{alt, hint})
+		wild = max(wild, hint)
+	}
+	slices.SortStableFunc(alts, func(a, b alternative) int {
+		if (a.node.constraint == nil) != (b.node.constraint == nil) {
+			if a.node.constraint == nil {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(b.hint, a.hint)
+	})
+	for i := range alts {
+		alts[i].node.alt = nil
+		if i+1 < len(alts) {
+			alts[i].node.alt = alts[i+1].node
+		}
+	}
+	n.children[len(n.children)-1] = alts[0].node
+
+	for i, child := range static {
+		child.yield = wild > hints[i]
+	}
+	return max(highest, wild)
+}
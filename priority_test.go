@@ -0,0 +1,67 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoutePriority(t *testing.T) {
+	router := New()
+	respond := func(name string) HandlerFunc {
+		return func(c *Context) { c.String(http.StatusOK, name+" "+c.Params.ByName("v")) }
+	}
+	router.GET("/special", respond("special"))
+	router.GET("/docs/intro", respond("intro"))
+	router.GET("/:v", respond("page"))
+	router.Route("/:v", http.MethodGet).Priority(100)
+	router.GET("/items/:id|int", respond("id"))
+	router.GET("/items/:v|int", respond("number"))
+	router.Route("/items/:v|int", http.MethodGet).Priority(1)
+	router.GET("/items/:v|alpha", respond("name"))
+	router.GET("/files/readme", respond("readme"))
+	router.GET("/files/plain", respond("plain"))
+	router.GET("/files/:v/list", respond("list"))
+	router.Route("/files/:v/list", http.MethodGet).Priority(5)
+	router.GET("/about", respond("about"))
+
+	for path, body := range map[string]string{
+		"/special": "page special",
+		"/other":   "page other",
+		// the static routes are served once the wildcard fails
+		"/docs/intro":   "intro ",
+		"/about":        "page about",
+		"/items/1":      "number 1",
+		"/items/old":    "name old",
+		"/files/readme": "readme ",
+		"/files/plain":  "plain ",
+		"/files/x/list": "list x",
+	} {
+		w := PerformRequest(router, http.MethodGet, path)
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.Equal(t, body, w.Body.String(), path)
+	}
+
+	// the priorities are kept by the clones and can be reset
+	clone := router.Clone()
+	router.GET("/:v/:w", respond("pair"))
+	router.GET("/news", respond("news"))
+	router.Route("/news", http.MethodGet).Priority(200)
+	assert.Equal(t, "news ", PerformRequest(router, http.MethodGet, "/news").Body.String())
+	assert.Equal(t, "page news", PerformRequest(clone, http.MethodGet, "/news").Body.String())
+	router.GET("/x", respond("x"))
+	assert.Equal(t, "page x", PerformRequest(router, http.MethodGet, "/x").Body.String())
+	assert.Equal(t, "pair a", PerformRequest(router, http.MethodGet, "/a/b").Body.String())
+
+	router = New()
+	router.GET("/special", respond("special"))
+	router.GET("/:v", respond("page"))
+	router.Route("/:v", http.MethodGet).Priority(10).Priority(0)
+	assert.Equal(t, "special ", PerformRequest(router, http.MethodGet, "/special").Body.String())
+	assert.Zero(t, router.table.Load().prioritized)
+}
@@ -0,0 +1,244 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// FilterOp is a comparison operator of a query filter.
+type FilterOp string
+
+// Filter operators supported by the query DSL.
+const (
+	FilterEq       FilterOp = "eq"
+	FilterNe       FilterOp = "ne"
+	FilterLt       FilterOp = "lt"
+	FilterLte      FilterOp = "lte"
+	FilterGt       FilterOp = "gt"
+	FilterGte      FilterOp = "gte"
+	FilterIn       FilterOp = "in"
+	FilterContains FilterOp = "contains"
+	FilterPrefix   FilterOp = "prefix"
+)
+
+var filterOps = map[FilterOp]bool{
+	FilterEq: true, FilterNe: true, FilterLt: true, FilterLte: true, FilterGt: true,
+	FilterGte: true, FilterIn: true, FilterContains: true, FilterPrefix: true,
+}
+
+const (
+	defaultQueryMaxSort    = 5
+	defaultQueryMaxFilters = 10
+	maxQueryFieldLen       = 64
+)
+
+// QueryDSLConfig defines the query parameters and the allowlists of the sort and
+// filter query DSL.
+type QueryDSLConfig struct {
+	// SortParam is the query parameter of the sort expression.
+	// Optional. Default value is "sort".
+	SortParam string
+
+	// FilterParam is the query parameter of the filter expressions.
+	// Optional. Default value is "filter".
+	FilterParam string
+
+	// SortFields lists the fields allowed in the sort expression.
+	// Sorting is rejected if it is empty.
+	SortFields []string
+
+	// FilterFields lists the fields allowed in filter expressions, with the
+	// operators allowed for each of them; a nil list allows every operator.
+	// Filtering is rejected if it is empty.
+	FilterFields map[string][]FilterOp
+
+	// MaxSort caps the number of sort fields. Optional. Default value is 5.
+	MaxSort int
+
+	// MaxFilters caps the number of filters. Optional. Default value is 10.
+	MaxFilters int
+}
+
+// QuerySort is a field of a sort expression.
+type QuerySort struct {
+	Field string
+	Desc  bool
+}
+
+// QueryFilter is a filter expression. Values holds the values of the "in"
+// operator, separated by '|' in the query; it holds Value for other operators.
+type QueryFilter struct {
+	Field  string
+	Op     FilterOp
+	Value  string
+	Values []string
+}
+
+// QueryDSL is the parsed sort and filter expressions of a request. Fields and
+// operators are guaranteed to be allowlisted, values are raw strings that must
+// still be passed as parameters, never interpolated, when building queries.
+type QueryDSL struct {
+	Sort    []QuerySort
+	Filters []QueryFilter
+}
+
+// QueryDSLError is returned when a sort or filter expression is invalid or not allowed.
+type QueryDSLError struct {
+	Param string
+	Expr  string
+	Msg   string
+}
+
+func (e *QueryDSLError) Error() string {
+	return fmt.Sprintf("invalid %s expression %q: %s", e.Param, e.Expr, e.Msg)
+}
+
+// ParseQueryDSL parses the sort expression, e.g. "-created_at,name", and the
+// filter expressions, e.g. "status:eq:active", checking them against the
+// allowlists of conf. Each filter expression may hold several comma separated
+// filters.
+func ParseQueryDSL(sort string, filters []string, conf QueryDSLConfig) (QueryDSL, error) {
+	conf.setDefaults()
+	var dsl QueryDSL
+	if sort != "" {
+		allowed := make(map[string]bool, len(conf.SortFields))
+		for _, field := range conf.SortFields {
+			allowed[field] = true
+		}
+		for _, expr := range strings.Split(sort, ",") {
+			s, err := parseQuerySort(expr, allowed)
+			if err != nil {
+				err.Param = conf.SortParam
+				return QueryDSL{}, err
+			}
+			if len(dsl.Sort) == conf.MaxSort {
+				return QueryDSL{}, &QueryDSLError{conf.SortParam, sort, fmt.Sprintf("more than %d fields", conf.MaxSort)}
+			}
+			dsl.Sort = append(dsl.Sort, s)
+		}
+	}
+	for _, filter := range filters {
+		if filter == "" {
+			continue
+		}
+		for _, expr := range strings.Split(filter, ",") {
+			f, err := parseQueryFilter(expr, conf.FilterFields)
+			if err != nil {
+				err.Param = conf.FilterParam
+				return QueryDSL{}, err
+			}
+			if len(dsl.Filters) == conf.MaxFilters {
+				return QueryDSL{}, &QueryDSLError{conf.FilterParam, filter, fmt.Sprintf("more than %d filters", conf.MaxFilters)}
+			}
+			dsl.Filters = append(dsl.Filters, f)
+		}
+	}
+	return dsl, nil
+}
+
+func (conf *QueryDSLConfig) setDefaults() {
+	if conf.SortParam == "" {
+		conf.SortParam = "sort"
+	}
+	if conf.FilterParam == "" {
+		conf.FilterParam = "filter"
+	}
+	if conf.MaxSort <= 0 {
+		conf.MaxSort = defaultQueryMaxSort
+	}
+	if conf.MaxFilters <= 0 {
+		conf.MaxFilters = defaultQueryMaxFilters
+	}
+}
+
+func parseQuerySort(expr string, allowed map[string]bool) (QuerySort, *QueryDSLError) {
+	s := QuerySort{Field: strings.TrimSpace(expr)}
+	switch {
+	case strings.HasPrefix(s.Field, "-"):
+		s.Field, s.Desc = s.Field[1:], true
+	case strings.HasPrefix(s.Field, "+"):
+		s.Field = s.Field[1:]
+	}
+	if err := checkQueryField(s.Field); err != "" {
+		return QuerySort{}, &QueryDSLError{Expr: expr, Msg: err}
+	}
+	if !allowed[s.Field] {
+		return QuerySort{}, &QueryDSLError{Expr: expr, Msg: "field not sortable"}
+	}
+	return s, nil
+}
+
+func parseQueryFilter(expr string, allowed map[string][]FilterOp) (QueryFilter, *QueryDSLError) {
+	parts := strings.SplitN(strings.TrimSpace(expr), ":", 3)
+	if len(parts) != 3 {
+		return QueryFilter{}, &QueryDSLError{Expr: expr, Msg: "expected field:op:value"}
+	}
+	f := QueryFilter{Field: parts[0], Op: FilterOp(parts[1]), Value: parts[2]}
+	if err := checkQueryField(f.Field); err != "" {
+		return QueryFilter{}, &QueryDSLError{Expr: expr, Msg: err}
+	}
+	if !filterOps[f.Op] {
+		return QueryFilter{}, &QueryDSLError{Expr: expr, Msg: "unknown operator"}
+	}
+	ops, ok := allowed[f.Field]
+	if !ok {
+		return QueryFilter{}, &QueryDSLError{Expr: expr, Msg: "field not filterable"}
+	}
+	if ops != nil && !containsFilterOp(ops, f.Op) {
+		return QueryFilter{}, &QueryDSLError{Expr: expr, Msg: "operator not allowed on field"}
+	}
+	if f.Op == FilterIn {
+		f.Values = strings.Split(f.Value, "|")
+	} else {
+		f.Values = []string{f.Value}
+	}
+	return f, nil
+}
+
+// checkQueryField returns why field is not a valid field name, if it is not.
+// Field names are made of letters, digits, '_' and '.' only.
+func checkQueryField(field string) string {
+	if field == "" {
+		return "empty field"
+	}
+	if len(field) > maxQueryFieldLen {
+		return "field name too long"
+	}
+	for _, r := range field {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '.') {
+			return "invalid field name"
+		}
+	}
+	return ""
+}
+
+func containsFilterOp(ops []FilterOp, op FilterOp) bool {
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// ShouldBindQueryDSL parses the sort and filter query parameters of the request,
+// see ParseQueryDSL.
+func (c *Context) ShouldBindQueryDSL(conf QueryDSLConfig) (QueryDSL, error) {
+	conf.setDefaults()
+	return ParseQueryDSL(c.Query(conf.SortParam), c.QueryArray(conf.FilterParam), conf)
+}
+
+// BindQueryDSL is like ShouldBindQueryDSL, but aborts the request with
+// status 400 if the expressions are invalid or not allowed.
+func (c *Context) BindQueryDSL(conf QueryDSLConfig) (QueryDSL, error) {
+	dsl, err := c.ShouldBindQueryDSL(conf)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err).SetType(ErrorTypeBind) //nolint: errcheck
+	}
+	return dsl, err
+}
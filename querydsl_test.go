@@ -0,0 +1,96 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testQueryDSLConfig = QueryDSLConfig{
+	SortFields: []string{"created_at", "name"},
+	FilterFields: map[string][]FilterOp{
+		"status": {FilterEq, FilterNe, FilterIn},
+		"age":    nil,
+	},
+}
+
+func TestParseQueryDSL(t *testing.T) {
+	dsl, err := ParseQueryDSL("-created_at,+name", []string{"status:eq:active,age:gte:18", "status:in:a|b"}, testQueryDSLConfig)
+	require.NoError(t, err)
+	assert.Equal(t, []QuerySort{{Field: "created_at", Desc: true}, {Field: "name"}}, dsl.Sort)
+	assert.Equal(t, []QueryFilter{
+		{Field: "status", Op: FilterEq, Value: "active", Values: []string{"active"}},
+		{Field: "age", Op: FilterGte, Value: "18", Values: []string{"18"}},
+		{Field: "status", Op: FilterIn, Value: "a|b", Values: []string{"a", "b"}},
+	}, dsl.Filters)
+
+	dsl, err = ParseQueryDSL("", nil, testQueryDSLConfig)
+	require.NoError(t, err)
+	assert.Empty(t, dsl.Sort)
+	assert.Empty(t, dsl.Filters)
+
+	// values may hold colons
+	dsl, err = ParseQueryDSL("", []string{"age:eq:10:30"}, testQueryDSLConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "10:30", dsl.Filters[0].Value)
+}
+
+func TestParseQueryDSLErrors(t *testing.T) {
+	tests := []struct {
+		sort    string
+		filters []string
+		msg     string
+	}{
+		{"password", nil, `invalid sort expression "password": field not sortable`},
+		{"name;drop table", nil, `invalid sort expression "name;drop table": invalid field name`},
+		{"-", nil, `invalid sort expression "-": empty field`},
+		{"name,name,name,name,name,name", nil, `invalid sort expression "name,name,name,name,name,name": more than 5 fields`},
+		{"", []string{"status"}, `invalid filter expression "status": expected field:op:value`},
+		{"", []string{"status:like:a"}, `invalid filter expression "status:like:a": unknown operator`},
+		{"", []string{"status:gt:a"}, `invalid filter expression "status:gt:a": operator not allowed on field`},
+		{"", []string{"role:eq:admin"}, `invalid filter expression "role:eq:admin": field not filterable`},
+		{"", []string{"st atus:eq:a"}, `invalid filter expression "st atus:eq:a": invalid field name`},
+	}
+	for _, tt := range tests {
+		_, err := ParseQueryDSL(tt.sort, tt.filters, testQueryDSLConfig)
+		var dslErr *QueryDSLError
+		require.True(t, errors.As(err, &dslErr), tt.msg)
+		assert.Equal(t, tt.msg, err.Error())
+	}
+
+	conf := testQueryDSLConfig
+	conf.MaxFilters = 1
+	_, err := ParseQueryDSL("", []string{"age:gt:1", "age:lt:9"}, conf)
+	assert.EqualError(t, err, `invalid filter expression "age:lt:9": more than 1 filters`)
+}
+
+func TestContextBindQueryDSL(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodGet, "/?order=-name&filter=status:ne:gone&filter=age:lt:9", nil)
+
+	conf := testQueryDSLConfig
+	conf.SortParam = "order"
+	dsl, err := c.ShouldBindQueryDSL(conf)
+	require.NoError(t, err)
+	assert.Equal(t, []QuerySort{{Field: "name", Desc: true}}, dsl.Sort)
+	assert.Len(t, dsl.Filters, 2)
+	assert.False(t, c.IsAborted())
+
+	w = httptest.NewRecorder()
+	c, _ = CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodGet, "/?sort=secret", nil)
+	_, err = c.BindQueryDSL(testQueryDSLConfig)
+	require.Error(t, err)
+	assert.True(t, c.IsAborted())
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, ErrorTypeBind, c.Errors.Last().Type)
+}
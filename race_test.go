@@ -0,0 +1,11 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build race
+
+package gin
+
+// raceEnabled reports whether the tests run with the race detector, which
+// makes allocations of its own.
+const raceEnabled = true
@@ -70,30 +70,34 @@ func CustomRecoveryWithWriter(out io.Writer, handle RecoveryFunc) HandlerFunc {
 					}
 				}
 				if logger != nil {
-					stack := stack(3)
+					stack := Redactions.Redact(string(stack(3)))
 					httpRequest, _ := httputil.DumpRequest(c.Request, false)
 					headers := strings.Split(string(httpRequest), "\r\n")
 					for idx, header := range headers {
 						current := strings.Split(header, ":")
-						if current[0] == "Authorization" {
+						if idx > 0 && len(current) > 1 && Redactions.Header(current[0]) {
 							headers[idx] = current[0] + ": *"
 						}
 					}
-					headersToStr := strings.Join(headers, "\r\n")
+					headersToStr := Redactions.Redact(strings.Join(headers, "\r\n"))
+					message := Redactions.Redact(fmt.Sprint(err))
 					if brokenPipe {
-						logger.Printf("%s\n%s%s", err, headersToStr, reset)
+						logger.Printf("%s\n%s%s", message, headersToStr, reset)
 					} else if IsDebugging() {
 						logger.Printf("[Recovery] %s panic recovered:\n%s\n%s\n%s%s",
-							timeFormat(time.Now()), headersToStr, err, stack, reset)
+							timeFormat(time.Now()), headersToStr, message, stack, reset)
 					} else {
 						logger.Printf("[Recovery] %s panic recovered:\n%s\n%s%s",
-							timeFormat(time.Now()), err, stack, reset)
+							timeFormat(time.Now()), message, stack, reset)
 					}
 				}
 				if brokenPipe {
 					// If the connection is dead, we can't write a status to it.
 					c.Error(err.(error)) //nolint: errcheck
 					c.Abort()
+				} else if c.hijacked {
+					// The connection belongs to the handler which hijacked it.
+					c.Abort()
 				} else {
 					handle(c, err)
 				}
@@ -0,0 +1,64 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import "sort"
+
+// RouteRedirects counts the requests of a route whose path had to be fixed, as
+// reported by Engine.RedirectReport.
+type RouteRedirects struct {
+	Method string `json:"method"`
+	Host   string `json:"host,omitempty"`
+	Path   string `json:"path"`
+	// TrailingSlash counts the requests redirected to the route, or served by
+	// it with TrailingSlashMatch, after adding or removing a trailing slash.
+	TrailingSlash uint64 `json:"trailingSlash"`
+	// CaseFix counts the requests served by the route of a case-insensitive
+	// group whose path had a different case, and the ones redirected to it by
+	// RedirectFixedPath.
+	CaseFix uint64 `json:"caseFix"`
+}
+
+// RedirectReport returns the routes whose requests had their path fixed by
+// the trailing slash policies, the case-insensitive groups or
+// RedirectFixedPath, the most fixed first, with the number of fixes since the
+// start. It finds the routes still requested with wrong URLs before turning
+// their redirects off:
+//
+//	for _, r := range router.RedirectReport() {
+//	    log.Printf("%s %s: %d slash, %d case fixes", r.Method, r.Path, r.TrailingSlash, r.CaseFix)
+//	}
+func (engine *Engine) RedirectReport() []RouteRedirects {
+	var report []RouteRedirects
+	for _, route := range engine.table.Load().routes {
+		r := RouteRedirects{
+			Method:        route.Method,
+			Host:          route.Host,
+			Path:          route.Path,
+			TrailingSlash: route.slashFixes.Load(),
+			CaseFix:       route.caseFixes.Load(),
+		}
+		if r.TrailingSlash > 0 || r.CaseFix > 0 {
+			report = append(report, r)
+		}
+	}
+	sort.SliceStable(report, func(i, j int) bool {
+		return report[i].TrailingSlash+report[i].CaseFix > report[j].TrailingSlash+report[j].CaseFix
+	})
+	return report
+}
+
+// countFixedPath counts the redirect of RedirectFixedPath to the route of the
+// tree matching the fixed path of the request.
+func (engine *Engine) countFixedPath(c *Context, root *node, httpMethod string) {
+	*c.skippedNodes = (*c.skippedNodes)[:0]
+	value := root.getValue(c.Request.URL.Path, nil, c.skippedNodes, false)
+	if value.handlers == nil {
+		return
+	}
+	if route := engine.table.Load().routeIndex[routeKey("", httpMethod, value.fullPath)]; route != nil {
+		route.caseFixes.Add(1)
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngineRedirectReport(t *testing.T) {
+	router := New()
+	router.RedirectFixedPath = true
+	router.GET("/users", handlerTest1)
+	router.GET("/docs", handlerTest1)
+	router.GET("/status", handlerTest1)
+	api := router.Group("/api").CaseInsensitive(true).TrailingSlash(TrailingSlashMatch)
+	api.GET("/items", handlerTest1)
+
+	assert.Empty(t, router.RedirectReport())
+
+	assert.Equal(t, http.StatusMovedPermanently, PerformRequest(router, http.MethodGet, "/users/").Code)
+	assert.Equal(t, http.StatusMovedPermanently, PerformRequest(router, http.MethodGet, "/DOCS").Code)
+	assert.Equal(t, http.StatusOK, PerformRequest(router, http.MethodGet, "/api/items/").Code)
+	assert.Equal(t, http.StatusOK, PerformRequest(router, http.MethodGet, "/API/Items").Code)
+	assert.Equal(t, http.StatusOK, PerformRequest(router, http.MethodGet, "/Api/items").Code)
+	assert.Equal(t, http.StatusOK, PerformRequest(router, http.MethodGet, "/status").Code)
+
+	assert.Equal(t, []RouteRedirects{
+		{Method: http.MethodGet, Path: "/api/items", TrailingSlash: 1, CaseFix: 2},
+		{Method: http.MethodGet, Path: "/users", TrailingSlash: 1},
+		{Method: http.MethodGet, Path: "/docs", CaseFix: 1},
+	}, router.RedirectReport())
+
+	// the counters survive the copy-on-write of the routes while serving
+	router.GET("/health", handlerTest1)
+	assert.Len(t, router.RedirectReport(), 3)
+}
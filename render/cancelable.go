@@ -0,0 +1,132 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"net/http"
+	"reflect"
+
+	"github.com/jialequ/mpgw/internal/json"
+)
+
+// cancelChunkSize is the size of the chunks written by CancelableJSON, the
+// context is checked before each of them.
+const cancelChunkSize = 32 << 10
+
+// CancelableJSON is JSON rendering stopping when Context is done, such as when
+// the client of the request went away. The top level slices and arrays are
+// encoded element by element, and the output is written in chunks, checking
+// Context in between, so that the large responses of the abandoned requests
+// stop using CPU. The output is the same as JSON.
+type CancelableJSON struct {
+	Context context.Context
+	Data    any
+}
+
+type jsonMarshaler interface {
+	MarshalJSON() ([]byte, error)
+}
+
+// Render (CancelableJSON) encodes and writes the data in chunks, and returns
+// the error of Context when it is done before the end.
+func (r CancelableJSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	if err := r.Context.Err(); err != nil {
+		return err
+	}
+	cw := &cancelWriter{ctx: r.Context, w: w}
+
+	v := reflect.ValueOf(r.Data)
+	if !encodesElements(v) {
+		jsonBytes, err := json.Marshal(r.Data)
+		if err != nil {
+			return err
+		}
+		_, err = cw.Write(jsonBytes)
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i := 0; i < v.Len(); i++ {
+		if err := r.Context.Err(); err != nil {
+			return err
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		elem := v.Index(i)
+		if elem.CanAddr() {
+			// the elements of the slices are addressable, as in json.Marshal
+			// their pointer methods are used
+			elem = elem.Addr()
+		}
+		jsonBytes, err := json.Marshal(elem.Interface())
+		if err != nil {
+			return err
+		}
+		buf.Write(jsonBytes)
+		if buf.Len() >= cancelChunkSize {
+			if _, err = cw.Write(buf.Bytes()); err != nil {
+				return err
+			}
+			buf.Reset()
+		}
+	}
+	buf.WriteByte(']')
+	_, err := cw.Write(buf.Bytes())
+	return err
+}
+
+// WriteContentType (CancelableJSON) writes JSON ContentType.
+func (r CancelableJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+}
+
+// encodesElements reports whether v is encoded as a JSON array of its elements,
+// which can be encoded one by one.
+func encodesElements(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8 {
+			// null and base64
+			return false
+		}
+	case reflect.Array:
+	default:
+		return false
+	}
+	switch v.Interface().(type) {
+	case jsonMarshaler, encoding.TextMarshaler:
+		return false
+	}
+	return true
+}
+
+// cancelWriter writes in chunks until its context is done.
+type cancelWriter struct {
+	ctx context.Context
+	w   http.ResponseWriter
+}
+
+func (w *cancelWriter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		if err = w.ctx.Err(); err != nil {
+			return n, err
+		}
+		chunk := p[:min(len(p), cancelChunkSize)]
+		var written int
+		written, err = w.w.Write(chunk)
+		n += written
+		if err != nil {
+			return n, err
+		}
+		p = p[len(chunk):]
+	}
+	return n, nil
+}
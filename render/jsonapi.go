@@ -0,0 +1,153 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/jialequ/mpgw/internal/json"
+)
+
+var jsonAPIContentType = []string{"application/vnd.api+json"}
+
+// JSONAPI contains the given JSON:API document, see https://jsonapi.org.
+// Data is either a JSONAPIDocument or the primary data of a document, such as
+// a JSONAPIResource or a slice of resources.
+type JSONAPI struct {
+	Data any
+}
+
+// JSONAPIDocument is the top-level object of a JSON:API document.
+// A document contains either Data or Errors.
+type JSONAPIDocument struct {
+	Data     any               `json:"data,omitempty"`
+	Errors   []JSONAPIError    `json:"errors,omitempty"`
+	Meta     map[string]any    `json:"meta,omitempty"`
+	Links    *JSONAPILinks     `json:"links,omitempty"`
+	Included []JSONAPIResource `json:"included,omitempty"`
+}
+
+// JSONAPIResource is a resource object.
+type JSONAPIResource struct {
+	Type          string                         `json:"type"`
+	ID            string                         `json:"id,omitempty"`
+	Attributes    any                            `json:"attributes,omitempty"`
+	Relationships map[string]JSONAPIRelationship `json:"relationships,omitempty"`
+	Links         *JSONAPILinks                  `json:"links,omitempty"`
+	Meta          map[string]any                 `json:"meta,omitempty"`
+}
+
+// JSONAPIResourceIdentifier identifies a resource in a relationship.
+type JSONAPIResourceIdentifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// JSONAPIRelationship is a relationship object. Data is a
+// JSONAPIResourceIdentifier for to-one relationships, or a slice of them for
+// to-many relationships.
+type JSONAPIRelationship struct {
+	Data  any            `json:"data,omitempty"`
+	Links *JSONAPILinks  `json:"links,omitempty"`
+	Meta  map[string]any `json:"meta,omitempty"`
+}
+
+// JSONAPILinks is a links object.
+type JSONAPILinks struct {
+	Self    string `json:"self,omitempty"`
+	Related string `json:"related,omitempty"`
+	First   string `json:"first,omitempty"`
+	Last    string `json:"last,omitempty"`
+	Prev    string `json:"prev,omitempty"`
+	Next    string `json:"next,omitempty"`
+}
+
+// JSONAPIError is an error object.
+type JSONAPIError struct {
+	ID     string              `json:"id,omitempty"`
+	Status string              `json:"status,omitempty"`
+	Code   string              `json:"code,omitempty"`
+	Title  string              `json:"title,omitempty"`
+	Detail string              `json:"detail,omitempty"`
+	Source *JSONAPIErrorSource `json:"source,omitempty"`
+	Meta   map[string]any      `json:"meta,omitempty"`
+}
+
+// JSONAPIErrorSource references the source of an error in the request.
+type JSONAPIErrorSource struct {
+	Pointer   string `json:"pointer,omitempty"`
+	Parameter string `json:"parameter,omitempty"`
+	Header    string `json:"header,omitempty"`
+}
+
+// NewJSONAPIError returns an error object for err, with the status code and title of status.
+func NewJSONAPIError(status int, err error) JSONAPIError {
+	return JSONAPIError{
+		Status: strconv.Itoa(status),
+		Title:  http.StatusText(status),
+		Detail: err.Error(),
+	}
+}
+
+// JSONAPIPagination returns the pagination links of a page-based collection,
+// using the page[number] and page[size] query parameters of u. Pages are
+// numbered from 1 and total is the number of items of the collection.
+func JSONAPIPagination(u *url.URL, number, size, total int) *JSONAPILinks {
+	if size <= 0 {
+		size = 1
+	}
+	if number < 1 {
+		number = 1
+	}
+	last := (total + size - 1) / size
+	if last < 1 {
+		last = 1
+	}
+	page := func(n int) string {
+		pu := *u
+		query := pu.Query()
+		query.Set("page[number]", strconv.Itoa(n))
+		query.Set("page[size]", strconv.Itoa(size))
+		pu.RawQuery = query.Encode()
+		return pu.String()
+	}
+
+	links := &JSONAPILinks{
+		Self:  page(number),
+		First: page(1),
+		Last:  page(last),
+	}
+	if number > 1 {
+		links.Prev = page(number - 1)
+	}
+	if number < last {
+		links.Next = page(number + 1)
+	}
+	return links
+}
+
+// Render (JSONAPI) writes the document with the JSON:API media type.
+func (r JSONAPI) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	doc := r.Data
+	switch r.Data.(type) {
+	case JSONAPIDocument, *JSONAPIDocument:
+	default:
+		doc = JSONAPIDocument{Data: r.Data}
+	}
+	jsonBytes, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(jsonBytes)
+	return err
+}
+
+// WriteContentType (JSONAPI) writes the JSON:API media type.
+func (r JSONAPI) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonAPIContentType)
+}
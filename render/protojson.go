@@ -0,0 +1,36 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"net/http"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtoJSON contains the given proto message, generated or dynamic.
+type ProtoJSON struct {
+	Data    proto.Message
+	Options protojson.MarshalOptions
+}
+
+// Render (ProtoJSON) marshals the given message with the canonical proto JSON mapping.
+func (r ProtoJSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+
+	bytes, err := r.Options.Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(bytes)
+	return err
+}
+
+// WriteContentType (ProtoJSON) writes JSON ContentType.
+func (r ProtoJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+}
@@ -31,6 +31,9 @@ var (
 	_ Render     = (*AsciiJSON)(nil)
 	_ Render     = (*ProtoBuf)(nil)
 	_ Render     = (*TOML)(nil)
+	_ Render     = (*ProtoJSON)(nil)
+	_ Render     = (*JSONAPI)(nil)
+	_ Render     = (*CancelableJSON)(nil)
 )
 
 func writeContentType(w http.ResponseWriter, value []string) {
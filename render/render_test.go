@@ -5,12 +5,14 @@
 package render
 
 import (
+	"context"
 	"encoding/xml"
 	"errors"
 	"html/template"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strconv"
 	"strings"
 	"testing"
@@ -18,6 +20,7 @@ import (
 	"github.com/jialequ/mpgw/internal/json"
 	testdata "github.com/jialequ/mpgw/testdata/protoexample"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -246,6 +249,82 @@ func TestRenderPureJSON(t *testing.T) {
 	assert.Equal(t, literal_3516, w.Header().Get(literal_2953))
 }
 
+type pointerMarshaler struct{ ID int }
+
+func (m *pointerMarshaler) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Itoa(m.ID * 10)), nil
+}
+
+// cancelingMarshaler cancels the rendering context when it is encoded.
+type cancelingMarshaler struct{ cancel context.CancelFunc }
+
+func (m cancelingMarshaler) MarshalJSON() ([]byte, error) {
+	m.cancel()
+	return []byte(`"` + strings.Repeat("x", 64<<10) + `"`), nil
+}
+
+func TestRenderCancelableJSON(t *testing.T) {
+	large := make([]string, 20000)
+	for i := range large {
+		large[i] = "<item>"
+	}
+	for _, data := range []any{
+		map[string]any{"foo": "bar", "html": "<b>"},
+		[]pointerMarshaler{{1}, {2}},
+		[2]pointerMarshaler{{1}, {2}},
+		[]any{1, "a", nil, []int{}},
+		[]int{},
+		[]int(nil),
+		[]byte("raw"),
+		large,
+		"text",
+	} {
+		expected, err := json.Marshal(data)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		require.NoError(t, (CancelableJSON{Context: context.Background(), Data: data}).Render(w))
+		assert.Equal(t, string(expected), w.Body.String())
+		assert.Equal(t, literal_3516, w.Header().Get(literal_2953))
+	}
+
+	w := httptest.NewRecorder()
+	assert.Error(t, (CancelableJSON{Context: context.Background(), Data: []any{make(chan int)}}).Render(w))
+}
+
+func TestRenderCancelableJSONCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w := httptest.NewRecorder()
+	assert.ErrorIs(t, (CancelableJSON{Context: ctx, Data: map[string]any{"foo": "bar"}}).Render(w), context.Canceled)
+	assert.Empty(t, w.Body.String())
+
+	// canceled while encoding, the elements after are not encoded
+	ctx, cancel = context.WithCancel(context.Background())
+	encoded := 0
+	data := make([]any, 100)
+	for i := range data {
+		data[i] = countingMarshaler{&encoded}
+	}
+	data[1] = cancelingMarshaler{cancel}
+	w = httptest.NewRecorder()
+	assert.ErrorIs(t, (CancelableJSON{Context: ctx, Data: data}).Render(w), context.Canceled)
+	assert.Equal(t, 1, encoded)
+	assert.Empty(t, w.Body.String())
+
+	// canceled while writing, the chunks after are not written
+	ctx, cancel = context.WithCancel(context.Background())
+	w = httptest.NewRecorder()
+	assert.ErrorIs(t, (CancelableJSON{Context: ctx, Data: cancelingMarshaler{cancel}}).Render(w), context.Canceled)
+	assert.Empty(t, w.Body.String())
+}
+
+type countingMarshaler struct{ n *int }
+
+func (m countingMarshaler) MarshalJSON() ([]byte, error) {
+	*m.n++
+	return []byte("0"), nil
+}
+
 type xmlmap map[string]any
 
 // Allows type H to be used with xml.Marshal
@@ -591,6 +670,47 @@ func TestRenderWriteError(t *testing.T) {
 	assert.Equal(t, `write "my-prefix:" error`, err.Error())
 }
 
+func TestRenderJSONAPI(t *testing.T) {
+	w := httptest.NewRecorder()
+	data := JSONAPIResource{
+		Type:       "articles",
+		ID:         "1",
+		Attributes: map[string]any{"title": "JSON:API"},
+		Relationships: map[string]JSONAPIRelationship{
+			"author": {Data: JSONAPIResourceIdentifier{Type: "people", ID: "9"}},
+		},
+	}
+
+	err := (JSONAPI{data}).Render(w)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"data":{"type":"articles","id":"1","attributes":{"title":"JSON:API"},`+
+		`"relationships":{"author":{"data":{"type":"people","id":"9"}}}}}`, w.Body.String())
+	assert.Equal(t, "application/vnd.api+json", w.Header().Get(literal_2953))
+
+	w = httptest.NewRecorder()
+	doc := JSONAPIDocument{Errors: []JSONAPIError{NewJSONAPIError(http.StatusNotFound, errors.New("no such article"))}}
+	assert.NoError(t, (JSONAPI{&doc}).Render(w))
+	assert.JSONEq(t, `{"errors":[{"status":"404","title":"Not Found","detail":"no such article"}]}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	assert.Error(t, (JSONAPI{make(chan int)}).Render(w))
+}
+
+func TestJSONAPIPagination(t *testing.T) {
+	u, _ := url.Parse("/articles?sort=title")
+	links := JSONAPIPagination(u, 2, 10, 25)
+	assert.Equal(t, "/articles?page%5Bnumber%5D=2&page%5Bsize%5D=10&sort=title", links.Self)
+	assert.Equal(t, "/articles?page%5Bnumber%5D=1&page%5Bsize%5D=10&sort=title", links.First)
+	assert.Equal(t, "/articles?page%5Bnumber%5D=3&page%5Bsize%5D=10&sort=title", links.Last)
+	assert.Equal(t, links.First, links.Prev)
+	assert.Equal(t, links.Last, links.Next)
+
+	links = JSONAPIPagination(u, 0, 0, 0)
+	assert.Equal(t, links.First, links.Last)
+	assert.Empty(t, links.Prev)
+	assert.Empty(t, links.Next)
+}
+
 const literal_3516 = "application/json; charset=utf-8"
 
 const literal_2953 = "Content-Type"
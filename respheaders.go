@@ -0,0 +1,194 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// headerFieldsPlan is how the values of a struct type are split between the
+// response headers and the body.
+type headerFieldsPlan struct {
+	headers []headerField
+	// body is the type of the body without the header fields, and fields the
+	// indices of its fields in the original type. body is nil when the header
+	// fields cannot be left out of the body.
+	body   reflect.Type
+	fields []int
+}
+
+// headerField is a field of a struct tagged with the response header it sets.
+type headerField struct {
+	index     int
+	name      string
+	omitEmpty bool
+}
+
+// headerFieldsPlans caches the plans by struct type, nil for the types without
+// header fields.
+var headerFieldsPlans sync.Map
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// headerFields sets the response headers declared by the fields of obj tagged
+// with `respheader:"Name"`, such as the total count of a paginated list, and
+// returns the value to render in the body, a copy of obj without these fields:
+//
+//	type page struct {
+//	    Total int    `respheader:"X-Total-Count"`
+//	    Next  string `respheader:"Link,omitempty"`
+//	    Items []Item `json:"items"`
+//	}
+//
+// The tag is distinct from the header tag of the request bindings, so that
+// the structs bound from the request headers render as usual. The zero values
+// are sent unless the tag has the omitempty option, nil pointers never are.
+// The header fields stay in the body when the type embeds other structs or
+// marshals itself: tag them with `json:"-"` then.
+func (c *Context) headerFields(obj any) any {
+	t := reflect.TypeOf(obj)
+	if t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return obj
+	}
+	plan := headerFieldsPlanOf(t)
+	if plan == nil {
+		return obj
+	}
+	v := reflect.ValueOf(obj)
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return obj
+		}
+		v = v.Elem()
+	}
+
+	header := c.Writer.Header()
+	for _, f := range plan.headers {
+		fv := v.Field(f.index)
+		if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+		for fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				break
+			}
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Pointer {
+			continue
+		}
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.String {
+			header.Del(f.name)
+			for i := 0; i < fv.Len(); i++ {
+				header.Add(f.name, fv.Index(i).String())
+			}
+			continue
+		}
+		header.Set(f.name, formatHeaderValue(fv))
+	}
+
+	if plan.body == nil {
+		return obj
+	}
+	body := reflect.New(plan.body).Elem()
+	for i, j := range plan.fields {
+		body.Field(i).Set(v.Field(j))
+	}
+	return body.Interface()
+}
+
+// headerFieldsPlanOf returns the plan of t, or nil if it has no header fields.
+func headerFieldsPlanOf(t reflect.Type) *headerFieldsPlan {
+	if plan, ok := headerFieldsPlans.Load(t); ok {
+		return plan.(*headerFieldsPlan)
+	}
+	if !hasHeaderFields(t) {
+		actual, _ := headerFieldsPlans.LoadOrStore(t, (*headerFieldsPlan)(nil))
+		return actual.(*headerFieldsPlan)
+	}
+	plan := &headerFieldsPlan{}
+	splittable := !t.Implements(jsonMarshalerType) && !reflect.PointerTo(t).Implements(jsonMarshalerType) &&
+		!t.Implements(textMarshalerType) && !reflect.PointerTo(t).Implements(textMarshalerType)
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if tag := sf.Tag.Get("respheader"); sf.IsExported() && tag != "" && tag != "-" {
+			name, opts, _ := strings.Cut(tag, ",")
+			plan.headers = append(plan.headers, headerField{
+				index:     i,
+				name:      http.CanonicalHeaderKey(name),
+				omitEmpty: opts == "omitempty",
+			})
+			continue
+		}
+		if sf.Anonymous {
+			splittable = false
+		}
+		if sf.IsExported() {
+			fields = append(fields, reflect.StructField{Name: sf.Name, Type: sf.Type, Tag: sf.Tag})
+			plan.fields = append(plan.fields, i)
+		}
+	}
+	if _, ok := t.FieldByName("XMLName"); !ok && splittable {
+		// the body type is unnamed, keep the name of the XML element
+		fields = append(fields, reflect.StructField{
+			Name: "XMLName",
+			Type: reflect.TypeOf(xml.Name{}),
+			Tag:  reflect.StructTag(`xml:"` + t.Name() + `" json:"-" yaml:"-" toml:"-"`),
+		})
+	}
+	if splittable {
+		plan.body = reflect.StructOf(fields)
+	}
+	actual, _ := headerFieldsPlans.LoadOrStore(t, plan)
+	return actual.(*headerFieldsPlan)
+}
+
+// hasHeaderFields reports whether t has exported fields tagged with
+// respheader.
+func hasHeaderFields(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if tag := sf.Tag.Get("respheader"); sf.IsExported() && tag != "" && tag != "-" {
+			return true
+		}
+	}
+	return false
+}
+
+// formatHeaderValue formats the value of a header field.
+func formatHeaderValue(v reflect.Value) string {
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).UTC().Format(http.TimeFormat)
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits())
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type headerPage struct {
+	Total    int       `respheader:"X-Total-Count"`
+	Next     string    `respheader:"Link,omitempty"`
+	Modified time.Time `respheader:"Last-Modified"`
+	Vary     []string  `respheader:"Vary"`
+	Cursor   *string   `respheader:"X-Cursor"`
+	Items    []string  `json:"items" xml:"item"`
+	internal int
+}
+
+type headerMeta struct {
+	Name string
+}
+
+type headerEmbedded struct {
+	headerMeta
+	Total int `respheader:"X-Total-Count" json:"-"`
+}
+
+func TestContextRenderHeaderFields(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := CreateTestContext(w)
+	modified := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	c.JSON(http.StatusOK, &headerPage{
+		Total:    42,
+		Modified: modified,
+		Vary:     []string{"Accept", "Origin"},
+		Items:    []string{"a", "b"},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"items":["a","b"]}`, w.Body.String())
+	assert.Equal(t, "42", w.Header().Get("X-Total-Count"))
+	assert.Equal(t, "Wed, 01 May 2024 10:00:00 GMT", w.Header().Get("Last-Modified"))
+	assert.Equal(t, []string{"Accept", "Origin"}, w.Header().Values("Vary"))
+	assert.NotContains(t, w.Header(), "Link")
+	assert.NotContains(t, w.Header(), "X-Cursor")
+
+	w = httptest.NewRecorder()
+	c, _ = CreateTestContext(w)
+	cursor := "abc"
+	c.XML(http.StatusOK, headerPage{Next: "</items?page=2>; rel=\"next\"", Cursor: &cursor, Items: []string{"a"}})
+	assert.Equal(t, "<headerPage><item>a</item></headerPage>", w.Body.String())
+	assert.Equal(t, "0", w.Header().Get("X-Total-Count"))
+	assert.Equal(t, "</items?page=2>; rel=\"next\"", w.Header().Get("Link"))
+	assert.Equal(t, "abc", w.Header().Get("X-Cursor"))
+
+	// the header fields of the types embedding structs stay in the body unless tagged json:"-"
+	w = httptest.NewRecorder()
+	c, _ = CreateTestContext(w)
+	c.JSON(http.StatusOK, headerEmbedded{headerMeta{Name: "gin"}, 3})
+	assert.Equal(t, "3", w.Header().Get("X-Total-Count"))
+	assert.Equal(t, `{"Name":"gin"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	c, _ = CreateTestContext(w)
+	c.JSON(http.StatusOK, H{"total": 1})
+	assert.Equal(t, `{"total":1}`, w.Body.String())
+
+	// the structs bound from the request headers render as usual
+	type bound struct {
+		Authorization string `header:"Authorization"`
+	}
+	w = httptest.NewRecorder()
+	c, _ = CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.Header.Set("Authorization", "Bearer secret")
+	var b bound
+	assert.NoError(t, c.ShouldBindHeader(&b))
+	c.JSON(http.StatusOK, b)
+	assert.Equal(t, `{"Authorization":"Bearer secret"}`, w.Body.String())
+	assert.Empty(t, w.Header().Get("Authorization"))
+}
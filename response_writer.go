@@ -9,6 +9,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"strconv"
 )
 
 const (
@@ -26,8 +27,8 @@ type ResponseWriter interface {
 	// Status returns the HTTP response status code of the current request.
 	Status() int
 
-	// Size returns the number of bytes already written into the response http body.
-	// See Written()
+	// Size returns the number of bytes already written into the response http body,
+	// which is 0 for a HEAD request whatever its Content-Length. See Written()
 	Size() int
 
 	// WriteString writes the string into the response body.
@@ -47,6 +48,19 @@ type responseWriter struct {
 	http.ResponseWriter
 	size   int
 	status int
+	// owner is the context of the writer, whose request and handler are
+	// checked when a body is not allowed.
+	owner *Context
+	// dropped reports whether a body not allowed was dropped.
+	dropped bool
+	// headPending reports whether the header of the response to a HEAD request
+	// is deferred until WriteHeaderNow, so that its Content-Length is the size
+	// of the dropped body, headSize.
+	headPending bool
+	headSize    int
+	// discarded reports whether the body of the response is discarded, as
+	// the response was replaced by an error once its header was written.
+	discarded bool
 }
 
 var _ ResponseWriter = (*responseWriter)(nil)
@@ -59,6 +73,10 @@ func (w *responseWriter) reset(writer http.ResponseWriter) {
 	w.ResponseWriter = writer
 	w.size = noWritten
 	w.status = defaultStatus
+	w.dropped = false
+	w.headPending = false
+	w.headSize = 0
+	w.discarded = false
 }
 
 func (w *responseWriter) WriteHeader(code int) {
@@ -74,19 +92,102 @@ func (w *responseWriter) WriteHeader(code int) {
 func (w *responseWriter) WriteHeaderNow() {
 	if !w.Written() {
 		w.size = 0
-		w.ResponseWriter.WriteHeader(w.status)
+		w.writeHeader()
+	} else if w.headPending {
+		w.writeHeader()
+	}
+}
+
+// start marks the response as written before its body, deferring the header
+// of the response to a HEAD request.
+func (w *responseWriter) start() {
+	if !w.Written() {
+		w.size = 0
+		if w.headPending = w.head(); !w.headPending {
+			w.writeHeader()
+		}
+	}
+}
+
+// head reports whether the response is the one to a HEAD request.
+func (w *responseWriter) head() bool {
+	return w.owner != nil && w.owner.Request != nil && w.owner.Request.Method == http.MethodHead
+}
+
+func (w *responseWriter) writeHeader() {
+	if c := w.owner; c != nil && len(c.headerHooks) > 0 {
+		hooks := c.headerHooks
+		c.headerHooks = nil
+		for _, hook := range hooks {
+			hook()
+		}
+	}
+	if c := w.owner; c != nil && c.timing && c.phases.firstByte.IsZero() {
+		c.phases.firstByte = c.now()
+	}
+	header := w.ResponseWriter.Header()
+	if w.headPending {
+		w.headPending = false
+		if w.headSize > 0 && w.status >= 200 && w.status != http.StatusNoContent &&
+			w.status != http.StatusNotModified && header.Get("Content-Length") == "" {
+			header.Set("Content-Length", strconv.Itoa(w.headSize))
+		}
+	}
+	if w.status < 200 || w.status == http.StatusNoContent {
+		// RFC 9110: 1xx and 204 responses have no Content-Length
+		header.Del("Content-Length")
+		header.Del("Transfer-Encoding")
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+// bodyAllowed reports whether the response can have a body: the 1xx, 204 and
+// 304 responses, the responses to HEAD requests and the discarded ones can
+// not.
+func (w *responseWriter) bodyAllowed() bool {
+	switch {
+	case w.status < 200, w.status == http.StatusNoContent, w.status == http.StatusNotModified, w.discarded:
+		return false
+	}
+	return !w.head()
+}
+
+// drop drops a body not allowed. A body written for a HEAD request is expected,
+// e.g. from a handler shared with GET, while the one of a response whose status
+// forbids it is a mistake, reported once per response in debug mode.
+func (w *responseWriter) drop(data int) {
+	if data == 0 || w.dropped || !IsDebugging() {
+		return
+	}
+	w.dropped = true
+	if w.status < 200 || w.status == http.StatusNoContent || w.status == http.StatusNotModified {
+		handler := "unknown handler"
+		if w.owner != nil && w.owner.handlers != nil {
+			handler = w.owner.HandlerName()
+		}
+		debugPrint("[WARNING] %s wrote a body to a %d response, dropped", handler, w.status)
 	}
 }
 
 func (w *responseWriter) Write(data []byte) (n int, err error) {
-	w.WriteHeaderNow()
+	w.start()
+	if !w.bodyAllowed() {
+		w.headSize += len(data)
+		w.drop(len(data))
+		return len(data), nil
+	}
 	n, err = w.ResponseWriter.Write(data)
 	w.size += n
 	return
 }
 
 func (w *responseWriter) WriteString(s string) (n int, err error) {
-	w.WriteHeaderNow()
+	w.start()
+	if !w.bodyAllowed() {
+		w.headSize += len(s)
+		w.drop(len(s))
+		return len(s), nil
+	}
 	n, err = io.WriteString(w.ResponseWriter, s)
 	w.size += n
 	return
@@ -109,7 +210,12 @@ func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	if w.size < 0 {
 		w.size = 0
 	}
-	return w.ResponseWriter.(http.Hijacker).Hijack()
+	w.headPending = false
+	conn, rw, err := w.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil {
+		notifyHijacked(conn)
+	}
+	return conn, rw, err
 }
 
 // CloseNotify implements the http.CloseNotifier interface.
@@ -119,6 +225,8 @@ func (w *responseWriter) CloseNotify() <-chan bool {
 
 // Flush implements the http.Flusher interface.
 func (w *responseWriter) Flush() {
+	// the size of a streamed body is unknown
+	w.headSize = 0
 	w.WriteHeaderNow()
 	w.ResponseWriter.(http.Flusher).Flush()
 }
@@ -103,6 +103,65 @@ func TestResponseWriterWrite(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestResponseWriterBodyNotAllowed(t *testing.T) {
+	router := New()
+	router.GET("/empty", func(c *Context) {
+		c.Header("Content-Length", "2")
+		c.Status(http.StatusNoContent)
+		_, _ = c.Writer.WriteString("{}")
+	})
+	router.GET("/cached", func(c *Context) {
+		c.Header("Content-Length", "2")
+		c.Status(http.StatusNotModified)
+		_, _ = c.Writer.Write([]byte("{}"))
+	})
+	router.Match([]string{http.MethodGet, http.MethodHead}, "/users", func(c *Context) {
+		c.String(http.StatusOK, "users")
+	})
+	var size int
+	router.HEAD("/hello", func(c *Context) {
+		c.String(http.StatusOK, "hello")
+		size = c.Writer.Size()
+	})
+
+	var w *httptest.ResponseRecorder
+	output := captureOutput(t, func() {
+		SetMode(DebugMode)
+		defer SetMode(TestMode)
+		w = PerformRequest(router, http.MethodGet, "/empty")
+	})
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Empty(t, w.Header().Get("Content-Length"))
+	assert.Contains(t, output, "[WARNING] github.com/jialequ/mpgw.TestResponseWriterBodyNotAllowed.func1 wrote a body to a 204 response, dropped")
+
+	w = PerformRequest(router, http.MethodGet, "/cached")
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, "2", w.Header().Get("Content-Length"))
+
+	// the body of a HEAD response is dropped without warning
+	output = captureOutput(t, func() {
+		SetMode(DebugMode)
+		defer SetMode(TestMode)
+		w = PerformRequest(router, http.MethodHead, "/users")
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.NotContains(t, output, "WARNING")
+	assert.Equal(t, "5", w.Header().Get("Content-Length"))
+
+	// so is the one of an explicit HEAD route, whose Content-Length is set
+	w = PerformRequest(router, http.MethodHead, "/hello")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, "5", w.Header().Get("Content-Length"))
+	assert.Equal(t, 0, size)
+
+	w = PerformRequest(router, http.MethodGet, "/users")
+	assert.Equal(t, "users", w.Body.String())
+}
+
 func TestResponseWriterHijack(t *testing.T) {
 	testWriter := httptest.NewRecorder()
 	writer := &responseWriter{}
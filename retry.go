@@ -0,0 +1,400 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultRetryAttempts  = 3
+	defaultRetryBackoff   = 25 * time.Millisecond
+	maxRetryBackoff       = time.Second
+	defaultRetryBudget    = 0.2
+	defaultRetryBudgetMin = 10
+	retryBudgetWindow     = 10 * time.Second
+	maxRetryDiscard       = 4 << 10
+)
+
+var defaultRetryStatuses = []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+
+// RetryPolicy defines how the requests sent to an upstream are retried, or
+// hedged. Only idempotent requests are retried by default, and requests whose
+// body cannot be replayed, see http.Request.GetBody, are never retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts of a request, including the
+	// first one. Optional. Default value is 3.
+	MaxAttempts int
+
+	// RetryOn is the list of response statuses retried. Transport errors and
+	// per-try timeouts are always retried. Optional. Default value is 502, 503 and 504.
+	RetryOn []int
+
+	// NonIdempotent also retries the requests which are not idempotent, such as
+	// POST requests without an Idempotency-Key header. Optional. Default value is false.
+	NonIdempotent bool
+
+	// PerTryTimeout bounds each attempt, while http.Client.Timeout bounds the
+	// whole request. Optional. Default value is 0, no timeout.
+	PerTryTimeout time.Duration
+
+	// Backoff is the delay before the first retry, doubled at each following
+	// retry up to 1s, with jitter. Optional. Default value is 25ms.
+	Backoff time.Duration
+
+	// HedgeDelay enables hedging: when an attempt has not answered after this
+	// delay, another one is sent concurrently, and the first response which is
+	// not retried wins. Failed attempts are followed by a new one immediately.
+	// Optional. Default value is 0, requests are retried sequentially.
+	HedgeDelay time.Duration
+
+	// BudgetRatio limits the retries and hedges to this ratio of the requests
+	// sent in the last 10s, so that retries do not overload a failing upstream.
+	// A negative value disables the budget. Optional. Default value is 0.2.
+	BudgetRatio float64
+
+	// BudgetMinRetries is the number of retries and hedges always allowed per
+	// 10s, for upstreams with little traffic. Optional. Default value is 10.
+	BudgetMinRetries int
+}
+
+// RetryStats are the counters of the retry policy of an upstream.
+type RetryStats struct {
+	// Requests is the number of requests sent.
+	Requests uint64
+	// Attempts is the number of attempts, including retries and hedges.
+	Attempts uint64
+	// Retries is the number of sequential retries.
+	Retries uint64
+	// Hedges is the number of hedged attempts.
+	Hedges uint64
+	// BudgetExhausted is the number of retries and hedges denied by the budget.
+	BudgetExhausted uint64
+}
+
+// retryTransport retries or hedges the requests sent to next.
+type retryTransport struct {
+	next   http.RoundTripper
+	policy RetryPolicy
+	retry  map[int]bool
+	budget retryBudget
+
+	requests        uint64
+	attempts        uint64
+	retries         uint64
+	hedges          uint64
+	budgetExhausted uint64
+}
+
+func newRetryTransport(next http.RoundTripper, policy RetryPolicy, now func() time.Time) *retryTransport {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = defaultRetryAttempts
+	}
+	if len(policy.RetryOn) == 0 {
+		policy.RetryOn = defaultRetryStatuses
+	}
+	if policy.Backoff <= 0 {
+		policy.Backoff = defaultRetryBackoff
+	}
+	if policy.BudgetRatio == 0 {
+		policy.BudgetRatio = defaultRetryBudget
+	}
+	if policy.BudgetMinRetries <= 0 {
+		policy.BudgetMinRetries = defaultRetryBudgetMin
+	}
+	t := &retryTransport{
+		next:   next,
+		policy: policy,
+		retry:  make(map[int]bool, len(policy.RetryOn)),
+		budget: retryBudget{ratio: policy.BudgetRatio, min: policy.BudgetMinRetries, now: now},
+	}
+	for _, status := range policy.RetryOn {
+		t.retry[status] = true
+	}
+	return t
+}
+
+// Stats returns a snapshot of the counters.
+func (t *retryTransport) Stats() RetryStats {
+	return RetryStats{
+		Requests:        atomic.LoadUint64(&t.requests),
+		Attempts:        atomic.LoadUint64(&t.attempts),
+		Retries:         atomic.LoadUint64(&t.retries),
+		Hedges:          atomic.LoadUint64(&t.hedges),
+		BudgetExhausted: atomic.LoadUint64(&t.budgetExhausted),
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddUint64(&t.requests, 1)
+	t.budget.request()
+	if t.policy.MaxAttempts == 1 || !t.retryable(req) {
+		return t.attempt(req, true)
+	}
+	if t.policy.HedgeDelay > 0 {
+		return t.hedge(req)
+	}
+
+	resp, err := t.attempt(req, true)
+	for n := 1; n < t.policy.MaxAttempts && t.shouldRetry(req, resp, err); n++ {
+		if !t.allow() {
+			break
+		}
+		if !sleepContext(req.Context(), t.backoff(n)) {
+			break
+		}
+		discard(resp)
+		atomic.AddUint64(&t.retries, 1)
+		resp, err = t.attempt(req, false)
+	}
+	return resp, err
+}
+
+// retryable reports whether req may be sent more than once.
+func (t *retryTransport) retryable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if t.policy.NonIdempotent {
+		return true
+	}
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	// same convention as net/http
+	_, ok := req.Header["Idempotency-Key"]
+	if !ok {
+		_, ok = req.Header["X-Idempotency-Key"]
+	}
+	return ok
+}
+
+// shouldRetry reports whether the outcome of an attempt is retried.
+func (t *retryTransport) shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	return t.retry[resp.StatusCode]
+}
+
+// allow takes a retry from the budget.
+func (t *retryTransport) allow() bool {
+	if t.budget.allow() {
+		return true
+	}
+	atomic.AddUint64(&t.budgetExhausted, 1)
+	return false
+}
+
+// backoff returns the delay before the retry n, between half and all of the
+// exponential backoff.
+func (t *retryTransport) backoff(n int) time.Duration {
+	d := t.policy.Backoff << (n - 1)
+	if d > maxRetryBackoff || d <= 0 {
+		d = maxRetryBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1)) //nolint: gosec
+}
+
+// attempt sends a copy of req, with its own context bounded by PerTryTimeout,
+// which is released when the response body is closed.
+func (t *retryTransport) attempt(req *http.Request, first bool) (*http.Response, error) {
+	atomic.AddUint64(&t.attempts, 1)
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if t.policy.PerTryTimeout > 0 {
+		ctx, cancel = context.WithTimeout(req.Context(), t.policy.PerTryTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(req.Context())
+	}
+	r := req.Clone(ctx)
+	if !first && req.Body != nil && req.Body != http.NoBody {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		r.Body = body
+	}
+	resp, err := t.next.RoundTrip(r)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type attemptResult struct {
+	resp *http.Response
+	err  error
+	n    int
+}
+
+// hedge sends attempts concurrently, a new one each HedgeDelay or as soon as
+// one fails, and returns the first outcome which is not retried, or the last
+// one. The attempts still in flight are canceled.
+func (t *retryTransport) hedge(req *http.Request) (*http.Response, error) {
+	results := make(chan attemptResult, t.policy.MaxAttempts)
+	cancels := make([]context.CancelFunc, 0, t.policy.MaxAttempts)
+	send := func() {
+		n := len(cancels)
+		ctx, cancel := context.WithCancel(req.Context())
+		cancels = append(cancels, cancel)
+		go func() {
+			resp, err := t.attempt(req.WithContext(ctx), n == 0)
+			results <- attemptResult{resp, err, n}
+		}()
+	}
+	sendMore := func() bool {
+		if len(cancels) == t.policy.MaxAttempts || req.Context().Err() != nil || !t.allow() {
+			return false
+		}
+		atomic.AddUint64(&t.hedges, 1)
+		send()
+		return true
+	}
+
+	send()
+	timer := time.NewTimer(t.policy.HedgeDelay)
+	defer timer.Stop()
+	var last attemptResult
+	for received := 0; received < len(cancels); {
+		select {
+		case <-timer.C:
+			if sendMore() {
+				timer.Reset(t.policy.HedgeDelay)
+			}
+		case r := <-results:
+			received++
+			if last.resp != nil {
+				discard(last.resp)
+				cancels[last.n]()
+			}
+			if !t.shouldRetry(req, r.resp, r.err) {
+				for n, cancel := range cancels {
+					if n != r.n {
+						cancel()
+					}
+				}
+				go discardResults(results, len(cancels)-received)
+				if r.resp != nil {
+					r.resp.Body = &cancelBody{ReadCloser: r.resp.Body, cancel: cancels[r.n]}
+				} else {
+					cancels[r.n]()
+				}
+				return r.resp, r.err
+			}
+			if r.resp == nil {
+				cancels[r.n]()
+			}
+			last = r
+			if sendMore() {
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(t.policy.HedgeDelay)
+			}
+		}
+	}
+	if last.resp != nil {
+		last.resp.Body = &cancelBody{ReadCloser: last.resp.Body, cancel: cancels[last.n]}
+	}
+	return last.resp, last.err
+}
+
+// discardResults closes the responses of the attempts which lost a hedge.
+func discardResults(results <-chan attemptResult, n int) {
+	for ; n > 0; n-- {
+		discard((<-results).resp)
+	}
+}
+
+// discard drains a little of the body of a response which is not returned,
+// so that its connection can be reused, and closes it.
+func discard(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxRetryDiscard)) //nolint: errcheck
+	resp.Body.Close()
+}
+
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// cancelBody releases the context of an attempt once its body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// retryBudget counts the requests and retries over fixed windows.
+type retryBudget struct {
+	ratio float64
+	min   int
+	// now returns the current time, time.Now if nil.
+	now func() time.Time
+
+	mu       sync.Mutex
+	start    time.Time
+	requests int
+	retries  int
+}
+
+func (b *retryBudget) roll() {
+	now := time.Now()
+	if b.now != nil {
+		now = b.now()
+	}
+	if now.Sub(b.start) >= retryBudgetWindow {
+		b.start, b.requests, b.retries = now, 0, 0
+	}
+}
+
+func (b *retryBudget) request() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.roll()
+	b.requests++
+}
+
+func (b *retryBudget) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.roll()
+	if b.ratio >= 0 && b.retries >= b.min && float64(b.retries) >= b.ratio*float64(b.requests) {
+		return false
+	}
+	b.retries++
+	return true
+}
@@ -0,0 +1,199 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// doUpstream sends a request with the client of u, and returns the response
+// status and body.
+func doUpstream(t *testing.T, u *Upstream, method, target string, body io.Reader, headers ...header) (int, string) {
+	req, err := http.NewRequest(method, target, body)
+	require.NoError(t, err)
+	for _, h := range headers {
+		req.Header.Set(h.Key, h.Value)
+	}
+	resp, err := u.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(data)
+}
+
+func TestUpstreamRetry(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if calls.Add(1)%3 != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, "%s %s", r.Method, body)
+	}))
+	defer server.Close()
+
+	router := New()
+	u, err := router.AddUpstream("users", UpstreamConfig{
+		URL:   server.URL,
+		Retry: &RetryPolicy{Backoff: time.Millisecond},
+	})
+	require.NoError(t, err)
+
+	status, body := doUpstream(t, u, http.MethodGet, server.URL, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "GET ", body)
+	assert.Equal(t, RetryStats{Requests: 1, Attempts: 3, Retries: 2}, u.RetryStats())
+
+	// POST requests are retried only with an idempotency key, and their body is replayed
+	status, _ = doUpstream(t, u, http.MethodPost, server.URL, strings.NewReader("order"))
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	status, body = doUpstream(t, u, http.MethodPost, server.URL, strings.NewReader("order"), header{"Idempotency-Key", "1"})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "POST order", body)
+
+	// bodies which cannot be replayed are sent once
+	calls.Store(0)
+	status, _ = doUpstream(t, u, http.MethodPut, server.URL, io.NopCloser(strings.NewReader("order")))
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, int32(1), calls.Load())
+
+	// the last response is returned once the attempts are exhausted
+	calls.Store(0)
+	u, err = router.AddUpstream("users", UpstreamConfig{
+		URL:   server.URL,
+		Retry: &RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond},
+	})
+	require.NoError(t, err)
+	status, _ = doUpstream(t, u, http.MethodGet, server.URL, nil)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, RetryStats{}, (&Upstream{}).RetryStats())
+}
+
+func TestUpstreamRetryPerTryTimeout(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		io.WriteString(w, "ok") //nolint: errcheck
+	}))
+	defer server.Close()
+
+	router := New()
+	u, err := router.AddUpstream("slow", UpstreamConfig{
+		URL:   server.URL,
+		Retry: &RetryPolicy{PerTryTimeout: 50 * time.Millisecond, Backoff: time.Millisecond},
+	})
+	require.NoError(t, err)
+	status, body := doUpstream(t, u, http.MethodGet, server.URL, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "ok", body)
+	assert.Equal(t, uint64(1), u.RetryStats().Retries)
+}
+
+func TestUpstreamHedge(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if n == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		fmt.Fprintf(w, "attempt %d", n)
+	}))
+	defer server.Close()
+
+	router := New()
+	u, err := router.AddUpstream("slow", UpstreamConfig{
+		URL:   server.URL,
+		Retry: &RetryPolicy{HedgeDelay: 20 * time.Millisecond},
+	})
+	require.NoError(t, err)
+	start := time.Now()
+	status, body := doUpstream(t, u, http.MethodGet, server.URL, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "attempt 2", body)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, RetryStats{Requests: 1, Attempts: 2, Hedges: 1}, u.RetryStats())
+
+	// failed attempts are followed by a new one immediately
+	calls.Store(1)
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 4 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		io.WriteString(w, "ok") //nolint: errcheck
+	}))
+	defer failing.Close()
+	u, err = router.AddUpstream("failing", UpstreamConfig{
+		URL:   failing.URL,
+		Retry: &RetryPolicy{HedgeDelay: time.Minute},
+	})
+	require.NoError(t, err)
+	start = time.Now()
+	status, body = doUpstream(t, u, http.MethodGet, failing.URL, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "ok", body)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, RetryStats{Requests: 1, Attempts: 3, Hedges: 2}, u.RetryStats())
+}
+
+func TestRetryBudget(t *testing.T) {
+	b := retryBudget{ratio: 0.5, min: 1}
+	b.request()
+	assert.True(t, b.allow())
+	assert.False(t, b.allow())
+	for i := 0; i < 4; i++ {
+		b.request()
+	}
+	assert.True(t, b.allow())
+	assert.True(t, b.allow())
+	assert.False(t, b.allow())
+
+	// a new window starts over
+	b.start = time.Now().Add(-retryBudgetWindow)
+	assert.True(t, b.allow())
+	assert.False(t, b.allow())
+
+	unlimited := retryBudget{ratio: -1}
+	for i := 0; i < 100; i++ {
+		assert.True(t, unlimited.allow())
+	}
+
+	// the budget is shared by the requests of an upstream
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	router := New()
+	u, err := router.AddUpstream("down", UpstreamConfig{
+		URL:   server.URL,
+		Retry: &RetryPolicy{Backoff: time.Millisecond, BudgetMinRetries: 2, BudgetRatio: 0.1},
+	})
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		status, _ := doUpstream(t, u, http.MethodGet, server.URL, nil)
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+	}
+	assert.Equal(t, RetryStats{Requests: 3, Attempts: 5, Retries: 2, BudgetExhausted: 2}, u.RetryStats())
+}
@@ -0,0 +1,380 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"html/template"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jialequ/mpgw/binding"
+	"github.com/jialequ/mpgw/render"
+)
+
+// DefaultRouteCatalogPath is the path where Engine.RouteCatalog serves the catalog.
+const DefaultRouteCatalogPath = "/__routes"
+
+// Route holds the metadata of a registered route. Routes are annotated through
+// a RouteHandle once registered:
+//
+//	router.GET("/users/:id", getUser)
+//	router.Route("/users/:id").
+//	    Describe("Returns a single user").
+//	    Tags("users")
+type Route struct {
+	Method      string
+	Path        string
+	Host        string
+	Name        string
+	Description string
+	Tags        []string
+	Deprecation *RouteDeprecation
+
+	bodyType    reflect.Type
+	bodyBinding binding.Binding
+	responses   []RouteResponse
+	meta        map[string]any
+
+	group      *RouterGroup
+	override   *RouteConfig
+	configOnce sync.Once
+	config     RouteConfig
+	// timeout is the timeout set by RouteHandle.Timeout, or 0.
+	timeout time.Duration
+	// priority is the priority set by RouteHandle.Priority.
+	priority int
+	// mock is the mock response set by RouteHandle.Mock, or nil.
+	mock *routeMock
+
+	// hits counts the hits of the route for RouteStats.
+	hits atomic.Uint64
+	// slashFixes and caseFixes count the requests served or redirected after
+	// fixing their trailing slash, or their case, see Engine.RedirectReport.
+	slashFixes atomic.Uint64
+	caseFixes  atomic.Uint64
+	// disabled is the status answered by the route disabled by
+	// Engine.DisableRoute, or 0.
+	disabled      atomic.Int32
+	disabledUntil atomic.Int64
+}
+
+// RouteParam is a parameter inferred from a route path.
+type RouteParam struct {
+	Name     string `json:"name"`
+	CatchAll bool   `json:"catchAll"`
+	// Constraint is the constraint of a param, such as "int", or the segment
+	// bounds of a catch-all, such as "{1,3}".
+	Constraint string `json:"constraint,omitempty"`
+}
+
+// RouteResponse is a response of a route, declared with RouteHandle.Responds.
+type RouteResponse struct {
+	Status int `json:"status"`
+	// Type is the type of the body of the response, nil if it has none.
+	Type reflect.Type `json:"-"`
+}
+
+// RouteDoc is the catalog entry of a route, as served by Engine.RouteCatalog.
+type RouteDoc struct {
+	Method      string            `json:"method"`
+	Path        string            `json:"path"`
+	Host        string            `json:"host,omitempty"`
+	Name        string            `json:"name,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Params      []RouteParam      `json:"params,omitempty"`
+	Body        string            `json:"body,omitempty"`
+	Deprecation *RouteDeprecation `json:"deprecation,omitempty"`
+	Responses   []RouteResponse   `json:"responses,omitempty"`
+	Meta        map[string]any    `json:"meta,omitempty"`
+
+	// BodyType and BodyBinding are the type and the binding set by Binds.
+	BodyType    reflect.Type    `json:"-"`
+	BodyBinding binding.Binding `json:"-"`
+}
+
+// RouteHandle annotates registered routes, see RouterGroup.Route. The
+// annotations update the routes while the engine serves requests.
+type RouteHandle struct {
+	engine *Engine
+	// keys are the keys of the routes in the route index.
+	keys []string
+}
+
+// Route returns the handle annotating the routes of the group registered at
+// relativePath with the given methods, or with any method if none is given,
+// such as the ones registered by Any. It panics if a method has no route at
+// relativePath, or if no route is registered there.
+func (group *RouterGroup) Route(relativePath string, methods ...string) *RouteHandle {
+	absolutePath := group.calculateAbsolutePath(relativePath)
+	// the routes registered by a running Update are not published yet
+	u := &group.engine.updates
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	t := group.engine.routeTable
+	h := &RouteHandle{engine: group.engine}
+	if len(methods) == 0 {
+		trees := t.trees
+		if group.host != "" {
+			trees = nil
+			if ht := t.hosts[group.host]; ht != nil {
+				trees = ht.trees
+			}
+		}
+		for _, tree := range trees {
+			if key := routeKey(group.host, tree.method, absolutePath); t.routeIndex[key] != nil {
+				h.keys = append(h.keys, key)
+			}
+		}
+		assert1(len(h.keys) > 0, "no route is registered at '"+absolutePath+"'")
+		return h
+	}
+	for _, method := range methods {
+		key := routeKey(group.host, method, absolutePath)
+		assert1(t.routeIndex[key] != nil, "no route "+method+" '"+absolutePath+"' is registered")
+		if !slices.Contains(h.keys, key) {
+			h.keys = append(h.keys, key)
+		}
+	}
+	return h
+}
+
+// annotate applies fn to the routes of the handle in an update of the table.
+func (h *RouteHandle) annotate(fn func(route *Route)) *RouteHandle {
+	engine := h.engine
+	engine.update(func() {
+		for _, key := range h.keys {
+			if route := engine.routeIndex[key]; route != nil {
+				fn(engine.editRoute(route))
+			}
+		}
+	})
+	return h
+}
+
+// Name names the routes, so their paths can be built with Engine.PathBuilder.
+// Names are unique per engine, the routes of a handle sharing theirs.
+func (h *RouteHandle) Name(name string) *RouteHandle {
+	engine := h.engine
+	return h.annotate(func(route *Route) {
+		if prev, ok := engine.namedRoutes[name]; ok {
+			assert1(prev.Path == route.Path, "route name '"+name+"' is already used by '"+prev.Path+"'")
+		} else {
+			if engine.namedRoutes == nil {
+				engine.namedRoutes = make(map[string]*Route)
+			}
+			engine.namedRoutes[name] = route
+		}
+		route.Name = name
+	})
+}
+
+// Describe sets a human description on the routes.
+func (h *RouteHandle) Describe(description string) *RouteHandle {
+	return h.annotate(func(route *Route) {
+		route.Description = description
+	})
+}
+
+// Responds declares a response of the routes, with its status and the type of
+// its body, given as a value or a pointer, or nil if it has none:
+//
+//	router.Route("/users/:id", http.MethodGet).
+//	    Responds(http.StatusOK, User{}).
+//	    Responds(http.StatusNotFound, nil)
+func (h *RouteHandle) Responds(status int, obj any) *RouteHandle {
+	t := reflect.TypeOf(obj)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return h.annotate(func(route *Route) {
+		route.responses = append(route.responses, RouteResponse{Status: status, Type: t})
+	})
+}
+
+// Meta attaches a metadata value under key to the routes, read by middleware
+// with Context.RouteMeta to behave per route:
+//
+//	router.Route("/admin/users").Meta("auth", "admin").Meta("rateLimit", 100)
+func (h *RouteHandle) Meta(key string, value any) *RouteHandle {
+	return h.annotate(func(route *Route) {
+		if route.meta == nil {
+			route.meta = make(map[string]any)
+		}
+		route.meta[key] = value
+	})
+}
+
+// RouteMeta returns the metadata attached with RouteHandle.Meta to the route
+// matched by the request, nil if none. It must not be modified.
+func (c *Context) RouteMeta() map[string]any {
+	if c.engine == nil || c.Request == nil {
+		return nil
+	}
+	if route := c.engine.table.Load().routeIndex[routeKey(c.routeHost, c.Request.Method, c.fullPath)]; route != nil {
+		return route.meta
+	}
+	return nil
+}
+
+// Tags appends tags to the routes.
+func (h *RouteHandle) Tags(tags ...string) *RouteHandle {
+	return h.annotate(func(route *Route) {
+		route.Tags = append(route.Tags, tags...)
+	})
+}
+
+// RouteDocs returns the catalog of the registered routes, in registration order.
+func (engine *Engine) RouteDocs() []RouteDoc {
+	routes := engine.table.Load().routes
+	docs := make([]RouteDoc, 0, len(routes))
+	for _, route := range routes {
+		docs = append(docs, RouteDoc{
+			Method:      route.Method,
+			Path:        route.Path,
+			Host:        route.Host,
+			Name:        route.Name,
+			Description: route.Description,
+			Tags:        route.Tags,
+			Params:      routeParams(route.Path),
+			Body:        route.bodyTypeName(),
+			Deprecation: route.Deprecation,
+			Responses:   route.responses,
+			Meta:        route.meta,
+			BodyType:    route.bodyType,
+			BodyBinding: route.bodyBinding,
+		})
+	}
+	return docs
+}
+
+// bodyTypeName returns the name of the type set by Binds, if any.
+func (route *Route) bodyTypeName() string {
+	if route.bodyType == nil {
+		return ""
+	}
+	return route.bodyType.String()
+}
+
+// routeParams infers the parameters of a route from its path.
+func routeParams(path string) []RouteParam {
+	var params []RouteParam
+	for _, part := range splitRoutePath(path) {
+		if part.param {
+			params = append(params, RouteParam{Name: part.text, CatchAll: part.catchAll, Constraint: part.constraint})
+		}
+	}
+	return params
+}
+
+// routePathPart is either a static piece of a route path or a parameter.
+type routePathPart struct {
+	text       string
+	param      bool
+	catchAll   bool
+	constraint string
+}
+
+// splitRoutePath splits a route path into its static pieces and parameters.
+// A parameter starts with ':' or '*' and ends at the next '/', or at the
+// literal following it in a compound segment such as ":name.:ext". The
+// constraint of a parameter such as ":id|int", or the segment bounds of a
+// catch-all such as "*path{1,3}", are split from its name.
+func splitRoutePath(path string) []routePathPart {
+	var parts []routePathPart
+	for path != "" {
+		i := strings.IndexAny(path, ":*")
+		if i < 0 {
+			parts = append(parts, routePathPart{text: path})
+			break
+		}
+		if i > 0 {
+			parts = append(parts, routePathPart{text: path[:i]})
+		}
+		end := strings.IndexByte(path[i:], '/')
+		if end < 0 {
+			end = len(path) - i
+		}
+		if isCompound(path[i : i+end]) {
+			parts = append(parts, splitCompound(path[i:i+end])...)
+		} else if path[i] == '*' && end == 1 && i+end < len(path) {
+			parts = append(parts, routePathPart{text: segmentWildcard, param: true})
+		} else {
+			part := routePathPart{text: path[i+1 : i+end], param: true, catchAll: path[i] == '*'}
+			sep := byte('|')
+			if part.catchAll {
+				sep = '{'
+			}
+			if j := strings.IndexByte(part.text, sep); j > 0 {
+				part.text, part.constraint = part.text[:j], part.text[j:]
+				if !part.catchAll {
+					part.constraint = part.constraint[1:]
+				}
+			}
+			parts = append(parts, part)
+		}
+		path = path[i+end:]
+	}
+	return parts
+}
+
+// splitCompound splits a compound segment into its parameters and literals.
+func splitCompound(segment string) []routePathPart {
+	var parts []routePathPart
+	for segment != "" {
+		if segment[0] == ':' {
+			end := 1 + paramNameLen(segment[1:])
+			parts = append(parts, routePathPart{text: segment[1:end], param: true})
+			segment = segment[end:]
+			continue
+		}
+		end := strings.IndexByte(segment, ':')
+		if end < 0 {
+			end = len(segment)
+		}
+		parts = append(parts, routePathPart{text: segment[:end]})
+		segment = segment[end:]
+	}
+	return parts
+}
+
+var routeCatalogTemplate = template.Must(template.New("routes").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Routes</title></head>
+<body>
+<h1>Routes</h1>
+<table>
+<tr><th>Method</th><th>Path</th><th>Parameters</th><th>Tags</th><th>Description</th></tr>
+{{range .}}<tr><td>{{.Method}}</td><td><code>{{.Host}}{{.Path}}</code></td><td>{{range .Params}}{{.Name}}{{if .CatchAll}} (catch-all){{end}} {{end}}</td><td>{{range .Tags}}{{.}} {{end}}</td><td>{{.Description}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// RouteCatalog registers a GET handler at DefaultRouteCatalogPath serving the
+// catalog of the engine's routes, as JSON or HTML depending on the Accept header.
+// The given handlers run before the catalog and are meant to protect it, e.g.
+// BasicAuth. Outside of debug mode RouteCatalog panics when no handler is given,
+// so the catalog is never exposed unauthenticated by accident.
+func (engine *Engine) RouteCatalog(handlers ...HandlerFunc) IRoutes {
+	assert1(IsDebugging() || len(handlers) > 0, "the route catalog must be protected by a middleware outside of debug mode")
+	handlers = append(handlers, func(c *Context) {
+		docs := engine.RouteDocs()
+		switch c.NegotiateFormat(MIMEJSON, MIMEHTML) {
+		case MIMEHTML:
+			c.Render(http.StatusOK, render.HTML{Template: routeCatalogTemplate, Data: docs})
+		default:
+			c.JSON(http.StatusOK, docs)
+		}
+	})
+	routes := engine.GET(DefaultRouteCatalogPath, handlers...)
+	engine.Route(DefaultRouteCatalogPath, http.MethodGet).Describe("Catalog of the registered routes")
+	return routes
+}
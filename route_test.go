@@ -0,0 +1,144 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/jialequ/mpgw/internal/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteAnnotations(t *testing.T) {
+	router := New()
+	router.GET("/users/:id", handlerTest1)
+	router.Route("/users/:id", http.MethodGet).Describe("Returns a user").Tags("users")
+	v1 := router.Group("/v1")
+	v1.Match([]string{http.MethodPut, http.MethodPatch}, "/files/*path", handlerTest2)
+	v1.Route("/files/*path").Tags("files", "write")
+	v1.Use(handlerTest1)
+
+	docs := router.RouteDocs()
+	require.Len(t, docs, 3)
+	assert.Equal(t, RouteDoc{
+		Method:      http.MethodGet,
+		Path:        "/users/:id",
+		Description: "Returns a user",
+		Tags:        []string{"users"},
+		Params:      []RouteParam{{Name: "id"}},
+	}, docs[0])
+	for _, doc := range docs[1:] {
+		assert.Equal(t, "/v1/files/*path", doc.Path)
+		assert.Empty(t, doc.Description)
+		assert.Equal(t, []string{"files", "write"}, doc.Tags)
+		assert.Equal(t, []RouteParam{{Name: "path", CatchAll: true}}, doc.Params)
+	}
+
+	router.POST("/users", handlerTest1)
+	router.Route("/users", http.MethodPost).Responds(http.StatusCreated, &RouteParam{}).Responds(http.StatusConflict, nil)
+	assert.Equal(t, []RouteResponse{
+		{Status: http.StatusCreated, Type: reflect.TypeOf(RouteParam{})},
+		{Status: http.StatusConflict},
+	}, router.RouteDocs()[3].Responses)
+
+	for _, info := range router.Routes() {
+		if info.Path == "/users/:id" {
+			assert.Equal(t, "Returns a user", info.Description)
+			assert.Equal(t, []string{"users"}, info.Tags)
+		}
+	}
+}
+
+func TestRouteHandle(t *testing.T) {
+	router := New()
+	items := router.Group("/items")
+	items.GET("", handlerTest1)
+	items.POST("", handlerTest1)
+	router.Host("api.example.com").GET("/items", handlerTest1)
+
+	all := items.Route("")
+	post := items.Route("", http.MethodPost, http.MethodPost)
+	all.Tags("items")
+	post.Describe("Creates an item")
+	all.Tags("catalog")
+
+	docs := router.RouteDocs()
+	require.Len(t, docs, 3)
+	assert.Equal(t, []string{"items", "catalog"}, docs[0].Tags)
+	assert.Empty(t, docs[0].Description)
+	assert.Equal(t, []string{"items", "catalog"}, docs[1].Tags)
+	assert.Equal(t, "Creates an item", docs[1].Description)
+	assert.Empty(t, docs[2].Tags)
+
+	router.Host("api.example.com").Route("/items").Tags("host")
+	assert.Equal(t, []string{"host"}, router.RouteDocs()[2].Tags)
+	assert.PanicsWithValue(t, "no route PUT '/items' is registered", func() {
+		items.Route("", http.MethodPut)
+	})
+}
+
+func TestRouteMeta(t *testing.T) {
+	router := New()
+	var meta map[string]any
+	router.Use(func(c *Context) {
+		meta = c.RouteMeta()
+	})
+	router.GET("/admin/users", handlerTest1)
+	router.Route("/admin/users", http.MethodGet).Meta("auth", "admin").Meta("rateLimit", 100)
+	router.Any("/public", handlerTest1)
+	router.Route("/public").Meta("auth", "none")
+	router.GET("/plain", handlerTest1)
+
+	PerformRequest(router, http.MethodGet, "/admin/users")
+	assert.Equal(t, map[string]any{"auth": "admin", "rateLimit": 100}, meta)
+	PerformRequest(router, http.MethodPost, "/public")
+	assert.Equal(t, map[string]any{"auth": "none"}, meta)
+	PerformRequest(router, http.MethodGet, "/plain")
+	assert.Nil(t, meta)
+	PerformRequest(router, http.MethodGet, "/missing")
+	assert.Nil(t, meta)
+
+	assert.Equal(t, map[string]any{"auth": "admin", "rateLimit": 100}, router.RouteDocs()[0].Meta)
+}
+
+func TestRouteCatalog(t *testing.T) {
+	SetMode(DebugMode)
+	defer SetMode(TestMode)
+
+	router := New()
+	router.POST("/orders/:id/items", handlerTest1)
+	router.Route("/orders/:id/items", http.MethodPost).Describe("Adds an item <b>")
+	router.RouteCatalog()
+
+	w := PerformRequest(router, http.MethodGet, DefaultRouteCatalogPath, header{Key: "Accept", Value: MIMEJSON})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var docs []RouteDoc
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &docs))
+	require.Len(t, docs, 2)
+	assert.Equal(t, "/orders/:id/items", docs[0].Path)
+	assert.Equal(t, []RouteParam{{Name: "id"}}, docs[0].Params)
+	assert.Equal(t, DefaultRouteCatalogPath, docs[1].Path)
+
+	w = PerformRequest(router, http.MethodGet, DefaultRouteCatalogPath, header{Key: "Accept", Value: MIMEHTML})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "<code>/orders/:id/items</code>")
+	assert.Contains(t, w.Body.String(), "Adds an item &lt;b&gt;")
+}
+
+func TestRouteCatalogReleaseMode(t *testing.T) {
+	SetMode(ReleaseMode)
+	defer SetMode(TestMode)
+
+	router := New()
+	assert.Panics(t, func() { router.RouteCatalog() })
+	assert.NotPanics(t, func() {
+		router.RouteCatalog(func(c *Context) { c.AbortWithStatus(http.StatusUnauthorized) })
+	})
+	w := PerformRequest(router, http.MethodGet, DefaultRouteCatalogPath)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,195 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// ConfigSwitch is a boolean setting of RouteConfig, left to ConfigInherit to
+// inherit it from the enclosing group or engine.
+type ConfigSwitch uint8
+
+const (
+	// ConfigInherit inherits the setting.
+	ConfigInherit ConfigSwitch = iota
+	// ConfigOn enables the setting.
+	ConfigOn
+	// ConfigOff disables the setting.
+	ConfigOff
+)
+
+// Enabled reports whether the setting is on.
+func (s ConfigSwitch) Enabled() bool {
+	return s == ConfigOn
+}
+
+// RouteConfig holds the settings of routes, cascading from the engine to the
+// groups and to the routes, each level overriding the settings it sets:
+//
+//	router.Configure(gin.RouteConfig{Timeout: 5 * time.Second, MaxBodyBytes: 1 << 20})
+//	uploads := router.Group("/uploads")
+//	uploads.Configure(gin.RouteConfig{MaxBodyBytes: 1 << 30})
+//	uploads.POST("/bulk", bulkUpload)
+//	uploads.Route("/bulk").Override(gin.RouteConfig{Timeout: -1})
+//
+// Settings must be set before the engine serves requests. Zero values inherit.
+type RouteConfig struct {
+	// Timeout bounds the handling of a request: the request context is
+	// canceled once it elapses. A negative value removes an inherited timeout.
+	Timeout time.Duration
+
+	// MaxBodyBytes caps the size of the request body, reading past it fails.
+	// A negative value removes an inherited limit.
+	MaxBodyBytes int64
+
+	// Compression tells compression middleware whether to compress responses.
+	Compression ConfigSwitch
+
+	// RequireAuth tells authentication middleware whether to authenticate
+	// requests; BasicAuth lets requests through when it is ConfigOff.
+	RequireAuth ConfigSwitch
+
+	// RequireIfMatch tells Context.RequireIfMatch whether to answer 428
+	// Precondition Required to the unsafe requests without If-Match header.
+	RequireIfMatch ConfigSwitch
+
+	// RequestBuffering tells Upstream.NewRequest how to buffer the request
+	// bodies forwarded, so that the retries and hedges can replay them.
+	RequestBuffering RequestBuffering
+
+	// BufferMemory is the size of the bodies buffered in memory by
+	// BufferingMemory and BufferingDisk. Default value is 1MB.
+	BufferMemory int64
+
+	// Values holds custom settings, for middleware, inherited key by key.
+	Values map[string]any
+}
+
+// merge returns conf overridden by the settings set by over.
+func (conf RouteConfig) merge(over *RouteConfig) RouteConfig {
+	if over == nil {
+		return conf
+	}
+	if over.Timeout != 0 {
+		conf.Timeout = over.Timeout
+	}
+	if over.MaxBodyBytes != 0 {
+		conf.MaxBodyBytes = over.MaxBodyBytes
+	}
+	if over.Compression != ConfigInherit {
+		conf.Compression = over.Compression
+	}
+	if over.RequireAuth != ConfigInherit {
+		conf.RequireAuth = over.RequireAuth
+	}
+	if over.RequireIfMatch != ConfigInherit {
+		conf.RequireIfMatch = over.RequireIfMatch
+	}
+	if over.RequestBuffering != BufferingInherit {
+		conf.RequestBuffering = over.RequestBuffering
+	}
+	if over.BufferMemory != 0 {
+		conf.BufferMemory = over.BufferMemory
+	}
+	if len(over.Values) > 0 {
+		values := make(map[string]any, len(conf.Values)+len(over.Values))
+		for k, v := range conf.Values {
+			values[k] = v
+		}
+		for k, v := range over.Values {
+			values[k] = v
+		}
+		conf.Values = values
+	}
+	return conf
+}
+
+// Configure sets the config of the group, inherited by its routes and subgroups.
+// On the engine, it sets the config inherited by all routes.
+func (group *RouterGroup) Configure(conf RouteConfig) {
+	group.config = &conf
+	group.engine.routeConfigured.Store(true)
+}
+
+// Override overrides the config of the routes.
+func (h *RouteHandle) Override(conf RouteConfig) *RouteHandle {
+	return h.annotate(func(route *Route) {
+		route.override = &conf
+		h.engine.routeConfigured.Store(true)
+	})
+}
+
+// resolvedConfig returns the config of the group, merged with the ones of its parents.
+func (group *RouterGroup) resolvedConfig() RouteConfig {
+	if group == nil {
+		return RouteConfig{}
+	}
+	return group.parent.resolvedConfig().merge(group.config)
+}
+
+// Config returns the config of the route, resolved from the engine, its groups
+// and its overrides. Negative settings are resolved to zero.
+func (route *Route) Config() RouteConfig {
+	route.configOnce.Do(func() {
+		conf := route.group.resolvedConfig().merge(route.override)
+		if conf.Timeout < 0 {
+			conf.Timeout = 0
+		}
+		if conf.MaxBodyBytes < 0 {
+			conf.MaxBodyBytes = 0
+		}
+		if conf.BufferMemory < 0 {
+			conf.BufferMemory = 0
+		}
+		route.config = conf
+	})
+	return route.config
+}
+
+// ResolveRouteConfig returns the resolved config of the route registered for
+// method and path without host, and whether there is such a route.
+func (engine *Engine) ResolveRouteConfig(method, path string) (RouteConfig, bool) {
+	return engine.resolveRouteConfig("", method, path)
+}
+
+// resolveRouteConfig returns the resolved config of a route of a host pattern.
+func (engine *Engine) resolveRouteConfig(host, method, path string) (RouteConfig, bool) {
+	route := engine.table.Load().routeIndex[routeKey(host, method, path)]
+	if route == nil {
+		return RouteConfig{}, false
+	}
+	return route.Config(), true
+}
+
+// RouteConfig returns the resolved config of the route matched by the request.
+func (c *Context) RouteConfig() RouteConfig {
+	if c.engine == nil || c.Request == nil {
+		return RouteConfig{}
+	}
+	conf, _ := c.engine.resolveRouteConfig(c.routeHost, c.Request.Method, c.fullPath)
+	return conf
+}
+
+// applyRouteConfig enforces the timeout and body limit of the matched route.
+// The returned function releases the resources of the timeout.
+func (engine *Engine) applyRouteConfig(c *Context) context.CancelFunc {
+	conf, ok := engine.resolveRouteConfig(c.routeHost, c.Request.Method, c.fullPath)
+	if !ok {
+		return nil
+	}
+	if conf.MaxBodyBytes > 0 && c.Request.Body != nil && c.Request.Body != http.NoBody {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, conf.MaxBodyBytes)
+	}
+	if conf.Timeout > 0 {
+		ctx, cancel := engine.withTimeout(c.Request.Context(), conf.Timeout, nil)
+		c.Request = c.Request.WithContext(ctx)
+		c.deadlined = true
+		return cancel
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteConfigResolution(t *testing.T) {
+	router := New()
+	router.Configure(RouteConfig{
+		Timeout:     time.Second,
+		Compression: ConfigOn,
+		Values:      map[string]any{"a": 1, "b": 1},
+	})
+	router.GET("/root", handlerTest1)
+
+	v1 := router.Group("/v1")
+	admin := v1.Group("/admin")
+	admin.GET("/users", handlerTest1)
+	admin.GET("/export", handlerTest1)
+	admin.Route("/export", http.MethodGet).Override(RouteConfig{Timeout: -1, MaxBodyBytes: 10, Compression: ConfigOff})
+	// configured after its subgroup was created and its routes registered
+	v1.Configure(RouteConfig{MaxBodyBytes: 1024, RequireAuth: ConfigOn, Values: map[string]any{"b": 2}})
+
+	conf, ok := router.ResolveRouteConfig(http.MethodGet, "/root")
+	assert.True(t, ok)
+	assert.Equal(t, RouteConfig{Timeout: time.Second, Compression: ConfigOn, Values: map[string]any{"a": 1, "b": 1}}, conf)
+
+	conf, _ = router.ResolveRouteConfig(http.MethodGet, "/v1/admin/users")
+	assert.Equal(t, RouteConfig{
+		Timeout:      time.Second,
+		MaxBodyBytes: 1024,
+		Compression:  ConfigOn,
+		RequireAuth:  ConfigOn,
+		Values:       map[string]any{"a": 1, "b": 2},
+	}, conf)
+	assert.True(t, conf.RequireAuth.Enabled())
+
+	conf, _ = router.ResolveRouteConfig(http.MethodGet, "/v1/admin/export")
+	assert.Equal(t, time.Duration(0), conf.Timeout)
+	assert.Equal(t, int64(10), conf.MaxBodyBytes)
+	assert.False(t, conf.Compression.Enabled())
+
+	_, ok = router.ResolveRouteConfig(http.MethodPost, "/root")
+	assert.False(t, ok)
+}
+
+func TestRouteConfigEnforcement(t *testing.T) {
+	router := New()
+	router.Configure(RouteConfig{Timeout: 20 * time.Millisecond, MaxBodyBytes: 4})
+	router.POST("/limited", func(c *Context) {
+		_, err := io.ReadAll(c.Request.Body)
+		assert.Error(t, err)
+		<-c.Request.Context().Done()
+		c.String(http.StatusOK, c.Request.Context().Err().Error())
+	})
+	router.POST("/free", func(c *Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		assert.NoError(t, err)
+		_, hasDeadline := c.Request.Context().Deadline()
+		assert.False(t, hasDeadline)
+		assert.Equal(t, time.Duration(0), c.RouteConfig().Timeout)
+		c.String(http.StatusOK, string(body))
+	})
+	router.Route("/free", http.MethodPost).Override(RouteConfig{Timeout: -1, MaxBodyBytes: -1})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/limited", strings.NewReader("too long")))
+	assert.Equal(t, "context deadline exceeded", w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/free", strings.NewReader("too long")))
+	assert.Equal(t, "too long", w.Body.String())
+}
+
+func TestRouteConfigBasicAuth(t *testing.T) {
+	router := New()
+	router.Use(BasicAuth(Accounts{"admin": "password"}))
+	router.GET("/private", handlerTest1)
+	router.GET("/health", func(c *Context) {
+		c.String(http.StatusOK, "up")
+	})
+	router.Route("/health", http.MethodGet).Override(RouteConfig{RequireAuth: ConfigOff})
+
+	w := PerformRequest(router, http.MethodGet, "/private")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = PerformRequest(router, http.MethodGet, "/health")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "up", w.Body.String())
+}
+
+func TestRouteConfigOverrideWithoutRoute(t *testing.T) {
+	router := New()
+	router.GET("/users", handlerTest1)
+	assert.PanicsWithValue(t, "no route is registered at '/orders'", func() {
+		router.Route("/orders").Override(RouteConfig{Timeout: time.Second})
+	})
+	assert.PanicsWithValue(t, "no route POST '/users' is registered", func() {
+		router.Route("/users", http.MethodPost).Override(RouteConfig{Timeout: time.Second})
+	})
+}
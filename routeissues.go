@@ -0,0 +1,178 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RouteIssueKind is the kind of a routing mistake found by
+// Engine.ValidateRoutes.
+type RouteIssueKind string
+
+// Kinds of the issues found by Engine.ValidateRoutes.
+const (
+	// RouteIssueShadowed is a route whose requests are all served by another
+	// route, such as a param whose constraint duplicates an earlier one.
+	RouteIssueShadowed RouteIssueKind = "shadowed"
+	// RouteIssueUnreachable is a route whose requests match no route.
+	RouteIssueUnreachable RouteIssueKind = "unreachable"
+	// RouteIssueTrailingSlash is a pair of routes differing only by a trailing
+	// slash, so that the clients getting the slash wrong are silently served
+	// by the other route instead of being redirected.
+	RouteIssueTrailingSlash RouteIssueKind = "trailingSlash"
+)
+
+// RouteIssue is a routing mistake found by Engine.ValidateRoutes.
+type RouteIssue struct {
+	Kind   RouteIssueKind `json:"kind"`
+	Method string         `json:"method"`
+	Host   string         `json:"host,omitempty"`
+	Path   string         `json:"path"`
+	// Other is the path of the other route involved: the one serving the
+	// requests of a shadowed route, or the other route of a trailing slash
+	// pair.
+	Other string `json:"other,omitempty"`
+}
+
+// String describes the issue.
+func (issue RouteIssue) String() string {
+	route := issue.Method + " " + issue.Host + issue.Path
+	switch issue.Kind {
+	case RouteIssueShadowed:
+		return fmt.Sprintf("%s: shadowed by %s", route, issue.Other)
+	case RouteIssueUnreachable:
+		return route + ": unreachable"
+	case RouteIssueTrailingSlash:
+		return fmt.Sprintf("%s: differs from %s only by a trailing slash", route, issue.Other)
+	}
+	return route + ": " + string(issue.Kind)
+}
+
+// sampleValues are the values tried for the params of a route, and
+// sampleCatchAlls the ones of its catch-all, to find the requests it serves.
+var (
+	sampleValues    = []string{"1", "a", "x1", "0f8fad5b-d9cb-469f-a165-70867728950e", "x-1"}
+	sampleCatchAlls = []string{"/1", "/a/1", "/", "/a/b/c", "/x1/a"}
+)
+
+// ValidateRoutes scans the routes for the mistakes the registration accepts:
+// the routes shadowed by other routes, the unreachable ones, and the pairs of
+// routes differing only by a trailing slash. Each route is looked up with
+// sample requests satisfying the constraints of its params; the routes whose
+// constraints accept none of the samples are not checked. CI can fail the
+// builds on routing mistakes:
+//
+//	for _, issue := range router.ValidateRoutes() {
+//	    t.Error(issue)
+//	}
+func (engine *Engine) ValidateRoutes() []RouteIssue {
+	t := engine.table.Load()
+	constraints := engine.routeConstraints()
+	var issues []RouteIssue
+	for _, route := range t.routes {
+		trees := t.trees
+		if route.Host != "" {
+			ht := t.hosts[route.Host]
+			if ht == nil {
+				continue
+			}
+			trees = ht.trees
+		}
+		root := trees.get(route.Method)
+		if root == nil {
+			continue
+		}
+		if issue, ok := checkRouteReachable(root, route, constraints); !ok {
+			issues = append(issues, issue)
+		}
+		if other, ok := strings.CutSuffix(route.Path, "/"); ok && other != "" &&
+			t.routeIndex[routeKey(route.Host, route.Method, other)] != nil {
+			issues = append(issues, RouteIssue{
+				Kind:   RouteIssueTrailingSlash,
+				Method: route.Method,
+				Host:   route.Host,
+				Path:   other,
+				Other:  route.Path,
+			})
+		}
+	}
+	return issues
+}
+
+// checkRouteReachable looks the route up in its tree with sample requests. It
+// reports whether one of them is served by the route, or the issue otherwise.
+func checkRouteReachable(root *node, route *Route, constraints map[string]func(string) bool) (RouteIssue, bool) {
+	issue := RouteIssue{Kind: RouteIssueUnreachable, Method: route.Method, Host: route.Host, Path: route.Path}
+	sampled := false
+	for variant := range sampleValues {
+		path, ok := samplePath(route.Path, variant, constraints)
+		if !ok {
+			continue
+		}
+		sampled = true
+		var params Params
+		var skippedNodes []skippedNode
+		value := root.getValue(path, &params, &skippedNodes, false)
+		if value.handlers != nil && value.fullPath == route.Path {
+			return issue, true
+		}
+		if value.handlers != nil && issue.Other == "" {
+			issue.Kind, issue.Other = RouteIssueShadowed, value.fullPath
+		}
+	}
+	return issue, !sampled
+}
+
+// samplePath returns the variant of a request path matching the route path,
+// its params set to sample values accepted by their constraints. It reports
+// whether the constraints accept one of them.
+func samplePath(routePath string, variant int, constraints map[string]func(string) bool) (string, bool) {
+	var sb strings.Builder
+	escaped := false
+	for _, part := range splitRoutePath(routePath) {
+		if escaped {
+			// an escaped colon, as in "/test\:param", is literal
+			sb.WriteString(":" + part.text)
+			escaped = false
+			continue
+		}
+		if !part.param {
+			text, ok := strings.CutSuffix(part.text, `\`)
+			sb.WriteString(text)
+			escaped = ok
+			continue
+		}
+		candidates, accept := sampleValues, func(string) bool { return true }
+		switch {
+		case part.catchAll:
+			candidates = sampleCatchAlls
+			if bounds, _ := catchAllBounds("*" + part.text + part.constraint); bounds != nil {
+				accept = bounds
+			}
+		case part.constraint != "":
+			if accept = constraints[part.constraint]; accept == nil {
+				return "", false
+			}
+		}
+		value, ok := sampleValue(candidates, variant, accept)
+		if !ok {
+			return "", false
+		}
+		sb.WriteString(value)
+	}
+	return sb.String(), true
+}
+
+// sampleValue returns the first candidate accepted, from the variant on.
+func sampleValue(candidates []string, variant int, accept func(string) bool) (string, bool) {
+	for i := range candidates {
+		if value := candidates[(variant+i)%len(candidates)]; accept(value) {
+			return value, true
+		}
+	}
+	return "", false
+}
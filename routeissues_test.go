@@ -0,0 +1,56 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngineValidateRoutes(t *testing.T) {
+	router := New()
+	router.RegisterConstraint("sku", func(s string) bool { return strings.HasPrefix(s, "SKU-") })
+	router.GET("/users/new", handlerTest1)
+	router.GET("/users/:id|int", handlerTest1)
+	router.GET("/users/:num|int", handlerTest1)
+	router.GET("/users/:name", handlerTest1)
+	router.GET("/files/*path{1,2}", handlerTest1)
+	router.GET("/products/:sku|sku", handlerTest1)
+	router.GET("/docs", handlerTest1)
+	router.GET("/docs/", handlerTest1)
+	router.POST("/docs", handlerTest1)
+	router.Host("api.example.com").GET("/v1/:from-:to", handlerTest1)
+
+	issues := router.ValidateRoutes()
+	assert.Equal(t, []RouteIssue{
+		{Kind: RouteIssueShadowed, Method: http.MethodGet, Path: "/users/:num|int", Other: "/users/:id|int"},
+		{Kind: RouteIssueTrailingSlash, Method: http.MethodGet, Path: "/docs", Other: "/docs/"},
+	}, issues)
+	assert.Equal(t, "GET /users/:num|int: shadowed by /users/:id|int", issues[0].String())
+	assert.Equal(t, "GET /docs: differs from /docs/ only by a trailing slash", issues[1].String())
+
+	assert.Empty(t, New().ValidateRoutes())
+}
+
+func TestCheckRouteReachable(t *testing.T) {
+	root := &node{}
+	root.addRoute("/users/:id|int", fakeHandler("/users/:id|int"))
+	constraints := builtinConstraints
+
+	_, ok := checkRouteReachable(root, &Route{Method: http.MethodGet, Path: "/users/:id|int"}, constraints)
+	assert.True(t, ok)
+
+	issue, ok := checkRouteReachable(root, &Route{Method: http.MethodGet, Path: "/orders"}, constraints)
+	assert.False(t, ok)
+	assert.Equal(t, RouteIssue{Kind: RouteIssueUnreachable, Method: http.MethodGet, Path: "/orders"}, issue)
+	assert.Equal(t, "GET /orders: unreachable", issue.String())
+
+	// the routes whose constraints accept no sample are not checked
+	_, ok = checkRouteReachable(root, &Route{Method: http.MethodGet, Path: "/files/*path{9}"}, constraints)
+	assert.True(t, ok)
+}
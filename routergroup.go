@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"path"
 	"regexp"
+	"slices"
 	"strings"
 )
 
@@ -57,6 +58,12 @@ type RouterGroup struct {
 	basePath string
 	engine   *Engine
 	root     bool
+	host     string
+
+	parent          *RouterGroup
+	config          *RouteConfig
+	caseInsensitive *bool
+	trailingSlash   TrailingSlashPolicy
 }
 
 var _ IRouter = (*RouterGroup)(nil)
@@ -74,6 +81,8 @@ func (group *RouterGroup) Group(relativePath string, handlers ...HandlerFunc) *R
 		Handlers: group.combineHandlers(handlers),
 		basePath: group.calculateAbsolutePath(relativePath),
 		engine:   group.engine,
+		host:     group.host,
+		parent:   group,
 	}
 }
 
@@ -84,10 +93,16 @@ func (group *RouterGroup) BasePath() string {
 }
 
 func (group *RouterGroup) handle(httpMethod, relativePath string, handlers HandlersChain) IRoutes {
+	group.register(httpMethod, relativePath, handlers)
+	return group.returnObj()
+}
+
+func (group *RouterGroup) register(httpMethod, relativePath string, handlers HandlersChain) *Route {
 	absolutePath := group.calculateAbsolutePath(relativePath)
 	handlers = group.combineHandlers(handlers)
-	group.engine.addRoute(httpMethod, absolutePath, handlers)
-	return group.returnObj()
+	route := group.engine.addHostRoute(group.host, httpMethod, absolutePath, handlers)
+	route.group = group
+	return route
 }
 
 // Handle registers a new request handle and middleware with the given path and method.
@@ -145,25 +160,49 @@ func (group *RouterGroup) HEAD(relativePath string, handlers ...HandlerFunc) IRo
 // Any registers a route that matches all the HTTP methods.
 // GET, POST, PUT, PATCH, HEAD, OPTIONS, DELETE, CONNECT, TRACE.
 func (group *RouterGroup) Any(relativePath string, handlers ...HandlerFunc) IRoutes {
-	for _, method := range anyMethods {
-		group.handle(method, relativePath, handlers)
-	}
-
-	return group.returnObj()
+	return group.handleMethods(anyMethods, relativePath, handlers)
 }
 
 // Match registers a route that matches the specified methods that you declared.
+// The methods listed twice are registered once.
 func (group *RouterGroup) Match(methods []string, relativePath string, handlers ...HandlerFunc) IRoutes {
 	for _, method := range methods {
-		group.handle(method, relativePath, handlers)
+		if matched := regEnLetter.MatchString(method); !matched {
+			panic("http method " + method + " is not valid")
+		}
 	}
+	return group.handleMethods(methods, relativePath, handlers)
+}
 
+// AnyExcept registers a route that matches the methods of Any but the excluded
+// ones, sharing the handlers:
+//
+//	router.AnyExcept("/files/*path", []string{http.MethodConnect, http.MethodTrace}, serveFiles)
+func (group *RouterGroup) AnyExcept(relativePath string, exclude []string, handlers ...HandlerFunc) IRoutes {
+	methods := make([]string, 0, len(anyMethods))
+	for _, method := range anyMethods {
+		if !slices.Contains(exclude, method) {
+			methods = append(methods, method)
+		}
+	}
+	assert1(len(methods) > 0, "AnyExcept excludes all the methods of '"+relativePath+"'")
+	return group.handleMethods(methods, relativePath, handlers)
+}
+
+// handleMethods registers the route for each of the methods.
+func (group *RouterGroup) handleMethods(methods []string, relativePath string, handlers HandlersChain) IRoutes {
+	for i, method := range methods {
+		if !slices.Contains(methods[:i], method) {
+			group.register(method, relativePath, handlers)
+		}
+	}
 	return group.returnObj()
 }
 
 // StaticFile registers a single route in order to serve a single file of the local filesystem.
 // router.StaticFile("favicon.ico", "./resources/favicon.ico")
 func (group *RouterGroup) StaticFile(relativePath, filepath string) IRoutes {
+	group.engine.AddCheck("static file "+group.calculateAbsolutePath(relativePath), checkFile(filepath))
 	return group.staticFileHandler(relativePath, func(c *Context) {
 		c.File(filepath)
 	})
@@ -173,6 +212,7 @@ func (group *RouterGroup) StaticFile(relativePath, filepath string) IRoutes {
 // router.StaticFileFS("favicon.ico", "./resources/favicon.ico", Dir{".", false})
 // Gin by default uses: gin.Dir()
 func (group *RouterGroup) StaticFileFS(relativePath, filepath string, fs http.FileSystem) IRoutes {
+	group.engine.AddCheck("static file "+group.calculateAbsolutePath(relativePath), checkFS(fs, filepath))
 	return group.staticFileHandler(relativePath, func(c *Context) {
 		c.FileFromFS(filepath, fs)
 	})
@@ -182,8 +222,8 @@ func (group *RouterGroup) staticFileHandler(relativePath string, handler Handler
 	if strings.Contains(relativePath, ":") || strings.Contains(relativePath, "*") {
 		panic("URL parameters can not be used when serving a static file")
 	}
-	group.GET(relativePath, handler)
-	group.HEAD(relativePath, handler)
+	group.register(http.MethodGet, relativePath, HandlersChain{handler})
+	group.register(http.MethodHead, relativePath, HandlersChain{handler})
 	return group.returnObj()
 }
 
@@ -200,6 +240,15 @@ func (group *RouterGroup) Static(relativePath, root string) IRoutes {
 
 // StaticFS works just like `Static()` but a custom `http.FileSystem` can be used instead.
 // Gin by default uses: gin.Dir()
+// The files of the file systems without modification times, such as an
+// embed.FS, are served as modified at the build time of the binary, with
+// ETags hashing their content computed at startup, so that browsers can
+// revalidate them:
+//
+//	//go:embed assets
+//	var assets embed.FS
+//
+//	router.StaticFS("/static", http.FS(assets))
 func (group *RouterGroup) StaticFS(relativePath string, fs http.FileSystem) IRoutes {
 	if strings.Contains(relativePath, ":") || strings.Contains(relativePath, "*") {
 		panic("URL parameters can not be used when serving a static folder")
@@ -208,17 +257,23 @@ func (group *RouterGroup) StaticFS(relativePath string, fs http.FileSystem) IRou
 	urlPattern := path.Join(relativePath, "/*filepath")
 
 	// Register GET and HEAD handlers
-	group.GET(urlPattern, handler)
-	group.HEAD(urlPattern, handler)
+	group.register(http.MethodGet, urlPattern, HandlersChain{handler})
+	group.register(http.MethodHead, urlPattern, HandlersChain{handler})
 	return group.returnObj()
 }
 
 func (group *RouterGroup) createStaticHandler(relativePath string, fs http.FileSystem) HandlerFunc {
 	absolutePath := group.calculateAbsolutePath(relativePath)
+	group.engine.AddCheck("static "+absolutePath, checkFS(fs, "/"))
+	_, noListing := fs.(*OnlyFilesFS)
+	embedded := newEmbeddedFS(fs)
+	if embedded != nil {
+		fs = embedded
+	}
 	fileServer := http.StripPrefix(absolutePath, http.FileServer(fs))
 
 	return func(c *Context) {
-		if _, noListing := fs.(*OnlyFilesFS); noListing {
+		if noListing {
 			c.Writer.WriteHeader(http.StatusNotFound)
 		}
 
@@ -234,6 +289,11 @@ func (group *RouterGroup) createStaticHandler(relativePath string, fs http.FileS
 		}
 		f.Close()
 
+		if embedded != nil {
+			if etag := embedded.etag(file); etag != "" {
+				c.Writer.Header().Set("ETag", etag)
+			}
+		}
 		fileServer.ServeHTTP(c.Writer, c.Request)
 	}
 }
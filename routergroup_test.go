@@ -7,6 +7,7 @@ package gin
 import (
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -81,13 +82,21 @@ func performRequestInGroup(t *testing.T, method string) {
 		panic("unknown method")
 	}
 
+	// the responses to HEAD requests have no body
+	body := func(s string) string {
+		if method == http.MethodHead {
+			return ""
+		}
+		return s
+	}
+
 	w := PerformRequest(router, method, "/v1/login/test")
 	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, "the method was "+method+" and index 3", w.Body.String())
+	assert.Equal(t, body("the method was "+method+" and index 3"), w.Body.String())
 
 	w = PerformRequest(router, method, "/v1/test")
 	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, "the method was "+method+" and index 1", w.Body.String())
+	assert.Equal(t, body("the method was "+method+" and index 1"), w.Body.String())
 }
 
 func TestRouterGroupInvalidStatic(t *testing.T) {
@@ -166,6 +175,36 @@ func TestRouterGroupBadMethod(t *testing.T) {
 	})
 }
 
+func TestRouterGroupMethodSets(t *testing.T) {
+	router := New()
+	router.HandleMethodNotAllowed = true
+	router.Match([]string{http.MethodGet, http.MethodHead, http.MethodGet}, "/users", handlerTest1)
+	router.Route("/users").Tags("users")
+	assert.Equal(t, IRoutes(router), router.AnyExcept("/files", []string{http.MethodConnect, http.MethodTrace}, handlerTest1))
+	router.Route("/files").Tags("files")
+
+	for _, method := range anyMethods {
+		code := http.StatusOK
+		if method == http.MethodConnect || method == http.MethodTrace {
+			code = http.StatusMethodNotAllowed
+		}
+		assert.Equal(t, code, PerformRequest(router, method, "/files").Code, method)
+	}
+	assert.Equal(t, http.StatusMethodNotAllowed, PerformRequest(router, http.MethodPost, "/users").Code)
+	routes := router.Routes()
+	assert.Len(t, routes, 9)
+	for _, route := range routes {
+		assert.Equal(t, []string{strings.TrimPrefix(route.Path, "/")}, route.Tags)
+	}
+
+	assert.PanicsWithValue(t, "http method get is not valid", func() {
+		router.Match([]string{"get"}, "/lower", handlerTest1)
+	})
+	assert.PanicsWithValue(t, "AnyExcept excludes all the methods of '/none'", func() {
+		router.AnyExcept("/none", anyMethods, handlerTest1)
+	})
+}
+
 func TestRouterGroupPipeline(t *testing.T) {
 	router := New()
 	testRoutesInterface(t, router)
@@ -304,6 +304,38 @@ func TestRouteParamsByName(t *testing.T) {
 	assert.Equal(t, solve, wild)
 }
 
+func TestRouteSegmentWildcard(t *testing.T) {
+	router := New()
+	router.GET("/api/:version/*/status", func(c *Context) {
+		c.String(http.StatusOK, "%s %s", c.Param("version"), c.Param("*"))
+	})
+	router.GET("/api/:version/users/status", func(c *Context) {
+		c.String(http.StatusOK, "users")
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/api/v1/orders/status")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "v1 orders", w.Body.String())
+	assert.Equal(t, "users", PerformRequest(router, http.MethodGet, "/api/v1/users/status").Body.String())
+	assert.Equal(t, http.StatusNotFound, PerformRequest(router, http.MethodGet, "/api/v1/a/b/status").Code)
+}
+
+func TestRouteCompoundParams(t *testing.T) {
+	router := New()
+	router.GET("/download/:name.:ext", func(c *Context) {
+		c.String(http.StatusOK, "%s %s", c.Param("name"), c.Param("ext"))
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/download/report%20q1.pdf")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "report q1 pdf", w.Body.String())
+	assert.Equal(t, http.StatusNotFound, PerformRequest(router, http.MethodGet, "/download/report").Code)
+
+	docs := router.RouteDocs()
+	assert.Len(t, docs, 1)
+	assert.Equal(t, []RouteParam{{Name: "name"}, {Name: "ext"}}, docs[0].Params)
+}
+
 // TestContextParamsGet tests that a parameter can be parsed from the URL even with extra slashes.
 func TestRouteParamsByNameWithExtraSlash(t *testing.T) {
 	name := ""
@@ -641,8 +673,10 @@ func TestRouterStaticFSNotFound(t *testing.T) {
 	w := PerformRequest(router, http.MethodGet, literal_2906)
 	assert.Equal(t, literal_1384, w.Body.String())
 
+	// the body of the response to a HEAD request is dropped
 	w = PerformRequest(router, http.MethodHead, literal_2906)
-	assert.Equal(t, literal_1384, w.Body.String())
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, w.Body.String())
 }
 
 func TestRouterStaticFSFileNotFound(t *testing.T) {
@@ -826,3 +860,92 @@ const literal_1384 = "non existent"
 const literal_2906 = "/nonexistent"
 
 const solve = "/is/super/great"
+
+func TestRouteHandleHeadWithGet(t *testing.T) {
+	router := New()
+	router.HandleMethodNotAllowed = true
+	router.GET("/users/:id", func(c *Context) {
+		c.Header("X-User", c.Param("id"))
+		c.String(http.StatusOK, "user %s", c.Param("id"))
+	})
+	router.GET("/stream", func(c *Context) {
+		c.String(http.StatusOK, "chunk")
+		c.Writer.Flush()
+		c.String(http.StatusOK, "chunk")
+	})
+	router.GET("/sized", func(c *Context) {
+		c.Header("Content-Length", "100")
+		c.String(http.StatusOK, "partial")
+	})
+	router.GET("/explicit", handlerTest1)
+	router.HEAD("/explicit", func(c *Context) { c.Status(http.StatusAccepted) })
+	router.Host("api.example.com").GET("/users/:id", func(c *Context) { c.String(http.StatusOK, "api") })
+
+	w := PerformRequest(router, http.MethodHead, "/users/42")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "GET", w.Header().Get("Allow"))
+
+	router.HandleHeadWithGet = true
+	w = PerformRequest(router, http.MethodHead, "/users/42")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, "7", w.Header().Get("Content-Length"))
+	assert.Equal(t, "42", w.Header().Get("X-User"))
+
+	w = performHostRequest(router, http.MethodHead, "api.example.com", "/users/42")
+	assert.Equal(t, "3", w.Header().Get("Content-Length"))
+
+	w = PerformRequest(router, http.MethodHead, "/stream")
+	assert.Empty(t, w.Header().Get("Content-Length"))
+	w = PerformRequest(router, http.MethodHead, "/sized")
+	assert.Equal(t, "100", w.Header().Get("Content-Length"))
+	assert.Equal(t, http.StatusAccepted, PerformRequest(router, http.MethodHead, "/explicit").Code)
+
+	w = PerformRequest(router, http.MethodPost, "/users/42")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "GET, HEAD", w.Header().Get("Allow"))
+}
+
+func TestRouteHandleOPTIONS(t *testing.T) {
+	router := New()
+	router.HandleMethodNotAllowed = true
+	router.Use(func(c *Context) { c.Header("Access-Control-Allow-Origin", "*") })
+	router.GET("/users/:id", handlerTest1)
+	router.PUT("/users/:id", handlerTest1)
+	router.DELETE("/users/:id", handlerTest1)
+	router.OPTIONS("/custom", func(c *Context) { c.String(http.StatusOK, "custom") })
+	router.POST("/custom", handlerTest1)
+	router.Host("api.example.com").PATCH("/users/:id", handlerTest1)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, PerformRequest(router, http.MethodOptions, "/users/1").Code)
+
+	router.HandleOPTIONS = true
+	router.HandleHeadWithGet = true
+	w := PerformRequest(router, http.MethodOptions, "/users/1")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "GET, PUT, DELETE, HEAD, OPTIONS", w.Header().Get("Allow"))
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Body.String())
+
+	w = performHostRequest(router, http.MethodOptions, "api.example.com", "/users/1")
+	assert.Equal(t, "PATCH, GET, PUT, DELETE, HEAD, OPTIONS", w.Header().Get("Allow"))
+
+	// the OPTIONS routes take precedence
+	w = PerformRequest(router, http.MethodOptions, "/custom")
+	assert.Equal(t, "custom", w.Body.String())
+	assert.Empty(t, w.Header().Get("Allow"))
+
+	w = PerformRequest(router, http.MethodOptions, "/missing")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = PerformRequest(router, http.MethodPost, "/users/1")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "GET, PUT, DELETE, HEAD, OPTIONS", w.Header().Get("Allow"))
+
+	req := httptest.NewRequest(http.MethodOptions, "/", nil)
+	req.URL.Path = "*"
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "GET, PUT, DELETE, POST, HEAD, OPTIONS", w.Header().Get("Allow"))
+}
@@ -0,0 +1,209 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultRouteStatsHalfLife is the default time after which the hits counted by
+// RouteStats lose half of their weight.
+const DefaultRouteStatsHalfLife = 10 * time.Minute
+
+// RouteHits is the decayed number of hits of a route, as exported by
+// RouteStats.
+type RouteHits struct {
+	Method string  `json:"method"`
+	Host   string  `json:"host,omitempty"`
+	Path   string  `json:"path"`
+	Hits   float64 `json:"hits"`
+}
+
+// RouteStats counts the hits of the routes of an engine, decayed over time so
+// that the recent traffic weighs more, and orders the children of the nodes of
+// the routing trees by their traffic with Reprioritize. The trees are walked
+// in the order of the children, so the hot routes of skewed workloads match
+// faster than with the registration-time order. The statistics are exported
+// and imported to persist the learned ordering across restarts:
+//
+//	stats := router.RouteStats()
+//	if f, err := os.Open("routes.json"); err == nil {
+//	    stats.Import(f)
+//	    f.Close()
+//	}
+//	stats.Reprioritize()
+//	go router.Run(":8080")
+//	...
+//	stats.Export(out)
+type RouteStats struct {
+	engine *Engine
+	now    func() time.Time
+
+	mu       sync.Mutex
+	halfLife time.Duration
+	scores   map[string]float64
+	last     time.Time
+}
+
+// RouteStats returns the route statistics of the engine, counting the hits of
+// the routes from the first call on.
+func (engine *Engine) RouteStats() *RouteStats {
+	if s := engine.routeStats.Load(); s != nil {
+		return s
+	}
+	engine.routeStats.CompareAndSwap(nil, &RouteStats{
+		engine:   engine,
+		now:      engine.now,
+		halfLife: DefaultRouteStatsHalfLife,
+		scores:   make(map[string]float64),
+		last:     engine.now(),
+	})
+	return engine.routeStats.Load()
+}
+
+// hit counts a hit of the route served by c.
+func (s *RouteStats) hit(c *Context, method string) {
+	if route := s.engine.table.Load().routeIndex[routeKey(c.routeHost, method, c.fullPath)]; route != nil {
+		route.hits.Add(1)
+	}
+}
+
+// SetHalfLife sets the time after which the hits lose half of their weight.
+// Non-positive values disable the decay.
+func (s *RouteStats) SetHalfLife(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fold()
+	s.halfLife = d
+}
+
+// fold decays the scores and adds the hits counted since the last fold.
+func (s *RouteStats) fold() {
+	now := s.now()
+	factor := 1.0
+	if s.halfLife > 0 {
+		factor = math.Pow(0.5, float64(now.Sub(s.last))/float64(s.halfLife))
+	}
+	s.last = now
+	for key, score := range s.scores {
+		s.scores[key] = score * factor
+	}
+	for _, route := range s.engine.table.Load().routes {
+		if hits := route.hits.Swap(0); hits > 0 {
+			s.scores[routeKey(route.Host, route.Method, route.Path)] += float64(hits)
+		}
+	}
+}
+
+// Snapshot returns the decayed hits of the routes, the most hit first, then in
+// registration order.
+func (s *RouteStats) Snapshot() []RouteHits {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fold()
+	routes := s.engine.table.Load().routes
+	snapshot := make([]RouteHits, 0, len(routes))
+	for _, route := range routes {
+		snapshot = append(snapshot, RouteHits{
+			Method: route.Method,
+			Host:   route.Host,
+			Path:   route.Path,
+			Hits:   s.scores[routeKey(route.Host, route.Method, route.Path)],
+		})
+	}
+	sort.SliceStable(snapshot, func(i, j int) bool { return snapshot[i].Hits > snapshot[j].Hits })
+	return snapshot
+}
+
+// Export writes the snapshot of the statistics as JSON.
+func (s *RouteStats) Export(w io.Writer) error {
+	return json.NewEncoder(w).Encode(s.Snapshot())
+}
+
+// Import reads statistics written by Export and replaces the hits of the
+// routes they list, such as the ones of the previous run of the application.
+// The routes which are not registered are ignored.
+func (s *RouteStats) Import(r io.Reader) error {
+	var hits []RouteHits
+	if err := json.NewDecoder(r).Decode(&hits); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fold()
+	for _, h := range hits {
+		key := routeKey(h.Host, h.Method, h.Path)
+		if s.engine.table.Load().routeIndex[key] != nil {
+			s.scores[key] = h.Hits
+		}
+	}
+	return nil
+}
+
+// Reprioritize orders the children of the nodes of the routing trees by the
+// decayed hits of the routes below them, falling back to the registration-time
+// priority. Once the engine serves requests, it reorders a copy of the trees,
+// swapped atomically, so it can run periodically.
+func (s *RouteStats) Reprioritize() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fold()
+	engine := s.engine
+	engine.update(func() {
+		for _, tree := range engine.trees {
+			s.reorder("", tree.method, tree.root)
+		}
+		for _, ht := range engine.hosts {
+			for _, tree := range ht.trees {
+				s.reorder(ht.pattern, tree.method, tree.root)
+			}
+		}
+	})
+}
+
+// reorder orders the static children of n, and returns the hits of the routes
+// below n.
+func (s *RouteStats) reorder(host, method string, n *node) float64 {
+	weights := make([]float64, len(n.children))
+	var total float64
+	for i, child := range n.children {
+		for ; child != nil; child = child.alt {
+			weights[i] += s.reorder(host, method, child)
+		}
+		total += weights[i]
+	}
+	if n.handlers != nil {
+		total += s.scores[routeKey(host, method, n.fullPath)]
+	}
+
+	// the static children are the ones of the indices, the wildcard child is
+	// always the last one
+	static := len(n.indices)
+	order := make([]int, static)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if weights[a] != weights[b] {
+			return weights[a] > weights[b]
+		}
+		return n.children[a].priority > n.children[b].priority
+	})
+	children := make([]*node, static, len(n.children))
+	indices := make([]byte, static)
+	for i, j := range order {
+		children[i] = n.children[j]
+		indices[i] = n.indices[j]
+	}
+	n.children = append(children, n.children[static:]...)
+	n.indices = string(indices)
+	return total
+}
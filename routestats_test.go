@@ -0,0 +1,92 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStatsRouter() *Engine {
+	router := New()
+	router.GET("/alpha", handlerTest1)
+	router.GET("/beta", handlerTest1)
+	router.GET("/gamma/:id", handlerTest1)
+	router.POST("/gamma/:id", handlerTest1)
+	return router
+}
+
+func TestRouteStats(t *testing.T) {
+	router := newStatsRouter()
+	// hits before the statistics are enabled are not counted
+	PerformRequest(router, http.MethodGet, "/alpha")
+
+	now := time.Now()
+	stats := router.RouteStats()
+	assert.Same(t, stats, router.RouteStats())
+	stats.now = func() time.Time { return now }
+
+	for i := 0; i < 4; i++ {
+		PerformRequest(router, http.MethodGet, "/gamma/1")
+	}
+	PerformRequest(router, http.MethodGet, "/beta")
+	PerformRequest(router, http.MethodGet, "/missing")
+
+	assert.Equal(t, []RouteHits{
+		{Method: http.MethodGet, Path: "/gamma/:id", Hits: 4},
+		{Method: http.MethodGet, Path: "/beta", Hits: 1},
+		{Method: http.MethodGet, Path: "/alpha"},
+		{Method: http.MethodPost, Path: "/gamma/:id"},
+	}, stats.Snapshot())
+
+	// the hits lose half of their weight every half-life
+	now = now.Add(DefaultRouteStatsHalfLife)
+	PerformRequest(router, http.MethodGet, "/beta")
+	snapshot := stats.Snapshot()
+	assert.InDelta(t, 2, snapshot[0].Hits, 1e-9)
+	assert.InDelta(t, 1.5, snapshot[1].Hits, 1e-9)
+
+	stats.SetHalfLife(0)
+	now = now.Add(time.Hour)
+	assert.InDelta(t, 2, stats.Snapshot()[0].Hits, 1e-9)
+}
+
+func TestRouteStatsReprioritize(t *testing.T) {
+	router := newStatsRouter()
+	root := router.trees.get(http.MethodGet)
+	assert.Equal(t, "abg", root.indices)
+
+	stats := router.RouteStats()
+	for i := 0; i < 3; i++ {
+		PerformRequest(router, http.MethodGet, "/gamma/1")
+	}
+	PerformRequest(router, http.MethodGet, "/beta")
+	stats.Reprioritize()
+	// the serving engine reordered a copy of the trees
+	root = router.trees.get(http.MethodGet)
+	assert.Equal(t, "gba", root.indices)
+	checkPriorities(t, root)
+
+	for path, code := range map[string]int{"/alpha": 200, "/beta": 200, "/gamma/2": 200, "/delta": 404} {
+		assert.Equal(t, code, PerformRequest(router, http.MethodGet, path).Code, path)
+	}
+
+	// the learned ordering is restored from the exported statistics
+	var buf bytes.Buffer
+	require.NoError(t, stats.Export(&buf))
+	other := newStatsRouter()
+	other.GET("/epsilon", handlerTest1)
+	require.NoError(t, other.RouteStats().Import(strings.NewReader(buf.String())))
+	other.RouteStats().Reprioritize()
+	assert.Equal(t, "gbae", other.trees.get(http.MethodGet).indices)
+
+	assert.Error(t, other.RouteStats().Import(strings.NewReader("{")))
+}
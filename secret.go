@@ -0,0 +1,189 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jialequ/mpgw/internal/json"
+)
+
+// Redacted replaces the secrets and the redacted values in the outputs.
+const Redacted = "[REDACTED]"
+
+// minRedactedSecret is the length under which secret values are not searched
+// in the outputs, as they would redact unrelated text.
+const minRedactedSecret = 4
+
+// Secret is a string holding a secret, such as a key or a credential, which is
+// redacted when formatted, marshaled to JSON or logged with log/slog. Reveal
+// returns its value.
+type Secret string
+
+// Reveal returns the value of the secret.
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+// String returns Redacted, or "" if the secret is empty.
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return Redacted
+}
+
+// GoString returns the redacted secret, for the %#v verb.
+func (s Secret) GoString() string {
+	return `gin.Secret("` + s.String() + `")`
+}
+
+// MarshalJSON marshals the redacted secret.
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// LogValue returns the redacted secret, see slog.LogValuer.
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+// RedactionRegistry is a set of secret values, headers and query parameters
+// redacted by the outputs of the engine: the logger, the recovery dumps and
+// the HAR recorder.
+type RedactionRegistry struct {
+	mu       sync.RWMutex
+	headers  map[string]struct{}
+	query    map[string]struct{}
+	secrets  map[string]struct{}
+	replacer *strings.Replacer
+}
+
+// Redactions is the registry used by the outputs of the engine. It redacts the
+// Authorization, Proxy-Authorization, Cookie and Set-Cookie headers, and the
+// secrets of the upstreams.
+var Redactions = NewRedactionRegistry()
+
+// NewRedactionRegistry returns a registry redacting the Authorization,
+// Proxy-Authorization, Cookie and Set-Cookie headers.
+func NewRedactionRegistry() *RedactionRegistry {
+	r := &RedactionRegistry{
+		headers: make(map[string]struct{}),
+		query:   make(map[string]struct{}),
+		secrets: make(map[string]struct{}),
+	}
+	r.AddHeaders("Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie")
+	return r
+}
+
+// AddSecrets registers secret values, replaced wherever they appear. Values
+// shorter than 4 bytes are ignored.
+func (r *RedactionRegistry) AddSecrets(secrets ...Secret) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range secrets {
+		if len(s) >= minRedactedSecret {
+			r.secrets[string(s)] = struct{}{}
+		}
+	}
+	r.rebuild()
+}
+
+// RemoveSecrets unregisters secret values, such as expired tokens.
+func (r *RedactionRegistry) RemoveSecrets(secrets ...Secret) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range secrets {
+		delete(r.secrets, string(s))
+	}
+	r.rebuild()
+}
+
+func (r *RedactionRegistry) rebuild() {
+	if len(r.secrets) == 0 {
+		r.replacer = nil
+		return
+	}
+	values := make([]string, 0, len(r.secrets))
+	for s := range r.secrets {
+		values = append(values, s)
+	}
+	// longest first, so that a secret containing another is fully replaced
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	pairs := make([]string, 0, 2*len(values))
+	for _, s := range values {
+		pairs = append(pairs, s, Redacted)
+	}
+	r.replacer = strings.NewReplacer(pairs...)
+}
+
+// AddHeaders registers headers whose values are redacted.
+func (r *RedactionRegistry) AddHeaders(names ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, name := range names {
+		r.headers[http.CanonicalHeaderKey(name)] = struct{}{}
+	}
+}
+
+// AddQuery registers query parameters whose values are redacted.
+func (r *RedactionRegistry) AddQuery(params ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, param := range params {
+		r.query[param] = struct{}{}
+	}
+}
+
+// Header reports whether the values of the header are redacted.
+func (r *RedactionRegistry) Header(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.headers[http.CanonicalHeaderKey(name)]
+	return ok
+}
+
+// Query reports whether the values of the query parameter are redacted.
+func (r *RedactionRegistry) Query(param string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.query[param]
+	return ok
+}
+
+// Redact replaces the secret values found in s.
+func (r *RedactionRegistry) Redact(s string) string {
+	r.mu.RLock()
+	replacer := r.replacer
+	r.mu.RUnlock()
+	if replacer == nil {
+		return s
+	}
+	return replacer.Replace(s)
+}
+
+// RedactQuery redacts the values of the registered parameters of a raw query,
+// and the secret values it contains. It keeps the order of the parameters.
+func (r *RedactionRegistry) RedactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	params := strings.Split(rawQuery, "&")
+	for i, param := range params {
+		key, _, ok := strings.Cut(param, "=")
+		if !ok {
+			continue
+		}
+		if name, err := url.QueryUnescape(key); err == nil && r.Query(name) {
+			params[i] = key + "=" + url.QueryEscape(Redacted)
+		}
+	}
+	return r.Redact(strings.Join(params, "&"))
+}
@@ -0,0 +1,141 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/jialequ/mpgw/internal/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withRedactions replaces the global registry for the duration of a test.
+func withRedactions(t *testing.T) *RedactionRegistry {
+	old := Redactions
+	Redactions = NewRedactionRegistry()
+	t.Cleanup(func() { Redactions = old })
+	return Redactions
+}
+
+func TestSecret(t *testing.T) {
+	s := Secret("hunter22")
+	assert.Equal(t, "hunter22", s.Reveal())
+	assert.Equal(t, Redacted, fmt.Sprint(s))
+	assert.Equal(t, Redacted, fmt.Sprintf("%s %v", s, s)[:len(Redacted)])
+	assert.Equal(t, `"[REDACTED]"`, fmt.Sprintf("%q", s))
+	assert.Equal(t, `gin.Secret("[REDACTED]")`, fmt.Sprintf("%#v", s))
+	assert.Empty(t, Secret("").String())
+
+	conf := struct {
+		Key  Secret `json:"key"`
+		Name string `json:"name"`
+	}{Key: s, Name: "jwt"}
+	data, err := json.Marshal(conf)
+	require.NoError(t, err)
+	assert.Equal(t, `{"key":"[REDACTED]","name":"jwt"}`, string(data))
+	assert.Contains(t, fmt.Sprintf("%+v", conf), "Key:[REDACTED]")
+
+	// secrets are still loaded from JSON
+	require.NoError(t, json.Unmarshal([]byte(`{"key":"loaded"}`), &conf))
+	assert.Equal(t, "loaded", conf.Key.Reveal())
+
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("config", "key", s)
+	assert.Contains(t, buf.String(), "key=[REDACTED]")
+	assert.NotContains(t, buf.String(), "hunter22")
+}
+
+func TestRedactionRegistry(t *testing.T) {
+	r := NewRedactionRegistry()
+	assert.Equal(t, "no secret", r.Redact("no secret"))
+	assert.True(t, r.Header("authorization"))
+	assert.False(t, r.Header("X-Api-Key"))
+
+	r.AddSecrets("abc", "s3cret", "s3cret-longer")
+	r.AddHeaders("x-api-key")
+	r.AddQuery("token")
+	assert.True(t, r.Header("X-Api-Key"))
+	assert.True(t, r.Query("token"))
+	assert.Equal(t, "abc [REDACTED] [REDACTED]", r.Redact("abc s3cret s3cret-longer"))
+	assert.Equal(t, "a=1&token=%5BREDACTED%5D&b=[REDACTED]&flag", r.RedactQuery("a=1&token=xyz&b=s3cret&flag"))
+	assert.Empty(t, r.RedactQuery(""))
+
+	r.RemoveSecrets("s3cret", "s3cret-longer")
+	assert.Equal(t, "s3cret", r.Redact("s3cret"))
+}
+
+func TestRedactionOutputs(t *testing.T) {
+	r := withRedactions(t)
+	r.AddSecrets("tok-12345")
+	r.AddQuery("api_key")
+	r.AddHeaders("X-Api-Key")
+
+	var logs bytes.Buffer
+	router := New()
+	router.Use(LoggerWithWriter(&logs), RecoveryWithWriter(&logs))
+	router.GET("/fail", func(c *Context) {
+		c.Error(errors.New("upstream rejected tok-12345")) //nolint: errcheck
+		c.Status(http.StatusBadGateway)
+	})
+	router.GET("/panic", func(c *Context) {
+		panic("cannot use tok-12345")
+	})
+
+	PerformRequest(router, http.MethodGet, "/fail?api_key=k1&q=tok-12345")
+	assert.Contains(t, logs.String(), "/fail?api_key=%5BREDACTED%5D&q=[REDACTED]")
+	assert.Contains(t, logs.String(), "upstream rejected [REDACTED]")
+	assert.NotContains(t, logs.String(), "k1")
+	assert.NotContains(t, logs.String(), "tok-12345")
+
+	SetMode(DebugMode)
+	defer SetMode(TestMode)
+	logs.Reset()
+	PerformRequest(router, http.MethodGet, "/panic", header{"X-Api-Key", "k2"}, header{"Authorization", "Bearer k3"})
+	assert.Contains(t, logs.String(), "cannot use [REDACTED]")
+	assert.Contains(t, logs.String(), "X-Api-Key: *")
+	assert.Contains(t, logs.String(), "Authorization: *")
+	assert.NotContains(t, logs.String(), "X-Api-Key: k2")
+	assert.NotContains(t, logs.String(), "Authorization: Bearer")
+	assert.NotContains(t, logs.String(), "tok-12345")
+
+	var recorded *HAR
+	router = New()
+	router.Use(HARRecorder(HARConfig{Sink: func(har *HAR) error { recorded = har; return nil }}))
+	router.GET("/har", func(c *Context) {
+		c.Header("X-Api-Key", "k4")
+		c.String(http.StatusOK, "token tok-12345")
+	})
+	PerformRequest(router, http.MethodGet, "/har?api_key=k5", header{"X-Api-Key", "k6"})
+	require.NotNil(t, recorded)
+	data, err := json.Marshal(recorded)
+	require.NoError(t, err)
+	for _, leaked := range []string{"k4", "k5", "k6", "tok-12345"} {
+		assert.NotContains(t, string(data), leaked)
+	}
+}
+
+func TestUpstreamSecretsRedacted(t *testing.T) {
+	r := withRedactions(t)
+	router := New()
+	_, err := router.AddUpstream("users", UpstreamConfig{
+		URL:  "http://users.internal",
+		Auth: UpstreamAuth{BearerToken: "static-token"},
+	})
+	require.NoError(t, err)
+	_, err = router.AddUpstream("orders", UpstreamConfig{
+		URL: "http://orders.internal",
+		Auth: UpstreamAuth{ClientCredentials: &ClientCredentials{
+			TokenURL: "http://auth.internal/token", ClientID: "gateway", ClientSecret: "client-secret",
+		}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "[REDACTED] [REDACTED]", r.Redact("static-token client-secret"))
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Shed describes a request rejected to shed load, by a rate limit, a
+// concurrency limit or a maintenance, answered by Context.AbortWithShed with
+// the headers telling the client when to retry. All the shedding paths of the
+// engine answer with it, so that clients can handle them the same way.
+type Shed struct {
+	// Status is the status of the response, such as 429 Too Many Requests.
+	// Optional. Default value is 503 Service Unavailable.
+	Status int
+
+	// RetryAfter is the delay after which the request may be retried,
+	// advertised in the Retry-After header rounded up to the second. Optional.
+	// Default value is Reset when the quota is exhausted.
+	RetryAfter time.Duration
+
+	// Limit, Remaining and Reset describe the quota of a rate limit: the
+	// number of requests allowed per window, the ones left in the current one
+	// and the delay until its end. They are advertised in the RateLimit-Limit,
+	// RateLimit-Remaining and RateLimit-Reset headers when Limit is set.
+	// Optional.
+	Limit     int
+	Remaining int
+	Reset     time.Duration
+
+	// Policy is advertised in the RateLimit-Policy header, such as "100;w=60".
+	// Optional.
+	Policy string
+}
+
+// writeHeaders sets the retry hint headers of the shed request.
+func (s Shed) writeHeaders(header http.Header) {
+	retryAfter := s.RetryAfter
+	if retryAfter <= 0 && s.Limit > 0 && s.Remaining <= 0 {
+		retryAfter = s.Reset
+	}
+	if retryAfter > 0 {
+		header.Set("Retry-After", ceilSeconds(retryAfter))
+	}
+	if s.Limit > 0 {
+		header.Set("RateLimit-Limit", strconv.Itoa(s.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(max(s.Remaining, 0)))
+		header.Set("RateLimit-Reset", ceilSeconds(s.Reset))
+	}
+	if s.Policy != "" {
+		header.Set("RateLimit-Policy", s.Policy)
+	}
+}
+
+// status returns the status of the response.
+func (s Shed) status() int {
+	if s.Status == 0 {
+		return http.StatusServiceUnavailable
+	}
+	return s.Status
+}
+
+// ceilSeconds formats d as a number of seconds, rounded up.
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64((max(d, 0)+time.Second-1)/time.Second), 10)
+}
+
+// AbortWithShed rejects the request to shed load, with the status and the
+// retry hint headers of s.
+func (c *Context) AbortWithShed(s Shed) {
+	s.writeHeaders(c.Writer.Header())
+	c.AbortWithStatus(s.status())
+}
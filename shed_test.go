@@ -0,0 +1,53 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextAbortWithShed(t *testing.T) {
+	for _, tt := range []struct {
+		shed   Shed
+		status int
+		header http.Header
+	}{
+		{Shed{}, http.StatusServiceUnavailable, http.Header{}},
+		{Shed{RetryAfter: 1500 * time.Millisecond}, http.StatusServiceUnavailable, http.Header{"Retry-After": {"2"}}},
+		{
+			Shed{Status: http.StatusTooManyRequests, Limit: 100, Remaining: -1, Reset: 30 * time.Second, Policy: "100;w=60"},
+			http.StatusTooManyRequests,
+			http.Header{
+				"Retry-After":         {"30"},
+				"Ratelimit-Limit":     {"100"},
+				"Ratelimit-Remaining": {"0"},
+				"Ratelimit-Reset":     {"30"},
+				"Ratelimit-Policy":    {"100;w=60"},
+			},
+		},
+		{
+			Shed{Status: http.StatusTooManyRequests, RetryAfter: time.Second, Limit: 10, Remaining: 3, Reset: 5 * time.Second},
+			http.StatusTooManyRequests,
+			http.Header{
+				"Retry-After":         {"1"},
+				"Ratelimit-Limit":     {"10"},
+				"Ratelimit-Remaining": {"3"},
+				"Ratelimit-Reset":     {"5"},
+			},
+		},
+	} {
+		w := httptest.NewRecorder()
+		c, _ := CreateTestContext(w)
+		c.AbortWithShed(tt.shed)
+		assert.True(t, c.IsAborted())
+		assert.Equal(t, tt.status, w.Code)
+		assert.Equal(t, tt.header, w.Header())
+	}
+}
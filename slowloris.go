@@ -0,0 +1,294 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultSlowlorisHeaderTimeout = 10 * time.Second
+	defaultSlowlorisUploadGrace   = 5 * time.Second
+)
+
+// Reasons reported in SlowlorisEvent.
+const (
+	SlowlorisHeaderTimeout = "header timeout"
+	SlowlorisSlowUpload    = "slow upload"
+)
+
+// SlowlorisConfig defines the config of a SlowlorisListener.
+type SlowlorisConfig struct {
+	// HeaderTimeout is the maximum duration to receive a request head, counted from
+	// the connection accept for the first request and from the first byte for the
+	// following ones. Unlike http.Server.ReadHeaderTimeout it is set per listener,
+	// and the earliest of both deadlines applies.
+	// Optional. Default value is 10s.
+	HeaderTimeout time.Duration
+
+	// MinUploadRate is the minimum throughput of request bodies, in bytes per
+	// second. Only the time spent waiting for the client counts, so slow handlers
+	// are not penalized. Optional. Default value is 0 (not enforced).
+	MinUploadRate int64
+
+	// UploadGrace is the waiting time allowed before MinUploadRate is enforced.
+	// Optional. Default value is 5s.
+	UploadGrace time.Duration
+
+	// OnAbuse is called when an abusive connection is closed. Optional.
+	OnAbuse func(event SlowlorisEvent)
+}
+
+// SlowlorisEvent describes an abusive connection closed by a SlowlorisListener.
+type SlowlorisEvent struct {
+	Reason     string
+	RemoteAddr string
+	// Bytes is the number of bytes received for the offending head or body.
+	Bytes int64
+	// Elapsed is the time spent receiving the offending head or body.
+	Elapsed time.Duration
+}
+
+// SlowlorisStats are the counters of a SlowlorisListener.
+type SlowlorisStats struct {
+	Accepted       uint64
+	HeaderTimeouts uint64
+	SlowUploads    uint64
+}
+
+// SlowlorisListener is a net.Listener closing the connections which send their
+// request heads or bodies too slowly, see SlowlorisConfig. It serves plain text
+// HTTP/1.x; upgraded and HTTP/2 connections are not checked.
+type SlowlorisListener struct {
+	net.Listener
+	conf SlowlorisConfig
+
+	accepted       atomic.Uint64
+	headerTimeouts atomic.Uint64
+	slowUploads    atomic.Uint64
+}
+
+// NewSlowlorisListener wraps l with the given protection config.
+func NewSlowlorisListener(l net.Listener, conf SlowlorisConfig) *SlowlorisListener {
+	if conf.HeaderTimeout <= 0 {
+		conf.HeaderTimeout = defaultSlowlorisHeaderTimeout
+	}
+	if conf.UploadGrace <= 0 {
+		conf.UploadGrace = defaultSlowlorisUploadGrace
+	}
+	return &SlowlorisListener{Listener: l, conf: conf}
+}
+
+// Accept waits for and returns the next protected connection.
+func (l *SlowlorisListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	l.accepted.Add(1)
+	return &slowlorisConn{Conn: conn, listener: l, headStart: time.Now()}, nil
+}
+
+// Stats returns a snapshot of the listener counters.
+func (l *SlowlorisListener) Stats() SlowlorisStats {
+	return SlowlorisStats{
+		Accepted:       l.accepted.Load(),
+		HeaderTimeouts: l.headerTimeouts.Load(),
+		SlowUploads:    l.slowUploads.Load(),
+	}
+}
+
+func (l *SlowlorisListener) abuse(event SlowlorisEvent) {
+	if event.Reason == SlowlorisHeaderTimeout {
+		l.headerTimeouts.Add(1)
+	} else {
+		l.slowUploads.Add(1)
+	}
+	if l.conf.OnAbuse != nil {
+		l.conf.OnAbuse(event)
+	}
+}
+
+// slowlorisConn follows the request framing with an httpFramer and arms a read
+// deadline according to the phase of the current request.
+type slowlorisConn struct {
+	net.Conn
+	listener *SlowlorisListener
+	framer   httpFramer
+	buf      []byte
+	err      error
+
+	// headStart is when the current request head started, zero when idle.
+	headStart time.Time
+	// bodyBytes and bodyWaited measure the current request body.
+	bodyBytes  int64
+	bodyWaited time.Duration
+
+	mu sync.Mutex
+	// serverDeadline is the read deadline set by net/http.
+	serverDeadline time.Time
+	// deadline is the deadline armed for the current phase.
+	deadline time.Time
+}
+
+func (sc *slowlorisConn) Read(p []byte) (int, error) {
+	f := &sc.framer
+	if perr := f.resume(); perr != nil {
+		f.out = append(f.out, "REJECTED\r\n\r\n"...)
+		sc.err = perr
+	} else if f.state == stateHead && len(f.raw) > 0 && sc.headStart.IsZero() {
+		sc.headStart = time.Now()
+	}
+	for len(f.out) == 0 {
+		if sc.err != nil {
+			return 0, sc.err
+		}
+		if f.state == stateTunnel && len(f.raw) == 0 {
+			sc.arm(time.Time{})
+			return sc.Conn.Read(p)
+		}
+		if sc.buf == nil {
+			sc.buf = make([]byte, hardenedReadSize)
+		}
+
+		inBody := f.state != stateHead && f.state != stateUpgrade
+		if f.state == stateUpgrade {
+			// the upgrade request is being served
+			sc.arm(time.Time{})
+		} else {
+			sc.arm(sc.phaseDeadline(inBody))
+		}
+		start := time.Now()
+		n, err := sc.Conn.Read(sc.buf)
+		if inBody {
+			sc.bodyWaited += time.Since(start)
+			sc.bodyBytes += int64(n)
+		}
+
+		if perr := f.feed(sc.buf[:n]); perr != nil {
+			f.out = append(f.out, "REJECTED\r\n\r\n"...)
+			sc.err = perr
+			continue
+		}
+		if f.state == stateHead {
+			if len(f.raw) == 0 {
+				sc.headStart = time.Time{}
+			} else if sc.headStart.IsZero() {
+				sc.headStart = start
+			}
+		}
+		if f.state != stateHead && !inBody {
+			sc.bodyBytes, sc.bodyWaited = 0, 0
+		}
+
+		if err != nil {
+			if sc.expired(err) {
+				sc.report(inBody)
+				sc.Conn.Close() //nolint: errcheck
+				sc.err = err
+				return 0, err
+			}
+			if isTimeout(err) {
+				// net/http interrupts reads with deadlines and keeps using the connection
+				f.interrupt()
+				if len(f.out) == 0 {
+					return 0, err
+				}
+				break
+			}
+			f.flush()
+			sc.err = err
+		}
+	}
+	return f.read(p), nil
+}
+
+// phaseDeadline returns the deadline of the next read.
+func (sc *slowlorisConn) phaseDeadline(inBody bool) time.Time {
+	conf := sc.listener.conf
+	if !inBody {
+		if sc.headStart.IsZero() {
+			return time.Time{}
+		}
+		return sc.headStart.Add(conf.HeaderTimeout)
+	}
+	if conf.MinUploadRate <= 0 {
+		return time.Time{}
+	}
+	allowed := conf.UploadGrace + time.Duration(sc.bodyBytes)*time.Second/time.Duration(conf.MinUploadRate)
+	return time.Now().Add(allowed - sc.bodyWaited)
+}
+
+// report counts and reports the connection as abusive.
+func (sc *slowlorisConn) report(inBody bool) {
+	event := SlowlorisEvent{
+		Reason:     SlowlorisHeaderTimeout,
+		RemoteAddr: sc.RemoteAddr().String(),
+		Bytes:      int64(len(sc.framer.raw)),
+		Elapsed:    time.Since(sc.headStart),
+	}
+	if inBody {
+		event.Reason = SlowlorisSlowUpload
+		event.Bytes = sc.bodyBytes
+		event.Elapsed = sc.bodyWaited
+	}
+	sc.listener.abuse(event)
+}
+
+// hijacked implements connHijackNotifier: the connection no longer carries
+// requests, so the deadlines of their phases no longer apply.
+func (sc *slowlorisConn) hijacked() {
+	sc.framer.tunnel()
+	sc.headStart = time.Time{}
+	sc.arm(time.Time{})
+}
+
+// arm sets the deadline of the current phase, keeping the one of net/http if earlier.
+func (sc *slowlorisConn) arm(deadline time.Time) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.deadline = deadline
+	sc.apply() //nolint: errcheck
+}
+
+// expired reports whether err is caused by the deadline of the current phase
+// rather than the one set by net/http.
+func (sc *slowlorisConn) expired(err error) bool {
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		return false
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return !sc.deadline.IsZero() && !time.Now().Before(sc.deadline) &&
+		(sc.serverDeadline.IsZero() || sc.deadline.Before(sc.serverDeadline))
+}
+
+// apply sets the earliest of both deadlines on the connection. sc.mu must be held.
+func (sc *slowlorisConn) apply() error {
+	deadline := sc.serverDeadline
+	if deadline.IsZero() || (!sc.deadline.IsZero() && sc.deadline.Before(deadline)) {
+		deadline = sc.deadline
+	}
+	return sc.Conn.SetReadDeadline(deadline)
+}
+
+func (sc *slowlorisConn) SetReadDeadline(t time.Time) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.serverDeadline = t
+	return sc.apply()
+}
+
+func (sc *slowlorisConn) SetDeadline(t time.Time) error {
+	if err := sc.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return sc.Conn.SetWriteDeadline(t)
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveSlowloris(t *testing.T, conf SlowlorisConfig) (*SlowlorisListener, chan SlowlorisEvent) {
+	events := make(chan SlowlorisEvent, 1)
+	conf.OnAbuse = func(event SlowlorisEvent) { events <- event }
+
+	router := New()
+	router.POST("/echo", func(c *Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, "%s", body)
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	sl := NewSlowlorisListener(listener, conf)
+	t.Cleanup(func() { sl.Close() })
+	go http.Serve(sl, router) //nolint: errcheck
+	return sl, events
+}
+
+func dialSlowloris(t *testing.T, sl *SlowlorisListener, raw string) net.Conn {
+	conn, err := net.Dial("tcp", sl.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	_, err = io.WriteString(conn, raw)
+	require.NoError(t, err)
+	return conn
+}
+
+func TestSlowlorisHeaderTimeout(t *testing.T) {
+	sl, events := serveSlowloris(t, SlowlorisConfig{HeaderTimeout: 50 * time.Millisecond})
+
+	conn := dialSlowloris(t, sl, "POST /echo HTTP/1.1\r\nHost")
+	conn.SetReadDeadline(time.Now().Add(time.Second)) //nolint: errcheck
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err, "the connection must be closed by the server")
+
+	event := <-events
+	assert.Equal(t, SlowlorisHeaderTimeout, event.Reason)
+	assert.Equal(t, int64(len("POST /echo HTTP/1.1\r\nHost")), event.Bytes)
+	assert.Equal(t, uint64(1), sl.Stats().HeaderTimeouts)
+}
+
+func TestSlowlorisSlowUpload(t *testing.T) {
+	sl, events := serveSlowloris(t, SlowlorisConfig{MinUploadRate: 1000, UploadGrace: 50 * time.Millisecond})
+
+	conn := dialSlowloris(t, sl, "POST /echo HTTP/1.1\r\nHost: x\r\nContent-Length: 10000\r\n\r\n0123456789")
+	conn.SetReadDeadline(time.Now().Add(time.Second)) //nolint: errcheck
+	_, err := io.ReadAll(conn)
+	assert.NoError(t, err, "the connection must be closed by the server")
+
+	event := <-events
+	assert.Equal(t, SlowlorisSlowUpload, event.Reason)
+	assert.Equal(t, uint64(1), sl.Stats().SlowUploads)
+	assert.Equal(t, uint64(0), sl.Stats().HeaderTimeouts)
+}
+
+func TestSlowlorisUpgradeNotSwitched(t *testing.T) {
+	sl, events := serveSlowloris(t, SlowlorisConfig{HeaderTimeout: 50 * time.Millisecond})
+
+	// the deadlines still apply after an upgrade request served without
+	// switching protocols
+	conn := dialSlowloris(t, sl, "POST /echo HTTP/1.1\r\nHost: x\r\nUpgrade: websocket\r\n\r\nPOST /echo HTTP/1.1\r\nHost")
+	conn.SetReadDeadline(time.Now().Add(time.Second)) //nolint: errcheck
+	out, err := io.ReadAll(conn)
+	assert.NoError(t, err, "the connection must be closed by the server")
+	assert.True(t, strings.HasPrefix(string(out), "HTTP/1.1 200 OK"))
+
+	event := <-events
+	assert.Equal(t, SlowlorisHeaderTimeout, event.Reason)
+}
+
+func TestSlowlorisKeepAlive(t *testing.T) {
+	sl, events := serveSlowloris(t, SlowlorisConfig{HeaderTimeout: 50 * time.Millisecond, MinUploadRate: 1000})
+
+	client := &http.Client{}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Post("http://"+sl.Addr().String()+"/echo", MIMEPlain, strings.NewReader("hello"))
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "hello", string(body))
+		// idle keep-alive connections are not subject to the header timeout
+		time.Sleep(60 * time.Millisecond)
+	}
+	assert.Equal(t, uint64(1), sl.Stats().Accepted)
+	assert.Empty(t, events)
+}
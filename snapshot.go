@@ -0,0 +1,425 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jialequ/mpgw/internal/bytesconv"
+)
+
+// ErrInvalidSnapshot is returned by LoadRoutes for a snapshot it cannot read.
+var ErrInvalidSnapshot = errors.New("snapshot: invalid route snapshot")
+
+// snapshotMagic starts the route snapshots, with the version of their format.
+const snapshotMagic = "GIN-ROUTES\x00\x01"
+
+// the flags of the snapshot nodes.
+const (
+	snapshotWildChild byte = 1 << iota
+	snapshotYield
+	snapshotHandlers
+	snapshotAlt
+)
+
+// SaveRoutes writes a snapshot of the route trees of the engine, loaded at
+// startup by LoadRoutes in place of registering the routes: the large route
+// tables, such as the generated ones, are then served without being built
+// again on each cold start. The handlers are saved by function name, and the
+// routes with their name, description, tags and priority. For example, in a
+// build step:
+//
+//	router := api.NewRouter() // registers the routes
+//	err := router.SaveRoutes(file)
+func (engine *Engine) SaveRoutes(w io.Writer) error {
+	t := engine.table.Load()
+	s := &snapshotWriter{w: bufio.NewWriter(w), names: make(map[string]uint64)}
+	s.w.WriteString(snapshotMagic) //nolint: errcheck
+
+	s.uint(uint64(len(t.routes)))
+	for _, route := range t.routes {
+		s.string(route.Host)
+		s.string(route.Method)
+		s.string(route.Path)
+		s.string(route.Name)
+		s.string(route.Description)
+		s.uint(uint64(len(route.Tags)))
+		for _, tag := range route.Tags {
+			s.string(tag)
+		}
+		s.int(int64(route.priority))
+	}
+
+	type hostTree struct {
+		host string
+		tree methodTree
+	}
+	var trees []hostTree
+	for _, tree := range t.trees {
+		trees = append(trees, hostTree{tree: tree})
+	}
+	for _, pattern := range sortedKeys(t.hosts) {
+		for _, tree := range t.hosts[pattern].trees {
+			trees = append(trees, hostTree{host: pattern, tree: tree})
+		}
+	}
+	s.uint(uint64(len(trees)))
+	for _, ht := range trees {
+		s.string(ht.host)
+		s.string(ht.tree.method)
+		s.node(ht.tree.root)
+	}
+	return s.w.Flush()
+}
+
+// snapshotWriter writes a route snapshot, the handler names once, then by
+// index.
+type snapshotWriter struct {
+	w     *bufio.Writer
+	names map[string]uint64
+	buf   [binary.MaxVarintLen64]byte
+}
+
+// The write errors are returned by Flush.
+
+func (s *snapshotWriter) uint(v uint64) {
+	s.w.Write(s.buf[:binary.PutUvarint(s.buf[:], v)]) //nolint: errcheck
+}
+
+func (s *snapshotWriter) int(v int64) {
+	s.w.Write(s.buf[:binary.PutVarint(s.buf[:], v)]) //nolint: errcheck
+}
+
+func (s *snapshotWriter) string(v string) {
+	s.uint(uint64(len(v)))
+	s.w.WriteString(v) //nolint: errcheck
+}
+
+func (s *snapshotWriter) node(n *node) {
+	s.string(n.path)
+	s.string(n.indices)
+	s.string(n.fullPath)
+	s.w.WriteByte(byte(n.nType)) //nolint: errcheck
+	s.uint(uint64(n.priority))
+	var flags byte
+	if n.wildChild {
+		flags |= snapshotWildChild
+	}
+	if n.yield {
+		flags |= snapshotYield
+	}
+	if n.handlers != nil {
+		flags |= snapshotHandlers
+	}
+	if n.alt != nil {
+		flags |= snapshotAlt
+	}
+	s.w.WriteByte(flags) //nolint: errcheck
+	if n.handlers != nil {
+		s.uint(uint64(len(n.handlers)))
+		for _, h := range n.handlers {
+			name := nameOfFunction(h)
+			i, ok := s.names[name]
+			if !ok {
+				i = uint64(len(s.names))
+				s.names[name] = i
+			}
+			s.uint(i)
+			if !ok {
+				s.string(name)
+			}
+		}
+	}
+	s.uint(uint64(len(n.children)))
+	for _, child := range n.children {
+		s.node(child)
+	}
+	if n.alt != nil {
+		s.node(n.alt)
+	}
+}
+
+// LoadRoutes registers the routes of a snapshot written by SaveRoutes, with
+// the handlers of the same names, typically the ones of the route
+// registrations it replaces. The param constraints must be registered first,
+// see Engine.RegisterConstraint. The engine must have no routes yet; more
+// routes can be registered once it is loaded:
+//
+//	router := gin.New()
+//	err := router.LoadRoutes(file, api.ListUsers, api.GetUser, authMiddleware)
+//
+// The routes are loaded in the root group. Their other annotations, such as
+// their timeout or their metadata, are not saved. The handlers are rebound by
+// function name: the closures created by the same function, such as the
+// handlers returned by a factory with different arguments, share their name
+// and cannot be told apart, their routes are registered after loading the
+// snapshot instead. It returns an error, leaving the engine unchanged, if the
+// snapshot is invalid or a handler is missing.
+func (engine *Engine) LoadRoutes(r io.Reader, handlers ...HandlerFunc) error {
+	byName := make(map[string]HandlerFunc, len(handlers))
+	for _, h := range handlers {
+		name := nameOfFunction(h)
+		if _, ok := byName[name]; ok {
+			return fmt.Errorf("snapshot: ambiguous handler name %q", name)
+		}
+		byName[name] = h
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s := &snapshotReader{
+		data:        bytesconv.BytesToString(data),
+		handlers:    byName,
+		constraints: engine.routeConstraints(),
+	}
+	routes, trees, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	engine.update(func() {
+		if len(engine.routes) > 0 {
+			err = errors.New("snapshot: the engine already has routes")
+			return
+		}
+		for _, tree := range trees {
+			methods := engine.treesFor(tree.host)
+			*methods = append(*methods, methodTree{method: tree.method, root: tree.root})
+		}
+		engine.routes = routes
+		engine.routeIndex = make(map[string]*Route, len(routes))
+		for _, route := range routes {
+			route.group = &engine.RouterGroup
+			engine.routeIndex[routeKey(route.Host, route.Method, route.Path)] = route
+			if route.Name != "" {
+				if engine.namedRoutes == nil {
+					engine.namedRoutes = make(map[string]*Route)
+				}
+				engine.namedRoutes[route.Name] = route
+			}
+			if route.priority != 0 {
+				engine.prioritized++
+			}
+
+			paramsCount := countParams(route.Path)
+			if isCatchAllHost(route.Host) {
+				paramsCount++
+			}
+			engine.maxParams = max(engine.maxParams, paramsCount)
+			engine.maxSections = max(engine.maxSections, countSections(route.Path))
+		}
+	})
+	if err == nil {
+		debugPrint("Loaded %d routes from the snapshot", len(routes))
+	}
+	return err
+}
+
+// snapshotTree is a tree read from a snapshot.
+type snapshotTree struct {
+	host, method string
+	root         *node
+}
+
+// snapshotReader reads a route snapshot, keeping the first error. Its strings
+// are the ones of the snapshot, not copied.
+type snapshotReader struct {
+	data        string
+	err         error
+	names       []string
+	handlers    map[string]HandlerFunc
+	constraints map[string]func(string) bool
+
+	nodes  []node
+	chains HandlersChain
+}
+
+// maxSnapshotDepth bounds the depth of the trees of a snapshot, and
+// maxSnapshotAlloc is the number of nodes and handlers allocated at once.
+const (
+	maxSnapshotDepth = 1 << 16
+	maxSnapshotAlloc = 1 << 12
+)
+
+func (s *snapshotReader) read() ([]*Route, []snapshotTree, error) {
+	var ok bool
+	if s.data, ok = strings.CutPrefix(s.data, snapshotMagic); !ok {
+		return nil, nil, ErrInvalidSnapshot
+	}
+
+	count := s.len()
+	slab := make([]Route, count)
+	routes := make([]*Route, count)
+	for i := range routes {
+		route := &slab[i]
+		route.Host = s.string()
+		route.Method = s.string()
+		route.Path = s.string()
+		route.Name = s.string()
+		route.Description = s.string()
+		if tags := s.len(); tags > 0 {
+			route.Tags = make([]string, tags)
+			for j := range route.Tags {
+				route.Tags[j] = s.string()
+			}
+		}
+		route.priority = int(s.int())
+		routes[i] = route
+	}
+
+	trees := make([]snapshotTree, s.len())
+	for i := range trees {
+		trees[i].host = s.string()
+		trees[i].method = s.string()
+		trees[i].root = s.node(0)
+	}
+	if s.err == nil && s.data != "" {
+		s.fail(ErrInvalidSnapshot)
+	}
+	if s.err != nil {
+		return nil, nil, s.err
+	}
+	return routes, trees, nil
+}
+
+// fail keeps the first error reading the snapshot, and stops reading it.
+func (s *snapshotReader) fail(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+	s.data = ""
+}
+
+func (s *snapshotReader) uint() uint64 {
+	v, n := binary.Uvarint(bytesconv.StringToBytes(s.data))
+	if n <= 0 {
+		s.fail(ErrInvalidSnapshot)
+		return 0
+	}
+	s.data = s.data[n:]
+	return v
+}
+
+func (s *snapshotReader) int() int64 {
+	v, n := binary.Varint(bytesconv.StringToBytes(s.data))
+	if n <= 0 {
+		s.fail(ErrInvalidSnapshot)
+		return 0
+	}
+	s.data = s.data[n:]
+	return v
+}
+
+func (s *snapshotReader) byte() byte {
+	if s.data == "" {
+		s.fail(ErrInvalidSnapshot)
+		return 0
+	}
+	b := s.data[0]
+	s.data = s.data[1:]
+	return b
+}
+
+// len reads a length, of a string or a list whose items take at least a byte,
+// so at most the length of the rest of the snapshot.
+func (s *snapshotReader) len() int {
+	n := s.uint()
+	if n > uint64(len(s.data)) {
+		s.fail(ErrInvalidSnapshot)
+		return 0
+	}
+	return int(n)
+}
+
+func (s *snapshotReader) string() string {
+	n := s.len()
+	v := s.data[:n]
+	s.data = s.data[n:]
+	return v
+}
+
+// node reads a node and its subtree, rebinding its handlers and constraint.
+func (s *snapshotReader) node(depth int) *node {
+	if depth > maxSnapshotDepth {
+		s.fail(ErrInvalidSnapshot)
+	}
+	if s.err != nil {
+		return nil
+	}
+	if len(s.nodes) == 0 {
+		s.nodes = make([]node, maxSnapshotAlloc)
+	}
+	n := &s.nodes[0]
+	s.nodes = s.nodes[1:]
+	n.path = s.string()
+	n.indices = s.string()
+	n.fullPath = s.string()
+	n.nType = nodeType(s.byte())
+	n.priority = uint32(s.uint())
+	flags := s.byte()
+	n.wildChild = flags&snapshotWildChild != 0
+	n.yield = flags&snapshotYield != 0
+	if flags&snapshotHandlers != 0 {
+		size := s.len()
+		if size == 0 || size >= int(abortIndex) {
+			s.fail(ErrInvalidSnapshot)
+			return nil
+		}
+		if len(s.chains) < size {
+			s.chains = make(HandlersChain, maxSnapshotAlloc)
+		}
+		n.handlers, s.chains = s.chains[:size:size], s.chains[size:]
+		for i := range n.handlers {
+			n.handlers[i] = s.handler(n.fullPath)
+		}
+	}
+	switch {
+	case n.nType == param && strings.IndexByte(n.path, '|') > 0 && !isCompound(n.path):
+		name := n.path[strings.IndexByte(n.path, '|')+1:]
+		if n.constraint = s.constraints[name]; n.constraint == nil {
+			s.fail(fmt.Errorf("snapshot: unknown constraint '%s' in path '%s'", name, n.fullPath))
+		}
+	case n.nType == catchAll && n.path != "":
+		var ok bool
+		if n.constraint, ok = catchAllBounds(n.path); !ok {
+			s.fail(ErrInvalidSnapshot)
+		}
+	}
+	if children := s.len(); children > 0 {
+		n.children = make([]*node, children)
+		for i := range n.children {
+			n.children[i] = s.node(depth + 1)
+		}
+	}
+	if flags&snapshotAlt != 0 {
+		n.alt = s.node(depth + 1)
+	}
+	return n
+}
+
+// handler reads a handler name and returns the handler of that name.
+func (s *snapshotReader) handler(fullPath string) HandlerFunc {
+	i := s.uint()
+	switch {
+	case i == uint64(len(s.names)):
+		s.names = append(s.names, s.string())
+	case i > uint64(len(s.names)):
+		s.fail(ErrInvalidSnapshot)
+	}
+	if s.err != nil {
+		return nil
+	}
+	h := s.handlers[s.names[i]]
+	if h == nil {
+		s.fail(fmt.Errorf("snapshot: handler %s of '%s' not given", s.names[i], fullPath))
+	}
+	return h
+}
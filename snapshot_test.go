@@ -0,0 +1,146 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func snapshotAuth(c *Context) {
+	c.Header("X-Auth", "ok")
+}
+
+func snapshotEcho(c *Context) {
+	c.String(http.StatusOK, "%s %v", c.FullPath(), c.Params)
+}
+
+func snapshotPage(c *Context) {
+	c.String(http.StatusOK, "page %s", c.Param("v"))
+}
+
+func snapshotRouter() *Engine {
+	router := New()
+	router.RegisterConstraint("even", func(s string) bool { return strings.HasSuffix(s, "0") })
+	api := router.Group("/api", snapshotAuth)
+	api.GET("/users", snapshotEcho)
+	api.Route("/users", http.MethodGet).Name("users").Tags("users").Describe("Lists the users")
+	api.GET("/users/:id|int", snapshotEcho)
+	api.Route("/users/:id|int", http.MethodGet).Name("user")
+	api.GET("/users/:id|even/even", snapshotEcho)
+	api.GET("/users/:name|alpha", snapshotEcho)
+	api.POST("/users/:id", snapshotEcho)
+	api.GET("/files/*path{1,2}", snapshotEcho)
+	api.GET("/:v", snapshotPage)
+	api.Route("/:v", http.MethodGet).Priority(10)
+	api.GET("/about", snapshotEcho)
+	router.Host("*.example.com").GET("/", snapshotEcho)
+	router.Host("*tenant").GET("/home", snapshotEcho)
+	return router
+}
+
+func TestSaveLoadRoutes(t *testing.T) {
+	router := snapshotRouter()
+	var snapshot bytes.Buffer
+	require.NoError(t, router.SaveRoutes(&snapshot))
+
+	loaded := New()
+	loaded.RegisterConstraint("even", func(s string) bool { return strings.HasSuffix(s, "0") })
+	require.NoError(t, loaded.LoadRoutes(bytes.NewReader(snapshot.Bytes()), snapshotAuth, snapshotEcho, snapshotPage))
+
+	routes, loadedRoutes := router.Routes(), loaded.Routes()
+	require.Len(t, loadedRoutes, len(routes))
+	for i := range routes {
+		routes[i].HandlerFunc, loadedRoutes[i].HandlerFunc = nil, nil
+	}
+	assert.Equal(t, routes, loadedRoutes)
+	for i, tree := range router.trees {
+		assert.Equal(t, treeShape(tree.root), treeShape(loaded.trees[i].root))
+	}
+	assert.Equal(t, router.maxParams, loaded.maxParams)
+	assert.Equal(t, router.maxSections, loaded.maxSections)
+	for _, req := range []struct{ method, host, path string }{
+		{http.MethodGet, "", "/api/users"},
+		{http.MethodGet, "", "/api/users/42"},
+		{http.MethodGet, "", "/api/users/40/even"},
+		{http.MethodGet, "", "/api/users/ada"},
+		{http.MethodGet, "", "/api/users/4-2"},
+		{http.MethodPost, "", "/api/users/ada"},
+		{http.MethodGet, "", "/api/files/a/b"},
+		{http.MethodGet, "", "/api/files/a/b/c"},
+		{http.MethodGet, "", "/api/about"},
+		{http.MethodGet, "", "/api/users/"},
+		{http.MethodGet, "www.example.com", "/"},
+		{http.MethodGet, "acme.test", "/home"},
+	} {
+		want := PerformRequest(router, req.method, req.path, header{"Host", req.host})
+		w := PerformRequest(loaded, req.method, req.path, header{"Host", req.host})
+		assert.Equal(t, want.Code, w.Code, req.path)
+		assert.Equal(t, want.Body.String(), w.Body.String(), req.path)
+		assert.Equal(t, want.Header(), w.Header(), req.path)
+	}
+	assert.Equal(t, "page about", PerformRequest(loaded, http.MethodGet, "/api/about").Body.String())
+
+	// the annotations are kept, and more routes can be registered
+	assert.Equal(t, "/api/users/7", loaded.PathBuilder("user").Param("id", "7").MustBuild())
+	assert.Equal(t, router.RouteDocs(), loaded.RouteDocs())
+	loaded.GET("/api/news", snapshotEcho)
+	loaded.Route("/api/news", http.MethodGet).Priority(20)
+	assert.Equal(t, "/api/news []", PerformRequest(loaded, http.MethodGet, "/api/news").Body.String())
+	assert.Equal(t, "page users", PerformRequest(loaded, http.MethodGet, "/api/users").Body.String())
+}
+
+func TestLoadRoutesErrors(t *testing.T) {
+	var snapshot bytes.Buffer
+	require.NoError(t, snapshotRouter().SaveRoutes(&snapshot))
+	even := func(s string) bool { return true }
+
+	router := New()
+	err := router.LoadRoutes(bytes.NewReader(snapshot.Bytes()), snapshotAuth, snapshotEcho, snapshotPage)
+	require.EqualError(t, err, "snapshot: unknown constraint 'even' in path '/api/users/:id|even/even'")
+
+	router.RegisterConstraint("even", even)
+	err = router.LoadRoutes(bytes.NewReader(snapshot.Bytes()), snapshotAuth, snapshotEcho)
+	require.ErrorContains(t, err, "snapshot: handler github.com/jialequ/mpgw.snapshotPage of '/api/:v' not given")
+	err = router.LoadRoutes(bytes.NewReader(snapshot.Bytes()), snapshotEcho, snapshotEcho)
+	require.EqualError(t, err, `snapshot: ambiguous handler name "github.com/jialequ/mpgw.snapshotEcho"`)
+	assert.Empty(t, router.Routes())
+
+	// the truncated snapshots are invalid
+	for i := 0; i < snapshot.Len(); i++ {
+		err := router.LoadRoutes(bytes.NewReader(snapshot.Bytes()[:i]), snapshotAuth, snapshotEcho, snapshotPage)
+		require.ErrorIs(t, err, ErrInvalidSnapshot, i)
+	}
+	assert.Empty(t, router.Routes())
+
+	router.GET("/", snapshotEcho)
+	err = router.LoadRoutes(bytes.NewReader(snapshot.Bytes()), snapshotAuth, snapshotEcho, snapshotPage)
+	require.EqualError(t, err, "snapshot: the engine already has routes")
+	assert.Len(t, router.Routes(), 1)
+}
+
+func BenchmarkLoadRoutes(b *testing.B) {
+	SetMode(ReleaseMode)
+	defer SetMode(TestMode)
+	specs := generatedRoutes(50000)
+	router := New()
+	router.AddRoutes(specs)
+	var snapshot bytes.Buffer
+	if err := router.SaveRoutes(&snapshot); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := New().LoadRoutes(bytes.NewReader(snapshot.Bytes()), specs[0].Handlers...); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
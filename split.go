@@ -0,0 +1,163 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"hash/fnv"
+	"math/rand"
+)
+
+// SplitVariantKey is the key under which the variant assigned by Split is
+// stored in the context, so that it is available to the log formatters in
+// LogFormatterParams.Keys.
+const SplitVariantKey = "_gin-gonic/gin/splitvariantkey"
+
+const (
+	splitUpstreamKey      = "_gin-gonic/gin/splitupstreamkey"
+	defaultSplitHeader    = "X-Variant"
+	defaultSplitCookieAge = 30 * 24 * 60 * 60
+)
+
+// SplitVariant is a variant of an experiment, served by an upstream.
+type SplitVariant struct {
+	// Name identifies the variant in cookies, headers and logs. Required.
+	Name string
+
+	// Upstream is the name of the upstream serving the variant, see Engine.AddUpstream.
+	Upstream string
+
+	// Weight is the share of the traffic assigned to the variant, relative to
+	// the other variants, e.g. a percentage. Variants of weight 0 are only
+	// served when requested by header or cookie.
+	Weight int
+}
+
+// SplitConfig defines the config of the Split middleware.
+type SplitConfig struct {
+	// Name is the name of the experiment, for the default cookie name and the
+	// sticky assignments. Required.
+	Name string
+
+	// Variants are the variants of the experiment. Required.
+	Variants []SplitVariant
+
+	// Header is a request header selecting a variant by name, for tests and
+	// previews. Optional. Default value is "", the variant cannot be forced.
+	Header string
+
+	// Cookie is the cookie keeping the assigned variant across requests.
+	// Optional. Default value is "split_" followed by Name.
+	Cookie string
+
+	// CookieMaxAge is the lifetime of the cookie, in seconds. A negative value
+	// disables the cookie. Optional. Default value is 30 days.
+	CookieMaxAge int
+
+	// StickyKey returns a key, such as a user identifier, from which the variant
+	// is derived, so that it is assigned consistently across devices. Requests
+	// with an empty key are assigned randomly. Optional.
+	StickyKey func(c *Context) string
+
+	// ResponseHeader is the response header carrying the assigned variant.
+	// Optional. Default value is "X-Variant".
+	ResponseHeader string
+}
+
+// Split returns a middleware assigning each request to a variant of an A/B
+// experiment, by header, cookie, sticky key or randomly according to the
+// weights, in that order. The assigned variant is stored in the context under
+// SplitVariantKey and sent in a response header, and its upstream is returned
+// by Context.SplitUpstream:
+//
+//	router.GET("/search", gin.Split(gin.SplitConfig{
+//	    Name: "ranking",
+//	    Variants: []gin.SplitVariant{
+//	        {Name: "control", Upstream: "search", Weight: 90},
+//	        {Name: "ranking-v2", Upstream: "search-v2", Weight: 10},
+//	    },
+//	}), func(c *gin.Context) {
+//	    req, _ := c.SplitUpstream().NewRequest(c, http.MethodGet, c.Request.URL.RequestURI(), nil)
+//	    ...
+//	})
+func Split(conf SplitConfig) HandlerFunc {
+	assert1(conf.Name != "", "SplitConfig.Name is required")
+	assert1(len(conf.Variants) > 0, "SplitConfig.Variants is required")
+	total := 0
+	for _, v := range conf.Variants {
+		assert1(v.Name != "", "SplitVariant.Name is required")
+		assert1(v.Weight >= 0, "SplitVariant.Weight cannot be negative")
+		total += v.Weight
+	}
+	assert1(total > 0, "split variants need a positive total weight")
+	if conf.Cookie == "" {
+		conf.Cookie = "split_" + conf.Name
+	}
+	if conf.CookieMaxAge == 0 {
+		conf.CookieMaxAge = defaultSplitCookieAge
+	}
+	if conf.ResponseHeader == "" {
+		conf.ResponseHeader = defaultSplitHeader
+	}
+
+	byName := func(name string) *SplitVariant {
+		for i := range conf.Variants {
+			if conf.Variants[i].Name == name {
+				return &conf.Variants[i]
+			}
+		}
+		return nil
+	}
+	byWeight := func(n int) *SplitVariant {
+		for i := range conf.Variants {
+			if n < conf.Variants[i].Weight {
+				return &conf.Variants[i]
+			}
+			n -= conf.Variants[i].Weight
+		}
+		return &conf.Variants[len(conf.Variants)-1]
+	}
+
+	return func(c *Context) {
+		var v *SplitVariant
+		if conf.Header != "" {
+			v = byName(c.requestHeader(conf.Header))
+		}
+		if v == nil && conf.CookieMaxAge > 0 {
+			if name, err := c.Cookie(conf.Cookie); err == nil {
+				v = byName(name)
+			}
+		}
+		if v == nil {
+			var key string
+			if conf.StickyKey != nil {
+				key = conf.StickyKey(c)
+			}
+			if key != "" {
+				h := fnv.New32a()
+				h.Write([]byte(conf.Name + "\x00" + key)) //nolint: errcheck
+				v = byWeight(int(h.Sum32() % uint32(total)))
+			} else {
+				v = byWeight(rand.Intn(total)) //nolint: gosec
+			}
+		}
+
+		if conf.CookieMaxAge > 0 {
+			c.SetCookie(conf.Cookie, v.Name, conf.CookieMaxAge, "/", "", c.Request.TLS != nil, true)
+		}
+		c.Header(conf.ResponseHeader, v.Name)
+		c.Set(SplitVariantKey, v.Name)
+		c.Set(splitUpstreamKey, v.Upstream)
+		c.Next()
+	}
+}
+
+// SplitUpstream returns the upstream of the variant assigned by Split, or nil.
+func (c *Context) SplitUpstream() *Upstream {
+	name := c.GetString(splitUpstreamKey)
+	if name == "" || c.engine == nil {
+		return nil
+	}
+	return c.engine.Upstream(name)
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplit(t *testing.T) {
+	router := New()
+	_, err := router.AddUpstream("search", UpstreamConfig{URL: "http://search.internal"})
+	require.NoError(t, err)
+	_, err = router.AddUpstream("search-v2", UpstreamConfig{URL: "http://search-v2.internal"})
+	require.NoError(t, err)
+
+	var logs bytes.Buffer
+	router.Use(LoggerWithConfig(LoggerConfig{
+		Output: &logs,
+		Formatter: func(params LogFormatterParams) string {
+			return params.Keys[SplitVariantKey].(string) + "\n"
+		},
+	}))
+	router.GET("/search", Split(SplitConfig{
+		Name:   "ranking",
+		Header: "X-Force-Variant",
+		Variants: []SplitVariant{
+			{Name: "control", Upstream: "search", Weight: 50},
+			{Name: "v2", Upstream: "search-v2", Weight: 50},
+			{Name: "preview", Upstream: "search-v2"},
+		},
+		StickyKey: func(c *Context) string { return c.Query("user") },
+	}), func(c *Context) {
+		c.String(http.StatusOK, c.SplitUpstream().URL.Host)
+	})
+
+	// random assignment, sticky through the cookie
+	counts := map[string]int{}
+	for i := 0; i < 200; i++ {
+		w := PerformRequest(router, http.MethodGet, "/search")
+		variant := w.Header().Get("X-Variant")
+		counts[variant]++
+		assert.Contains(t, w.Header().Get("Set-Cookie"), "split_ranking="+variant)
+		assert.Contains(t, w.Header().Get("Set-Cookie"), "HttpOnly")
+
+		w = PerformRequest(router, http.MethodGet, "/search", header{"Cookie", "split_ranking=" + variant})
+		assert.Equal(t, variant, w.Header().Get("X-Variant"))
+	}
+	assert.Greater(t, counts["control"], 50)
+	assert.Greater(t, counts["v2"], 50)
+	assert.Zero(t, counts["preview"])
+	assert.Equal(t, 400, strings.Count(logs.String(), "\n"))
+
+	// the header forces a variant, even of weight 0; unknown names are ignored
+	w := PerformRequest(router, http.MethodGet, "/search", header{"X-Force-Variant", "preview"},
+		header{"Cookie", "split_ranking=control"})
+	assert.Equal(t, "preview", w.Header().Get("X-Variant"))
+	assert.Equal(t, "search-v2.internal", w.Body.String())
+	w = PerformRequest(router, http.MethodGet, "/search", header{"X-Force-Variant", "unknown"},
+		header{"Cookie", "split_ranking=control"})
+	assert.Equal(t, "control", w.Header().Get("X-Variant"))
+	assert.Equal(t, "search.internal", w.Body.String())
+
+	// sticky keys always get the same variant
+	for i := 0; i < 20; i++ {
+		user := "/search?user=" + strconv.Itoa(i)
+		first := PerformRequest(router, http.MethodGet, user).Header().Get("X-Variant")
+		for j := 0; j < 3; j++ {
+			assert.Equal(t, first, PerformRequest(router, http.MethodGet, user).Header().Get("X-Variant"))
+		}
+	}
+}
+
+func TestSplitConfig(t *testing.T) {
+	router := New()
+	router.GET("/", Split(SplitConfig{
+		Name:           "exp",
+		Variants:       []SplitVariant{{Name: "only", Upstream: "missing", Weight: 1}},
+		CookieMaxAge:   -1,
+		ResponseHeader: "X-Exp",
+	}), func(c *Context) {
+		assert.Nil(t, c.SplitUpstream())
+		c.String(http.StatusOK, c.GetString(SplitVariantKey))
+	})
+	w := PerformRequest(router, http.MethodGet, "/")
+	assert.Equal(t, "only", w.Body.String())
+	assert.Equal(t, "only", w.Header().Get("X-Exp"))
+	assert.Empty(t, w.Header().Get("Set-Cookie"))
+
+	c, _ := CreateTestContext(nil)
+	assert.Nil(t, c.SplitUpstream())
+
+	assert.Panics(t, func() { Split(SplitConfig{Variants: []SplitVariant{{Name: "a", Weight: 1}}}) })
+	assert.Panics(t, func() { Split(SplitConfig{Name: "exp"}) })
+	assert.Panics(t, func() { Split(SplitConfig{Name: "exp", Variants: []SplitVariant{{Name: "a"}}}) })
+	assert.Panics(t, func() { Split(SplitConfig{Name: "exp", Variants: []SplitVariant{{Weight: 1}}}) })
+	assert.Panics(t, func() { Split(SplitConfig{Name: "exp", Variants: []SplitVariant{{Name: "a", Weight: -1}}}) })
+}
@@ -0,0 +1,152 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-contrib/sse"
+)
+
+// ErrSSEClosed is returned by the writes to a closed SSEWriter.
+var ErrSSEClosed = errors.New("sse: stream closed")
+
+// SSEWriter writes Server-Sent Events to the client, see Context.SSE. Its
+// methods are safe for concurrent use, but it must not be used once the
+// handler returned.
+type SSEWriter struct {
+	c    *Context
+	done <-chan struct{}
+
+	mu        sync.Mutex
+	closed    bool
+	heartbeat ClockTimer
+}
+
+// SSE starts a Server-Sent Events stream: it sends the headers of the stream
+// and returns a writer of its events, flushed as they are sent. The stream
+// ends when the handler returns, after closing the writer:
+//
+//	router.GET("/events", func(c *gin.Context) {
+//	    stream := c.SSE()
+//	    defer stream.Close()
+//	    stream.Heartbeat(15 * time.Second)
+//	    for msg := range messages(c.Request.Context(), stream.LastEventID()) {
+//	        if err := stream.Send(msg.ID, "message", msg); err != nil {
+//	            return // the client is gone
+//	        }
+//	    }
+//	})
+func (c *Context) SSE() *SSEWriter {
+	header := c.Writer.Header()
+	header.Set("Content-Type", sse.ContentType)
+	header.Set("Cache-Control", "no-cache")
+	// proxies such as nginx must not buffer the events
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+	return &SSEWriter{c: c, done: c.Request.Context().Done()}
+}
+
+// LastEventID returns the ID of the last event received by the client before
+// it reconnected, from the Last-Event-ID request header, to resume the stream.
+func (s *SSEWriter) LastEventID() string {
+	return s.c.requestHeader("Last-Event-ID")
+}
+
+// Done returns a channel closed when the client disconnects.
+func (s *SSEWriter) Done() <-chan struct{} {
+	return s.done
+}
+
+// Send sends an event with an optional ID and name. The structs, slices and
+// maps are sent as JSON, the other values formatted with fmt.Sprint. It
+// returns the error of the request context once the client disconnected.
+func (s *SSEWriter) Send(id, event string, data any) error {
+	var buf bytes.Buffer
+	if err := sse.Encode(&buf, sse.Event{Id: id, Event: event, Data: data}); err != nil {
+		return err
+	}
+	return s.write(buf.Bytes())
+}
+
+// Retry tells the client to wait d before reconnecting once the stream ends.
+func (s *SSEWriter) Retry(d time.Duration) error {
+	return s.write([]byte("retry:" + strconv.FormatInt(d.Milliseconds(), 10) + "\n\n"))
+}
+
+// Comment sends a comment, ignored by the clients.
+func (s *SSEWriter) Comment(text string) error {
+	return s.write([]byte(": " + strings.ReplaceAll(text, "\n", "\n: ") + "\n\n"))
+}
+
+// Heartbeat sends a comment every interval of the engine clock while the
+// stream is open, to keep the connections idle behind proxies open, until
+// the writer is closed. A non-positive interval stops the heartbeats.
+func (s *SSEWriter) Heartbeat(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.heartbeat != nil {
+		s.heartbeat.Stop()
+		s.heartbeat = nil
+	}
+	if interval <= 0 || s.closed {
+		return
+	}
+	var clock Clock = systemClock{}
+	if s.c.engine != nil {
+		clock = s.c.engine.clock()
+	}
+	var beat func()
+	beat = func() {
+		if s.Comment("heartbeat") != nil {
+			return
+		}
+		s.mu.Lock()
+		if !s.closed {
+			s.heartbeat = clock.AfterFunc(interval, beat)
+		}
+		s.mu.Unlock()
+	}
+	s.heartbeat = clock.AfterFunc(interval, beat)
+}
+
+// Close stops the heartbeats and the writes to the stream. It must be called
+// before the handler returns.
+func (s *SSEWriter) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.heartbeat != nil {
+		s.heartbeat.Stop()
+		s.heartbeat = nil
+	}
+}
+
+// write writes and flushes the data unless the stream is closed or the client
+// disconnected.
+func (s *SSEWriter) write(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrSSEClosed
+	}
+	select {
+	case <-s.done:
+		return s.c.Request.Context().Err()
+	default:
+	}
+	if _, err := s.c.Writer.Write(data); err != nil {
+		return err
+	}
+	s.c.Writer.Flush()
+	return nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextSSE(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	router := New()
+	router.Clock = clock
+	var lastID string
+	var errs []error
+	router.GET("/events", func(c *Context) {
+		stream := c.SSE()
+		lastID = stream.LastEventID()
+		stream.Heartbeat(10 * time.Second)
+		errs = append(errs,
+			stream.Retry(3*time.Second),
+			stream.Send("1", "greeting", "hello\nworld"),
+			stream.Send("", "", H{"n": 2}),
+		)
+		clock.Advance(25 * time.Second)
+		errs = append(errs, stream.Comment("bye"))
+		stream.Close()
+		clock.Advance(time.Minute)
+		errs = append(errs, stream.Send("3", "", "late"))
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/events", header{"Last-Event-ID", "7"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, w.Flushed)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+	assert.Equal(t, "no", w.Header().Get("X-Accel-Buffering"))
+	assert.Equal(t, "7", lastID)
+	assert.Equal(t, []error{nil, nil, nil, nil, ErrSSEClosed}, errs)
+	assert.Equal(t, "retry:3000\n\n"+
+		"id:1\nevent:greeting\ndata:hello\ndata:world\n\n"+
+		"data:{\"n\":2}\n\n"+
+		": heartbeat\n\n: heartbeat\n\n"+
+		": bye\n\n", w.Body.String())
+}
+
+func TestContextSSEClientGone(t *testing.T) {
+	router := New()
+	var err error
+	var done bool
+	router.GET("/events", func(c *Context) {
+		stream := c.SSE()
+		defer stream.Close()
+		<-stream.Done()
+		done = true
+		err = stream.Send("", "", "lost")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.True(t, done)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, w.Body.String())
+}
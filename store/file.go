@@ -0,0 +1,167 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// staleLockAge is the age after which a lock file left by a crashed process is removed.
+const staleLockAge = 10 * time.Second
+
+// File is a Store keeping one file per key in a directory. Several processes may
+// share the same directory: writes are atomic (temp file + rename) and Incr is
+// serialized with lock files.
+type File struct {
+	dir string
+	mu  sync.Mutex
+	now func() time.Time
+}
+
+var _ Store = (*File)(nil)
+
+// NewFile returns a Store persisting keys into dir, which is created if needed.
+func NewFile(dir string) (*File, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	return &File{dir: dir, now: time.Now}, nil
+}
+
+func (f *File) path(key string) string {
+	return filepath.Join(f.dir, hex.EncodeToString([]byte(key)))
+}
+
+// Get implements Store.
+func (f *File) Get(_ context.Context, key string) ([]byte, error) {
+	value, _, err := f.read(f.path(key))
+	return value, err
+}
+
+// Set implements Store.
+func (f *File) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	return f.write(f.path(key), value, expiration(f.now(), ttl))
+}
+
+// Delete implements Store.
+func (f *File) Delete(_ context.Context, key string) error {
+	if err := os.Remove(f.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Incr implements Store.
+func (f *File) Incr(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	p := f.path(key)
+	unlock, err := f.lock(ctx, p)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	var n int64
+	value, expireAt, err := f.read(p)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		expireAt = expiration(f.now(), ttl)
+	case err != nil:
+		return 0, err
+	default:
+		if n, err = strconv.ParseInt(string(value), 10, 64); err != nil {
+			return 0, err
+		}
+	}
+	n += delta
+	return n, f.write(p, strconv.AppendInt(nil, n, 10), expireAt)
+}
+
+func (f *File) read(p string) ([]byte, time.Time, error) {
+	data, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, time.Time{}, ErrNotFound
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	header, value, ok := bytes.Cut(data, []byte{'\n'})
+	if !ok {
+		return nil, time.Time{}, errors.New("store: corrupted entry " + p)
+	}
+	nsec, err := strconv.ParseInt(string(header), 10, 64)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var expireAt time.Time
+	if nsec != 0 {
+		expireAt = time.Unix(0, nsec)
+		if !f.now().Before(expireAt) {
+			return nil, time.Time{}, ErrNotFound
+		}
+	}
+	return value, expireAt, nil
+}
+
+func (f *File) write(p string, value []byte, expireAt time.Time) error {
+	var nsec int64
+	if !expireAt.IsZero() {
+		nsec = expireAt.UnixNano()
+	}
+	data := strconv.AppendInt(nil, nsec, 10)
+	data = append(data, '\n')
+	data = append(data, value...)
+
+	tmp, err := os.CreateTemp(f.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (f *File) lock(ctx context.Context, p string) (func(), error) {
+	f.mu.Lock()
+	lockPath := p + ".lock"
+	for {
+		lf, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			lf.Close()
+			return func() {
+				os.Remove(lockPath)
+				f.mu.Unlock()
+			}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			f.mu.Unlock()
+			return nil, err
+		}
+		if fi, statErr := os.Stat(lockPath); statErr == nil && time.Since(fi.ModTime()) > staleLockAge {
+			os.Remove(lockPath)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			f.mu.Unlock()
+			return nil, ctx.Err()
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
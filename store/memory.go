@@ -0,0 +1,110 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package store
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// sweepEvery is the number of writes after which expired keys are evicted.
+const sweepEvery = 1024
+
+type memoryItem struct {
+	value    []byte
+	expireAt time.Time
+}
+
+func (i memoryItem) expired(now time.Time) bool {
+	return !i.expireAt.IsZero() && !now.Before(i.expireAt)
+}
+
+// Memory is an in-process Store. It is safe for concurrent use and can be shared
+// by several engines living in the same process.
+type Memory struct {
+	mu     sync.Mutex
+	items  map[string]memoryItem
+	writes int
+	now    func() time.Time
+}
+
+var _ Store = (*Memory)(nil)
+
+// NewMemory returns an empty in-memory Store.
+func NewMemory() *Memory {
+	return &Memory{items: make(map[string]memoryItem), now: time.Now}
+}
+
+// Get implements Store.
+func (m *Memory) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.items[key]
+	if !ok || item.expired(m.now()) {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), item.value...), nil
+}
+
+// Set implements Store.
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	m.items[key] = memoryItem{value: append([]byte(nil), value...), expireAt: expiration(now, ttl)}
+	m.wrote(now)
+	return nil
+}
+
+// Delete implements Store.
+func (m *Memory) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.items, key)
+	return nil
+}
+
+// Incr implements Store.
+func (m *Memory) Incr(_ context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	item, ok := m.items[key]
+	var n int64
+	if ok && !item.expired(now) {
+		var err error
+		if n, err = strconv.ParseInt(string(item.value), 10, 64); err != nil {
+			return 0, err
+		}
+	} else {
+		item = memoryItem{expireAt: expiration(now, ttl)}
+	}
+	n += delta
+	item.value = strconv.AppendInt(item.value[:0], n, 10)
+	m.items[key] = item
+	m.wrote(now)
+	return n, nil
+}
+
+// Len returns the number of keys currently held, including expired keys not yet evicted.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.items)
+}
+
+func (m *Memory) wrote(now time.Time) {
+	if m.writes++; m.writes < sweepEvery {
+		return
+	}
+	m.writes = 0
+	for k, item := range m.items {
+		if item.expired(now) {
+			delete(m.items, k)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package store
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// incrScript increments a key and sets its ttl only when the key is created.
+const incrScript = `local created = redis.call('EXISTS', KEYS[1]) == 0
+local v = redis.call('INCRBY', KEYS[1], ARGV[1])
+if created and tonumber(ARGV[2]) > 0 then redis.call('PEXPIRE', KEYS[1], ARGV[2]) end
+return v`
+
+// RedisConfig configures a Redis Store.
+type RedisConfig struct {
+	// Addr is the host:port of the Redis server.
+	Addr string
+	// Password is sent with AUTH when not empty.
+	Password string
+	// DB is selected with SELECT when not zero.
+	DB int
+	// DialTimeout bounds the connection establishment. Default 5s.
+	DialTimeout time.Duration
+	// PoolSize is the maximum number of idle connections kept. Default 8.
+	PoolSize int
+}
+
+// Redis is a Store backed by a Redis server, suitable for sharing state between
+// processes. It speaks RESP directly and has no external dependency.
+type Redis struct {
+	conf RedisConfig
+	pool chan *redisConn
+}
+
+var _ Store = (*Redis)(nil)
+
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// redisError is an error reply sent by the server.
+type redisError string
+
+func (e redisError) Error() string { return "store: redis: " + string(e) }
+
+// NewRedis returns a Redis Store. Connections are established lazily.
+func NewRedis(conf RedisConfig) *Redis {
+	if conf.DialTimeout <= 0 {
+		conf.DialTimeout = 5 * time.Second
+	}
+	if conf.PoolSize <= 0 {
+		conf.PoolSize = 8
+	}
+	return &Redis{conf: conf, pool: make(chan *redisConn, conf.PoolSize)}
+}
+
+// Get implements Store.
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, error) {
+	reply, err := r.do(ctx, "GET", key)
+	if err != nil {
+		return nil, err
+	}
+	if reply == nil {
+		return nil, ErrNotFound
+	}
+	return reply.([]byte), nil
+}
+
+// Set implements Store.
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	_, err := r.do(ctx, args...)
+	return err
+}
+
+// Delete implements Store.
+func (r *Redis) Delete(ctx context.Context, key string) error {
+	_, err := r.do(ctx, "DEL", key)
+	return err
+}
+
+// Incr implements Store.
+func (r *Redis) Incr(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	var ms int64
+	if ttl > 0 {
+		ms = ttl.Milliseconds()
+	}
+	reply, err := r.do(ctx, "EVAL", incrScript, "1", key,
+		strconv.FormatInt(delta, 10), strconv.FormatInt(ms, 10))
+	if err != nil {
+		return 0, err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("store: redis: unexpected reply %v", reply)
+	}
+	return n, nil
+}
+
+// Close closes the idle connections of the pool.
+func (r *Redis) Close() error {
+	for {
+		select {
+		case c := <-r.pool:
+			c.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (r *Redis) do(ctx context.Context, args ...string) (any, error) {
+	c, err := r.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		c.SetDeadline(deadline) //nolint: errcheck
+	} else {
+		c.SetDeadline(time.Time{}) //nolint: errcheck
+	}
+	reply, err := c.roundTrip(args)
+	var rerr redisError
+	if err != nil && !errors.As(err, &rerr) {
+		c.Close()
+		return nil, err
+	}
+	r.put(c)
+	return reply, err
+}
+
+func (r *Redis) get(ctx context.Context) (*redisConn, error) {
+	select {
+	case c := <-r.pool:
+		return c, nil
+	default:
+	}
+	d := net.Dialer{Timeout: r.conf.DialTimeout}
+	nc, err := d.DialContext(ctx, "tcp", r.conf.Addr)
+	if err != nil {
+		return nil, err
+	}
+	c := &redisConn{Conn: nc, r: bufio.NewReader(nc)}
+	if r.conf.Password != "" {
+		if _, err = c.roundTrip([]string{"AUTH", r.conf.Password}); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	if r.conf.DB != 0 {
+		if _, err = c.roundTrip([]string{"SELECT", strconv.Itoa(r.conf.DB)}); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (r *Redis) put(c *redisConn) {
+	select {
+	case r.pool <- c:
+	default:
+		c.Close()
+	}
+}
+
+func (c *redisConn) roundTrip(args []string) (any, error) {
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+	if _, err := c.Write(buf); err != nil {
+		return nil, err
+	}
+	return readReply(c.r)
+}
+
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.New("store: redis: malformed reply")
+	}
+	kind, body := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err = io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]any, n)
+		for i := range items {
+			if items[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("store: redis: unknown reply type %q", kind)
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package store provides the key/value contract shared by middleware that needs
+// state across engines or processes (rate limiters, sessions, caches), together
+// with in-memory, file and Redis implementations.
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned by Store.Get when the key does not exist or has expired.
+var ErrNotFound = errors.New("store: key not found")
+
+// Store is the interface implemented by every shared state backend.
+//
+// TTL semantics are the same for all implementations:
+//   - a ttl <= 0 means the key never expires;
+//   - Set always replaces both the value and the ttl of the key;
+//   - Incr applies the ttl only when it creates the key, so a counter keeps the
+//     expiration of its first increment (fixed window semantics).
+type Store interface {
+	// Get returns the value of key or ErrNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores value under key for the given ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+	// Incr atomically adds delta to the integer stored under key and returns the
+	// new value. A missing key is treated as 0.
+	Incr(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error)
+}
+
+type prefixed struct {
+	Store
+	prefix string
+}
+
+// WithPrefix returns a Store which prepends prefix to every key before delegating
+// to s. It allows several middlewares to share one backend without key clashes.
+func WithPrefix(s Store, prefix string) Store {
+	return prefixed{Store: s, prefix: prefix}
+}
+
+func (p prefixed) Get(ctx context.Context, key string) ([]byte, error) {
+	return p.Store.Get(ctx, p.prefix+key)
+}
+
+func (p prefixed) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return p.Store.Set(ctx, p.prefix+key, value, ttl)
+}
+
+func (p prefixed) Delete(ctx context.Context, key string) error {
+	return p.Store.Delete(ctx, p.prefix+key)
+}
+
+func (p prefixed) Incr(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	return p.Store.Incr(ctx, p.prefix+key, delta, ttl)
+}
+
+func expiration(now time.Time, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return now.Add(ttl)
+}
@@ -0,0 +1,212 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package store
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func testStore(t *testing.T, s Store, advance func(time.Duration)) {
+	ctx := context.Background()
+
+	_, err := s.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, s.Set(ctx, "k", []byte("v"), 0))
+	v, err := s.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v"), v)
+
+	require.NoError(t, s.Set(ctx, "ttl", []byte("v"), time.Second))
+	require.NoError(t, s.Delete(ctx, "k"))
+	require.NoError(t, s.Delete(ctx, "k"))
+	_, err = s.Get(ctx, "k")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	n, err := s.Incr(ctx, "counter", 2, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	advance(500 * time.Millisecond)
+	n, err = s.Incr(ctx, "counter", 3, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+
+	// the ttl of a counter is the one of its first increment
+	advance(600 * time.Millisecond)
+	_, err = s.Get(ctx, "ttl")
+	assert.ErrorIs(t, err, ErrNotFound)
+	n, err = s.Incr(ctx, "counter", 1, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	p := WithPrefix(s, "ns:")
+	require.NoError(t, p.Set(ctx, "k", []byte("prefixed"), 0))
+	v, err = s.Get(ctx, "ns:k")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("prefixed"), v)
+	v, err = p.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("prefixed"), v)
+	n, err = p.Incr(ctx, "c", 1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	require.NoError(t, p.Delete(ctx, "k"))
+	_, err = s.Get(ctx, "ns:k")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMemoryStore(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	s := NewMemory()
+	s.now = clock.now
+	testStore(t, s, func(d time.Duration) { clock.t = clock.t.Add(d) })
+
+	for i := 0; i < sweepEvery; i++ {
+		require.NoError(t, s.Set(context.Background(), "tmp"+strconv.Itoa(i), nil, time.Second))
+	}
+	clock.t = clock.t.Add(time.Hour)
+	for i := 0; i < sweepEvery; i++ {
+		require.NoError(t, s.Set(context.Background(), "last", nil, 0))
+	}
+	assert.LessOrEqual(t, s.Len(), 3)
+}
+
+func TestFileStore(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	s, err := NewFile(t.TempDir())
+	require.NoError(t, err)
+	s.now = clock.now
+	testStore(t, s, func(d time.Duration) { clock.t = clock.t.Add(d) })
+}
+
+func TestFileStoreConcurrentIncr(t *testing.T) {
+	dir := t.TempDir()
+	a, err := NewFile(dir)
+	require.NoError(t, err)
+	b, err := NewFile(dir)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(s Store) {
+			defer wg.Done()
+			_, err := s.Incr(context.Background(), "c", 1, 0)
+			assert.NoError(t, err)
+		}([]Store{a, b}[i%2])
+	}
+	wg.Wait()
+	v, err := a.Get(context.Background(), "c")
+	require.NoError(t, err)
+	assert.Equal(t, "20", string(v))
+}
+
+// fakeRedis implements the handful of commands used by the Redis store.
+type fakeRedis struct {
+	mu    sync.Mutex
+	mem   *Memory
+	clock *fakeClock
+	auth  string
+}
+
+func (f *fakeRedis) serve(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	ctx := context.Background()
+	for {
+		req, err := readReply(r)
+		if err != nil {
+			return
+		}
+		items := req.([]any)
+		args := make([]string, len(items))
+		for i, item := range items {
+			args[i] = string(item.([]byte))
+		}
+		f.mu.Lock()
+		var out string
+		switch args[0] {
+		case "AUTH":
+			if args[1] == f.auth {
+				out = "+OK\r\n"
+			} else {
+				out = "-WRONGPASS invalid password\r\n"
+			}
+		case "GET":
+			if v, err := f.mem.Get(ctx, args[1]); err != nil {
+				out = "$-1\r\n"
+			} else {
+				out = "$" + strconv.Itoa(len(v)) + "\r\n" + string(v) + "\r\n"
+			}
+		case "SET":
+			var ttl time.Duration
+			if len(args) == 5 {
+				ms, _ := strconv.Atoi(args[4])
+				ttl = time.Duration(ms) * time.Millisecond
+			}
+			f.mem.Set(ctx, args[1], []byte(args[2]), ttl) //nolint: errcheck
+			out = "+OK\r\n"
+		case "DEL":
+			f.mem.Delete(ctx, args[1]) //nolint: errcheck
+			out = ":1\r\n"
+		case "EVAL":
+			delta, _ := strconv.ParseInt(args[4], 10, 64)
+			ms, _ := strconv.Atoi(args[5])
+			n, _ := f.mem.Incr(ctx, args[3], delta, time.Duration(ms)*time.Millisecond)
+			out = ":" + strconv.FormatInt(n, 10) + "\r\n"
+		default:
+			out = "-ERR unknown command\r\n"
+		}
+		f.mu.Unlock()
+		if _, err = conn.Write([]byte(out)); err != nil {
+			return
+		}
+	}
+}
+
+func TestRedisStore(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	fake := &fakeRedis{mem: NewMemory(), clock: clock, auth: "secret"}
+	fake.mem.now = clock.now
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	go fake.serve(ln)
+
+	s := NewRedis(RedisConfig{Addr: ln.Addr().String(), Password: "secret"})
+	defer s.Close()
+	testStore(t, s, func(d time.Duration) {
+		fake.mu.Lock()
+		clock.t = clock.t.Add(d)
+		fake.mu.Unlock()
+	})
+
+	bad := NewRedis(RedisConfig{Addr: ln.Addr().String(), Password: "wrong"})
+	_, err = bad.Get(context.Background(), "k")
+	assert.EqualError(t, err, "store: redis: WRONGPASS invalid password")
+}
@@ -0,0 +1,131 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// swapHistory is the number of previous generations kept for Engine.Rollback.
+const swapHistory = 8
+
+var (
+	// ErrNoRollback is returned by Engine.Rollback when there is no previous
+	// generation to restore.
+	ErrNoRollback = errors.New("swap: no previous generation")
+
+	// ErrNotSwappable is returned by Engine.SwapInto when the handler of the
+	// server is not an engine.
+	ErrNotSwappable = errors.New("swap: server handler is not an engine")
+)
+
+// GenerationStats is a snapshot of the generations of the routes served by an
+// engine, see Engine.Swap.
+type GenerationStats struct {
+	// Generation is the number of the generation serving the requests: 0 for
+	// the routes of the engine until the first swap, then incremented by each
+	// swap. A rollback restores the number of the previous generation.
+	Generation uint64
+	// Since is when the serving generation was installed, zero for the
+	// generation 0.
+	Since time.Time
+	// Swaps and Rollbacks count the calls to Swap and Rollback.
+	Swaps     uint64
+	Rollbacks uint64
+	// History is the number of previous generations Rollback can restore.
+	History int
+}
+
+// generation is a generation of the routes served by an engine.
+type generation struct {
+	engine *Engine // nil for the engine itself
+	number uint64
+	since  time.Time
+}
+
+// generations is the history of the swaps of an engine.
+type generations struct {
+	mu        sync.Mutex
+	current   generation
+	previous  []generation
+	last      uint64
+	swaps     uint64
+	rollbacks uint64
+}
+
+// Swap makes the engine serve the requests with next, typically a clone
+// updated with Clone, without restarting the server nor dropping its
+// connections. The swap happens between requests: the requests in flight
+// complete with the previous generation, which Rollback restores. It must be
+// called on the engine given to the server, whose server settings, such as
+// UseH2C or HTTPHardening, keep applying. Swapping to the engine itself or nil
+// serves its own routes again.
+func (engine *Engine) Swap(next *Engine) {
+	if next == engine {
+		next = nil
+	}
+	g := &engine.generations
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.previous = append(g.previous, g.current)
+	if len(g.previous) > swapHistory {
+		g.previous = g.previous[len(g.previous)-swapHistory:]
+	}
+	g.last++
+	g.swaps++
+	g.current = generation{engine: next, number: g.last, since: time.Now()}
+	engine.swapped.Store(next)
+}
+
+// Rollback restores the generation served before the latest Swap, such as
+// after a reload whose new routes misbehave. The last 8 generations are kept.
+// It returns ErrNoRollback when there is none.
+func (engine *Engine) Rollback() error {
+	g := &engine.generations
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.previous) == 0 {
+		return ErrNoRollback
+	}
+	g.current = g.previous[len(g.previous)-1]
+	g.previous = g.previous[:len(g.previous)-1]
+	g.rollbacks++
+	engine.swapped.Store(g.current.engine)
+	return nil
+}
+
+// SwapInto makes the engine serve the requests of server, whose handler is
+// the engine it was started with, see Swap:
+//
+//	next := buildRoutes(conf)
+//	if err := next.Validate(); err == nil {
+//	    next.SwapInto(server)
+//	}
+func (engine *Engine) SwapInto(server *http.Server) error {
+	serving, ok := server.Handler.(*Engine)
+	if !ok {
+		return ErrNotSwappable
+	}
+	serving.Swap(engine)
+	return nil
+}
+
+// Generations returns a snapshot of the generations of the routes served by
+// the engine.
+func (engine *Engine) Generations() GenerationStats {
+	g := &engine.generations
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return GenerationStats{
+		Generation: g.current.number,
+		Since:      g.current.since,
+		Swaps:      g.swaps,
+		Rollbacks:  g.rollbacks,
+		History:    len(g.previous),
+	}
+}
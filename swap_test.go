@@ -0,0 +1,62 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngineSwap(t *testing.T) {
+	router := New()
+	router.GET("/version", func(c *Context) { c.String(http.StatusOK, "v0") })
+	get := func(path string) *httptest.ResponseRecorder {
+		return PerformRequest(router, http.MethodGet, path)
+	}
+	assert.Equal(t, GenerationStats{}, router.Generations())
+	assert.ErrorIs(t, router.Rollback(), ErrNoRollback)
+
+	v1 := router.Clone()
+	v1.GET("/ping", handlerTest1)
+	router.Swap(v1)
+	assert.Equal(t, http.StatusOK, get("/ping").Code)
+	assert.Equal(t, "v0", get("/version").Body.String())
+
+	v2 := New()
+	v2.GET("/version", func(c *Context) { c.String(http.StatusOK, "v2") })
+	server := &http.Server{Handler: router}
+	assert.NoError(t, v2.SwapInto(server))
+	assert.Equal(t, "v2", get("/version").Body.String())
+	assert.Equal(t, http.StatusNotFound, get("/ping").Code)
+	stats := router.Generations()
+	assert.Equal(t, uint64(2), stats.Generation)
+	assert.Equal(t, uint64(2), stats.Swaps)
+	assert.Equal(t, 2, stats.History)
+	assert.False(t, stats.Since.IsZero())
+
+	assert.NoError(t, router.Rollback())
+	assert.Equal(t, http.StatusOK, get("/ping").Code)
+	stats = router.Generations()
+	assert.Equal(t, uint64(1), stats.Generation)
+	assert.Equal(t, uint64(1), stats.Rollbacks)
+
+	router.Swap(router)
+	assert.Equal(t, http.StatusNotFound, get("/ping").Code)
+	assert.Equal(t, uint64(3), router.Generations().Generation)
+	assert.NoError(t, router.Rollback())
+	assert.NoError(t, router.Rollback())
+	assert.Equal(t, http.StatusNotFound, get("/ping").Code)
+	assert.Equal(t, uint64(0), router.Generations().Generation)
+
+	for i := 0; i < 2*swapHistory; i++ {
+		router.Swap(v1)
+	}
+	assert.Equal(t, swapHistory, router.Generations().History)
+
+	assert.ErrorIs(t, v2.SwapInto(&http.Server{Handler: http.NotFoundHandler()}), ErrNotSwappable)
+}
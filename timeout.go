@@ -0,0 +1,177 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+var default504Body = []byte("504 Gateway Timeout")
+
+// errRouteTimeout is the cause of the cancellation of the context of a request
+// exceeding the timeout of its route.
+var errRouteTimeout = errors.New("gin: route timeout exceeded")
+
+// errRequestDeadline is the cause of the cancellation of the context of a
+// request exceeding the deadline set by SetDeadline.
+var errRequestDeadline = errors.New("gin: request deadline exceeded")
+
+// Timeout bounds the handling of the routes:
+//
+//	router.GET("/slow", report)
+//	router.Route("/slow").Timeout(2 * time.Second)
+//
+// The request context is canceled once d elapses, and the writer is guarded:
+// if no response was written by then, the client gets 504 Gateway Timeout, and
+// the writes of the handlers fail with http.ErrHandlerTimeout. The handlers run
+// on the goroutine of the request, so they must return once the request
+// context is done for the 504 to be sent in time. Unlike RouteConfig.Timeout,
+// which only cancels the context, a response is always sent.
+func (h *RouteHandle) Timeout(d time.Duration) *RouteHandle {
+	assert1(d > 0, "route timeout must be positive")
+	engine := h.engine
+	return h.annotate(func(route *Route) {
+		if route.timeout == 0 {
+			engine.timedRoutes++
+		}
+		route.timeout = d
+	})
+}
+
+// startTimeout guards the writer of a request matching a route with a timeout,
+// and returns the guard, or nil if the route has no timeout.
+func (engine *Engine) startTimeout(c *Context, httpMethod string) *timeoutWriter {
+	route := engine.table.Load().routeIndex[routeKey(c.routeHost, httpMethod, c.fullPath)]
+	if route == nil || route.timeout == 0 {
+		return nil
+	}
+	return c.guardTimeout(route.timeout, errRouteTimeout)
+}
+
+// SetDeadline bounds the handling of the request by deadline, as the
+// timeout of a route: once it passes, the request context is canceled, with
+// Done and Err firing even without Engine.ContextWithFallback, and the writer
+// is guarded, answering 504 Gateway Timeout if no response was written and
+// failing the writes with http.ErrHandlerTimeout. A middleware can propagate
+// the deadline of the client:
+//
+//	router.Use(func(c *gin.Context) {
+//	    if ms, err := strconv.Atoi(c.GetHeader("X-Timeout-Ms")); err == nil {
+//	        c.SetDeadline(time.Now().Add(time.Duration(ms) * time.Millisecond))
+//	    }
+//	})
+//
+// A deadline later than the current one of the request has no effect.
+func (c *Context) SetDeadline(deadline time.Time) {
+	if current, ok := c.Request.Context().Deadline(); ok && !deadline.Before(current) {
+		return
+	}
+	c.deadlines = append(c.deadlines, c.guardTimeout(deadline.Sub(c.now()), errRequestDeadline))
+}
+
+// guardTimeout cancels the request context with cause once d elapses, and
+// guards the writer, returning the guard.
+func (c *Context) guardTimeout(d time.Duration, cause error) *timeoutWriter {
+	ctx, cancel := c.engine.withTimeout(c.Request.Context(), d, cause)
+	c.Request = c.Request.WithContext(ctx)
+	c.deadlined = true
+	w := &timeoutWriter{ResponseWriter: c.Writer, ctx: ctx, cancel: cancel, cause: cause}
+	c.Writer = w
+	return w
+}
+
+// finishDeadlines finishes the guards of the deadlines set by SetDeadline.
+func (c *Context) finishDeadlines() {
+	for i := len(c.deadlines) - 1; i >= 0; i-- {
+		c.deadlines[i].finish(c)
+	}
+	c.deadlines = c.deadlines[:0]
+}
+
+// timeoutWriter refuses the writes once the timeout of the route or the
+// deadline of the request elapsed, answering 504 if the response was not
+// written yet.
+type timeoutWriter struct {
+	ResponseWriter
+	ctx      context.Context
+	cancel   context.CancelFunc
+	cause    error
+	timedOut bool
+}
+
+// expired reports whether the timeout elapsed, answering 504 the first time
+// if no response was written.
+func (w *timeoutWriter) expired() bool {
+	if w.timedOut {
+		return true
+	}
+	if w.ctx.Err() == nil || context.Cause(w.ctx) != w.cause { //nolint: errorlint
+		return false
+	}
+	w.timedOut = true
+	if !w.ResponseWriter.Written() {
+		header := w.ResponseWriter.Header()
+		header.Del("Content-Length")
+		header["Content-Type"] = mimePlain
+		w.ResponseWriter.WriteHeader(http.StatusGatewayTimeout)
+		if _, err := w.ResponseWriter.Write(default504Body); err != nil {
+			debugPrint("cannot write message to writer during route timeout: %v", err)
+		}
+	}
+	return true
+}
+
+// finish answers 504 if the handlers returned past the timeout without writing
+// a response, and releases the timer.
+func (w *timeoutWriter) finish(c *Context) {
+	if !c.hijacked {
+		w.expired()
+	}
+	w.cancel()
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	if !w.expired() {
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	if !w.expired() {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	if w.expired() {
+		return 0, http.ErrHandlerTimeout
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	if w.expired() {
+		return 0, http.ErrHandlerTimeout
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *timeoutWriter) Flush() {
+	if !w.expired() {
+		w.ResponseWriter.Flush()
+	}
+}
+
+func (w *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if w.expired() {
+		return nil, nil, http.ErrHandlerTimeout
+	}
+	return w.ResponseWriter.Hijack()
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteTimeout(t *testing.T) {
+	router := New()
+	var writeErr error
+	router.GET("/slow", func(c *Context) {
+		<-c.Request.Context().Done()
+		c.Header("Content-Type", MIMEJSON)
+		_, writeErr = c.Writer.WriteString("late")
+	})
+	router.Route("/slow", http.MethodGet).Timeout(10 * time.Millisecond)
+	router.GET("/silent", func(c *Context) {
+		c.Status(http.StatusCreated)
+		<-c.Request.Context().Done()
+	})
+	router.Route("/silent", http.MethodGet).Timeout(10 * time.Millisecond)
+	router.GET("/fast", func(c *Context) {
+		deadline, ok := c.Request.Context().Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+		c.String(http.StatusOK, "fast")
+	})
+	router.Route("/fast", http.MethodGet).Timeout(time.Second)
+	router.GET("/free", func(c *Context) {
+		_, ok := c.Request.Context().Deadline()
+		assert.False(t, ok)
+		c.String(http.StatusOK, "free")
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/slow")
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.Equal(t, "504 Gateway Timeout", w.Body.String())
+	assert.Equal(t, MIMEPlain, w.Header().Get("Content-Type"))
+	assert.ErrorIs(t, writeErr, http.ErrHandlerTimeout)
+
+	w = PerformRequest(router, http.MethodGet, "/silent")
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+
+	w = PerformRequest(router, http.MethodGet, "/fast")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "fast", w.Body.String())
+
+	w = PerformRequest(router, http.MethodGet, "/free")
+	assert.Equal(t, "free", w.Body.String())
+}
+
+func TestRouteTimeoutWhileServing(t *testing.T) {
+	router := New()
+	router.GET("/status", handlerTest1)
+	assert.Equal(t, http.StatusOK, PerformRequest(router, http.MethodGet, "/status").Code)
+
+	// the timeout applies once the route is annotated, even while serving
+	router.GET("/export", func(c *Context) {
+		<-c.Request.Context().Done()
+	})
+	router.Route("/export", http.MethodGet).Timeout(10 * time.Millisecond)
+	assert.Equal(t, http.StatusGatewayTimeout, PerformRequest(router, http.MethodGet, "/export").Code)
+
+	clone := router.Clone()
+	assert.Equal(t, http.StatusGatewayTimeout, PerformRequest(clone, http.MethodGet, "/export").Code)
+
+	router.GET("/zero", handlerTest1)
+	assert.PanicsWithValue(t, "route timeout must be positive", func() {
+		router.Route("/zero", http.MethodGet).Timeout(0)
+	})
+}
+
+func TestSetDeadline(t *testing.T) {
+	clock := NewFakeClock(time.Unix(1700000000, 0))
+	router := New()
+	router.Clock = clock
+	router.Use(func(c *Context) {
+		if ms, err := strconv.Atoi(c.GetHeader("X-Timeout-Ms")); err == nil {
+			c.SetDeadline(clock.Now().Add(time.Duration(ms) * time.Millisecond))
+		}
+	})
+	var writeErr error
+	router.GET("/slow", func(c *Context) {
+		assert.NoError(t, c.Err())
+		c.SetDeadline(clock.Now().Add(time.Hour))
+		deadline, ok := c.Deadline()
+		assert.True(t, ok)
+		assert.Equal(t, clock.Now().Add(time.Second), deadline)
+
+		clock.Advance(time.Second)
+		<-c.Done()
+		require.ErrorIs(t, c.Err(), context.DeadlineExceeded)
+		assert.Equal(t, errRequestDeadline, context.Cause(c.Request.Context()))
+		_, writeErr = c.Writer.WriteString("late")
+	})
+	router.GET("/silent", func(c *Context) {
+		c.SetDeadline(clock.Now().Add(time.Millisecond))
+		clock.Advance(time.Millisecond)
+	})
+	router.GET("/fast", func(c *Context) {
+		c.String(http.StatusOK, "fast")
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/slow", header{"X-Timeout-Ms", "1000"})
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.Equal(t, "504 Gateway Timeout", w.Body.String())
+	require.ErrorIs(t, writeErr, http.ErrHandlerTimeout)
+
+	w = PerformRequest(router, http.MethodGet, "/silent", header{"X-Timeout-Ms", "1000"})
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+
+	w = PerformRequest(router, http.MethodGet, "/fast", header{"X-Timeout-Ms", "1000"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "fast", w.Body.String())
+	clock.Advance(time.Hour)
+}
+
+func TestContextDoneWithTimeout(t *testing.T) {
+	router := New()
+	router.GET("/timeout", func(c *Context) {
+		_, ok := c.Deadline()
+		assert.True(t, ok)
+		<-c.Done()
+		require.ErrorIs(t, c.Err(), context.DeadlineExceeded)
+	})
+	router.Route("/timeout", http.MethodGet).Timeout(time.Millisecond)
+	router.GET("/configured", func(c *Context) {
+		<-c.Done()
+		require.ErrorIs(t, c.Err(), context.DeadlineExceeded)
+	})
+	router.Route("/configured", http.MethodGet).Override(RouteConfig{Timeout: time.Millisecond})
+	router.GET("/free", func(c *Context) {
+		assert.Nil(t, c.Done())
+		assert.NoError(t, c.Err())
+	})
+
+	assert.Equal(t, http.StatusGatewayTimeout, PerformRequest(router, http.MethodGet, "/timeout").Code)
+	assert.Equal(t, http.StatusOK, PerformRequest(router, http.MethodGet, "/configured").Code)
+	assert.Equal(t, http.StatusOK, PerformRequest(router, http.MethodGet, "/free").Code)
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import "net/http"
+
+// TrailingSlashPolicy is the handling of the requests whose path matches a
+// route only with or without a trailing slash, see RouterGroup.TrailingSlash.
+type TrailingSlashPolicy uint8
+
+const (
+	// TrailingSlashInherit inherits the policy of the parent group, the
+	// engine's RedirectTrailingSlash for the top-level groups.
+	TrailingSlashInherit TrailingSlashPolicy = iota
+	// TrailingSlashStrict answers 404.
+	TrailingSlashStrict
+	// TrailingSlashRedirect redirects with 301 for GET requests and 307 for
+	// the other methods, as RedirectTrailingSlash does.
+	TrailingSlashRedirect
+	// TrailingSlashPermanentRedirect redirects with 308 whatever the method.
+	TrailingSlashPermanentRedirect
+	// TrailingSlashMatch serves the route directly, without redirect.
+	TrailingSlashMatch
+)
+
+// TrailingSlash sets the handling of the requests whose path matches a route of
+// the group or of its subgroups only with or without a trailing slash, instead
+// of the engine-wide RedirectTrailingSlash:
+//
+//	api := router.Group("/api").TrailingSlash(gin.TrailingSlashMatch)
+//	api.GET("/users", listUsers) // serves "/api/users/" too
+func (group *RouterGroup) TrailingSlash(policy TrailingSlashPolicy) *RouterGroup {
+	group.trailingSlash = policy
+	return group
+}
+
+// trailingSlashPolicy returns the policy of the group or of its closest parent
+// setting one.
+func (group *RouterGroup) trailingSlashPolicy() TrailingSlashPolicy {
+	for ; group != nil; group = group.parent {
+		if group.trailingSlash != TrailingSlashInherit {
+			return group.trailingSlash
+		}
+	}
+	return TrailingSlashInherit
+}
+
+// serveTrailingSlash handles a request matching a route of the tree only with
+// or without a trailing slash, according to the policy of the route's group.
+// It reports whether the request was handled.
+func (engine *Engine) serveTrailingSlash(c *Context, root *node, host, httpMethod, rPath string, unescape bool) bool {
+	tsrPath := rPath + "/"
+	if rPath[len(rPath)-1] == '/' {
+		tsrPath = rPath[:len(rPath)-1]
+	}
+	*c.params = (*c.params)[:0]
+	*c.skippedNodes = (*c.skippedNodes)[:0]
+	value := root.getValue(tsrPath, c.params, c.skippedNodes, unescape)
+
+	policy := TrailingSlashInherit
+	var route *Route
+	if value.handlers != nil {
+		if route = engine.table.Load().routeIndex[routeKey(host, httpMethod, value.fullPath)]; route != nil {
+			policy = route.group.trailingSlashPolicy()
+		}
+	}
+	if policy == TrailingSlashInherit {
+		policy = TrailingSlashStrict
+		if engine.RedirectTrailingSlash {
+			policy = TrailingSlashRedirect
+		}
+	}
+
+	switch policy {
+	case TrailingSlashRedirect:
+		redirectTrailingSlash(c, 0)
+	case TrailingSlashPermanentRedirect:
+		redirectTrailingSlash(c, http.StatusPermanentRedirect)
+	case TrailingSlashMatch:
+		if value.handlers == nil {
+			return false
+		}
+		if value.params != nil {
+			c.Params = *value.params
+		}
+		c.routeHost = host
+		engine.serveRoute(c, httpMethod, value)
+	default:
+		return false
+	}
+	if route != nil {
+		route.slashFixes.Add(1)
+	}
+	return true
+}
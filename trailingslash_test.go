@@ -0,0 +1,49 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterGroupTrailingSlash(t *testing.T) {
+	router := New()
+	router.GET("/default", handlerTest1)
+	router.Group("/strict").TrailingSlash(TrailingSlashStrict).GET("/items", handlerTest1)
+	permanent := router.Group("/permanent").TrailingSlash(TrailingSlashPermanentRedirect)
+	permanent.POST("/items/", handlerTest1)
+	match := router.Group("/match").TrailingSlash(TrailingSlashMatch)
+	match.GET("/users/:id", func(c *Context) { c.String(http.StatusOK, c.FullPath()+" "+c.Param("id")) })
+	match.Group("/inherit").GET("/items", handlerTest1)
+	match.Group("/redirect").TrailingSlash(TrailingSlashRedirect).GET("/items", handlerTest1)
+	router.Host("api.example.com").TrailingSlash(TrailingSlashMatch).GET("/host", handlerTest1)
+
+	for _, tt := range []struct {
+		method, path string
+		code         int
+		location     string
+	}{
+		{http.MethodGet, "/default/", http.StatusMovedPermanently, "/default"},
+		{http.MethodGet, "/strict/items/", http.StatusNotFound, ""},
+		{http.MethodPost, "/permanent/items", http.StatusPermanentRedirect, "/permanent/items/"},
+		{http.MethodGet, "/match/users/7/", http.StatusOK, ""},
+		{http.MethodGet, "/match/inherit/items/", http.StatusOK, ""},
+		{http.MethodGet, "/match/redirect/items/", http.StatusMovedPermanently, "/match/redirect/items"},
+	} {
+		w := PerformRequest(router, tt.method, tt.path)
+		assert.Equal(t, tt.code, w.Code, tt.path)
+		assert.Equal(t, tt.location, w.Header().Get("Location"), tt.path)
+	}
+	assert.Equal(t, "/match/users/:id 7", PerformRequest(router, http.MethodGet, "/match/users/7/").Body.String())
+	assert.Equal(t, http.StatusOK, performHostRequest(router, http.MethodGet, "api.example.com", "/host/").Code)
+
+	// the groups inheriting the policy follow the engine
+	router.RedirectTrailingSlash = false
+	assert.Equal(t, http.StatusNotFound, PerformRequest(router, http.MethodGet, "/default/").Code)
+	assert.Equal(t, http.StatusOK, PerformRequest(router, http.MethodGet, "/match/users/7/").Code)
+}
@@ -0,0 +1,155 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+)
+
+// Tx is a transaction, such as a *sql.Tx.
+type Tx interface {
+	Commit() error
+	Rollback() error
+}
+
+// TxManager begins the transactions of the requests, see Transactional.
+type TxManager interface {
+	BeginTx(ctx context.Context) (Tx, error)
+}
+
+// TxManagerFunc is a function beginning transactions.
+type TxManagerFunc func(ctx context.Context) (Tx, error)
+
+// BeginTx calls f(ctx).
+func (f TxManagerFunc) BeginTx(ctx context.Context) (Tx, error) {
+	return f(ctx)
+}
+
+// SQLTxManager returns a TxManager beginning the *sql.Tx transactions of db
+// with opts.
+func SQLTxManager(db *sql.DB, opts *sql.TxOptions) TxManager {
+	return TxManagerFunc(func(ctx context.Context) (Tx, error) {
+		tx, err := db.BeginTx(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		return tx, nil
+	})
+}
+
+// requestTx is the transaction of a request.
+type requestTx struct {
+	tx Tx
+	// errs is the number of errors of the request when it began.
+	errs int
+	done atomic.Bool
+}
+
+// finish commits the transaction if the request succeeded, that is it has a
+// 2xx or 3xx status, was not aborted and got no errors since it began, or
+// rolls it back, once. It returns the error of the commit.
+func (t *requestTx) finish(c *Context, status int) error {
+	if !t.done.CompareAndSwap(false, true) {
+		return nil
+	}
+	if status >= 200 && status < 400 && !c.IsAborted() && len(c.Errors) == t.errs {
+		return t.tx.Commit()
+	}
+	t.rollback(c)
+	return nil
+}
+
+// rollback rolls the transaction back, attaching its error to the request.
+func (t *requestTx) rollback(c *Context) {
+	if err := t.tx.Rollback(); err != nil {
+		c.Error(err) //nolint: errcheck
+	}
+}
+
+// Transactional returns a middleware running the handlers after it in a
+// transaction begun by manager with the context of the request, which the
+// handlers get with Transaction:
+//
+//	router.POST("/transfers", gin.Transactional(gin.SQLTxManager(db, nil)), func(c *gin.Context) {
+//	    tx := gin.MustTransaction[*sql.Tx](c)
+//	    ...
+//	    c.JSON(http.StatusCreated, transfer)
+//	})
+//
+// The transaction is committed right before the response header is written,
+// or once the handlers return without writing it, if the status is 2xx or 3xx
+// and the request was neither aborted nor given errors, see Context.Error. It
+// is rolled back otherwise, including when the handlers panic, before the
+// panic goes on to Recovery. A failing commit answers 500 Internal Server
+// Error instead, discarding the body written by the handlers. The errors of
+// the commits and rollbacks are attached to the request, and the ones of
+// manager abort it with 500 Internal Server Error.
+//
+// The writes made once the header is written, such as by streaming handlers,
+// are not part of the transaction. Nested Transactional middlewares join the
+// transaction of the first one.
+func Transactional(manager TxManager) HandlerFunc {
+	assert1(manager != nil, "transaction manager can not be nil")
+	return func(c *Context) {
+		if c.tx != nil {
+			c.Next()
+			return
+		}
+		tx, err := manager.BeginTx(c.Request.Context())
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err) //nolint: errcheck
+			return
+		}
+		t := &requestTx{tx: tx, errs: len(c.Errors)}
+		c.tx = t
+		c.headerHooks = append(c.headerHooks, func() {
+			if err := t.finish(c, c.writermem.status); err != nil {
+				c.Error(err) //nolint: errcheck
+				c.writermem.status = http.StatusInternalServerError
+				c.writermem.discarded = true
+				header := c.writermem.Header()
+				header.Del("Content-Type")
+				header.Del("Content-Length")
+			}
+		})
+
+		returned := false
+		defer func() {
+			// the handlers panicked
+			if !returned && t.done.CompareAndSwap(false, true) {
+				t.rollback(c)
+			}
+		}()
+		c.Next()
+		returned = true
+		if err := t.finish(c, c.Writer.Status()); err != nil {
+			c.Error(err) //nolint: errcheck
+			c.Writer.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+}
+
+// Transaction returns the transaction of the request begun by Transactional,
+// as a T such as *sql.Tx, and whether there is one.
+func Transaction[T any](c *Context) (tx T, ok bool) {
+	if c.tx != nil {
+		tx, ok = c.tx.tx.(T)
+	}
+	return tx, ok
+}
+
+// MustTransaction returns the transaction of the request begun by
+// Transactional, as a T, and panics if there is none.
+func MustTransaction[T any](c *Context) T {
+	tx, ok := Transaction[T](c)
+	if !ok {
+		panic("no transaction of type " + reflect.TypeOf((*T)(nil)).Elem().String() + " was begun")
+	}
+	return tx
+}
@@ -0,0 +1,135 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeTx struct {
+	commitErr error
+	outcome   string
+	// sent reports whether a body was sent when it finished.
+	sent bool
+	w    ResponseWriter
+}
+
+func (tx *fakeTx) finish(outcome string) {
+	tx.outcome = outcome
+	tx.sent = tx.w != nil && tx.w.Size() > 0
+}
+
+func (tx *fakeTx) Commit() error {
+	tx.finish("commit")
+	return tx.commitErr
+}
+
+func (tx *fakeTx) Rollback() error {
+	tx.finish("rollback")
+	return nil
+}
+
+func TestTransactional(t *testing.T) {
+	var tx *fakeTx
+	var commitErr error
+	router := New()
+	router.Use(func(c *Context) {
+		tx = &fakeTx{w: c.Writer, commitErr: commitErr}
+		c.Next()
+	}, Recovery(), Transactional(TxManagerFunc(func(context.Context) (Tx, error) {
+		return tx, nil
+	})))
+	router.POST("/created", func(c *Context) {
+		assert.Same(t, tx, MustTransaction[*fakeTx](c))
+		c.JSON(http.StatusCreated, "ok")
+	})
+	router.POST("/status", func(c *Context) {
+		c.Status(http.StatusAccepted)
+	})
+	router.POST("/conflict", func(c *Context) {
+		c.AbortWithStatus(http.StatusConflict)
+	})
+	router.POST("/error", func(c *Context) {
+		c.Error(errors.New("failed")) //nolint: errcheck
+		c.String(http.StatusOK, "partial")
+	})
+	router.POST("/panic", func(c *Context) {
+		panic("boom")
+	})
+	router.POST("/nested", Transactional(TxManagerFunc(func(context.Context) (Tx, error) {
+		panic("nested transaction begun")
+	})), func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	for _, tt := range []struct {
+		path    string
+		code    int
+		outcome string
+	}{
+		{"/created", http.StatusCreated, "commit"},
+		{"/status", http.StatusAccepted, "commit"},
+		{"/conflict", http.StatusConflict, "rollback"},
+		{"/error", http.StatusOK, "rollback"},
+		{"/panic", http.StatusInternalServerError, "rollback"},
+		{"/nested", http.StatusOK, "commit"},
+	} {
+		w := PerformRequest(router, http.MethodPost, tt.path)
+		assert.Equal(t, tt.code, w.Code, tt.path)
+		assert.Equal(t, tt.outcome, tx.outcome, tt.path)
+		assert.False(t, tx.sent, tt.path)
+	}
+
+	// the failed commits replace the responses
+	commitErr = errors.New("serialization failure")
+	for _, path := range []string{"/created", "/status"} {
+		w := PerformRequest(router, http.MethodPost, path)
+		assert.Equal(t, http.StatusInternalServerError, w.Code, path)
+		assert.Empty(t, w.Body.String(), path)
+		assert.Empty(t, w.Header().Get("Content-Type"), path)
+		assert.Equal(t, "commit", tx.outcome, path)
+	}
+}
+
+func TestTransactionalErrors(t *testing.T) {
+	var errs []string
+	router := New()
+	router.Use(func(c *Context) {
+		c.Next()
+		errs = c.Errors.Errors()
+	})
+	router.MapErrors()
+	router.POST("/commit", Transactional(TxManagerFunc(func(context.Context) (Tx, error) {
+		return &fakeTx{commitErr: errors.New("serialization failure")}, nil
+	})), func(c *Context) {
+		c.Status(http.StatusCreated)
+	})
+	router.POST("/begin", Transactional(TxManagerFunc(func(context.Context) (Tx, error) {
+		return nil, errors.New("no connection")
+	})), func(c *Context) {
+		t.Error("the handler ran without transaction")
+	})
+
+	w := PerformRequest(router, http.MethodPost, "/commit")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, MIMEProblemJSON, w.Header().Get("Content-Type"))
+	assert.Equal(t, []string{"serialization failure"}, errs)
+
+	w = PerformRequest(router, http.MethodPost, "/begin")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, []string{"no connection"}, errs)
+
+	c, _ := CreateTestContext(nil)
+	_, ok := Transaction[*fakeTx](c)
+	assert.False(t, ok)
+	assert.PanicsWithValue(t, "no transaction of type *gin.fakeTx was begun", func() {
+		MustTransaction[*fakeTx](c)
+	})
+}
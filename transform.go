@@ -0,0 +1,511 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jialequ/mpgw/internal/json"
+)
+
+// JSONRewriteOp is the operation of a JSONRewrite.
+type JSONRewriteOp uint8
+
+const (
+	// JSONSet sets the value at the path, creating the missing objects.
+	JSONSet JSONRewriteOp = iota
+	// JSONRemove removes the value at the path.
+	JSONRemove
+	// JSONRename renames the field at the path to To.
+	JSONRename
+)
+
+// JSONRewrite rewrites the fields of a JSON request body matched by a JSONPath.
+// Paths support the root $, fields (.name or ['name']), indexes ([0]) and
+// wildcards ([*] or .*), e.g. "$.items[*].price".
+type JSONRewrite struct {
+	Op    JSONRewriteOp
+	Path  string
+	Value any
+	To    string
+}
+
+// RequestTransform declares the rewrites of the requests of a route, applied by
+// the TransformRequest middleware before the handlers, typically before proxying
+// them to an upstream.
+type RequestTransform struct {
+	// SetHeaders sets request headers, replacing their values.
+	SetHeaders map[string]string
+	// AddHeaders adds values to request headers.
+	AddHeaders map[string]string
+	// RemoveHeaders removes request headers.
+	RemoveHeaders []string
+
+	// RenameQuery renames query parameters, from old to new name.
+	RenameQuery map[string]string
+	// SetQuery sets query parameters, replacing their values.
+	SetQuery map[string]string
+	// RemoveQuery removes query parameters.
+	RemoveQuery []string
+
+	// JSON lists the rewrites of JSON request bodies, applied in order. Bodies
+	// which are not JSON are left as is.
+	JSON []JSONRewrite
+}
+
+type jsonPathSegment struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+type compiledJSONRewrite struct {
+	JSONRewrite
+	segments []jsonPathSegment
+}
+
+var transformBufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// TransformRequest returns a middleware applying the transform to the requests.
+// JSON paths are compiled once, and bodies are rewritten in pooled buffers,
+// reused once the request has been served.
+// Requests whose JSON body cannot be parsed are aborted with status 400.
+func TransformRequest(transform RequestTransform) HandlerFunc {
+	rewrites := make([]compiledJSONRewrite, 0, len(transform.JSON))
+	for _, rw := range transform.JSON {
+		segments, err := parseJSONPath(rw.Path)
+		if err != nil {
+			panic(err)
+		}
+		assert1(rw.Op != JSONRename || rw.To != "", "JSONRename needs To: "+rw.Path)
+		assert1(len(segments) > 0 || rw.Op == JSONSet, "cannot remove or rename the root of the body")
+		rewrites = append(rewrites, compiledJSONRewrite{JSONRewrite: rw, segments: segments})
+	}
+
+	return func(c *Context) {
+		header := c.Request.Header
+		for _, name := range transform.RemoveHeaders {
+			header.Del(name)
+		}
+		for name, value := range transform.SetHeaders {
+			header.Set(name, value)
+		}
+		for name, value := range transform.AddHeaders {
+			header.Add(name, value)
+		}
+
+		if len(transform.RenameQuery)+len(transform.SetQuery)+len(transform.RemoveQuery) > 0 {
+			query := c.Request.URL.Query()
+			for from, to := range transform.RenameQuery {
+				if values, ok := query[from]; ok {
+					delete(query, from)
+					query[to] = values
+				}
+			}
+			for name, value := range transform.SetQuery {
+				query.Set(name, value)
+			}
+			for _, name := range transform.RemoveQuery {
+				query.Del(name)
+			}
+			c.Request.URL.RawQuery = query.Encode()
+			c.queryCache = nil
+		}
+
+		if len(rewrites) > 0 && isJSONRequest(c.Request) {
+			buf := transformBufferPool.Get().(*bytes.Buffer)
+			buf.Reset()
+			defer transformBufferPool.Put(buf)
+			if err := rewriteJSONBody(c.Request, rewrites, buf); err != nil {
+				c.AbortWithError(http.StatusBadRequest, err).SetType(ErrorTypeBind) //nolint: errcheck
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+func isJSONRequest(req *http.Request) bool {
+	if req.Body == nil || req.Body == http.NoBody {
+		return false
+	}
+	ct := filterFlags(req.Header.Get("Content-Type"))
+	return ct == MIMEJSON || strings.HasSuffix(ct, "+json")
+}
+
+// rewriteJSONBody rewrites the body of req, using buf to hold the new body.
+func rewriteJSONBody(req *http.Request, rewrites []compiledJSONRewrite, buf *bytes.Buffer) error {
+	if _, err := buf.ReadFrom(req.Body); err != nil {
+		return err
+	}
+	req.Body.Close()
+
+	var doc any
+	decoder := json.NewDecoder(bytes.NewReader(buf.Bytes()))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return err
+	}
+	for _, rw := range rewrites {
+		doc = applyJSONRewrite(doc, rw.segments, &rw.JSONRewrite)
+	}
+
+	buf.Reset()
+	if err := json.NewEncoder(buf).Encode(doc); err != nil {
+		return err
+	}
+	body := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
+
+// applyJSONRewrite applies rw to the values of node matched by segments, and
+// returns the new node.
+func applyJSONRewrite(node any, segments []jsonPathSegment, rw *JSONRewrite) any {
+	if len(segments) == 0 {
+		return rw.Value
+	}
+	seg, last := segments[0], len(segments) == 1
+	switch n := node.(type) {
+	case map[string]any:
+		if seg.isIndex {
+			return node
+		}
+		keys := []string{seg.key}
+		if seg.wildcard {
+			keys = make([]string, 0, len(n))
+			for k := range n {
+				keys = append(keys, k)
+			}
+		}
+		for _, k := range keys {
+			child, ok := n[k]
+			switch {
+			case !last:
+				if !ok && rw.Op == JSONSet && !seg.wildcard {
+					child = map[string]any{}
+				} else if !ok {
+					continue
+				}
+				n[k] = applyJSONRewrite(child, segments[1:], rw)
+			case rw.Op == JSONSet:
+				n[k] = rw.Value
+			case rw.Op == JSONRemove:
+				delete(n, k)
+			case rw.Op == JSONRename && ok:
+				delete(n, k)
+				n[rw.To] = child
+			}
+		}
+		return n
+	case []any:
+		if !seg.isIndex && !seg.wildcard {
+			return node
+		}
+		if seg.wildcard {
+			if last && rw.Op == JSONRemove {
+				return n[:0]
+			}
+			for i := range n {
+				n[i] = applyJSONRewrite(n[i], segments[1:], rw)
+			}
+			return n
+		}
+		if seg.index < 0 || seg.index >= len(n) {
+			return n
+		}
+		if last && rw.Op == JSONRemove {
+			return append(n[:seg.index], n[seg.index+1:]...)
+		}
+		if !last || rw.Op == JSONSet {
+			n[seg.index] = applyJSONRewrite(n[seg.index], segments[1:], rw)
+		}
+		return n
+	}
+	return node
+}
+
+// parseJSONPath parses a JSONPath made of fields, indexes and wildcards.
+func parseJSONPath(path string) ([]jsonPathSegment, error) {
+	invalid := func() ([]jsonPathSegment, error) {
+		return nil, &jsonPathError{path}
+	}
+	if !strings.HasPrefix(path, "$") {
+		return invalid()
+	}
+	var segments []jsonPathSegment
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			name := rest[:end]
+			if name == "" {
+				return invalid()
+			}
+			segments = append(segments, jsonPathSegment{key: name, wildcard: name == "*"})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return invalid()
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			switch {
+			case inner == "*":
+				segments = append(segments, jsonPathSegment{wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				segments = append(segments, jsonPathSegment{key: inner[1 : len(inner)-1]})
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return invalid()
+				}
+				segments = append(segments, jsonPathSegment{index: index, isIndex: true})
+			}
+		default:
+			return invalid()
+		}
+	}
+	return segments, nil
+}
+
+type jsonPathError struct {
+	path string
+}
+
+func (e *jsonPathError) Error() string {
+	return "invalid JSONPath " + strconv.Quote(e.path)
+}
+
+// MIMEProblemJSON is the media type of problem details, see RFC 9457.
+const MIMEProblemJSON = "application/problem+json"
+
+const defaultMaxErrorBody = 4096
+
+// hopByHopHeaders are the headers meaningful only for a single connection,
+// see RFC 9110 section 7.6.1.
+var hopByHopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// Problem is a problem details object, see RFC 9457.
+type Problem struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Status   int    `json:"status,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// ResponseTransform declares the rewrites of the responses of a route, applied
+// by the TransformResponse middleware, typically to responses proxied from an
+// upstream.
+type ResponseTransform struct {
+	// StripHopByHop removes the hop-by-hop headers, and the ones listed by
+	// the Connection header.
+	StripHopByHop bool
+
+	// SetHeaders sets response headers, replacing their values.
+	SetHeaders map[string]string
+	// RemoveHeaders removes response headers.
+	RemoveHeaders []string
+
+	// RewriteLocation rewrites the Location and Content-Location headers
+	// starting with a prefix, e.g. "http://users:8080/" to "/api/users/".
+	// The longest matching prefix wins.
+	RewriteLocation map[string]string
+
+	// ErrorProblems replaces the bodies of error responses, with status 400 or
+	// more, by problem details, unless they already are. Other responses are
+	// streamed as is.
+	ErrorProblems bool
+
+	// MapError builds the problem details of an error response from its status,
+	// headers and body, truncated to MaxErrorBody bytes. Optional. Default
+	// value uses the status text as title and the body as detail.
+	MapError func(status int, header http.Header, body []byte) Problem
+
+	// MaxErrorBody caps the size of the error bodies given to MapError.
+	// Optional. Default value is 4096.
+	MaxErrorBody int
+}
+
+// TransformResponse returns a middleware applying the transform to the
+// responses. Headers are rewritten right before being sent, so that streamed
+// responses are not buffered; only error bodies mapped to problem details are.
+func TransformResponse(transform ResponseTransform) HandlerFunc {
+	if transform.MaxErrorBody <= 0 {
+		transform.MaxErrorBody = defaultMaxErrorBody
+	}
+	if transform.MapError == nil {
+		transform.MapError = func(status int, _ http.Header, body []byte) Problem {
+			return Problem{
+				Type:   "about:blank",
+				Title:  http.StatusText(status),
+				Status: status,
+				Detail: strings.TrimSpace(string(body)),
+			}
+		}
+	}
+
+	return func(c *Context) {
+		w := &responseTransformWriter{ResponseWriter: c.Writer, transform: &transform}
+		c.Writer = w
+		defer func() { c.Writer = w.ResponseWriter }()
+
+		c.Next()
+
+		if c.hijacked {
+			return
+		}
+		// responses without body, e.g. aborted ones, have not been prepared yet
+		w.prepare()
+		if w.capturing {
+			w.writeProblem()
+		}
+	}
+}
+
+type responseTransformWriter struct {
+	ResponseWriter
+	transform *ResponseTransform
+	prepared  bool
+	capturing bool
+	body      bytes.Buffer
+}
+
+// prepare rewrites the headers before they are sent, and tells whether the
+// body must be captured to be mapped to problem details.
+func (w *responseTransformWriter) prepare() {
+	if w.prepared {
+		return
+	}
+	w.prepared = true
+	t := w.transform
+	header := w.Header()
+	if t.StripHopByHop {
+		for _, value := range header.Values("Connection") {
+			for _, name := range strings.Split(value, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					header.Del(name)
+				}
+			}
+		}
+		for _, name := range hopByHopHeaders {
+			header.Del(name)
+		}
+	}
+	for _, name := range t.RemoveHeaders {
+		header.Del(name)
+	}
+	for name, value := range t.SetHeaders {
+		header.Set(name, value)
+	}
+	if len(t.RewriteLocation) > 0 {
+		for _, name := range []string{"Location", "Content-Location"} {
+			if location := header.Get(name); location != "" {
+				header.Set(name, rewriteLocation(location, t.RewriteLocation))
+			}
+		}
+	}
+	if t.ErrorProblems && w.Status() >= http.StatusBadRequest &&
+		filterFlags(header.Get("Content-Type")) != MIMEProblemJSON {
+		w.capturing = true
+	}
+}
+
+func rewriteLocation(location string, prefixes map[string]string) string {
+	best := ""
+	for prefix := range prefixes {
+		if len(prefix) > len(best) && strings.HasPrefix(location, prefix) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return location
+	}
+	return prefixes[best] + location[len(best):]
+}
+
+func (w *responseTransformWriter) WriteHeaderNow() {
+	w.prepare()
+	if !w.capturing {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *responseTransformWriter) Write(data []byte) (int, error) {
+	w.prepare()
+	if w.capturing {
+		w.capture(data)
+		return len(data), nil
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseTransformWriter) WriteString(s string) (int, error) {
+	w.prepare()
+	if w.capturing {
+		w.capture([]byte(s))
+		return len(s), nil
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *responseTransformWriter) capture(data []byte) {
+	if room := w.transform.MaxErrorBody - w.body.Len(); room > 0 {
+		if len(data) > room {
+			data = data[:room]
+		}
+		w.body.Write(data)
+	}
+}
+
+func (w *responseTransformWriter) Written() bool {
+	return w.capturing || w.ResponseWriter.Written()
+}
+
+func (w *responseTransformWriter) Flush() {
+	w.prepare()
+	if !w.capturing {
+		w.ResponseWriter.Flush()
+	}
+}
+
+// writeProblem writes the problem details of the error response.
+func (w *responseTransformWriter) writeProblem() {
+	header := w.Header()
+	problem := w.transform.MapError(w.Status(), header, w.body.Bytes())
+	body, err := json.Marshal(problem)
+	if err != nil {
+		panic(err)
+	}
+	header.Del("Content-Encoding")
+	header.Set("Content-Type", MIMEProblemJSON)
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	w.ResponseWriter.Write(body) //nolint: errcheck
+}
@@ -0,0 +1,191 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type transformedRequest struct {
+	header http.Header
+	query  string
+	body   string
+	length int64
+}
+
+func performTransform(t *testing.T, transform RequestTransform, req *http.Request) (*httptest.ResponseRecorder, transformedRequest) {
+	var got transformedRequest
+	router := New()
+	router.Any("/upstream", TransformRequest(transform), func(c *Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		require.NoError(t, err)
+		got = transformedRequest{c.Request.Header, c.Request.URL.RawQuery, string(body), c.Request.ContentLength}
+		assert.Equal(t, c.Request.URL.Query().Get("page"), c.Query("page"))
+	})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w, got
+}
+
+func TestTransformRequestHeadersAndQuery(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/upstream?p=2&debug=1&keep=x", nil)
+	req.Header.Set("Cookie", "secret")
+	req.Header.Set("X-Env", "dev")
+	_, got := performTransform(t, RequestTransform{
+		SetHeaders:    map[string]string{"X-Env": "prod"},
+		AddHeaders:    map[string]string{"X-Forwarded-Prefix": "/api"},
+		RemoveHeaders: []string{"Cookie"},
+		RenameQuery:   map[string]string{"p": "page", "missing": "other"},
+		SetQuery:      map[string]string{"source": "gateway"},
+		RemoveQuery:   []string{"debug"},
+	}, req)
+
+	assert.Empty(t, got.header.Get("Cookie"))
+	assert.Equal(t, "prod", got.header.Get("X-Env"))
+	assert.Equal(t, "/api", got.header.Get("X-Forwarded-Prefix"))
+	assert.Equal(t, "keep=x&page=2&source=gateway", got.query)
+}
+
+func TestTransformRequestJSON(t *testing.T) {
+	transform := RequestTransform{JSON: []JSONRewrite{
+		{Op: JSONRename, Path: "$.user.name", To: "fullName"},
+		{Op: JSONRemove, Path: "$.user.password"},
+		{Op: JSONSet, Path: "$.meta.source", Value: "gateway"},
+		{Op: JSONSet, Path: "$.items[*].currency", Value: "EUR"},
+		{Op: JSONRemove, Path: "$['items'][0].internal"},
+		{Op: JSONRemove, Path: "$.tags[1]"},
+		{Op: JSONSet, Path: "$.tags[9]", Value: "ignored"},
+	}}
+	body := `{"user":{"name":"Ada","password":"x"},"items":[{"id":1,"internal":true},{"id":12345678901234567890}],"tags":["a","b","c"]}`
+	req := httptest.NewRequest(http.MethodPost, "/upstream", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	w, got := performTransform(t, transform, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"user":{"fullName":"Ada"},"meta":{"source":"gateway"},
+		"items":[{"id":1,"currency":"EUR"},{"id":12345678901234567890,"currency":"EUR"}],"tags":["a","c"]}`, got.body)
+	assert.Contains(t, got.body, "12345678901234567890")
+	assert.Equal(t, int64(len(got.body)), got.length)
+
+	// other bodies are left as is
+	req = httptest.NewRequest(http.MethodPost, "/upstream", strings.NewReader("a=b"))
+	req.Header.Set("Content-Type", MIMEPOSTForm)
+	_, got = performTransform(t, transform, req)
+	assert.Equal(t, "a=b", got.body)
+
+	req = httptest.NewRequest(http.MethodPost, "/upstream", strings.NewReader("{"))
+	req.Header.Set("Content-Type", "application/vnd.api+json")
+	w, _ = performTransform(t, transform, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestParseJSONPath(t *testing.T) {
+	segments, err := parseJSONPath(`$.a["b c"][2].*[*]`)
+	require.NoError(t, err)
+	assert.Equal(t, []jsonPathSegment{
+		{key: "a"}, {key: "b c"}, {index: 2, isIndex: true}, {key: "*", wildcard: true}, {wildcard: true},
+	}, segments)
+
+	for _, path := range []string{"a.b", "$.", "$..a", "$[x]", "$[0", "$a"} {
+		_, err = parseJSONPath(path)
+		assert.EqualError(t, err, "invalid JSONPath \""+path+"\"")
+	}
+
+	assert.Panics(t, func() { TransformRequest(RequestTransform{JSON: []JSONRewrite{{Path: "nope"}}}) })
+	assert.Panics(t, func() { TransformRequest(RequestTransform{JSON: []JSONRewrite{{Op: JSONRename, Path: "$.a"}}}) })
+	assert.Panics(t, func() { TransformRequest(RequestTransform{JSON: []JSONRewrite{{Op: JSONRemove, Path: "$"}}}) })
+}
+
+func TestTransformResponseHeaders(t *testing.T) {
+	router := New()
+	router.Use(TransformResponse(ResponseTransform{
+		StripHopByHop:   true,
+		SetHeaders:      map[string]string{"X-Gateway": "mpgw"},
+		RemoveHeaders:   []string{"Server"},
+		RewriteLocation: map[string]string{"http://users:8080/": "/api/", "http://users:8080/v2/": "/api/v2/"},
+	}))
+	router.GET("/redirect", func(c *Context) {
+		c.Header("Connection", "close, X-Upstream-Trace")
+		c.Header("X-Upstream-Trace", "1")
+		c.Header("Keep-Alive", "timeout=5")
+		c.Header("Server", "upstream")
+		c.Header("Content-Location", "http://other/x")
+		c.Redirect(http.StatusFound, "http://users:8080/v2/users/1")
+	})
+	router.GET("/empty", func(c *Context) {
+		c.Header("Location", "http://users:8080/users")
+		c.Status(http.StatusCreated)
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/redirect")
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/api/v2/users/1", w.Header().Get("Location"))
+	assert.Equal(t, "http://other/x", w.Header().Get("Content-Location"))
+	assert.Equal(t, "mpgw", w.Header().Get("X-Gateway"))
+	for _, name := range []string{"Connection", "X-Upstream-Trace", "Keep-Alive", "Server"} {
+		assert.Empty(t, w.Header().Get(name), name)
+	}
+
+	w = PerformRequest(router, http.MethodGet, "/empty")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "/api/users", w.Header().Get("Location"))
+	assert.Equal(t, "mpgw", w.Header().Get("X-Gateway"))
+}
+
+func TestTransformResponseProblems(t *testing.T) {
+	router := New()
+	router.Use(TransformResponse(ResponseTransform{ErrorProblems: true, MaxErrorBody: 10}))
+	router.GET("/ok", func(c *Context) {
+		c.String(http.StatusOK, "fine")
+		c.Writer.Flush()
+	})
+	router.GET("/error", func(c *Context) {
+		c.Header("Content-Encoding", "gzip")
+		c.String(http.StatusBadGateway, "upstream exploded badly")
+	})
+	router.GET("/abort", func(c *Context) {
+		c.AbortWithStatus(http.StatusForbidden)
+	})
+	router.GET("/problem", func(c *Context) {
+		c.Data(http.StatusNotFound, MIMEProblemJSON, []byte(`{"title":"own"}`))
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/ok")
+	assert.Equal(t, "fine", w.Body.String())
+
+	w = PerformRequest(router, http.MethodGet, "/error")
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Equal(t, MIMEProblemJSON, w.Header().Get("Content-Type"))
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.JSONEq(t, `{"type":"about:blank","title":"Bad Gateway","status":502,"detail":"upstream e"}`, w.Body.String())
+
+	w = PerformRequest(router, http.MethodGet, "/abort")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.JSONEq(t, `{"type":"about:blank","title":"Forbidden","status":403}`, w.Body.String())
+
+	w = PerformRequest(router, http.MethodGet, "/problem")
+	assert.JSONEq(t, `{"title":"own"}`, w.Body.String())
+
+	router = New()
+	router.Use(TransformResponse(ResponseTransform{
+		ErrorProblems: true,
+		MapError: func(status int, header http.Header, body []byte) Problem {
+			return Problem{Type: "https://example.com/upstream", Status: status, Detail: header.Get("X-Reason")}
+		},
+	}))
+	router.GET("/", func(c *Context) {
+		c.Header("X-Reason", "quota")
+		c.JSON(http.StatusTooManyRequests, H{"error": "slow down"})
+	})
+	w = PerformRequest(router, http.MethodGet, "/")
+	assert.JSONEq(t, `{"type":"https://example.com/upstream","status":429,"detail":"quota"}`, w.Body.String())
+}
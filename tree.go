@@ -7,6 +7,7 @@ package gin
 import (
 	"bytes"
 	"net/url"
+	"strconv"
 	"strings"
 	"unicode"
 	"unicode/utf8"
@@ -20,7 +21,13 @@ var (
 	strSlash = []byte("/")
 )
 
+// segmentWildcard is the key of the values of the anonymous wildcards matching a
+// single path segment, as in "/files/*/meta".
+const segmentWildcard = "*"
+
 // Param is a single URL parameter, consisting of a key and a value.
+// The values of the anonymous wildcards matching a single path segment, as in
+// "/files/*/meta", have the key "*", in the order of the path.
 type Param struct {
 	Key   string
 	Value string
@@ -115,6 +122,16 @@ type node struct {
 	children  []*node // child nodes, at most 1 :param style node at the end of the array
 	handlers  HandlersChain
 	fullPath  string
+
+	// constraint is the constraint of the value of a param node, or the
+	// segment bounds of a catch-all one, and alt the next param node at the
+	// same position, tried when the constraint fails.
+	constraint func(string) bool
+	alt        *node
+
+	// yield reports whether the wildcard sibling of the static node is tried
+	// first, as its routes have a higher priority, see RouteHandle.Priority.
+	yield bool
 }
 
 // Increments priority of the given child and reorders if necessary
@@ -140,15 +157,50 @@ func (n *node) incrementChildPrio(pos int) int {
 	return newPos
 }
 
+// sortChildren orders the indexed children of the nodes of the tree by
+// priority, as incrementChildPrio does on each insertion.
+func (n *node) sortChildren() {
+	if len(n.indices) > 1 {
+		indices := []byte(n.indices)
+		// insertion sort, stable and without allocations
+		for i := 1; i < len(indices); i++ {
+			for j := i; j > 0 && n.children[j-1].priority < n.children[j].priority; j-- {
+				n.children[j-1], n.children[j] = n.children[j], n.children[j-1]
+				indices[j-1], indices[j] = indices[j], indices[j-1]
+			}
+		}
+		n.indices = string(indices)
+	}
+	for _, child := range n.children {
+		child.sortChildren()
+	}
+	if n.alt != nil {
+		n.alt.sortChildren()
+	}
+}
+
 // addRoute adds a node with the given handle to the path.
 // Not concurrency-safe!
-func (n *node) addRoute(path string, handlers HandlersChain) { // NOSONAR
+func (n *node) addRoute(path string, handlers HandlersChain) {
+	n.addConstrainedRoute(path, handlers, builtinConstraints)
+}
+
+// addConstrainedRoute adds a node with the given handle to the path, resolving
+// the constraints of its params in constraints.
+func (n *node) addConstrainedRoute(path string, handlers HandlersChain, constraints map[string]func(string) bool) {
+	n.insertRoute(path, handlers, constraints, true)
+}
+
+// insertRoute adds a node with the given handle to the path. The children are
+// reordered by priority unless reorder is false, for the bulk insertions
+// sorting them once with sortChildren.
+func (n *node) insertRoute(path string, handlers HandlersChain, constraints map[string]func(string) bool, reorder bool) { // NOSONAR
 	fullPath := path
 	n.priority++
 
 	// Empty tree
 	if len(n.path) == 0 && len(n.children) == 0 {
-		n.insertChild(path, fullPath, handlers)
+		n.insertChild(path, fullPath, handlers, constraints)
 		n.nType = root
 		return
 	}
@@ -201,7 +253,11 @@ walk:
 			for i, max := 0, len(n.indices); i < max; i++ {
 				if c == n.indices[i] {
 					parentFullPathIndex += len(n.path)
-					i = n.incrementChildPrio(i)
+					if reorder {
+						i = n.incrementChildPrio(i)
+					} else {
+						n.children[i].priority++
+					}
 					n = n.children[i]
 					continue walk
 				}
@@ -215,16 +271,30 @@ walk:
 					fullPath: fullPath,
 				}
 				n.addChild(child)
-				n.incrementChildPrio(len(n.indices) - 1)
+				if reorder {
+					n.incrementChildPrio(len(n.indices) - 1)
+				} else {
+					child.priority++
+				}
 				n = child
 			} else if n.wildChild {
 				// inserting a wildcard node, need to check if it conflicts with the existing wildcard
+				parent := n
 				n = n.children[len(n.children)-1]
 				n.priority++
 
-				// Check if the wildcard matches
-				if len(path) >= len(n.path) && n.path == path[:len(n.path)] {
-					continue walk
+				// Check if the wildcard matches, the whole segment, or one of
+				// the params with other constraints at the same position
+				for alt := n; alt != nil; alt = alt.alt {
+					if len(path) >= len(alt.path) && alt.path == path[:len(alt.path)] &&
+						(len(alt.path) == len(path) || alt.nType != catchAll && path[len(alt.path)] == '/') {
+						n = alt
+						continue walk
+					}
+				}
+				if c == ':' && n.nType == param && (n.constraint != nil || hasConstraint(path)) {
+					parent.insertAlternative(path, fullPath, handlers, constraints)
+					return
 				}
 
 				// Wildcard conflict
@@ -240,7 +310,7 @@ walk:
 					"'")
 			}
 
-			n.insertChild(path, fullPath, handlers)
+			n.insertChild(path, fullPath, handlers, constraints)
 			return
 		}
 
@@ -254,6 +324,55 @@ walk:
 	}
 }
 
+// paramKey returns the key of the values of a param node.
+func paramKey(path string) string {
+	if path == segmentWildcard {
+		return segmentWildcard
+	}
+	if i := strings.IndexByte(path, '|'); i > 0 {
+		return path[1:i]
+	}
+	return path[1:]
+}
+
+// hasConstraint reports whether the param at the start of path has a
+// constraint, as in ":id|int".
+func hasConstraint(path string) bool {
+	end := strings.IndexByte(path, '/')
+	if end < 0 {
+		end = len(path)
+	}
+	return strings.IndexByte(path[:end], '|') > 0
+}
+
+// insertAlternative inserts the param at the start of path as an alternative
+// of the wildcard child of n with another constraint. The constrained params
+// are tried in registration order, before the unconstrained one.
+func (n *node) insertAlternative(path, fullPath string, handlers HandlersChain, constraints map[string]func(string) bool) {
+	tmp := &node{}
+	tmp.insertChild(path, fullPath, handlers, constraints)
+	alt := tmp.children[0]
+
+	head := &n.children[len(n.children)-1]
+	if alt.constraint == nil {
+		last := *head
+		for last.alt != nil {
+			last = last.alt
+		}
+		if last.constraint == nil {
+			panic("'" + alt.path + "' in new path '" + fullPath +
+				"' conflicts with existing wildcard '" + last.path + "'")
+		}
+		last.alt = alt
+		return
+	}
+	for *head != nil && (*head).constraint != nil {
+		head = &(*head).alt
+	}
+	alt.alt = *head
+	*head = alt
+}
+
 // Search for a wildcard segment and check the name for invalid characters.
 // Returns -1 as index, if no wildcard was found.
 func findWildcard(path string) (wildcard string, i int, valid bool) { // NOSONAR
@@ -278,20 +397,173 @@ func findWildcard(path string) (wildcard string, i int, valid bool) { // NOSONAR
 
 		// Find end and check for invalid characters
 		valid = true
+		wildcard = path[start:]
 		for end, c := range []byte(path[start+1:]) {
-			switch c {
-			case '/':
-				return path[start : start+1+end], start, valid
-			case ':', '*':
+			if c == '/' {
+				wildcard = path[start : start+1+end]
+				break
+			}
+			if c == ':' || c == '*' {
 				valid = false
 			}
 		}
-		return path[start:], start, valid
+		if !valid && isCompound(wildcard) {
+			valid = validCompound(wildcard)
+		}
+		return wildcard, start, valid
 	}
 	return "", -1, false
 }
 
-func (n *node) insertChild(path string, fullPath string, handlers HandlersChain) { // NOSONAR
+// catchAllBounds returns the constraint of the segment bounds of a catch-all
+// wildcard, as in "*path{1,3}" matching 1 to 3 segments, "*path{1,}" at least
+// one and "*path{2}" exactly two, nil if it has none. The segments are the
+// non-empty pieces of the value between the slashes. It reports whether the
+// bounds are valid.
+func catchAllBounds(wildcard string) (func(string) bool, bool) {
+	open := strings.IndexByte(wildcard, '{')
+	if open < 0 {
+		return nil, true
+	}
+	bounds, ok := strings.CutSuffix(wildcard[open+1:], "}")
+	if !ok {
+		return nil, false
+	}
+	lo, hi, ranged := strings.Cut(bounds, ",")
+	if !ranged {
+		hi = lo
+	}
+	minSegs, maxSegs := 0, -1
+	var err error
+	if lo != "" {
+		if minSegs, err = strconv.Atoi(lo); err != nil || minSegs < 0 {
+			return nil, false
+		}
+	}
+	if hi != "" {
+		if maxSegs, err = strconv.Atoi(hi); err != nil || maxSegs < max(minSegs, 1) {
+			return nil, false
+		}
+	}
+	if lo == "" && hi == "" {
+		return nil, false
+	}
+	return func(value string) bool {
+		segs := 0
+		for _, seg := range strings.Split(value, "/") {
+			if seg != "" {
+				segs++
+			}
+		}
+		return segs >= minSegs && (maxSegs < 0 || segs <= maxSegs)
+	}, true
+}
+
+// catchAllKey returns the key of the values of a catch-all node, without its
+// segment bounds.
+func catchAllKey(path string) string {
+	key := path[2:]
+	if i := strings.IndexByte(key, '{'); i >= 0 {
+		return key[:i]
+	}
+	return key
+}
+
+// accepts reports whether the constraint of the node, if any, accepts value.
+func (n *node) accepts(value string) bool {
+	return n.constraint == nil || n.constraint(value)
+}
+
+// isCompound reports whether the param wildcard is a compound segment of
+// several params separated by literals, as in ":name.:ext" or ":from-:to".
+func isCompound(wildcard string) bool {
+	return wildcard[0] == ':' && strings.IndexByte(wildcard[1:], ':') > 0
+}
+
+// paramNameLen returns the length of the name of a param of a compound
+// segment, made of letters, digits and underscores.
+func paramNameLen(s string) int {
+	for i, c := range []byte(s) {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// validCompound reports whether the params of a compound segment are named and
+// separated by a literal.
+func validCompound(wildcard string) bool {
+	for wildcard != "" {
+		end := 1 + paramNameLen(wildcard[1:])
+		if end == 1 {
+			return false
+		}
+		wildcard = wildcard[end:]
+		literal := wildcard
+		if i := strings.IndexByte(wildcard, ':'); i >= 0 {
+			literal = wildcard[:i]
+		}
+		if strings.ContainsAny(literal, "*\\") || literal == "" && wildcard != "" {
+			return false
+		}
+		wildcard = wildcard[len(literal):]
+	}
+	return true
+}
+
+// matchCompound matches the value of a path segment against a compound segment,
+// and appends the values of its params to ps when it is not nil. The values are
+// not empty and end at the first occurrence of the literal following them, or
+// at the end of the segment for a trailing literal, so "archive.tar.gz" matches
+// ":name.:ext" with name "archive" and ext "tar.gz".
+func matchCompound(pattern, value string, ps *Params, unescape bool) bool {
+	for pattern != "" {
+		end := 1 + paramNameLen(pattern[1:])
+		key := pattern[1:end]
+		pattern = pattern[end:]
+		literal := pattern
+		if i := strings.IndexByte(pattern, ':'); i >= 0 {
+			literal = pattern[:i]
+		}
+		pattern = pattern[len(literal):]
+
+		var val string
+		switch {
+		case literal == "":
+			val, value = value, ""
+		case pattern == "":
+			if !strings.HasSuffix(value, literal) {
+				return false
+			}
+			val, value = value[:len(value)-len(literal)], ""
+		default:
+			i := -1
+			if value != "" {
+				i = strings.Index(value[1:], literal)
+			}
+			if i < 0 {
+				return false
+			}
+			val, value = value[:i+1], value[i+1+len(literal):]
+		}
+		if val == "" {
+			return false
+		}
+
+		if ps != nil {
+			if unescape {
+				if v, err := url.QueryUnescape(val); err == nil {
+					val = v
+				}
+			}
+			*ps = append(*ps, Param{Key: key, Value: val})
+		}
+	}
+	return true
+}
+
+func (n *node) insertChild(path string, fullPath string, handlers HandlersChain, constraints map[string]func(string) bool) { // NOSONAR
 	for {
 		// Find prefix until first wildcard
 		wildcard, i, valid := findWildcard(path)
@@ -305,12 +577,15 @@ func (n *node) insertChild(path string, fullPath string, handlers HandlersChain)
 				wildcard + "' in path '" + fullPath + "'")
 		}
 
-		// check if the wildcard has a name
-		if len(wildcard) < 2 {
+		// check if the wildcard has a name, unless it is an anonymous wildcard
+		// matching a single segment in the middle of the path
+		segment := wildcard == segmentWildcard && i+1 < len(path) &&
+			strings.HasSuffix(fullPath[:len(fullPath)-len(path)+i], "/")
+		if len(wildcard) < 2 && !segment {
 			panic("wildcards must be named with a non-empty name in path '" + fullPath + "'")
 		}
 
-		if wildcard[0] == ':' { // param
+		if wildcard[0] == ':' || segment { // param
 			if i > 0 {
 				// Insert prefix before the current wildcard
 				n.path = path[:i]
@@ -322,6 +597,13 @@ func (n *node) insertChild(path string, fullPath string, handlers HandlersChain)
 				path:     wildcard,
 				fullPath: fullPath,
 			}
+			if i := strings.IndexByte(wildcard, '|'); i > 0 && !isCompound(wildcard) {
+				name := wildcard[i+1:]
+				child.constraint = constraints[name]
+				if child.constraint == nil {
+					panic("unknown constraint '" + name + "' in path '" + fullPath + "'")
+				}
+			}
 			n.addChild(child)
 			n.wildChild = true
 			n = child
@@ -368,6 +650,13 @@ func (n *node) insertChild(path string, fullPath string, handlers HandlersChain)
 		if path[i] != '/' {
 			panic("no / before catch-all in path '" + fullPath + "'")
 		}
+		if strings.HasPrefix(wildcard, "*{") {
+			panic("wildcards must be named with a non-empty name in path '" + fullPath + "'")
+		}
+		bounds, ok := catchAllBounds(wildcard)
+		if !ok {
+			panic("invalid segment bounds in catch-all '" + wildcard + "' in path '" + fullPath + "'")
+		}
 
 		n.path = path[:i]
 
@@ -385,11 +674,12 @@ func (n *node) insertChild(path string, fullPath string, handlers HandlersChain)
 
 		// second node: node holding the variable
 		child = &node{
-			path:     path[i:],
-			nType:    catchAll,
-			handlers: handlers,
-			priority: 1,
-			fullPath: fullPath,
+			path:       path[i:],
+			nType:      catchAll,
+			handlers:   handlers,
+			priority:   1,
+			fullPath:   fullPath,
+			constraint: bounds,
 		}
 		n.children = []*node{child}
 
@@ -410,10 +700,50 @@ type nodeValue struct {
 	fullPath string
 }
 
+// skippedNode is a node with a wildcard child whose static children are
+// tried first: the walk resumes at its wildcard child, with path, if they fail.
+// If alt is set, the walk resumes at this alternative of the wildcard child
+// instead, the previous ones having accepted the value but failed further.
+// If static is set, node is instead a static child yielding to its wildcard
+// sibling, walked with path if the wildcard fails.
 type skippedNode struct {
 	path        string
 	node        *node
+	alt         *node
 	paramsCount int16
+	static      bool
+}
+
+// rollback pops the skipped nodes down to the last one on path, truncating
+// params to its count, and returns it, or false if there is none. If siblings
+// is set, only the siblings of a wildcard which failed are considered: the
+// static children yielding to it and its alternatives.
+func rollback(skippedNodes *[]skippedNode, path string, params *Params, siblings bool) (skippedNode, bool) {
+	for i := len(*skippedNodes) - 1; i >= 0; i-- {
+		skipped := (*skippedNodes)[i]
+		if strings.HasSuffix(skipped.path, path) && (!siblings || skipped.static || skipped.alt != nil) {
+			*skippedNodes = (*skippedNodes)[:i]
+			if params != nil {
+				*params = (*params)[:skipped.paramsCount]
+			}
+			return skipped, true
+		}
+	}
+	if !siblings {
+		*skippedNodes = (*skippedNodes)[:0]
+	}
+	return skippedNode{}, false
+}
+
+// acceptingAlt returns the first of the alternative params from n whose
+// constraint accepts value, or nil.
+func acceptingAlt(n *node, value string) *node {
+	for ; n != nil; n = n.alt {
+		if n.constraint == nil || n.constraint(value) {
+			return n
+		}
+	}
+	return nil
 }
 
 // Returns the handle registered with the given path (key). The values of
@@ -423,35 +753,49 @@ type skippedNode struct {
 // given path.
 func (n *node) getValue(path string, params *Params, skippedNodes *[]skippedNode, unescape bool) (value nodeValue) { // NOSONAR
 	var globalParamsCount int16
+	// resumed reports whether n is a skipped node, whose static children
+	// already failed, and resumeAlt the alternative of its wildcard child to
+	// resume at
+	resumed := false
+	var resumeAlt *node
 
 walk: // Outer loop for walking the tree
 	for {
 		prefix := n.path
+		indices := n.indices
+		if resumed {
+			indices = ""
+			resumed = false
+		}
 		if len(path) > len(prefix) {
 			if path[:len(prefix)] == prefix {
+				walked := path
 				path = path[len(prefix):]
 
 				// Try all the non-wildcard children first by matching the indices
 				idxc := path[0]
-				for i, c := range []byte(n.indices) {
+				for i, c := range []byte(indices) {
 					if c == idxc {
 						//  strings.HasPrefix(n.children[len(n.children)-1].path, ":") == n.wildChild
 						if n.wildChild {
-							index := len(*skippedNodes)
-							*skippedNodes = (*skippedNodes)[:index+1]
-							(*skippedNodes)[index] = skippedNode{
-								path: prefix + path,
-								node: &node{
-									path:      n.path,
-									wildChild: n.wildChild,
-									nType:     n.nType,
-									priority:  n.priority,
-									children:  n.children,
-									handlers:  n.handlers,
-									fullPath:  n.fullPath,
-								},
-								paramsCount: globalParamsCount,
+							if n.children[i].yield {
+								// the wildcard child has a higher priority, the
+								// static child is walked if it fails
+								*skippedNodes = append(*skippedNodes, skippedNode{
+									path:        path,
+									node:        n.children[i],
+									paramsCount: globalParamsCount,
+									static:      true,
+								})
+								break
 							}
+							// appended, as the contexts allocated before the
+							// routes were updated may have a smaller capacity
+							*skippedNodes = append(*skippedNodes, skippedNode{
+								path:        walked,
+								node:        n,
+								paramsCount: globalParamsCount,
+							})
 						}
 
 						n = n.children[i]
@@ -463,18 +807,10 @@ walk: // Outer loop for walking the tree
 					// If the path at the end of the loop is not equal to '/' and the current node has no child nodes
 					// the current node needs to roll back to last valid skippedNode
 					if path != "/" {
-						for length := len(*skippedNodes); length > 0; length-- {
-							skippedNode := (*skippedNodes)[length-1]
-							*skippedNodes = (*skippedNodes)[:length-1]
-							if strings.HasSuffix(skippedNode.path, path) {
-								path = skippedNode.path
-								n = skippedNode.node
-								if value.params != nil {
-									*value.params = (*value.params)[:skippedNode.paramsCount]
-								}
-								globalParamsCount = skippedNode.paramsCount
-								continue walk
-							}
+						if skipped, ok := rollback(skippedNodes, path, value.params, false); ok {
+							path, n, globalParamsCount = skipped.path, skipped.node, skipped.paramsCount
+							resumed, resumeAlt = !skipped.static, skipped.alt
+							continue walk
 						}
 					}
 
@@ -486,7 +822,11 @@ walk: // Outer loop for walking the tree
 				}
 
 				// Handle wildcard child, which is always at the end of the array
+				parent := n
 				n = n.children[len(n.children)-1]
+				if resumeAlt != nil {
+					n, resumeAlt = resumeAlt, nil
+				}
 				globalParamsCount++
 
 				switch n.nType {
@@ -500,6 +840,31 @@ walk: // Outer loop for walking the tree
 						end++
 					}
 
+					// Find the param whose constraint accepts the value, the
+					// next one being tried if the walk fails past it
+					if n = acceptingAlt(n, path[:end]); n == nil {
+						// roll back to last valid skippedNode
+						if skipped, ok := rollback(skippedNodes, path, value.params, false); ok {
+							path, n, globalParamsCount = skipped.path, skipped.node, skipped.paramsCount
+							resumed, resumeAlt = !skipped.static, skipped.alt
+							continue walk
+						}
+						return value
+					}
+					if alt := acceptingAlt(n.alt, path[:end]); alt != nil {
+						*skippedNodes = append(*skippedNodes, skippedNode{
+							path:        walked,
+							node:        parent,
+							alt:         alt,
+							paramsCount: globalParamsCount - 1,
+						})
+					}
+
+					compound := isCompound(n.path)
+					if compound {
+						globalParamsCount += int16(strings.Count(n.path, ":")) - 1
+					}
+
 					// Save param value
 					if params != nil {
 						// Preallocate capacity if necessary
@@ -512,6 +877,18 @@ walk: // Outer loop for walking the tree
 						if value.params == nil {
 							value.params = params
 						}
+					}
+					if compound {
+						if !matchCompound(n.path, path[:end], value.params, unescape) {
+							// roll back to last valid skippedNode
+							if skipped, ok := rollback(skippedNodes, path, value.params, false); ok {
+								path, n, globalParamsCount = skipped.path, skipped.node, skipped.paramsCount
+								resumed, resumeAlt = !skipped.static, skipped.alt
+								continue walk
+							}
+							return value
+						}
+					} else if params != nil {
 						// Expand slice within preallocated capacity
 						i := len(*value.params)
 						*value.params = (*value.params)[:i+1]
@@ -522,7 +899,7 @@ walk: // Outer loop for walking the tree
 							}
 						}
 						(*value.params)[i] = Param{
-							Key:   n.path[1:],
+							Key:   paramKey(n.path),
 							Value: val,
 						}
 					}
@@ -536,6 +913,11 @@ walk: // Outer loop for walking the tree
 						}
 
 						// ... but we can't
+						if skipped, ok := rollback(skippedNodes, path, value.params, true); ok {
+							path, n, globalParamsCount = skipped.path, skipped.node, skipped.paramsCount
+							resumed, resumeAlt = !skipped.static, skipped.alt
+							continue walk
+						}
 						value.tsr = len(path) == end+1
 						return value
 					}
@@ -544,6 +926,11 @@ walk: // Outer loop for walking the tree
 						value.fullPath = n.fullPath
 						return value
 					}
+					if skipped, ok := rollback(skippedNodes, path, value.params, true); ok {
+						path, n, globalParamsCount = skipped.path, skipped.node, skipped.paramsCount
+						resumed, resumeAlt = !skipped.static, skipped.alt
+						continue walk
+					}
 					if len(n.children) == 1 {
 						// No handle found. Check if a handle for this path + a
 						// trailing slash exists for TSR recommendation
@@ -553,6 +940,16 @@ walk: // Outer loop for walking the tree
 					return value
 
 				case catchAll:
+					if n.constraint != nil && !n.constraint(path) {
+						// roll back to last valid skippedNode
+						if skipped, ok := rollback(skippedNodes, path, value.params, false); ok {
+							path, n, globalParamsCount = skipped.path, skipped.node, skipped.paramsCount
+							resumed, resumeAlt = !skipped.static, skipped.alt
+							continue walk
+						}
+						return value
+					}
+
 					// Save param value
 					if params != nil {
 						// Preallocate capacity if necessary
@@ -575,7 +972,7 @@ walk: // Outer loop for walking the tree
 							}
 						}
 						(*value.params)[i] = Param{
-							Key:   n.path[2:],
+							Key:   catchAllKey(n.path),
 							Value: val,
 						}
 					}
@@ -594,18 +991,10 @@ walk: // Outer loop for walking the tree
 			// If the current path does not equal '/' and the node does not have a registered handle and the most recently matched node has a child node
 			// the current node needs to roll back to last valid skippedNode
 			if n.handlers == nil && path != "/" {
-				for length := len(*skippedNodes); length > 0; length-- {
-					skippedNode := (*skippedNodes)[length-1]
-					*skippedNodes = (*skippedNodes)[:length-1]
-					if strings.HasSuffix(skippedNode.path, path) {
-						path = skippedNode.path
-						n = skippedNode.node
-						if value.params != nil {
-							*value.params = (*value.params)[:skippedNode.paramsCount]
-						}
-						globalParamsCount = skippedNode.paramsCount
-						continue walk
-					}
+				if skipped, ok := rollback(skippedNodes, path, value.params, false); ok {
+					path, n, globalParamsCount = skipped.path, skipped.node, skipped.paramsCount
+					resumed, resumeAlt = !skipped.static, skipped.alt
+					continue walk
 				}
 				//	n = latestNode.children[len(latestNode.children)-1]
 			}
@@ -631,11 +1020,11 @@ walk: // Outer loop for walking the tree
 
 			// No handle found. Check if a handle for this path + a
 			// trailing slash exists for trailing slash recommendation
-			for i, c := range []byte(n.indices) {
+			for i, c := range []byte(indices) {
 				if c == '/' {
 					n = n.children[i]
 					value.tsr = (len(n.path) == 1 && n.handlers != nil) ||
-						(n.nType == catchAll && n.children[0].handlers != nil)
+						(n.nType == catchAll && n.children[0].handlers != nil && n.children[0].accepts("/"))
 					return value
 				}
 			}
@@ -649,18 +1038,10 @@ walk: // Outer loop for walking the tree
 
 		// roll back to last valid skippedNode
 		if !value.tsr && path != "/" {
-			for length := len(*skippedNodes); length > 0; length-- {
-				skippedNode := (*skippedNodes)[length-1]
-				*skippedNodes = (*skippedNodes)[:length-1]
-				if strings.HasSuffix(skippedNode.path, path) {
-					path = skippedNode.path
-					n = skippedNode.node
-					if value.params != nil {
-						*value.params = (*value.params)[:skippedNode.paramsCount]
-					}
-					globalParamsCount = skippedNode.paramsCount
-					continue walk
-				}
+			if skipped, ok := rollback(skippedNodes, path, value.params, false); ok {
+				path, n, globalParamsCount = skipped.path, skipped.node, skipped.paramsCount
+				resumed, resumeAlt = !skipped.static, skipped.alt
+				continue walk
 			}
 		}
 
@@ -733,7 +1114,7 @@ walk: // Outer loop for walking the tree
 					if c == '/' {
 						n = n.children[i]
 						if (len(n.path) == 1 && n.handlers != nil) ||
-							(n.nType == catchAll && n.children[0].handlers != nil) {
+							(n.nType == catchAll && n.children[0].handlers != nil && n.children[0].accepts("/")) {
 							return append(ciPath, '/')
 						}
 						return nil
@@ -827,7 +1208,8 @@ walk: // Outer loop for walking the tree
 			return nil
 		}
 
-		n = n.children[0]
+		// the wildcard child is always the last one
+		n = n.children[len(n.children)-1]
 		switch n.nType {
 		case param:
 			// Find param end (either '/' or path end)
@@ -836,6 +1218,14 @@ walk: // Outer loop for walking the tree
 				end++
 			}
 
+			// Find the param whose constraint accepts the value
+			for n.constraint != nil && !n.constraint(path[:end]) {
+				if n.alt == nil {
+					return nil
+				}
+				n = n.alt
+			}
+
 			// Add param value to case insensitive path
 			ciPath = append(ciPath, path[:end]...)
 
@@ -872,6 +1262,9 @@ walk: // Outer loop for walking the tree
 			return nil
 
 		case catchAll:
+			if !n.accepts(path) {
+				return nil
+			}
 			return append(ciPath, path...)
 
 		default:
@@ -511,6 +511,215 @@ func TestEmptyWildcardName(t *testing.T) {
 	}
 }
 
+func TestTreeSegmentWildcard(t *testing.T) {
+	tree := &node{}
+	routes := [...]string{
+		"/files/*/meta",
+		"/files/*/data/*/raw",
+		"/files/static/meta",
+		"/api/:version/*/status",
+		"/api/:version/users",
+	}
+	for _, route := range routes {
+		tree.addRoute(route, fakeHandler(route))
+	}
+
+	checkRequests(t, tree, testRequests{
+		{"/files/a/meta", false, "/files/*/meta", Params{Param{"*", "a"}}},
+		{"/files/static/meta", false, "/files/static/meta", nil},
+		{"/files/static/data/x/raw", false, "/files/*/data/*/raw", Params{Param{"*", "static"}, Param{"*", "x"}}},
+		{"/files/a/b/meta", true, "", Params{Param{"*", "a"}}},
+		{"/files/a", true, "", Params{Param{"*", "a"}}},
+		{"/api/v1/health/status", false, "/api/:version/*/status", Params{Param{"version", "v1"}, Param{"*", "health"}}},
+		{"/api/v1/users", false, "/api/:version/users", Params{Param{"version", "v1"}}},
+	})
+	checkPriorities(t, tree)
+}
+
+func TestTreeSegmentWildcardConflict(t *testing.T) {
+	routes := []testRoute{
+		{"/files/*/meta", false},
+		{"/files/*/data", false},
+		{"/files/:id/meta", true},
+		{"/files/*path", true},
+		{"/files/*x/meta", true},
+		{"/files/x*/meta", true},
+		{"/src/*path", false},
+		{"/src/*/meta", true},
+		{"/end/*", true},
+	}
+	testRoutes(t, routes)
+}
+
+func TestTreeCompoundParams(t *testing.T) {
+	tree := &node{}
+	routes := [...]string{
+		"/download/:name.:ext",
+		"/download/latest",
+		"/range/:from-:to",
+		"/v/:major.:minor.:patch/info",
+		"/export/:from-:to.json",
+	}
+	for _, route := range routes {
+		tree.addRoute(route, fakeHandler(route))
+	}
+
+	checkRequests(t, tree, testRequests{
+		{"/download/archive.tar.gz", false, "/download/:name.:ext", Params{Param{"name", "archive"}, Param{"ext", "tar.gz"}}},
+		{"/download/latest", false, "/download/latest", nil},
+		{"/download/readme", true, "", Params{}},
+		{"/download/.gz", true, "", Params{}},
+		{"/download/archive.", true, "", Params{Param{"name", "archive"}}},
+		{"/range/10-20", false, "/range/:from-:to", Params{Param{"from", "10"}, Param{"to", "20"}}},
+		{"/range/-5-10", false, "/range/:from-:to", Params{Param{"from", "-5"}, Param{"to", "10"}}},
+		{"/v/1.2.3/info", false, "/v/:major.:minor.:patch/info", Params{Param{"major", "1"}, Param{"minor", "2"}, Param{"patch", "3"}}},
+		{"/export/1-2.json", false, "/export/:from-:to.json", Params{Param{"from", "1"}, Param{"to", "2"}}},
+		{"/export/1-2.xml", true, "", Params{Param{"from", "1"}}},
+	})
+	checkPriorities(t, tree)
+}
+
+func TestTreeCompoundParamsConflict(t *testing.T) {
+	routes := []testRoute{
+		{"/download/:name.:ext", false},
+		{"/download/:name.:ext/meta", false},
+		{"/download/:name", true},
+		{"/download/:name-:ext", true},
+		{"/a/:x:y", true},
+		{"/b/:x.:", true},
+		{"/c/:x.*y", true},
+		{"/d/:.:y", true},
+		{"/e/:x\\.:y", true},
+	}
+	testRoutes(t, routes)
+}
+
+func TestTreeConstraints(t *testing.T) {
+	tree := &node{}
+	routes := [...]string{
+		"/users/:id|int",
+		"/users/:name|alpha/posts",
+		"/users/:name|alpha",
+		"/users/:any",
+		"/users/me",
+		"/items/:id|uuid",
+	}
+	for _, route := range routes {
+		tree.addRoute(route, fakeHandler(route))
+	}
+
+	checkRequests(t, tree, testRequests{
+		{"/users/42", false, "/users/:id|int", Params{Param{"id", "42"}}},
+		{"/users/bob", false, "/users/:name|alpha", Params{Param{"name", "bob"}}},
+		{"/users/bob/posts", false, "/users/:name|alpha/posts", Params{Param{"name", "bob"}}},
+		{"/users/bob42", false, "/users/:any", Params{Param{"any", "bob42"}}},
+		{"/users/me", false, "/users/me", nil},
+		{"/users/mel", false, "/users/:name|alpha", Params{Param{"name", "mel"}}},
+		{"/items/0f8fad5b-d9cb-469f-a165-70867728950e", false, "/items/:id|uuid", Params{Param{"id", "0f8fad5b-d9cb-469f-a165-70867728950e"}}},
+		{"/items/42", true, "", Params{}},
+	})
+
+	if out, found := tree.findCaseInsensitivePath("/USERS/Bob", false); !found || string(out) != "/users/Bob" {
+		t.Errorf("Wrong result for case-insensitive route '/USERS/Bob': %s", out)
+	}
+}
+
+func TestTreeConstraintsBacktracking(t *testing.T) {
+	tree := &node{}
+	routes := [...]string{
+		"/users/:id|int",
+		"/users/:any/posts",
+		"/img/:w|int/x",
+		"/img/:w|alpha/z",
+		"/img/:w/y",
+	}
+	for _, route := range routes {
+		tree.addRoute(route, fakeHandler(route))
+	}
+
+	checkRequests(t, tree, testRequests{
+		{"/users/42", false, "/users/:id|int", Params{Param{"id", "42"}}},
+		{"/users/42/posts", false, "/users/:any/posts", Params{Param{"any", "42"}}},
+		{"/users/42/other", true, "", Params{Param{"any", "42"}}},
+		{"/img/12/x", false, "/img/:w|int/x", Params{Param{"w", "12"}}},
+		{"/img/12/y", false, "/img/:w/y", Params{Param{"w", "12"}}},
+		{"/img/ab/y", false, "/img/:w/y", Params{Param{"w", "ab"}}},
+		{"/img/12/z", true, "", Params{Param{"w", "12"}}},
+	})
+}
+
+func TestTreeConstraintsConflict(t *testing.T) {
+	routes := []testRoute{
+		{"/users/:id|int", false},
+		{"/users/:id|int/posts", false},
+		{"/users/:name|alpha", false},
+		{"/users/:any", false},
+		{"/users/:other", true},
+		{"/users/*path", true},
+		{"/users/:id|unknown", true},
+		{"/files/*path", false},
+		{"/files/:id|int", true},
+	}
+	testRoutes(t, routes)
+}
+
+func TestTreeCatchAllBounds(t *testing.T) {
+	tree := &node{}
+	routes := [...]string{
+		"/files/*path{1,}",
+		"/docs/*path{1,2}",
+		"/exact/*path{2}",
+		"/assets/*path{,1}",
+	}
+	for _, route := range routes {
+		tree.addRoute(route, fakeHandler(route))
+	}
+
+	checkRequests(t, tree, testRequests{
+		{"/files/", true, "", Params{}},
+		{"/files/a", false, "/files/*path{1,}", Params{Param{"path", "/a"}}},
+		{"/files/a/b/c/d", false, "/files/*path{1,}", Params{Param{"path", "/a/b/c/d"}}},
+		{"/docs/a/", false, "/docs/*path{1,2}", Params{Param{"path", "/a/"}}},
+		{"/docs/a/b", false, "/docs/*path{1,2}", Params{Param{"path", "/a/b"}}},
+		{"/docs/a/b/c", true, "", Params{}},
+		{"/exact/a", true, "", Params{}},
+		{"/exact/a/b", false, "/exact/*path{2}", Params{Param{"path", "/a/b"}}},
+		{"/assets/", false, "/assets/*path{,1}", Params{Param{"path", "/"}}},
+		{"/assets/a/b", true, "", Params{}},
+	})
+
+	// no trailing slash redirect to a catch-all refusing it
+	if value := tree.getValue("/files", nil, getSkippedNodes(), false); value.tsr {
+		t.Error("expected no TSR recommendation for '/files'")
+	}
+	if value := tree.getValue("/assets", nil, getSkippedNodes(), false); !value.tsr {
+		t.Error("expected TSR recommendation for '/assets'")
+	}
+	if out, found := tree.findCaseInsensitivePath("/DOCS/a/b/c", false); found {
+		t.Errorf("Wrong result for case-insensitive route '/DOCS/a/b/c': %s", out)
+	}
+	if out, found := tree.findCaseInsensitivePath("/DOCS/a/b", false); !found || string(out) != "/docs/a/b" {
+		t.Errorf("Wrong result for case-insensitive route '/DOCS/a/b': %s", out)
+	}
+}
+
+func TestTreeCatchAllBoundsConflict(t *testing.T) {
+	routes := []testRoute{
+		{"/a/*path{", true},
+		{"/b/*path{x}", true},
+		{"/c/*path{3,1}", true},
+		{"/d/*path{0}", true},
+		{"/e/*{1}", true},
+		{"/f/*path{,}", true},
+		{"/g/*path{1,}", false},
+		{"/g/*path", true},
+		{"/g/*path{1,3}", true},
+		{"/h/*path", false},
+		{"/h/*path{1,}", true},
+	}
+	testRoutes(t, routes)
+}
+
 func TestTreeCatchAllConflict(t *testing.T) {
 	routes := []testRoute{
 		{"/src/*filepath/x", true},
@@ -939,6 +1148,21 @@ func TestTreeExpandParamsCapacity(t *testing.T) {
 	}
 }
 
+func TestTreeSkippedNodesCapacity(t *testing.T) {
+	tree := &node{}
+	routes := [...]string{"/:a", "/b/:c", "/b/d/:e", "/b/d/f/:g", "/b/d/f/h"}
+	for _, route := range routes {
+		tree.addRoute(route, fakeHandler(route))
+	}
+	// the contexts allocated before the routes were updated while serving
+	// may have less room for the skipped nodes than the tree needs
+	skippedNodes := make([]skippedNode, 0)
+	value := tree.getValue("/b/d/f/h", getParams(), &skippedNodes, false)
+	if value.fullPath != "/b/d/f/h" {
+		t.Errorf("wrong route %q", value.fullPath)
+	}
+}
+
 func TestTreeWildcardConflictEx(t *testing.T) {
 	conflicts := [...]struct {
 		route        string
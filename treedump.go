@@ -0,0 +1,167 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Formats of Engine.DumpTree.
+const (
+	TreeFormatJSON = "json"
+	TreeFormatDOT  = "dot"
+)
+
+// RouteTree is the route tree of a method, as exported by Engine.DumpTree.
+type RouteTree struct {
+	// Host is the host pattern of the tree, see Engine.Host, empty for the
+	// default host.
+	Host   string    `json:"host,omitempty"`
+	Method string    `json:"method"`
+	Root   *TreeNode `json:"root"`
+}
+
+// TreeNode is a node of a route tree.
+type TreeNode struct {
+	// Path is the path segment of the node, and FullPath the route it serves.
+	Path     string `json:"path"`
+	FullPath string `json:"fullPath,omitempty"`
+	// Type is "static", "root", "param" or "catchAll".
+	Type string `json:"type"`
+	// Priority is the number of routes below the node, the children being
+	// tried by decreasing priority.
+	Priority uint32 `json:"priority"`
+	// Indices are the first bytes of the static children, and WildChild
+	// reports whether the last child is a param or catch-all node.
+	Indices   string `json:"indices,omitempty"`
+	WildChild bool   `json:"wildChild,omitempty"`
+	// Handlers are the names of the handlers of the route, middlewares
+	// included, empty for the nodes without route.
+	Handlers []string    `json:"handlers,omitempty"`
+	Children []*TreeNode `json:"children,omitempty"`
+	// Alt is the next param node at the same position, tried when the
+	// constraint of the node fails.
+	Alt *TreeNode `json:"alt,omitempty"`
+}
+
+var nodeTypeNames = [...]string{static: "static", root: "root", param: "param", catchAll: "catchAll"}
+
+// RouteTrees returns the route trees of the engine, the ones of the default
+// host first, then the ones of the host patterns in lexical order.
+func (engine *Engine) RouteTrees() []RouteTree {
+	t := engine.table.Load()
+	trees := make([]RouteTree, 0, len(t.trees))
+	for _, tree := range t.trees {
+		trees = append(trees, RouteTree{Method: tree.method, Root: exportNode(tree.root)})
+	}
+	for _, pattern := range sortedKeys(t.hosts) {
+		for _, tree := range t.hosts[pattern].trees {
+			trees = append(trees, RouteTree{Host: pattern, Method: tree.method, Root: exportNode(tree.root)})
+		}
+	}
+	return trees
+}
+
+func exportNode(n *node) *TreeNode {
+	tn := &TreeNode{
+		Path:      n.path,
+		Type:      nodeTypeNames[n.nType],
+		Priority:  n.priority,
+		Indices:   n.indices,
+		WildChild: n.wildChild,
+	}
+	if len(n.handlers) > 0 {
+		tn.FullPath = n.fullPath
+		for _, h := range n.handlers {
+			tn.Handlers = append(tn.Handlers, nameOfFunction(h))
+		}
+	}
+	for _, child := range n.children {
+		tn.Children = append(tn.Children, exportNode(child))
+	}
+	if n.alt != nil {
+		tn.Alt = exportNode(n.alt)
+	}
+	return tn
+}
+
+// DumpTree writes the route trees of the engine to w, in TreeFormatJSON, or
+// in TreeFormatDOT to be rendered with Graphviz, e.g. with
+// "dot -Tsvg routes.dot > routes.svg", to debug routing conflicts and
+// priorities in large APIs. The DOT graph links the alternatives of the
+// constrained param nodes with dashed edges.
+func (engine *Engine) DumpTree(w io.Writer, format string) error {
+	trees := engine.RouteTrees()
+	switch format {
+	case TreeFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(trees)
+	case TreeFormatDOT:
+		bw := bufio.NewWriter(w)
+		d := dotWriter{w: bw}
+		d.write(trees)
+		return bw.Flush()
+	}
+	return fmt.Errorf("unsupported route tree format %q", format)
+}
+
+// dotWriter writes route trees as a Graphviz graph.
+type dotWriter struct {
+	w     *bufio.Writer
+	nodes int
+}
+
+func (d *dotWriter) write(trees []RouteTree) {
+	d.w.WriteString("digraph routes {\n")
+	d.w.WriteString("\trankdir=LR;\n")
+	d.w.WriteString("\tnode [shape=box, fontname=\"monospace\"];\n")
+	for i, tree := range trees {
+		label := tree.Method
+		if tree.Host != "" {
+			label += " " + tree.Host
+		}
+		fmt.Fprintf(d.w, "\ttree%d [label=%s, shape=plaintext];\n", i, dotQuote(label))
+		fmt.Fprintf(d.w, "\ttree%d -> n%d;\n", i, d.node(tree.Root))
+	}
+	d.w.WriteString("}\n")
+}
+
+// node writes n and its descendants, and returns its id.
+func (d *dotWriter) node(n *TreeNode) int {
+	id := d.nodes
+	d.nodes++
+	label := fmt.Sprintf("%s\n%s prio=%d", n.Path, n.Type, n.Priority)
+	if n.WildChild {
+		label += " wildChild"
+	}
+	style := ""
+	if len(n.Handlers) > 0 {
+		label += "\n" + n.Handlers[len(n.Handlers)-1]
+		style = ", style=bold"
+	}
+	if n.Type == "param" || n.Type == "catchAll" {
+		style += ", shape=ellipse"
+	}
+	fmt.Fprintf(d.w, "\tn%d [label=%s%s];\n", id, dotQuote(label), style)
+	for _, child := range n.Children {
+		fmt.Fprintf(d.w, "\tn%d -> n%d;\n", id, d.node(child))
+	}
+	if n.Alt != nil {
+		fmt.Fprintf(d.w, "\tn%d -> n%d [style=dashed, label=\"alt\"];\n", id, d.node(n.Alt))
+	}
+	return id
+}
+
+var dotReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// dotQuote returns s as a DOT string.
+func dotQuote(s string) string {
+	return `"` + dotReplacer.Replace(s) + `"`
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngineDumpTree(t *testing.T) {
+	router := New()
+	router.Use(handlerTest1)
+	router.GET("/users/:id|int", handlerTest2)
+	router.GET("/users/:name", handlerTest2)
+	router.GET("/static/*filepath", handlerTest2)
+	router.Host("api.example.com").POST("/", handlerTest2)
+
+	var buf bytes.Buffer
+	require.NoError(t, router.DumpTree(&buf, TreeFormatJSON))
+	var trees []RouteTree
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &trees))
+	assert.Equal(t, trees, router.RouteTrees())
+	require.Len(t, trees, 2)
+
+	get := trees[0]
+	assert.Equal(t, "GET", get.Method)
+	assert.Empty(t, get.Host)
+	assert.Equal(t, "/", get.Root.Path)
+	assert.Equal(t, "root", get.Root.Type)
+	assert.Equal(t, uint32(3), get.Root.Priority)
+	users := get.Root.Children[0]
+	assert.Equal(t, "users/", users.Path)
+	assert.True(t, users.WildChild)
+	id := users.Children[0]
+	assert.Equal(t, ":id|int", id.Path)
+	assert.Equal(t, "param", id.Type)
+	assert.Equal(t, "/users/:id|int", id.FullPath)
+	assert.Equal(t, []string{
+		"github.com/jialequ/mpgw.handlerTest1",
+		"github.com/jialequ/mpgw.handlerTest2",
+	}, id.Handlers)
+	require.NotNil(t, id.Alt)
+	assert.Equal(t, ":name", id.Alt.Path)
+	assert.Nil(t, id.Alt.Alt)
+	assert.Equal(t, "catchAll", get.Root.Children[1].Children[0].Type)
+	assert.Empty(t, get.Root.Children[1].Handlers)
+
+	assert.Equal(t, RouteTree{Host: "api.example.com", Method: "POST", Root: &TreeNode{
+		Path:     "/",
+		FullPath: "/",
+		Type:     "root",
+		Priority: 1,
+		Handlers: []string{"github.com/jialequ/mpgw.handlerTest1", "github.com/jialequ/mpgw.handlerTest2"},
+	}}, trees[1])
+
+	buf.Reset()
+	require.NoError(t, router.DumpTree(&buf, TreeFormatDOT))
+	dot := buf.String()
+	assert.Contains(t, dot, "digraph routes {\n")
+	assert.Contains(t, dot, `tree1 [label="POST api.example.com", shape=plaintext];`)
+	assert.Contains(t, dot, `[label=":id|int\nparam prio=2\ngithub.com/jialequ/mpgw.handlerTest2", style=bold, shape=ellipse];`)
+	assert.Contains(t, dot, `[style=dashed, label="alt"];`)
+
+	assert.Error(t, router.DumpTree(&buf, "svg"))
+}
@@ -0,0 +1,199 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"maps"
+	"slices"
+	"sync"
+)
+
+// routeTable is the routing state of an engine. Once the engine serves
+// requests, the table they read is never modified: the registrations change a
+// copy of it, published atomically (copy-on-write).
+type routeTable struct {
+	trees         methodTrees
+	hosts         map[string]*hostTrees
+	wildcardHosts []*hostTrees
+	catchAllHost  *hostTrees
+	maxParams     uint16
+	maxSections   uint16
+
+	routes      []*Route
+	routeIndex  map[string]*Route
+	namedRoutes map[string]*Route
+	deprecated  map[string]*Route
+	// timedRoutes is the number of routes with a timeout.
+	timedRoutes int
+	// prioritized is the number of routes with a priority.
+	prioritized int
+	// mockedRoutes is the number of routes with a mock response.
+	mockedRoutes int
+	// fallbacks are the fallbacks of the groups, see RouterGroup.Fallback.
+	fallbacks []groupFallback
+}
+
+// updates serializes the changes of the route table of an engine.
+type updates struct {
+	mu sync.Mutex
+	// batches is the number of running Update calls, and copied reports
+	// whether the table was copied since the last publication.
+	batches int
+	copied  bool
+}
+
+// Update registers and annotates routes while the engine serves requests,
+// such as the routes of a plugin or the ones reloaded by an admin endpoint:
+// the routes registered by fn, through the engine or its groups, are served
+// all at once when fn returns, with their annotations. The requests in flight
+// keep using the previous routes. If fn panics, e.g. on a route conflict, none
+// of its routes are served:
+//
+//	router.Update(func() {
+//	    plugins := router.Group("/plugins/" + name)
+//	    plugins.GET("/status", status)
+//	    plugins.Route("/status").Meta("auth", "admin")
+//	})
+//
+// Routes can also be registered while serving without Update, each
+// registration and annotation being then served on its own: the annotations
+// of a route may be missed by its first requests. The routes registered by
+// the other goroutines while fn runs are served along with its own.
+func (engine *Engine) Update(fn func()) {
+	u := &engine.updates
+	u.mu.Lock()
+	u.batches++
+	u.mu.Unlock()
+
+	completed := false
+	defer func() {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		u.batches--
+		if !completed && u.copied {
+			engine.routeTable = engine.table.Load()
+			u.copied = false
+		}
+		if u.batches == 0 && u.copied {
+			engine.table.Store(engine.routeTable)
+			u.copied = false
+		}
+	}()
+	fn()
+	completed = true
+}
+
+// update runs fn, which changes the route table. Once the engine serves
+// requests, fn changes a copy of the table, published when fn returns or, in
+// Update, when the batch completes, and discarded if fn panics.
+func (engine *Engine) update(fn func()) {
+	u := &engine.updates
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if !engine.serving.Load() {
+		fn()
+		return
+	}
+	if !u.copied {
+		engine.routeTable = engine.routeTable.copy()
+		u.copied = true
+	}
+	completed := false
+	defer func() {
+		if u.batches > 0 {
+			return
+		}
+		if completed {
+			engine.table.Store(engine.routeTable)
+		} else {
+			engine.routeTable = engine.table.Load()
+		}
+		u.copied = false
+	}()
+	fn()
+	completed = true
+}
+
+// editRoute returns the route to annotate in an update: a copy replacing it
+// in the table if requests may read it.
+func (engine *Engine) editRoute(route *Route) *Route {
+	key := routeKey(route.Host, route.Method, route.Path)
+	if !engine.serving.Load() || engine.table.Load().routeIndex[key] != route {
+		return route
+	}
+	cp := route.copy()
+	t := engine.routeTable
+	t.routes[slices.Index(t.routes, route)] = cp
+	t.routeIndex[key] = cp
+	for name, r := range t.namedRoutes {
+		if r == route {
+			t.namedRoutes[name] = cp
+		}
+	}
+	if t.deprecated[key] == route {
+		t.deprecated[key] = cp
+	}
+	return cp
+}
+
+// copy returns a copy of the table whose trees and indexes can be changed.
+// The routes are shared.
+func (t *routeTable) copy() *routeTable {
+	cp := &routeTable{
+		trees:        t.trees.clone(),
+		maxParams:    t.maxParams,
+		maxSections:  t.maxSections,
+		routes:       slices.Clone(t.routes),
+		routeIndex:   maps.Clone(t.routeIndex),
+		namedRoutes:  maps.Clone(t.namedRoutes),
+		deprecated:   maps.Clone(t.deprecated),
+		timedRoutes:  t.timedRoutes,
+		mockedRoutes: t.mockedRoutes,
+		prioritized:  t.prioritized,
+		fallbacks:    t.fallbacks,
+	}
+	for pattern, ht := range t.hosts {
+		if cp.hosts == nil {
+			cp.hosts = make(map[string]*hostTrees, len(t.hosts))
+		}
+		cp.hosts[pattern] = &hostTrees{pattern: ht.pattern, suffix: ht.suffix, param: ht.param, trees: ht.trees.clone()}
+	}
+	for _, ht := range t.wildcardHosts {
+		cp.wildcardHosts = append(cp.wildcardHosts, cp.hosts[ht.pattern])
+	}
+	if t.catchAllHost != nil {
+		cp.catchAllHost = cp.hosts[t.catchAllHost.pattern]
+	}
+	return cp
+}
+
+// copy returns a copy of the route whose annotations can be changed, with its
+// counters and state.
+func (route *Route) copy() *Route {
+	cp := &Route{
+		Method:      route.Method,
+		Path:        route.Path,
+		Host:        route.Host,
+		Name:        route.Name,
+		Description: route.Description,
+		Tags:        slices.Clone(route.Tags),
+		Deprecation: route.Deprecation,
+		bodyType:    route.bodyType,
+		bodyBinding: route.bodyBinding,
+		responses:   slices.Clone(route.responses),
+		meta:        maps.Clone(route.meta),
+		group:       route.group,
+		override:    route.override,
+		timeout:     route.timeout,
+		priority:    route.priority,
+		mock:        route.mock,
+	}
+	cp.hits.Store(route.hits.Load())
+	cp.slashFixes.Store(route.slashFixes.Load())
+	cp.caseFixes.Store(route.caseFixes.Load())
+	cp.disabled.Store(route.disabled.Load())
+	cp.disabledUntil.Store(route.disabledUntil.Load())
+	return cp
+}
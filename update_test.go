@@ -0,0 +1,87 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngineRegisterWhileServing(t *testing.T) {
+	router := New()
+	router.GET("/ping", handlerTest1)
+	api := router.Group("/api")
+
+	var wg, started sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		started.Add(1)
+		go func() {
+			defer wg.Done()
+			PerformRequest(router, http.MethodGet, "/ping")
+			started.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				w := PerformRequest(router, http.MethodGet, "/ping")
+				assert.Equal(t, http.StatusOK, w.Code)
+				PerformRequest(router, http.MethodGet, "/api/items/1")
+			}
+		}()
+	}
+	started.Wait()
+	for i := 0; i < 50; i++ {
+		api.GET(fmt.Sprintf("/items%d/:id", i), handlerTest1)
+		api.Route(fmt.Sprintf("/items%d/:id", i), http.MethodGet).Name(fmt.Sprintf("item%d", i))
+	}
+	router.Update(func() {
+		api.GET("/items/:id", func(c *Context) {
+			c.String(http.StatusOK, "%v", c.RouteMeta()["auth"])
+		})
+		api.Route("/items/:id", http.MethodGet).Meta("auth", "admin")
+	})
+	close(done)
+	wg.Wait()
+
+	w := PerformRequest(router, http.MethodGet, "/api/items49/1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = PerformRequest(router, http.MethodGet, "/api/items/1")
+	assert.Equal(t, "admin", w.Body.String())
+	assert.Len(t, router.Routes(), 52)
+	path, err := router.PathBuilder("item7").Param("id", "3").Build()
+	assert.NoError(t, err)
+	assert.Equal(t, "/api/items7/3", path)
+}
+
+func TestEngineUpdatePanic(t *testing.T) {
+	router := New()
+	router.GET("/users/:id", handlerTest1)
+	PerformRequest(router, http.MethodGet, "/users/1")
+
+	assert.Panics(t, func() {
+		router.Update(func() {
+			router.GET("/orders", handlerTest1)
+			router.GET("/users/:name", handlerTest1)
+		})
+	})
+	w := PerformRequest(router, http.MethodGet, "/orders")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Len(t, router.Routes(), 1)
+
+	assert.Panics(t, func() { router.GET("/users/:name", handlerTest1) })
+	router.GET("/orders", handlerTest1)
+	router.Route("/orders", http.MethodGet).Describe("Lists the orders")
+	w = PerformRequest(router, http.MethodGet, "/orders")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Lists the orders", router.RouteDocs()[1].Description)
+}
@@ -0,0 +1,383 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"crypto/md5"  //nolint: gosec
+	"crypto/sha1" //nolint: gosec
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrChecksumMismatch is returned by Context.Upload when the body does not
+	// match the checksum given by the client.
+	ErrChecksumMismatch = errors.New("upload: checksum mismatch")
+	// ErrChecksumUnsupported is returned by Context.Upload when the client gives
+	// a checksum with none of the configured algorithms.
+	ErrChecksumUnsupported = errors.New("upload: unsupported checksum algorithm")
+	// ErrChecksumRequired is returned by Context.Upload when a checksum is required
+	// and the client gives none.
+	ErrChecksumRequired = errors.New("upload: checksum required")
+)
+
+var uploadHashes = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// UploadConfig defines the config of Context.Upload.
+type UploadConfig struct {
+	// Algorithms lists the digests computed on the fly, among "md5", "sha1",
+	// "sha256" and "sha512". Optional. Default value is []string{"sha256"}.
+	Algorithms []string
+
+	// ChecksumHeader is the request header holding the client checksums, as a
+	// list of algorithm=value, e.g. "sha-256=:<base64>:" as in the Content-Digest
+	// header of RFC 9530. Values may also be plain base64 or hex.
+	// Optional. Default value is "Content-Digest".
+	ChecksumHeader string
+
+	// RequireChecksum rejects uploads without a checksum. Optional. Default value is false.
+	RequireChecksum bool
+
+	// MaxSize caps the size of the body, 0 means no limit. Optional. Default value is 0.
+	MaxSize int64
+}
+
+// UploadDigest is the digest of an uploaded body.
+type UploadDigest struct {
+	Size int64
+	// Sums holds the digests keyed by algorithm.
+	Sums map[string][]byte
+	// Verified lists the algorithms verified against the client checksums.
+	Verified []string
+}
+
+// Hex returns the digest of algorithm alg, hex encoded.
+func (d *UploadDigest) Hex(alg string) string {
+	return hex.EncodeToString(d.Sums[alg])
+}
+
+// Upload streams the request body to dst, computing the configured digests on
+// the fly, and verifies them against the checksums given by the client. On
+// ErrChecksumMismatch, dst has received the whole body and the caller should
+// discard it. The digest, useful as a content address, is returned even then.
+//
+//	f, _ := os.CreateTemp(dir, "upload")
+//	digest, err := c.Upload(gin.UploadConfig{}, f)
+//	if err != nil {
+//	    os.Remove(f.Name())
+//	    ...
+//	}
+//	os.Rename(f.Name(), filepath.Join(dir, digest.Hex("sha256")))
+func (c *Context) Upload(conf UploadConfig, dst io.Writer) (*UploadDigest, error) {
+	if len(conf.Algorithms) == 0 {
+		conf.Algorithms = []string{"sha256"}
+	}
+	if conf.ChecksumHeader == "" {
+		conf.ChecksumHeader = "Content-Digest"
+	}
+
+	hashes := make(map[string]hash.Hash, len(conf.Algorithms))
+	writers := make([]io.Writer, 0, len(conf.Algorithms)+1)
+	writers = append(writers, dst)
+	for _, alg := range conf.Algorithms {
+		newHash, ok := uploadHashes[alg]
+		if !ok {
+			panic("gin: unknown upload digest algorithm " + alg)
+		}
+		hashes[alg] = newHash()
+		writers = append(writers, hashes[alg])
+	}
+
+	checksums, err := parseChecksums(c.requestHeader(conf.ChecksumHeader))
+	if err != nil {
+		return nil, err
+	}
+	if checksums == nil && conf.RequireChecksum {
+		return nil, ErrChecksumRequired
+	}
+
+	body := c.Request.Body
+	if conf.MaxSize > 0 {
+		body = http.MaxBytesReader(c.Writer, body, conf.MaxSize)
+	}
+	digest := &UploadDigest{Sums: make(map[string][]byte, len(hashes))}
+	digest.Size, err = io.Copy(io.MultiWriter(writers...), body)
+	if err != nil {
+		return nil, err
+	}
+	for alg, h := range hashes {
+		digest.Sums[alg] = h.Sum(nil)
+	}
+
+	if checksums == nil {
+		return digest, nil
+	}
+	for _, alg := range conf.Algorithms {
+		want, ok := checksums[alg]
+		if !ok {
+			continue
+		}
+		if subtle.ConstantTimeCompare(want, digest.Sums[alg]) != 1 {
+			return digest, fmt.Errorf("%w: %s", ErrChecksumMismatch, alg)
+		}
+		digest.Verified = append(digest.Verified, alg)
+	}
+	if len(digest.Verified) == 0 {
+		return digest, ErrChecksumUnsupported
+	}
+	return digest, nil
+}
+
+// parseChecksums parses a list of algorithm=value checksums, keyed by the
+// algorithm names of uploadHashes. Unknown algorithms are skipped; it returns
+// nil only if header is empty.
+func parseChecksums(header string) (map[string][]byte, error) {
+	if header == "" {
+		return nil, nil
+	}
+	checksums := make(map[string][]byte)
+	for _, item := range strings.Split(header, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return nil, fmt.Errorf("upload: malformed checksum %q", item)
+		}
+		alg := strings.ReplaceAll(strings.ToLower(name), "-", "")
+		newHash, known := uploadHashes[alg]
+		if !known {
+			continue
+		}
+		sum, err := decodeChecksum(value, newHash().Size())
+		if err != nil {
+			return nil, fmt.Errorf("upload: malformed %s checksum: %w", name, err)
+		}
+		checksums[alg] = sum
+	}
+	return checksums, nil
+}
+
+// decodeChecksum decodes a checksum of size bytes, encoded as a structured
+// field byte sequence (:base64:), hex or base64.
+func decodeChecksum(value string, size int) ([]byte, error) {
+	if len(value) >= 2 && value[0] == ':' && value[len(value)-1] == ':' {
+		return base64.StdEncoding.DecodeString(value[1 : len(value)-1])
+	}
+	if len(value) == hex.EncodedLen(size) {
+		if sum, err := hex.DecodeString(value); err == nil {
+			return sum, nil
+		}
+	}
+	return base64.StdEncoding.DecodeString(value)
+}
+
+var (
+	// ErrUploadTooLarge is the cause of the UploadValidationError of a file
+	// larger than SaveOptions.MaxSize.
+	ErrUploadTooLarge = errors.New("upload: file too large")
+	// ErrUploadType is the cause of the UploadValidationError of a file whose
+	// content is not of SaveOptions.AllowedTypes.
+	ErrUploadType = errors.New("upload: file type not allowed")
+	// ErrUploadFilename is the cause of the UploadValidationError of a file
+	// whose name has nothing left once sanitized.
+	ErrUploadFilename = errors.New("upload: invalid filename")
+)
+
+// SaveOptions defines the validation of the files saved by
+// Context.SaveUploadedFile.
+type SaveOptions struct {
+	// MaxSize caps the size of the file, 0 means no limit. The size given by
+	// the client is not trusted: the file is written to a temporary file of
+	// its directory, created with mode 0600, renamed once its size is checked.
+	// Optional. Default value is 0.
+	MaxSize int64
+
+	// AllowedTypes lists the accepted MIME types, such as "image/png" or
+	// "image/*", matched against the type sniffed from the content with
+	// http.DetectContentType, neither the extension nor the Content-Type
+	// given by the client. Optional. Default value accepts any type.
+	AllowedTypes []string
+
+	// SanitizeFilename saves the file in the directory dst, under its client
+	// filename sanitized with SanitizeFilename, instead of at dst.
+	// Optional. Default value is false.
+	SanitizeFilename bool
+
+	// Atomic writes the file to a temporary file of its directory, created
+	// with mode 0600, renamed once complete, so that it is never seen partly
+	// written. Optional. Default value is false.
+	Atomic bool
+}
+
+// UploadValidationError is the error of Context.SaveUploadedFile for a file
+// failing the validation of its SaveOptions. Nothing is saved then.
+type UploadValidationError struct {
+	// Filename is the client filename of the file.
+	Filename string
+	// Size is the size of the file, or the size read past MaxSize.
+	Size int64
+	// Type is the sniffed MIME type of the file, empty if not sniffed.
+	Type string
+	// Err is ErrUploadTooLarge, ErrUploadType or ErrUploadFilename.
+	Err error
+}
+
+func (e *UploadValidationError) Error() string {
+	if e.Type != "" {
+		return fmt.Sprintf("%v: %q is %s", e.Err, e.Filename, e.Type)
+	}
+	return fmt.Sprintf("%v: %q", e.Err, e.Filename)
+}
+
+func (e *UploadValidationError) Unwrap() error {
+	return e.Err
+}
+
+// windowsReservedNames are the file names reserved by Windows, whatever
+// their extension.
+var windowsReservedNames = []string{
+	"CON", "PRN", "AUX", "NUL",
+	"COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9",
+	"LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9",
+}
+
+// SanitizeFilename returns a client filename safe to save a file under: its
+// last path element, with the control and reserved characters replaced by
+// '_', the leading and trailing dots and spaces trimmed, the names reserved
+// by Windows prefixed with '_', and cut to 255 bytes, keeping its extension.
+// It returns "" if nothing is left.
+func SanitizeFilename(name string) string {
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(`<>:"|?*`, r) || r == utf8.RuneError {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(name, ". ")
+	if name == "" {
+		return ""
+	}
+	base, _, _ := strings.Cut(name, ".")
+	for _, reserved := range windowsReservedNames {
+		if strings.EqualFold(strings.TrimSpace(base), reserved) {
+			name = "_" + name
+			break
+		}
+	}
+	if len(name) > 255 {
+		ext := filepath.Ext(name)
+		if len(ext) > 32 {
+			ext = ""
+		}
+		stem := name[:255-len(ext)]
+		for !utf8.ValidString(stem) {
+			stem = stem[:len(stem)-1]
+		}
+		name = stem + ext
+	}
+	return name
+}
+
+// saveUploadedFile saves the form file at dst, validated by opt.
+func saveUploadedFile(file *multipart.FileHeader, dst string, opt SaveOptions) error {
+	if opt.SanitizeFilename {
+		name := SanitizeFilename(file.Filename)
+		if name == "" {
+			return &UploadValidationError{Filename: file.Filename, Size: file.Size, Err: ErrUploadFilename}
+		}
+		dst = filepath.Join(dst, name)
+	}
+	if opt.MaxSize > 0 && file.Size > opt.MaxSize {
+		return &UploadValidationError{Filename: file.Filename, Size: file.Size, Err: ErrUploadTooLarge}
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	var body io.Reader = src
+	if len(opt.AllowedTypes) > 0 {
+		head := make([]byte, 512)
+		n, err := io.ReadFull(src, head)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF { //nolint: errorlint
+			return err
+		}
+		mimeType, _, _ := strings.Cut(http.DetectContentType(head[:n]), ";")
+		if !allowedType(opt.AllowedTypes, mimeType) {
+			return &UploadValidationError{Filename: file.Filename, Size: file.Size, Type: mimeType, Err: ErrUploadType}
+		}
+		body = io.MultiReader(bytes.NewReader(head[:n]), src)
+	}
+	if opt.MaxSize > 0 {
+		// the size given by the client may lie
+		body = io.LimitReader(body, opt.MaxSize+1)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return err
+	}
+	// the file is checked in a temporary file, not to replace dst with an
+	// invalid one
+	temp := opt.Atomic || opt.MaxSize > 0
+	var out *os.File
+	if temp {
+		out, err = os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*.tmp")
+	} else {
+		out, err = os.Create(dst)
+	}
+	if err != nil {
+		return err
+	}
+
+	n, err := io.Copy(out, body)
+	if err == nil && opt.MaxSize > 0 && n > opt.MaxSize {
+		err = &UploadValidationError{Filename: file.Filename, Size: n, Err: ErrUploadTooLarge}
+	}
+	if err == nil && opt.Atomic {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && temp {
+		err = os.Rename(out.Name(), dst)
+	}
+	if err != nil && temp {
+		os.Remove(out.Name())
+	}
+	return err
+}
+
+// allowedType reports whether mimeType matches one of the allowed types.
+func allowedType(allowed []string, mimeType string) bool {
+	for _, t := range allowed {
+		if t == mimeType || strings.HasSuffix(t, "/*") && strings.HasPrefix(mimeType, t[:len(t)-1]) {
+			return true
+		}
+	}
+	return false
+}
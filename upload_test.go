@@ -0,0 +1,192 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"crypto/md5" //nolint: gosec
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const uploadBody = "artifact content"
+
+func uploadContext(checksum string) *Context {
+	c, _ := CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest(http.MethodPut, "/artifacts", strings.NewReader(uploadBody))
+	if checksum != "" {
+		c.Request.Header.Set("Content-Digest", checksum)
+	}
+	return c
+}
+
+func TestUpload(t *testing.T) {
+	sha := sha256.Sum256([]byte(uploadBody))
+	md := md5.Sum([]byte(uploadBody)) //nolint: gosec
+
+	var dst bytes.Buffer
+	digest, err := uploadContext("").Upload(UploadConfig{}, &dst)
+	require.NoError(t, err)
+	assert.Equal(t, uploadBody, dst.String())
+	assert.Equal(t, int64(len(uploadBody)), digest.Size)
+	assert.Equal(t, hex.EncodeToString(sha[:]), digest.Hex("sha256"))
+	assert.Empty(t, digest.Verified)
+
+	checksums := []string{
+		"sha-256=:" + base64.StdEncoding.EncodeToString(sha[:]) + ":",
+		"sha256=" + hex.EncodeToString(sha[:]),
+		"SHA-256=" + base64.StdEncoding.EncodeToString(sha[:]) + ", crc32c=:AAAAAA==:",
+	}
+	for _, checksum := range checksums {
+		digest, err = uploadContext(checksum).Upload(UploadConfig{}, &bytes.Buffer{})
+		require.NoError(t, err, checksum)
+		assert.Equal(t, []string{"sha256"}, digest.Verified)
+	}
+
+	conf := UploadConfig{Algorithms: []string{"sha256", "md5"}}
+	digest, err = uploadContext("md5="+hex.EncodeToString(md[:])).Upload(conf, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"md5"}, digest.Verified)
+	assert.Equal(t, hex.EncodeToString(md[:]), digest.Hex("md5"))
+}
+
+func TestUploadErrors(t *testing.T) {
+	wrong := sha256.Sum256([]byte("other"))
+
+	digest, err := uploadContext("sha-256=:"+base64.StdEncoding.EncodeToString(wrong[:])+":").Upload(UploadConfig{}, &bytes.Buffer{})
+	require.ErrorIs(t, err, ErrChecksumMismatch)
+	assert.NotNil(t, digest)
+
+	_, err = uploadContext("crc32c=:AAAAAA==:").Upload(UploadConfig{}, &bytes.Buffer{})
+	require.ErrorIs(t, err, ErrChecksumUnsupported)
+
+	_, err = uploadContext("").Upload(UploadConfig{RequireChecksum: true}, &bytes.Buffer{})
+	require.ErrorIs(t, err, ErrChecksumRequired)
+
+	_, err = uploadContext("sha-256").Upload(UploadConfig{}, &bytes.Buffer{})
+	require.EqualError(t, err, `upload: malformed checksum "sha-256"`)
+
+	_, err = uploadContext("sha-256=:!!:").Upload(UploadConfig{}, &bytes.Buffer{})
+	require.Error(t, err)
+
+	_, err = uploadContext("").Upload(UploadConfig{MaxSize: 4}, &bytes.Buffer{})
+	var maxErr *http.MaxBytesError
+	assert.True(t, errors.As(err, &maxErr))
+
+	assert.Panics(t, func() {
+		uploadContext("").Upload(UploadConfig{Algorithms: []string{"crc32"}}, &bytes.Buffer{}) //nolint: errcheck
+	})
+}
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// formFile returns the header of a form file uploaded with content.
+func formFile(t *testing.T, filename string, content []byte) (*Context, *multipart.FileHeader) {
+	buf := new(bytes.Buffer)
+	mw := multipart.NewWriter(buf)
+	w, err := mw.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	_, err = w.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+	c, _ := CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest(http.MethodPost, "/", buf)
+	c.Request.Header.Set("Content-Type", mw.FormDataContentType())
+	f, err := c.FormFile("file")
+	require.NoError(t, err)
+	return c, f
+}
+
+func TestSaveUploadedFileOptions(t *testing.T) {
+	dir := t.TempDir()
+	png := append(append([]byte(nil), pngHeader...), bytes.Repeat([]byte{0}, 100)...)
+	opts := SaveOptions{MaxSize: 1000, AllowedTypes: []string{"image/*"}, SanitizeFilename: true, Atomic: true}
+
+	c, f := formFile(t, "../../photo.png", png)
+	require.NoError(t, c.SaveUploadedFile(f, dir, opts))
+	saved, err := os.ReadFile(filepath.Join(dir, "photo.png"))
+	require.NoError(t, err)
+	assert.Equal(t, png, saved)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// the type is sniffed, not taken from the extension
+	c, f = formFile(t, "script.png", []byte("#!/bin/sh\nrm -rf /"))
+	err = c.SaveUploadedFile(f, dir, opts)
+	var verr *UploadValidationError
+	require.ErrorAs(t, err, &verr)
+	require.ErrorIs(t, err, ErrUploadType)
+	assert.Equal(t, "text/plain", verr.Type)
+	assert.Equal(t, `upload: file type not allowed: "script.png" is text/plain`, err.Error())
+
+	c, f = formFile(t, "big.png", append(png, make([]byte, 1000)...))
+	err = c.SaveUploadedFile(f, dir, opts)
+	require.ErrorIs(t, err, ErrUploadTooLarge)
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, int64(1116), verr.Size)
+
+	// the size given by the client may lie, and nothing is saved
+	f.Size = 10
+	err = c.SaveUploadedFile(f, dir, opts)
+	require.ErrorIs(t, err, ErrUploadTooLarge)
+	// nor replaced
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "big.png"), []byte("original"), 0o600))
+	opts.Atomic = false
+	err = c.SaveUploadedFile(f, dir, opts)
+	require.ErrorIs(t, err, ErrUploadTooLarge)
+	saved, err = os.ReadFile(filepath.Join(dir, "big.png"))
+	require.NoError(t, err)
+	assert.Equal(t, "original", string(saved))
+
+	c, f = formFile(t, "..", png)
+	require.ErrorIs(t, c.SaveUploadedFile(f, dir, opts), ErrUploadFilename)
+
+	// nothing is left behind
+	entries, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	// without options, as before
+	c, f = formFile(t, "any.bin", []byte("data"))
+	require.NoError(t, c.SaveUploadedFile(f, filepath.Join(dir, "sub", "any.bin")))
+	saved, err = os.ReadFile(filepath.Join(dir, "sub", "any.bin"))
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(saved))
+
+	assert.PanicsWithValue(t, "SaveUploadedFile takes at most one SaveOptions", func() {
+		_ = c.SaveUploadedFile(f, dir, opts, SaveOptions{})
+	})
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	for name, want := range map[string]string{
+		"report.pdf":                       "report.pdf",
+		"../../etc/passwd":                 "passwd",
+		`C:\Users\ada\cv.docx`:             "cv.docx",
+		"..":                               "",
+		" . ":                              "",
+		".htaccess":                        "htaccess",
+		"a<b>c:d\"e|f?g*h\x00.txt":         "a_b_c_d_e_f_g_h_.txt",
+		"CON.txt":                          "_CON.txt",
+		"console.txt":                      "console.txt",
+		"résumé.pdf":                       "résumé.pdf",
+		strings.Repeat("é", 200) + ".jpeg": strings.Repeat("é", 125) + ".jpeg",
+	} {
+		assert.Equal(t, want, SanitizeFilename(name), name)
+	}
+}
@@ -0,0 +1,376 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jialequ/mpgw/internal/json"
+)
+
+const defaultTokenEarlyExpiry = 30 * time.Second
+
+// UpstreamTLS defines the TLS settings used to reach an upstream.
+type UpstreamTLS struct {
+	// CAFile is a PEM bundle of the certificate authorities trusted for the
+	// upstream, instead of the system ones. Optional.
+	CAFile string
+
+	// CertFile and KeyFile are the PEM client certificate and key presented to
+	// the upstream. They are reloaded when the files change. Optional.
+	CertFile string
+	KeyFile  string
+
+	// ServerName is sent as SNI and used to verify the upstream certificate.
+	// Optional. Default value is the host of the upstream URL.
+	ServerName string
+
+	// InsecureSkipVerify disables the verification of the upstream certificate.
+	// Optional. Default value is false.
+	InsecureSkipVerify bool
+}
+
+// ClientCredentials defines an OAuth2 client credentials grant, see RFC 6749 section 4.4.
+type ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret Secret
+	Scopes       []string
+
+	// EarlyExpiry renews the tokens this long before they expire.
+	// Optional. Default value is 30s.
+	EarlyExpiry time.Duration
+
+	// HTTPClient requests the tokens. Optional. Default value is http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// UpstreamAuth defines the credentials injected into the requests sent to an
+// upstream, replacing any Authorization header. The secrets and the tokens
+// obtained are registered in Redactions.
+type UpstreamAuth struct {
+	// BearerToken is a static bearer token. Optional.
+	BearerToken Secret
+
+	// ClientCredentials obtains bearer tokens with an OAuth2 client credentials
+	// grant, cached until they expire. Optional.
+	ClientCredentials *ClientCredentials
+}
+
+// UpstreamConfig defines an upstream the engine sends requests to.
+type UpstreamConfig struct {
+	// URL is the base URL of the upstream. Required.
+	URL string
+
+	// TLS defines the TLS settings of the upstream. Optional.
+	TLS *UpstreamTLS
+
+	// Auth defines the credentials injected into the requests. Optional.
+	Auth UpstreamAuth
+
+	// Timeout bounds the requests, see http.Client. Optional. Default value is 0, no timeout.
+	Timeout time.Duration
+
+	// Retry defines how failed requests are retried or hedged. Optional.
+	// Default value is nil, requests are sent once.
+	Retry *RetryPolicy
+}
+
+// Upstream is an upstream registered on the engine, with its own TLS settings
+// and credentials. Requests sent with its client also carry the baggage of the
+// request context, see BaggageTransport.
+type Upstream struct {
+	Name string
+	URL  *url.URL
+
+	client *http.Client
+	retry  *retryTransport
+	tls    *UpstreamTLS
+}
+
+// Client returns the client of the upstream.
+func (u *Upstream) Client() *http.Client {
+	return u.client
+}
+
+// RetryStats returns a snapshot of the counters of the retry policy, zero if
+// the upstream has none.
+func (u *Upstream) RetryStats() RetryStats {
+	if u.retry == nil {
+		return RetryStats{}
+	}
+	return u.retry.Stats()
+}
+
+// NewRequest returns a request to path, relative to the URL of the upstream,
+// carrying the request context and the baggage of c. The body of the request
+// of c, forwarded, is buffered according to RouteConfig.RequestBuffering.
+func (u *Upstream) NewRequest(c *Context, method, path string, body io.Reader) (*http.Request, error) {
+	target := *u.URL
+	path, query, _ := strings.Cut(path, "?")
+	target.Path, target.RawPath, target.RawQuery = joinPaths(u.URL.Path, path), "", query
+	ctx := ContextWithBaggage(c.Request.Context(), c.Baggage())
+
+	var getBody func() (io.ReadCloser, error)
+	var size int64
+	if body != nil && body == io.Reader(c.Request.Body) {
+		var err error
+		if body, getBody, size, err = c.bufferRequestBody(); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err == nil && getBody != nil {
+		req.GetBody, req.ContentLength = getBody, size
+	}
+	return req, err
+}
+
+// AddUpstream registers an upstream under name, replacing any upstream of the
+// same name. It fails if the TLS files cannot be loaded.
+func (engine *Engine) AddUpstream(name string, conf UpstreamConfig) (*Upstream, error) {
+	u, err := url.Parse(conf.URL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("upstream %s: URL %q is not absolute", name, conf.URL)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if conf.TLS != nil {
+		tlsConfig, err := upstreamTLSConfig(conf.TLS, u.Hostname())
+		if err != nil {
+			return nil, fmt.Errorf("upstream %s: %w", name, err)
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	var rt http.RoundTripper = transport
+	switch {
+	case conf.Auth.ClientCredentials != nil:
+		Redactions.AddSecrets(conf.Auth.ClientCredentials.ClientSecret)
+		rt = &authTransport{next: rt, source: newTokenSource(conf.Auth.ClientCredentials, engine.now)}
+	case conf.Auth.BearerToken != "":
+		rt = &authTransport{next: rt, source: staticToken(conf.Auth.BearerToken)}
+		Redactions.AddSecrets(conf.Auth.BearerToken)
+	}
+	var retry *retryTransport
+	if conf.Retry != nil {
+		retry = newRetryTransport(rt, *conf.Retry, engine.now)
+		rt = retry
+	}
+
+	upstream := &Upstream{
+		Name:   name,
+		URL:    u,
+		client: &http.Client{Transport: BaggageTransport(rt), Timeout: conf.Timeout},
+		retry:  retry,
+		tls:    conf.TLS,
+	}
+	engine.upstreamsMu.Lock()
+	defer engine.upstreamsMu.Unlock()
+	if engine.upstreams == nil {
+		engine.upstreams = make(map[string]*Upstream)
+	}
+	engine.upstreams[name] = upstream
+	return upstream, nil
+}
+
+// Upstream returns the upstream registered under name, or nil.
+func (engine *Engine) Upstream(name string) *Upstream {
+	engine.upstreamsMu.RLock()
+	defer engine.upstreamsMu.RUnlock()
+	return engine.upstreams[name]
+}
+
+func upstreamTLSConfig(conf *UpstreamTLS, host string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         conf.ServerName,
+		InsecureSkipVerify: conf.InsecureSkipVerify, //nolint: gosec
+		MinVersion:         tls.VersionTLS12,
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = host
+	}
+	if conf.CAFile != "" {
+		pem, err := os.ReadFile(conf.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", conf.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if conf.CertFile != "" || conf.KeyFile != "" {
+		cert := &reloadingCert{certFile: conf.CertFile, keyFile: conf.KeyFile}
+		if _, err := cert.get(); err != nil {
+			return nil, err
+		}
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return cert.get()
+		}
+	}
+	return tlsConfig, nil
+}
+
+// reloadingCert is a client certificate reloaded when its files change.
+type reloadingCert struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+func (r *reloadingCert) get() (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	modTime, err := latestModTime(r.certFile, r.keyFile)
+	if err != nil {
+		if r.cert != nil {
+			return r.cert, nil
+		}
+		return nil, err
+	}
+	if r.cert != nil && !modTime.After(r.modTime) {
+		return r.cert, nil
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		if r.cert != nil {
+			debugPrint("cannot reload upstream client certificate: %v", err)
+			return r.cert, nil
+		}
+		return nil, err
+	}
+	r.cert, r.modTime = &cert, modTime
+	return r.cert, nil
+}
+
+func latestModTime(files ...string) (time.Time, error) {
+	var latest time.Time
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+type tokenSource interface {
+	token() (string, error)
+}
+
+type staticToken string
+
+func (t staticToken) token() (string, error) {
+	return string(t), nil
+}
+
+// clientCredentialsSource caches the tokens of a client credentials grant.
+type clientCredentialsSource struct {
+	conf *ClientCredentials
+	now  func() time.Time
+
+	mu      sync.Mutex
+	value   string
+	expires time.Time
+}
+
+func newTokenSource(conf *ClientCredentials, now func() time.Time) *clientCredentialsSource {
+	if conf.EarlyExpiry <= 0 {
+		conf.EarlyExpiry = defaultTokenEarlyExpiry
+	}
+	if conf.HTTPClient == nil {
+		conf.HTTPClient = http.DefaultClient
+	}
+	return &clientCredentialsSource{conf: conf, now: now}
+}
+
+func (s *clientCredentialsSource) token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.value != "" && (s.expires.IsZero() || s.now().Before(s.expires)) {
+		return s.value, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(s.conf.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.conf.Scopes, " "))
+	}
+	req, err := http.NewRequest(http.MethodPost, s.conf.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", MIMEPOSTForm)
+	req.Header.Set("Accept", MIMEJSON)
+	req.SetBasicAuth(url.QueryEscape(s.conf.ClientID), url.QueryEscape(s.conf.ClientSecret.Reveal()))
+	resp, err := s.conf.HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err = json.Unmarshal(body, &token); err != nil {
+		return "", err
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("token response without access_token")
+	}
+	Redactions.RemoveSecrets(Secret(s.value))
+	Redactions.AddSecrets(Secret(token.AccessToken))
+	s.value, s.expires = token.AccessToken, time.Time{}
+	if token.ExpiresIn > 0 {
+		s.expires = s.now().Add(time.Duration(token.ExpiresIn)*time.Second - s.conf.EarlyExpiry)
+	}
+	return s.value, nil
+}
+
+// authTransport injects a bearer token into the requests.
+type authTransport struct {
+	next   http.RoundTripper
+	source tokenSource
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.source.token()
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, fmt.Errorf("upstream auth: %w", err)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return t.next.RoundTrip(req)
+}
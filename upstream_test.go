@@ -0,0 +1,202 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeClientCert writes a self-signed client certificate and its key.
+func writeClientCert(t *testing.T, dir, cn string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile, keyFile = filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestUpstreamTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s %s %s", r.TLS.PeerCertificates[0].Subject.CommonName, r.URL.RequestURI(),
+			r.Header.Get("Authorization"), r.Header.Get(BaggageHeader))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert, MinVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600))
+	certFile, keyFile := writeClientCert(t, dir, "gateway")
+
+	router := New()
+	upstream, err := router.AddUpstream("users", UpstreamConfig{
+		URL:  server.URL + "/v1",
+		TLS:  &UpstreamTLS{CAFile: caFile, CertFile: certFile, KeyFile: keyFile, ServerName: "example.com"},
+		Auth: UpstreamAuth{BearerToken: "static"},
+	})
+	require.NoError(t, err)
+	assert.Same(t, upstream, router.Upstream("users"))
+	assert.Nil(t, router.Upstream("missing"))
+
+	router.GET("/users/:id", func(c *Context) {
+		c.Baggage().Set("tenant", "acme")
+		u := router.Upstream("users")
+		req, rerr := u.NewRequest(c, http.MethodGet, "/users/"+c.Param("id")+"?full=1", nil)
+		require.NoError(t, rerr)
+		req.Header.Set("Authorization", "Basic client-credentials")
+		resp, rerr := u.Client().Do(req)
+		if rerr != nil {
+			c.String(http.StatusBadGateway, rerr.Error())
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		c.String(resp.StatusCode, string(body))
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/users/42")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gateway /v1/users/42?full=1 Bearer static tenant=acme", w.Body.String())
+
+	// without the CA bundle, the upstream certificate is not trusted
+	_, err = router.AddUpstream("users", UpstreamConfig{URL: server.URL})
+	require.NoError(t, err)
+	w = PerformRequest(router, http.MethodGet, "/users/42")
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+}
+
+func TestUpstreamConfigErrors(t *testing.T) {
+	router := New()
+	_, err := router.AddUpstream("a", UpstreamConfig{URL: "/relative"})
+	assert.Error(t, err)
+	_, err = router.AddUpstream("a", UpstreamConfig{URL: "://bad"})
+	assert.Error(t, err)
+	_, err = router.AddUpstream("a", UpstreamConfig{URL: "https://a", TLS: &UpstreamTLS{CAFile: "missing.pem"}})
+	assert.Error(t, err)
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	require.NoError(t, os.WriteFile(empty, nil, 0o600))
+	_, err = router.AddUpstream("a", UpstreamConfig{URL: "https://a", TLS: &UpstreamTLS{CAFile: empty}})
+	assert.EqualError(t, err, "upstream a: no certificate found in "+empty)
+	_, err = router.AddUpstream("a", UpstreamConfig{URL: "https://a", TLS: &UpstreamTLS{CertFile: empty, KeyFile: empty}})
+	assert.Error(t, err)
+}
+
+func TestUpstreamReloadingCert(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeClientCert(t, dir, "first")
+	cert := &reloadingCert{certFile: certFile, keyFile: keyFile}
+	first, err := cert.get()
+	require.NoError(t, err)
+	again, err := cert.get()
+	require.NoError(t, err)
+	assert.Same(t, first, again)
+
+	writeClientCert(t, dir, "second")
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, later, later))
+	second, err := cert.get()
+	require.NoError(t, err)
+	assert.NotSame(t, first, second)
+
+	// a broken or missing file keeps the last good certificate
+	require.NoError(t, os.WriteFile(certFile, []byte("broken"), 0o600))
+	require.NoError(t, os.Chtimes(certFile, later.Add(time.Minute), later.Add(time.Minute)))
+	kept, err := cert.get()
+	require.NoError(t, err)
+	assert.Same(t, second, kept)
+	require.NoError(t, os.Remove(keyFile))
+	kept, err = cert.get()
+	require.NoError(t, err)
+	assert.Same(t, second, kept)
+}
+
+func TestUpstreamClientCredentials(t *testing.T) {
+	var issued atomic.Int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		if user != "gateway" || pass != "s3cret" || r.FormValue("grant_type") != "client_credentials" {
+			http.Error(w, "invalid_client", http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "read write", r.FormValue("scope"))
+		n := issued.Add(1)
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":%d}`, n, 3600)
+	}))
+	defer tokenServer.Close()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Header.Get("Authorization")) //nolint: errcheck
+	}))
+	defer upstream.Close()
+
+	get := func(u *Upstream) (string, error) {
+		resp, err := u.Client().Get(u.URL.String())
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	router := New()
+	u, err := router.AddUpstream("orders", UpstreamConfig{URL: upstream.URL, Auth: UpstreamAuth{
+		ClientCredentials: &ClientCredentials{
+			TokenURL: tokenServer.URL, ClientID: "gateway", ClientSecret: "s3cret", Scopes: []string{"read", "write"},
+		},
+	}})
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		body, err := get(u)
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer token-1", body)
+	}
+	assert.Equal(t, int32(1), issued.Load())
+
+	// tokens are renewed once they expire, early
+	u.Client().Transport.(baggageTransport).next.(*authTransport).source.(*clientCredentialsSource).expires = time.Now()
+	body, err := get(u)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer token-2", body)
+
+	u, err = router.AddUpstream("orders", UpstreamConfig{URL: upstream.URL, Auth: UpstreamAuth{
+		ClientCredentials: &ClientCredentials{TokenURL: tokenServer.URL, ClientID: "gateway", ClientSecret: "wrong"},
+	}})
+	require.NoError(t, err)
+	_, err = get(u)
+	assert.ErrorContains(t, err, "upstream auth: token request failed with status 401: invalid_client")
+}
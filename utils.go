@@ -5,12 +5,15 @@
 package gin
 
 import (
+	"cmp"
 	"encoding/xml"
 	"net/http"
 	"os"
 	"path"
 	"reflect"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"unicode"
 )
@@ -100,18 +103,56 @@ func chooseData(custom, wildcard any) any {
 	panic("negotiation config is invalid")
 }
 
-func parseAccept(acceptHeader string) []string {
+// parseAccept returns the media ranges of an Accept header by decreasing
+// quality value, the ones of equal quality in their order, and the ones
+// refused with q=0.
+func parseAccept(acceptHeader string) (accepted, rejected []string) {
 	parts := strings.Split(acceptHeader, ",")
-	out := make([]string, 0, len(parts))
+	ranges := make([]acceptRange, 0, len(parts))
 	for _, part := range parts {
+		quality := 1.0
 		if i := strings.IndexByte(part, ';'); i > 0 {
+			quality = acceptQuality(part[i+1:])
 			part = part[:i]
 		}
-		if part = strings.TrimSpace(part); part != "" {
-			out = append(out, part)
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		if quality <= 0 {
+			rejected = append(rejected, part)
+			continue
+		}
+		ranges = append(ranges, acceptRange{part, quality})
+	}
+	slices.SortStableFunc(ranges, func(a, b acceptRange) int {
+		return cmp.Compare(b.quality, a.quality)
+	})
+	accepted = make([]string, len(ranges))
+	for i, r := range ranges {
+		accepted[i] = r.mediaRange
+	}
+	return accepted, rejected
+}
+
+type acceptRange struct {
+	mediaRange string
+	quality    float64
+}
+
+// acceptQuality returns the quality value of the params of a media range, 1
+// if it has none or an invalid one.
+func acceptQuality(params string) float64 {
+	for _, param := range strings.Split(params, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if strings.EqualFold(strings.TrimSpace(name), "q") {
+			q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || q > 1 {
+				return 1
+			}
+			return q
 		}
 	}
-	return out
+	return 1
 }
 
 func lastChar(str string) uint8 {
@@ -61,7 +61,7 @@ func TestLastChar(t *testing.T) {
 }
 
 func TestParseAccept(t *testing.T) {
-	parts := parseAccept("text/html , application/xhtml+xml,application/xml;q=0.9,  */* ;q=0.8")
+	parts, _ := parseAccept("text/html , application/xhtml+xml,application/xml;q=0.9,  */* ;q=0.8")
 	assert.Len(t, parts, 4)
 	assert.Equal(t, literal_5068, parts[0])
 	assert.Equal(t, "application/xhtml+xml", parts[1])
@@ -152,3 +152,13 @@ func TestIsASCII(t *testing.T) {
 const literal_7354 = "/path2"
 
 const literal_5068 = "text/html"
+
+func TestParseAcceptQuality(t *testing.T) {
+	accepted, rejected := parseAccept("text/*;q=0.3, application/json;level=1;q=0.9, application/xml ; Q=0.95, */*;q=0.1, text/csv;q=0, image/png;q=bad")
+	assert.Equal(t, []string{"image/png", "application/xml", "application/json", "text/*", "*/*"}, accepted)
+	assert.Equal(t, []string{"text/csv"}, rejected)
+
+	accepted, rejected = parseAccept("")
+	assert.Empty(t, accepted)
+	assert.Empty(t, rejected)
+}
@@ -0,0 +1,147 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/jialequ/mpgw/render"
+)
+
+// engineCheck is a check run by Engine.Validate.
+type engineCheck struct {
+	name  string
+	check func() error
+}
+
+// AddCheck registers a check run by Validate, such as the validation of the
+// config of a middleware or of a dependency.
+func (engine *Engine) AddCheck(name string, check func() error) {
+	assert1(check != nil, "check can not be nil")
+	engine.checks = append(engine.checks, engineCheck{name: name, check: check})
+}
+
+// TLSCheck returns a check loading a certificate and its key, for the files
+// given to RunTLS:
+//
+//	router.AddCheck("tls", gin.TLSCheck(certFile, keyFile))
+func TLSCheck(certFile, keyFile string) func() error {
+	return func() error {
+		_, err := tls.LoadX509KeyPair(certFile, keyFile)
+		return err
+	}
+}
+
+// Validate checks the configuration of the engine without serving requests:
+// the routes are rebuilt to detect conflicts, HTML templates are parsed, the
+// roots of static routes must exist, the TLS files of the upstreams must load,
+// the trusted proxies must parse, TrustedPlatform must agree with the platform
+// profile, and the checks registered with AddCheck must pass. It returns all
+// the errors found, joined, for --check-config flags and smoke tests:
+//
+//	if err := router.Validate(); err != nil {
+//	    log.Fatal(err)
+//	}
+func (engine *Engine) Validate() error {
+	var errs []error
+	check := func(name string, fn func() error) {
+		if err := safeCheck(fn); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	trees := make(map[string]methodTrees)
+	for _, route := range engine.table.Load().routes {
+		check("route "+route.Method+" "+route.Host+route.Path, func() error {
+			trees[route.Host] = addCheckedRoute(trees[route.Host], route.Method, route.Path, engine.routeConstraints())
+			return nil
+		})
+	}
+	if engine.HTMLRender != nil {
+		check("templates", func() error { return checkTemplates(engine.HTMLRender) })
+	}
+	check("trusted proxies", func() error {
+		_, err := engine.prepareTrustedCIDRs()
+		return err
+	})
+	check("platform", engine.checkPlatform)
+	engine.upstreamsMu.RLock()
+	for name, u := range engine.upstreams {
+		if u.tls != nil {
+			check("upstream "+name, func() error {
+				_, err := upstreamTLSConfig(u.tls, u.URL.Hostname())
+				return err
+			})
+		}
+	}
+	engine.upstreamsMu.RUnlock()
+	for _, c := range engine.checks {
+		check(c.name, c.check)
+	}
+	return errors.Join(errs...)
+}
+
+// addCheckedRoute adds a route to trees, panicking on conflicts as
+// Engine.addRoute.
+func addCheckedRoute(trees methodTrees, method, path string, constraints map[string]func(string) bool) methodTrees {
+	root := trees.get(method)
+	if root == nil {
+		root = &node{fullPath: "/"}
+		trees = append(trees, methodTree{method: method, root: root})
+	}
+	root.addConstrainedRoute(path, HandlersChain{func(*Context) {}}, constraints)
+	return trees
+}
+
+// checkTemplates parses the templates of the debug renderer, which are parsed
+// per request otherwise.
+func checkTemplates(r render.HTMLRender) error {
+	switch r := r.(type) {
+	case render.HTMLDebug:
+		r.Instance("", nil)
+	case render.HTMLProduction:
+		if r.Template == nil {
+			return errors.New("no template loaded")
+		}
+	}
+	return nil
+}
+
+// safeCheck runs a check, turning its panics into errors.
+func safeCheck(fn func() error) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			if e, ok := rec.(error); ok {
+				err = e
+				return
+			}
+			err = fmt.Errorf("%v", rec)
+		}
+	}()
+	return fn()
+}
+
+// checkFS reports whether name can be opened in fs.
+func checkFS(fs http.FileSystem, name string) func() error {
+	return func() error {
+		f, err := fs.Open(name)
+		if err != nil {
+			return err
+		}
+		return f.Close()
+	}
+}
+
+// checkFile reports whether the file exists.
+func checkFile(name string) func() error {
+	return func() error {
+		_, err := os.Stat(name)
+		return err
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngineValidate(t *testing.T) {
+	router := New()
+	router.LoadHTMLGlob("./testdata/template/*")
+	router.GET("/users/:id", handlerTest1)
+	router.GET("/users/:id/orders", handlerTest1)
+	router.Static("/assets", "./testdata")
+	router.StaticFile("/favicon.ico", "./testdata/template/hello.tmpl")
+	router.StaticFileFS("/raw", "/template/raw.tmpl", http.Dir("./testdata"))
+	router.AddCheck("tls", TLSCheck("./testdata/certificate/cert.pem", "./testdata/certificate/key.pem"))
+	assert.NoError(t, router.Validate())
+}
+
+func TestEngineValidateErrors(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := filepath.Join(dir, "index.tmpl")
+	require.NoError(t, os.WriteFile(tmpl, []byte("{{ .Title }}"), 0o600))
+
+	SetMode(DebugMode)
+	router := New()
+	router.LoadHTMLFiles(tmpl)
+	SetMode(TestMode)
+	router.GET("/users/:id", handlerTest1)
+	router.Static("/assets", filepath.Join(dir, "missing"))
+	router.StaticFile("/favicon.ico", filepath.Join(dir, "favicon.ico"))
+	router.trustedProxies = []string{"not-an-ip"}
+	router.AddCheck("tls", TLSCheck(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")))
+	router.AddCheck("cache", func() error { return errors.New("cache unreachable") })
+	router.AddCheck("panicking", func() error { panic("invalid config") })
+
+	// the files change after registration
+	require.NoError(t, os.WriteFile(tmpl, []byte("{{ .Title "), 0o600))
+	router.routes = append(router.routes, &Route{Method: http.MethodGet, Path: "/users/:name"})
+
+	err := router.Validate()
+	require.Error(t, err)
+	for _, msg := range []string{
+		"route GET /users/:name: ':name' in new path '/users/:name' conflicts with existing wildcard ':id'",
+		"templates: template: index.tmpl:1: unclosed action",
+		"static /assets: open " + filepath.Join(dir, "missing"),
+		"static file /favicon.ico: stat " + filepath.Join(dir, "favicon.ico"),
+		"trusted proxies: invalid IP address: not-an-ip",
+		"tls: open " + filepath.Join(dir, "cert.pem"),
+		"cache: cache unreachable",
+		"panicking: invalid config",
+	} {
+		assert.ErrorContains(t, err, msg)
+	}
+	assert.NotContains(t, err.Error(), "route GET /users/:id:")
+	assert.Panics(t, func() { router.AddCheck("nil", nil) })
+}
+
+func TestEngineValidateUpstreams(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeClientCert(t, dir, "gateway")
+	router := New()
+	_, err := router.AddUpstream("users", UpstreamConfig{
+		URL: "https://users.internal",
+		TLS: &UpstreamTLS{CertFile: certFile, KeyFile: keyFile},
+	})
+	require.NoError(t, err)
+	require.NoError(t, router.Validate())
+
+	require.NoError(t, os.Remove(keyFile))
+	assert.ErrorContains(t, router.Validate(), "upstream users: stat "+keyFile)
+}
@@ -0,0 +1,176 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"strings"
+)
+
+// APIVersionKey is the key under which the version negotiated by a Versions
+// route is stored in the context.
+const APIVersionKey = "_gin-gonic/gin/apiversionkey"
+
+// VersionConfig defines the config of Versioned.
+type VersionConfig struct {
+	// Vendor is the vendor of the media types requesting a version in the
+	// Accept header, "myapp" for "application/vnd.myapp.v2+json". Required
+	// unless Header is set.
+	Vendor string
+
+	// Header is a request header carrying the version, such as "X-API-Version",
+	// with or without the "v" prefix. It takes precedence over the Accept
+	// header. Optional. Default value is "", the version is only negotiated
+	// with the Accept header.
+	Header string
+
+	// Default is the version served to the requests which do not ask for one.
+	// Required.
+	Default string
+}
+
+// Versions registers the routes of several versions of an API under the same
+// paths, dispatched by the version requested by the clients, see Versioned.
+type Versions struct {
+	group  *RouterGroup
+	conf   VersionConfig
+	vendor string
+	routes map[string]map[string]HandlersChain
+}
+
+// VersionGroup registers the routes of a version of an API.
+type VersionGroup struct {
+	versions *Versions
+	version  string
+	handlers HandlersChain
+}
+
+// Versioned returns a facility registering the same paths for several versions
+// of an API. The requests are dispatched to the handlers of the version they
+// ask for, in the Accept header or in conf.Header, or to the ones of
+// conf.Default when they ask for none:
+//
+//	api := router.Group("/api").Versioned(gin.VersionConfig{Vendor: "myapp", Default: "1"})
+//	api.Version("1").GET("/users/:id", getUserV1)
+//	api.Version("2").GET("/users/:id", getUserV2)
+//
+// The group middlewares run before the dispatch, the version middlewares after.
+// The requests asking for a version which does not serve the route are answered
+// with 406 Not Acceptable. The negotiated version is returned by
+// Context.APIVersion.
+func (group *RouterGroup) Versioned(conf VersionConfig) *Versions {
+	assert1(conf.Vendor != "" || conf.Header != "", "VersionConfig.Vendor or VersionConfig.Header is required")
+	assert1(conf.Default != "", "VersionConfig.Default is required")
+	return &Versions{
+		group:  group,
+		conf:   conf,
+		vendor: "application/vnd." + strings.ToLower(conf.Vendor) + ".v",
+		routes: make(map[string]map[string]HandlersChain),
+	}
+}
+
+// Version returns the group registering the routes of version, running the
+// given middlewares before their handlers.
+func (v *Versions) Version(version string, middleware ...HandlerFunc) *VersionGroup {
+	assert1(version != "", "version can not be empty")
+	return &VersionGroup{versions: v, version: strings.TrimPrefix(version, "v"), handlers: middleware}
+}
+
+// Handle registers the handlers of the version for the given method and path.
+func (g *VersionGroup) Handle(httpMethod, relativePath string, handlers ...HandlerFunc) IRoutes {
+	assert1(len(handlers) > 0, "there must be at least one handler")
+	v := g.versions
+	key := httpMethod + " " + relativePath
+	chains := v.routes[key]
+	if chains == nil {
+		chains = make(map[string]HandlersChain)
+		v.routes[key] = chains
+		v.group.handle(httpMethod, relativePath, HandlersChain{v.dispatch(chains)})
+	}
+	if _, ok := chains[g.version]; ok {
+		panic("version " + g.version + " of route " + key + " is already registered")
+	}
+	chain := make(HandlersChain, 0, len(g.handlers)+len(handlers))
+	chains[g.version] = append(append(chain, g.handlers...), handlers...)
+	return v.group.returnObj()
+}
+
+// GET is a shortcut for g.Handle("GET", path, handlers...).
+func (g *VersionGroup) GET(relativePath string, handlers ...HandlerFunc) IRoutes {
+	return g.Handle(http.MethodGet, relativePath, handlers...)
+}
+
+// POST is a shortcut for g.Handle("POST", path, handlers...).
+func (g *VersionGroup) POST(relativePath string, handlers ...HandlerFunc) IRoutes {
+	return g.Handle(http.MethodPost, relativePath, handlers...)
+}
+
+// PUT is a shortcut for g.Handle("PUT", path, handlers...).
+func (g *VersionGroup) PUT(relativePath string, handlers ...HandlerFunc) IRoutes {
+	return g.Handle(http.MethodPut, relativePath, handlers...)
+}
+
+// PATCH is a shortcut for g.Handle("PATCH", path, handlers...).
+func (g *VersionGroup) PATCH(relativePath string, handlers ...HandlerFunc) IRoutes {
+	return g.Handle(http.MethodPatch, relativePath, handlers...)
+}
+
+// DELETE is a shortcut for g.Handle("DELETE", path, handlers...).
+func (g *VersionGroup) DELETE(relativePath string, handlers ...HandlerFunc) IRoutes {
+	return g.Handle(http.MethodDelete, relativePath, handlers...)
+}
+
+// dispatch returns the handler running the chain of the requested version.
+func (v *Versions) dispatch(chains map[string]HandlersChain) HandlerFunc {
+	return func(c *Context) {
+		header := c.Writer.Header()
+		if v.conf.Vendor != "" {
+			header.Add("Vary", "Accept")
+		}
+		if v.conf.Header != "" {
+			header.Add("Vary", v.conf.Header)
+		}
+		version := v.requested(c)
+		if version == "" {
+			version = v.conf.Default
+		}
+		chain, ok := chains[version]
+		if !ok {
+			c.AbortWithStatus(http.StatusNotAcceptable)
+			return
+		}
+		c.Set(APIVersionKey, version)
+		c.handlers = chain
+		c.index = -1
+	}
+}
+
+// requested returns the version requested by c, or "".
+func (v *Versions) requested(c *Context) string {
+	if v.conf.Header != "" {
+		if version := strings.TrimSpace(c.requestHeader(v.conf.Header)); version != "" {
+			return strings.TrimPrefix(version, "v")
+		}
+	}
+	if v.conf.Vendor == "" {
+		return ""
+	}
+	for _, accept := range c.Request.Header.Values("Accept") {
+		for _, mediaType := range strings.Split(accept, ",") {
+			mediaType, _, _ = strings.Cut(mediaType, ";")
+			mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+			if version, ok := strings.CutPrefix(mediaType, v.vendor); ok {
+				version, _, _ = strings.Cut(version, "+")
+				return version
+			}
+		}
+	}
+	return ""
+}
+
+// APIVersion returns the version negotiated by a Versions route, or "".
+func (c *Context) APIVersion() string {
+	return c.GetString(APIVersionKey)
+}
@@ -0,0 +1,58 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersioned(t *testing.T) {
+	var calls []string
+	router := New()
+	api := router.Group("/api", func(c *Context) { calls = append(calls, "group") }).Versioned(VersionConfig{
+		Vendor:  "myapp",
+		Header:  "X-API-Version",
+		Default: "1",
+	})
+	v1 := api.Version("1")
+	v2 := api.Version("v2", func(c *Context) { calls = append(calls, "v2") })
+	v1.GET("/users/:id", func(c *Context) { c.String(http.StatusOK, "v1 "+c.Param("id")+" "+c.APIVersion()) })
+	v2.GET("/users/:id", func(c *Context) { c.String(http.StatusOK, "v2 "+c.Param("id")+" "+c.APIVersion()) })
+	router.Route("/api/users/:id", http.MethodGet).Name("user")
+	v2.POST("/users", func(c *Context) { c.String(http.StatusCreated, "created") })
+
+	w := PerformRequest(router, http.MethodGet, "/api/users/1")
+	assert.Equal(t, "v1 1 1", w.Body.String())
+	assert.Equal(t, []string{"Accept", "X-API-Version"}, w.Header().Values("Vary"))
+	assert.Equal(t, []string{"group"}, calls)
+
+	calls = nil
+	w = PerformRequest(router, http.MethodGet, "/api/users/2", header{"Accept", "text/html, application/vnd.MyApp.v2+json;q=0.9"})
+	assert.Equal(t, "v2 2 2", w.Body.String())
+	assert.Equal(t, []string{"group", "v2"}, calls)
+
+	w = PerformRequest(router, http.MethodGet, "/api/users/3", header{"Accept", "application/vnd.myapp.v2+json"}, header{"X-API-Version", "v1"})
+	assert.Equal(t, "v1 3 1", w.Body.String())
+
+	w = PerformRequest(router, http.MethodGet, "/api/users/4", header{"X-API-Version", "3"})
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	w = PerformRequest(router, http.MethodPost, "/api/users")
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	w = PerformRequest(router, http.MethodPost, "/api/users", header{"X-API-Version", "2"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	assert.Len(t, router.Routes(), 2)
+	path, err := router.PathBuilder("user").Param("id", "5").Build()
+	assert.NoError(t, err)
+	assert.Equal(t, "/api/users/5", path)
+
+	assert.PanicsWithValue(t, "version 1 of route GET /users/:id is already registered", func() {
+		v1.GET("/users/:id", func(c *Context) {})
+	})
+	assert.Panics(t, func() { router.Versioned(VersionConfig{Default: "1"}) })
+}
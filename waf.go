@@ -0,0 +1,330 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+const defaultWAFBodyLimit = 8 << 10
+
+// WAFTarget is a set of request parts inspected by a rule.
+type WAFTarget uint8
+
+// Request parts inspected by the rules of a WAF.
+const (
+	// WAFPath is the decoded URL path.
+	WAFPath WAFTarget = 1 << iota
+	// WAFQuery is the decoded query string.
+	WAFQuery
+	// WAFHeaders are the header values.
+	WAFHeaders
+	// WAFBody is the beginning of the body, see WAFConfig.BodyLimit.
+	WAFBody
+
+	// WAFAll is every part of the request.
+	WAFAll = WAFPath | WAFQuery | WAFHeaders | WAFBody
+)
+
+func (t WAFTarget) String() string {
+	switch t {
+	case WAFPath:
+		return "path"
+	case WAFQuery:
+		return "query"
+	case WAFHeaders:
+		return "headers"
+	case WAFBody:
+		return "body"
+	}
+	return fmt.Sprintf("WAFTarget(%d)", uint8(t))
+}
+
+// WAFAction tells what happens to a request matching a rule.
+type WAFAction uint8
+
+const (
+	// WAFDefault uses the mode of the WAF, for rules.
+	WAFDefault WAFAction = iota
+	// WAFBlock aborts the request with WAFConfig.BlockStatus.
+	WAFBlock
+	// WAFLog only reports the match, to evaluate a rule before enforcing it.
+	WAFLog
+)
+
+// WAFRule is a pattern requests are checked against.
+type WAFRule struct {
+	// ID identifies the rule in matches and stats. Required.
+	ID string
+
+	// Pattern is a regular expression, see regexp/syntax. Required.
+	Pattern string
+
+	// Targets are the request parts the pattern is matched against.
+	// Optional. Default value is WAFAll.
+	Targets WAFTarget
+
+	// Action overrides the mode of the WAF for the rule. Optional.
+	Action WAFAction
+}
+
+// DefaultWAFRules returns basic signatures of SQL injections, cross-site
+// scripting and path traversals. They catch unsophisticated attacks only, and
+// may need to be switched to WAFLog for endpoints accepting free text.
+func DefaultWAFRules() []WAFRule {
+	return []WAFRule{
+		{ID: "sqli-union", Pattern: `(?i)\bunion\b[\s(]+(all\s+)?select\b`, Targets: WAFQuery | WAFBody},
+		{ID: "sqli-tautology", Pattern: `(?i)['"]\s*\bor\b\s*['"]?\w+['"]?\s*=\s*['"]?\w+`, Targets: WAFQuery | WAFBody},
+		{ID: "sqli-comment", Pattern: `(?i)['"]\s*(;|--|#|/\*)`, Targets: WAFQuery},
+		{ID: "sqli-stacked", Pattern: `(?i);\s*(drop|delete|insert|update|alter|truncate)\s`, Targets: WAFQuery | WAFBody},
+		{ID: "xss-script", Pattern: `(?i)<\s*script\b`, Targets: WAFQuery | WAFBody | WAFHeaders},
+		{ID: "xss-handler", Pattern: `(?i)<[^>]+\bon[a-z]+\s*=`, Targets: WAFQuery | WAFBody},
+		{ID: "xss-uri", Pattern: `(?i)\b(javascript|vbscript)\s*:`, Targets: WAFQuery | WAFBody},
+		{ID: "path-traversal", Pattern: `(^|[\\/])\.\.([\\/]|$)`, Targets: WAFPath | WAFQuery},
+	}
+}
+
+// WAFMatch is a rule matched by a request.
+type WAFMatch struct {
+	Rule    string
+	Target  WAFTarget
+	Blocked bool
+}
+
+// WAFRuleStats are the counters of a rule.
+type WAFRuleStats struct {
+	ID      string
+	Matches uint64
+	Blocked uint64
+}
+
+// WAFConfig defines the config of a WAF.
+type WAFConfig struct {
+	// Rules are the rules checked, in order. Optional. Default value is DefaultWAFRules().
+	Rules []WAFRule
+
+	// Mode is the action of the rules using WAFDefault.
+	// Optional. Default value is WAFBlock.
+	Mode WAFAction
+
+	// BodyLimit is the number of bytes of the body inspected. The body is
+	// still available to the handlers in full. A negative value disables the
+	// inspection of bodies. Optional. Default value is 8KB.
+	BodyLimit int
+
+	// BlockStatus is the status of the blocked requests.
+	// Optional. Default value is 403.
+	BlockStatus int
+
+	// OnMatch is called for each rule matched by a request. Optional. Default
+	// value logs the match in debug mode.
+	OnMatch func(c *Context, m WAFMatch)
+
+	// Skip tells which requests are not inspected. Optional.
+	Skip Skipper
+}
+
+type wafRule struct {
+	WAFRule
+	re      *regexp.Regexp
+	block   bool
+	matches atomic.Uint64
+	blocked atomic.Uint64
+}
+
+// WAF is a lightweight web application firewall, matching compiled patterns
+// against the requests. It is no substitute for a full featured WAF, but stops
+// basic attacks at the edge.
+type WAF struct {
+	conf    WAFConfig
+	rules   []*wafRule
+	targets WAFTarget
+}
+
+// NewWAF compiles the rules of conf.
+func NewWAF(conf WAFConfig) (*WAF, error) {
+	if conf.Rules == nil {
+		conf.Rules = DefaultWAFRules()
+	}
+	if conf.Mode == WAFDefault {
+		conf.Mode = WAFBlock
+	}
+	if conf.BodyLimit == 0 {
+		conf.BodyLimit = defaultWAFBodyLimit
+	}
+	if conf.BlockStatus == 0 {
+		conf.BlockStatus = http.StatusForbidden
+	}
+	if conf.OnMatch == nil {
+		conf.OnMatch = func(c *Context, m WAFMatch) {
+			debugPrint("[WARNING] %s %s matches WAF rule %s in %s (blocked: %t)\n",
+				c.Request.Method, c.Request.URL.Path, m.Rule, m.Target, m.Blocked)
+		}
+	}
+
+	w := &WAF{conf: conf}
+	for _, rule := range conf.Rules {
+		if rule.ID == "" {
+			return nil, fmt.Errorf("waf: rule %q without ID", rule.Pattern)
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("waf: rule %s: %w", rule.ID, err)
+		}
+		if rule.Targets == 0 {
+			rule.Targets = WAFAll
+		}
+		action := rule.Action
+		if action == WAFDefault {
+			action = conf.Mode
+		}
+		w.rules = append(w.rules, &wafRule{WAFRule: rule, re: re, block: action == WAFBlock})
+		w.targets |= rule.Targets
+	}
+	if conf.BodyLimit < 0 {
+		w.targets &^= WAFBody
+	}
+	return w, nil
+}
+
+// Stats returns a snapshot of the counters of the rules.
+func (w *WAF) Stats() []WAFRuleStats {
+	stats := make([]WAFRuleStats, len(w.rules))
+	for i, rule := range w.rules {
+		stats[i] = WAFRuleStats{ID: rule.ID, Matches: rule.matches.Load(), Blocked: rule.blocked.Load()}
+	}
+	return stats
+}
+
+// Handler returns a middleware checking the requests against the rules.
+func (w *WAF) Handler() HandlerFunc {
+	return func(c *Context) {
+		if w.conf.Skip != nil && w.conf.Skip(c) {
+			c.Next()
+			return
+		}
+		if w.inspect(c) {
+			c.AbortWithStatus(w.conf.BlockStatus)
+			return
+		}
+		c.Next()
+	}
+}
+
+// inspect reports the rules matched by the request, up to the first blocking
+// one, and whether it is blocked.
+func (w *WAF) inspect(c *Context) bool {
+	var query string
+	if w.targets&WAFQuery != 0 {
+		query = unescapeLenient(c.Request.URL.RawQuery)
+	}
+	var body []byte
+	if w.targets&WAFBody != 0 && c.Request.Body != nil && c.Request.Body != http.NoBody {
+		body = w.peekBody(c.Request)
+	}
+
+	for _, rule := range w.rules {
+		target := w.match(c.Request, rule, query, body)
+		if target == 0 {
+			continue
+		}
+		rule.matches.Add(1)
+		if rule.block {
+			rule.blocked.Add(1)
+		}
+		w.conf.OnMatch(c, WAFMatch{Rule: rule.ID, Target: target, Blocked: rule.block})
+		if rule.block {
+			return true
+		}
+	}
+	return false
+}
+
+// match returns the request part matched by the rule, or 0.
+func (w *WAF) match(req *http.Request, rule *wafRule, query string, body []byte) WAFTarget {
+	if rule.Targets&WAFPath != 0 && rule.re.MatchString(req.URL.Path) {
+		return WAFPath
+	}
+	if rule.Targets&WAFQuery != 0 && query != "" && rule.re.MatchString(query) {
+		return WAFQuery
+	}
+	if rule.Targets&WAFHeaders != 0 {
+		for _, values := range req.Header {
+			for _, value := range values {
+				if rule.re.MatchString(value) {
+					return WAFHeaders
+				}
+			}
+		}
+	}
+	if rule.Targets&WAFBody != 0 && len(body) > 0 && rule.re.Match(body) {
+		return WAFBody
+	}
+	return 0
+}
+
+// peekBody reads the beginning of the body, and puts it back for the handlers.
+func (w *WAF) peekBody(req *http.Request) []byte {
+	body := make([]byte, w.conf.BodyLimit)
+	n, err := io.ReadFull(req.Body, body)
+	body = body[:n]
+	rest := req.Body
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		rest = io.NopCloser(errReader{err})
+	}
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), rest), req.Body}
+	return []byte(unescapeLenient(string(body)))
+}
+
+// unescapeLenient decodes the percent-encoded bytes and the '+' of a query
+// string or form body, as the handlers get its values. The malformed escapes
+// are kept as is, rather than giving up on the whole string, which would let
+// them hide the escapes of the other values.
+func unescapeLenient(s string) string {
+	if !strings.ContainsAny(s, "%+") {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '+':
+			b.WriteByte(' ')
+		case s[i] == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			b.WriteByte(unhex(s[i+1])<<4 | unhex(s[i+2]))
+			i += 2
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case c >= 'a':
+		return c - 'a' + 10
+	case c >= 'A':
+		return c - 'A' + 10
+	}
+	return c - '0'
+}
+
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
@@ -0,0 +1,134 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWAFDefaultRules(t *testing.T) {
+	waf, err := NewWAF(WAFConfig{})
+	require.NoError(t, err)
+	router := New()
+	router.Use(waf.Handler())
+	router.Any("/*path", func(c *Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, "%s", body)
+	})
+
+	for _, target := range []string{
+		"/search?q=1%27%20OR%20%271%27%3D%271",
+		"/search?q=1+UNION+ALL+SELECT+password+FROM+users",
+		// a malformed escape does not hide the others
+		"/search?q=1%20union%20select%20pw&x=%zz",
+		"/search?q=%3Cscript%3Ealert(1)%3C/script%3E",
+		"/search?q=%3Cimg+src%3Dx+onerror%3Dalert(1)%3E",
+		"/redirect?to=javascript:alert(1)",
+		"/files/..%2F..%2Fetc/passwd",
+		"/files?name=../../etc/passwd",
+	} {
+		w := PerformRequest(router, http.MethodGet, target)
+		assert.Equal(t, http.StatusForbidden, w.Code, target)
+	}
+	for _, target := range []string{
+		"/search?q=union+station",
+		"/search?q=O%27Reilly",
+		"/files/report..pdf",
+		"/posts?sort=-created",
+	} {
+		w := PerformRequest(router, http.MethodGet, target)
+		assert.Equal(t, http.StatusOK, w.Code, target)
+	}
+
+	// the body is inspected, and left to the handler in full
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/comments", strings.NewReader(`{"text":"<script>steal()</script>"}`))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/comments", strings.NewReader(`{"text":"hello"}`))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"text":"hello"}`, w.Body.String())
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/comments", strings.NewReader("q=1%20union%20select%20pw&x=%zz"))
+	req.Header.Set("Content-Type", MIMEPOSTForm)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	stats := waf.Stats()
+	require.Len(t, stats, len(DefaultWAFRules()))
+	assert.Equal(t, WAFRuleStats{ID: "sqli-union", Matches: 3, Blocked: 3}, stats[0])
+	assert.Equal(t, WAFRuleStats{ID: "path-traversal", Matches: 2, Blocked: 2}, stats[len(stats)-1])
+}
+
+func TestWAFCustomRules(t *testing.T) {
+	var matches []WAFMatch
+	waf, err := NewWAF(WAFConfig{
+		Rules: []WAFRule{
+			{ID: "scanner", Pattern: `(?i)sqlmap|nikto`, Targets: WAFHeaders},
+			{ID: "admin", Pattern: `^/admin`, Targets: WAFPath, Action: WAFBlock},
+			{ID: "secret", Pattern: `secret`, Targets: WAFBody},
+		},
+		Mode:        WAFLog,
+		BodyLimit:   16,
+		BlockStatus: http.StatusNotFound,
+		OnMatch:     func(c *Context, m WAFMatch) { matches = append(matches, m) },
+		Skip:        func(c *Context) bool { return c.Request.URL.Path == "/admin/health" },
+	})
+	require.NoError(t, err)
+	router := New()
+	router.Use(waf.Handler())
+	router.Any("/*path", func(c *Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, "%s", body)
+	})
+
+	// log mode only reports the matches
+	w := PerformRequest(router, http.MethodGet, "/", header{"User-Agent", "sqlmap/1.7"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []WAFMatch{{Rule: "scanner", Target: WAFHeaders}}, matches)
+
+	w = PerformRequest(router, http.MethodGet, "/admin/users", header{"User-Agent", "nikto"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, WAFMatch{Rule: "admin", Target: WAFPath, Blocked: true}, matches[2])
+	w = PerformRequest(router, http.MethodGet, "/admin/health")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// only the beginning of the body is inspected
+	matches = nil
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("0123456789abcdef secret")))
+	assert.Equal(t, "0123456789abcdef secret", w.Body.String())
+	assert.Empty(t, matches)
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("a secret")))
+	assert.Equal(t, []WAFMatch{{Rule: "secret", Target: WAFBody}}, matches)
+
+	assert.Equal(t, []WAFRuleStats{
+		{ID: "scanner", Matches: 2},
+		{ID: "admin", Matches: 1, Blocked: 1},
+		{ID: "secret", Matches: 1},
+	}, waf.Stats())
+}
+
+func TestWAFConfigErrors(t *testing.T) {
+	_, err := NewWAF(WAFConfig{Rules: []WAFRule{{Pattern: "a"}}})
+	assert.EqualError(t, err, `waf: rule "a" without ID`)
+	_, err = NewWAF(WAFConfig{Rules: []WAFRule{{ID: "bad", Pattern: "("}}})
+	assert.ErrorContains(t, err, "waf: rule bad: error parsing regexp")
+
+	waf, err := NewWAF(WAFConfig{Rules: []WAFRule{{ID: "any", Pattern: "x"}}, BodyLimit: -1})
+	require.NoError(t, err)
+	assert.Equal(t, WAFPath|WAFQuery|WAFHeaders, waf.targets)
+	assert.Equal(t, "body", WAFBody.String())
+	assert.Equal(t, "WAFTarget(15)", WAFAll.String())
+}
@@ -0,0 +1,168 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WarmupSpec is a synthetic request executed by Engine.Warmup.
+type WarmupSpec struct {
+	// Method is the method of the request. Optional. Default value is GET.
+	Method string
+
+	// Path is the target of the request, with its query, such as "/users?page=1".
+	Path string
+
+	// Host is the Host header of the request, for the routes of Engine.Host.
+	// Optional.
+	Host string
+
+	// Header and Body are sent with the request. Optional.
+	Header http.Header
+	Body   []byte
+
+	// Repeat is the number of times the request is executed. Optional. Default
+	// value is 1.
+	Repeat int
+
+	// Status is the expected response status. Optional. Default value is 0,
+	// any status below 500 is expected.
+	Status int
+}
+
+// WarmupResult is the outcome of a WarmupSpec, the last one when repeated.
+type WarmupResult struct {
+	Method   string
+	Path     string
+	Status   int
+	Duration time.Duration
+	Err      error
+}
+
+type warmupContextKey struct{}
+
+// Warmup executes synthetic requests through the handlers chains of the engine,
+// in process without network, to prime the caches, the template parses and the
+// connection pools of the upstreams after start and before the instance is
+// reported ready. Handlers can detect these requests with Context.IsWarmup, to
+// skip their side effects. It returns the errors of the requests answering an
+// unexpected status or panicking, joined:
+//
+//	go router.Run(":8080")
+//	if err := router.Warmup(specs); err != nil {
+//	    log.Print(err)
+//	}
+//	ready.Store(true)
+func (engine *Engine) Warmup(requests []WarmupSpec) error {
+	var errs []error
+	for _, result := range engine.WarmupResults(requests) {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("warmup %s %s: %w", result.Method, result.Path, result.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WarmupResults executes the requests as Warmup does and returns their results.
+func (engine *Engine) WarmupResults(requests []WarmupSpec) []WarmupResult {
+	results := make([]WarmupResult, 0, len(requests))
+	for _, spec := range requests {
+		if spec.Method == "" {
+			spec.Method = http.MethodGet
+		}
+		if spec.Repeat <= 0 {
+			spec.Repeat = 1
+		}
+		var result WarmupResult
+		for i := 0; i < spec.Repeat; i++ {
+			result = engine.warmup(spec)
+			if result.Err != nil {
+				break
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// warmup executes a request once.
+func (engine *Engine) warmup(spec WarmupSpec) (result WarmupResult) {
+	result = WarmupResult{Method: spec.Method, Path: spec.Path}
+	ctx := context.WithValue(context.Background(), warmupContextKey{}, true)
+	req, err := http.NewRequestWithContext(ctx, spec.Method, spec.Path, bytes.NewReader(spec.Body))
+	if err != nil {
+		result.Err = err
+		return
+	}
+	req.RequestURI = spec.Path
+	req.RemoteAddr = "127.0.0.1:0"
+	req.Host = spec.Host
+	for key, values := range spec.Header {
+		req.Header[key] = append([]string(nil), values...)
+	}
+
+	w := &warmupWriter{header: make(http.Header)}
+	start := time.Now()
+	defer func() {
+		result.Duration = time.Since(start)
+		if rec := recover(); rec != nil {
+			result.Err = fmt.Errorf("panic: %v", rec)
+		}
+	}()
+	engine.ServeHTTP(w, req)
+
+	result.Status = w.status
+	if result.Status == 0 {
+		result.Status = http.StatusOK
+	}
+	switch {
+	case spec.Status != 0 && result.Status != spec.Status:
+		result.Err = fmt.Errorf("status %d, expected %d", result.Status, spec.Status)
+	case spec.Status == 0 && result.Status >= http.StatusInternalServerError:
+		result.Err = fmt.Errorf("status %d", result.Status)
+	}
+	return
+}
+
+// IsWarmup reports whether the request is a synthetic request of Engine.Warmup.
+func (c *Context) IsWarmup() bool {
+	if c.Request == nil {
+		return false
+	}
+	warmup, _ := c.Request.Context().Value(warmupContextKey{}).(bool)
+	return warmup
+}
+
+// warmupWriter is the http.ResponseWriter of the warmup requests, discarding
+// their bodies.
+type warmupWriter struct {
+	header http.Header
+	status int
+}
+
+func (w *warmupWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *warmupWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return len(p), nil
+}
+
+func (w *warmupWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *warmupWriter) Flush() {}
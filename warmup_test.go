@@ -0,0 +1,74 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngineWarmup(t *testing.T) {
+	calls := map[string]int{}
+	var query, token string
+	router := New()
+	router.Use(Recovery())
+	router.GET("/users/:id", func(c *Context) {
+		calls["users"]++
+		assert.True(t, c.IsWarmup())
+		assert.Equal(t, "7", c.Param("id"))
+		query, token = c.Query("page"), c.GetHeader("X-Token")
+		c.String(http.StatusOK, "user")
+	})
+	router.POST("/echo", func(c *Context) {
+		calls["echo"]++
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusCreated, "%s", body)
+	})
+	router.Host("api.example.com").GET("/host", func(c *Context) {
+		calls["host"]++
+		c.Status(http.StatusNoContent)
+	})
+	router.GET("/panic", func(c *Context) { panic("cold cache") })
+
+	require.NoError(t, router.Warmup([]WarmupSpec{
+		{Path: "/users/7?page=2", Header: http.Header{"X-Token": {"token"}}, Repeat: 3},
+		{Method: http.MethodPost, Path: "/echo", Body: []byte("hello"), Status: http.StatusCreated},
+		{Path: "/host", Host: "api.example.com:443"},
+		{Path: "/missing"},
+	}))
+	assert.Equal(t, map[string]int{"users": 3, "echo": 1, "host": 1}, calls)
+	assert.Equal(t, "2", query)
+	assert.Equal(t, "token", token)
+
+	err := router.Warmup([]WarmupSpec{
+		{Path: "/panic"},
+		{Path: "/users/7", Status: http.StatusAccepted, Repeat: 2},
+		{Path: "/%zz"},
+	})
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "warmup GET /panic: status 500")
+	assert.ErrorContains(t, err, "warmup GET /users/7: status 200, expected 202")
+	assert.ErrorContains(t, err, "warmup GET /%zz: parse")
+	assert.Equal(t, 4, calls["users"], "repeats stop at the first error")
+
+	results := router.WarmupResults([]WarmupSpec{{Path: "/host", Host: "api.example.com"}})
+	require.Len(t, results, 1)
+	assert.Equal(t, http.StatusNoContent, results[0].Status)
+	assert.NoError(t, results[0].Err)
+	assert.Positive(t, results[0].Duration)
+}
+
+func TestEngineWarmupPanic(t *testing.T) {
+	router := New()
+	router.GET("/panic", func(c *Context) { panic("cold cache") })
+	assert.EqualError(t, router.Warmup([]WarmupSpec{{Path: "/panic"}}), "warmup GET /panic: panic: cold cache")
+
+	c, _ := CreateTestContext(nil)
+	assert.False(t, c.IsWarmup())
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+const defaultWatchdogBudget = 100 * time.Millisecond
+
+// WatchdogConfig defines the config for the Watchdog middleware.
+type WatchdogConfig struct {
+	// Budget is the maximum execution time of the handlers chain of a request.
+	// Optional. Default value is 100ms.
+	Budget time.Duration
+
+	// Output is a writer where chains exceeding the budget are reported.
+	// Optional. Default value is gin.DefaultWriter.
+	Output io.Writer
+
+	// OnExceed is called instead of writing to Output when a chain exceeds the budget.
+	// Optional.
+	OnExceed func(c *Context, report WatchdogReport)
+
+	// Skip indicates which requests are not watched. Optional.
+	Skip Skipper
+}
+
+// WatchdogReport is the execution time breakdown of a handlers chain.
+type WatchdogReport struct {
+	Method   string
+	Path     string
+	Total    time.Duration
+	Budget   time.Duration
+	Segments []WatchdogSegment
+}
+
+// WatchdogSegment is the execution time of one handler of the chain, excluding
+// the time spent in the handlers it called through Context.Next.
+type WatchdogSegment struct {
+	Handler  string
+	Duration time.Duration
+}
+
+// String formats the report as written to WatchdogConfig.Output.
+func (r WatchdogReport) String() string {
+	s := fmt.Sprintf("[GIN-watchdog] %s %s took %v, budget %v\n", r.Method, r.Path, r.Total, r.Budget)
+	for _, seg := range r.Segments {
+		s += fmt.Sprintf("    %12v  %s\n", seg.Duration, seg.Handler)
+	}
+	return s
+}
+
+// watchdog records the exclusive execution time of each handler of a chain.
+type watchdog struct {
+	durations []time.Duration
+	ran       []bool
+	// children accumulates, per nesting level, the time spent in nested handlers.
+	children []time.Duration
+}
+
+func (w *watchdog) run(c *Context) {
+	i := c.index
+	w.children = append(w.children, 0)
+	start := time.Now()
+	c.handlers[i](c)
+	elapsed := time.Since(start)
+
+	n := len(w.children) - 1
+	nested := w.children[n]
+	w.children = w.children[:n]
+	if n > 0 {
+		w.children[n-1] += elapsed
+	}
+	w.durations[i] += elapsed - nested
+	w.ran[i] = true
+}
+
+// Watchdog returns a middleware which, in debug mode, measures the execution time
+// of every following handler of the chain and reports the requests exceeding the
+// budget with a per-handler breakdown, to find which middleware causes tail latency.
+// It does nothing outside of debug mode, and should be registered first.
+func Watchdog(conf WatchdogConfig) HandlerFunc {
+	if conf.Budget <= 0 {
+		conf.Budget = defaultWatchdogBudget
+	}
+	if conf.Output == nil {
+		conf.Output = DefaultWriter
+	}
+	onExceed := conf.OnExceed
+	if onExceed == nil {
+		onExceed = func(_ *Context, report WatchdogReport) {
+			fmt.Fprint(conf.Output, report.String())
+		}
+	}
+
+	return func(c *Context) {
+		if !IsDebugging() || c.watchdog != nil || (conf.Skip != nil && conf.Skip(c)) {
+			c.Next()
+			return
+		}
+
+		w := &watchdog{
+			durations: make([]time.Duration, len(c.handlers)),
+			ran:       make([]bool, len(c.handlers)),
+		}
+		c.watchdog = w
+		start := time.Now()
+		c.Next()
+		total := time.Since(start)
+		c.watchdog = nil
+
+		if total <= conf.Budget {
+			return
+		}
+		report := WatchdogReport{
+			Method: c.Request.Method,
+			Path:   c.FullPath(),
+			Total:  total,
+			Budget: conf.Budget,
+		}
+		if report.Path == "" {
+			report.Path = c.Request.URL.Path
+		}
+		for i, d := range w.durations {
+			if w.ran[i] {
+				report.Segments = append(report.Segments, WatchdogSegment{
+					Handler:  nameOfFunction(c.handlers[i]),
+					Duration: d,
+				})
+			}
+		}
+		onExceed(c, report)
+	}
+}
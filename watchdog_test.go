@@ -0,0 +1,86 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func watchdogSlowMiddleware(c *Context) {
+	time.Sleep(20 * time.Millisecond)
+	c.Next()
+}
+
+func watchdogFastHandler(c *Context) {
+	c.Status(http.StatusOK)
+}
+
+func TestWatchdog(t *testing.T) {
+	SetMode(DebugMode)
+	defer SetMode(TestMode)
+
+	var reports []WatchdogReport
+	router := New()
+	router.Use(Watchdog(WatchdogConfig{
+		Budget: 10 * time.Millisecond,
+		OnExceed: func(_ *Context, report WatchdogReport) {
+			reports = append(reports, report)
+		},
+	}))
+	router.GET("/slow", watchdogSlowMiddleware, watchdogFastHandler)
+	router.GET("/fast", watchdogFastHandler)
+
+	PerformRequest(router, http.MethodGet, "/fast")
+	assert.Empty(t, reports)
+
+	w := PerformRequest(router, http.MethodGet, "/slow")
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, reports, 1)
+	report := reports[0]
+	assert.Equal(t, "/slow", report.Path)
+	assert.Greater(t, report.Total, report.Budget)
+	require.Len(t, report.Segments, 2)
+	assert.Contains(t, report.Segments[0].Handler, "watchdogSlowMiddleware")
+	assert.Contains(t, report.Segments[1].Handler, "watchdogFastHandler")
+	assert.GreaterOrEqual(t, report.Segments[0].Duration, 20*time.Millisecond)
+	assert.Less(t, report.Segments[1].Duration, 10*time.Millisecond)
+}
+
+func TestWatchdogOutput(t *testing.T) {
+	SetMode(DebugMode)
+	defer SetMode(TestMode)
+
+	buffer := new(bytes.Buffer)
+	router := New()
+	router.Use(Watchdog(WatchdogConfig{Budget: time.Millisecond, Output: buffer}))
+	router.GET("/slow", watchdogSlowMiddleware, func(c *Context) { c.Abort() }, watchdogFastHandler)
+
+	PerformRequest(router, http.MethodGet, "/slow")
+	assert.Contains(t, buffer.String(), "[GIN-watchdog] GET /slow took")
+	assert.Contains(t, buffer.String(), "watchdogSlowMiddleware")
+	assert.NotContains(t, buffer.String(), "watchdogFastHandler")
+}
+
+func TestWatchdogReleaseMode(t *testing.T) {
+	SetMode(ReleaseMode)
+	defer SetMode(TestMode)
+
+	called := false
+	router := New()
+	router.Use(Watchdog(WatchdogConfig{
+		Budget:   time.Nanosecond,
+		OnExceed: func(*Context, WatchdogReport) { called = true },
+	}))
+	router.GET("/slow", watchdogSlowMiddleware, watchdogFastHandler)
+
+	PerformRequest(router, http.MethodGet, "/slow")
+	assert.False(t, called)
+}
@@ -0,0 +1,438 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bufio"
+	"crypto/sha1" //nolint: gosec
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/jialequ/mpgw/internal/json"
+)
+
+// The message types of WebSocket.ReadMessage and WebSocket.WriteMessage, the
+// opcodes of their frames, see RFC 6455 section 5.2.
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+)
+
+// The close codes of WebSocketCloseError, see RFC 6455 section 7.4.1.
+const (
+	CloseNormalClosure    = 1000
+	CloseGoingAway        = 1001
+	CloseProtocolError    = 1002
+	CloseNoStatusReceived = 1005
+	CloseInvalidPayload   = 1007
+	ClosePolicyViolation  = 1008
+	CloseMessageTooBig    = 1009
+	CloseInternalError    = 1011
+)
+
+const (
+	websocketGUID             = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	defaultWebSocketReadLimit = 1 << 20
+	continuationFrame         = 0
+	maxControlPayload         = 125
+)
+
+var (
+	// ErrBadHandshake is returned by Context.Upgrade when the request is not
+	// a valid WebSocket handshake.
+	ErrBadHandshake = errors.New("websocket: bad handshake")
+	// ErrOriginNotAllowed is returned by Context.Upgrade when the origin of
+	// the request is refused by WebSocketConfig.CheckOrigin.
+	ErrOriginNotAllowed = errors.New("websocket: origin not allowed")
+	// ErrWebSocketClosed is returned by the writes to a closed WebSocket.
+	ErrWebSocketClosed = errors.New("websocket: connection closed")
+)
+
+// WebSocketConfig defines the WebSocket upgrades of Context.Upgrade.
+type WebSocketConfig struct {
+	// CheckOrigin reports whether the origin of the request is allowed, after
+	// the middlewares of the route, such as the authentication ones.
+	// Optional. Default value allows the requests without Origin header and
+	// the ones whose origin host is the host of the request, see Context.Host.
+	CheckOrigin func(c *Context) bool
+
+	// Subprotocols are the subprotocols supported by the server: the first
+	// one of them requested by the client is selected. Optional.
+	Subprotocols []string
+
+	// ReadLimit is the maximum size of the messages read: the connection is
+	// closed with CloseMessageTooBig beyond it. Optional. Default value is
+	// 1 MiB.
+	ReadLimit int64
+}
+
+// WebSocketCloseError is returned by WebSocket.ReadMessage when the peer
+// closed the connection, or the connection was closed on a protocol error.
+type WebSocketCloseError struct {
+	Code int
+	Text string
+}
+
+func (e *WebSocketCloseError) Error() string {
+	s := "websocket: close " + strconv.Itoa(e.Code)
+	if e.Text != "" {
+		s += ": " + e.Text
+	}
+	return s
+}
+
+// WebSocket is a WebSocket connection accepted by Context.Upgrade. A reader
+// and a writer may use it concurrently.
+type WebSocket struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	subprotocol string
+	readLimit   int64
+
+	wmu sync.Mutex
+	// closed reports whether a close frame was sent, and connClosed whether
+	// the connection was closed.
+	closed     bool
+	connClosed bool
+}
+
+// Upgrade performs the WebSocket handshake of the request and returns the
+// connection, taken over with Context.Hijack. The headers set on the response
+// by the middlewares, such as cookies, are sent with the handshake. On failure,
+// the request is aborted with the status of the error, 403 Forbidden for
+// ErrOriginNotAllowed, 400 Bad Request for ErrBadHandshake:
+//
+//	router.GET("/ws", func(c *gin.Context) {
+//	    ws, err := c.Upgrade()
+//	    if err != nil {
+//	        return
+//	    }
+//	    defer ws.Close()
+//	    for {
+//	        typ, msg, err := ws.ReadMessage()
+//	        if err != nil {
+//	            return
+//	        }
+//	        ws.WriteMessage(typ, msg)
+//	    }
+//	})
+//
+// The connection may be followed with TrackConnection, passing it to
+// TrackedConn.SetCloser.
+func (c *Context) Upgrade(conf ...WebSocketConfig) (*WebSocket, error) {
+	var cfg WebSocketConfig
+	if len(conf) > 0 {
+		cfg = conf[0]
+	}
+	if cfg.ReadLimit <= 0 {
+		cfg.ReadLimit = defaultWebSocketReadLimit
+	}
+	checkOrigin := cfg.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+
+	fail := func(code int, err error) (*WebSocket, error) {
+		c.AbortWithError(code, err) //nolint: errcheck
+		return nil, err
+	}
+	key := c.requestHeader("Sec-WebSocket-Key")
+	switch {
+	case c.Request.Method != http.MethodGet || !c.IsWebsocket():
+		return fail(http.StatusBadRequest, ErrBadHandshake)
+	case c.requestHeader("Sec-WebSocket-Version") != "13":
+		c.Header("Sec-WebSocket-Version", "13")
+		return fail(http.StatusUpgradeRequired, ErrBadHandshake)
+	case !validWebSocketKey(key):
+		return fail(http.StatusBadRequest, ErrBadHandshake)
+	case !checkOrigin(c):
+		return fail(http.StatusForbidden, ErrOriginNotAllowed)
+	}
+	subprotocol := selectSubprotocol(c.Request.Header.Values("Sec-WebSocket-Protocol"), cfg.Subprotocols)
+
+	c.Writer.WriteHeader(http.StatusSwitchingProtocols)
+	conn, err := c.Hijack()
+	if err != nil {
+		return fail(http.StatusInternalServerError, err)
+	}
+	header := c.Writer.Header().Clone()
+	header.Set("Upgrade", "websocket")
+	header.Set("Connection", "Upgrade")
+	header.Set("Sec-WebSocket-Accept", websocketAccept(key))
+	if subprotocol != "" {
+		header.Set("Sec-WebSocket-Protocol", subprotocol)
+	}
+	bw := bufio.NewWriter(conn)
+	bw.WriteString("HTTP/1.1 101 Switching Protocols\r\n") //nolint: errcheck
+	header.Write(bw)                                       //nolint: errcheck
+	bw.WriteString("\r\n")                                 //nolint: errcheck
+	if err := bw.Flush(); err != nil {
+		conn.Close() //nolint: errcheck
+		return nil, err
+	}
+	return &WebSocket{
+		conn:        conn,
+		br:          bufio.NewReader(conn),
+		subprotocol: subprotocol,
+		readLimit:   cfg.ReadLimit,
+	}, nil
+}
+
+// sameOrigin allows the requests without Origin header, sent by non-browser
+// clients, and the ones from the host of the request.
+func sameOrigin(c *Context) bool {
+	origin := c.requestHeader("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, c.Host())
+}
+
+// validWebSocketKey reports whether key is the base64 encoding of 16 bytes.
+func validWebSocketKey(key string) bool {
+	b, err := base64.StdEncoding.DecodeString(key)
+	return err == nil && len(b) == 16
+}
+
+// websocketAccept returns the Sec-WebSocket-Accept value of a key.
+func websocketAccept(key string) string {
+	h := sha1.New() //nolint: gosec
+	h.Write([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// selectSubprotocol returns the first subprotocol requested by the client and
+// supported by the server.
+func selectSubprotocol(requested []string, supported []string) string {
+	for _, values := range requested {
+		for _, p := range strings.Split(values, ",") {
+			if p = strings.TrimSpace(p); slices.Contains(supported, p) {
+				return p
+			}
+		}
+	}
+	return ""
+}
+
+// Subprotocol returns the subprotocol selected by the handshake, or an empty
+// string.
+func (ws *WebSocket) Subprotocol() string {
+	return ws.subprotocol
+}
+
+// NetConn returns the underlying connection, e.g. to set deadlines.
+func (ws *WebSocket) NetConn() net.Conn {
+	return ws.conn
+}
+
+// ReadMessage reads the next text or binary message. It answers the pings and
+// skips the pongs read meanwhile. It returns a *WebSocketCloseError once the
+// peer closed the connection, after answering its close frame, or when the
+// connection was closed on a protocol error.
+func (ws *WebSocket) ReadMessage() (messageType int, data []byte, err error) {
+	for {
+		fin, opcode, payload, err := ws.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch opcode {
+		case PingMessage:
+			if err := ws.writeFrame(PongMessage, payload); err != nil && !errors.Is(err, ErrWebSocketClosed) {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			continue
+		case CloseMessage:
+			return 0, nil, ws.peerClosed(payload)
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				return 0, nil, ws.fail(CloseProtocolError, "message interrupted by a new one")
+			}
+			messageType = opcode
+		case continuationFrame:
+			if messageType == 0 {
+				return 0, nil, ws.fail(CloseProtocolError, "continuation of no message")
+			}
+		default:
+			return 0, nil, ws.fail(CloseProtocolError, "unknown opcode "+strconv.Itoa(opcode))
+		}
+		if int64(len(data))+int64(len(payload)) > ws.readLimit {
+			return 0, nil, ws.fail(CloseMessageTooBig, "message too big")
+		}
+		data = append(data, payload...)
+		if fin {
+			if messageType == TextMessage && !utf8.Valid(data) {
+				return 0, nil, ws.fail(CloseInvalidPayload, "invalid UTF-8 text")
+			}
+			return messageType, data, nil
+		}
+	}
+}
+
+// readFrame reads a frame, which the clients must mask.
+func (ws *WebSocket) readFrame() (fin bool, opcode int, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(ws.br, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin, opcode = head[0]&0x80 != 0, int(head[0]&0x0f)
+	if head[0]&0x70 != 0 {
+		return false, 0, nil, ws.fail(CloseProtocolError, "reserved bits set")
+	}
+	if head[1]&0x80 == 0 {
+		return false, 0, nil, ws.fail(CloseProtocolError, "unmasked client frame")
+	}
+	size := uint64(head[1] & 0x7f)
+	switch size {
+	case 126:
+		var b [2]byte
+		if _, err = io.ReadFull(ws.br, b[:]); err != nil {
+			return false, 0, nil, err
+		}
+		size = uint64(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		if _, err = io.ReadFull(ws.br, b[:]); err != nil {
+			return false, 0, nil, err
+		}
+		size = binary.BigEndian.Uint64(b[:])
+	}
+	if opcode >= CloseMessage && (size > maxControlPayload || !fin) {
+		return false, 0, nil, ws.fail(CloseProtocolError, "invalid control frame")
+	}
+	if size > uint64(ws.readLimit) {
+		return false, 0, nil, ws.fail(CloseMessageTooBig, "message too big")
+	}
+	var mask [4]byte
+	if _, err = io.ReadFull(ws.br, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload = make([]byte, size)
+	if _, err = io.ReadFull(ws.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, opcode, payload, nil
+}
+
+// peerClosed answers the close frame of the peer and closes the connection.
+func (ws *WebSocket) peerClosed(payload []byte) error {
+	closeErr := &WebSocketCloseError{Code: CloseNoStatusReceived}
+	if len(payload) >= 2 {
+		closeErr.Code = int(binary.BigEndian.Uint16(payload))
+		closeErr.Text = string(payload[2:])
+	}
+	ws.writeFrame(CloseMessage, payload[:min(len(payload), 2)]) //nolint: errcheck
+	ws.closeConn()
+	return closeErr
+}
+
+// fail closes the connection with the code, and returns the close error.
+func (ws *WebSocket) fail(code int, text string) error {
+	ws.CloseWithStatus(code, text) //nolint: errcheck
+	return &WebSocketCloseError{Code: code, Text: text}
+}
+
+// WriteMessage writes a message of the given type in a single frame.
+func (ws *WebSocket) WriteMessage(messageType int, data []byte) error {
+	if messageType >= CloseMessage && len(data) > maxControlPayload {
+		return fmt.Errorf("websocket: control message of %d bytes, more than %d", len(data), maxControlPayload)
+	}
+	return ws.writeFrame(messageType, data)
+}
+
+// ReadJSON reads the next message and decodes it as JSON into v.
+func (ws *WebSocket) ReadJSON(v any) error {
+	_, data, err := ws.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// WriteJSON writes v encoded as JSON in a text message.
+func (ws *WebSocket) WriteJSON(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return ws.WriteMessage(TextMessage, data)
+}
+
+// writeFrame writes an unmasked final frame, as the servers do.
+func (ws *WebSocket) writeFrame(opcode int, payload []byte) error {
+	ws.wmu.Lock()
+	defer ws.wmu.Unlock()
+	if ws.closed {
+		return ErrWebSocketClosed
+	}
+	frame := make([]byte, 0, len(payload)+10)
+	frame = append(frame, 0x80|byte(opcode))
+	switch n := len(payload); {
+	case n <= maxControlPayload:
+		frame = append(frame, byte(n))
+	case n <= 0xffff:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	frame = append(frame, payload...)
+	if _, err := ws.conn.Write(frame); err != nil {
+		return err
+	}
+	if opcode == CloseMessage {
+		ws.closed = true
+	}
+	return nil
+}
+
+// CloseWithStatus sends a close frame with the code and reason, then closes
+// the connection.
+func (ws *WebSocket) CloseWithStatus(code int, reason string) error {
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	payload = append(payload, reason[:min(len(reason), maxControlPayload-2)]...)
+	err := ws.writeFrame(CloseMessage, payload)
+	if cerr := ws.closeConn(); err == nil || errors.Is(err, ErrWebSocketClosed) {
+		err = cerr
+	}
+	return err
+}
+
+// Close closes the connection normally, see CloseWithStatus.
+func (ws *WebSocket) Close() error {
+	return ws.CloseWithStatus(CloseNormalClosure, "")
+}
+
+// closeConn closes the underlying connection once.
+func (ws *WebSocket) closeConn() error {
+	ws.wmu.Lock()
+	defer ws.wmu.Unlock()
+	ws.closed = true
+	if ws.connClosed {
+		return nil
+	}
+	ws.connClosed = true
+	return ws.conn.Close()
+}
@@ -0,0 +1,168 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wsTestClient is a minimal WebSocket client writing masked frames.
+type wsTestClient struct {
+	conn net.Conn
+	br   *bufio.Reader
+	resp *http.Response
+}
+
+func dialWebSocket(t *testing.T, srv *httptest.Server, header http.Header) *wsTestClient {
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	require.NoError(t, req.Write(conn))
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	require.NoError(t, err)
+	return &wsTestClient{conn: conn, br: br, resp: resp}
+}
+
+func (ws *wsTestClient) write(t *testing.T, fin bool, opcode byte, payload []byte) {
+	head := opcode
+	if fin {
+		head |= 0x80
+	}
+	frame := []byte{head, 0x80 | byte(len(payload))}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	_, err := ws.conn.Write(frame)
+	require.NoError(t, err)
+}
+
+func (ws *wsTestClient) read(t *testing.T) (opcode byte, payload []byte) {
+	var head [2]byte
+	_, err := io.ReadFull(ws.br, head[:])
+	require.NoError(t, err)
+	payload = make([]byte, head[1]&0x7f)
+	_, err = io.ReadFull(ws.br, payload)
+	require.NoError(t, err)
+	return head[0] & 0x0f, payload
+}
+
+func TestContextUpgrade(t *testing.T) {
+	router := New()
+	done := make(chan error, 1)
+	router.GET("/ws", func(c *Context) {
+		c.Header("Set-Cookie", "session=1")
+		ws, err := c.Upgrade(WebSocketConfig{Subprotocols: []string{"v2", "v1"}})
+		if err != nil {
+			done <- err
+			return
+		}
+		defer ws.Close()
+		ws.WriteMessage(TextMessage, []byte(ws.Subprotocol())) //nolint: errcheck
+		for {
+			typ, msg, err := ws.ReadMessage()
+			if err != nil {
+				done <- err
+				return
+			}
+			if err := ws.WriteMessage(typ, append([]byte("echo "), msg...)); err != nil {
+				done <- err
+				return
+			}
+		}
+	})
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	ws := dialWebSocket(t, srv, http.Header{"Sec-Websocket-Protocol": {"v3, v1", "v2"}})
+	assert.Equal(t, http.StatusSwitchingProtocols, ws.resp.StatusCode)
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", ws.resp.Header.Get("Sec-WebSocket-Accept"))
+	assert.Equal(t, "v1", ws.resp.Header.Get("Sec-WebSocket-Protocol"))
+	assert.Equal(t, "session=1", ws.resp.Header.Get("Set-Cookie"))
+	opcode, payload := ws.read(t)
+	assert.Equal(t, byte(TextMessage), opcode)
+	assert.Equal(t, "v1", string(payload))
+
+	// a fragmented message, with a ping in between
+	ws.write(t, false, TextMessage, []byte("hel"))
+	ws.write(t, true, PingMessage, []byte("p"))
+	ws.write(t, true, continuationFrame, []byte("lo"))
+	opcode, payload = ws.read(t)
+	assert.Equal(t, byte(PongMessage), opcode)
+	assert.Equal(t, "p", string(payload))
+	opcode, payload = ws.read(t)
+	assert.Equal(t, byte(TextMessage), opcode)
+	assert.Equal(t, "echo hello", string(payload))
+
+	ws.write(t, true, CloseMessage, binary.BigEndian.AppendUint16(nil, CloseGoingAway))
+	opcode, payload = ws.read(t)
+	assert.Equal(t, byte(CloseMessage), opcode)
+	assert.Equal(t, uint16(CloseGoingAway), binary.BigEndian.Uint16(payload))
+	err := <-done
+	var closeErr *WebSocketCloseError
+	require.True(t, errors.As(err, &closeErr))
+	assert.Equal(t, CloseGoingAway, closeErr.Code)
+
+	// invalid UTF-8 text fails the connection
+	ws = dialWebSocket(t, srv, nil)
+	ws.read(t)
+	ws.write(t, true, TextMessage, []byte{0xff})
+	opcode, payload = ws.read(t)
+	assert.Equal(t, byte(CloseMessage), opcode)
+	assert.Equal(t, uint16(CloseInvalidPayload), binary.BigEndian.Uint16(payload))
+	assert.EqualError(t, <-done, "websocket: close 1007: invalid UTF-8 text")
+}
+
+func TestContextUpgradeRefused(t *testing.T) {
+	router := New()
+	errs := make(chan error, 1)
+	router.GET("/ws", func(c *Context) {
+		ws, err := c.Upgrade()
+		if err == nil {
+			ws.Close()
+		}
+		errs <- err
+	})
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	ws := dialWebSocket(t, srv, http.Header{"Origin": {"https://evil.example"}})
+	assert.Equal(t, http.StatusForbidden, ws.resp.StatusCode)
+	assert.ErrorIs(t, <-errs, ErrOriginNotAllowed)
+
+	ws = dialWebSocket(t, srv, http.Header{"Origin": {"http://" + strings.TrimPrefix(srv.URL, "http://")}})
+	assert.Equal(t, http.StatusSwitchingProtocols, ws.resp.StatusCode)
+	assert.NoError(t, <-errs)
+
+	ws = dialWebSocket(t, srv, http.Header{"Sec-Websocket-Version": {"8"}})
+	assert.Equal(t, http.StatusUpgradeRequired, ws.resp.StatusCode)
+	assert.Equal(t, "13", ws.resp.Header.Get("Sec-WebSocket-Version"))
+	assert.ErrorIs(t, <-errs, ErrBadHandshake)
+
+	w := PerformRequest(router, http.MethodGet, "/ws")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.ErrorIs(t, <-errs, ErrBadHandshake)
+}
@@ -0,0 +1,254 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/jialequ/mpgw/internal/json"
+)
+
+// WideEventConfig defines the wide events emitted by Engine.WideEvents.
+type WideEventConfig struct {
+	// Output is the sink the events are written to, as one JSON object per
+	// line. Optional. Default value is gin.DefaultWriter.
+	Output io.Writer
+
+	// Emit is called with each event instead of writing it to Output, in the
+	// goroutine of the request. Optional.
+	Emit func(event *WideEvent)
+
+	// Skip indicates which requests emit no event. Optional.
+	Skip Skipper
+}
+
+// WideEvent is the canonical record of a request: one event per request with
+// everything known about it, including the fields added with
+// Context.LogFields and the time spent in each phase of its handling.
+type WideEvent struct {
+	Time   time.Time `json:"time"`
+	Method string    `json:"method"`
+	Host   string    `json:"host"`
+	// Path is the path of the request, with the values registered in
+	// Redactions redacted.
+	Path string `json:"path"`
+	// Route is the path of the matched route, empty if none matched.
+	Route    string `json:"route,omitempty"`
+	Status   int    `json:"status"`
+	BodySize int    `json:"bodySize"`
+	ClientIP string `json:"clientIP"`
+	// Duration is the time spent handling the request, in nanoseconds in JSON.
+	Duration time.Duration   `json:"duration"`
+	Phases   WideEventPhases `json:"phases"`
+	// Fields are the fields added with Context.LogFields.
+	Fields map[string]any `json:"fields,omitempty"`
+	// Errors are the errors attached to the context, with the secrets
+	// registered in Redactions redacted.
+	Errors []string `json:"errors,omitempty"`
+	// Timings are the timestamps the phases are computed from, for the
+	// integrations exporting the events with Emit, see Context.Timings.
+	Timings RequestTimings `json:"-"`
+}
+
+// WideEventPhases is the time spent in each phase of the handling of a
+// request, in nanoseconds in JSON.
+type WideEventPhases struct {
+	// Match is the time spent before the handlers chain ran, finding the route.
+	Match time.Duration `json:"match"`
+	// Middleware is the time spent in the handlers of the chain but the last
+	// one, rendering excluded.
+	Middleware time.Duration `json:"middleware"`
+	// Handler is the time spent in the last handler of the chain, rendering
+	// excluded.
+	Handler time.Duration `json:"handler"`
+	// Render is the time spent rendering the responses with Context.Render.
+	Render time.Duration `json:"render"`
+}
+
+// wideEvents emits the wide events of an engine.
+type wideEvents struct {
+	conf WideEventConfig
+	mu   sync.Mutex
+}
+
+// RequestTimings are the timestamps of the phases of a request, on the
+// engine clock, zero for the phases not reached yet.
+type RequestTimings struct {
+	// Start is when the request was received.
+	Start time.Time
+	// Matched is when the route was found and the handlers chain started.
+	Matched time.Time
+	// Handlers are the entries into and exits from the handlers of the chain,
+	// in the order they were entered: the exits of the middlewares include
+	// the handlers they ran with Next.
+	Handlers []HandlerTiming
+	// HandlerStart and HandlerEnd are when the last handler of the chain
+	// started and returned.
+	HandlerStart, HandlerEnd time.Time
+	// FirstByte is when the header of the response was written.
+	FirstByte time.Time
+	// End is when the request was served.
+	End time.Time
+}
+
+// HandlerTiming is the entry into and exit from a handler of the chain.
+type HandlerTiming struct {
+	// Name is the name of the handler function, see Context.HandlerNames.
+	Name        string
+	Enter, Exit time.Time
+}
+
+// requestPhases times the phases of a request.
+type requestPhases struct {
+	start, chained time.Time
+	handlers       []handlerTiming
+	// handlerStart and handlerEnd are when the last handler ran, and
+	// handler the time spent in it, rendering included.
+	handlerStart, handlerEnd time.Time
+	firstByte, end           time.Time
+	// render is the time spent rendering, handlerRender in the last handler.
+	handler, render, handlerRender time.Duration
+	inHandler                      bool
+}
+
+type handlerTiming struct {
+	handler     HandlerFunc
+	enter, exit time.Time
+}
+
+// Timings returns the timestamps of the phases of the request up to now,
+// recorded when Engine.TimeRequests or Engine.WideEvents is on, or zero
+// timings otherwise. The APM integrations can read them in a middleware once
+// Next returns, or from the wide events, which are computed from them.
+func (c *Context) Timings() RequestTimings {
+	if !c.timing {
+		return RequestTimings{}
+	}
+	p := &c.phases
+	t := RequestTimings{
+		Start:        p.start,
+		Matched:      p.chained,
+		HandlerStart: p.handlerStart,
+		HandlerEnd:   p.handlerEnd,
+		FirstByte:    p.firstByte,
+		End:          p.end,
+	}
+	if len(p.handlers) > 0 {
+		t.Handlers = make([]HandlerTiming, len(p.handlers))
+		for i, h := range p.handlers {
+			t.Handlers[i] = HandlerTiming{Name: nameOfFunction(h.handler), Enter: h.enter, Exit: h.exit}
+		}
+	}
+	return t
+}
+
+// WideEvents emits a wide event per request, written to conf.Output as JSON
+// or passed to conf.Emit, after the request is served:
+//
+//	router.WideEvents(&gin.WideEventConfig{Output: sink})
+//
+// The event carries the phase timings measured by the engine, on its Clock,
+// and the fields added with Context.LogFields. A nil config stops the events.
+func (engine *Engine) WideEvents(conf *WideEventConfig) {
+	if conf == nil {
+		engine.wideEvents.Store(nil)
+		return
+	}
+	w := &wideEvents{conf: *conf}
+	if w.conf.Output == nil {
+		w.conf.Output = DefaultWriter
+	}
+	engine.wideEvents.Store(w)
+}
+
+// runTimed runs the current handler of the chain, timing it.
+func (c *Context) runTimed() {
+	p := &c.phases
+	start := c.now()
+	if p.chained.IsZero() {
+		p.chained = start
+	}
+	i := len(p.handlers)
+	p.handlers = append(p.handlers, handlerTiming{handler: c.handlers[c.index], enter: start})
+	last := c.index == int8(len(c.handlers))-1
+	if last {
+		p.handlerStart = start
+		p.inHandler = true
+	}
+	c.runHandler()
+	end := c.now()
+	p.handlers[i].exit = end
+	if last {
+		p.handlerEnd = end
+		p.handler += end.Sub(start)
+		p.inHandler = false
+	}
+}
+
+// timeRender records the time spent rendering a response since start.
+func (c *Context) timeRender(start time.Time) {
+	d := c.now().Sub(start)
+	c.phases.render += d
+	if c.phases.inHandler {
+		c.phases.handlerRender += d
+	}
+}
+
+// emit emits the wide event of a served request.
+func (w *wideEvents) emit(c *Context) {
+	if w.conf.Skip != nil && w.conf.Skip(c) {
+		return
+	}
+	p := &c.phases
+	end := p.end
+	event := &WideEvent{
+		Time:     p.start,
+		Method:   c.Request.Method,
+		Host:     c.Request.Host,
+		Path:     Redactions.Redact(c.Request.URL.Path),
+		Route:    c.fullPath,
+		Status:   c.Writer.Status(),
+		BodySize: max(c.Writer.Size(), 0),
+		ClientIP: c.ClientIP(),
+		Duration: end.Sub(p.start),
+	}
+	if p.chained.IsZero() {
+		event.Phases.Match = event.Duration
+	} else {
+		event.Phases = WideEventPhases{
+			Match:      p.chained.Sub(p.start),
+			Middleware: end.Sub(p.chained) - p.handler - (p.render - p.handlerRender),
+			Handler:    p.handler - p.handlerRender,
+			Render:     p.render,
+		}
+	}
+	if fields := c.LoggedFields(); len(fields) > 0 {
+		event.Fields = make(map[string]any, len(fields))
+		for _, f := range fields {
+			event.Fields[f.Key] = f.Value.Resolve().Any()
+		}
+	}
+	for _, err := range c.Errors {
+		event.Errors = append(event.Errors, Redactions.Redact(err.Error()))
+	}
+
+	if w.conf.Emit != nil {
+		event.Timings = c.Timings()
+		w.conf.Emit(event)
+		return
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		debugPrint("cannot marshal wide event: %v", err)
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err = w.conf.Output.Write(append(data, '\n')); err != nil {
+		debugPrint("cannot write wide event: %v", err)
+	}
+}
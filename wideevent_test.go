@@ -0,0 +1,154 @@
+// Copyright 2024 Gin Core Team. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package gin
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jialequ/mpgw/internal/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngineWideEvents(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	router := New()
+	router.Clock = clock
+	var sink bytes.Buffer
+	router.WideEvents(&WideEventConfig{
+		Output: &sink,
+		Skip:   func(c *Context) bool { return c.Request.URL.Path == "/health" },
+	})
+
+	router.Use(func(c *Context) {
+		clock.Advance(time.Millisecond)
+		c.LogFields("tenant", "acme")
+		c.Next()
+		clock.Advance(2 * time.Millisecond)
+	})
+	router.GET("/users/:id", func(c *Context) {
+		clock.Advance(10 * time.Millisecond)
+		c.LogFields("user", c.Param("id"), "admin", true)
+		_ = c.Error(errors.New("cache miss"))
+		c.Render(http.StatusOK, renderFunc(func(w http.ResponseWriter) error {
+			clock.Advance(5 * time.Millisecond)
+			_, err := w.Write([]byte("ok"))
+			return err
+		}))
+	})
+	router.GET("/health", func(c *Context) { c.Status(http.StatusNoContent) })
+
+	PerformRequest(router, http.MethodGet, "/users/42")
+	PerformRequest(router, http.MethodGet, "/health")
+	PerformRequest(router, http.MethodGet, "/missing")
+
+	lines := strings.Split(strings.TrimSpace(sink.String()), "\n")
+	require.Len(t, lines, 2)
+	var event WideEvent
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &event))
+	assert.Equal(t, start, event.Time.UTC())
+	assert.Equal(t, http.MethodGet, event.Method)
+	assert.Equal(t, "/users/42", event.Path)
+	assert.Equal(t, "/users/:id", event.Route)
+	assert.Equal(t, http.StatusOK, event.Status)
+	assert.Equal(t, 2, event.BodySize)
+	assert.Equal(t, 18*time.Millisecond, event.Duration)
+	assert.Equal(t, WideEventPhases{
+		Middleware: 3 * time.Millisecond,
+		Handler:    10 * time.Millisecond,
+		Render:     5 * time.Millisecond,
+	}, event.Phases)
+	assert.Equal(t, map[string]any{"tenant": "acme", "user": "42", "admin": true}, event.Fields)
+	assert.Equal(t, []string{"cache miss"}, event.Errors)
+
+	var missing WideEvent
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &missing))
+	assert.Equal(t, http.StatusNotFound, missing.Status)
+	assert.Empty(t, missing.Route)
+
+	var emitted []*WideEvent
+	router.WideEvents(&WideEventConfig{Emit: func(e *WideEvent) { emitted = append(emitted, e) }})
+	assert.Equal(t, router.wideEvents.Load(), router.Clone().wideEvents.Load())
+	PerformRequest(router, http.MethodGet, "/health")
+	require.Len(t, emitted, 1)
+	assert.Equal(t, http.StatusNoContent, emitted[0].Status)
+
+	router.WideEvents(nil)
+	PerformRequest(router, http.MethodGet, "/health")
+	assert.Len(t, emitted, 1)
+}
+
+// renderFunc is a render.Render calling the function to write the body.
+type renderFunc func(w http.ResponseWriter) error
+
+func (f renderFunc) Render(w http.ResponseWriter) error { return f(w) }
+
+func (renderFunc) WriteContentType(http.ResponseWriter) {}
+
+func timingMiddleware(c *Context) {
+	c.Next()
+}
+
+func timingHandler(c *Context) {
+	c.String(http.StatusOK, "ok")
+}
+
+func TestContextTimings(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	router := New()
+	router.Clock = clock
+	router.TimeRequests = true
+	var inner, outer RequestTimings
+	router.Use(func(c *Context) {
+		clock.Advance(time.Millisecond)
+		c.Next()
+		outer = c.Timings()
+	}, timingMiddleware)
+	router.GET("/", func(c *Context) {
+		clock.Advance(10 * time.Millisecond)
+		timingHandler(c)
+		clock.Advance(5 * time.Millisecond)
+		inner = c.Timings()
+	})
+
+	PerformRequest(router, http.MethodGet, "/")
+	ms := func(n int) time.Time { return start.Add(time.Duration(n) * time.Millisecond) }
+	assert.Equal(t, start, outer.Start)
+	assert.Equal(t, start, outer.Matched)
+	require.Len(t, outer.Handlers, 3)
+	assert.Equal(t, "github.com/jialequ/mpgw.timingMiddleware", outer.Handlers[1].Name)
+	assert.Equal(t, []time.Time{start, ms(1), ms(1)}, []time.Time{outer.Handlers[0].Enter, outer.Handlers[1].Enter, outer.Handlers[2].Enter})
+	assert.Equal(t, ms(16), outer.Handlers[1].Exit)
+	assert.Equal(t, ms(16), outer.Handlers[2].Exit)
+	assert.True(t, outer.Handlers[0].Exit.IsZero())
+	assert.Equal(t, ms(1), outer.HandlerStart)
+	assert.Equal(t, ms(16), outer.HandlerEnd)
+	assert.Equal(t, ms(11), outer.FirstByte)
+	assert.True(t, outer.End.IsZero())
+	assert.True(t, inner.HandlerEnd.IsZero())
+	assert.Equal(t, ms(11), inner.FirstByte)
+
+	// the wide events share them
+	var emitted *WideEvent
+	router.WideEvents(&WideEventConfig{Emit: func(e *WideEvent) { emitted = e }})
+	PerformRequest(router, http.MethodGet, "/")
+	require.NotNil(t, emitted)
+	assert.Equal(t, emitted.Time, emitted.Timings.Start)
+	assert.Equal(t, emitted.Duration, emitted.Timings.End.Sub(emitted.Timings.Start))
+	assert.Equal(t, emitted.Phases.Handler, emitted.Timings.HandlerEnd.Sub(emitted.Timings.HandlerStart))
+	assert.Equal(t, ms(32), emitted.Timings.End)
+
+	router.WideEvents(nil)
+	router.TimeRequests = false
+	PerformRequest(router, http.MethodGet, "/")
+	assert.Equal(t, RequestTimings{}, outer)
+}